@@ -0,0 +1,92 @@
+// Package audit records the gosling commands that change deployments or the
+// Nest.
+//
+// Entries are appended as JSON lines to .gosling/audit.jsonl in the Nest, so
+// the log of a CI runner or a laptop shows who ran what, at which commit, and
+// how it ended. The file is only ever appended to.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Outcomes of an audited command
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Entry is one audited run of a command
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Command    string    `json:"command"` // e.g. "gosling rollback"
+	Args       []string  `json:"args"`    // Positional arguments and flags set, secrets redacted
+	User       string    `json:"user"`
+	GitSHA     string    `json:"git_sha,omitempty"` // Nest commit, absent outside a git repository
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// Path returns the location of the audit log of a Nest
+func Path(nestRoot string) string {
+	return filepath.Join(nestRoot, ".gosling", "audit.jsonl")
+}
+
+// Append writes an entry at the end of the audit log at path, creating the
+// log if needed
+func Append(path string, entry *Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	// One write per entry so concurrent gosling processes do not interleave lines
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// Read returns the entries of the audit log at path, oldest first. A missing
+// log has no entries.
+func Read(path string) ([]*Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []*Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid audit entry: %w", path, line, err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	nest := t.TempDir()
+	path := Path(nest)
+
+	entries, err := Read(path)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected no entries before the first append, got %v, %v", entries, err)
+	}
+
+	first := &Entry{Time: time.Now().UTC(), Command: "gosling deploy", Args: []string{"--egg=my-app"}, User: "alice", Outcome: OutcomeSuccess}
+	second := &Entry{Time: time.Now().UTC(), Command: "gosling rollback", User: "bob", Outcome: OutcomeFailure, Error: "no previous plan"}
+	for _, entry := range []*Entry{first, second} {
+		if err := Append(path, entry); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, err = Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Command != "gosling deploy" || entries[1].Error != "no previous plan" {
+		t.Errorf("expected both entries in order, got %+v", entries)
+	}
+	if filepath.Dir(path) != filepath.Join(nest, ".gosling") {
+		t.Errorf("unexpected audit log location %s", path)
+	}
+}
+
+func TestReadInvalidEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.WriteFile(path, []byte("{\"command\":\"gosling deploy\"}\nnot json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil || !strings.Contains(err.Error(), ":2: invalid audit entry") {
+		t.Errorf("expected the invalid line to be reported, got %v", err)
+	}
+}
@@ -1,26 +1,39 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/polar-gosling/gosling/internal/parser"
 	"github.com/spf13/cobra"
 )
 
 var (
-	eggType     string
-	eggProvider string
-	eggRegion   string
-	jobSchedule string
-	interactive bool
+	eggType         string
+	eggProvider     string
+	eggRegion       string
+	bucketRepos     []string
+	jobSchedule     string
+	interactive     bool
+	eggTemplateName string
+	eggTemplateVars []string
+
+	ufFailedThreshold int
+	ufMaxAge          string
+	ufCheckInterval   string
+	ufEggs            []string
 )
 
 // addCmd represents the add command
 var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add configurations to the Nest repository",
-	Long:  `Add Egg configurations or Job definitions to the Nest repository.`,
+	Long:  `Add Egg, EggsBucket, Job or UglyFox configurations to the Nest repository.`,
 }
 
 // addEggCmd represents the add egg command
@@ -32,13 +45,58 @@ var addEggCmd = &cobra.Command{
 An Egg represents a single managed repository with its runner configuration.
 The configuration file will be created at Eggs/<name>/config.fly
 
+With --template, the file is generated from a template instead: a built-in
+one (docker-vm, serverless-lambda, gpu-runner) or Templates/<template>.fly.tmpl
+in the Nest, a Go text/template reading the Egg name as {{ .name }} and each
+--set variable as {{ .<variable> }}.
+
 Example:
   gosling add egg my-app --type vm --provider yandex
-  gosling add egg api-service --type serverless --provider aws`,
+  gosling add egg api-service --type serverless --provider aws
+  gosling add egg web-builds --type vm --provider azure --region westeurope
+  gosling add egg my-app -i   # prompt for each setting and preview the file
+  gosling add egg my-app --mr # commit on a new branch, push and open a merge request
+  gosling add egg ml-train --template gpu-runner --set gpu_count=2 --set project_id=42`,
 	Args: cobra.ExactArgs(1),
 	RunE: runAddEgg,
 }
 
+// addEggsBucketCmd represents the add eggsbucket command
+var addEggsBucketCmd = &cobra.Command{
+	Use:   "eggsbucket <name>",
+	Short: "Add a new EggsBucket configuration",
+	Long: `Add a new EggsBucket configuration for several repositories.
+
+An EggsBucket serves multiple repositories with one shared runner
+configuration. Each repository gets its own repo block with a gitlab section.
+The configuration file will be created at Eggs/<name>/config.fly
+
+Example:
+  gosling add eggsbucket platform --repos auth-service,api-gateway --provider yandex
+  gosling add eggsbucket web --repos frontend,backend --type serverless --provider aws`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAddEggsBucket,
+}
+
+// addUglyFoxCmd represents the add uglyfox command
+var addUglyFoxCmd = &cobra.Command{
+	Use:   "uglyfox",
+	Short: "Add the UglyFox configuration",
+	Long: `Add the UglyFox runner lifecycle configuration.
+
+The configuration file will be created at UF/config.fly with a commented
+template covering pruning, a runners_condition with apex/nadir pools, and
+lifecycle policies. Without --eggs, the condition applies to every Egg
+currently in the Nest.
+
+Example:
+  gosling add uglyfox
+  gosling add uglyfox --failed-threshold 5 --max-age 12h --eggs my-app,api-service
+  gosling add uglyfox -i`,
+	Args: cobra.NoArgs,
+	RunE: runAddUglyFox,
+}
+
 // addJobCmd represents the add job command
 var addJobCmd = &cobra.Command{
 	Use:   "job <name>",
@@ -50,7 +108,8 @@ The job file will be created at Jobs/<name>.fly
 
 Example:
   gosling add job rotate-secrets --schedule "0 2 * * *"
-  gosling add job update-runners`,
+  gosling add job update-runners
+  gosling add job cleanup -i   # prompt for the schedule and preview the file`,
 	Args: cobra.ExactArgs(1),
 	RunE: runAddJob,
 }
@@ -58,17 +117,37 @@ Example:
 func init() {
 	rootCmd.AddCommand(addCmd)
 	addCmd.AddCommand(addEggCmd)
+	addCmd.AddCommand(addEggsBucketCmd)
 	addCmd.AddCommand(addJobCmd)
+	addCmd.AddCommand(addUglyFoxCmd)
 
 	// Egg flags
 	addEggCmd.Flags().StringVarP(&eggType, "type", "t", "vm", "Runner type: vm or serverless")
-	addEggCmd.Flags().StringVarP(&eggProvider, "provider", "p", "yandex", "Cloud provider: yandex or aws")
+	addEggCmd.Flags().StringVarP(&eggProvider, "provider", "p", "yandex", "Cloud provider: yandex, aws, or azure")
 	addEggCmd.Flags().StringVarP(&eggRegion, "region", "r", "", "Cloud region (e.g., ru-central1-a, us-east-1)")
 	addEggCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive mode")
+	addEggCmd.Flags().StringVar(&eggTemplateName, "template", "", "Generate the configuration from a built-in or Templates/ template")
+	addEggCmd.Flags().StringArrayVar(&eggTemplateVars, "set", nil, "Template variable as <variable>=<value> (repeatable)")
+	addPublishFlags(addEggCmd)
+
+	// EggsBucket flags
+	addEggsBucketCmd.Flags().StringSliceVar(&bucketRepos, "repos", nil, "Repository names served by the bucket (comma-separated)")
+	addEggsBucketCmd.Flags().StringVarP(&eggType, "type", "t", "vm", "Runner type: vm or serverless")
+	addEggsBucketCmd.Flags().StringVarP(&eggProvider, "provider", "p", "yandex", "Cloud provider: yandex, aws, or azure")
+	addEggsBucketCmd.Flags().StringVarP(&eggRegion, "region", "r", "", "Cloud region (e.g., ru-central1-a, us-east-1)")
+	mustMarkRequired(addEggsBucketCmd, "repos")
+	addPublishFlags(addEggsBucketCmd)
 
 	// Job flags
 	addJobCmd.Flags().StringVarP(&jobSchedule, "schedule", "s", "", "Cron schedule expression")
 	addJobCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive mode")
+
+	// UglyFox flags
+	addUglyFoxCmd.Flags().IntVar(&ufFailedThreshold, "failed-threshold", 3, "Failures before a runner is pruned")
+	addUglyFoxCmd.Flags().StringVar(&ufMaxAge, "max-age", "24h", "Maximum runner age")
+	addUglyFoxCmd.Flags().StringVar(&ufCheckInterval, "check-interval", "5m", "How often runners are checked")
+	addUglyFoxCmd.Flags().StringSliceVar(&ufEggs, "eggs", nil, "Egg names the runner pools apply to (default: all Eggs in the Nest)")
+	addUglyFoxCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive mode")
 }
 
 func runAddEgg(cmd *cobra.Command, args []string) error {
@@ -79,55 +158,394 @@ func runAddEgg(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid egg name: must contain only alphanumeric characters, hyphens, and underscores")
 	}
 
-	// Validate type
-	if eggType != "vm" && eggType != "serverless" {
-		return fmt.Errorf("invalid type: must be 'vm' or 'serverless'")
+	// Find Nest root
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("not in a Nest repository: %w\nRun 'gosling init' to create a new Nest repository", err)
 	}
 
-	// Validate provider
-	if eggProvider != "yandex" && eggProvider != "aws" {
-		return fmt.Errorf("invalid provider: must be 'yandex' or 'aws'")
+	eggDir := filepath.Join(nestRoot, "Eggs", eggName)
+	configPath := filepath.Join(eggDir, "config.fly")
+	if _, err := os.Stat(configPath); err == nil {
+		return fmt.Errorf("Egg configuration already exists at %s", configPath)
 	}
 
-	// Set default region if not provided
-	if eggRegion == "" {
-		if eggProvider == "yandex" {
-			eggRegion = "ru-central1-a"
-		} else {
-			eggRegion = "us-east-1"
+	var configContent string
+	if eggTemplateName != "" {
+		for _, flag := range []string{"interactive", "type", "provider", "region"} {
+			if cmd.Flags().Changed(flag) {
+				return fmt.Errorf("--%s cannot be combined with --template; set template variables with --set", flag)
+			}
+		}
+		vars, err := parseTemplateVars(eggTemplateVars)
+		if err != nil {
+			return err
+		}
+		tmpl, err := findEggTemplate(nestRoot, eggTemplateName)
+		if err != nil {
+			return err
+		}
+		if configContent, err = renderEggTemplate(tmpl, eggName, configPath, vars); err != nil {
+			return err
+		}
+	} else if len(eggTemplateVars) > 0 {
+		return fmt.Errorf("--set requires --template")
+	} else if interactive {
+		p := newPrompter(cmd.InOrStdin(), cmd.OutOrStdout())
+		opts, err := promptEggOptions(p, eggName, eggType, eggProvider, eggRegion)
+		if err != nil {
+			return err
+		}
+		configContent = renderEggConfig(opts)
+		if ok, err := confirmWrite(p, cmd.OutOrStdout(), configPath, configContent); err != nil || !ok {
+			return err
 		}
+	} else {
+		region, err := resolveRunnerOptions(eggType, eggProvider, eggRegion)
+		if err != nil {
+			return err
+		}
+		configContent = generateEggConfig(eggName, eggType, eggProvider, region)
 	}
 
-	// Find Nest root
-	nestRoot, err := findNestRoot()
+	publisher, err := preparePublish(nestRoot, "gosling/add-egg-"+eggName)
 	if err != nil {
-		return fmt.Errorf("not in a Nest repository: %w\nRun 'gosling init' to create a new Nest repository", err)
+		return err
 	}
 
-	// Create Egg directory
-	eggDir := filepath.Join(nestRoot, "Eggs", eggName)
+	// Create Egg directory and config.fly
 	if err := os.MkdirAll(eggDir, 0755); err != nil {
 		return fmt.Errorf("failed to create Egg directory: %w", err)
 	}
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		return fmt.Errorf("failed to create config.fly: %w", err)
+	}
+
+	fmt.Printf("✅ Created Egg configuration: %s\n", configPath)
+	fmt.Println("\nNext steps:")
+	fmt.Println("  1. Edit the configuration file to customize settings")
+	fmt.Println("  2. Add GitLab project ID and token secret")
+	fmt.Println("  3. Validate: gosling validate")
+	fmt.Println("  4. Deploy: gosling deploy")
+
+	if publisher != nil {
+		fmt.Println()
+		return publisher.publish(context.Background(), "feat(eggs): add egg "+eggName,
+			fmt.Sprintf("Adds the Egg configuration `Eggs/%s/config.fly`, created with `gosling add egg`.", eggName),
+			configPath)
+	}
+	return nil
+}
+
+func runAddEggsBucket(cmd *cobra.Command, args []string) error {
+	bucketName := args[0]
+
+	// Validate bucket name
+	if !isValidName(bucketName) {
+		return fmt.Errorf("invalid eggsbucket name: must contain only alphanumeric characters, hyphens, and underscores")
+	}
+
+	// Validate repositories
+	if len(bucketRepos) == 0 {
+		return fmt.Errorf("at least one repository must be given with --repos")
+	}
+	seen := make(map[string]bool, len(bucketRepos))
+	for _, repo := range bucketRepos {
+		if !isValidName(repo) {
+			return fmt.Errorf("invalid repository name %q: must contain only alphanumeric characters, hyphens, and underscores", repo)
+		}
+		if seen[repo] {
+			return fmt.Errorf("duplicate repository name %q", repo)
+		}
+		seen[repo] = true
+	}
+
+	region, err := resolveRunnerOptions(eggType, eggProvider, eggRegion)
+	if err != nil {
+		return err
+	}
+
+	// Find Nest root
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("not in a Nest repository: %w\nRun 'gosling init' to create a new Nest repository", err)
+	}
 
 	// Create config.fly
-	configPath := filepath.Join(eggDir, "config.fly")
+	configPath := filepath.Join(nestRoot, "Eggs", bucketName, "config.fly")
 	if _, err := os.Stat(configPath); err == nil {
-		return fmt.Errorf("Egg configuration already exists at %s", configPath)
+		return fmt.Errorf("configuration already exists at %s", configPath)
+	}
+	publisher, err := preparePublish(nestRoot, "gosling/add-eggsbucket-"+bucketName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create EggsBucket directory: %w", err)
 	}
 
-	configContent := generateEggConfig(eggName, eggType, eggProvider, eggRegion)
+	configContent := generateEggsBucketConfig(bucketName, eggType, eggProvider, region, bucketRepos)
 	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
 		return fmt.Errorf("failed to create config.fly: %w", err)
 	}
 
-	fmt.Printf("✅ Created Egg configuration: %s\n", configPath)
+	fmt.Printf("✅ Created EggsBucket configuration: %s\n", configPath)
 	fmt.Println("\nNext steps:")
-	fmt.Println("  1. Edit the configuration file to customize settings")
-	fmt.Println("  2. Add GitLab project ID and token secret")
+	fmt.Println("  1. Set the GitLab project ID of each repository")
+	fmt.Println("  2. Store each repository's runner token in the secret manager")
 	fmt.Println("  3. Validate: gosling validate")
 	fmt.Println("  4. Deploy: gosling deploy")
 
+	if publisher != nil {
+		fmt.Println()
+		return publisher.publish(context.Background(), "feat(eggs): add eggsbucket "+bucketName,
+			fmt.Sprintf("Adds the EggsBucket configuration `Eggs/%s/config.fly`, created with `gosling add eggsbucket`.", bucketName),
+			configPath)
+	}
+	return nil
+}
+
+// resolveRunnerOptions validates the --type, --provider and --region flags
+// shared by egg and eggsbucket and returns the region, defaulted per provider
+func resolveRunnerOptions(runnerType, provider, region string) (string, error) {
+	// Validate type
+	if runnerType != "vm" && runnerType != "serverless" {
+		return "", fmt.Errorf("invalid type: must be 'vm' or 'serverless'")
+	}
+
+	// Validate provider
+	if provider != "yandex" && provider != "aws" && provider != "azure" {
+		return "", fmt.Errorf("invalid provider: must be 'yandex', 'aws', or 'azure'")
+	}
+
+	// Set default region if not provided
+	if region == "" {
+		region = defaultRegion(provider)
+	}
+	if !parser.IsValidRegion(provider, region) {
+		return "", fmt.Errorf("invalid region %q for %s: must be one of %s", region, provider, strings.Join(parser.Regions(provider), ", "))
+	}
+
+	return region, nil
+}
+
+// defaultRegion returns the region used when a provider's region is not given
+func defaultRegion(provider string) string {
+	switch provider {
+	case "yandex":
+		return "ru-central1-a"
+	case "azure":
+		return "eastus"
+	default:
+		return "us-east-1"
+	}
+}
+
+// uglyFoxOptions holds the tunable values of the UglyFox template
+type uglyFoxOptions struct {
+	FailedThreshold  int
+	MaxAge           string
+	CheckInterval    string
+	Eggs             []string
+	ApexMax          int
+	ApexMin          int
+	NadirMax         int
+	NadirMin         int
+	NadirIdleTimeout string
+}
+
+func runAddUglyFox(cmd *cobra.Command, args []string) error {
+	// Find Nest root
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("not in a Nest repository: %w\nRun 'gosling init' to create a new Nest repository", err)
+	}
+
+	configPath := filepath.Join(nestRoot, "UF", "config.fly")
+	if _, err := os.Stat(configPath); err == nil {
+		return fmt.Errorf("UglyFox configuration already exists at %s", configPath)
+	}
+
+	opts := uglyFoxOptions{
+		FailedThreshold:  ufFailedThreshold,
+		MaxAge:           ufMaxAge,
+		CheckInterval:    ufCheckInterval,
+		Eggs:             ufEggs,
+		ApexMax:          10,
+		ApexMin:          2,
+		NadirMax:         5,
+		NadirMin:         0,
+		NadirIdleTimeout: "30m",
+	}
+	if len(opts.Eggs) == 0 {
+		opts.Eggs, err = listNestEggs(nestRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	var p *prompter
+	if interactive {
+		p = newPrompter(cmd.InOrStdin(), cmd.OutOrStdout())
+		if err := promptUglyFoxOptions(p, &opts); err != nil {
+			return err
+		}
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	configContent := generateUglyFoxConfig(opts)
+	if interactive {
+		if ok, err := confirmWrite(p, cmd.OutOrStdout(), configPath, configContent); err != nil || !ok {
+			return err
+		}
+	}
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		return fmt.Errorf("failed to create config.fly: %w", err)
+	}
+
+	fmt.Printf("✅ Created UglyFox configuration: %s\n", configPath)
+	fmt.Println("\nNext steps:")
+	fmt.Println("  1. Edit the configuration file to tune pools and policies")
+	fmt.Println("  2. Validate: gosling validate")
+	fmt.Println("  3. Deploy: gosling deploy")
+
+	return nil
+}
+
+// promptUglyFoxOptions asks for the pruning thresholds and pool sizes
+func promptUglyFoxOptions(p *prompter, opts *uglyFoxOptions) error {
+	var err error
+	if opts.FailedThreshold, err = p.askInt("Failures before a runner is pruned", opts.FailedThreshold, 1, 100); err != nil {
+		return err
+	}
+	if opts.MaxAge, err = p.ask("Maximum runner age", opts.MaxAge, validateDuration); err != nil {
+		return err
+	}
+	if opts.CheckInterval, err = p.ask("Check interval", opts.CheckInterval, validateDuration); err != nil {
+		return err
+	}
+	if opts.ApexMax, err = p.askInt("Maximum active (apex) runners", opts.ApexMax, 0, 1000); err != nil {
+		return err
+	}
+	if opts.ApexMin, err = p.askInt("Minimum active (apex) runners", opts.ApexMin, 0, opts.ApexMax); err != nil {
+		return err
+	}
+	if opts.NadirMax, err = p.askInt("Maximum idle (nadir) runners", opts.NadirMax, 0, 1000); err != nil {
+		return err
+	}
+	if opts.NadirMin, err = p.askInt("Minimum idle (nadir) runners", opts.NadirMin, 0, opts.NadirMax); err != nil {
+		return err
+	}
+	if opts.NadirIdleTimeout, err = p.ask("Idle time before a runner is demoted", opts.NadirIdleTimeout, validateDuration); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o uglyFoxOptions) validate() error {
+	if o.FailedThreshold < 1 || o.FailedThreshold > 100 {
+		return fmt.Errorf("invalid failed threshold %d: must be between 1 and 100", o.FailedThreshold)
+	}
+	durations := []struct{ name, value string }{
+		{"max age", o.MaxAge},
+		{"check interval", o.CheckInterval},
+		{"idle timeout", o.NadirIdleTimeout},
+	}
+	for _, d := range durations {
+		if err := validateDuration(d.value); err != nil {
+			return fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+	}
+	for _, egg := range o.Eggs {
+		if !isValidName(egg) {
+			return fmt.Errorf("invalid egg name %q: must contain only alphanumeric characters, hyphens, and underscores", egg)
+		}
+	}
+	return nil
+}
+
+// validateDuration checks that s is a Go duration such as "30m" or "24h"
+func validateDuration(s string) error {
+	if _, err := time.ParseDuration(s); err != nil {
+		return fmt.Errorf("%q is not a duration (e.g. 30m, 24h)", s)
+	}
+	return nil
+}
+
+// listNestEggs returns the names of the Egg directories in the Nest
+func listNestEggs(nestRoot string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(nestRoot, "Eggs"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Eggs directory: %w", err)
+	}
+	var eggs []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), "_") {
+			eggs = append(eggs, entry.Name())
+		}
+	}
+	return eggs, nil
+}
+
+// promptEggOptions asks for the Egg settings, using the flag values as defaults
+func promptEggOptions(p *prompter, name, runnerType, provider, region string) (eggOptions, error) {
+	var err error
+	if runnerType, err = p.choose("Runner type", []string{"vm", "serverless"}, runnerType); err != nil {
+		return eggOptions{}, err
+	}
+	if provider, err = p.choose("Cloud provider", parser.CloudProviders, provider); err != nil {
+		return eggOptions{}, err
+	}
+	if region == "" {
+		region, _ = resolveRunnerOptions(runnerType, provider, "")
+	}
+	region, err = p.ask("Region", region, func(s string) error {
+		_, err := resolveRunnerOptions(runnerType, provider, s)
+		return err
+	})
+	if err != nil {
+		return eggOptions{}, err
+	}
+
+	opts := newEggOptions(name, runnerType, provider, region)
+	if opts.CPU, err = p.askInt("vCPUs per runner", opts.CPU, 1, 128); err != nil {
+		return eggOptions{}, err
+	}
+	if opts.Memory, err = p.askInt("Memory per runner (MB)", opts.Memory, 512, 524288); err != nil {
+		return eggOptions{}, err
+	}
+	if opts.Disk, err = p.askInt("Disk per runner (GB)", opts.Disk, 10, 10240); err != nil {
+		return eggOptions{}, err
+	}
+	if opts.Concurrent, err = p.askInt("Concurrent jobs", opts.Concurrent, 1, 100); err != nil {
+		return eggOptions{}, err
+	}
+	if opts.ProjectID, err = p.askInt("GitLab project ID (0 to set later)", opts.ProjectID, 0, 999999999); err != nil {
+		return eggOptions{}, err
+	}
+	return opts, nil
+}
+
+// confirmWrite previews the generated file and asks whether to write it
+func confirmWrite(p *prompter, w io.Writer, path, content string) (bool, error) {
+	fmt.Fprintf(w, "\n--- %s ---\n%s---\n\n", path, content)
+	ok, err := p.confirm("Write this file?", true)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		fmt.Fprintln(w, "Aborted, nothing was written")
+	}
+	return ok, nil
+}
+
+// validateSchedule checks a job's cron schedule
+func validateSchedule(schedule string) error {
+	if cronErr := parser.ValidateCronExpression(schedule); cronErr != nil {
+		return fmt.Errorf("invalid schedule %q: %s", schedule, cronErr.Error())
+	}
 	return nil
 }
 
@@ -139,6 +557,13 @@ func runAddJob(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid job name: must contain only alphanumeric characters, hyphens, and underscores")
 	}
 
+	// Validate schedule if provided
+	if jobSchedule != "" {
+		if err := validateSchedule(jobSchedule); err != nil {
+			return err
+		}
+	}
+
 	// Find Nest root
 	nestRoot, err := findNestRoot()
 	if err != nil {
@@ -152,6 +577,22 @@ func runAddJob(cmd *cobra.Command, args []string) error {
 	}
 
 	jobContent := generateJobConfig(jobName, jobSchedule)
+	if interactive {
+		p := newPrompter(cmd.InOrStdin(), cmd.OutOrStdout())
+		schedule, err := p.ask("Cron schedule (empty to set later)", jobSchedule, func(s string) error {
+			if s == "" {
+				return nil
+			}
+			return validateSchedule(s)
+		})
+		if err != nil {
+			return err
+		}
+		jobContent = generateJobConfig(jobName, schedule)
+		if ok, err := confirmWrite(p, cmd.OutOrStdout(), jobPath, jobContent); err != nil || !ok {
+			return err
+		}
+	}
 	if err := os.WriteFile(jobPath, []byte(jobContent), 0644); err != nil {
 		return fmt.Errorf("failed to create job file: %w", err)
 	}
@@ -165,18 +606,42 @@ func runAddJob(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// eggOptions holds the values of the Egg template
+type eggOptions struct {
+	Name       string
+	Type       string
+	Provider   string
+	Region     string
+	CPU        int
+	Memory     int // MB
+	Disk       int // GB
+	Concurrent int
+	ProjectID  int // 0 leaves a TODO in the generated file
+}
+
+// newEggOptions returns the options for an Egg with default resources for its type
+func newEggOptions(name, runnerType, provider, region string) eggOptions {
+	cpu, memory, disk, concurrent := defaultResources(runnerType)
+	return eggOptions{
+		Name:       name,
+		Type:       runnerType,
+		Provider:   provider,
+		Region:     region,
+		CPU:        cpu,
+		Memory:     memory,
+		Disk:       disk,
+		Concurrent: concurrent,
+	}
+}
+
 func generateEggConfig(name, runnerType, provider, region string) string {
-	// Determine default resources based on type
-	cpu := 2
-	memory := 4096
-	disk := 20
-	concurrent := 3
+	return renderEggConfig(newEggOptions(name, runnerType, provider, region))
+}
 
-	if runnerType == "serverless" {
-		cpu = 1
-		memory = 2048
-		disk = 10
-		concurrent = 1
+func renderEggConfig(opts eggOptions) string {
+	projectID := "# TODO: Set your GitLab project ID\n    project_id = 0"
+	if opts.ProjectID != 0 {
+		projectID = fmt.Sprintf("project_id = %d", opts.ProjectID)
 	}
 
 	return fmt.Sprintf(`# Egg Configuration: %s
@@ -204,12 +669,11 @@ egg "%s" {
   }
   
   gitlab {
-    # TODO: Set your GitLab project ID
-    project_id = 0
+    %s
     
     # TODO: Set your GitLab runner token secret
-    # Format: yc-lockbox://{secret-id}/{key} or aws-sm://{secret-name}/{key}
-    token_secret = "%s-lockbox://gitlab-tokens/%s-runner-token"
+    # Format: yc-lockbox://{secret-id}/{key}, aws-sm://{secret-name}/{key} or azure-kv://{vault-name}/{secret-name}
+    token_secret = "%s://gitlab-tokens/%s-runner-token"
   }
   
   environment {
@@ -217,7 +681,83 @@ egg "%s" {
     # Add custom environment variables here
   }
 }
-`, name, runnerType, provider, name, runnerType, provider, region, cpu, memory, disk, concurrent, provider, name)
+`, opts.Name, opts.Type, opts.Provider, opts.Name, opts.Type, opts.Provider, opts.Region,
+		opts.CPU, opts.Memory, opts.Disk, opts.Concurrent, projectID, secretScheme(opts.Provider), opts.Name)
+}
+
+func generateEggsBucketConfig(name, runnerType, provider, region string, repos []string) string {
+	cpu, memory, disk, concurrent := defaultResources(runnerType)
+
+	var repoBlocks strings.Builder
+	for i, repo := range repos {
+		if i > 0 {
+			repoBlocks.WriteString("\n")
+		}
+		fmt.Fprintf(&repoBlocks, `    repo "%s" {
+      gitlab {
+        # TODO: Set the GitLab project ID of %s
+        project_id   = 0
+        server_name  = "gitlab.com"
+        token_secret = "%s://gitlab-tokens/%s-runner-token"
+      }
+    }
+`, repo, repo, secretScheme(provider), repo)
+	}
+
+	return fmt.Sprintf(`# EggsBucket Configuration: %s
+# Runner Type: %s
+# Cloud Provider: %s
+
+eggsbucket "%s" {
+  type = "%s"
+
+  cloud {
+    provider = "%s"
+    region   = "%s"
+  }
+
+  resources {
+    cpu    = %d
+    memory = %d  # MB
+    disk   = %d  # GB
+  }
+
+  runner {
+    tags         = ["docker", "linux"]
+    concurrent   = %d
+    idle_timeout = "10m"
+  }
+
+  repositories {
+%s  }
+
+  environment {
+    DOCKER_DRIVER = "overlay2"
+    # Add custom environment variables here
+  }
+}
+`, name, runnerType, provider, name, runnerType, provider, region, cpu, memory, disk, concurrent, repoBlocks.String())
+}
+
+// defaultResources returns the default cpu, memory (MB), disk (GB) and
+// concurrency for a runner type
+func defaultResources(runnerType string) (cpu, memory, disk, concurrent int) {
+	if runnerType == "serverless" {
+		return 1, 2048, 10, 1
+	}
+	return 2, 4096, 20, 3
+}
+
+// secretScheme returns the URI scheme of the provider's native secret manager
+func secretScheme(provider string) string {
+	switch provider {
+	case "aws":
+		return "aws-sm"
+	case "azure":
+		return "azure-kv"
+	default:
+		return "yc-lockbox"
+	}
 }
 
 func generateJobConfig(name, schedule string) string {
@@ -263,6 +803,67 @@ job "%s" {%s%s
 `, name, name, scheduleComment, scheduleValue, name)
 }
 
+func generateUglyFoxConfig(opts uglyFoxOptions) string {
+	eggsValue := `["my-app"] # TODO: List the Eggs these pools apply to`
+	if len(opts.Eggs) > 0 {
+		quoted := make([]string, len(opts.Eggs))
+		for i, egg := range opts.Eggs {
+			quoted[i] = fmt.Sprintf("%q", egg)
+		}
+		eggsValue = "[" + strings.Join(quoted, ", ") + "]"
+	}
+
+	return fmt.Sprintf(`# UglyFox Configuration
+# Runner lifecycle management: pruning, pool sizing and policies
+
+uglyfox {
+  # When failed or old runners are terminated
+  pruning {
+    failed_threshold = %d      # failures before a runner is pruned
+    max_age          = "%s"  # runners older than this are replaced
+    check_interval   = "%s"  # how often runners are checked
+  }
+
+  # Pool sizing for a group of Eggs. Add more runners_condition blocks
+  # to size other groups of Eggs differently.
+  runners_condition "default" {
+    eggs_entities = %s
+
+    # Active runners
+    apex {
+      max_count        = %d
+      min_count        = %d
+      cpu_threshold    = 80  # CPU percentage that triggers promotion
+      memory_threshold = 80  # memory percentage that triggers promotion
+    }
+
+    # Idle runners
+    nadir {
+      max_count    = %d
+      min_count    = %d
+      idle_timeout = "%s"
+    }
+  }
+
+  # Lifecycle rules, evaluated on every check
+  # Actions: terminate, demote_to_nadir, promote_to_apex
+  policies {
+    rule "terminate_old_failed" {
+      condition = "failed_count >= %d AND age > %s"
+      action    = "terminate"
+    }
+
+    rule "demote_idle" {
+      condition = "state == 'apex' AND idle_time > %s"
+      action    = "demote_to_nadir"
+    }
+  }
+}
+`, opts.FailedThreshold, opts.MaxAge, opts.CheckInterval, eggsValue,
+		opts.ApexMax, opts.ApexMin, opts.NadirMax, opts.NadirMin, opts.NadirIdleTimeout,
+		opts.FailedThreshold, opts.MaxAge, opts.NadirIdleTimeout)
+}
+
 func isValidName(name string) bool {
 	if name == "" {
 		return false
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+func TestGenerateEggsBucketConfig(t *testing.T) {
+	repos := []string{"auth-service", "api-gateway"}
+	content := generateEggsBucketConfig("platform", "vm", "yandex", "ru-central1-a", repos)
+
+	config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+	if err != nil {
+		t.Fatalf("generated config does not parse: %v\n%s", err, content)
+	}
+	if len(config.Blocks) != 1 || config.Blocks[0].Type != "eggsbucket" || config.Blocks[0].Labels[0] != "platform" {
+		t.Fatalf("expected a single eggsbucket \"platform\" block, got %+v", config.Blocks)
+	}
+
+	repositories, ok := config.Blocks[0].GetBlock("repositories")
+	if !ok {
+		t.Fatal("repositories block missing")
+	}
+	repoBlocks := repositories.GetBlocks("repo")
+	if len(repoBlocks) != len(repos) {
+		t.Fatalf("expected %d repo blocks, got %d", len(repos), len(repoBlocks))
+	}
+	for i, repo := range repoBlocks {
+		if repo.Labels[0] != repos[i] {
+			t.Errorf("repo %d: expected label %q, got %q", i, repos[i], repo.Labels[0])
+		}
+		gitlab, ok := repo.GetBlock("gitlab")
+		if !ok {
+			t.Fatalf("repo %q has no gitlab block", repos[i])
+		}
+		secret, _ := gitlab.GetAttribute("token_secret")
+		if s, _ := secret.AsString(); s != "yc-lockbox://gitlab-tokens/"+repos[i]+"-runner-token" {
+			t.Errorf("repo %q: unexpected token_secret %q", repos[i], s)
+		}
+	}
+
+	// Once project IDs are filled in, the scaffold is a valid configuration
+	filled := content
+	for i := range repos {
+		filled = strings.Replace(filled, "project_id   = 0", fmt.Sprintf("project_id   = %d", 42+i), 1)
+	}
+	config, err = parser.NewParser().Parse([]byte(filled), "config.fly")
+	if err != nil {
+		t.Fatalf("failed to parse filled config: %v", err)
+	}
+	if result := parser.NewValidator(config).Validate(); !result.IsValid() {
+		t.Errorf("filled config should be valid: %s", result.Error())
+	}
+}
+
+func TestResolveRunnerOptions(t *testing.T) {
+	region, err := resolveRunnerOptions("vm", "azure", "")
+	if err != nil || region != "eastus" {
+		t.Errorf("expected default azure region eastus, got %q (%v)", region, err)
+	}
+	if _, err := resolveRunnerOptions("container", "aws", ""); err == nil {
+		t.Error("expected error for invalid type")
+	}
+	if _, err := resolveRunnerOptions("vm", "gcp", ""); err == nil {
+		t.Error("expected error for invalid provider")
+	}
+	if _, err := resolveRunnerOptions("vm", "azure", "mars-1"); err == nil {
+		t.Error("expected error for invalid azure region")
+	}
+}
+
+func TestGenerateUglyFoxConfig(t *testing.T) {
+	opts := uglyFoxOptions{
+		FailedThreshold:  3,
+		MaxAge:           "24h",
+		CheckInterval:    "5m",
+		Eggs:             []string{"my-app", "api-service"},
+		ApexMax:          10,
+		ApexMin:          2,
+		NadirMax:         5,
+		NadirMin:         0,
+		NadirIdleTimeout: "30m",
+	}
+
+	for _, eggs := range [][]string{opts.Eggs, nil} {
+		opts.Eggs = eggs
+		content := generateUglyFoxConfig(opts)
+		config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+		if err != nil {
+			t.Fatalf("generated config does not parse: %v\n%s", err, content)
+		}
+		if result := parser.NewValidator(config).Validate(); !result.IsValid() {
+			t.Errorf("generated config should be valid (eggs %v): %s", eggs, result.Error())
+		}
+	}
+}
+
+func TestPromptUglyFoxOptions(t *testing.T) {
+	// Accept the first default, retry an invalid duration, then override pool sizes
+	input := strings.Join([]string{
+		"",     // failed threshold
+		"soon", // max age: invalid
+		"12h",  // max age
+		"",     // check interval
+		"20",   // apex max
+		"30",   // apex min: above max
+		"4",    // apex min
+		"",     // nadir max
+		"",     // nadir min
+		"15m",  // idle timeout
+	}, "\n") + "\n"
+
+	opts := uglyFoxOptions{FailedThreshold: 3, MaxAge: "24h", CheckInterval: "5m", ApexMax: 10, ApexMin: 2, NadirMax: 5, NadirIdleTimeout: "30m"}
+	var out strings.Builder
+	if err := promptUglyFoxOptions(newPrompter(strings.NewReader(input), &out), &opts); err != nil {
+		t.Fatalf("promptUglyFoxOptions failed: %v\n%s", err, out.String())
+	}
+
+	want := uglyFoxOptions{FailedThreshold: 3, MaxAge: "12h", CheckInterval: "5m", ApexMax: 20, ApexMin: 4, NadirMax: 5, NadirMin: 0, NadirIdleTimeout: "15m"}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("got %+v, want %+v", opts, want)
+	}
+	if !strings.Contains(out.String(), "is not a duration") || !strings.Contains(out.String(), "must be between 0 and 20") {
+		t.Errorf("expected validation messages in prompt output, got:\n%s", out.String())
+	}
+
+	// Running out of input is an error rather than an endless loop
+	if err := promptUglyFoxOptions(newPrompter(strings.NewReader("\n"), &out), &opts); err == nil {
+		t.Error("expected error when input ends early")
+	}
+}
+
+func TestPromptEggOptions(t *testing.T) {
+	input := strings.Join([]string{
+		"container", // type: invalid
+		"serverless",
+		"azure",
+		"mars-1", // region: invalid for azure
+		"",       // region: default eastus
+		"",       // cpu
+		"4096",   // memory
+		"",       // disk
+		"",       // concurrent
+		"12345",  // project ID
+	}, "\n") + "\n"
+
+	var out strings.Builder
+	opts, err := promptEggOptions(newPrompter(strings.NewReader(input), &out), "my-app", "vm", "yandex", "")
+	if err != nil {
+		t.Fatalf("promptEggOptions failed: %v\n%s", err, out.String())
+	}
+
+	want := eggOptions{
+		Name: "my-app", Type: "serverless", Provider: "azure", Region: "eastus",
+		CPU: 1, Memory: 4096, Disk: 10, Concurrent: 1, ProjectID: 12345,
+	}
+	if opts != want {
+		t.Errorf("got %+v, want %+v", opts, want)
+	}
+
+	content := renderEggConfig(opts)
+	if !strings.Contains(content, "project_id = 12345") || strings.Contains(content, "TODO: Set your GitLab project ID") {
+		t.Errorf("expected project ID without TODO in generated config:\n%s", content)
+	}
+	if _, err := parser.NewParser().Parse([]byte(content), "config.fly"); err != nil {
+		t.Errorf("generated config does not parse: %v", err)
+	}
+}
+
+func TestRenderEggConfigMatchesDefaults(t *testing.T) {
+	// Interactive mode with every default must produce the non-interactive file
+	got := renderEggConfig(newEggOptions("my-app", "vm", "aws", "us-east-1"))
+	if want := generateEggConfig("my-app", "vm", "aws", "us-east-1"); got != want {
+		t.Errorf("renderEggConfig with defaults differs from generateEggConfig")
+	}
+}
+
+func TestConfirmWrite(t *testing.T) {
+	var out strings.Builder
+	ok, err := confirmWrite(newPrompter(strings.NewReader("n\n"), &out), &out, "Jobs/backup.fly", "job \"backup\" {}\n")
+	if err != nil || ok {
+		t.Errorf("expected declined write, got ok=%v err=%v", ok, err)
+	}
+	if !strings.Contains(out.String(), "--- Jobs/backup.fly ---") || !strings.Contains(out.String(), "Aborted") {
+		t.Errorf("expected preview and abort message, got:\n%s", out.String())
+	}
+
+	ok, err = confirmWrite(newPrompter(strings.NewReader("\n"), &out), &out, "Jobs/backup.fly", "")
+	if err != nil || !ok {
+		t.Errorf("expected default answer to write, got ok=%v err=%v", ok, err)
+	}
+}
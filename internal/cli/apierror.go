@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+// friendlyError rewrites a MotherGoose error response in err for people: the
+// raw "HTTP 404: 404 Not Found - {...}" is replaced by the message of its
+// error envelope, followed by the invalid fields of a validation error and a
+// hint on what to do. Other errors are returned as they are.
+func friendlyError(err error) error {
+	var httpErr *mothergoose.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.API == nil {
+		return err
+	}
+	apiErr := httpErr.API
+
+	msg := strings.Replace(err.Error(), httpErr.Error(), apiErr.Message, 1)
+	if fields := apiErr.FieldErrors(); len(fields) > 0 {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			msg += fmt.Sprintf("\n  %s: %s", name, fields[name])
+		}
+	}
+
+	switch {
+	case mothergoose.IsNotFound(err):
+		msg += "\nCheck the name, or run 'gosling status --all' to list the deployed Eggs"
+	case mothergoose.IsConflict(err):
+		msg += "\nAnother change to this Egg is in progress; run 'gosling status' and retry once it has finished"
+	case mothergoose.IsValidation(err):
+		msg += "\nMotherGoose rejected the request; run 'gosling validate' and check that gosling and MotherGoose versions match ('gosling doctor')"
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+func TestFriendlyError(t *testing.T) {
+	body := `{"error": "invalid egg configuration", "code": "validation_error", "details": {"runner.tags": "must not be empty", "resources.cpu": "must be at least 1"}}`
+	httpErr := &mothergoose.HTTPError{
+		StatusCode: 422,
+		Status:     "422 Unprocessable Entity",
+		Body:       body,
+		API:        &mothergoose.APIError{StatusCode: 422, Message: "invalid egg configuration", Code: mothergoose.CodeValidation, Details: []byte(`{"runner.tags": "must not be empty", "resources.cpu": "must be at least 1"}`)},
+	}
+	got := friendlyError(fmt.Errorf("failed to store egg configuration: %w", httpErr)).Error()
+	want := "failed to store egg configuration: invalid egg configuration\n" +
+		"  resources.cpu: must be at least 1\n" +
+		"  runner.tags: must not be empty\n" +
+		"MotherGoose rejected the request"
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("got:\n%s\nwant prefix:\n%s", got, want)
+	}
+
+	notFound := &mothergoose.HTTPError{StatusCode: 404, Status: "404 Not Found", Body: `{"error": "egg \"api\" not found"}`,
+		API: &mothergoose.APIError{StatusCode: 404, Message: `egg "api" not found`}}
+	got = friendlyError(fmt.Errorf("failed to get egg status: %w", notFound)).Error()
+	if !strings.HasPrefix(got, `failed to get egg status: egg "api" not found`+"\n") || !strings.Contains(got, "gosling status --all") {
+		t.Errorf("unexpected not found message:\n%s", got)
+	}
+
+	// Errors without an envelope are left alone
+	for _, err := range []error{errors.New("boom"), &mothergoose.HTTPError{StatusCode: 502, Status: "502 Bad Gateway", Body: "upstream"}} {
+		if friendlyError(err) != err {
+			t.Errorf("expected %v to be returned as is", err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/audit"
+	"github.com/polar-gosling/gosling/internal/git"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// auditTimeout bounds sending an audit entry to MotherGoose
+const auditTimeout = 10 * time.Second
+
+// auditRedactedFlags are flags whose values never reach the audit log
+var auditRedactedFlags = map[string]bool{"api-key": true}
+
+// audited wraps the RunE of a command that changes deployments or the Nest so
+// every run, successful or not, is recorded in the audit log. Dry runs change
+// nothing and are not recorded.
+func audited(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			return run(cmd, args)
+		}
+		start := time.Now()
+		err := run(cmd, args)
+		recordAudit(cmd, newAuditEntry(cmd, args, start, err))
+		return err
+	}
+}
+
+// newAuditEntry describes a run of cmd that started at start and ended with runErr
+func newAuditEntry(cmd *cobra.Command, args []string, start time.Time, runErr error) *audit.Entry {
+	entry := &audit.Entry{
+		Time:       start.UTC(),
+		Command:    cmd.CommandPath(),
+		Args:       append([]string{}, args...),
+		User:       currentUser(),
+		Outcome:    audit.OutcomeSuccess,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		value := flag.Value.String()
+		if auditRedactedFlags[flag.Name] {
+			value = "REDACTED"
+		}
+		entry.Args = append(entry.Args, "--"+flag.Name+"="+value)
+	})
+	if runErr != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = runErr.Error()
+	}
+	return entry
+}
+
+// recordAudit appends entry to the audit log of the Nest, if run inside one,
+// and sends it to MotherGoose when audit_remote is enabled. Failing to record
+// is reported but does not fail the command.
+func recordAudit(cmd *cobra.Command, entry *audit.Entry) {
+	log := logger("audit")
+	if nestRoot, err := findNestRoot(); err == nil {
+		if sha, err := git.Run(nestRoot, "rev-parse", "HEAD"); err == nil {
+			entry.GitSHA = strings.TrimSpace(string(sha))
+		}
+		if err := audit.Append(audit.Path(nestRoot), entry); err != nil {
+			log.Warn(err.Error())
+		} else {
+			log.Debug("Recorded in the audit log", "path", audit.Path(nestRoot))
+		}
+	}
+
+	remote, err := auditRemoteEnabled()
+	if err != nil {
+		log.Warn(err.Error())
+		return
+	}
+	if !remote {
+		return
+	}
+	apiURL, _ := cmd.Flags().GetString("api-url")
+	apiKey, _ := cmd.Flags().GetString("api-key")
+	conn, err := resolveAPI(apiURL, apiKey)
+	if err != nil {
+		log.Warn(fmt.Sprintf("Failed to send audit entry: %v", err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+	defer cancel()
+	if err := conn.client().RecordAuditEntry(ctx, entry); err != nil {
+		log.Warn(err.Error())
+	}
+}
+
+// auditRemoteEnabled reports whether audit entries are sent to MotherGoose,
+// as set by $GOSLING_AUDIT_REMOTE or the audit_remote profile setting
+func auditRemoteEnabled() (bool, error) {
+	if value := os.Getenv("GOSLING_AUDIT_REMOTE"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid GOSLING_AUDIT_REMOTE %q: must be true or false", value)
+		}
+		return enabled, nil
+	}
+	p, err := activeProfile()
+	if err != nil {
+		return false, err
+	}
+	return p.AuditRemote, nil
+}
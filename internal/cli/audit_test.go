@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+func TestAudited(t *testing.T) {
+	useTestConfig(t)
+	root := t.TempDir()
+	t.Chdir(root)
+	writeNestFile(t, root, "Jobs/.keep", "")
+	writeNestFile(t, root, "UF/.keep", "")
+	writeNestFile(t, root, "Eggs/my-app/config.fly", "")
+
+	var received []*audit.Entry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/audit" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var entry audit.Entry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			t.Errorf("failed to decode audit entry: %v", err)
+		}
+		received = append(received, &entry)
+	}))
+	defer server.Close()
+
+	runs := 0
+	newCmd := func(fail bool) *cobra.Command {
+		cmd := &cobra.Command{Use: "rollback", RunE: audited(func(cmd *cobra.Command, args []string) error {
+			runs++
+			if fail {
+				return fmt.Errorf("no previous plan")
+			}
+			return nil
+		})}
+		cmd.Flags().String("egg", "", "")
+		cmd.Flags().String("api-url", "", "")
+		cmd.Flags().String("api-key", "", "")
+		cmd.Flags().Bool("dry-run", false, "")
+		return cmd
+	}
+	run := func(fail bool, args ...string) error {
+		cmd := newCmd(fail)
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+
+	if err := run(false, "--egg", "my-app", "--api-key", "secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := run(true, "--egg", "my-app"); err == nil {
+		t.Fatal("expected the command's error")
+	}
+	if err := run(false, "--egg", "my-app", "--dry-run"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := audit.Read(audit.Path(root))
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if runs != 3 || len(entries) != 2 {
+		t.Fatalf("expected 3 runs and 2 audit entries (dry runs are not audited), got %d and %d", runs, len(entries))
+	}
+	if entries[0].Outcome != audit.OutcomeSuccess || strings.Join(entries[0].Args, " ") != "--api-key=REDACTED --egg=my-app" {
+		t.Errorf("unexpected first entry %+v", entries[0])
+	}
+	if entries[1].Outcome != audit.OutcomeFailure || entries[1].Error != "no previous plan" {
+		t.Errorf("expected the failure to be audited, got %+v", entries[1])
+	}
+	if len(received) != 0 {
+		t.Errorf("expected nothing sent to MotherGoose by default, got %d entries", len(received))
+	}
+
+	t.Setenv("GOSLING_AUDIT_REMOTE", "true")
+	if err := run(false, "--egg", "my-app", "--api-url", server.URL, "--api-key", "secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(received) != 1 || received[0].Command != "rollback" {
+		t.Errorf("expected the entry to be sent to MotherGoose, got %+v", received)
+	}
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bootstrapCloud  string
+	bootstrapRegion string
+	bootstrapDryRun bool
+)
+
+// bootstrapCmd represents the bootstrap command
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap [mothergoose.fly]",
+	Short: "Provision the MotherGoose backend from a mothergoose block",
+	Long: `Provision the MotherGoose and UglyFox backend infrastructure declared in a
+mothergoose block: service accounts, the database, message queues, buckets,
+functions, the API gateway and triggers, in that order.
+
+The file defaults to mothergoose.fly in the current directory, or MG/config.fly
+in the Nest repository. It must pass a stricter validation than 'gosling
+validate': every resource needs a valid name, and triggers, dead-letter
+queues and service account references must name declared resources.
+
+Limitation: provisioning is not implemented yet apart from the storage
+buckets on AWS. Every mothergoose block declares an API gateway, the
+fastapi_app function and a database, so bootstrap cannot provision a
+complete backend on any provider today: it validates the block, lists the
+resources with --dry-run and, without it, fails with "provider X not
+supported yet" before creating anything rather than leaving half a backend
+behind. Create the backend with your usual tooling in the meantime.
+
+With --dry-run the resources are listed without creating anything, with a
+warning when bootstrap would fail.
+
+Example:
+  gosling bootstrap --cloud yandex --region ru-central1-a --dry-run
+  gosling bootstrap MG/config.fly --cloud aws --region us-east-1`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBootstrap,
+}
+
+func init() {
+	rootCmd.AddCommand(bootstrapCmd)
+	bootstrapCmd.Flags().StringVar(&bootstrapCloud, "cloud", "", "Cloud provider: yandex, aws, or azure")
+	bootstrapCmd.Flags().StringVar(&bootstrapRegion, "region", "", "Cloud region")
+	bootstrapCmd.Flags().BoolVar(&bootstrapDryRun, "dry-run", false, "List the resources without creating them")
+}
+
+// bootstrapOutput is the machine-readable result of `gosling bootstrap`
+type bootstrapOutput struct {
+	DryRun    bool                      `json:"dry_run"`
+	File      string                    `json:"file"`
+	Cloud     string                    `json:"cloud"`
+	Region    string                    `json:"region"`
+	Resources []bootstrapResourceOutput `json:"resources"`
+}
+
+type bootstrapResourceOutput struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+func runBootstrap(cmd *cobra.Command, args []string) error {
+	conn, err := resolveConnection("", "", bootstrapCloud, bootstrapRegion)
+	if err != nil {
+		return err
+	}
+	if conn.Cloud == "" {
+		return fmt.Errorf("--cloud flag is required (or set GOSLING_CLOUD or the profile's cloud)")
+	}
+	if conn.Region == "" {
+		return fmt.Errorf("--region flag is required (or set GOSLING_REGION or the profile's region)")
+	}
+	var provider deployer.CloudProvider
+	switch conn.Cloud {
+	case "yandex":
+		provider = deployer.CloudProviderYandex
+	case "aws":
+		provider = deployer.CloudProviderAWS
+	case "azure":
+		provider = deployer.CloudProviderAzure
+	default:
+		return fmt.Errorf("unsupported cloud provider: %s", conn.Cloud)
+	}
+
+	filePath, err := bootstrapFile(args)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadBackendConfig(filePath)
+	if err != nil {
+		return err
+	}
+	if err := cfg.CheckProvider(provider); err != nil {
+		return err
+	}
+
+	w := msgOut()
+	report := &bootstrapOutput{DryRun: bootstrapDryRun, File: filePath, Cloud: conn.Cloud, Region: conn.Region}
+	for _, r := range cfg.Resources() {
+		report.Resources = append(report.Resources, bootstrapResourceOutput{Kind: r.Kind, Name: r.Name})
+	}
+	fmt.Fprintf(w, "MotherGoose backend from %s on %s (%s): %d resource(s)\n", filePath, provider, conn.Region, len(report.Resources))
+
+	if bootstrapDryRun {
+		if err := cfg.CheckProvisioning(provider); err != nil {
+			logger("bootstrap").Warn(fmt.Sprintf("Bootstrap would fail: %v", err))
+		}
+		if isStructuredOutput() {
+			return writeStructured(os.Stdout, report)
+		}
+		for _, r := range report.Resources {
+			fmt.Printf("  + %s %s\n", r.Kind, r.Name)
+		}
+		fmt.Println("\nNo resources will be created")
+		return nil
+	}
+
+	ctx := commandContext(cmd)
+	d, err := deployer.NewDeployer(ctx)
+	if err != nil {
+		return err
+	}
+	if err := d.DeployBackendInfrastructure(ctx, provider, conn.Region, cfg, func(r deployer.BackendResource) {
+		fmt.Fprintf(w, "✅ Created %s\n", r)
+	}); err != nil {
+		return fmt.Errorf("bootstrap failed: %w", err)
+	}
+
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, report)
+	}
+	fmt.Println("\nMotherGoose backend provisioned successfully.")
+	return nil
+}
+
+// bootstrapFile returns the mothergoose configuration to provision
+func bootstrapFile(args []string) (string, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	if _, err := os.Stat("mothergoose.fly"); err == nil {
+		return "mothergoose.fly", nil
+	}
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return "", fmt.Errorf("no mothergoose.fly in the current directory and no Nest repository found: %w", err)
+	}
+	return filepath.Join(nestRoot, "MG", "config.fly"), nil
+}
+
+// loadBackendConfig parses and validates a mothergoose configuration
+func loadBackendConfig(filePath string) (*deployer.BackendConfig, error) {
+	config, err := parser.NewParser().ParseFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	if result := parser.ValidateMotherGoose(config); !result.IsValid() {
+		return nil, fmt.Errorf("invalid %s: %s", filePath, result.Error())
+	}
+	for i := range config.Blocks {
+		if config.Blocks[i].Type == "mothergoose" {
+			return deployer.ParseMotherGoose(&config.Blocks[i])
+		}
+	}
+	return nil, fmt.Errorf("no mothergoose block in %s", filePath)
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/spf13/cobra"
+)
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a shell completion script for gosling.
+
+Egg names complete from the Eggs/ directory of the current Nest. Commands
+that talk to MotherGoose (status, drift, logs, rollback) also offer the Eggs
+deployed there when an API URL and key are configured.
+
+To load completions:
+
+Bash:
+  source <(gosling completion bash)
+  # or permanently (Linux):
+  gosling completion bash > /etc/bash_completion.d/gosling
+
+Zsh:
+  gosling completion zsh > "${fpath[1]}/_gosling"
+
+Fish:
+  gosling completion fish > ~/.config/fish/completions/gosling.fish
+
+PowerShell:
+  gosling completion powershell | Out-String | Invoke-Expression`,
+	Args:                  cobra.ExactArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+// remoteCompletionTimeout bounds the MotherGoose lookup so tab never hangs
+const remoteCompletionTimeout = 2 * time.Second
+
+func init() {
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	switch args[0] {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(out, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(out)
+	case "fish":
+		return rootCmd.GenFishCompletion(out, true)
+	case "powershell":
+		return rootCmd.GenPowerShellCompletionWithDesc(out)
+	default:
+		return fmt.Errorf("unsupported shell %q: must be one of bash, zsh, fish, powershell", args[0])
+	}
+}
+
+// mustRegisterEggCompletion attaches egg name completion to a command's --egg flag
+func mustRegisterEggCompletion(cmd *cobra.Command, complete cobra.CompletionFunc) {
+	if err := cmd.RegisterFlagCompletionFunc("egg", complete); err != nil {
+		panic(fmt.Sprintf("failed to register --egg completion on %q: %v", cmd.Name(), err))
+	}
+}
+
+// completeEggNames returns a completion function listing the Eggs of the
+// local Nest and, with remote, the Eggs known to MotherGoose
+func completeEggNames(remote bool) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names := map[string]bool{}
+		if root, err := findNestRoot(); err == nil {
+			for _, name := range localEggNames(filepath.Join(root, "Eggs")) {
+				names[name] = true
+			}
+		}
+		if remote {
+			for _, name := range remoteEggNames(cmd) {
+				names[name] = true
+			}
+		}
+
+		var matches []string
+		for name := range names {
+			if strings.HasPrefix(name, toComplete) {
+				matches = append(matches, name)
+			}
+		}
+		sort.Strings(matches)
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeFirstArgEggName completes a command's first argument with local Egg names
+func completeFirstArgEggName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeEggNames(false)(cmd, args, toComplete)
+}
+
+// localEggNames lists the Egg directories that hold a config.fly or config.fly.json
+func localEggNames(eggsDir string) []string {
+	dirs, err := listEggDirs(eggsDir)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(dirs))
+	for i, dir := range dirs {
+		names[i] = dir.Name
+	}
+	return names
+}
+
+// remoteEggNames lists the Eggs deployed to MotherGoose. Completion must not
+// fail, so a missing configuration or unreachable API yields no names.
+func remoteEggNames(cmd *cobra.Command) []string {
+	apiURL, _ := cmd.Flags().GetString("api-url")
+	apiKey, _ := cmd.Flags().GetString("api-key")
+	conn, err := resolveConnection(apiURL, apiKey, "", "")
+	if err != nil || conn.requireAPI() != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteCompletionTimeout)
+	defer cancel()
+	client := conn.client(mothergoose.WithTimeout(remoteCompletionTimeout), mothergoose.WithMaxRetries(0))
+	eggs, err := client.ListEggs(ctx)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(eggs))
+	for _, egg := range eggs {
+		names = append(names, egg.Name)
+	}
+	return names
+}
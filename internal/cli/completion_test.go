@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteEggNames(t *testing.T) {
+	useTestConfig(t)
+	root := t.TempDir()
+	t.Chdir(root)
+	writeNestFile(t, root, "Jobs/.keep", "")
+	writeNestFile(t, root, "UF/.keep", "")
+	writeNestFile(t, root, "Eggs/my-app/config.fly", "")
+	writeNestFile(t, root, "Eggs/api-service/config.fly", "")
+	writeNestFile(t, root, "Eggs/_shared/common.fly", "")
+	writeNestFile(t, root, "Eggs/empty/README.md", "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(mothergoose.EggsPage{
+			Eggs: []*deployer.EggConfig{{Name: "my-app"}, {Name: "legacy-app"}},
+		})
+	}))
+	defer server.Close()
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("api-url", server.URL, "")
+	cmd.Flags().String("api-key", "key", "")
+
+	names, directive := completeEggNames(false)(cmd, nil, "")
+	if want := []string{"api-service", "my-app"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("local completion: got %v, want %v", names, want)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("expected file completion to be disabled, got %v", directive)
+	}
+
+	names, _ = completeEggNames(true)(cmd, nil, "")
+	if want := []string{"api-service", "legacy-app", "my-app"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("remote completion: got %v, want %v", names, want)
+	}
+
+	names, _ = completeEggNames(true)(cmd, nil, "my")
+	if want := []string{"my-app"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("prefix completion: got %v, want %v", names, want)
+	}
+
+	// An unreachable API still completes local names
+	server.Close()
+	names, _ = completeEggNames(true)(cmd, nil, "")
+	if want := []string{"api-service", "my-app"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("completion with API down: got %v, want %v", names, want)
+	}
+}
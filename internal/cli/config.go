@@ -0,0 +1,457 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/polar-gosling/gosling/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+// profileName holds the value of the global --profile flag
+var profileName string
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage CLI connection profiles",
+	Long: `Manage named profiles of MotherGoose connection settings and cloud
+defaults, stored in ~/.config/gosling/config.yaml (or $GOSLING_CONFIG).
+
+Settings are resolved in this order:
+  1. Command-line flags (--api-url, --api-key, --cloud, --region)
+  2. Environment variables (GOSLING_API_URL, GOSLING_API_KEY, GOSLING_CLOUD, GOSLING_REGION)
+  3. The profile selected by --profile, $GOSLING_PROFILE or 'gosling config use'
+
+API keys are never written to the config file: 'gosling config set api_key'
+stores the key in the OS keychain (macOS Keychain, Secret Service or Windows
+Credential Manager) and records keychain://<profile> instead. Pass "-" to
+read the key from stdin. api_key may also be a reference to a secret:
+  env://VAR                     environment variable
+  file://path                   file contents
+  vault://mount/path/key        HashiCorp Vault KV v2 (VAULT_ADDR, VAULT_TOKEN)
+  yc-lockbox://secret-id/key    Yandex Cloud Lockbox
+
+Instead of an API key, gosling can authenticate with short-lived tokens from
+an OIDC client credentials flow: set oidc_token_url, oidc_client_id and either
+oidc_client_secret or oidc_client_assertion (a JWT such as a GitLab CI ID
+token, e.g. env://GOSLING_ID_TOKEN), or the matching GOSLING_OIDC_* variables.
+Secrets are stored like api_key. An API key given by --api-key or
+GOSLING_API_KEY takes precedence over OIDC.
+
+Example:
+  gosling config set api_url https://mothergoose.example.com --profile prod
+  gosling config set api_key - --profile prod < key.txt
+  gosling config set api_key vault://secret/mothergoose/api-key --profile ci
+  gosling config set oidc_client_assertion env://GOSLING_ID_TOKEN --profile ci
+  gosling config use prod`,
+}
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a profile setting",
+	Long: `Set a setting of the selected profile (--profile, $GOSLING_PROFILE or the
+current profile), creating the profile if needed.
+
+Keys: ` + strings.Join(profileKeys, ", ") + `
+
+Example:
+  gosling config set api_url https://mothergoose.example.com
+  gosling config set cloud aws --profile staging`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+// configUseCmd represents the config use command
+var configUseCmd = &cobra.Command{
+	Use:   "use <profile>",
+	Short: "Select the current profile",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUse,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUseCmd)
+
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Connection profile to use (default: $GOSLING_PROFILE or the current profile)")
+}
+
+// defaultProfile is the profile used before any profile has been selected
+const defaultProfile = "default"
+
+// profileKeys are the settings accepted by `gosling config set`
+var profileKeys = []string{"api_url", "api_key", "cloud", "region", "audit_remote",
+	"oidc_token_url", "oidc_client_id", "oidc_client_secret", "oidc_client_assertion", "oidc_scopes"}
+
+// profile holds the connection settings of a named profile
+type profile struct {
+	APIURL string `json:"api_url,omitempty"`
+	APIKey string `json:"api_key,omitempty"` // Secret reference, see secrets.Resolve
+	Cloud  string `json:"cloud,omitempty"`
+	Region string `json:"region,omitempty"`
+
+	AuditRemote bool `json:"audit_remote,omitempty"` // Send audit entries to MotherGoose
+
+	OIDCTokenURL        string `json:"oidc_token_url,omitempty"`
+	OIDCClientID        string `json:"oidc_client_id,omitempty"`
+	OIDCClientSecret    string `json:"oidc_client_secret,omitempty"`    // Secret reference
+	OIDCClientAssertion string `json:"oidc_client_assertion,omitempty"` // Secret reference
+	OIDCScopes          string `json:"oidc_scopes,omitempty"`           // Space- or comma-separated
+}
+
+// cliConfig is the content of the CLI config file
+type cliConfig struct {
+	CurrentProfile string              `json:"current_profile,omitempty"`
+	Profiles       map[string]*profile `json:"profiles,omitempty"`
+}
+
+// cliConfigPath returns the location of the CLI config file
+func cliConfigPath() (string, error) {
+	if path := os.Getenv("GOSLING_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gosling", "config.yaml"), nil
+}
+
+// loadCLIConfig reads the CLI config file; a missing file is an empty config
+func loadCLIConfig(path string) (*cliConfig, error) {
+	cfg := &cliConfig{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// saveCLIConfig writes the CLI config file. It may hold API keys, so it is
+// only readable by the owner.
+func saveCLIConfig(path string, cfg *cliConfig) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// selectedProfile returns the profile name chosen by --profile or
+// $GOSLING_PROFILE, and whether it was chosen explicitly
+func selectedProfile(cfg *cliConfig) (string, bool) {
+	if profileName != "" {
+		return profileName, true
+	}
+	if name := os.Getenv("GOSLING_PROFILE"); name != "" {
+		return name, true
+	}
+	if cfg.CurrentProfile != "" {
+		return cfg.CurrentProfile, false
+	}
+	return defaultProfile, false
+}
+
+// activeProfile loads the selected profile. An explicitly selected profile
+// must exist; otherwise a missing profile is empty.
+func activeProfile() (*profile, error) {
+	path, err := cliConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loadCLIConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	name, explicit := selectedProfile(cfg)
+	if p, ok := cfg.Profiles[name]; ok {
+		return p, nil
+	}
+	if explicit {
+		return nil, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+	return &profile{}, nil
+}
+
+// connection holds MotherGoose connection settings and cloud defaults
+// resolved from flags, environment variables and the active profile
+type connection struct {
+	APIURL string
+	APIKey string
+	Cloud  string
+	Region string
+	// OIDC replaces APIKey when the client credentials flow is configured
+	OIDC *mothergoose.OIDCConfig
+}
+
+// resolveConnection fills every setting not given as a flag from the
+// GOSLING_* environment variables, then the active profile
+func resolveConnection(apiURL, apiKey, cloud, region string) (*connection, error) {
+	p, err := activeProfile()
+	if err != nil {
+		return nil, err
+	}
+	pick := func(flag, env, fromProfile string) string {
+		if flag != "" {
+			return flag
+		}
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+		return fromProfile
+	}
+
+	conn := &connection{
+		APIURL: pick(apiURL, "GOSLING_API_URL", p.APIURL),
+		Cloud:  pick(cloud, "GOSLING_CLOUD", p.Cloud),
+		Region: pick(region, "GOSLING_REGION", p.Region),
+	}
+	switch {
+	case apiKey != "":
+		conn.APIKey = apiKey
+	case os.Getenv("GOSLING_API_KEY") != "":
+		conn.APIKey = os.Getenv("GOSLING_API_KEY")
+	case pick("", "GOSLING_OIDC_TOKEN_URL", p.OIDCTokenURL) != "":
+		conn.OIDC, err = resolveOIDC(p, pick)
+		if err != nil {
+			return nil, err
+		}
+	case p.APIKey != "":
+		if !secrets.IsReference(p.APIKey) {
+			logger("config").Warn("The profile's api_key is stored in plaintext; run 'gosling config set api_key' to move it to the OS keychain")
+		}
+		conn.APIKey, err = secrets.Resolve(context.Background(), p.APIKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// resolveOIDC reads the OIDC client credentials settings from the
+// GOSLING_OIDC_* environment variables and the profile, resolving secrets
+func resolveOIDC(p *profile, pick func(flag, env, fromProfile string) string) (*mothergoose.OIDCConfig, error) {
+	oidc := &mothergoose.OIDCConfig{
+		TokenURL: pick("", "GOSLING_OIDC_TOKEN_URL", p.OIDCTokenURL),
+		ClientID: pick("", "GOSLING_OIDC_CLIENT_ID", p.OIDCClientID),
+		Scopes:   strings.Fields(strings.ReplaceAll(pick("", "GOSLING_OIDC_SCOPES", p.OIDCScopes), ",", " ")),
+	}
+	if oidc.ClientID == "" {
+		return nil, fmt.Errorf("OIDC client ID is not set: use GOSLING_OIDC_CLIENT_ID or 'gosling config set oidc_client_id'")
+	}
+	var err error
+	if ref := pick("", "GOSLING_OIDC_CLIENT_ASSERTION", p.OIDCClientAssertion); ref != "" {
+		if oidc.ClientAssertion, err = secrets.Resolve(context.Background(), ref); err != nil {
+			return nil, err
+		}
+	} else if ref := pick("", "GOSLING_OIDC_CLIENT_SECRET", p.OIDCClientSecret); ref != "" {
+		if oidc.ClientSecret, err = secrets.Resolve(context.Background(), ref); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, fmt.Errorf("OIDC client credentials are not set: use GOSLING_OIDC_CLIENT_SECRET, GOSLING_OIDC_CLIENT_ASSERTION or 'gosling config set oidc_client_secret'")
+	}
+	return oidc, nil
+}
+
+// requireAPI fails if the MotherGoose API URL or credentials are unset
+func (c *connection) requireAPI() error {
+	if c.APIURL == "" {
+		return fmt.Errorf("MotherGoose API URL is not set: use --api-url, GOSLING_API_URL or 'gosling config set api_url'")
+	}
+	if c.APIKey == "" && c.OIDC == nil {
+		return fmt.Errorf("MotherGoose API key is not set: use --api-key, GOSLING_API_KEY or 'gosling config set api_key' (or configure OIDC, see 'gosling config --help')")
+	}
+	return nil
+}
+
+// client returns a MotherGoose client authenticated with the connection's
+// API key or OIDC settings
+func (c *connection) client(opts ...mothergoose.ClientOption) *mothergoose.Client {
+	if c.OIDC != nil {
+		opts = append([]mothergoose.ClientOption{mothergoose.WithOIDC(*c.OIDC)}, opts...)
+	}
+	return mothergoose.NewClient(c.APIURL, c.APIKey, opts...)
+}
+
+// resolveAPI resolves the MotherGoose connection, failing if its API URL or
+// credentials are unset
+func resolveAPI(apiURL, apiKey string) (*connection, error) {
+	conn, err := resolveConnection(apiURL, apiKey, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.requireAPI(); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	path, err := cliConfigPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadCLIConfig(path)
+	if err != nil {
+		return err
+	}
+	name, _ := selectedProfile(cfg)
+	switch key {
+	case "api_key":
+		if value, err = storeSecret(cmd, name, "API key", value); err != nil {
+			return err
+		}
+	case "oidc_client_secret":
+		if value, err = storeSecret(cmd, name+"/oidc", "OIDC client secret", value); err != nil {
+			return err
+		}
+	}
+	if err := setProfileValue(cfg, name, key, value); err != nil {
+		return err
+	}
+	if err := saveCLIConfig(path, cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Set %s in profile %q (%s)\n", key, name, path)
+	return nil
+}
+
+// setProfileValue sets key in the named profile, creating it if needed. The
+// first profile created becomes the current one.
+func setProfileValue(cfg *cliConfig, name, key, value string) error {
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*profile)
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		p = &profile{}
+	}
+
+	switch key {
+	case "api_url":
+		p.APIURL = value
+	case "api_key":
+		p.APIKey = value
+	case "cloud":
+		if !containsString(parser.CloudProviders, value) {
+			return fmt.Errorf("invalid cloud %q: must be one of %s", value, strings.Join(parser.CloudProviders, ", "))
+		}
+		p.Cloud = value
+	case "region":
+		p.Region = value
+	case "audit_remote":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid audit_remote %q: must be true or false", value)
+		}
+		p.AuditRemote = enabled
+	case "oidc_token_url":
+		p.OIDCTokenURL = value
+	case "oidc_client_id":
+		p.OIDCClientID = value
+	case "oidc_client_secret":
+		p.OIDCClientSecret = value
+	case "oidc_client_assertion":
+		if value != "" && !secrets.IsReference(value) {
+			return fmt.Errorf("invalid oidc_client_assertion: must be a secret reference such as env://GOSLING_ID_TOKEN, not the token itself")
+		}
+		p.OIDCClientAssertion = value
+	case "oidc_scopes":
+		p.OIDCScopes = value
+	default:
+		return fmt.Errorf("unknown setting %q: must be one of %s", key, strings.Join(profileKeys, ", "))
+	}
+
+	cfg.Profiles[name] = p
+	if cfg.CurrentProfile == "" {
+		cfg.CurrentProfile = name
+	}
+	return nil
+}
+
+// storeSecret moves a plaintext secret, such as an API key, into the OS
+// keychain under account and returns the reference to store in the profile.
+// "-" reads the secret from stdin.
+func storeSecret(cmd *cobra.Command, account, what, value string) (string, error) {
+	if value == "-" {
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s from stdin: %w", what, err)
+		}
+		value = strings.TrimSpace(string(data))
+	}
+	if value == "" || secrets.IsReference(value) {
+		return value, nil
+	}
+	if err := secrets.DefaultKeychain.Set(secrets.KeychainService, account, value); err != nil {
+		return "", fmt.Errorf("failed to store %s in the OS keychain (use a secret URI such as env://VAR or vault://... instead): %w", what, err)
+	}
+	return secrets.KeychainReference(account), nil
+}
+
+func runConfigUse(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	path, err := cliConfigPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadCLIConfig(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Profiles[name]; !ok {
+		names := make([]string, 0, len(cfg.Profiles))
+		for n := range cfg.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("profile %q not found: known profiles are %v", name, names)
+	}
+	cfg.CurrentProfile = name
+	if err := saveCLIConfig(path, cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Using profile %q\n", name)
+	return nil
+}
+
+func containsString(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
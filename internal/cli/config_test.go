@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/polar-gosling/gosling/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+// useTestConfig points the CLI config at a fresh file and clears overrides
+func useTestConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("GOSLING_CONFIG", path)
+	for _, name := range []string{"GOSLING_PROFILE", "GOSLING_API_URL", "GOSLING_API_KEY", "GOSLING_CLOUD", "GOSLING_REGION", "GOSLING_AUDIT_REMOTE",
+		"GOSLING_OIDC_TOKEN_URL", "GOSLING_OIDC_CLIENT_ID", "GOSLING_OIDC_CLIENT_SECRET", "GOSLING_OIDC_CLIENT_ASSERTION", "GOSLING_OIDC_SCOPES"} {
+		t.Setenv(name, "")
+	}
+	profileName = ""
+	t.Cleanup(func() { profileName = "" })
+	return path
+}
+
+func TestConfigSetAndUse(t *testing.T) {
+	path := useTestConfig(t)
+
+	if err := runConfigSet(nil, []string{"api_url", "https://staging.example.com"}); err != nil {
+		t.Fatalf("config set failed: %v", err)
+	}
+	profileName = "prod"
+	for _, kv := range [][]string{{"api_url", "https://prod.example.com"}, {"api_key", "env://PROD_KEY"}, {"cloud", "aws"}} {
+		if err := runConfigSet(nil, kv); err != nil {
+			t.Fatalf("config set %s failed: %v", kv[0], err)
+		}
+	}
+	if err := runConfigSet(nil, []string{"cloud", "gcp"}); err == nil {
+		t.Error("expected error for unknown cloud")
+	}
+	if err := runConfigSet(nil, []string{"timeout", "5s"}); err == nil {
+		t.Error("expected error for unknown key")
+	}
+	profileName = ""
+
+	cfg, err := loadCLIConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CurrentProfile != defaultProfile || cfg.Profiles["prod"].Cloud != "aws" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected config file mode 0600, got %v (%v)", info.Mode(), err)
+	}
+
+	if err := runConfigUse(nil, []string{"missing"}); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+	if err := runConfigUse(nil, []string{"prod"}); err != nil {
+		t.Fatalf("config use failed: %v", err)
+	}
+	t.Setenv("PROD_KEY", "secret")
+	conn, err := resolveAPI("", "")
+	if err != nil || conn.APIURL != "https://prod.example.com" || conn.APIKey != "secret" {
+		t.Errorf("expected prod profile settings, got %+v (%v)", conn, err)
+	}
+}
+
+func TestResolveConnectionPrecedence(t *testing.T) {
+	path := useTestConfig(t)
+	cfg := &cliConfig{}
+	for _, kv := range [][]string{{"api_url", "https://profile.example.com"}, {"api_key", "profile-key"}, {"region", "eu-west-1"}} {
+		if err := setProfileValue(cfg, defaultProfile, kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := saveCLIConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GOSLING_API_URL", "https://env.example.com")
+	conn, err := resolveConnection("", "flag-key", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := connection{APIURL: "https://env.example.com", APIKey: "flag-key", Region: "eu-west-1"}
+	if *conn != want {
+		t.Errorf("got %+v, want %+v", *conn, want)
+	}
+
+	// An explicitly selected profile must exist
+	t.Setenv("GOSLING_PROFILE", "prod")
+	if _, err := resolveConnection("", "", "", ""); err == nil || !strings.Contains(err.Error(), `"prod"`) {
+		t.Errorf("expected missing profile error, got %v", err)
+	}
+}
+
+func TestResolveAPIRequiresSettings(t *testing.T) {
+	useTestConfig(t)
+	if _, err := resolveAPI("", "key"); err == nil || !strings.Contains(err.Error(), "API URL") {
+		t.Errorf("expected missing API URL error, got %v", err)
+	}
+	if _, err := resolveAPI("https://mg.example.com", ""); err == nil || !strings.Contains(err.Error(), "API key") {
+		t.Errorf("expected missing API key error, got %v", err)
+	}
+}
+
+func TestResolveConnectionOIDC(t *testing.T) {
+	path := useTestConfig(t)
+	cfg := &cliConfig{}
+	for _, kv := range [][]string{
+		{"api_url", "https://mg.example.com"},
+		{"api_key", "profile-key"},
+		{"oidc_token_url", "https://idp.example.com/token"},
+		{"oidc_client_id", "gosling"},
+		{"oidc_client_secret", "env://OIDC_SECRET"},
+		{"oidc_scopes", "deploy, status"},
+	} {
+		if err := setProfileValue(cfg, defaultProfile, kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := setProfileValue(cfg, defaultProfile, "oidc_client_assertion", "eyJhbGciOi"); err == nil {
+		t.Error("expected a plaintext client assertion to be rejected")
+	}
+	if err := saveCLIConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("OIDC_SECRET", "s3cr3t")
+	conn, err := resolveAPI("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mothergoose.OIDCConfig{TokenURL: "https://idp.example.com/token", ClientID: "gosling", ClientSecret: "s3cr3t", Scopes: []string{"deploy", "status"}}
+	if conn.APIKey != "" || conn.OIDC == nil || !reflect.DeepEqual(*conn.OIDC, want) {
+		t.Errorf("expected OIDC to replace the profile's API key, got %+v", conn)
+	}
+
+	// A GitLab CI ID token from the environment takes the client secret's place
+	t.Setenv("GOSLING_OIDC_CLIENT_ASSERTION", "env://GOSLING_ID_TOKEN")
+	t.Setenv("GOSLING_ID_TOKEN", "id.token.jwt")
+	if conn, err = resolveAPI("", ""); err != nil || conn.OIDC.ClientAssertion != "id.token.jwt" || conn.OIDC.ClientSecret != "" {
+		t.Errorf("expected the client assertion, got %+v (%v)", conn.OIDC, err)
+	}
+
+	// An explicit API key wins
+	t.Setenv("GOSLING_API_KEY", "env-key")
+	if conn, err = resolveAPI("", ""); err != nil || conn.APIKey != "env-key" || conn.OIDC != nil {
+		t.Errorf("expected the API key from the environment, got %+v (%v)", conn, err)
+	}
+
+	t.Setenv("GOSLING_API_KEY", "")
+	t.Setenv("GOSLING_OIDC_CLIENT_ASSERTION", "")
+	t.Setenv("OIDC_SECRET", "")
+	if _, err := resolveAPI("", ""); err == nil || !strings.Contains(err.Error(), "OIDC_SECRET") {
+		t.Errorf("expected unresolved secret error, got %v", err)
+	}
+}
+
+// memoryKeychain is an in-memory secrets.Keychain for tests
+type memoryKeychain map[string]string
+
+func (k memoryKeychain) Get(service, account string) (string, error) {
+	secret, ok := k[service+"/"+account]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k memoryKeychain) Set(service, account, secret string) error {
+	k[service+"/"+account] = secret
+	return nil
+}
+
+func (k memoryKeychain) Delete(service, account string) error {
+	delete(k, service+"/"+account)
+	return nil
+}
+
+func TestConfigSetStoresAPIKeyInKeychain(t *testing.T) {
+	path := useTestConfig(t)
+	keychain := memoryKeychain{}
+	defaultKeychain := secrets.DefaultKeychain
+	secrets.DefaultKeychain = keychain
+	t.Cleanup(func() { secrets.DefaultKeychain = defaultKeychain })
+
+	profileName = "prod"
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader("s3cr3t\n"))
+	if err := runConfigSet(cmd, []string{"api_key", "-"}); err != nil {
+		t.Fatalf("config set failed: %v", err)
+	}
+	if err := runConfigSet(cmd, []string{"api_url", "https://prod.example.com"}); err != nil {
+		t.Fatalf("config set failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cr3t") || !strings.Contains(string(data), "keychain://prod") {
+		t.Errorf("expected only a keychain reference in the config file, got:\n%s", data)
+	}
+	if keychain["gosling/prod"] != "s3cr3t" {
+		t.Errorf("expected API key in keychain, got %v", keychain)
+	}
+
+	conn, err := resolveAPI("", "")
+	if err != nil || conn.APIKey != "s3cr3t" {
+		t.Errorf("expected API key from keychain, got %+v (%v)", conn, err)
+	}
+}
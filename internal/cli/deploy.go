@@ -2,134 +2,506 @@ package cli
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/polar-gosling/gosling/internal/cost"
 	"github.com/polar-gosling/gosling/internal/deployer"
 	"github.com/polar-gosling/gosling/internal/mothergoose"
 	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/polar-gosling/gosling/internal/secrets"
+	"github.com/polar-gosling/gosling/internal/signing"
+	"github.com/polar-gosling/gosling/internal/telemetry"
 	"github.com/spf13/cobra"
 )
 
 var (
-	deployDryRun bool
-	deployCloud  string
-	deployRegion string
-	deployAPIURL string
-	deployAPIKey string
+	deployDryRun  bool
+	deployCloud   string
+	deployRegion  string
+	deployAPIURL  string
+	deployAPIKey  string
+	deployEnv     string
+	deploySignKey string
+	deployQuotas  bool
+	// deployConcurrency is the number of Eggs deployed in parallel
+	deployConcurrency int
+	deployFailFast    bool
+	// deployApplyTimeout bounds how long deploy waits for MotherGoose to apply a plan
+	deployApplyTimeout time.Duration
+	// deployOverrideCloud lets --cloud and --region replace the Eggs' cloud blocks
+	deployOverrideCloud bool
 )
 
+// planPollInterval is how often deploy checks whether a submitted plan was applied
+var planPollInterval = 5 * time.Second
+
 var deployCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: "Deploy resources from Nest repository",
-	Long:  "Deploy resources from Nest repository to cloud providers.",
-	RunE:  runDeploy,
+	Long: `Deploy resources from Nest repository to cloud providers.
+
+Each Egg is deployed to the provider and region of its cloud block, so
+--cloud and --region are optional. When given (or set through GOSLING_CLOUD,
+GOSLING_REGION or the profile) they must agree with every Egg's cloud block;
+--override-cloud deploys the Eggs to them instead.
+
+Every Egg of the Nest is deployed unless the selection is narrowed with
+--egg (repeatable), --eggs a,b, --path with a glob such as 'Eggs/team-a-*',
+or --changed-since <git-ref>, which picks the Eggs whose directory changed
+since that revision (all Eggs when Eggs/_* includes or Presets/ changed).
+Given together, the filters must all match.
+
+With --env, each Egg's config.<env>.fly overlay (if present) is deep-merged
+over its config.fly and the merged result is validated before deploying.
+
+The Nest's policies (Policies/*.fly) must pass for the selected Eggs before
+anything is deployed.
+A policy can be skipped with --policy-skip and a mandatory --policy-skip-reason.
+
+With --check-quotas, the vCPUs, memory and disk of all VM Eggs and the
+concurrency of all serverless Eggs are compared with the quota left in the
+target cloud (EC2 On-Demand vCPUs and Lambda concurrency on AWS, Compute
+Cloud quotas of the folder's cloud on Yandex, which needs YC_FOLDER_ID),
+and nothing is deployed if they do not fit.
+
+Each changed Egg's plan is submitted to MotherGoose and applied, and deploy
+waits (up to --apply-timeout) until MotherGoose reports it applied or failed.
+
+Once every Egg is deployed, the Jobs of Jobs/*.fly whose configuration
+changed are registered with MotherGoose, which runs them on schedule, and
+UF/config.fly replaces the pruning, pool and policy configuration UglyFox
+applies if it differs (the changed attributes are listed). Jobs and UglyFox
+are left alone when the Eggs are narrowed with --egg, --eggs, --path or
+--changed-since.
+
+Up to --concurrency Eggs (default 4) are deployed in parallel, with a status
+line as each is queued, starts and finishes. A failed Egg does not stop the
+others; the failures are reported together at the end and deploy exits with
+an error. --fail-fast starts no more Eggs after the first failure.
+
+With --dry-run nothing is changed, and every Egg is shown with its estimated
+monthly cost together with the Nest-wide total (see also 'gosling plan').
+
+With --sign-key (or $GOSLING_SIGN_KEY), every plan is signed with an Ed25519
+key before it is sent to MotherGoose, which can then reject unsigned or
+tampered plans. The key is a PEM file or a secret reference such as
+vault://secret/gosling/plan-signing-key; check signatures with
+'gosling verify-plan'.
+
+Example:
+  gosling deploy --api-url ... --api-key ...
+  gosling deploy --cloud yandex --region ru-central1-a --api-url ... --api-key ...
+  gosling deploy --env prod --cloud aws --region us-east-1 --override-cloud
+  gosling deploy --sign-key plan-signing.key --cloud aws --region us-east-1
+  gosling deploy --check-quotas --cloud aws --region us-east-1
+  gosling deploy --egg my-app --egg my-api
+  gosling deploy --changed-since origin/main --path 'Eggs/team-a-*'`,
+	RunE: audited(runDeploy),
 }
 
 func init() {
 	rootCmd.AddCommand(deployCmd)
 	deployCmd.Flags().BoolVar(&deployDryRun, "dry-run", false, "Preview changes")
-	deployCmd.Flags().StringVar(&deployCloud, "cloud", "", "Cloud provider")
+	deployCmd.Flags().StringVar(&deployCloud, "cloud", "", "Cloud provider: yandex, aws, or azure")
 	deployCmd.Flags().StringVar(&deployRegion, "region", "", "Cloud region")
 	deployCmd.Flags().StringVar(&deployAPIURL, "api-url", "", "MotherGoose API URL")
 	deployCmd.Flags().StringVar(&deployAPIKey, "api-key", "", "MotherGoose API key")
-	mustMarkRequired(deployCmd, "api-url")
-	mustMarkRequired(deployCmd, "api-key")
+	deployCmd.Flags().StringVar(&deployEnv, "env", "", "Environment overlay to apply (e.g. prod for config.prod.fly)")
+	deployCmd.Flags().StringVar(&deploySignKey, "sign-key", "", "Ed25519 private key (PEM file or secret reference) to sign plans with (default: $GOSLING_SIGN_KEY)")
+	deployCmd.Flags().BoolVar(&deployQuotas, "check-quotas", false, "Check the cloud's quotas before deploying (yandex, aws)")
+	deployCmd.Flags().BoolVar(&deployOverrideCloud, "override-cloud", false, "Deploy to --cloud/--region even where the Eggs' cloud blocks differ")
+	deployCmd.Flags().IntVarP(&deployConcurrency, "concurrency", "j", 4, "Number of Eggs to deploy in parallel")
+	deployCmd.Flags().DurationVar(&deployApplyTimeout, "apply-timeout", 30*time.Minute, "How long to wait for MotherGoose to apply each plan")
+	deployCmd.Flags().BoolVar(&deployFailFast, "fail-fast", false, "Stop starting Eggs after the first one fails")
+	addSelectionFlags(deployCmd)
+	addPolicyFlags(deployCmd)
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
-	if deployCloud == "" {
-		return fmt.Errorf("--cloud flag is required")
+	ctx := commandContext(cmd)
+	conn, err := resolveConnection(deployAPIURL, deployAPIKey, deployCloud, deployRegion)
+	if err != nil {
+		return err
 	}
-	if deployRegion == "" {
-		return fmt.Errorf("--region flag is required")
+	if err := conn.requireAPI(); err != nil {
+		return err
 	}
-	var cloudProvider deployer.CloudProvider
-	switch deployCloud {
+	target := deployTarget{Region: conn.Region, Override: deployOverrideCloud}
+	switch conn.Cloud {
+	case "":
 	case "yandex":
-		cloudProvider = deployer.CloudProviderYandex
+		target.Cloud = deployer.CloudProviderYandex
 	case "aws":
-		cloudProvider = deployer.CloudProviderAWS
+		target.Cloud = deployer.CloudProviderAWS
+	case "azure":
+		target.Cloud = deployer.CloudProviderAzure
 	default:
-		return fmt.Errorf("unsupported cloud provider: %s", deployCloud)
+		return fmt.Errorf("unsupported cloud provider: %s", conn.Cloud)
+	}
+	if deployOverrideCloud && target.Cloud == "" && target.Region == "" {
+		return fmt.Errorf("--override-cloud needs --cloud or --region")
+	}
+	if deployConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", deployConcurrency)
+	}
+	if deployEnv != "" && !parser.IsValidEnvironmentName(deployEnv) {
+		return fmt.Errorf("invalid environment name %q", deployEnv)
 	}
 	nestRoot, err := findNestRoot()
 	if err != nil {
 		return fmt.Errorf("failed to find Nest repository: %w", err)
 	}
-	fmt.Printf("Found Nest repository at: %s\n", nestRoot)
+	log := logger("deploy")
+	log.Info(fmt.Sprintf("Found Nest repository at: %s", nestRoot), "nest", nestRoot)
+	if deployEnv != "" {
+		log.Info(fmt.Sprintf("Environment: %s", deployEnv), "env", deployEnv)
+	}
 	eggsDir := filepath.Join(nestRoot, "Eggs")
-	eggs, err := parseEggConfigs(eggsDir)
+	eggs, err := parseEggConfigs(eggsDir, deployEnv)
 	if err != nil {
 		return fmt.Errorf("failed to parse Egg configurations: %w", err)
 	}
 	if len(eggs) == 0 {
 		return fmt.Errorf("no Egg configurations found")
 	}
-	fmt.Printf("Found %d Egg configuration(s)\n", len(eggs))
+	log.Info(fmt.Sprintf("Found %d Egg configuration(s)", len(eggs)), "eggs", len(eggs))
+	selection := eggSelection{
+		Names:        append(deployOnlyEgg, deployOnlyEggs...),
+		ChangedSince: deployChangedSince,
+		Paths:        deployPaths,
+	}
+	if selection.isSet() {
+		selected, err := selection.filter(nestRoot, eggs)
+		if err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("Selected %d of %d Egg(s)", len(selected), len(eggs)), "selected", len(selected), "eggs", len(eggs))
+		if len(selected) == 0 {
+			log.Info("No Egg matches the selection; nothing to deploy.")
+			if isStructuredOutput() {
+				return writeStructured(os.Stdout, &deployOutput{DryRun: deployDryRun, Eggs: []*eggDeployOutput{}})
+			}
+			return nil
+		}
+		eggs = selected
+	}
+	// Jobs and the UglyFox configuration are deployed with the whole Nest,
+	// not with a selection of Eggs
+	var jobs []*deployer.JobConfig
+	var uglyFox *deployer.UglyFoxConfig
+	if !selection.isSet() {
+		jobs, err = parseJobConfigs(filepath.Join(nestRoot, "Jobs"), deployEnv)
+		if err != nil {
+			return fmt.Errorf("failed to parse Job configurations: %w", err)
+		}
+		if len(jobs) > 0 {
+			log.Info(fmt.Sprintf("Found %d Job configuration(s)", len(jobs)), "jobs", len(jobs))
+		}
+		uglyFox, err = parseUglyFoxConfig(filepath.Join(nestRoot, "UF"), deployEnv)
+		if err != nil {
+			return fmt.Errorf("failed to parse UglyFox configuration: %w", err)
+		}
+	}
+	for i, egg := range eggs {
+		if err := target.reconcile(egg); err != nil {
+			if isStructuredOutput() {
+				// Nothing is deployed, but scripts still get a document
+				report := &deployOutput{DryRun: deployDryRun}
+				for _, other := range eggs {
+					report.Eggs = append(report.Eggs, &eggDeployOutput{EggName: other.Name, Status: deployStatusSkipped})
+				}
+				report.Eggs[i].Status = deployStatusFailed
+				report.Eggs[i].Error = err.Error()
+				if werr := writeStructured(os.Stdout, report); werr != nil {
+					return werr
+				}
+			}
+			return err
+		}
+	}
+
+	engine, err := loadPolicies(nestRoot)
+	if err != nil {
+		return err
+	}
+	var selectedNames []string
+	if selection.isSet() {
+		for _, egg := range eggs {
+			selectedNames = append(selectedNames, egg.Name)
+		}
+	}
+	violations, err := nestPolicyViolations(engine, eggsDir, deployEnv, selectedNames)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return policyError(violations)
+	}
+
+	if deployQuotas {
+		d, err := deployer.NewDeployer(ctx)
+		if err != nil {
+			return err
+		}
+		for _, group := range groupByPlacement(eggs) {
+			if err := checkQuotas(ctx, d, group, group[0].Cloud.Provider, group[0].Cloud.Region); err != nil {
+				return err
+			}
+		}
+	}
+
+	signingKey, err := loadSigningKey(deploySignKey)
+	if err != nil {
+		return err
+	}
+	if signingKey != nil {
+		keyID := signing.KeyID(signingKey.Public().(ed25519.PublicKey))
+		log.Info(fmt.Sprintf("Signing plans with key %s", keyID), "key_id", keyID)
+	}
 
-	client := mothergoose.NewClient(deployAPIURL, deployAPIKey)
+	client := conn.client()
 
-	for _, egg := range eggs {
-		fmt.Printf("\n=== Deploying Egg: %s ===\n", egg.Name)
-		if err := deployEgg(ctx, egg, cloudProvider, deployRegion, client); err != nil {
-			return fmt.Errorf("failed to deploy egg %s: %w", egg.Name, err)
+	report := &deployOutput{DryRun: deployDryRun}
+	if deployDryRun {
+		report.TotalCost = &costOutput{Currency: cost.Currency}
+	}
+	report.Eggs, err = deployAll(ctx, logOut(), eggs, deployConcurrency, deployFailFast,
+		func(ctx context.Context, log *slog.Logger, egg *deployer.EggConfig) (*eggDeployOutput, error) {
+			ctx, span := telemetry.StartSpan(ctx, "deploy egg", telemetry.EggKey.String(egg.Name))
+			result, err := deployEgg(ctx, log, egg, egg.Cloud.Provider, egg.Cloud.Region, client, signingKey)
+			if err == nil && deployDryRun {
+				result.Cost = estimateEggCost(log, egg, egg.Cloud.Provider)
+			}
+			telemetry.EndSpan(span, err)
+			return result, err
+		})
+	if err == nil && len(jobs) > 0 {
+		report.Jobs, err = deployJobs(ctx, log, jobs, client, deployDryRun)
+	}
+	if err == nil && uglyFox != nil {
+		report.UglyFox, err = deployUglyFox(ctx, log, uglyFox, client, deployDryRun)
+	}
+	if deployDryRun {
+		for _, result := range report.Eggs {
+			report.TotalCost.add(result.Cost)
+		}
+	}
+
+	if isStructuredOutput() {
+		if werr := writeStructured(os.Stdout, report); werr != nil {
+			return werr
 		}
+		return err
+	}
+	if err != nil {
+		return err
 	}
 	if deployDryRun {
-		fmt.Println("\nDry-run completed successfully.")
+		log.Info(fmt.Sprintf("Estimated monthly cost: %s", report.TotalCost))
+		log.Info("Dry-run completed successfully.")
 	} else {
-		fmt.Println("\nDeployment completed successfully.")
+		log.Info("Deployment completed successfully.")
 	}
 	return nil
 }
 
-func parseEggConfigs(eggsDir string) ([]*deployer.EggConfig, error) {
-	var eggs []*deployer.EggConfig
+// deployOutput is the machine-readable result of `gosling deploy`
+type deployOutput struct {
+	DryRun    bool                 `json:"dry_run"`
+	Eggs      []*eggDeployOutput   `json:"eggs"`
+	Jobs      []*jobDeployOutput   `json:"jobs,omitempty"`
+	UglyFox   *uglyFoxDeployOutput `json:"uglyfox,omitempty"`
+	TotalCost *costOutput          `json:"total_cost,omitempty"` // Nest-wide estimate, dry-run only
+}
+
+// Values for eggDeployOutput.Status
+const (
+	deployStatusUnchanged = "unchanged"
+	deployStatusPlanned   = "planned"
+	deployStatusApplied   = "applied"
+)
+
+// eggDeployOutput describes what deploy did (or would do) for a single Egg
+type eggDeployOutput struct {
+	EggName      string          `json:"egg_name"`
+	Status       string          `json:"status"`
+	PlanID       string          `json:"plan_id,omitempty"`
+	ConfigHash   string          `json:"config_hash"`
+	SigningKeyID string          `json:"signing_key_id,omitempty"`
+	RunnerType   string          `json:"runner_type"`
+	Cloud        string          `json:"cloud"`
+	Region       string          `json:"region"`
+	Resources    resourcesOutput `json:"resources"`
+	Cost         *costOutput     `json:"cost,omitempty"`  // Estimate, dry-run only
+	Error        string          `json:"error,omitempty"` // Why a failed Egg was not deployed
+}
+
+// estimateEggCost logs and returns the estimated monthly cost of egg, or
+// nil when the provider has no pricing
+func estimateEggCost(log *slog.Logger, egg *deployer.EggConfig, provider deployer.CloudProvider) *costOutput {
+	estimate, err := cost.DefaultCatalog().Estimate(egg, provider)
+	if err != nil {
+		log.Info(fmt.Sprintf("Estimated cost: unavailable (%v)", err))
+		return nil
+	}
+	out := newCostOutput(estimate)
+	log.Info(fmt.Sprintf("Estimated cost: %s", out), "monthly", out.Monthly)
+	return out
+}
+
+// loadSigningKey reads the plan signing key from a PEM file or secret
+// reference, falling back to $GOSLING_SIGN_KEY. No key means plans are unsigned.
+func loadSigningKey(ref string) (ed25519.PrivateKey, error) {
+	if ref == "" {
+		ref = os.Getenv("GOSLING_SIGN_KEY")
+	}
+	if ref == "" {
+		return nil, nil
+	}
+
+	var data []byte
+	if secrets.IsReference(ref) {
+		value, err := secrets.Resolve(context.Background(), ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve signing key: %w", err)
+		}
+		data = []byte(value)
+	} else {
+		var err error
+		if data, err = os.ReadFile(ref); err != nil {
+			return nil, fmt.Errorf("failed to read signing key: %w", err)
+		}
+	}
+	return signing.ParsePrivateKey(data)
+}
+
+// eggConfigPath returns the config.fly of an Egg directory, or its
+// config.fly.json when it is written in the JSON syntax
+func eggConfigPath(eggDir string) (string, bool) {
+	for _, name := range []string{"config.fly", "config" + parser.JSONExtension} {
+		path := filepath.Join(eggDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// eggDir is an Egg directory of the Nest and its configuration file
+type eggDir struct {
+	Name       string
+	ConfigPath string
+}
+
+// listEggDirs returns the directories under eggsDir holding a config.fly or
+// config.fly.json, sorted by name. Directories starting with "_" hold shared
+// include fragments and are skipped.
+func listEggDirs(eggsDir string) ([]eggDir, error) {
 	entries, err := os.ReadDir(eggsDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read Eggs directory: %w", err)
 	}
-	p := parser.NewParser()
+	var dirs []eggDir
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), "_") {
 			continue
 		}
-		configPath := filepath.Join(eggsDir, entry.Name(), "config.fly")
-		if _, err := os.Stat(configPath); os.IsNotExist(err) {
-			continue
+		if configPath, ok := eggConfigPath(filepath.Join(eggsDir, entry.Name())); ok {
+			dirs = append(dirs, eggDir{Name: entry.Name(), ConfigPath: configPath})
 		}
-		config, err := p.ParseFile(configPath)
+	}
+	return dirs, nil
+}
+
+// parseEggConfigs parses every Eggs/<name>/config.fly, merging the overlay
+// for env over it when env is set. Overlaid configurations are validated.
+// An Egg is named after its directory, unless its config.fly stamps out
+// several with count or for_each, which are named by their labels.
+func parseEggConfigs(eggsDir, env string) ([]*deployer.EggConfig, error) {
+	var eggs []*deployer.EggConfig
+	dirs, err := listEggDirs(eggsDir)
+	if err != nil {
+		return nil, err
+	}
+	p := parser.NewParser()
+	for _, dir := range dirs {
+		configPath := dir.ConfigPath
+		config, err := p.ParseFileForEnv(configPath, env)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
 		}
-		egg, err := convertToEggConfig(config, entry.Name())
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert config: %w", err)
+		if env != "" {
+			if result := parser.NewValidator(config).Validate(); !result.IsValid() {
+				return nil, fmt.Errorf("invalid %s configuration for %s: %s", env, configPath, result.Error())
+			}
+		}
+		var eggBlocks []*parser.Block
+		for i := range config.Blocks {
+			if config.Blocks[i].Type == "egg" {
+				eggBlocks = append(eggBlocks, &config.Blocks[i])
+			}
+		}
+		if len(eggBlocks) == 0 {
+			return nil, fmt.Errorf("failed to convert config: no egg block found in %s", configPath)
+		}
+		for _, eggBlock := range eggBlocks {
+			egg, err := convertToEggConfig(eggBlock, expandedEggName(dir.Name, eggBlock, len(eggBlocks)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert config: %w", err)
+			}
+			eggs = append(eggs, egg)
 		}
-		eggs = append(eggs, egg)
 	}
 	return eggs, nil
 }
 
-func convertToEggConfig(config *parser.Config, name string) (*deployer.EggConfig, error) {
-	var eggBlock *parser.Block
-	for i := range config.Blocks {
-		if config.Blocks[i].Type == "egg" {
-			eggBlock = &config.Blocks[i]
-			break
-		}
+// expandedEggName names one of the eggBlocks egg blocks of an Egg directory:
+// after the directory, unless the block is an instance of count or for_each
+// or the file has several egg blocks, which are named by their labels. The
+// instances of count = 1 keep their label so changing the count does not
+// rename them.
+func expandedEggName(dirName string, eggBlock *parser.Block, eggBlocks int) string {
+	if (eggBlock.Repeated || eggBlocks > 1) && len(eggBlock.Labels) > 0 {
+		return eggBlock.Labels[0]
 	}
-	if eggBlock == nil {
-		return nil, fmt.Errorf("no egg block found")
+	return dirName
+}
+
+// resolveEggBlock finds the egg block of the Egg called name, parsed with the
+// env overlay merged. Eggs are named as deploy names them: after their
+// directory, or by their label when stamped out with count or for_each, so
+// the instances are looked for in every directory of eggsDir.
+func resolveEggBlock(eggsDir, name, env string) (eggDir, *parser.Config, *parser.Block, error) {
+	dirs, err := listEggDirs(eggsDir)
+	if err != nil {
+		return eggDir{}, nil, nil, err
 	}
+	// The Egg's own directory goes first so a broken neighbour cannot hide it
+	sort.SliceStable(dirs, func(i, j int) bool { return dirs[i].Name == name && dirs[j].Name != name })
+
+	p := parser.NewParser()
+	for _, dir := range dirs {
+		config, err := p.ParseFileForEnv(dir.ConfigPath, env)
+		if err != nil {
+			return eggDir{}, nil, nil, fmt.Errorf("failed to parse %s: %w", dir.ConfigPath, err)
+		}
+		if selected := selectedEggBlocks(config, dir.Name, map[string]bool{name: true}); len(selected.Blocks) > 0 {
+			return dir, config, &selected.Blocks[0], nil
+		}
+	}
+	return eggDir{}, nil, nil, fmt.Errorf("egg %q not found: no config.fly in Eggs/%s and no count or for_each instance with that name", name, name)
+}
+
+func convertToEggConfig(eggBlock *parser.Block, name string) (*deployer.EggConfig, error) {
 	egg := &deployer.EggConfig{
 		Name:        name,
 		Environment: make(map[string]string),
@@ -150,6 +522,10 @@ func convertToEggConfig(config *parser.Config, name string) (*deployer.EggConfig
 						egg.Cloud.Provider = deployer.CloudProviderYandex
 					case "aws":
 						egg.Cloud.Provider = deployer.CloudProviderAWS
+					case "azure":
+						egg.Cloud.Provider = deployer.CloudProviderAzure
+					default:
+						return nil, fmt.Errorf("%s: unsupported cloud provider: %s", provider.Position, providerStr)
 					}
 				}
 			}
@@ -158,6 +534,32 @@ func convertToEggConfig(config *parser.Config, name string) (*deployer.EggConfig
 					egg.Cloud.Region = regionStr
 				}
 			}
+			if network, ok := childBlock.GetBlock("network"); ok {
+				if vpcID, ok := network.GetAttribute("vpc_id"); ok {
+					if vpcStr, err := vpcID.AsString(); err == nil {
+						egg.Network.VPCID = vpcStr
+					}
+				}
+				if subnetID, ok := network.GetAttribute("subnet_id"); ok {
+					if subnetStr, err := subnetID.AsString(); err == nil {
+						egg.Network.SubnetID = subnetStr
+					}
+				}
+				if groups, ok := network.GetAttribute("security_groups"); ok {
+					if groupList, err := groups.AsList(); err == nil {
+						for _, group := range groupList {
+							if groupStr, err := group.AsString(); err == nil {
+								egg.Network.SecurityGroups = append(egg.Network.SecurityGroups, groupStr)
+							}
+						}
+					}
+				}
+				if publicIP, ok := network.GetAttribute("public_ip"); ok {
+					if publicIPBool, err := publicIP.AsBool(); err == nil {
+						egg.Network.DisablePublicIP = !publicIPBool
+					}
+				}
+			}
 		case "resources":
 			if cpu, ok := childBlock.GetAttribute("cpu"); ok {
 				if cpuInt, err := cpu.AsInt(); err == nil {
@@ -174,6 +576,21 @@ func convertToEggConfig(config *parser.Config, name string) (*deployer.EggConfig
 					egg.Resources.Disk = diskInt
 				}
 			}
+			if gpu, ok := childBlock.GetAttribute("gpu"); ok {
+				if gpuInt, err := gpu.AsInt(); err == nil {
+					egg.Resources.GPU = gpuInt
+				}
+			}
+			if gpuType, ok := childBlock.GetAttribute("gpu_type"); ok {
+				if gpuTypeStr, err := gpuType.AsString(); err == nil {
+					egg.Resources.GPUType = gpuTypeStr
+				}
+			}
+			if platform, ok := childBlock.GetAttribute("platform"); ok {
+				if platformStr, err := platform.AsString(); err == nil {
+					egg.Resources.Platform = platformStr
+				}
+			}
 		case "runner":
 			if tags, ok := childBlock.GetAttribute("tags"); ok {
 				if tagList, err := tags.AsList(); err == nil {
@@ -193,8 +610,67 @@ func convertToEggConfig(config *parser.Config, name string) (*deployer.EggConfig
 			}
 			if idleTimeout, ok := childBlock.GetAttribute("idle_timeout"); ok {
 				if timeoutStr, err := idleTimeout.AsString(); err == nil {
-					if duration, err := time.ParseDuration(timeoutStr); err == nil {
-						egg.Runner.IdleTimeout = duration
+					duration, err := time.ParseDuration(timeoutStr)
+					if err != nil {
+						return nil, fmt.Errorf("%s: invalid idle_timeout: %w", idleTimeout.Position, err)
+					}
+					egg.Runner.IdleTimeout = duration
+				}
+			}
+			if jobTimeout, ok := childBlock.GetAttribute("job_timeout"); ok {
+				if timeoutStr, err := jobTimeout.AsString(); err == nil {
+					duration, err := time.ParseDuration(timeoutStr)
+					if err != nil {
+						return nil, fmt.Errorf("%s: invalid job_timeout: %w", jobTimeout.Position, err)
+					}
+					egg.Runner.JobTimeout = duration
+				}
+			}
+			if cache, ok := childBlock.GetBlock("cache"); ok {
+				egg.Runner.Cache = &deployer.CacheConfig{}
+				for name, dst := range map[string]*string{
+					"type":               &egg.Runner.Cache.Type,
+					"bucket":             &egg.Runner.Cache.Bucket,
+					"path":               &egg.Runner.Cache.Path,
+					"server_address":     &egg.Runner.Cache.ServerAddress,
+					"credentials_secret": &egg.Runner.Cache.CredentialsSecret,
+				} {
+					if val, ok := cache.GetAttribute(name); ok {
+						if str, err := val.AsString(); err == nil {
+							*dst = str
+						}
+					}
+				}
+				if shared, ok := cache.GetAttribute("shared"); ok {
+					if sharedBool, err := shared.AsBool(); err == nil {
+						egg.Runner.Cache.Shared = sharedBool
+					}
+				}
+			}
+			for name, dst := range map[string]*string{"os": &egg.Runner.OS, "arch": &egg.Runner.Arch} {
+				if val, ok := childBlock.GetAttribute(name); ok {
+					if str, err := val.AsString(); err == nil {
+						*dst = str
+					}
+				}
+			}
+			for name, dst := range map[string]*bool{"privileged": &egg.Runner.Privileged, "docker_dind": &egg.Runner.DockerDinD} {
+				if val, ok := childBlock.GetAttribute(name); ok {
+					if b, err := val.AsBool(); err == nil {
+						*dst = b
+					}
+				}
+			}
+			// Serverless runners ignore image and image_id
+			if egg.Type == deployer.RunnerTypeVM {
+				if image, ok := childBlock.GetAttribute("image"); ok {
+					if imageStr, err := image.AsString(); err == nil {
+						egg.Runner.Image = imageStr
+					}
+				}
+				if imageID, ok := childBlock.GetAttribute("image_id"); ok {
+					if imageIDStr, err := imageID.AsString(); err == nil {
+						egg.Runner.ImageID = imageIDStr
 					}
 				}
 			}
@@ -204,34 +680,119 @@ func convertToEggConfig(config *parser.Config, name string) (*deployer.EggConfig
 					egg.GitLab.ProjectID = projInt
 				}
 			}
+			if groupID, ok := childBlock.GetAttribute("group_id"); ok {
+				if groupInt, err := groupID.AsInt(); err == nil {
+					egg.GitLab.GroupID = groupInt
+				}
+			}
+			if serverName, ok := childBlock.GetAttribute("server_name"); ok {
+				if serverStr, err := serverName.AsString(); err == nil {
+					egg.GitLab.ServerName = serverStr
+				}
+			}
 			if tokenSecret, ok := childBlock.GetAttribute("token_secret"); ok {
 				if tokenStr, err := tokenSecret.AsString(); err == nil {
 					egg.GitLab.TokenSecret = tokenStr
 				}
 			}
+			if caCert, ok := childBlock.GetAttribute("ca_cert"); ok {
+				if caCertStr, err := caCert.AsString(); err == nil {
+					egg.GitLab.CACert = caCertStr
+				}
+			}
 		case "environment":
 			for key, attr := range childBlock.Attributes {
 				if valStr, err := attr.AsString(); err == nil {
 					egg.Environment[key] = valStr
 				}
 			}
+		case "autoscaling":
+			// Serverless runners are scaled by their cloud provider
+			if egg.Type != deployer.RunnerTypeVM {
+				continue
+			}
+			autoscaling := &deployer.AutoscalingConfig{
+				ScaleUpThreshold: parser.DefaultScaleUpThreshold,
+				ScaleDownDelay:   parser.DefaultScaleDownDelay,
+			}
+			for name, dst := range map[string]*int{
+				"min":                &autoscaling.Min,
+				"max":                &autoscaling.Max,
+				"scale_up_threshold": &autoscaling.ScaleUpThreshold,
+			} {
+				if val, ok := childBlock.GetAttribute(name); ok {
+					if n, err := val.AsInt(); err == nil {
+						*dst = n
+					}
+				}
+			}
+			if delay, ok := childBlock.GetAttribute("scale_down_delay"); ok {
+				if delayStr, err := delay.AsString(); err == nil {
+					duration, err := time.ParseDuration(delayStr)
+					if err != nil {
+						return nil, fmt.Errorf("%s: invalid scale_down_delay: %w", delay.Position, err)
+					}
+					autoscaling.ScaleDownDelay = duration
+				}
+			}
+			egg.Autoscaling = autoscaling
+		case "kubernetes":
+			// Only kubernetes runners are deployed to a cluster
+			if egg.Type != deployer.RunnerTypeKubernetes {
+				continue
+			}
+			egg.Kubernetes = &deployer.KubernetesConfig{}
+			for name, dst := range map[string]*string{
+				"namespace":         &egg.Kubernetes.Namespace,
+				"kubeconfig_secret": &egg.Kubernetes.KubeconfigSecret,
+			} {
+				if val, ok := childBlock.GetAttribute(name); ok {
+					if str, err := val.AsString(); err == nil {
+						*dst = str
+					}
+				}
+			}
+			if selector, ok := childBlock.GetAttribute("node_selector"); ok {
+				if labels, err := selector.AsMap(); err == nil {
+					egg.Kubernetes.NodeSelector = make(map[string]string, len(labels))
+					for label, val := range labels {
+						if str, err := val.AsString(); err == nil {
+							egg.Kubernetes.NodeSelector[label] = str
+						}
+					}
+				}
+			}
 		}
 	}
+	// The GPU type decides the platform of a GPU runner
+	if egg.Resources.GPU > 0 && egg.Resources.Platform == "" {
+		egg.Resources.Platform, _ = parser.GPUPlatform(string(egg.Cloud.Provider), egg.Resources.GPUType)
+	}
 	return egg, nil
 }
 
-func deployEgg(ctx context.Context, egg *deployer.EggConfig, provider deployer.CloudProvider, region string, client mothergoose.MotherGooseClient) error {
-	configHash, err := generateConfigHash(egg)
-	if err != nil {
-		return fmt.Errorf("failed to generate hash: %w", err)
+// deployEgg submits the deployment plan of egg to MotherGoose, or only shows it
+// with --dry-run, logging its progress to log
+func deployEgg(ctx context.Context, log *slog.Logger, egg *deployer.EggConfig, provider deployer.CloudProvider, region string, client mothergoose.MotherGooseClient, signingKey ed25519.PrivateKey) (*eggDeployOutput, error) {
+	configHash := deployer.ConfigHash(egg)
+	log.Debug(fmt.Sprintf("Config hash: %s", configHash), "config_hash", configHash)
+
+	result := &eggDeployOutput{
+		EggName:    egg.Name,
+		ConfigHash: configHash,
+		RunnerType: string(egg.Type),
+		Cloud:      string(provider),
+		Region:     region,
+		Resources:  newResourcesOutput(egg.Resources),
 	}
-	fmt.Printf("Config hash: %s\n", configHash)
 
 	// Check if configuration has changed
 	status, err := client.GetEggStatus(ctx, egg.Name)
 	if err == nil && status.LatestPlan != nil && status.LatestPlan.ConfigHash == configHash {
-		fmt.Println("No changes detected")
-		return nil
+		log.Info("No changes detected", "plan_id", status.LatestPlan.ID)
+		result.Status = deployStatusUnchanged
+		result.PlanID = status.LatestPlan.ID
+		return result, nil
 	}
 
 	plan := &deployer.DeploymentPlan{
@@ -240,58 +801,94 @@ func deployEgg(ctx context.Context, egg *deployer.EggConfig, provider deployer.C
 		PlanType:   "runner",
 		ConfigHash: configHash,
 		CreatedAt:  time.Now(),
-		Status:     "pending",
-		Metadata: map[string]interface{}{
-			"runner_type": string(egg.Type),
-			"cloud":       string(provider),
-			"region":      region,
-		},
+		Status:     deployer.PlanStatusPending,
+		Metadata:   triggerMetadata(),
 	}
+	plan.Metadata["runner_type"] = string(egg.Type)
+	plan.Metadata["cloud"] = string(provider)
+	plan.Metadata["region"] = region
+	result.PlanID = plan.ID
 
 	planBinary, err := generatePlanBinary(egg)
 	if err != nil {
-		return fmt.Errorf("failed to generate plan: %w", err)
+		return nil, fmt.Errorf("failed to generate plan: %w", err)
 	}
 	plan.PlanBinary = planBinary
+	if signingKey != nil {
+		signing.SignPlan(plan, signingKey)
+		result.SigningKeyID = plan.SigningKeyID
+	}
 
 	if deployDryRun {
-		fmt.Println("\n--- Deployment Plan (Dry Run) ---")
-		fmt.Printf("Plan ID: %s\n", plan.ID)
-		fmt.Printf("Egg Name: %s\n", plan.EggName)
-		fmt.Printf("Runner Type: %s\n", egg.Type)
-		fmt.Printf("Cloud: %s\n", provider)
-		fmt.Printf("Region: %s\n", region)
-		fmt.Printf("Resources: CPU=%d, Memory=%dMB, Disk=%dGB\n", egg.Resources.CPU, egg.Resources.Memory, egg.Resources.Disk)
-		fmt.Println("\nNo resources will be created")
-		return nil
+		log.Info("--- Deployment Plan (Dry Run) ---")
+		log.Info(fmt.Sprintf("Plan ID: %s", plan.ID))
+		log.Info(fmt.Sprintf("Egg Name: %s", plan.EggName))
+		log.Info(fmt.Sprintf("Runner Type: %s", egg.Type))
+		log.Info(fmt.Sprintf("Cloud: %s", provider))
+		log.Info(fmt.Sprintf("Region: %s", region))
+		log.Info(fmt.Sprintf("Resources: CPU=%d, Memory=%dMB, Disk=%dGB", egg.Resources.CPU, egg.Resources.Memory, egg.Resources.Disk))
+		if egg.Resources.GPU > 0 {
+			log.Info(fmt.Sprintf("GPUs: %d x %s on %s", egg.Resources.GPU, egg.Resources.GPUType, egg.Resources.Platform))
+		}
+		if egg.Kubernetes != nil {
+			log.Info(fmt.Sprintf("Namespace: %s", egg.Kubernetes.Namespace))
+		}
+		if plan.SigningKeyID != "" {
+			log.Info(fmt.Sprintf("Signed by: %s", plan.SigningKeyID))
+		}
+		log.Info("No resources will be created")
+		result.Status = deployStatusPlanned
+		return result, nil
 	}
 
 	// Store Egg configuration via MotherGoose API
 	if err := client.CreateOrUpdateEgg(ctx, egg); err != nil {
-		return fmt.Errorf("failed to store egg configuration: %w", err)
+		return nil, fmt.Errorf("failed to store egg configuration: %w", err)
 	}
-	fmt.Printf("Egg configuration stored successfully\n")
+	log.Info("Egg configuration stored successfully")
 
-	fmt.Println("Deployment applied successfully")
-	return nil
-}
+	registered, err := client.SubmitDeploymentPlan(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	result.PlanID = registered.ID
+	log.Info(fmt.Sprintf("Deployment plan %s submitted", registered.ID), "plan_id", registered.ID)
 
-func generateConfigHash(egg *deployer.EggConfig) (string, error) {
-	configJSON, err := json.Marshal(egg)
+	if _, err := client.ApplyPlan(ctx, egg.Name, registered.ID); err != nil {
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Waiting for plan %s to be applied...", registered.ID), "plan_id", registered.ID, "timeout", deployApplyTimeout)
+	waitCtx, cancel := context.WithTimeout(ctx, deployApplyTimeout)
+	defer cancel()
+	applied, err := mothergoose.WaitForPlan(waitCtx, client, egg.Name, registered.ID, planPollInterval)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	hash := sha256.Sum256(configJSON)
-	return hex.EncodeToString(hash[:]), nil
+
+	log.Info(fmt.Sprintf("Deployment applied successfully (status %s)", applied.Status), "plan_id", applied.ID, "status", applied.Status)
+	result.Status = deployStatusApplied
+	return result, nil
+}
+
+// planContent is the plan binary generated by deploy
+type planContent struct {
+	EggName     string                      `json:"egg_name"`
+	RunnerType  deployer.RunnerType         `json:"runner_type"`
+	Cloud       deployer.CloudConfig        `json:"cloud"`
+	Resources   deployer.ResourceConfig     `json:"resources"`
+	Autoscaling *deployer.AutoscalingConfig `json:"autoscaling,omitempty"`
+	Kubernetes  *deployer.KubernetesConfig  `json:"kubernetes,omitempty"`
+	Timestamp   int64                       `json:"timestamp"`
 }
 
 func generatePlanBinary(egg *deployer.EggConfig) ([]byte, error) {
-	planData := map[string]interface{}{
-		"egg_name":    egg.Name,
-		"runner_type": egg.Type,
-		"cloud":       egg.Cloud,
-		"resources":   egg.Resources,
-		"timestamp":   time.Now().Unix(),
-	}
-	return json.Marshal(planData)
+	return json.Marshal(planContent{
+		EggName:     egg.Name,
+		RunnerType:  egg.Type,
+		Cloud:       egg.Cloud,
+		Resources:   egg.Resources,
+		Autoscaling: egg.Autoscaling,
+		Kubernetes:  egg.Kubernetes,
+		Timestamp:   time.Now().Unix(),
+	})
 }
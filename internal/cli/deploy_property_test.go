@@ -3,8 +3,10 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
@@ -20,11 +22,18 @@ type MockMotherGooseClient struct {
 	GetEggStatusCalls       int
 	ListEggsCalls           int
 	CreateOrUpdateEggCalls  int
+	CreatePlanCalls         int
+	ApplyPlanCalls          int
+	RollbackCalls           int
 	GetDeploymentPlanCalls  int
 	ListDeploymentPlanCalls int
 	EggConfigs              map[string]*deployer.EggConfig
 	EggStatuses             map[string]*mothergoose.EggStatus
 	DeploymentPlans         map[string][]*deployer.DeploymentPlan
+	LogEntries              map[string][]*mothergoose.LogEntry
+	Jobs                    map[string]*deployer.JobConfig
+	UglyFox                 *deployer.UglyFoxConfig
+	TokenRotations          []*mothergoose.TokenRotation
 }
 
 func NewMockMotherGooseClient() *MockMotherGooseClient {
@@ -32,6 +41,8 @@ func NewMockMotherGooseClient() *MockMotherGooseClient {
 		EggConfigs:      make(map[string]*deployer.EggConfig),
 		EggStatuses:     make(map[string]*mothergoose.EggStatus),
 		DeploymentPlans: make(map[string][]*deployer.DeploymentPlan),
+		LogEntries:      make(map[string][]*mothergoose.LogEntry),
+		Jobs:            make(map[string]*deployer.JobConfig),
 	}
 }
 
@@ -64,6 +75,62 @@ func (m *MockMotherGooseClient) CreateOrUpdateEgg(ctx context.Context, config *d
 	return nil
 }
 
+func (m *MockMotherGooseClient) CreateOrUpdateEggs(ctx context.Context, configs []*deployer.EggConfig) error {
+	for _, config := range configs {
+		if err := m.CreateOrUpdateEgg(ctx, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockMotherGooseClient) DeleteEgg(ctx context.Context, eggName string) error {
+	if _, ok := m.EggConfigs[eggName]; !ok {
+		return &mothergoose.HTTPError{StatusCode: 404, Status: "404 Not Found"}
+	}
+	delete(m.EggConfigs, eggName)
+	delete(m.EggStatuses, eggName)
+	delete(m.DeploymentPlans, eggName)
+	return nil
+}
+
+func (m *MockMotherGooseClient) CreateDeploymentPlan(ctx context.Context, plan *deployer.DeploymentPlan) error {
+	m.CreatePlanCalls++
+	m.DeploymentPlans[plan.EggName] = append(m.DeploymentPlans[plan.EggName], plan)
+	return nil
+}
+
+func (m *MockMotherGooseClient) SubmitDeploymentPlan(ctx context.Context, plan *deployer.DeploymentPlan) (*deployer.DeploymentPlan, error) {
+	return plan, m.CreateDeploymentPlan(ctx, plan)
+}
+
+// ApplyPlan applies the plan at once
+func (m *MockMotherGooseClient) ApplyPlan(ctx context.Context, eggName, planID string) (*deployer.DeploymentPlan, error) {
+	m.ApplyPlanCalls++
+	for _, plan := range m.DeploymentPlans[eggName] {
+		if plan.ID == planID {
+			plan.Status = deployer.PlanStatusApplied
+			return plan, nil
+		}
+	}
+	return nil, fmt.Errorf("plan not found")
+}
+
+// RollbackEgg records a rollback plan that is applied at once
+func (m *MockMotherGooseClient) RollbackEgg(ctx context.Context, eggName, targetPlanID string) (*deployer.DeploymentPlan, error) {
+	m.RollbackCalls++
+	plan := &deployer.DeploymentPlan{
+		ID:           fmt.Sprintf("rollback-%d", m.RollbackCalls),
+		EggName:      eggName,
+		PlanType:     "runner",
+		CreatedAt:    time.Now(),
+		Status:       deployer.PlanStatusApplied,
+		RollbackPlan: targetPlanID,
+	}
+	m.DeploymentPlans[eggName] = append(m.DeploymentPlans[eggName], plan)
+	return plan, nil
+}
+
 func (m *MockMotherGooseClient) GetDeploymentPlan(ctx context.Context, eggName, planID string) (*deployer.DeploymentPlan, error) {
 	m.GetDeploymentPlanCalls++
 	if plans, ok := m.DeploymentPlans[eggName]; ok {
@@ -84,6 +151,17 @@ func (m *MockMotherGooseClient) ListDeploymentPlans(ctx context.Context, eggName
 	return []*deployer.DeploymentPlan{}, nil
 }
 
+func (m *MockMotherGooseClient) DeleteDeploymentPlan(ctx context.Context, eggName, planID string) error {
+	plans := m.DeploymentPlans[eggName]
+	for i, plan := range plans {
+		if plan.ID == planID {
+			m.DeploymentPlans[eggName] = append(plans[:i:i], plans[i+1:]...)
+			return nil
+		}
+	}
+	return &mothergoose.HTTPError{StatusCode: 404, Status: "404 Not Found"}
+}
+
 func (m *MockMotherGooseClient) SendHeartbeat(_ context.Context, _ string, _ mothergoose.HeartbeatPayload) error {
 	return nil
 }
@@ -92,6 +170,112 @@ func (m *MockMotherGooseClient) ReportRunnerMetrics(_ context.Context, _ string,
 	return nil
 }
 
+// ListRunners lists the active runners of every Egg status, by Egg name
+func (m *MockMotherGooseClient) ListRunners(ctx context.Context, filter mothergoose.RunnerFilter) ([]*mothergoose.Runner, error) {
+	names := make([]string, 0, len(m.EggStatuses))
+	for name := range m.EggStatuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var runners []*mothergoose.Runner
+	for _, name := range names {
+		for _, runner := range m.EggStatuses[name].ActiveRunners {
+			if filter.Matches(runner) {
+				runners = append(runners, runner)
+			}
+		}
+	}
+	return runners, nil
+}
+
+func (m *MockMotherGooseClient) GetRunner(ctx context.Context, runnerID string) (*mothergoose.Runner, error) {
+	runners, _ := m.ListRunners(ctx, mothergoose.RunnerFilter{})
+	for _, runner := range runners {
+		if runner.ID == runnerID {
+			return runner, nil
+		}
+	}
+	return nil, &mothergoose.HTTPError{StatusCode: 404, Status: "404 Not Found"}
+}
+
+func (m *MockMotherGooseClient) CreateOrUpdateJob(ctx context.Context, job *deployer.JobConfig) error {
+	m.Jobs[job.Name] = job
+	return nil
+}
+
+func (m *MockMotherGooseClient) ListJobs(ctx context.Context) ([]*deployer.JobConfig, error) {
+	names := make([]string, 0, len(m.Jobs))
+	for name := range m.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	jobs := make([]*deployer.JobConfig, 0, len(names))
+	for _, name := range names {
+		jobs = append(jobs, m.Jobs[name])
+	}
+	return jobs, nil
+}
+
+func (m *MockMotherGooseClient) GetUglyFoxConfig(ctx context.Context) (*deployer.UglyFoxConfig, error) {
+	if m.UglyFox == nil {
+		return nil, &mothergoose.HTTPError{StatusCode: 404, Status: "404 Not Found"}
+	}
+	return m.UglyFox, nil
+}
+
+func (m *MockMotherGooseClient) UpdateUglyFoxConfig(ctx context.Context, config *deployer.UglyFoxConfig) error {
+	m.UglyFox = config
+	return nil
+}
+
+func (m *MockMotherGooseClient) RotateEggToken(ctx context.Context, rotation *mothergoose.TokenRotation) error {
+	m.TokenRotations = append(m.TokenRotations, rotation)
+	return nil
+}
+
+func (m *MockMotherGooseClient) PauseRunners(ctx context.Context, eggName, runnerID string) ([]*mothergoose.Runner, error) {
+	return m.setRunnerState(eggName, runnerID, "paused")
+}
+
+func (m *MockMotherGooseClient) ResumeRunners(ctx context.Context, eggName, runnerID string) ([]*mothergoose.Runner, error) {
+	return m.setRunnerState(eggName, runnerID, "active")
+}
+
+func (m *MockMotherGooseClient) DrainRunners(ctx context.Context, eggName, runnerID string) ([]*mothergoose.Runner, error) {
+	return m.setRunnerState(eggName, runnerID, "draining")
+}
+
+// setRunnerState changes the state of the Egg's matching active runners
+func (m *MockMotherGooseClient) setRunnerState(eggName, runnerID, state string) ([]*mothergoose.Runner, error) {
+	status, ok := m.EggStatuses[eggName]
+	if !ok {
+		return nil, fmt.Errorf("egg not found")
+	}
+	var changed []*mothergoose.Runner
+	for _, runner := range status.ActiveRunners {
+		if runnerID == "" || runner.ID == runnerID {
+			runner.State = state
+			changed = append(changed, runner)
+		}
+	}
+	if runnerID != "" && len(changed) == 0 {
+		return nil, fmt.Errorf("runner not found")
+	}
+	return changed, nil
+}
+
+func (m *MockMotherGooseClient) StreamLogs(ctx context.Context, eggName string, opts mothergoose.LogStreamOptions, handle func(*mothergoose.LogEntry) error) error {
+	for _, entry := range m.LogEntries[eggName] {
+		if opts.RunnerID != "" && entry.RunnerID != opts.RunnerID {
+			continue
+		}
+		if err := handle(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Feature: gitops-runner-orchestration, Property 24: Dry-Run Non-Modification
 // Validates: Requirements 10.8
 func TestDryRunNonModification(t *testing.T) {
@@ -140,7 +324,7 @@ func TestDryRunNonModification(t *testing.T) {
 				ctx := context.Background()
 
 				// Parse the egg configs
-				eggs, err := parseEggConfigs(eggsDir)
+				eggs, err := parseEggConfigs(eggsDir, "")
 				if err != nil {
 					t.Logf("Failed to parse egg configs: %v", err)
 					return false
@@ -158,7 +342,7 @@ func TestDryRunNonModification(t *testing.T) {
 
 				// Execute deployment with dry-run
 				for _, egg := range eggs {
-					if err := deployEgg(ctx, egg, cloudProvider, region, mockClient); err != nil {
+					if _, err := deployEgg(ctx, newLogger(io.Discard, "deploy"), egg, cloudProvider, region, mockClient, nil); err != nil {
 						t.Logf("Deploy failed: %v", err)
 						return false
 					}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func TestParseEggConfigsExpandsRepeatedEggs(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", policyEggConfig)
+	writeNestFile(t, root, "Eggs/workers/config.fly", strings.Replace(policyEggConfig, `egg "my-app" {`, "egg \"worker-${count.index}\" {\n  count = 2\n", 1))
+
+	eggs, err := parseEggConfigs(filepath.Join(root, "Eggs"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, egg := range eggs {
+		names = append(names, egg.Name)
+	}
+	if strings.Join(names, ",") != "my-app,worker-0,worker-1" {
+		t.Errorf("expected my-app and two workers, got %v", names)
+	}
+
+	// A single instance keeps its label, so scaling to 2 does not rename it
+	for header, want := range map[string]string{
+		"egg \"worker-${count.index}\" {\n  count = 1\n":       "worker-0",
+		"egg \"worker-${each.key}\" {\n  for_each = [\"a\"]\n": "worker-a",
+	} {
+		writeNestFile(t, root, "Eggs/workers/config.fly", strings.Replace(policyEggConfig, `egg "my-app" {`, header, 1))
+		eggs, err := parseEggConfigs(filepath.Join(root, "Eggs"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(eggs) != 2 || eggs[1].Name != want {
+			t.Errorf("expected the single instance named %s, got %v", want, eggs)
+		}
+	}
+}
+
+func TestParseEggConfigsJobTimeout(t *testing.T) {
+	root := t.TempDir()
+	serverless := strings.Replace(policyEggConfig, `type = "vm"`, `type = "serverless"`, 1)
+	writeNestFile(t, root, "Eggs/my-app/config.fly", serverless)
+	writeNestFile(t, root, "Eggs/timed/config.fly", strings.NewReplacer(`egg "my-app"`, `egg "timed"`, "concurrent = 2", "concurrent = 2\n    job_timeout = \"20m\"").Replace(serverless))
+
+	eggs, err := parseEggConfigs(filepath.Join(root, "Eggs"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eggs[0].Runner.JobTimeout != 0 || eggs[1].Runner.JobTimeout != 20*time.Minute {
+		t.Fatalf("expected job timeouts 0 and 20m, got %s and %s", eggs[0].Runner.JobTimeout, eggs[1].Runner.JobTimeout)
+	}
+
+	// Only the name and job_timeout differ, so the job timeout must be hashed
+	eggs[1].Name = eggs[0].Name
+	if deployer.ConfigHash(eggs[0]) == deployer.ConfigHash(eggs[1]) {
+		t.Error("changing job_timeout did not change the config hash")
+	}
+}
+
+// generatedEggJSON is an Egg written in the JSON syntax, as tools generate them
+const generatedEggJSON = `{
+  "egg": {
+    "generated": {
+      "type": "vm",
+      "cloud": {"provider": "yandex", "region": "ru-central1-a"},
+      "resources": {"cpu": 2, "memory": 4096, "disk": 20},
+      "runner": {"tags": ["docker"], "concurrent": 2},
+      "gitlab": {
+        "project_id": 12345,
+        "server_name": "gitlab.com",
+        "token_secret": "yc-lockbox://gitlab/runner-token"
+      }
+    }
+  }
+}`
+
+func TestParseEggConfigsReadsJSON(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/generated/config.fly.json", generatedEggJSON)
+
+	eggs, err := parseEggConfigs(filepath.Join(root, "Eggs"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eggs) != 1 || eggs[0].Name != "generated" {
+		t.Fatalf("expected the generated egg, got %v", eggs)
+	}
+
+	files, err := findFlyFiles(root)
+	if err != nil || len(files) != 1 {
+		t.Errorf("expected validate to find config.fly.json, got %v, %v", files, err)
+	}
+}
+
+const testJobConfig = `job "rotate-secrets" {
+  schedule = "0 2 * * *"
+
+  runner {
+    type = "vm"
+    tags = ["privileged"]
+  }
+
+  script = "gosling rotate-tokens --all"
+}
+`
+
+func TestParseJobConfigs(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Jobs/rotate-secrets.fly", testJobConfig)
+	writeNestFile(t, root, "Jobs/rotate-secrets.prod.fly", `job "rotate-secrets" {
+  schedule = "0 3 * * *"
+}
+`)
+
+	jobs, err := parseJobConfigs(filepath.Join(root, "Jobs"), "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("expected one job, got %v", jobs)
+	}
+	job := jobs[0]
+	if job.Name != "rotate-secrets" || job.Schedule != "0 3 * * *" || job.Runner.Type != deployer.RunnerTypeVM ||
+		strings.Join(job.Runner.Tags, ",") != "privileged" || job.Script != "gosling rotate-tokens --all" {
+		t.Errorf("unexpected job %+v", job)
+	}
+
+	if jobs, err := parseJobConfigs(filepath.Join(t.TempDir(), "Jobs"), ""); err != nil || len(jobs) != 0 {
+		t.Errorf("expected no jobs without a Jobs directory, got %v, %v", jobs, err)
+	}
+}
+
+func TestDeployJobs(t *testing.T) {
+	client := NewMockMotherGooseClient()
+	unchanged := &deployer.JobConfig{Name: "cleanup", Schedule: "0 * * * *", Script: "true"}
+	client.Jobs["cleanup"] = unchanged
+	jobs := []*deployer.JobConfig{
+		{Name: "cleanup", Schedule: "0 * * * *", Script: "true"},
+		{Name: "rotate-secrets", Schedule: "0 2 * * *", Script: "gosling rotate-tokens --all"},
+	}
+	log := newLogger(io.Discard, "deploy")
+
+	results, err := deployJobs(context.Background(), log, jobs, client, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != deployStatusUnchanged || results[1].Status != deployStatusPlanned {
+		t.Errorf("expected unchanged and planned, got %s and %s", results[0].Status, results[1].Status)
+	}
+	if _, ok := client.Jobs["rotate-secrets"]; ok {
+		t.Error("dry run registered a job")
+	}
+
+	results, err = deployJobs(context.Background(), log, jobs, client, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[1].Status != deployStatusApplied || client.Jobs["rotate-secrets"] == nil {
+		t.Errorf("expected rotate-secrets to be registered, got %s", results[1].Status)
+	}
+	if client.Jobs["cleanup"] != unchanged {
+		t.Error("unchanged job was sent again")
+	}
+
+	status, err := listJobStatus(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 || status[0].JobName != "cleanup" || status[1].Schedule != "0 2 * * *" {
+		t.Errorf("unexpected job status %+v", status)
+	}
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/git"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffGitRev  string
+	diffUglyFox bool
+	diffEnv     string
+	diffAPIURL  string
+	diffAPIKey  string
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [old.fly new.fly | file...]",
+	Short: "Show attribute-level differences between .fly configurations",
+	Long: `Compare two versions of a .fly configuration structurally.
+
+Unlike a text diff, formatting and attribute order are ignored and each
+change is reported as an attribute path, e.g.
+
+  egg "my-app"
+    ~ resources.memory: 4096 → 8192
+
+With two file arguments, the first file is compared against the second.
+With --git, the given files (or, without arguments, every .fly file changed
+since the revision) are compared against their content at that revision.
+With --uglyfox, the Nest's UF/config.fly (with the overlay of --env) is
+compared against the UglyFox configuration MotherGoose applies.
+
+Example:
+  gosling diff old.fly Eggs/my-app/config.fly
+  gosling diff --git HEAD~1
+  gosling diff --git main Eggs/my-app/config.fly
+  gosling diff --uglyfox --env prod --api-url ... --api-key ...`,
+	RunE: runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVar(&diffGitRev, "git", "", "Compare against this git revision (e.g. HEAD~1)")
+	diffCmd.Flags().BoolVar(&diffUglyFox, "uglyfox", false, "Compare UF/config.fly against the UglyFox configuration deployed to MotherGoose")
+	diffCmd.Flags().StringVar(&diffEnv, "env", "", "Environment overlay to apply with --uglyfox (e.g. prod for config.prod.fly)")
+	diffCmd.Flags().StringVar(&diffAPIURL, "api-url", "", "MotherGoose API URL")
+	diffCmd.Flags().StringVar(&diffAPIKey, "api-key", "", "MotherGoose API key")
+}
+
+// diffOutput is the machine-readable result of `gosling diff`
+type diffOutput struct {
+	Files []*fileDiffOutput `json:"files"`
+}
+
+// fileDiffOutput lists the changes found in a single file
+type fileDiffOutput struct {
+	Path    string          `json:"path"`
+	Changes []parser.Change `json:"changes"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	var report *diffOutput
+	var err error
+	if diffUglyFox {
+		if diffGitRev != "" || len(args) > 0 {
+			return fmt.Errorf("--uglyfox cannot be combined with --git or files")
+		}
+		report, err = diffDeployedUglyFox(cmd)
+	} else if diffGitRev != "" {
+		report, err = diffAgainstGit(diffGitRev, args)
+	} else {
+		if len(args) != 2 {
+			return fmt.Errorf("expected two files to compare, or --git <revision>")
+		}
+		report, err = diffFiles(args[0], args[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, report)
+	}
+	printDiff(os.Stdout, report)
+	return nil
+}
+
+// diffDeployedUglyFox compares the Nest's UF/config.fly with the UglyFox
+// configuration deployed to MotherGoose
+func diffDeployedUglyFox(cmd *cobra.Command) (*diffOutput, error) {
+	if diffEnv != "" && !parser.IsValidEnvironmentName(diffEnv) {
+		return nil, fmt.Errorf("invalid environment name %q", diffEnv)
+	}
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+	desired, err := parseUglyFoxConfig(filepath.Join(nestRoot, "UF"), diffEnv)
+	if err != nil {
+		return nil, err
+	}
+	if desired == nil {
+		return nil, fmt.Errorf("no UglyFox configuration found in %s", filepath.Join(nestRoot, "UF"))
+	}
+	conn, err := resolveAPI(diffAPIURL, diffAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	current, err := deployedUglyFoxConfig(commandContext(cmd), conn.client())
+	if err != nil {
+		return nil, err
+	}
+	return &diffOutput{Files: []*fileDiffOutput{{
+		Path:    filepath.Join("UF", "config.fly"),
+		Changes: diffUglyFoxConfigs(current, desired),
+	}}}, nil
+}
+
+// diffFiles compares two .fly files on disk
+func diffFiles(oldPath, newPath string) (*diffOutput, error) {
+	oldConfig, err := parser.NewParser().ParseFile(oldPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", oldPath, err)
+	}
+	newConfig, err := parser.NewParser().ParseFile(newPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", newPath, err)
+	}
+
+	return &diffOutput{Files: []*fileDiffOutput{{
+		Path:    newPath,
+		Changes: emptyIfNil(parser.Diff(oldConfig, newConfig)),
+	}}}, nil
+}
+
+// diffAgainstGit compares files in the working tree with their content at rev.
+// Without files, every .fly file changed since rev is compared.
+func diffAgainstGit(rev string, files []string) (*diffOutput, error) {
+	if _, err := git.Run(".", "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown git revision %q", rev)
+	}
+
+	if len(files) == 0 {
+		out, err := git.Run(".", "diff", "--name-only", "--relative", rev, "--", "*.fly", "*"+parser.JSONExtension)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list changed files: %w", err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if line != "" {
+				files = append(files, filepath.FromSlash(line))
+			}
+		}
+	}
+
+	report := &diffOutput{Files: make([]*fileDiffOutput, 0, len(files))}
+	for _, file := range files {
+		oldConfig, err := parseGitRevision(rev, file)
+		if err != nil {
+			return nil, err
+		}
+
+		var newConfig *parser.Config
+		if _, err := os.Stat(file); err == nil {
+			newConfig, err = parser.NewParser().ParseFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+		} else if oldConfig == nil {
+			return nil, fmt.Errorf("%s exists neither in the working tree nor at %s", file, rev)
+		}
+
+		report.Files = append(report.Files, &fileDiffOutput{
+			Path:    file,
+			Changes: emptyIfNil(parser.Diff(oldConfig, newConfig)),
+		})
+	}
+	return report, nil
+}
+
+// parseGitRevision parses file as it was at rev. It returns a nil config if
+// the file did not exist at that revision.
+func parseGitRevision(rev, file string) (*parser.Config, error) {
+	dir, name := filepath.Split(file)
+	if dir == "" {
+		dir = "."
+	}
+	object := rev + ":./" + name
+	if _, err := git.Run(dir, "cat-file", "-e", object); err != nil {
+		return nil, nil
+	}
+	content, err := git.Run(dir, "show", object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w", file, rev, err)
+	}
+
+	// A separate parser is used because hclparse caches files by name
+	config, err := parser.NewParser().Parse(content, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s at %s: %w", file, rev, err)
+	}
+	return config, nil
+}
+
+// printDiff prints changes grouped by file and top-level block
+func printDiff(w io.Writer, report *diffOutput) {
+	if len(report.Files) == 0 {
+		fmt.Fprintln(w, "✅ No .fly files changed")
+		return
+	}
+	for _, file := range report.Files {
+		fmt.Fprintf(w, "📄 %s\n", file.Path)
+		if len(file.Changes) == 0 {
+			fmt.Fprintln(w, "   ✅ No changes")
+			fmt.Fprintln(w)
+			continue
+		}
+		block := ""
+		for _, change := range file.Changes {
+			if change.Block != block {
+				block = change.Block
+				fmt.Fprintf(w, "   %s\n", block)
+			}
+			fmt.Fprintf(w, "     %s\n", change)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func emptyIfNil(changes []parser.Change) []parser.Change {
+	if changes == nil {
+		return []parser.Change{}
+	}
+	return changes
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/git"
+)
+
+const diffBaseConfig = `egg "my-app" {
+  type = "vm"
+
+  resources {
+    cpu    = 2
+    memory = 4096
+  }
+}
+`
+
+func TestDiffFiles(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.fly")
+	newPath := filepath.Join(dir, "new.fly")
+	if err := os.WriteFile(oldPath, []byte(diffBaseConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newPath, []byte(strings.Replace(diffBaseConfig, "4096", "8192", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := diffFiles(oldPath, newPath)
+	if err != nil {
+		t.Fatalf("diffFiles failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	printDiff(&buf, report)
+	out := buf.String()
+	if !strings.Contains(out, `egg "my-app"`) || !strings.Contains(out, "~ resources.memory: 4096 → 8192") {
+		t.Errorf("unexpected diff output:\n%s", out)
+	}
+}
+
+func TestDiffAgainstGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	runGit := func(args ...string) {
+		t.Helper()
+		if _, err := git.Run(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join("Eggs", "my-app", "config.fly")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte(diffBaseConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit("init", "-q")
+	runGit("add", "-A")
+	runGit("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-qm", "initial")
+
+	// Reformat and change one value; add a new job
+	changed := strings.Replace(diffBaseConfig, "cpu    = 2", "cpu = 4", 1)
+	if err := os.WriteFile(configPath, []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jobPath := filepath.Join("Jobs", "backup.fly")
+	if err := os.MkdirAll(filepath.Dir(jobPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(jobPath, []byte("job \"backup\" {\n  schedule = \"0 2 * * *\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit("add", "-A")
+
+	report, err := diffAgainstGit("HEAD", nil)
+	if err != nil {
+		t.Fatalf("diffAgainstGit failed: %v", err)
+	}
+	if len(report.Files) != 2 {
+		t.Fatalf("expected 2 changed files, got %d", len(report.Files))
+	}
+
+	byPath := make(map[string]*fileDiffOutput)
+	for _, file := range report.Files {
+		byPath[file.Path] = file
+	}
+	egg := byPath[configPath]
+	if egg == nil || len(egg.Changes) != 1 || egg.Changes[0].String() != "~ resources.cpu: 2 → 4" {
+		t.Errorf("unexpected changes for %s: %+v", configPath, egg)
+	}
+	job := byPath[jobPath]
+	if job == nil || len(job.Changes) != 1 || job.Changes[0].String() != `+ schedule: "0 2 * * *"` {
+		t.Errorf("unexpected changes for %s: %+v", jobPath, job)
+	}
+
+	if _, err := diffAgainstGit("no-such-rev", nil); err == nil {
+		t.Error("expected error for unknown revision")
+	}
+}
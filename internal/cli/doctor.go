@@ -0,0 +1,530 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/gitlab"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorAPIURL      string
+	doctorAPIKey      string
+	doctorGitLabURL   string
+	doctorGitLabToken string
+	doctorTimeout     time.Duration
+	doctorGitLabConn  gitlabConnFlags
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the local environment",
+	Long: `Check that the environment is ready to manage runners and print a
+pass/fail report with a remediation hint for every failed check.
+
+Checks:
+  - Nest structure and Egg configurations
+  - Network access to the MotherGoose API URL
+  - MotherGoose API version compatibility with this gosling
+  - The client's circuit breaker for MotherGoose (server errors seen)
+  - Cloud credentials for the providers used by the Eggs
+  - GitLab token validity (--gitlab-token or GITLAB_TOKEN)
+  - Reachability of the secret backends referenced by token_secret
+
+Checks whose inputs are missing (e.g. no API URL in the flags, environment
+or profile) are skipped.
+The command exits with an error when any check fails.
+
+Example:
+  gosling doctor
+  gosling doctor --api-url https://mothergoose.example.com --api-key $KEY
+  gosling doctor --gitlab-url https://gitlab.example.com -o json`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringVar(&doctorAPIURL, "api-url", "", "MotherGoose API URL")
+	doctorCmd.Flags().StringVar(&doctorAPIKey, "api-key", "", "MotherGoose API key")
+	doctorCmd.Flags().StringVar(&doctorGitLabURL, "gitlab-url", "https://gitlab.com", "GitLab server URL")
+	doctorCmd.Flags().StringVar(&doctorGitLabToken, "gitlab-token", "", "GitLab token to verify (default: $GITLAB_TOKEN)")
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 5*time.Second, "Timeout for each network check")
+	doctorGitLabConn.register(doctorCmd)
+}
+
+// Outcomes of a doctor check
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// doctorCheck is the outcome of a single diagnostic
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// doctorOutput is the machine-readable result of `gosling doctor`
+type doctorOutput struct {
+	Checks []*doctorCheck `json:"checks"`
+	Passed int            `json:"passed"`
+	Failed int            `json:"failed"`
+}
+
+// nestTarget is a runner host (egg or eggsbucket) found in the Nest
+type nestTarget struct {
+	provider string
+	region   string
+	secrets  []string
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+
+	conn, err := resolveConnection(doctorAPIURL, doctorAPIKey, "", "")
+	if err != nil {
+		return err
+	}
+
+	nestCheck, targets := checkNest()
+	checks := []*doctorCheck{nestCheck}
+	checks = append(checks, checkAPI(ctx, conn, doctorTimeout)...)
+	checks = append(checks, checkCloudCredentials(targets)...)
+
+	token := doctorGitLabToken
+	if token == "" {
+		token = os.Getenv("GITLAB_TOKEN")
+	}
+	checks = append(checks, checkGitLabToken(ctx, doctorGitLabURL, token, &doctorGitLabConn, doctorTimeout))
+	checks = append(checks, checkSecretBackends(targets, doctorTimeout)...)
+
+	out := &doctorOutput{Checks: checks}
+	for _, check := range checks {
+		switch check.Status {
+		case checkPass:
+			out.Passed++
+		case checkFail:
+			out.Failed++
+		}
+	}
+
+	if isStructuredOutput() {
+		if err := writeStructured(os.Stdout, out); err != nil {
+			return err
+		}
+	} else {
+		printDoctorReport(out)
+	}
+
+	if out.Failed > 0 {
+		return fmt.Errorf("%d check(s) failed", out.Failed)
+	}
+	return nil
+}
+
+func printDoctorReport(out *doctorOutput) {
+	icons := map[string]string{checkPass: "✅", checkWarn: "⚠️ ", checkFail: "❌", checkSkip: "⏭️ "}
+	for _, check := range out.Checks {
+		fmt.Printf("%s %s: %s\n", icons[check.Status], check.Name, check.Message)
+		if check.Hint != "" && check.Status != checkPass {
+			fmt.Printf("   → %s\n", check.Hint)
+		}
+	}
+	fmt.Printf("\n%d passed, %d failed\n", out.Passed, out.Failed)
+}
+
+// checkNest verifies the Nest layout and that every Egg configuration is
+// valid, returning the runner hosts it defines
+func checkNest() (*doctorCheck, []nestTarget) {
+	check := &doctorCheck{Name: "nest"}
+	root, err := findNestRoot()
+	if err != nil {
+		check.Status = checkFail
+		check.Message = "no Nest repository found (needs Eggs/, Jobs/ and UF/)"
+		check.Hint = "run gosling from inside a Nest or create one with 'gosling init'"
+		return check, nil
+	}
+
+	dirs, err := listEggDirs(filepath.Join(root, "Eggs"))
+	if err != nil {
+		check.Status = checkFail
+		check.Message = err.Error()
+		return check, nil
+	}
+
+	var targets []nestTarget
+	var invalid []string
+	p := parser.NewParser()
+	for _, dir := range dirs {
+		config, err := p.ParseFile(dir.ConfigPath)
+		if err != nil {
+			invalid = append(invalid, dir.Name)
+			continue
+		}
+		if result := parser.NewValidator(config).Validate(); !result.IsValid() {
+			invalid = append(invalid, dir.Name)
+			continue
+		}
+		targets = append(targets, nestTargets(config)...)
+	}
+
+	if len(invalid) > 0 {
+		check.Status = checkFail
+		check.Message = fmt.Sprintf("invalid Egg configurations: %s", strings.Join(invalid, ", "))
+		check.Hint = "run 'gosling validate' for details"
+		return check, targets
+	}
+	if _, err := os.Stat(filepath.Join(root, "UF", "config.fly")); err != nil {
+		check.Status = checkWarn
+		check.Message = fmt.Sprintf("%s has %d runner host(s) but no UF/config.fly", root, len(targets))
+		check.Hint = "add UglyFox policies with 'gosling add uglyfox'"
+		return check, targets
+	}
+	check.Status = checkPass
+	check.Message = fmt.Sprintf("%s has %d runner host(s)", root, len(targets))
+	return check, targets
+}
+
+// nestTargets extracts the egg and eggsbucket blocks of a config
+func nestTargets(config *parser.Config) []nestTarget {
+	var targets []nestTarget
+	for i := range config.Blocks {
+		block := &config.Blocks[i]
+		if block.Type != "egg" && block.Type != "eggsbucket" {
+			continue
+		}
+		var target nestTarget
+		if cloud, ok := block.GetBlock("cloud"); ok {
+			target.provider = stringAttr(cloud, "provider")
+			target.region = stringAttr(cloud, "region")
+		}
+		gitlabBlocks := block.GetBlocks("gitlab")
+		if repositories, ok := block.GetBlock("repositories"); ok {
+			for _, repo := range repositories.GetBlocks("repo") {
+				gitlabBlocks = append(gitlabBlocks, repo.GetBlocks("gitlab")...)
+			}
+		}
+		for i := range gitlabBlocks {
+			if secret := stringAttr(&gitlabBlocks[i], "token_secret"); secret != "" {
+				target.secrets = append(target.secrets, secret)
+			}
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func stringAttr(block *parser.Block, name string) string {
+	value, ok := block.GetAttribute(name)
+	if !ok {
+		return ""
+	}
+	s, _ := value.AsString()
+	return s
+}
+
+// checkAPI verifies network access to the MotherGoose API, that its API
+// version is compatible with this gosling and that it is not failing
+func checkAPI(ctx context.Context, conn *connection, timeout time.Duration) []*doctorCheck {
+	network := &doctorCheck{Name: "mothergoose network"}
+	version := &doctorCheck{Name: "mothergoose version"}
+	circuit := &doctorCheck{Name: "mothergoose circuit"}
+	if conn.APIURL == "" {
+		network.Status, version.Status, circuit.Status = checkSkip, checkSkip, checkSkip
+		network.Message = "no API URL configured"
+		version.Message = "no API URL configured"
+		circuit.Message = "no API URL configured"
+		return []*doctorCheck{network, version, circuit}
+	}
+
+	address, err := dialAddress(conn.APIURL)
+	if err == nil {
+		err = dial(address, timeout)
+	}
+	if err != nil {
+		network.Status = checkFail
+		network.Message = err.Error()
+		network.Hint = "check the API URL and any proxy or firewall between you and MotherGoose"
+		version.Status, circuit.Status = checkSkip, checkSkip
+		version.Message = "MotherGoose is unreachable"
+		circuit.Message = "MotherGoose is unreachable"
+		return []*doctorCheck{network, version, circuit}
+	}
+	network.Status = checkPass
+	network.Message = fmt.Sprintf("%s is reachable", address)
+
+	client := conn.client(mothergoose.WithTimeout(timeout), mothergoose.WithMaxRetries(0))
+	server, err := client.GetServerVersion(ctx)
+	switch {
+	case err != nil:
+		version.Status = checkFail
+		version.Message = err.Error()
+		version.Hint = "check --api-key and that the URL points at the MotherGoose API"
+	case !server.IsCompatible():
+		version.Status = checkFail
+		version.Message = fmt.Sprintf("gosling %s speaks API v%d, MotherGoose %s serves API %s",
+			Version, mothergoose.APIMajorVersion, server.Version, server.APIVersion)
+		version.Hint = "upgrade gosling or MotherGoose so both use the same API major version"
+	default:
+		version.Status = checkPass
+		version.Message = fmt.Sprintf("gosling %s, MotherGoose %s (API %s)", Version, server.Version, server.APIVersion)
+	}
+
+	breaker := client.CircuitStatus()
+	switch {
+	case breaker.State != mothergoose.CircuitClosed:
+		circuit.Status = checkFail
+		circuit.Message = fmt.Sprintf("circuit %s after %d consecutive server errors", breaker.State, breaker.Failures)
+		circuit.Hint = "MotherGoose is failing; gosling refuses requests until " + breaker.RetryAt.Format(time.RFC3339)
+	case breaker.Failures > 0:
+		circuit.Status = checkWarn
+		circuit.Message = fmt.Sprintf("circuit closed, %d consecutive server error(s)", breaker.Failures)
+		circuit.Hint = fmt.Sprintf("gosling stops sending requests to MotherGoose after %d consecutive server errors",
+			mothergoose.DefaultCircuitBreakerPolicy.Threshold)
+	default:
+		circuit.Status = checkPass
+		circuit.Message = "circuit closed"
+	}
+	return []*doctorCheck{network, version, circuit}
+}
+
+// cloudCredentialSource lists where a provider's SDK looks for credentials
+type cloudCredentialSource struct {
+	envs  [][]string // each entry is a set of variables that must all be set
+	files []string   // relative to the home directory
+	hint  string
+}
+
+var cloudCredentialSources = map[string]cloudCredentialSource{
+	parser.ProviderAWS: {
+		envs:  [][]string{{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"}, {"AWS_PROFILE"}, {"AWS_WEB_IDENTITY_TOKEN_FILE"}},
+		files: []string{".aws/credentials", ".aws/config"},
+		hint:  "set AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY or run 'aws configure'",
+	},
+	parser.ProviderYandex: {
+		envs:  [][]string{{"YC_TOKEN"}, {"YC_IAM_TOKEN"}, {"YC_SERVICE_ACCOUNT_KEY_FILE"}},
+		files: []string{".config/yandex-cloud/config.yaml"},
+		hint:  "set YC_TOKEN or run 'yc init'",
+	},
+	parser.ProviderAzure: {
+		envs:  [][]string{{"AZURE_CLIENT_ID", "AZURE_TENANT_ID"}},
+		files: []string{".azure/azureProfile.json"},
+		hint:  "set AZURE_CLIENT_ID/AZURE_TENANT_ID or run 'az login'",
+	},
+}
+
+// checkCloudCredentials looks for credentials of every provider used in the
+// Nest. Without targets AWS and Yandex Cloud are checked as warnings only.
+func checkCloudCredentials(targets []nestTarget) []*doctorCheck {
+	used := map[string]bool{}
+	for _, target := range targets {
+		if _, ok := cloudCredentialSources[target.provider]; ok {
+			used[target.provider] = true
+		}
+	}
+	missing := checkFail
+	if len(used) == 0 {
+		used[parser.ProviderAWS] = true
+		used[parser.ProviderYandex] = true
+		missing = checkWarn
+	}
+
+	providers := make([]string, 0, len(used))
+	for provider := range used {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	home, _ := os.UserHomeDir()
+	checks := make([]*doctorCheck, 0, len(providers))
+	for _, provider := range providers {
+		check := &doctorCheck{Name: provider + " credentials"}
+		if source := findCredentials(cloudCredentialSources[provider], home); source != "" {
+			check.Status = checkPass
+			check.Message = "found in " + source
+		} else {
+			check.Status = missing
+			check.Message = "no credentials found"
+			check.Hint = cloudCredentialSources[provider].hint
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// findCredentials returns where credentials were found, or "" if nowhere
+func findCredentials(source cloudCredentialSource, home string) string {
+	for _, vars := range source.envs {
+		found := true
+		for _, name := range vars {
+			if os.Getenv(name) == "" {
+				found = false
+				break
+			}
+		}
+		if found {
+			return "$" + strings.Join(vars, ", $")
+		}
+	}
+	if home == "" {
+		return ""
+	}
+	for _, file := range source.files {
+		if _, err := os.Stat(filepath.Join(home, file)); err == nil {
+			return "~/" + file
+		}
+	}
+	return ""
+}
+
+// checkGitLabToken verifies the GitLab token by looking up its user
+func checkGitLabToken(ctx context.Context, serverURL, token string, conn *gitlabConnFlags, timeout time.Duration) *doctorCheck {
+	check := &doctorCheck{Name: "gitlab token"}
+	if token == "" {
+		check.Status = checkSkip
+		check.Message = "no --gitlab-token or GITLAB_TOKEN given"
+		return check
+	}
+
+	opts, err := conn.options(ctx, "")
+	if err != nil {
+		check.Status = checkFail
+		check.Message = err.Error()
+		check.Hint = "check --gitlab-ca-cert, --gitlab-client-cert and --gitlab-client-key"
+		return check
+	}
+	client, err := gitlab.NewClient(serverURL, token, opts...)
+	if err != nil {
+		check.Status = checkFail
+		check.Message = err.Error()
+		check.Hint = "check --gitlab-url and --gitlab-proxy"
+		return check
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	username, err := client.CurrentUser(ctx)
+	if err != nil {
+		check.Status = checkFail
+		check.Message = err.Error()
+		check.Hint = "create a token with the api and create_runner scopes and check that it has not expired"
+		return check
+	}
+	check.Status = checkPass
+	check.Message = fmt.Sprintf("authenticated as %s on %s", username, serverURL)
+	return check
+}
+
+// checkSecretBackends checks network access to every secret backend
+// referenced by a token_secret in the Nest
+func checkSecretBackends(targets []nestTarget, timeout time.Duration) []*doctorCheck {
+	endpoints := map[string]string{}
+	var failures []*doctorCheck
+	for _, target := range targets {
+		for _, secret := range target.secrets {
+			endpoint, err := secretEndpoint(secret, target)
+			if err != nil {
+				failures = append(failures, &doctorCheck{
+					Name:    "secret backend",
+					Status:  checkFail,
+					Message: err.Error(),
+					Hint:    "use yc-lockbox://, aws-sm://, azure-kv:// or vault:// with VAULT_ADDR set",
+				})
+				continue
+			}
+			endpoints[endpoint] = strings.SplitN(secret, "://", 2)[0]
+		}
+	}
+
+	addresses := make([]string, 0, len(endpoints))
+	for address := range endpoints {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	checks := make([]*doctorCheck, 0, len(addresses)+len(failures))
+	for _, address := range addresses {
+		check := &doctorCheck{Name: endpoints[address] + " secret backend"}
+		if err := dial(address, timeout); err != nil {
+			check.Status = checkFail
+			check.Message = err.Error()
+			check.Hint = "allow outbound HTTPS to " + address
+		} else {
+			check.Status = checkPass
+			check.Message = fmt.Sprintf("%s is reachable", address)
+		}
+		checks = append(checks, check)
+	}
+	if len(checks) == 0 && len(failures) == 0 {
+		checks = append(checks, &doctorCheck{Name: "secret backend", Status: checkSkip, Message: "no token_secret in the Nest"})
+	}
+	return append(checks, failures...)
+}
+
+// secretEndpoint returns the host:port serving a secret URI
+func secretEndpoint(secret string, target nestTarget) (string, error) {
+	scheme, rest, ok := strings.Cut(secret, "://")
+	if !ok {
+		return "", fmt.Errorf("token_secret %q is not a secret URI", secret)
+	}
+	switch scheme {
+	case "yc-lockbox":
+		return "payload.lockbox.api.cloud.yandex.net:443", nil
+	case "aws-sm":
+		region := target.region
+		if target.provider != parser.ProviderAWS || region == "" {
+			region = "us-east-1"
+		}
+		return fmt.Sprintf("secretsmanager.%s.amazonaws.com:443", region), nil
+	case "azure-kv":
+		vault := strings.SplitN(rest, "/", 2)[0]
+		return vault + ".vault.azure.net:443", nil
+	case "vault":
+		addr := os.Getenv("VAULT_ADDR")
+		if addr == "" {
+			return "", fmt.Errorf("token_secret %q uses Vault but VAULT_ADDR is not set", secret)
+		}
+		return dialAddress(addr)
+	default:
+		return "", fmt.Errorf("token_secret %q uses unsupported scheme %s://", secret, scheme)
+	}
+}
+
+// dialAddress returns the host:port of an http(s) URL
+func dialAddress(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid URL %q", rawURL)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	port := "443"
+	if u.Scheme == "http" {
+		port = "80"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// dial checks that a TCP connection to address can be opened
+func dial(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return fmt.Errorf("cannot connect to %s: %w", address, err)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+const doctorEggConfig = `
+egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "aws"
+    region   = "eu-west-1"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags = ["docker"]
+    concurrent = 2
+  }
+
+  gitlab {
+    project_id = 12345
+    server_name = "gitlab.com"
+    token_secret = "vault://secret/gitlab/runner-token"
+  }
+}
+`
+
+func TestCheckNest(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+
+	check, _ := checkNest()
+	if check.Status != checkFail || check.Hint == "" {
+		t.Errorf("expected failure with a hint outside a Nest, got %+v", check)
+	}
+
+	for _, dir := range []string{"Jobs/.keep", "UF/config.fly"} {
+		writeNestFile(t, root, dir, "")
+	}
+	writeNestFile(t, root, "Eggs/my-app/config.fly", doctorEggConfig)
+	check, targets := checkNest()
+	if check.Status != checkPass {
+		t.Fatalf("expected valid Nest, got %+v", check)
+	}
+	if len(targets) != 1 || targets[0].provider != "aws" || targets[0].region != "eu-west-1" ||
+		len(targets[0].secrets) != 1 {
+		t.Errorf("unexpected targets %+v", targets)
+	}
+
+	writeNestFile(t, root, "Eggs/broken/config.fly", `egg "broken" { type = "vm" }`)
+	check, _ = checkNest()
+	if check.Status != checkFail || !strings.Contains(check.Message, "broken") {
+		t.Errorf("expected invalid egg to fail the check, got %+v", check)
+	}
+}
+
+func TestCheckAPI(t *testing.T) {
+	apiVersion := "1.2"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(mothergoose.ServerVersion{Version: "2.0.0", APIVersion: apiVersion})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	checks := checkAPI(ctx, &connection{APIURL: server.URL, APIKey: "key"}, time.Second)
+	if checks[0].Status != checkPass || checks[1].Status != checkPass {
+		t.Errorf("expected both API checks to pass, got %+v %+v", checks[0], checks[1])
+	}
+
+	apiVersion = "2.0"
+	checks = checkAPI(ctx, &connection{APIURL: server.URL, APIKey: "key"}, time.Second)
+	if checks[1].Status != checkFail || checks[1].Hint == "" {
+		t.Errorf("expected incompatible API version to fail, got %+v", checks[1])
+	}
+
+	if checks[2].Status != checkPass {
+		t.Errorf("expected a closed circuit, got %+v", checks[2])
+	}
+
+	checks = checkAPI(ctx, &connection{}, time.Second)
+	if checks[0].Status != checkSkip || checks[1].Status != checkSkip || checks[2].Status != checkSkip {
+		t.Errorf("expected skipped checks without an API URL, got %+v %+v %+v", checks[0], checks[1], checks[2])
+	}
+}
+
+func TestCheckAPIServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	checks := checkAPI(context.Background(), &connection{APIURL: server.URL, APIKey: "key"}, time.Second)
+	if checks[1].Status != checkFail {
+		t.Errorf("expected the version check to fail, got %+v", checks[1])
+	}
+	if checks[2].Status != checkWarn || !strings.Contains(checks[2].Message, "1 consecutive server error") {
+		t.Errorf("expected the server error to be counted, got %+v", checks[2])
+	}
+}
+
+func TestCheckCloudCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_PROFILE", "AWS_WEB_IDENTITY_TOKEN_FILE",
+		"YC_TOKEN", "YC_IAM_TOKEN", "YC_SERVICE_ACCOUNT_KEY_FILE"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("YC_TOKEN", "token")
+
+	// Without targets missing credentials are only a warning
+	checks := checkCloudCredentials(nil)
+	if len(checks) != 2 || checks[0].Name != "aws credentials" || checks[0].Status != checkWarn ||
+		checks[1].Status != checkPass {
+		t.Errorf("unexpected checks %+v %+v", checks[0], checks[1])
+	}
+
+	checks = checkCloudCredentials([]nestTarget{{provider: "aws"}})
+	if len(checks) != 1 || checks[0].Status != checkFail || checks[0].Hint == "" {
+		t.Errorf("expected missing AWS credentials to fail, got %+v", checks)
+	}
+}
+
+func TestCheckSecretBackends(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	t.Setenv("VAULT_ADDR", server.URL)
+
+	checks := checkSecretBackends([]nestTarget{{secrets: []string{"vault://secret/a", "vault://secret/b"}}}, time.Second)
+	if len(checks) != 1 || checks[0].Status != checkPass {
+		t.Errorf("expected one reachable Vault backend, got %+v", checks)
+	}
+
+	checks = checkSecretBackends([]nestTarget{{secrets: []string{"s3://bucket/token"}}}, time.Second)
+	if len(checks) != 1 || checks[0].Status != checkFail {
+		t.Errorf("expected unsupported scheme to fail, got %+v", checks)
+	}
+
+	endpoint, _ := secretEndpoint("aws-sm://gitlab/token", nestTarget{provider: "aws", region: "eu-west-1"})
+	if endpoint != "secretsmanager.eu-west-1.amazonaws.com:443" {
+		t.Errorf("unexpected AWS Secrets Manager endpoint %q", endpoint)
+	}
+}
@@ -0,0 +1,298 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	driftEgg    string
+	driftEnv    string
+	driftAPIURL string
+	driftAPIKey string
+)
+
+// driftCmd represents the drift command
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Detect drift between the Nest and deployed Eggs",
+	Long: `Compare the Egg configurations in the Nest with what MotherGoose has deployed.
+
+For each Egg, the config hash of the local .fly file is compared with the
+hash of the deployed plan, and the live Egg configuration is compared field
+by field. Eggs of the Nest that were never deployed are reported as not
+deployed, and Eggs that are deployed but no longer exist in the Nest as
+unmanaged; neither counts as drift.
+
+The command exits with a non-zero status if any deployed Egg has drifted, so
+it can be used as a scheduled CI check.
+
+Example:
+  gosling drift --api-url https://mg.example.com --api-key $KEY
+  gosling drift --egg my-app --env prod --api-url ... --api-key ... -o json`,
+	RunE: runDrift,
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+	driftCmd.Flags().StringVar(&driftEgg, "egg", "", "Only check this Egg")
+	driftCmd.Flags().StringVar(&driftEnv, "env", "", "Environment overlay to apply (e.g. prod for config.prod.fly)")
+	driftCmd.Flags().StringVar(&driftAPIURL, "api-url", "", "MotherGoose API URL")
+	driftCmd.Flags().StringVar(&driftAPIKey, "api-key", "", "MotherGoose API key")
+	mustRegisterEggCompletion(driftCmd, completeEggNames(true))
+}
+
+// Values for eggDriftOutput.Status
+const (
+	driftStatusInSync      = "in-sync"
+	driftStatusDrifted     = "drifted"
+	driftStatusNotDeployed = "not-deployed"
+	driftStatusUnmanaged   = "unmanaged"
+)
+
+// driftOutput is the machine-readable result of `gosling drift`
+type driftOutput struct {
+	Eggs             []*eggDriftOutput `json:"eggs"`
+	InSyncCount      int               `json:"in_sync_count"`
+	DriftedCount     int               `json:"drifted_count"`
+	NotDeployedCount int               `json:"not_deployed_count"`
+	UnmanagedCount   int               `json:"unmanaged_count"`
+}
+
+// eggDriftOutput is the drift status of a single Egg
+type eggDriftOutput struct {
+	EggName      string       `json:"egg_name"`
+	Status       string       `json:"status"`
+	LocalHash    string       `json:"local_hash,omitempty"`
+	DeployedHash string       `json:"deployed_hash,omitempty"`
+	Fields       []fieldDrift `json:"fields,omitempty"`
+}
+
+// fieldDrift is a single field whose local and live values differ
+type fieldDrift struct {
+	Field string `json:"field"`
+	Local string `json:"local"`
+	Live  string `json:"live"`
+}
+
+func runDrift(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	if driftEnv != "" && !parser.IsValidEnvironmentName(driftEnv) {
+		return fmt.Errorf("invalid environment name %q", driftEnv)
+	}
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+	eggs, err := parseEggConfigs(filepath.Join(nestRoot, "Eggs"), driftEnv)
+	if err != nil {
+		return fmt.Errorf("failed to parse Egg configurations: %w", err)
+	}
+
+	conn, err := resolveAPI(driftAPIURL, driftAPIKey)
+	if err != nil {
+		return err
+	}
+	client := conn.client()
+	report, err := detectDrift(ctx, client, eggs, driftEgg)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		if err := writeStructured(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		printDrift(os.Stdout, report)
+	}
+
+	if report.DriftedCount > 0 {
+		return fmt.Errorf("drift detected in %d egg(s)", report.DriftedCount)
+	}
+	return nil
+}
+
+// detectDrift compares local Egg configurations with the live state in
+// MotherGoose. If only is set, only that Egg is checked.
+func detectDrift(ctx context.Context, client mothergoose.MotherGooseClient, local []*deployer.EggConfig, only string) (*driftOutput, error) {
+	liveEggs, err := client.ListEggs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list eggs: %w", err)
+	}
+	live := make(map[string]*deployer.EggConfig, len(liveEggs))
+	for _, egg := range liveEggs {
+		live[egg.Name] = egg
+	}
+
+	report := &driftOutput{Eggs: []*eggDriftOutput{}}
+	seen := make(map[string]bool, len(local))
+	for _, egg := range local {
+		seen[egg.Name] = true
+		if only != "" && egg.Name != only {
+			continue
+		}
+
+		result, err := eggDrift(ctx, client, egg, live[egg.Name])
+		if err != nil {
+			return nil, err
+		}
+		report.add(result)
+	}
+
+	// Deployed eggs that no longer exist in the Nest
+	var unmanaged []string
+	for name := range live {
+		if !seen[name] && (only == "" || name == only) {
+			unmanaged = append(unmanaged, name)
+		}
+	}
+	sort.Strings(unmanaged)
+	for _, name := range unmanaged {
+		report.add(&eggDriftOutput{EggName: name, Status: driftStatusUnmanaged})
+	}
+
+	if only != "" && len(report.Eggs) == 0 {
+		return nil, fmt.Errorf("egg %q not found in the Nest or in MotherGoose", only)
+	}
+	return report, nil
+}
+
+// eggDrift compares a single local Egg with its live configuration and status
+func eggDrift(ctx context.Context, client mothergoose.MotherGooseClient, local, live *deployer.EggConfig) (*eggDriftOutput, error) {
+	localHash := deployer.ConfigHash(local)
+	result := &eggDriftOutput{EggName: local.Name, LocalHash: localHash}
+
+	status, err := client.GetEggStatus(ctx, local.Name)
+	if mothergoose.IsNotFound(err) {
+		// MotherGoose has never seen this Egg
+		status, err = &mothergoose.EggStatus{EggName: local.Name}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of egg %s: %w", local.Name, err)
+	}
+	result.DeployedHash = status.ConfigHash
+	if result.DeployedHash == "" && status.LatestPlan != nil {
+		result.DeployedHash = status.LatestPlan.ConfigHash
+	}
+
+	if live == nil && result.DeployedHash == "" {
+		result.Status = driftStatusNotDeployed
+		return result, nil
+	}
+
+	if live != nil {
+		result.Fields = diffEggConfigs(local, live)
+	}
+	if result.DeployedHash != localHash || len(result.Fields) > 0 {
+		result.Status = driftStatusDrifted
+	} else {
+		result.Status = driftStatusInSync
+	}
+	return result, nil
+}
+
+func (r *driftOutput) add(egg *eggDriftOutput) {
+	r.Eggs = append(r.Eggs, egg)
+	switch egg.Status {
+	case driftStatusInSync:
+		r.InSyncCount++
+	case driftStatusNotDeployed:
+		r.NotDeployedCount++
+	case driftStatusUnmanaged:
+		r.UnmanagedCount++
+	default:
+		r.DriftedCount++
+	}
+}
+
+// diffEggConfigs lists the fields that differ between a local and a live Egg
+func diffEggConfigs(local, live *deployer.EggConfig) []fieldDrift {
+	var fields []fieldDrift
+	compare := func(field string, localVal, liveVal interface{}) {
+		l, r := fmt.Sprint(localVal), fmt.Sprint(liveVal)
+		if l != r {
+			fields = append(fields, fieldDrift{Field: field, Local: l, Live: r})
+		}
+	}
+
+	compare("type", local.Type, live.Type)
+	compare("cloud.provider", local.Cloud.Provider, live.Cloud.Provider)
+	compare("cloud.region", local.Cloud.Region, live.Cloud.Region)
+	compare("resources.cpu", local.Resources.CPU, live.Resources.CPU)
+	compare("resources.memory", local.Resources.Memory, live.Resources.Memory)
+	compare("resources.disk", local.Resources.Disk, live.Resources.Disk)
+	compare("runner.tags", local.Runner.Tags, live.Runner.Tags)
+	compare("runner.concurrent", local.Runner.Concurrent, live.Runner.Concurrent)
+	compare("runner.idle_timeout", local.Runner.IdleTimeout, live.Runner.IdleTimeout)
+	compare("gitlab.project_id", local.GitLab.ProjectID, live.GitLab.ProjectID)
+	compare("gitlab.group_id", local.GitLab.GroupID, live.GitLab.GroupID)
+	compare("gitlab.token_secret", local.GitLab.TokenSecret, live.GitLab.TokenSecret)
+	compare("autoscaling", local.Autoscaling, live.Autoscaling)
+
+	keys := make([]string, 0, len(local.Environment)+len(live.Environment))
+	for key := range local.Environment {
+		keys = append(keys, key)
+	}
+	for key := range live.Environment {
+		if _, ok := local.Environment[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		compare("environment."+key, local.Environment[key], live.Environment[key])
+	}
+
+	return fields
+}
+
+// printDrift prints a human-readable drift report
+func printDrift(w io.Writer, report *driftOutput) {
+	if len(report.Eggs) == 0 {
+		fmt.Fprintln(w, "No eggs found")
+		return
+	}
+	for _, egg := range report.Eggs {
+		switch egg.Status {
+		case driftStatusInSync:
+			fmt.Fprintf(w, "✅ %s: in sync\n", egg.EggName)
+		case driftStatusNotDeployed:
+			fmt.Fprintf(w, "⚠️  %s: not deployed\n", egg.EggName)
+		case driftStatusUnmanaged:
+			fmt.Fprintf(w, "⚠️  %s: deployed but not in the Nest\n", egg.EggName)
+		default:
+			fmt.Fprintf(w, "❌ %s: drifted\n", egg.EggName)
+			if egg.LocalHash != egg.DeployedHash {
+				fmt.Fprintf(w, "   config hash: %s (local) ≠ %s (deployed)\n", shortHash(egg.LocalHash), shortHash(egg.DeployedHash))
+			}
+			for _, field := range egg.Fields {
+				fmt.Fprintf(w, "   %s: %s (local) ≠ %s (live)\n", field.Field, field.Local, field.Live)
+			}
+		}
+	}
+	fmt.Fprintln(w, strings.Repeat("─", 50))
+	fmt.Fprintf(w, "Summary: %d in sync, %d drifted, %d not deployed, %d unmanaged\n",
+		report.InSyncCount, report.DriftedCount, report.NotDeployedCount, report.UnmanagedCount)
+}
+
+// shortHash abbreviates a config hash for display
+func shortHash(hash string) string {
+	if hash == "" {
+		return "-"
+	}
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
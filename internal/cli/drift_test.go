@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+func driftTestEgg(name string, memory int) *deployer.EggConfig {
+	return &deployer.EggConfig{
+		Name:        name,
+		Type:        deployer.RunnerTypeVM,
+		Cloud:       deployer.CloudConfig{Provider: deployer.CloudProviderYandex, Region: "ru-central1-a"},
+		Resources:   deployer.ResourceConfig{CPU: 2, Memory: memory, Disk: 20},
+		Runner:      deployer.RunnerConfig{Tags: []string{"docker"}, Concurrent: 2},
+		GitLab:      deployer.GitLabConfig{ProjectID: 1, TokenSecret: "yc-lockbox://gitlab/token"},
+		Environment: map[string]string{"LOG_LEVEL": "info"},
+	}
+}
+
+func TestDetectDrift(t *testing.T) {
+	client := NewMockMotherGooseClient()
+
+	// in-sync: live config and deployed hash match
+	synced := driftTestEgg("synced", 4096)
+	syncedHash := deployer.ConfigHash(synced)
+	client.EggConfigs["synced"] = driftTestEgg("synced", 4096)
+	client.EggStatuses["synced"] = &mothergoose.EggStatus{LatestPlan: &deployer.DeploymentPlan{ConfigHash: syncedHash}}
+
+	// drifted: memory changed locally since the last deploy
+	drifted := driftTestEgg("drifted", 8192)
+	deployed := driftTestEgg("drifted", 4096)
+	deployedHash := deployer.ConfigHash(deployed)
+	client.EggConfigs["drifted"] = deployed
+	client.EggStatuses["drifted"] = &mothergoose.EggStatus{ConfigHash: deployedHash}
+
+	// unmanaged: deployed but removed from the Nest
+	client.EggConfigs["orphan"] = driftTestEgg("orphan", 2048)
+
+	local := []*deployer.EggConfig{synced, drifted, driftTestEgg("new", 2048)}
+	report, err := detectDrift(context.Background(), client, local, "")
+	if err != nil {
+		t.Fatalf("detectDrift failed: %v", err)
+	}
+
+	statuses := make(map[string]*eggDriftOutput)
+	for _, egg := range report.Eggs {
+		statuses[egg.EggName] = egg
+	}
+	for name, want := range map[string]string{
+		"synced":  driftStatusInSync,
+		"drifted": driftStatusDrifted,
+		"new":     driftStatusNotDeployed,
+		"orphan":  driftStatusUnmanaged,
+	} {
+		if got := statuses[name]; got == nil || got.Status != want {
+			t.Errorf("egg %s: expected status %q, got %+v", name, want, got)
+		}
+	}
+	// Eggs never deployed and Eggs missing from the Nest are not drift
+	if report.InSyncCount != 1 || report.DriftedCount != 1 || report.NotDeployedCount != 1 || report.UnmanagedCount != 1 {
+		t.Errorf("expected 1 in sync, 1 drifted, 1 not deployed and 1 unmanaged, got %+v", report)
+	}
+
+	fields := statuses["drifted"].Fields
+	if len(fields) != 1 || fields[0] != (fieldDrift{Field: "resources.memory", Local: "8192", Live: "4096"}) {
+		t.Errorf("unexpected field drift: %+v", fields)
+	}
+
+	var buf bytes.Buffer
+	printDrift(&buf, report)
+	for _, want := range []string{"resources.memory: 8192 (local) ≠ 4096 (live)", "1 drifted, 1 not deployed, 1 unmanaged"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in output, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDetectDriftSingleEgg(t *testing.T) {
+	client := NewMockMotherGooseClient()
+	client.EggConfigs["other"] = driftTestEgg("other", 2048)
+
+	report, err := detectDrift(context.Background(), client, []*deployer.EggConfig{driftTestEgg("my-app", 2048)}, "my-app")
+	if err != nil {
+		t.Fatalf("detectDrift failed: %v", err)
+	}
+	if len(report.Eggs) != 1 || report.Eggs[0].EggName != "my-app" {
+		t.Errorf("expected only my-app to be checked, got %+v", report.Eggs)
+	}
+
+	if _, err := detectDrift(context.Background(), client, nil, "missing"); err == nil {
+		t.Error("expected error for unknown egg")
+	}
+}
+
+func TestDiffEggConfigsAutoscaling(t *testing.T) {
+	local, live := driftTestEgg("my-app", 4096), driftTestEgg("my-app", 4096)
+	local.Autoscaling = &deployer.AutoscalingConfig{Min: 1, Max: 10, ScaleUpThreshold: 80, ScaleDownDelay: 10 * time.Minute}
+
+	fields := diffEggConfigs(local, live)
+	want := fieldDrift{Field: "autoscaling", Local: "1-10 runners, up at 80%, down after 10m0s", Live: "<nil>"}
+	if len(fields) != 1 || fields[0] != want {
+		t.Errorf("unexpected field drift: %+v", fields)
+	}
+
+	live.Autoscaling = &deployer.AutoscalingConfig{Min: 1, Max: 10, ScaleUpThreshold: 80, ScaleDownDelay: 10 * time.Minute}
+	if fields := diffEggConfigs(local, live); len(fields) != 0 {
+		t.Errorf("expected no drift for equal autoscalers, got %+v", fields)
+	}
+}
+
+// unknownEggsClient answers like a MotherGoose that has never seen any Egg
+type unknownEggsClient struct {
+	*MockMotherGooseClient
+}
+
+func (c unknownEggsClient) GetEggStatus(ctx context.Context, eggName string) (*mothergoose.EggStatus, error) {
+	return nil, &mothergoose.HTTPError{StatusCode: 404, Status: "404 Not Found"}
+}
+
+func TestDetectDriftStatusNotFound(t *testing.T) {
+	client := unknownEggsClient{NewMockMotherGooseClient()}
+	report, err := detectDrift(context.Background(), client, []*deployer.EggConfig{driftTestEgg("new", 2048)}, "")
+	if err != nil {
+		t.Fatalf("detectDrift failed: %v", err)
+	}
+	if len(report.Eggs) != 1 || report.Eggs[0].Status != driftStatusNotDeployed {
+		t.Errorf("expected the unknown egg to be not deployed, got %+v", report.Eggs)
+	}
+}
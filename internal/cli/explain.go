@@ -0,0 +1,390 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	explainEgg string
+	explainEnv string
+)
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Show the effective configuration of an Egg and where each value comes from",
+	Long: `Show every attribute of an Egg as deploy sees it, with the file and line
+that sets it.
+
+An Egg's configuration may come from several places: its config.fly, the
+fragments it includes, the environment overlay selected with --env, the
+Nest-wide Defaults/config.fly and the resource preset it picks. explain
+merges them as deploy does and tells, for each attribute, which one won and
+the variables its expression refers to.
+
+It then shows the deployment configuration the Egg converts to (the VM or
+serverless container fields deploy passes to MotherGoose), or why it does
+not convert.
+
+Example:
+  gosling explain --egg my-app
+  gosling explain --egg my-app --env prod
+  gosling explain --egg my-app -o json`,
+	Args: cobra.NoArgs,
+	RunE: runExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+	explainCmd.Flags().StringVar(&explainEgg, "egg", "", "Egg to explain")
+	explainCmd.Flags().StringVar(&explainEnv, "env", "", "Environment overlay to apply (e.g. prod for config.prod.fly)")
+	mustMarkRequired(explainCmd, "egg")
+	mustRegisterEggCompletion(explainCmd, completeEggNames(false))
+}
+
+// Values for attributeSource.Origin
+const (
+	originEgg      = "egg"
+	originInclude  = "include"
+	originOverlay  = "overlay"
+	originDefaults = "defaults"
+	originPreset   = "preset"
+)
+
+// explainOutput is the machine-readable result of `gosling explain`
+type explainOutput struct {
+	Egg        string            `json:"egg"`
+	File       string            `json:"file"`
+	Env        string            `json:"env,omitempty"`
+	Attributes []attributeSource `json:"attributes"`
+	// Converted are the fields of the deployment configuration, VMConfig
+	// or ServerlessConfig, the Egg converts to
+	Converted []convertedField `json:"converted,omitempty"`
+	// ConvertError is why the Egg does not convert, if it does not
+	ConvertError string `json:"convert_error,omitempty"`
+}
+
+// attributeSource is an effective attribute of an Egg and where it is set
+type attributeSource struct {
+	Path      string   `json:"path"`
+	Value     string   `json:"value"`
+	File      string   `json:"file"`
+	Line      int      `json:"line"`
+	Origin    string   `json:"origin"`
+	Variables []string `json:"variables,omitempty"`
+
+	// pos is the position of the value, to find the variables it refers to
+	pos parser.Position
+}
+
+// convertedField is a field of an Egg's deployment configuration
+type convertedField struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+	output, err := explainEggConfig(nestRoot, explainEgg, explainEnv)
+	if err != nil {
+		return err
+	}
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, output)
+	}
+	return printExplain(os.Stdout, output)
+}
+
+// explainEggConfig returns the effective attributes of an Egg of the Nest at
+// nestRoot with their sources, relative to nestRoot
+func explainEggConfig(nestRoot, egg, env string) (*explainOutput, error) {
+	configPath, ok := eggConfigPath(filepath.Join(nestRoot, "Eggs", egg))
+	if !ok {
+		return nil, fmt.Errorf("egg %q not found in %s", egg, filepath.Join(nestRoot, "Eggs"))
+	}
+	p := parser.NewParser()
+	config, err := p.ParseFileForEnv(configPath, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+	block := findEggBlock(config, egg)
+	if block == nil {
+		return nil, fmt.Errorf("no egg block found in %s", configPath)
+	}
+
+	origins := map[string]string{
+		absPath(configPath): originEgg,
+		absPath(filepath.Join(nestRoot, parser.DefaultsDirName, parser.DefaultsFileName)): originDefaults,
+	}
+	if env != "" {
+		origins[absPath(parser.OverlayPath(configPath, env))] = originOverlay
+	}
+	output := &explainOutput{Egg: egg, File: relativeTo(nestRoot, configPath), Env: env, Attributes: []attributeSource{}}
+	collectAttributeSources(block, "", nestRoot, origins, &output.Attributes)
+	sort.Slice(output.Attributes, func(i, j int) bool {
+		return output.Attributes[i].Path < output.Attributes[j].Path
+	})
+	for i := range output.Attributes {
+		output.Attributes[i].Variables = p.ReferencedVariables(output.Attributes[i].pos)
+	}
+
+	if output.Converted, err = convertEgg(block); err != nil {
+		output.ConvertError = err.Error()
+	}
+	return output, nil
+}
+
+// convertEgg converts an egg block as deploy does and lists the fields of
+// the resulting deployment configuration
+func convertEgg(block *parser.Block) ([]convertedField, error) {
+	egg, err := deployer.ParseEgg(block)
+	if err != nil {
+		return nil, err
+	}
+	converter := deployer.NewConverter()
+	switch deployer.RunnerType(egg.Type) {
+	case deployer.RunnerTypeVM:
+		vm, err := converter.EggToVMConfig(egg)
+		if err != nil {
+			return nil, err
+		}
+		fields := deploymentFields("VMConfig", vm.Cloud, vm.Resources, vm.Runner, vm.GitLab, vm.Environment)
+		if vm.VMSize != "" {
+			fields = append(fields, convertedField{"VMConfig.VMSize", vm.VMSize})
+		}
+		if vm.Network.VPCID != "" {
+			fields = append(fields, convertedField{"VMConfig.Network.VPCID", vm.Network.VPCID})
+		}
+		if vm.Network.SubnetID != "" {
+			fields = append(fields, convertedField{"VMConfig.Network.SubnetID", vm.Network.SubnetID})
+		}
+		if len(vm.Network.SecurityGroups) > 0 {
+			fields = append(fields, convertedField{"VMConfig.Network.SecurityGroups", strings.Join(vm.Network.SecurityGroups, ", ")})
+		}
+		fields = append(fields, convertedField{"VMConfig.Network.DisablePublicIP", strconv.FormatBool(vm.Network.DisablePublicIP)})
+		if a := vm.Autoscaling; a != nil {
+			fields = append(fields,
+				convertedField{"VMConfig.Autoscaling.Min", strconv.Itoa(a.Min)},
+				convertedField{"VMConfig.Autoscaling.Max", strconv.Itoa(a.Max)},
+				convertedField{"VMConfig.Autoscaling.ScaleUpThreshold", strconv.Itoa(a.ScaleUpThreshold)},
+				convertedField{"VMConfig.Autoscaling.ScaleDownDelay", a.ScaleDownDelay.String()})
+		}
+		return fields, nil
+	case deployer.RunnerTypeServerless:
+		sc, err := converter.EggToServerlessConfig(egg)
+		if err != nil {
+			return nil, err
+		}
+		fields := deploymentFields("ServerlessConfig", sc.Cloud, sc.Resources, sc.Runner, sc.GitLab, sc.Environment)
+		return append(fields, convertedField{"ServerlessConfig.Timeout", sc.Timeout.String()}), nil
+	case deployer.RunnerTypeKubernetes:
+		k8s, err := converter.EggToK8sConfig(egg)
+		if err != nil {
+			return nil, err
+		}
+		fields := deploymentFields("K8sConfig", k8s.Cloud, k8s.Resources, k8s.Runner, k8s.GitLab, k8s.Environment)
+		fields = append(fields, convertedField{"K8sConfig.Kubernetes.Namespace", k8s.Kubernetes.Namespace})
+		labels := make([]string, 0, len(k8s.Kubernetes.NodeSelector))
+		for label, value := range k8s.Kubernetes.NodeSelector {
+			labels = append(labels, label+"="+value)
+		}
+		if len(labels) > 0 {
+			sort.Strings(labels)
+			fields = append(fields, convertedField{"K8sConfig.Kubernetes.NodeSelector", strings.Join(labels, ", ")})
+		}
+		return append(fields, convertedField{"K8sConfig.Kubernetes.KubeconfigSecret", k8s.Kubernetes.KubeconfigSecret}), nil
+	default:
+		return nil, fmt.Errorf("unsupported runner type: %s", egg.Type)
+	}
+}
+
+// deploymentFields lists the fields the deployment configurations share,
+// prefixed with the name of the type
+func deploymentFields(kind string, cloud deployer.CloudConfig, resources deployer.ResourceConfig,
+	runner deployer.RunnerConfig, gitlab deployer.GitLabConfig, environment map[string]string) []convertedField {
+	fields := []convertedField{
+		{"Cloud.Provider", string(cloud.Provider)},
+		{"Cloud.Region", cloud.Region},
+		{"Resources.CPU", strconv.Itoa(resources.CPU)},
+		{"Resources.Memory", strconv.Itoa(resources.Memory)},
+		{"Resources.Disk", strconv.Itoa(resources.Disk)},
+		{"Runner.Tags", strings.Join(runner.Tags, ", ")},
+		{"Runner.Concurrent", strconv.Itoa(runner.Concurrent)},
+		{"Runner.IdleTimeout", runner.IdleTimeout.String()},
+		{"GitLab.ServerName", gitlab.ServerName},
+		{"GitLab.TokenSecret", gitlab.TokenSecret},
+	}
+	if resources.GPU != 0 {
+		fields = append(fields, convertedField{"Resources.GPU", strconv.Itoa(resources.GPU)},
+			convertedField{"Resources.GPUType", resources.GPUType})
+	}
+	if resources.Platform != "" {
+		fields = append(fields, convertedField{"Resources.Platform", resources.Platform})
+	}
+	if runner.Image != "" {
+		fields = append(fields, convertedField{"Runner.Image", runner.Image})
+	}
+	if runner.ImageID != "" {
+		fields = append(fields, convertedField{"Runner.ImageID", runner.ImageID})
+	}
+	if runner.OS != "" {
+		fields = append(fields, convertedField{"Runner.OS", runner.OS})
+	}
+	if runner.Arch != "" {
+		fields = append(fields, convertedField{"Runner.Arch", runner.Arch})
+	}
+	if runner.Privileged {
+		fields = append(fields, convertedField{"Runner.Privileged", "true"})
+	}
+	if runner.DockerDinD {
+		fields = append(fields, convertedField{"Runner.DockerDinD", "true"})
+	}
+	if c := runner.Cache; c != nil {
+		fields = append(fields, convertedField{"Runner.Cache.Type", c.Type})
+		for _, field := range []convertedField{
+			{"Runner.Cache.Bucket", c.Bucket},
+			{"Runner.Cache.Path", c.Path},
+			{"Runner.Cache.ServerAddress", c.ServerAddress},
+			{"Runner.Cache.CredentialsSecret", c.CredentialsSecret},
+		} {
+			if field.Value != "" {
+				fields = append(fields, field)
+			}
+		}
+		fields = append(fields, convertedField{"Runner.Cache.Shared", strconv.FormatBool(c.Shared)})
+	}
+	if gitlab.GroupID != 0 {
+		fields = append(fields, convertedField{"GitLab.GroupID", strconv.Itoa(gitlab.GroupID)})
+	} else {
+		fields = append(fields, convertedField{"GitLab.ProjectID", strconv.Itoa(gitlab.ProjectID)})
+	}
+	names := make([]string, 0, len(environment))
+	for name := range environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fields = append(fields, convertedField{"Environment." + name, environment[name]})
+	}
+	for i := range fields {
+		fields[i].Field = kind + "." + fields[i].Field
+	}
+	return fields
+}
+
+// findEggBlock returns the egg block labeled name, or the only egg block
+func findEggBlock(config *parser.Config, name string) *parser.Block {
+	var eggs []*parser.Block
+	for i := range config.Blocks {
+		if config.Blocks[i].Type != "egg" {
+			continue
+		}
+		if len(config.Blocks[i].Labels) > 0 && config.Blocks[i].Labels[0] == name {
+			return &config.Blocks[i]
+		}
+		eggs = append(eggs, &config.Blocks[i])
+	}
+	if len(eggs) == 1 {
+		return eggs[0]
+	}
+	return nil
+}
+
+// collectAttributeSources appends the attributes of block and its nested
+// blocks, whose paths start with prefix
+func collectAttributeSources(block *parser.Block, prefix string, nestRoot string, origins map[string]string, out *[]attributeSource) {
+	var presetPos parser.Position
+	if preset, ok := block.GetAttribute("preset"); ok && block.Type == "resources" {
+		presetPos = preset.Position
+	}
+	for name, val := range block.Attributes {
+		origin, ok := origins[absPath(val.Position.File)]
+		if !ok {
+			origin = originInclude
+		}
+		if name != "preset" && presetPos.Line > 0 && val.Position == presetPos {
+			origin = originPreset
+		}
+		*out = append(*out, attributeSource{
+			Path:   prefix + name,
+			Value:  val.String(),
+			File:   relativeTo(nestRoot, val.Position.File),
+			Line:   val.Position.Line,
+			Origin: origin,
+			pos:    val.Position,
+		})
+	}
+	for i := range block.Blocks {
+		nested := &block.Blocks[i]
+		path := prefix + nested.Type
+		for _, label := range nested.Labels {
+			path += "." + label
+		}
+		collectAttributeSources(nested, path+".", nestRoot, origins, out)
+	}
+}
+
+func printExplain(w io.Writer, output *explainOutput) error {
+	heading := fmt.Sprintf("Egg %s (%s", output.Egg, output.File)
+	if output.Env != "" {
+		heading += ", env " + output.Env
+	}
+	fmt.Fprintln(w, heading+")")
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ATTRIBUTE\tVALUE\tSOURCE\tFROM")
+	for _, attr := range output.Attributes {
+		from := attr.Origin
+		if len(attr.Variables) > 0 {
+			from += " via " + strings.Join(attr.Variables, ", ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s:%d\t%s\n", attr.Path, attr.Value, attr.File, attr.Line, from)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	if output.ConvertError != "" {
+		fmt.Fprintf(w, "❌ Does not convert to a deployment configuration: %s\n", output.ConvertError)
+		return nil
+	}
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tVALUE")
+	for _, field := range output.Converted {
+		fmt.Fprintf(tw, "%s\t%s\n", field.Field, field.Value)
+	}
+	return tw.Flush()
+}
+
+// absPath returns path made absolute, or path itself if it cannot be
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// relativeTo shows path relative to root when inside it
+func relativeTo(root, path string) string {
+	rel, err := filepath.Rel(absPath(root), absPath(path))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainEggConfig(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Defaults/config.fly", `
+defaults {
+  runner {
+    idle_timeout = "15m"
+    tags         = ["shared"]
+  }
+}
+`)
+	writeNestFile(t, root, "Eggs/my-app/config.fly", "variable \"tags\" {\n  default = [\"docker\"]\n}\n"+
+		strings.NewReplacer("    idle_timeout = \"10m\"\n", "", `tags = ["docker"]`, "tags = var.tags").Replace(policyEggConfig))
+	writeNestFile(t, root, "Eggs/my-app/config.prod.fly", `
+egg {
+  runner {
+    concurrent = 8
+  }
+}
+`)
+
+	output, err := explainEggConfig(root, "my-app", "prod")
+	if err != nil {
+		t.Fatalf("explainEggConfig failed: %v", err)
+	}
+	want := map[string]attributeSource{
+		"type":                {Value: `"vm"`, File: "Eggs/my-app/config.fly", Line: 6, Origin: originEgg},
+		"runner.tags":         {Value: `["docker"]`, File: "Eggs/my-app/config.fly", Line: 20, Origin: originEgg, Variables: []string{"var.tags"}},
+		"runner.concurrent":   {Value: "8", File: "Eggs/my-app/config.prod.fly", Line: 4, Origin: originOverlay},
+		"runner.idle_timeout": {Value: `"15m"`, File: "Defaults/config.fly", Line: 4, Origin: originDefaults},
+	}
+	got := make(map[string]attributeSource)
+	for _, attr := range output.Attributes {
+		got[attr.Path] = attr
+	}
+	for path, w := range want {
+		g := got[path]
+		if g.Value != w.Value || g.File != w.File || g.Line != w.Line || g.Origin != w.Origin ||
+			strings.Join(g.Variables, ",") != strings.Join(w.Variables, ",") {
+			t.Errorf("%s = %+v, want %+v", path, g, w)
+		}
+	}
+
+	if output.ConvertError != "" {
+		t.Fatalf("expected the egg to convert, got %s", output.ConvertError)
+	}
+	converted := make(map[string]string)
+	for _, field := range output.Converted {
+		converted[field.Field] = field.Value
+	}
+	for field, value := range map[string]string{
+		"VMConfig.Runner.Concurrent":  "8",
+		"VMConfig.Runner.IdleTimeout": "15m0s",
+		"VMConfig.Resources.Memory":   "4096",
+	} {
+		if converted[field] != value {
+			t.Errorf("%s = %q, want %q", field, converted[field], value)
+		}
+	}
+
+	var b strings.Builder
+	if err := printExplain(&b, output); err != nil {
+		t.Fatalf("printExplain failed: %v", err)
+	}
+	if !strings.Contains(b.String(), "Defaults/config.fly:4") || !strings.Contains(b.String(), "egg via var.tags") {
+		t.Errorf("expected the defaults file in the output, got:\n%s", b.String())
+	}
+
+	if _, err := explainEggConfig(root, "missing", ""); err == nil || !strings.Contains(err.Error(), `egg "missing" not found`) {
+		t.Errorf("expected a missing egg error, got %v", err)
+	}
+}
+
+func TestValidateReportsInheritedDefaults(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Defaults/config.fly", "defaults {\n  runner {\n    idle_timeout = \"15m\"\n  }\n}\n")
+	writeNestFile(t, root, "Eggs/my-app/config.fly", strings.Replace(policyEggConfig,
+		"    idle_timeout = \"10m\"\n", "", 1))
+
+	result := validateFiles([]string{filepath.Join(root, "Eggs", "my-app", "config.fly")}, 1, nil)[0]
+	if !result.Valid {
+		t.Fatalf("expected a valid egg, got %s", result.Error)
+	}
+	want := []string{`egg "my-app": runner.idle_timeout = "15m" (Defaults/config.fly:3)`}
+	if strings.Join(result.Inherited, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Inherited = %q, want %q", result.Inherited, want)
+	}
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/polar-gosling/gosling/internal/tofu"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportEgg string
+	exportOut string
+	exportEnv string
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export Nest configurations to other formats",
+	Long:  `Export Nest configurations to other formats.`,
+}
+
+// exportTofuCmd represents the export tofu command
+var exportTofuCmd = &cobra.Command{
+	Use:   "tofu",
+	Short: "Generate an OpenTofu module for an Egg",
+	Long: `Generate the OpenTofu module for an Egg's runner infrastructure.
+
+The module contains the same resources MotherGoose provisions for the Egg
+(yandex_compute_instance, yandex_serverless_container, aws_instance,
+aws_lambda_function, azurerm_linux_virtual_machine or azurerm_container_group).
+Values MotherGoose supplies at deploy time, such as the runner image, network
+and MotherGoose credentials, are declared in variables.tf.
+
+--egg takes the name deploy gives the Egg: its directory, or the label of an
+instance of count or for_each (e.g. worker-0).
+
+main.tf, variables.tf and outputs.tf are written to --out, replacing existing
+files with the same names.
+
+Example:
+  gosling export tofu --egg my-app --out tofu/my-app
+  gosling export tofu --egg my-app --env prod --out tofu/my-app-prod`,
+	Args: cobra.NoArgs,
+	RunE: runExportTofu,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportTofuCmd)
+
+	exportTofuCmd.Flags().StringVar(&exportEgg, "egg", "", "Egg to export")
+	exportTofuCmd.Flags().StringVar(&exportOut, "out", "", "Directory to write the module to")
+	exportTofuCmd.Flags().StringVar(&exportEnv, "env", "", "Environment overlay to apply (e.g. prod for config.prod.fly)")
+	mustMarkRequired(exportTofuCmd, "egg")
+	mustRegisterEggCompletion(exportTofuCmd, completeEggNames(false))
+	mustMarkRequired(exportTofuCmd, "out")
+}
+
+// exportOutput is the machine-readable result of `gosling export tofu`
+type exportOutput struct {
+	EggName  string   `json:"egg_name"`
+	Provider string   `json:"provider"`
+	Type     string   `json:"type"`
+	Dir      string   `json:"dir"`
+	Files    []string `json:"files"`
+}
+
+func runExportTofu(cmd *cobra.Command, args []string) error {
+	if exportEnv != "" && !parser.IsValidEnvironmentName(exportEnv) {
+		return fmt.Errorf("invalid environment name %q", exportEnv)
+	}
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+
+	egg, err := loadParsedEgg(filepath.Join(nestRoot, "Eggs"), exportEgg, exportEnv)
+	if err != nil {
+		return err
+	}
+	module, err := tofuModule(egg)
+	if err != nil {
+		return err
+	}
+	if err := module.WriteDir(exportOut); err != nil {
+		return err
+	}
+
+	result := &exportOutput{
+		EggName:  egg.Name,
+		Provider: egg.Cloud.Provider,
+		Type:     egg.Type,
+		Dir:      exportOut,
+		Files:    module.FileNames(),
+	}
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, result)
+	}
+	fmt.Printf("✅ Exported %s (%s %s) to %s\n", result.EggName, result.Provider, result.Type, result.Dir)
+	for _, name := range result.Files {
+		fmt.Printf("📄 %s\n", filepath.Join(result.Dir, name))
+	}
+	return nil
+}
+
+// loadParsedEgg parses and validates the Egg called name with the env overlay
+// merged
+func loadParsedEgg(eggsDir, name, env string) (*deployer.ParsedEggConfig, error) {
+	dir, config, block, err := resolveEggBlock(eggsDir, name, env)
+	if err != nil {
+		return nil, err
+	}
+	if result := parser.NewValidator(config).Validate(); !result.IsValid() {
+		return nil, fmt.Errorf("invalid configuration %s: %s", dir.ConfigPath, result.Error())
+	}
+	egg, err := deployer.ParseEgg(block)
+	if err != nil {
+		return nil, err
+	}
+	egg.Name = name
+	return egg, nil
+}
+
+// tofuModule converts an Egg to its deployment configuration and generates the module
+func tofuModule(egg *deployer.ParsedEggConfig) (*tofu.Module, error) {
+	converter := deployer.NewConverter()
+	switch deployer.RunnerType(egg.Type) {
+	case deployer.RunnerTypeVM:
+		vm, err := converter.EggToVMConfig(egg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert egg: %w", err)
+		}
+		return tofu.VMModule(vm)
+	case deployer.RunnerTypeServerless:
+		serverless, err := converter.EggToServerlessConfig(egg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert egg: %w", err)
+		}
+		return tofu.ServerlessModule(serverless)
+	case deployer.RunnerTypeKubernetes:
+		return nil, fmt.Errorf("egg %s runs in an existing kubernetes cluster: there is no module to export", egg.Name)
+	default:
+		return nil, fmt.Errorf("unsupported runner type: %s", egg.Type)
+	}
+}
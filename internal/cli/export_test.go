@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportTofu(t *testing.T) {
+	content := `
+egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "aws"
+    region   = "eu-central-1"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags         = ["docker"]
+    concurrent   = 2
+    idle_timeout = "10m"
+  }
+
+  gitlab {
+    project_id   = 42
+    server_name  = "gitlab.com"
+    token_secret = "aws-sm://gitlab/runner-token"
+  }
+}
+`
+	overlay := `
+egg "my-app" {
+  type = "serverless"
+}
+`
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", content)
+	writeNestFile(t, root, "Eggs/my-app/config.prod.fly", overlay)
+
+	for env, want := range map[string]string{"": "aws_instance", "prod": "aws_lambda_function"} {
+		egg, err := loadParsedEgg(filepath.Join(root, "Eggs"), "my-app", env)
+		if err != nil {
+			t.Fatalf("loadParsedEgg(%q) failed: %v", env, err)
+		}
+		module, err := tofuModule(egg)
+		if err != nil {
+			t.Fatalf("tofuModule(%q) failed: %v", env, err)
+		}
+
+		out := filepath.Join(root, "tofu", env)
+		if err := module.WriteDir(out); err != nil {
+			t.Fatalf("WriteDir failed: %v", err)
+		}
+		main, err := os.ReadFile(filepath.Join(out, "main.tf"))
+		if err != nil {
+			t.Fatalf("main.tf not written: %v", err)
+		}
+		if !strings.Contains(string(main), `resource "`+want+`" "runner"`) {
+			t.Errorf("env %q: expected %s resource in main.tf:\n%s", env, want, main)
+		}
+	}
+}
+
+func TestLoadParsedEggRepeated(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", policyEggConfig)
+	writeNestFile(t, root, "Eggs/workers/config.fly", strings.Replace(policyEggConfig, `egg "my-app" {`, "egg \"worker-${count.index}\" {\n  count = 2\n", 1))
+	eggsDir := filepath.Join(root, "Eggs")
+
+	// Instances are found by the names deploy gives them, in any directory
+	for _, name := range []string{"my-app", "worker-0", "worker-1"} {
+		egg, err := loadParsedEgg(eggsDir, name, "")
+		if err != nil || egg.Name != name {
+			t.Errorf("loadParsedEgg(%q) = %v, %v", name, egg, err)
+		}
+	}
+	if _, err := loadParsedEgg(eggsDir, "workers", ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected the directory of repeated Eggs not to name an Egg, got %v", err)
+	}
+}
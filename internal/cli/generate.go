@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	generateEgg string
+	generateEnv string
+	generateJob string
+)
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate files for the repositories served by the Nest",
+	Long:  `Generate files for the repositories served by the Nest.`,
+}
+
+// generateCICmd represents the generate ci command
+var generateCICmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Generate a .gitlab-ci.yml snippet running jobs on an Egg's runners",
+	Long: `Generate a .gitlab-ci.yml snippet for a project served by an Egg.
+
+The snippet defines a hidden job, .<egg>-runner, with the tags of the Egg's
+runners, a per-branch cache and, for serverless runners, a job timeout within
+the runtime's limit. Its comments describe the resources each job gets. An
+example job extends it; copy the snippet into .gitlab-ci.yml and add
+"extends: .<egg>-runner" to the jobs that should run on the Egg.
+
+Example:
+  gosling generate ci --egg my-app >> .gitlab-ci.yml
+  gosling generate ci --egg my-app --env prod --job test`,
+	Args: cobra.NoArgs,
+	RunE: runGenerateCI,
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generateCICmd)
+
+	generateCICmd.Flags().StringVar(&generateEgg, "egg", "", "Egg whose runners run the jobs")
+	generateCICmd.Flags().StringVar(&generateEnv, "env", "", "Environment overlay to apply (e.g. prod for config.prod.fly)")
+	generateCICmd.Flags().StringVar(&generateJob, "job", "build", "Name of the example job")
+	mustMarkRequired(generateCICmd, "egg")
+	mustRegisterEggCompletion(generateCICmd, completeEggNames(false))
+}
+
+// ciSnippetOutput is the machine-readable result of `gosling generate ci`
+type ciSnippetOutput struct {
+	EggName string   `json:"egg_name"`
+	Job     string   `json:"job"`
+	Tags    []string `json:"tags"`
+	Timeout string   `json:"timeout,omitempty"`
+	Snippet string   `json:"snippet"`
+}
+
+func runGenerateCI(cmd *cobra.Command, args []string) error {
+	if generateEnv != "" && !parser.IsValidEnvironmentName(generateEnv) {
+		return fmt.Errorf("invalid environment name %q", generateEnv)
+	}
+	if !isValidName(generateJob) {
+		return fmt.Errorf("invalid job name %q: must contain only alphanumeric characters, hyphens, and underscores", generateJob)
+	}
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+
+	egg, err := loadParsedEgg(filepath.Join(nestRoot, "Eggs"), generateEgg, generateEnv)
+	if err != nil {
+		return err
+	}
+	result, err := ciSnippet(egg, generateJob)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, result)
+	}
+	fmt.Print(result.Snippet)
+	return nil
+}
+
+// ciSnippet generates the .gitlab-ci.yml snippet running job on egg's runners
+func ciSnippet(egg *deployer.ParsedEggConfig, job string) (*ciSnippetOutput, error) {
+	if len(egg.Runner.Tags) == 0 {
+		return nil, fmt.Errorf("egg %s has no runner tags: jobs could not select its runners", egg.Name)
+	}
+	timeout, err := ciJobTimeout(egg)
+	if err != nil {
+		return nil, err
+	}
+	result := &ciSnippetOutput{EggName: egg.Name, Job: job, Tags: egg.Runner.Tags}
+	if timeout > 0 {
+		result.Timeout = fmt.Sprintf("%dm", int(timeout/time.Minute))
+	}
+
+	hidden := "." + egg.Name + "-runner"
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Jobs on the runners of Egg %s (%s %s, %s)\n", egg.Name, egg.Cloud.Provider, egg.Type, egg.Cloud.Region)
+	fmt.Fprintf(&b, "# Runner resources: cpu = %d, memory = %d MB, disk = %d GB, shared by up to\n", egg.Resources.CPU, egg.Resources.Memory, egg.Resources.Disk)
+	fmt.Fprintf(&b, "# %d concurrent jobs\n", egg.Runner.Concurrent)
+	fmt.Fprintf(&b, "%s:\n", hidden)
+	b.WriteString("  tags:\n")
+	for _, tag := range egg.Runner.Tags {
+		fmt.Fprintf(&b, "    - %q\n", tag)
+	}
+	if result.Timeout != "" {
+		fmt.Fprintf(&b, "  timeout: %s  # %s serverless runners stop jobs after this\n", result.Timeout, egg.Cloud.Provider)
+	}
+	b.WriteString("  cache:\n")
+	b.WriteString("    key: \"$CI_COMMIT_REF_SLUG\"\n")
+	b.WriteString("    paths:\n")
+	b.WriteString("      - .cache/\n")
+	if egg.Type == string(deployer.RunnerTypeServerless) {
+		b.WriteString("    # Serverless runners are ephemeral: the cache only survives a job with a\n")
+		b.WriteString("    # distributed cache configured on the runner\n")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "%s:\n", job)
+	fmt.Fprintf(&b, "  extends: %s\n", hidden)
+	b.WriteString("  script:\n")
+	fmt.Fprintf(&b, "    - echo \"Running on the %s runners\"\n", egg.Name)
+	result.Snippet = b.String()
+	return result, nil
+}
+
+// ciJobTimeout returns the longest a job can run on egg's runners, or zero
+// when only the project's own timeout applies
+func ciJobTimeout(egg *deployer.ParsedEggConfig) (time.Duration, error) {
+	if egg.Type != string(deployer.RunnerTypeServerless) {
+		return 0, nil
+	}
+	config, err := deployer.NewConverter().EggToServerlessConfig(egg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert egg: %w", err)
+	}
+	timeout := config.Timeout
+	if limit := parser.ServerlessJobTimeout(egg.Cloud.Provider); timeout > limit {
+		timeout = limit
+	}
+	return timeout, nil
+}
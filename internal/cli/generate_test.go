@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+func TestCISnippet(t *testing.T) {
+	content := `
+egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "aws"
+    region   = "eu-central-1"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags         = ["docker", "linux:amd64"]
+    concurrent   = 3
+    idle_timeout = "10m"
+  }
+
+  gitlab {
+    project_id   = 42
+    server_name  = "gitlab.com"
+    token_secret = "aws-sm://gitlab/runner-token"
+  }
+}
+`
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", content)
+	writeNestFile(t, root, "Eggs/my-app/config.prod.fly", `egg "my-app" {
+  type = "serverless"
+}
+`)
+
+	for env, wantTimeout := range map[string]string{"": "", "prod": "15m"} {
+		egg, err := loadParsedEgg(filepath.Join(root, "Eggs"), "my-app", env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := ciSnippet(egg, "test")
+		if err != nil {
+			t.Fatalf("ciSnippet(%q) failed: %v", env, err)
+		}
+		if result.Timeout != wantTimeout {
+			t.Errorf("env %q: expected timeout %q, got %q", env, wantTimeout, result.Timeout)
+		}
+		if !strings.Contains(result.Snippet, "cpu = 2, memory = 4096 MB, disk = 20 GB") {
+			t.Errorf("env %q: expected resource hints in:\n%s", env, result.Snippet)
+		}
+
+		var ci map[string]struct {
+			Extends string
+			Tags    []string
+			Timeout string
+			Cache   struct{ Key string }
+		}
+		if err := yaml.Unmarshal([]byte(result.Snippet), &ci); err != nil {
+			t.Fatalf("env %q: snippet is not valid YAML: %v\n%s", env, err, result.Snippet)
+		}
+		runner := ci[".my-app-runner"]
+		if strings.Join(runner.Tags, ",") != "docker,linux:amd64" || runner.Timeout != wantTimeout || runner.Cache.Key != "$CI_COMMIT_REF_SLUG" {
+			t.Errorf("env %q: unexpected runner job %+v", env, runner)
+		}
+		if ci["test"].Extends != ".my-app-runner" {
+			t.Errorf("env %q: expected the example job to extend the runner job, got %+v", env, ci["test"])
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/gitlab"
+	"github.com/polar-gosling/gosling/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+// gitlabConnFlags holds the flags for reaching a self-hosted GitLab server
+type gitlabConnFlags struct {
+	caCert     string
+	clientCert string
+	clientKey  string
+	proxy      string
+	insecure   bool
+}
+
+// register adds the connection flags to cmd
+func (f *gitlabConnFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.caCert, "gitlab-ca-cert", "", "CA certificate of the GitLab server: PEM file, PEM or secret reference")
+	cmd.Flags().StringVar(&f.clientCert, "gitlab-client-cert", "", "Client certificate for mutual TLS: PEM file, PEM or secret reference")
+	cmd.Flags().StringVar(&f.clientKey, "gitlab-client-key", "", "Client certificate key for mutual TLS: PEM file, PEM or secret reference")
+	cmd.Flags().StringVar(&f.proxy, "gitlab-proxy", "", "HTTP proxy for GitLab requests (default: $HTTPS_PROXY)")
+	cmd.Flags().BoolVar(&f.insecure, "gitlab-insecure-skip-verify", false, "Do not verify the GitLab server certificate (insecure)")
+	cmd.MarkFlagsRequiredTogether("gitlab-client-cert", "gitlab-client-key")
+}
+
+// options returns the gitlab.Client options for the flags. caCert is used
+// when --gitlab-ca-cert is not set, e.g. the ca_cert of an Egg.
+func (f *gitlabConnFlags) options(ctx context.Context, caCert string) ([]gitlab.ClientOption, error) {
+	var opts []gitlab.ClientOption
+	if f.caCert != "" {
+		caCert = f.caCert
+	}
+	if caCert != "" {
+		data, err := loadPEM(ctx, caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load GitLab CA certificate: %w", err)
+		}
+		opts = append(opts, gitlab.WithRootCAs(data))
+	}
+	if f.clientCert != "" {
+		cert, err := loadPEM(ctx, f.clientCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load GitLab client certificate: %w", err)
+		}
+		key, err := loadPEM(ctx, f.clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load GitLab client key: %w", err)
+		}
+		opts = append(opts, gitlab.WithClientCertificate(cert, key))
+	}
+	if f.proxy != "" {
+		opts = append(opts, gitlab.WithProxy(f.proxy))
+	}
+	if f.insecure {
+		opts = append(opts, gitlab.WithInsecureSkipVerify())
+	}
+	return opts, nil
+}
+
+// loadPEM returns PEM data given inline, as a secret reference or as a file path
+func loadPEM(ctx context.Context, ref string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(ref), "-----BEGIN"):
+		return []byte(ref), nil
+	case secrets.IsReference(ref):
+		value, err := secrets.Resolve(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(value), nil
+	default:
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ref, err)
+		}
+		return data, nil
+	}
+}
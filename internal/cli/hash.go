@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hashEnv       string
+	hashCanonical bool
+)
+
+var hashCmd = &cobra.Command{
+	Use:   "hash <egg>",
+	Short: "Show the config hash of an Egg",
+	Long: `Show the config hash deploy and drift use to detect changes to an Egg.
+
+The hash covers a canonical form of the Egg: fields sorted by name, runner tags
+sorted, and unset fields left out, so reordering the .fly file or upgrading
+gosling does not change it. The hash is prefixed with the version of the
+canonical form (e.g. v1:). Use --canonical to print the hashed text when
+investigating an unexpected redeploy.
+
+Example:
+  gosling hash my-app
+  gosling hash my-app --env prod --canonical`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFirstArgEggName,
+	RunE:              runHash,
+}
+
+func init() {
+	rootCmd.AddCommand(hashCmd)
+	hashCmd.Flags().StringVar(&hashEnv, "env", "", "Environment overlay to apply (e.g. prod for config.prod.fly)")
+	hashCmd.Flags().BoolVar(&hashCanonical, "canonical", false, "Also print the canonical form that is hashed")
+}
+
+// hashOutput is the machine-readable result of `gosling hash`
+type hashOutput struct {
+	EggName   string `json:"egg_name"`
+	Hash      string `json:"hash"`
+	Version   string `json:"version"`
+	Canonical string `json:"canonical,omitempty"`
+}
+
+func runHash(cmd *cobra.Command, args []string) error {
+	if hashEnv != "" && !parser.IsValidEnvironmentName(hashEnv) {
+		return fmt.Errorf("invalid environment name %q", hashEnv)
+	}
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+	eggs, err := parseEggConfigs(filepath.Join(nestRoot, "Eggs"), hashEnv)
+	if err != nil {
+		return fmt.Errorf("failed to parse Egg configurations: %w", err)
+	}
+
+	for _, egg := range eggs {
+		if egg.Name == args[0] {
+			result := eggHash(egg, hashCanonical)
+			if isStructuredOutput() {
+				return writeStructured(os.Stdout, result)
+			}
+			printHash(os.Stdout, result)
+			return nil
+		}
+	}
+	return fmt.Errorf("egg %q not found in %s", args[0], filepath.Join(nestRoot, "Eggs"))
+}
+
+func eggHash(egg *deployer.EggConfig, canonical bool) *hashOutput {
+	result := &hashOutput{
+		EggName: egg.Name,
+		Hash:    deployer.ConfigHash(egg),
+		Version: deployer.ConfigHashVersion,
+	}
+	if canonical {
+		result.Canonical = deployer.CanonicalEggConfig(egg)
+	}
+	return result
+}
+
+func printHash(w io.Writer, result *hashOutput) {
+	fmt.Fprintf(w, "%s  %s\n", result.Hash, result.EggName)
+	if result.Canonical != "" {
+		fmt.Fprintf(w, "\n%s", result.Canonical)
+	}
+}
@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyEgg    string
+	historyLimit  int
+	historyStatus string
+	historySince  string
+	historyAPIURL string
+	historyAPIKey string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the deployment plans of an Egg",
+	Long: `List the deployment plans of an Egg, newest first, with who or what
+triggered each of them.
+
+--status keeps plans in one state (pending, applying, applied, failed,
+rolled_back). --since keeps plans created after a duration ago (90m, 36h,
+7d) or a date (2024-05-01, or RFC 3339).
+
+Example:
+  gosling history --egg my-app
+  gosling history --egg my-app --limit 20 --status applied --since 7d
+  gosling history --egg my-app --output json`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().StringVar(&historyEgg, "egg", "", "Egg name")
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 20, "Maximum number of plans to list (0 for all)")
+	historyCmd.Flags().StringVar(&historyStatus, "status", "", "Only list plans with this status")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only list plans created since a duration ago (e.g. 7d) or a date")
+	historyCmd.Flags().StringVar(&historyAPIURL, "api-url", "", "MotherGoose API URL")
+	historyCmd.Flags().StringVar(&historyAPIKey, "api-key", "", "MotherGoose API key")
+	mustMarkRequired(historyCmd, "egg")
+	mustRegisterEggCompletion(historyCmd, completeEggNames(true))
+}
+
+// historyFilter selects the plans listed by `gosling history`
+type historyFilter struct {
+	Status string
+	Since  time.Time // Zero for no limit
+	Limit  int       // Zero for no limit
+}
+
+// historyOutput is the machine-readable result of `gosling history`
+type historyOutput struct {
+	EggName string          `json:"egg_name"`
+	Plans   []*historyEntry `json:"plans"`
+}
+
+// historyEntry is a deployment plan with what triggered it
+type historyEntry struct {
+	*planOutput
+	TriggeredBy string `json:"triggered_by,omitempty"`
+	Trigger     string `json:"trigger,omitempty"`     // cli, ci or rollback
+	RollbackOf  string `json:"rollback_of,omitempty"` // Plan a rollback plan restored
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	filter := historyFilter{Status: historyStatus, Limit: historyLimit}
+	if historyLimit < 0 {
+		return fmt.Errorf("--limit must not be negative, got %d", historyLimit)
+	}
+	if historyStatus != "" && !isPlanStatus(historyStatus) {
+		return fmt.Errorf("invalid --status %q: must be one of pending, applying, applied, failed, rolled_back", historyStatus)
+	}
+	if historySince != "" {
+		since, err := parseSince(historySince, time.Now())
+		if err != nil {
+			return err
+		}
+		filter.Since = since
+	}
+
+	conn, err := resolveAPI(historyAPIURL, historyAPIKey)
+	if err != nil {
+		return err
+	}
+	client := conn.client()
+	report, err := planHistory(commandContext(cmd), client, historyEgg, filter)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, report)
+	}
+	printHistory(os.Stdout, report)
+	return nil
+}
+
+// planHistory lists the plans of eggName matching filter, newest first
+func planHistory(ctx context.Context, client mothergoose.MotherGooseClient, eggName string, filter historyFilter) (*historyOutput, error) {
+	plans, err := client.ListDeploymentPlans(ctx, eggName)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(plans, func(i, j int) bool {
+		return plans[i].CreatedAt.After(plans[j].CreatedAt)
+	})
+
+	report := &historyOutput{EggName: eggName, Plans: []*historyEntry{}}
+	for _, plan := range plans {
+		if filter.Limit > 0 && len(report.Plans) == filter.Limit {
+			break
+		}
+		if filter.Status != "" && plan.Status != filter.Status {
+			continue
+		}
+		if !filter.Since.IsZero() && plan.CreatedAt.Before(filter.Since) {
+			continue
+		}
+		report.Plans = append(report.Plans, newHistoryEntry(plan))
+	}
+	return report, nil
+}
+
+func newHistoryEntry(plan *deployer.DeploymentPlan) *historyEntry {
+	entry := &historyEntry{planOutput: newPlanOutput(plan), RollbackOf: plan.RollbackPlan}
+	if value, ok := plan.Metadata[metadataTriggeredBy]; ok {
+		entry.TriggeredBy = fmt.Sprint(value)
+	}
+	if value, ok := plan.Metadata[metadataTrigger]; ok {
+		entry.Trigger = fmt.Sprint(value)
+	}
+	if entry.Trigger == "" && plan.RollbackPlan != "" {
+		entry.Trigger = triggerRollback
+	}
+	return entry
+}
+
+// printHistory prints the plans as a table
+func printHistory(w io.Writer, report *historyOutput) {
+	if len(report.Plans) == 0 {
+		fmt.Fprintf(w, "No deployment plans found for egg %s\n", report.EggName)
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PLAN ID\tSTATUS\tCREATED\tAPPLIED\tCONFIG HASH\tTRIGGERED BY")
+	fmt.Fprintln(tw, "-------\t------\t-------\t-------\t-----------\t------------")
+	for _, plan := range report.Plans {
+		applied := "-"
+		if plan.AppliedAt != nil {
+			applied = plan.AppliedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			shortID(plan.ID), plan.Status, plan.CreatedAt.Format("2006-01-02 15:04"), applied,
+			shortHash(plan.ConfigHash), plan.trigger())
+	}
+	tw.Flush()
+}
+
+// trigger describes who or what created the plan
+func (e *historyEntry) trigger() string {
+	var parts []string
+	if e.TriggeredBy != "" {
+		parts = append(parts, e.TriggeredBy)
+	}
+	if e.Trigger != "" {
+		parts = append(parts, "("+e.Trigger+")")
+	}
+	if e.RollbackOf != "" {
+		parts = append(parts, "→ "+shortID(e.RollbackOf))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+// isPlanStatus reports whether status is a known deployment plan status
+func isPlanStatus(status string) bool {
+	switch status {
+	case deployer.PlanStatusPending, deployer.PlanStatusApplying, deployer.PlanStatusApplied,
+		deployer.PlanStatusFailed, deployer.PlanStatusRolledBack:
+		return true
+	}
+	return false
+}
+
+// parseSince parses --since as a duration before now (a Go duration, or a
+// number of days such as 7d) or as a date or RFC 3339 timestamp
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration such as 36h or 7d, or a date such as 2024-05-01", value)
+}
+
+// Plan metadata keys recording what triggered a deployment
+const (
+	metadataTriggeredBy = "triggered_by"
+	metadataTrigger     = "trigger"
+)
+
+// Values for the trigger metadata of a plan
+const (
+	triggerCLI      = "cli"
+	triggerCI       = "ci"
+	triggerRollback = "rollback"
+)
+
+// triggerMetadata describes who is deploying: the GitLab user and pipeline in
+// GitLab CI, the local user otherwise
+func triggerMetadata() map[string]interface{} {
+	metadata := map[string]interface{}{metadataTrigger: triggerCLI}
+	if os.Getenv("GITLAB_CI") != "" {
+		metadata[metadataTrigger] = triggerCI
+		if pipeline := os.Getenv("CI_PIPELINE_URL"); pipeline != "" {
+			metadata["pipeline_url"] = pipeline
+		}
+	}
+	if u := currentUser(); u != "" {
+		metadata[metadataTriggeredBy] = u
+	}
+	return metadata
+}
+
+// currentUser returns the GitLab user in GitLab CI, the local user otherwise
+func currentUser() string {
+	if os.Getenv("GITLAB_CI") != "" {
+		return os.Getenv("GITLAB_USER_LOGIN")
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func TestPlanHistory(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	client := NewMockMotherGooseClient()
+	client.DeploymentPlans["my-app"] = []*deployer.DeploymentPlan{
+		{ID: "plan-1", Status: deployer.PlanStatusRolledBack, CreatedAt: now.AddDate(0, 0, -20)},
+		{ID: "plan-3", Status: deployer.PlanStatusApplied, CreatedAt: now.AddDate(0, 0, -2),
+			Metadata: map[string]interface{}{metadataTriggeredBy: "alice", metadataTrigger: triggerCI}},
+		{ID: "plan-2", Status: deployer.PlanStatusFailed, CreatedAt: now.AddDate(0, 0, -5)},
+		{ID: "plan-4", Status: deployer.PlanStatusApplied, CreatedAt: now.AddDate(0, 0, -1), RollbackPlan: "plan-1"},
+	}
+
+	tests := []struct {
+		name   string
+		filter historyFilter
+		want   []string
+	}{
+		{name: "newest first", want: []string{"plan-4", "plan-3", "plan-2", "plan-1"}},
+		{name: "limit", filter: historyFilter{Limit: 2}, want: []string{"plan-4", "plan-3"}},
+		{name: "status", filter: historyFilter{Status: deployer.PlanStatusApplied}, want: []string{"plan-4", "plan-3"}},
+		{name: "since", filter: historyFilter{Since: now.AddDate(0, 0, -7)}, want: []string{"plan-4", "plan-3", "plan-2"}},
+		{name: "limit after filtering", filter: historyFilter{Status: deployer.PlanStatusApplied, Limit: 1}, want: []string{"plan-4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := planHistory(context.Background(), client, "my-app", tt.filter)
+			if err != nil {
+				t.Fatalf("planHistory failed: %v", err)
+			}
+			var got []string
+			for _, plan := range report.Plans {
+				got = append(got, plan.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	report, err := planHistory(context.Background(), client, "my-app", historyFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	printHistory(&out, report)
+	for _, want := range []string{"TRIGGERED BY", "alice (ci)", "(rollback) → plan-1"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"7d":                   now.AddDate(0, 0, -7),
+		"36h":                  now.Add(-36 * time.Hour),
+		"2024-05-01":           time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"2024-05-01T08:30:00Z": time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+	}
+	for value, want := range tests {
+		got, err := parseSince(value, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, bad := range []string{"yesterday", "-7d", "7w"} {
+		if _, err := parseSince(bad, now); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestTriggerMetadata(t *testing.T) {
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("GITLAB_USER_LOGIN", "alice")
+	t.Setenv("CI_PIPELINE_URL", "https://gitlab.example.com/platform/nest/-/pipelines/42")
+
+	metadata := triggerMetadata()
+	if metadata[metadataTrigger] != triggerCI || metadata[metadataTriggeredBy] != "alice" || metadata["pipeline_url"] == nil {
+		t.Errorf("expected the GitLab user and pipeline, got %v", metadata)
+	}
+
+	t.Setenv("GITLAB_CI", "")
+	if metadata := triggerMetadata(); metadata[metadataTrigger] != triggerCLI {
+		t.Errorf("expected a CLI trigger outside CI, got %v", metadata)
+	}
+}
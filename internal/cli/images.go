@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	imagesProvider string
+	imagesFamily   string
+	imagesAll      bool
+)
+
+// imagesCmd represents the images command
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "Inspect the boot disk images VM runners can use",
+}
+
+// imagesListCmd represents the images list command
+var imagesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the base images available to VM runners",
+	Long: `List the boot disk images VM runners of a cloud provider can start
+from, to choose the image or image_id of an Egg's runner block.
+
+Only the latest image of each family is listed unless --all is set; a runner
+with image = "<family>" starts from the family's latest image when deployed.
+--family keeps the families starting with a prefix.
+
+On Yandex Cloud the public images are listed, and the images of the folder
+runners are deployed to when YC_FOLDER_ID is set. Listing AWS and Azure
+images is not supported yet.
+
+Example:
+  gosling images list --provider yandex
+  gosling images list --provider yandex --family ubuntu --all
+  gosling images list --provider yandex --output json`,
+	Args: cobra.NoArgs,
+	RunE: runImagesList,
+}
+
+func init() {
+	rootCmd.AddCommand(imagesCmd)
+	imagesCmd.AddCommand(imagesListCmd)
+	imagesListCmd.Flags().StringVar(&imagesProvider, "provider", "", "Cloud provider: yandex, aws, or azure")
+	imagesListCmd.Flags().StringVar(&imagesFamily, "family", "", "Only list the image families starting with this prefix")
+	imagesListCmd.Flags().BoolVar(&imagesAll, "all", false, "List every image, not only the latest of each family")
+	mustMarkRequired(imagesListCmd, "provider")
+}
+
+// imageSource returns the boot disk images of a cloud provider
+type imageSource interface {
+	Images(ctx context.Context, provider deployer.CloudProvider) ([]deployer.Image, error)
+}
+
+// imageOutput is an image listed by `gosling images list`
+type imageOutput struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Family      string    `json:"family,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func runImagesList(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	d, err := deployer.NewDeployer(ctx)
+	if err != nil {
+		return err
+	}
+	images, err := listImages(ctx, d, imagesProvider, imagesFamily, imagesAll)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, images)
+	}
+	return printImages(os.Stdout, images)
+}
+
+// listImages lists the images of provider whose family starts with family,
+// only the latest of each family unless all is set
+func listImages(ctx context.Context, source imageSource, provider, family string, all bool) ([]imageOutput, error) {
+	supported := false
+	for _, name := range parser.CloudProviders {
+		supported = supported || name == provider
+	}
+	if !supported {
+		return nil, fmt.Errorf("unsupported cloud provider %q: must be one of %s", provider, strings.Join(parser.CloudProviders, ", "))
+	}
+	images, err := source.Images(ctx, deployer.CloudProvider(provider))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	if !all {
+		images = deployer.LatestImages(images)
+	}
+
+	output := []imageOutput{}
+	for _, image := range images {
+		if family != "" && !strings.HasPrefix(image.Family, family) {
+			continue
+		}
+		output = append(output, imageOutput{
+			ID:          image.ID,
+			Name:        image.Name,
+			Family:      image.Family,
+			Description: image.Description,
+			CreatedAt:   image.CreatedAt,
+		})
+	}
+	return output, nil
+}
+
+// printImages writes images as a table
+func printImages(w io.Writer, images []imageOutput) error {
+	if len(images) == 0 {
+		_, err := fmt.Fprintln(w, "No images found")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tID\tNAME\tCREATED")
+	for _, image := range images {
+		family := image.Family
+		if family == "" {
+			family = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", family, image.ID, image.Name, image.CreatedAt.Format("2006-01-02"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "\nSet runner { image = \"<family>\" } for a family's latest image, or image_id for a fixed one.")
+	return err
+}
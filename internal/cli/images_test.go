@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+type fakeImageSource []deployer.Image
+
+func (f fakeImageSource) Images(ctx context.Context, provider deployer.CloudProvider) ([]deployer.Image, error) {
+	return f, nil
+}
+
+func TestListImages(t *testing.T) {
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	source := fakeImageSource{
+		{ID: "fd8ubuntu1", Name: "ubuntu-22-04-docker-v1", Family: "ubuntu-22-04-docker", CreatedAt: created},
+		{ID: "fd8ubuntu2", Name: "ubuntu-22-04-docker-v2", Family: "ubuntu-22-04-docker", CreatedAt: created.Add(24 * time.Hour)},
+		{ID: "fd8debian1", Name: "debian-12-v1", Family: "debian-12", CreatedAt: created},
+	}
+
+	images, err := listImages(context.Background(), source, "yandex", "ubuntu", false)
+	if err != nil {
+		t.Fatalf("listImages failed: %v", err)
+	}
+	if len(images) != 1 || images[0].ID != "fd8ubuntu2" {
+		t.Errorf("expected the latest ubuntu image, got %+v", images)
+	}
+	if images, err = listImages(context.Background(), source, "yandex", "", true); err != nil || len(images) != 3 {
+		t.Errorf("expected every image with all, got %+v, %v", images, err)
+	}
+
+	var b strings.Builder
+	if err := printImages(&b, images); err != nil {
+		t.Fatalf("printImages failed: %v", err)
+	}
+	if !strings.Contains(b.String(), "ubuntu-22-04-docker  fd8ubuntu2") {
+		t.Errorf("expected the family and ID in the table, got:\n%s", b.String())
+	}
+
+	if _, err := listImages(context.Background(), source, "gcp", "", false); err == nil || !strings.Contains(err.Error(), `unsupported cloud provider "gcp"`) {
+		t.Errorf("expected an unsupported provider error, got %v", err)
+	}
+}
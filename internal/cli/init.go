@@ -131,6 +131,9 @@ For more information, see the Gosling CLI documentation.
 .terraform/
 .terraform.lock.hcl
 
+# Local gosling state (audit log)
+.gosling/
+
 # Sensitive files
 *.secret
 *.key
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// jobDeployOutput describes what deploy did (or would do) for a single Job
+type jobDeployOutput struct {
+	JobName  string `json:"job_name"`
+	Status   string `json:"status"`
+	Schedule string `json:"schedule"`
+	Error    string `json:"error,omitempty"` // Why the Job was not registered
+}
+
+// parseJobConfigs parses every Jobs/<name>.fly, merging the overlay for env
+// over it when env is set. Overlaid configurations are validated. A Nest
+// without a Jobs directory has no Jobs.
+func parseJobConfigs(jobsDir, env string) ([]*deployer.JobConfig, error) {
+	entries, err := os.ReadDir(jobsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Jobs directory: %w", err)
+	}
+	var jobs []*deployer.JobConfig
+	p := parser.NewParser()
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), "_") || !parser.IsFlyFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(jobsDir, entry.Name())
+		// Overlays are merged into the Job they belong to
+		if base, _, ok := parser.SplitOverlayPath(path); ok {
+			if _, err := os.Stat(base); err == nil {
+				continue
+			}
+		}
+		config, err := p.ParseFileForEnv(path, env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if env != "" {
+			if result := parser.NewValidator(config).Validate(); !result.IsValid() {
+				return nil, fmt.Errorf("invalid %s configuration for %s: %s", env, path, result.Error())
+			}
+		}
+		for i := range config.Blocks {
+			if config.Blocks[i].Type != "job" {
+				continue
+			}
+			job, err := deployer.ParseJob(&config.Blocks[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert %s: %w", path, err)
+			}
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// deployJobs registers jobs with MotherGoose, skipping those it already has
+// with the same configuration. With dryRun nothing is changed. Every Job is
+// attempted and the first failure is returned.
+func deployJobs(ctx context.Context, log *slog.Logger, jobs []*deployer.JobConfig, client mothergoose.MotherGooseClient, dryRun bool) ([]*jobDeployOutput, error) {
+	deployed, err := client.ListJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string][]byte, len(deployed))
+	for _, job := range deployed {
+		current[job.Name], _ = json.Marshal(job)
+	}
+
+	results := make([]*jobDeployOutput, 0, len(jobs))
+	var first error
+	for _, job := range jobs {
+		result := &jobDeployOutput{JobName: job.Name, Schedule: job.Schedule}
+		results = append(results, result)
+		if desired, _ := json.Marshal(job); string(desired) == string(current[job.Name]) {
+			result.Status = deployStatusUnchanged
+			log.Info(fmt.Sprintf("Job %s: unchanged", job.Name), "job", job.Name, "status", result.Status)
+			continue
+		}
+		if dryRun {
+			result.Status = deployStatusPlanned
+			log.Info(fmt.Sprintf("Job %s: would be registered (schedule %q)", job.Name, job.Schedule), "job", job.Name, "status", result.Status)
+			continue
+		}
+		if err := client.CreateOrUpdateJob(ctx, job); err != nil {
+			result.Status = deployStatusFailed
+			result.Error = err.Error()
+			log.Error(fmt.Sprintf("Job %s: failed: %v", job.Name, err), "job", job.Name, "status", result.Status)
+			if first == nil {
+				first = fmt.Errorf("failed to deploy job %s: %w", job.Name, err)
+			}
+			continue
+		}
+		result.Status = deployStatusApplied
+		log.Info(fmt.Sprintf("Job %s: registered (schedule %q)", job.Name, job.Schedule), "job", job.Name, "status", result.Status)
+	}
+	return results, first
+}
+
+// jobStatusOutput is a Job row of `gosling status --all`
+type jobStatusOutput struct {
+	JobName    string   `json:"job_name"`
+	Schedule   string   `json:"schedule"`
+	RunnerType string   `json:"runner_type"`
+	RunnerTags []string `json:"runner_tags"`
+}
+
+// listJobStatus returns the Jobs registered with MotherGoose, sorted by name.
+// A MotherGoose without the Jobs API has none.
+func listJobStatus(ctx context.Context, client mothergoose.MotherGooseClient) ([]*jobStatusOutput, error) {
+	jobs, err := client.ListJobs(ctx)
+	if mothergoose.IsNotFound(err) {
+		return []*jobStatusOutput{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	out := make([]*jobStatusOutput, 0, len(jobs))
+	for _, job := range jobs {
+		out = append(out, &jobStatusOutput{
+			JobName:    job.Name,
+			Schedule:   job.Schedule,
+			RunnerType: string(job.Runner.Type),
+			RunnerTags: job.Runner.Tags,
+		})
+	}
+	return out, nil
+}
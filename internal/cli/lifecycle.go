@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lifecycleEgg    string
+	lifecycleRunner string
+	lifecycleAPIURL string
+	lifecycleAPIKey string
+)
+
+// runnerPauseCmd represents the runner pause command
+var runnerPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Stop runners from accepting new jobs",
+	Long: `Pause the runners of an Egg, or a single runner with --runner. Paused
+runners finish the jobs they are running but pick up no new ones until
+resumed. The Egg's .fly files are not changed.
+
+Example:
+  gosling runner pause --egg my-app --runner runner-123
+  gosling runner pause --egg my-app`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRunnerLifecycle(mothergoose.RunnerActionPause)
+	},
+}
+
+// runnerResumeCmd represents the runner resume command
+var runnerResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Let paused or draining runners accept jobs again",
+	Long: `Resume the paused or draining runners of an Egg, or a single runner
+with --runner.
+
+Example:
+  gosling runner resume --egg my-app`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRunnerLifecycle(mothergoose.RunnerActionResume)
+	},
+}
+
+// runnerDrainCmd represents the runner drain command
+var runnerDrainCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "Retire runners once their running jobs finish",
+	Long: `Drain the runners of an Egg, or a single runner with --runner. Draining
+runners accept no new jobs and are retired by MotherGoose once their running
+jobs finish. Use 'gosling runner resume' to cancel a drain.
+
+Example:
+  gosling runner drain --egg my-app --runner runner-123`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRunnerLifecycle(mothergoose.RunnerActionDrain)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{runnerPauseCmd, runnerResumeCmd, runnerDrainCmd} {
+		runnerCmd.AddCommand(cmd)
+		cmd.Flags().StringVar(&lifecycleEgg, "egg", "", "Egg name")
+		cmd.Flags().StringVar(&lifecycleRunner, "runner", "", "Runner ID (default: all runners of the Egg)")
+		cmd.Flags().StringVar(&lifecycleAPIURL, "api-url", "", "MotherGoose API URL")
+		cmd.Flags().StringVar(&lifecycleAPIKey, "api-key", "", "MotherGoose API key")
+		mustMarkRequired(cmd, "egg")
+		mustRegisterEggCompletion(cmd, completeEggNames(true))
+	}
+}
+
+// runnerLifecycleOutput is the machine-readable result of `gosling runner pause|resume|drain`
+type runnerLifecycleOutput struct {
+	EggName string                `json:"egg_name"`
+	Action  string                `json:"action"`
+	Runners []*mothergoose.Runner `json:"runners"`
+}
+
+func runRunnerLifecycle(action mothergoose.RunnerAction) error {
+	conn, err := resolveAPI(lifecycleAPIURL, lifecycleAPIKey)
+	if err != nil {
+		return err
+	}
+	client := conn.client()
+
+	result, err := applyRunnerAction(context.Background(), client, action, lifecycleEgg, lifecycleRunner)
+	if err != nil {
+		return err
+	}
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, result)
+	}
+
+	if len(result.Runners) == 0 {
+		fmt.Printf("No runners of egg %s to %s\n", result.EggName, action)
+		return nil
+	}
+	fmt.Printf("✅ %s %d runner(s) of egg %s\n\n", actionPastTense(action), len(result.Runners), result.EggName)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUNNER ID\tSTATE\tREGION")
+	for _, runner := range result.Runners {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", runner.ID, runner.State, runner.Region)
+	}
+	return w.Flush()
+}
+
+// applyRunnerAction pauses, resumes or drains the runners of an Egg
+func applyRunnerAction(ctx context.Context, client mothergoose.MotherGooseClient, action mothergoose.RunnerAction, eggName, runnerID string) (*runnerLifecycleOutput, error) {
+	var runners []*mothergoose.Runner
+	var err error
+	switch action {
+	case mothergoose.RunnerActionPause:
+		runners, err = client.PauseRunners(ctx, eggName, runnerID)
+	case mothergoose.RunnerActionResume:
+		runners, err = client.ResumeRunners(ctx, eggName, runnerID)
+	case mothergoose.RunnerActionDrain:
+		runners, err = client.DrainRunners(ctx, eggName, runnerID)
+	default:
+		return nil, fmt.Errorf("unsupported runner action %q", action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if runners == nil {
+		runners = []*mothergoose.Runner{}
+	}
+	return &runnerLifecycleOutput{EggName: eggName, Action: string(action), Runners: runners}, nil
+}
+
+// actionPastTense describes a completed runner action
+func actionPastTense(action mothergoose.RunnerAction) string {
+	switch action {
+	case mothergoose.RunnerActionPause:
+		return "Paused"
+	case mothergoose.RunnerActionResume:
+		return "Resumed"
+	case mothergoose.RunnerActionDrain:
+		return "Draining"
+	default:
+		return string(action)
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+func TestApplyRunnerAction(t *testing.T) {
+	client := NewMockMotherGooseClient()
+	client.EggStatuses["my-app"] = &mothergoose.EggStatus{
+		EggName: "my-app",
+		ActiveRunners: []*mothergoose.Runner{
+			{ID: "runner-1", State: "active"},
+			{ID: "runner-2", State: "active"},
+		},
+	}
+	ctx := context.Background()
+
+	result, err := applyRunnerAction(ctx, client, mothergoose.RunnerActionPause, "my-app", "runner-1")
+	if err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if len(result.Runners) != 1 || result.Runners[0].State != "paused" || result.Action != "pause" {
+		t.Errorf("expected runner-1 paused, got %+v", result)
+	}
+	if state := client.EggStatuses["my-app"].ActiveRunners[1].State; state != "active" {
+		t.Errorf("expected runner-2 untouched, got %s", state)
+	}
+
+	result, err = applyRunnerAction(ctx, client, mothergoose.RunnerActionDrain, "my-app", "")
+	if err != nil {
+		t.Fatalf("drain failed: %v", err)
+	}
+	if len(result.Runners) != 2 {
+		t.Errorf("expected every runner drained, got %d", len(result.Runners))
+	}
+
+	result, err = applyRunnerAction(ctx, client, mothergoose.RunnerActionResume, "my-app", "runner-2")
+	if err != nil || result.Runners[0].State != "active" {
+		t.Errorf("expected runner-2 resumed, got %+v (%v)", result, err)
+	}
+
+	if _, err := applyRunnerAction(ctx, client, mothergoose.RunnerActionPause, "my-app", "missing"); err == nil {
+		t.Error("expected error for unknown runner")
+	}
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/polar-gosling/gosling/internal/diagnostic"
+	"github.com/polar-gosling/gosling/internal/lint"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintPath      string
+	lintEnable    []string
+	lintDisable   []string
+	lintListRules bool
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [file]",
+	Short: "Check .fly configuration files for risky or unusual settings",
+	Long: `Run advisory lint rules against .fly configuration files.
+
+Unlike 'gosling validate', lint findings do not mean a configuration is
+undeployable. Each rule has an ID and a severity (warning or error); only
+error findings make the command exit with a non-zero status.
+
+Rules can be configured per Nest in a .goslinglint.fly file at the Nest root:
+
+  lint {
+    disable = ["missing-idle-timeout"]
+
+    rule "concurrent-exceeds-cpu" {
+      severity = "error"
+    }
+  }
+
+With --output sarif, findings are written as SARIF 2.1.0 results, warnings
+at level "warning".
+
+Example:
+  gosling lint
+  gosling lint Eggs/my-app/config.fly
+  gosling lint --disable missing-idle-timeout
+  gosling lint --list-rules
+  gosling lint --output sarif > gosling-lint.sarif`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLint,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVarP(&lintPath, "path", "p", "", "Path to Nest repository (default: current directory)")
+	lintCmd.Flags().StringSliceVar(&lintEnable, "enable", nil, "Enable the given rule IDs (comma-separated)")
+	lintCmd.Flags().StringSliceVar(&lintDisable, "disable", nil, "Disable the given rule IDs (comma-separated)")
+	lintCmd.Flags().BoolVar(&lintListRules, "list-rules", false, "List available lint rules and exit")
+	enableSARIF(lintCmd)
+}
+
+// lintOutput is the machine-readable result of `gosling lint`
+type lintOutput struct {
+	Findings     []lint.Finding `json:"findings"`
+	WarningCount int            `json:"warning_count"`
+	ErrorCount   int            `json:"error_count"`
+}
+
+// lintRuleOutput describes a rule for `gosling lint --list-rules`
+type lintRuleOutput struct {
+	ID               string        `json:"id"`
+	Severity         lint.Severity `json:"severity"`
+	EnabledByDefault bool          `json:"enabled_by_default"`
+	Description      string        `json:"description"`
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	if lintListRules {
+		return listLintRules()
+	}
+
+	nestRoot := lintPath
+	if nestRoot == "" {
+		// The Nest root is optional when linting a single file; it only locates .goslinglint.fly
+		nestRoot, _ = findNestRoot()
+	}
+
+	var filesToLint []string
+	if len(args) > 0 {
+		absPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to resolve file path: %w", err)
+		}
+		filesToLint = append(filesToLint, absPath)
+	} else {
+		if nestRoot == "" {
+			return fmt.Errorf("not in a Nest repository: Nest repository not found\nRun 'gosling init' to create a new Nest repository")
+		}
+		var err error
+		filesToLint, err = findFlyFiles(nestRoot)
+		if err != nil {
+			return fmt.Errorf("failed to find .fly files: %w", err)
+		}
+	}
+
+	lintConfig := lint.NewConfig()
+	if nestRoot != "" {
+		var err error
+		lintConfig, err = lint.LoadConfig(filepath.Join(nestRoot, lint.ConfigFileName))
+		if err != nil {
+			return err
+		}
+	}
+	if err := lintConfig.Enable(lintEnable...); err != nil {
+		return err
+	}
+	if err := lintConfig.Disable(lintDisable...); err != nil {
+		return err
+	}
+
+	linter := lint.New(lintConfig)
+	p := parser.NewParser()
+	report := &lintOutput{Findings: []lint.Finding{}}
+	var diags []diagnostic.Diagnostic
+
+	for _, filePath := range filesToLint {
+		config, err := p.ParseFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", filePath, err)
+		}
+		for _, finding := range linter.Lint(config) {
+			diags = append(diags, findingDiagnostic(finding))
+			if nestRoot != "" {
+				if rel, err := filepath.Rel(nestRoot, finding.File); err == nil {
+					finding.File = rel
+				}
+			}
+			report.Findings = append(report.Findings, finding)
+			if finding.Severity == lint.SeverityError {
+				report.ErrorCount++
+			} else {
+				report.WarningCount++
+			}
+		}
+	}
+
+	// Findings are shown relative to the Nest, or to the working directory
+	// for a single file
+	root := nestRoot
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	if outputFormat == outputSARIF {
+		if err := writeSARIF(os.Stdout, diags, root, describeLintRule); err != nil {
+			return err
+		}
+	} else if isStructuredOutput() {
+		if err := writeStructured(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		if err := diagnostic.NewRenderer(root, useColor(os.Stdout)).Render(os.Stdout, diags); err != nil {
+			return err
+		}
+		if len(report.Findings) > 0 {
+			fmt.Println()
+			fmt.Println(strings.Repeat("─", 50))
+		}
+		fmt.Printf("Linted %d file(s): %d warning(s), %d error(s)\n", len(filesToLint), report.WarningCount, report.ErrorCount)
+	}
+
+	if report.ErrorCount > 0 {
+		return fmt.Errorf("lint failed with %d error(s)", report.ErrorCount)
+	}
+	return nil
+}
+
+// findingDiagnostic returns the diagnostic rendering a lint finding, coded
+// with its rule ID
+func findingDiagnostic(f lint.Finding) diagnostic.Diagnostic {
+	severity := diagnostic.SeverityWarning
+	if f.Severity == lint.SeverityError {
+		severity = diagnostic.SeverityError
+	}
+	return diagnostic.Diagnostic{
+		Severity: severity,
+		Code:     f.RuleID,
+		Message:  f.Message,
+		File:     f.File,
+		Line:     f.Line,
+		Column:   f.Column,
+	}
+}
+
+// describeLintRule returns the description of a lint rule ID
+func describeLintRule(id string) string {
+	for _, rule := range lint.Rules() {
+		if rule.ID == id {
+			return rule.Description
+		}
+	}
+	return ""
+}
+
+func listLintRules() error {
+	rules := lint.Rules()
+	if isStructuredOutput() {
+		out := make([]lintRuleOutput, 0, len(rules))
+		for _, rule := range rules {
+			out = append(out, lintRuleOutput{
+				ID:               rule.ID,
+				Severity:         rule.Severity,
+				EnabledByDefault: rule.EnabledByDefault,
+				Description:      rule.Description,
+			})
+		}
+		return writeStructured(os.Stdout, map[string]interface{}{"rules": out})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE ID\tSEVERITY\tDEFAULT\tDESCRIPTION")
+	fmt.Fprintln(w, "-------\t--------\t-------\t-----------")
+	for _, rule := range rules {
+		enabled := "on"
+		if !rule.EnabledByDefault {
+			enabled = "off"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", rule.ID, rule.Severity, enabled, rule.Description)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/polar-gosling/gosling/internal/secrets"
+	"github.com/polar-gosling/gosling/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listenAddr          string
+	listenPath          string
+	listenSecret        string
+	listenSigningSecret string
+	listenForward       bool
+	listenForwardURL    string
+	listenAPIURL        string
+	listenAPIKey        string
+)
+
+// listenForwardTimeout bounds how long a webhook forwarded to MotherGoose may take
+const listenForwardTimeout = 30 * time.Second
+
+// listenCmd represents the listen command
+var listenCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "Receive GitLab webhooks locally for debugging",
+	Long: `Run a local HTTP server that receives GitLab webhooks, checks them and
+prints each one as it arrives, to debug webhook wiring without deploying
+anything. Press Ctrl+C to stop.
+
+Webhooks are accepted at http://<addr><path>. With --secret (or
+$GOSLING_WEBHOOK_SECRET), requests without that X-Gitlab-Token are rejected
+with 401; with --signing-secret, so are those without a matching
+X-Gitlab-Signature payload HMAC. Both may be secret references.
+
+Push and pipeline events are summarized; other events are printed with
+their name and size. With --output json or yaml each webhook is written as
+its own document (one JSON object per line for json).
+
+With --forward, accepted webhooks are also sent on to the MotherGoose
+webhook endpoint (<api-url>/webhooks/gitlab, or --forward-url) with their
+GitLab headers, and MotherGoose's response is returned to the sender.
+
+To reach a local listener from GitLab, expose it with a tunnel (e.g. ssh -R
+or ngrok) and point a project webhook at the tunnel URL.
+
+Example:
+  gosling listen
+  gosling listen --addr :8088 --secret dev-token
+  gosling listen --forward --api-url http://localhost:8000`,
+	Args: cobra.NoArgs,
+	RunE: runListen,
+}
+
+func init() {
+	rootCmd.AddCommand(listenCmd)
+	listenCmd.Flags().StringVar(&listenAddr, "addr", "localhost:8088", "Address to listen on")
+	listenCmd.Flags().StringVar(&listenPath, "path", "/webhooks/gitlab", "URL path webhooks are accepted at")
+	listenCmd.Flags().StringVar(&listenSecret, "secret", "", "Expected X-Gitlab-Token, or a secret reference (default: $GOSLING_WEBHOOK_SECRET)")
+	listenCmd.Flags().StringVar(&listenSigningSecret, "signing-secret", "", "Key of the X-Gitlab-Signature payload HMAC, or a secret reference")
+	listenCmd.Flags().BoolVar(&listenForward, "forward", false, "Forward accepted webhooks to MotherGoose")
+	listenCmd.Flags().StringVar(&listenForwardURL, "forward-url", "", "Webhook endpoint to forward to (default: <api-url>/webhooks/gitlab)")
+	listenCmd.Flags().StringVar(&listenAPIURL, "api-url", "", "MotherGoose API URL")
+	listenCmd.Flags().StringVar(&listenAPIKey, "api-key", "", "MotherGoose API key")
+}
+
+func runListen(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(commandContext(cmd), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	secretRef := listenSecret
+	if secretRef == "" {
+		secretRef = os.Getenv("GOSLING_WEBHOOK_SECRET")
+	}
+	token, err := resolveListenSecret(ctx, secretRef, "--secret")
+	if err != nil {
+		return err
+	}
+	signingSecret, err := resolveListenSecret(ctx, listenSigningSecret, "--signing-secret")
+	if err != nil {
+		return err
+	}
+	verifier := &webhook.Verifier{Token: token}
+	if signingSecret != "" {
+		verifier.Secret = []byte(signingSecret)
+	}
+
+	forwardURL := listenForwardURL
+	if listenForward && forwardURL == "" {
+		conn, err := resolveAPI(listenAPIURL, listenAPIKey)
+		if err != nil {
+			return err
+		}
+		forwardURL = strings.TrimSuffix(conn.APIURL, "/") + "/webhooks/gitlab"
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", listenAddr, err)
+	}
+	log := logger("listen")
+	log.Info(fmt.Sprintf("👂 Listening for GitLab webhooks at http://%s%s", listener.Addr(), listenPath), "addr", listener.Addr().String())
+	if token == "" {
+		log.Warn("No --secret: accepting webhooks without checking X-Gitlab-Token")
+	}
+	if forwardURL != "" {
+		log.Info(fmt.Sprintf("↪ Forwarding accepted webhooks to %s", forwardURL), "forward_url", forwardURL)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(listenPath, newWebhookListener(verifier, forwardURL, os.Stdout))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- server.Serve(listener) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// resolveListenSecret returns the value of a secret flag, resolving secret references
+func resolveListenSecret(ctx context.Context, ref, flag string) (string, error) {
+	if !secrets.IsReference(ref) {
+		return ref, nil
+	}
+	value, err := secrets.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", flag, err)
+	}
+	return value, nil
+}
+
+// listenEventOutput is the machine-readable record of a received webhook
+type listenEventOutput struct {
+	ReceivedAt time.Time `json:"received_at"`
+	Event      string    `json:"event"`
+	Accepted   bool      `json:"accepted"`
+	Error      string    `json:"error,omitempty"` // Why the webhook was rejected or not forwarded
+	Size       int       `json:"size"`
+	// Payload is the decoded push or pipeline event, or the raw JSON of other events
+	Payload       any    `json:"payload,omitempty"`
+	ForwardStatus string `json:"forward_status,omitempty"`
+}
+
+// webhookListener receives GitLab webhooks, prints them to out and forwards
+// the accepted ones to forwardURL, if set
+type webhookListener struct {
+	verifier   *webhook.Verifier
+	forwardURL string
+	client     *http.Client
+	now        func() time.Time
+
+	mu  sync.Mutex // serializes writes to out
+	out io.Writer
+}
+
+func newWebhookListener(verifier *webhook.Verifier, forwardURL string, out io.Writer) *webhookListener {
+	return &webhookListener{
+		verifier:   verifier,
+		forwardURL: forwardURL,
+		client:     &http.Client{Timeout: listenForwardTimeout},
+		now:        time.Now,
+		out:        out,
+	}
+}
+
+func (l *webhookListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	record := &listenEventOutput{ReceivedAt: l.now(), Event: r.Header.Get(webhook.EventHeader)}
+	defer l.print(record)
+
+	body, err := l.verifier.Verify(r)
+	if err != nil {
+		record.Error = err.Error()
+		status := http.StatusUnauthorized
+		if errors.Is(err, webhook.ErrPayloadTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	record.Size = len(body)
+
+	payload, err := webhook.ParseEvent(record.Event, body)
+	switch {
+	case errors.Is(err, webhook.ErrUnsupportedEvent):
+		if json.Valid(body) {
+			record.Payload = json.RawMessage(body)
+		}
+	case err != nil:
+		record.Error = err.Error()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		record.Payload = payload
+	}
+	record.Accepted = true
+
+	if l.forwardURL == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	resp, err := l.forward(r, body)
+	if err != nil {
+		record.Error = err.Error()
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	record.ForwardStatus = resp.Status
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// forward sends a webhook on to forwardURL with its GitLab headers
+func (l *webhookListener) forward(r *http.Request, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, l.forwardURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range r.Header {
+		if name == "Content-Type" || strings.HasPrefix(name, "X-Gitlab-") {
+			req.Header[name] = values
+		}
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to forward webhook: %w", err)
+	}
+	return resp, nil
+}
+
+// print writes a received webhook to out in the selected output format
+func (l *webhookListener) print(record *listenEventOutput) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch outputFormat {
+	case outputJSON:
+		json.NewEncoder(l.out).Encode(record)
+	case outputYAML:
+		if data, err := yaml.Marshal(record); err == nil {
+			fmt.Fprintf(l.out, "---\n%s", data)
+		}
+	default:
+		fmt.Fprint(l.out, formatWebhook(record))
+	}
+}
+
+// formatWebhook summarizes a received webhook for people
+func formatWebhook(record *listenEventOutput) string {
+	var b strings.Builder
+	event := record.Event
+	if event == "" {
+		event = "(no X-Gitlab-Event)"
+	}
+	stamp := record.ReceivedAt.Format("15:04:05")
+	if !record.Accepted {
+		fmt.Fprintf(&b, "%s ❌ %s rejected: %s\n", stamp, event, record.Error)
+		return b.String()
+	}
+
+	switch payload := record.Payload.(type) {
+	case *webhook.PushEvent:
+		fmt.Fprintf(&b, "%s 📥 %s %s %s: %d commit(s) by %s\n", stamp, event, payload.Project.PathWithNamespace,
+			payload.Ref, payload.TotalCommitsCount, payload.UserUsername)
+		for _, commit := range payload.Commits {
+			fmt.Fprintf(&b, "   %.8s %s\n", commit.ID, commit.Title)
+		}
+		for _, file := range payload.ChangedFiles() {
+			fmt.Fprintf(&b, "   ~ %s\n", file)
+		}
+	case *webhook.PipelineEvent:
+		pipeline := payload.ObjectAttributes
+		fmt.Fprintf(&b, "%s 📥 %s %s #%d on %s: %s", stamp, event, payload.Project.PathWithNamespace,
+			pipeline.ID, pipeline.Ref, pipeline.Status)
+		if pipeline.Duration > 0 {
+			fmt.Fprintf(&b, " (%s)", time.Duration(pipeline.Duration*float64(time.Second)))
+		}
+		b.WriteString("\n")
+		for _, build := range payload.Builds {
+			fmt.Fprintf(&b, "   %s/%s: %s", build.Stage, build.Name, build.Status)
+			if build.Runner != nil {
+				fmt.Fprintf(&b, " on %s", build.Runner.Description)
+			}
+			b.WriteString("\n")
+		}
+	default:
+		fmt.Fprintf(&b, "%s 📥 %s (%d bytes)\n", stamp, event, record.Size)
+	}
+
+	switch {
+	case record.ForwardStatus != "":
+		fmt.Fprintf(&b, "   ↪ forwarded: %s\n", record.ForwardStatus)
+	case record.Error != "":
+		fmt.Fprintf(&b, "   ↪ not forwarded: %s\n", record.Error)
+	}
+	return b.String()
+}
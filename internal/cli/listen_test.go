@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/webhook"
+)
+
+const listenPushPayload = `{
+  "object_kind": "push",
+  "ref": "refs/heads/main",
+  "user_username": "jsmith",
+  "project": {"id": 15, "path_with_namespace": "platform/nest"},
+  "commits": [
+    {
+      "id": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+      "title": "Grow my-app runners",
+      "timestamp": "2024-01-02T10:00:00Z",
+      "modified": ["Eggs/my-app/config.fly"]
+    }
+  ],
+  "total_commits_count": 1
+}`
+
+func newTestListener(forwardURL string) (*webhookListener, *bytes.Buffer) {
+	var out bytes.Buffer
+	l := newWebhookListener(&webhook.Verifier{Token: "dev-token"}, forwardURL, &out)
+	l.now = func() time.Time { return time.Date(2024, 1, 2, 10, 0, 5, 0, time.UTC) }
+	return l, &out
+}
+
+func TestWebhookListener(t *testing.T) {
+	l, out := newTestListener("")
+	server := httptest.NewServer(l)
+	defer server.Close()
+
+	send := func(event, token, body string) int {
+		t.Helper()
+		req, err := webhook.NewRequest(server.URL, event, []byte(body), token, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := send(webhook.EventPush, "dev-token", listenPushPayload); status != http.StatusOK {
+		t.Errorf("expected the push to be accepted, got %d", status)
+	}
+	if status := send(webhook.EventPush, "wrong", listenPushPayload); status != http.StatusUnauthorized {
+		t.Errorf("expected a wrong token to be rejected, got %d", status)
+	}
+	if status := send("Issue Hook", "dev-token", `{"object_kind": "issue"}`); status != http.StatusOK {
+		t.Errorf("expected other events to be accepted, got %d", status)
+	}
+	if status := send(webhook.EventPipeline, "dev-token", `{"object_attributes": {"created_at": "yesterday"}}`); status != http.StatusBadRequest {
+		t.Errorf("expected an invalid payload to fail, got %d", status)
+	}
+
+	want := []string{
+		"10:00:05 📥 Push Hook platform/nest refs/heads/main: 1 commit(s) by jsmith",
+		"   da156088 Grow my-app runners",
+		"   ~ Eggs/my-app/config.fly",
+		"10:00:05 ❌ Push Hook rejected: webhook token is invalid",
+		"10:00:05 📥 Issue Hook (24 bytes)",
+		"10:00:05 ❌ Pipeline Hook rejected: failed to decode Pipeline Hook payload",
+	}
+	for _, line := range want {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected %q in output:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestWebhookListenerForward(t *testing.T) {
+	var forwarded *http.Request
+	var forwardedBody []byte
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r
+		forwardedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status": "queued"}`))
+	}))
+	defer backend.Close()
+
+	l, out := newTestListener(backend.URL + "/webhooks/gitlab")
+	req, err := webhook.NewRequest("/webhooks/gitlab", webhook.EventPush, []byte(listenPushPayload), "dev-token", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted || rec.Body.String() != `{"status": "queued"}` {
+		t.Errorf("expected MotherGoose's response, got %d %s", rec.Code, rec.Body.String())
+	}
+	if forwarded == nil || forwarded.URL.Path != "/webhooks/gitlab" {
+		t.Fatalf("expected the webhook to be forwarded, got %v", forwarded)
+	}
+	if forwarded.Header.Get(webhook.TokenHeader) != "dev-token" || forwarded.Header.Get(webhook.EventHeader) != webhook.EventPush {
+		t.Errorf("expected the GitLab headers to be forwarded, got %v", forwarded.Header)
+	}
+	if string(forwardedBody) != listenPushPayload {
+		t.Error("expected the payload to be forwarded unchanged")
+	}
+	if !strings.Contains(out.String(), "↪ forwarded: 202 Accepted") {
+		t.Errorf("expected the forward status in output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Supported values for the global --log-format flag
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// Values of the global logging flags
+var (
+	logVerbose bool
+	logQuiet   bool
+	logFormat  = logFormatText
+)
+
+// validateLogFlags checks the global logging flags
+func validateLogFlags() error {
+	if logVerbose && logQuiet {
+		return fmt.Errorf("--verbose and --quiet cannot be used together")
+	}
+	switch logFormat {
+	case logFormatText, logFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid log format %q: must be one of text, json", logFormat)
+	}
+}
+
+// logLevel returns the lowest level logged: debug with --verbose, warnings
+// with --quiet, info otherwise
+func logLevel() slog.Level {
+	switch {
+	case logVerbose:
+		return slog.LevelDebug
+	case logQuiet:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// logOut returns the writer for log messages. Human-readable logs go with
+// the progress messages (see msgOut); JSON logs always go to stderr so they
+// never mix with command results.
+func logOut() io.Writer {
+	if logFormat == logFormatJSON {
+		return os.Stderr
+	}
+	return msgOut()
+}
+
+// logger returns the logger of a CLI component, such as "deploy" or
+// "rollback". Human-readable warnings and errors always go to stderr.
+func logger(component string) *slog.Logger {
+	return newSplitLogger(logOut(), os.Stderr, component)
+}
+
+// newLogger returns a logger of a component writing to w in the selected
+// --log-format at the level selected by --verbose and --quiet
+func newLogger(w io.Writer, component string) *slog.Logger {
+	return newSplitLogger(w, w, component)
+}
+
+// newSplitLogger is newLogger with human-readable warnings and errors
+// written to errW instead
+func newSplitLogger(w, errW io.Writer, component string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: logLevel()}
+	var handler slog.Handler
+	if logFormat == logFormatJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = &textHandler{w: w, errW: errW, mu: &sync.Mutex{}, level: opts.Level, verbose: logVerbose}
+	}
+	return slog.New(handler).With("component", component)
+}
+
+// textHandler writes log records as plain lines for humans: the message
+// alone, with a marker for warnings and errors. With --verbose each line is
+// prefixed with its component and followed by its attributes.
+type textHandler struct {
+	w         io.Writer
+	errW      io.Writer   // Warnings and errors
+	mu        *sync.Mutex // Shared by loggers derived with With, so lines never interleave
+	level     slog.Leveler
+	verbose   bool
+	component string
+	attrs     []string // key=value, in order
+	group     string   // Prefix of the keys of attributes added later
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	if h.verbose && h.component != "" {
+		fmt.Fprintf(&b, "[%s] ", h.component)
+	}
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString("❌ ")
+	case r.Level >= slog.LevelWarn:
+		b.WriteString("⚠️  ")
+	}
+	b.WriteString(r.Message)
+	if h.verbose {
+		attrs := h.attrs[:len(h.attrs):len(h.attrs)] // Never append into the shared array
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, h.formatAttr(a))
+			return true
+		})
+		for _, attr := range attrs {
+			b.WriteString(" " + attr)
+		}
+	}
+	b.WriteString("\n")
+
+	w := h.w
+	if r.Level >= slog.LevelWarn {
+		w = h.errW
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]string{}, h.attrs...)
+	for _, a := range attrs {
+		if a.Key == "component" && h.group == "" {
+			clone.component = a.Value.String()
+			continue
+		}
+		clone.attrs = append(clone.attrs, h.formatAttr(a))
+	}
+	return &clone
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+func (h *textHandler) formatAttr(a slog.Attr) string {
+	return h.group + a.Key + "=" + a.Value.String()
+}
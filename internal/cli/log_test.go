@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// setLogFlags sets the global logging flags for the duration of a test
+func setLogFlags(t *testing.T, verbose, quiet bool, format string) {
+	t.Helper()
+	logVerbose, logQuiet, logFormat = verbose, quiet, format
+	t.Cleanup(func() { logVerbose, logQuiet, logFormat = false, false, logFormatText })
+}
+
+func TestLoggerText(t *testing.T) {
+	tests := []struct {
+		name    string
+		verbose bool
+		quiet   bool
+		want    string
+	}{
+		{name: "default", want: "Deployment plan submitted\n⚠️  Skipping policy\n"},
+		{name: "verbose", verbose: true, want: "[deploy] Config hash: abc egg=my-app config_hash=abc\n" +
+			"[deploy] Deployment plan submitted egg=my-app plan_id=plan-1\n" +
+			"[deploy] ⚠️  Skipping policy egg=my-app\n"},
+		{name: "quiet", quiet: true, want: "⚠️  Skipping policy\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setLogFlags(t, tt.verbose, tt.quiet, logFormatText)
+			var out bytes.Buffer
+			log := newLogger(&out, "deploy").With("egg", "my-app")
+			log.Debug("Config hash: abc", "config_hash", "abc")
+			log.Info("Deployment plan submitted", "plan_id", "plan-1")
+			log.Warn("Skipping policy")
+			if out.String() != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestLoggerJSON(t *testing.T) {
+	setLogFlags(t, false, false, logFormatJSON)
+	var out bytes.Buffer
+	newLogger(&out, "rollback").Info("Rollback plan: plan-2", "plan_id", "plan-2")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON log line, got %q: %v", out.String(), err)
+	}
+	if record["level"] != "INFO" || record["component"] != "rollback" || record["plan_id"] != "plan-2" {
+		t.Errorf("unexpected log record %v", record)
+	}
+}
+
+func TestValidateLogFlags(t *testing.T) {
+	setLogFlags(t, true, true, logFormatText)
+	if err := validateLogFlags(); err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+		t.Errorf("expected --verbose and --quiet to conflict, got %v", err)
+	}
+	setLogFlags(t, false, false, "xml")
+	if err := validateLogFlags(); err == nil {
+		t.Error("expected an invalid --log-format to be rejected")
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsEgg    string
+	logsRunner string
+	logsFollow bool
+	logsAPIURL string
+	logsAPIKey string
+)
+
+// logsCmd represents the logs command
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Stream runner and job logs",
+	Long: `Stream runner and job logs for an Egg from MotherGoose.
+
+With --follow the stream stays open and reconnects automatically if the
+connection drops, resuming after the last received line. Press Ctrl+C to stop.
+
+With --output json or yaml each log entry is written as its own document
+(one JSON object per line for json).
+
+Example:
+  gosling logs --egg my-app
+  gosling logs --egg my-app --runner runner-123 --follow`,
+	RunE: runLogs,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().StringVar(&logsEgg, "egg", "", "Egg name")
+	logsCmd.Flags().StringVar(&logsRunner, "runner", "", "Runner ID (default: all runners of the Egg)")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep streaming new log lines")
+	logsCmd.Flags().StringVar(&logsAPIURL, "api-url", "", "MotherGoose API URL")
+	logsCmd.Flags().StringVar(&logsAPIKey, "api-key", "", "MotherGoose API key")
+	mustMarkRequired(logsCmd, "egg")
+	mustRegisterEggCompletion(logsCmd, completeEggNames(true))
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	conn, err := resolveAPI(logsAPIURL, logsAPIKey)
+	if err != nil {
+		return err
+	}
+	client := conn.client()
+	opts := mothergoose.LogStreamOptions{
+		RunnerID: logsRunner,
+		Follow:   logsFollow,
+	}
+
+	err = streamLogs(ctx, client, logsEgg, opts, os.Stdout)
+	if errors.Is(err, context.Canceled) {
+		// Ctrl+C is the normal way to end a followed stream
+		return nil
+	}
+	return err
+}
+
+// streamLogs writes every log entry for an Egg to w in the selected output format
+func streamLogs(ctx context.Context, client mothergoose.MotherGooseClient, eggName string, opts mothergoose.LogStreamOptions, w io.Writer) error {
+	return client.StreamLogs(ctx, eggName, opts, func(entry *mothergoose.LogEntry) error {
+		return writeLogEntry(w, entry)
+	})
+}
+
+// writeLogEntry prints a single log entry
+func writeLogEntry(w io.Writer, entry *mothergoose.LogEntry) error {
+	switch outputFormat {
+	case outputJSON:
+		return json.NewEncoder(w).Encode(entry)
+	case outputYAML:
+		data, err := yaml.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode log entry: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "---\n%s", data)
+		return err
+	default:
+		prefix := entry.RunnerID
+		if entry.JobID != "" {
+			prefix += "/" + entry.JobID
+		}
+		_, err := fmt.Fprintf(w, "%s [%s] %s\n", entry.Timestamp.Format(time.RFC3339), prefix, entry.Message)
+		return err
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+func TestStreamLogsOutput(t *testing.T) {
+	original := outputFormat
+	defer func() { outputFormat = original }()
+
+	client := NewMockMotherGooseClient()
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	client.LogEntries["my-app"] = []*mothergoose.LogEntry{
+		{RunnerID: "runner-1", JobID: "42", Timestamp: ts, Message: "Running job"},
+		{RunnerID: "runner-2", Timestamp: ts, Message: "Idle"},
+	}
+
+	outputFormat = outputText
+	var textBuf bytes.Buffer
+	opts := mothergoose.LogStreamOptions{RunnerID: "runner-1"}
+	if err := streamLogs(context.Background(), client, "my-app", opts, &textBuf); err != nil {
+		t.Fatalf("streamLogs failed: %v", err)
+	}
+	expected := "2025-01-02T03:04:05Z [runner-1/42] Running job\n"
+	if textBuf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, textBuf.String())
+	}
+
+	outputFormat = outputJSON
+	var jsonBuf bytes.Buffer
+	if err := streamLogs(context.Background(), client, "my-app", mothergoose.LogStreamOptions{}, &jsonBuf); err != nil {
+		t.Fatalf("streamLogs failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d: %q", len(lines), jsonBuf.String())
+	}
+	var entry mothergoose.LogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("line is not valid JSON: %v", err)
+	}
+	if entry.RunnerID != "runner-2" || entry.Message != "Idle" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+}
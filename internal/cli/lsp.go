@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/polar-gosling/gosling/internal/lsp"
+	"github.com/spf13/cobra"
+)
+
+// lspCmd represents the lsp command
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server for .fly files",
+	Long: `Run a Language Server Protocol server for .fly files over stdin and stdout.
+
+Editors start the server and send it the .fly files being edited. It reports
+parse and validation errors as they are typed, completes block types,
+attributes, allowed values and preset names from the schema registry, shows
+the documentation of attributes and blocks on hover, and jumps to the
+definition of included files, presets, Eggs listed in eggs_entities and
+MotherGoose resources referenced by name.
+
+Environment overlays and include fragments in directories starting with "_"
+are only checked for syntax, as they are validated with the files they
+complete.
+
+Example:
+  gosling lsp`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return lsp.NewServer(Version).Serve(os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/polar-gosling/gosling/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+// metricsAddr is where long-running commands serve Prometheus metrics
+var metricsAddr string
+
+// shutdownTelemetry flushes and stops the exporters installed by
+// setupTelemetry; it is nil until then
+var shutdownTelemetry func(context.Context) error
+
+// addMetricsFlag adds --metrics-addr to a long-running command
+func addMetricsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics at http://<addr>/metrics (e.g. localhost:9464)")
+}
+
+// setupTelemetry installs the OTLP exporters configured in the environment
+// and, with --metrics-addr, the Prometheus endpoint. Failing to export to
+// OTLP only disables telemetry, but an endpoint that was asked for and
+// cannot be served fails the command.
+func setupTelemetry(cmd *cobra.Command) error {
+	shutdown, err := telemetry.Setup(commandContext(cmd), telemetry.Config{Version: Version, MetricsAddr: metricsAddr})
+	if err != nil {
+		if metricsAddr != "" {
+			return err
+		}
+		logger("telemetry").Warn(fmt.Sprintf("Telemetry disabled: %v", err))
+		return nil
+	}
+	shutdownTelemetry = shutdown
+	if metricsAddr != "" {
+		logger("telemetry").Info(fmt.Sprintf("📈 Serving metrics at http://%s/metrics", metricsAddr))
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/git"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateDryRun bool
+	migrateCommit bool
+)
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [file]",
+	Short: "Upgrade .fly files to the current schema version",
+	Long: `Upgrade .fly files to the current schema version and rewrite their
+deprecated attributes and blocks, keeping comments.
+
+Files declare the schema version they are written for with a top-level
+schema_version attribute; files without one are read as version 1. The
+transforms between versions, such as the rename of gitlab.server to
+server_name in version 2, are applied in order, schema_version is set to
+the current version and the file is formatted.
+
+gosling validate warns about deprecated attributes and blocks (GSL4001),
+with what replaces them and the version that will stop accepting them. Those
+with an automatic rewrite are migrated by this command; the others are left
+for you to change.
+
+Without arguments every .fly file of the Nest is migrated, including shared
+fragments such as Eggs/_shared. Each rewritten file must still parse before
+it is saved. A file that cannot be migrated is reported and the others are
+still migrated; migrate then exits with an error.
+
+With --dry-run the files are not changed: what would be rewritten is listed
+with the semantic diff of each file, as gosling diff shows it. With --commit the changed files are
+committed to git.
+
+Example:
+  gosling migrate --dry-run
+  gosling migrate
+  gosling migrate Eggs/my-app/config.fly
+  gosling migrate --commit`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: audited(runMigrate),
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Show what would be rewritten without changing files")
+	migrateCmd.Flags().BoolVar(&migrateCommit, "commit", false, "Commit the changed files")
+	migrateCmd.MarkFlagsMutuallyExclusive("dry-run", "commit")
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	nestRoot, err := findNestRoot()
+	if err != nil && (len(args) == 0 || migrateCommit) {
+		return fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+
+	var files []string
+	if len(args) == 1 {
+		files = []string{args[0]}
+	} else if files, err = findAllFlyFiles(nestRoot); err != nil {
+		return fmt.Errorf("failed to find .fly files: %w", err)
+	}
+
+	log := logger("migrate")
+	var changed, failures []string
+	report := &diffOutput{}
+	for _, file := range files {
+		display := file
+		if rel, err := filepath.Rel(nestRoot, file); nestRoot != "" && err == nil {
+			display = rel
+		}
+		// A file that cannot be migrated does not stop the others
+		migrated, changes, err := migrateFile(file, migrateDryRun)
+		if err != nil {
+			log.Error(fmt.Sprintf("%s: %v", display, err), "path", display)
+			failures = append(failures, fmt.Sprintf("  %s: %v", display, err))
+			continue
+		}
+		if len(migrated) == 0 {
+			continue
+		}
+		changed = append(changed, file)
+		verb := "Migrated"
+		if migrateDryRun {
+			verb = "Would migrate"
+		}
+		log.Info(fmt.Sprintf("✅ %s %s: %s", verb, display, strings.Join(migrated, ", ")), "path", display)
+		report.Files = append(report.Files, &fileDiffOutput{Path: display, Changes: changes})
+	}
+
+	var failed error
+	if len(failures) > 0 {
+		failed = fmt.Errorf("%d of %d file(s) failed to migrate:\n%s", len(failures), len(files), strings.Join(failures, "\n"))
+	}
+	if len(changed) == 0 {
+		if failed == nil {
+			log.Info("Nothing to migrate")
+		}
+		return failed
+	}
+	if migrateDryRun {
+		printDiff(cmd.OutOrStdout(), report)
+		return failed
+	}
+	if migrateCommit {
+		repo, err := git.Open(nestRoot)
+		if err != nil {
+			return err
+		}
+		summary := fmt.Sprintf("Migrate .fly files to schema version %d", parser.SchemaVersion)
+		if err := repo.Commit(summary, changed...); err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("✅ Committed: %s", summary))
+	}
+	return failed
+}
+
+// migrateFile upgrades a .fly file, saving it unless dryRun, and returns
+// what was rewritten and the semantic changes to the configuration
+func migrateFile(filePath string, dryRun bool) ([]string, []parser.Change, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	updated, migrated, err := parser.Migrate(content, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(migrated) == 0 {
+		return nil, nil, nil
+	}
+
+	newConfig, err := parser.NewParser().Parse(updated, filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("refusing to save %s: %w", filePath, err)
+	}
+	oldConfig, err := parser.NewParser().Parse(content, filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	changes := parser.Diff(oldConfig, newConfig)
+	if dryRun {
+		return migrated, changes, nil
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat %s: %w", filePath, err)
+	}
+	if err := os.WriteFile(filePath, updated, info.Mode().Perm()); err != nil {
+		return nil, nil, fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	return migrated, changes, nil
+}
+
+// findAllFlyFiles returns every .fly file below root, including include
+// fragments, skipping hidden directories such as .git
+func findAllFlyFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".fly") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
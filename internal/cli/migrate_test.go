@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+func TestMigrateFile(t *testing.T) {
+	root := t.TempDir()
+	config := strings.NewReplacer(
+		"disk   = 20", "disk   = 20\n    type   = \"vm\"",
+		"server_name =", "server =",
+	).Replace(policyEggConfig)
+	writeNestFile(t, root, "Eggs/my-app/config.fly", config)
+	writeNestFile(t, root, "Eggs/_shared/runner.fly", "runner {\n  tags = [\"docker\"]\n}\n")
+	writeNestFile(t, root, ".git/ignored.fly", "")
+
+	files, err := findAllFlyFiles(root)
+	if err != nil {
+		t.Fatalf("findAllFlyFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected the config and the shared fragment, got %v", files)
+	}
+
+	path := filepath.Join(root, "Eggs", "my-app", "config.fly")
+	migrated, changes, err := migrateFile(path, true)
+	if err != nil || strings.Join(migrated, ",") != "egg.gitlab.server,schema_version 1 → 2,egg.resources.type" {
+		t.Fatalf("expected the schema upgrade and resources.type to be migrated, got %v, %v", migrated, err)
+	}
+	paths := make(map[string]parser.ChangeKind)
+	for _, change := range changes {
+		paths[change.Path] = change.Kind
+	}
+	if paths["gitlab.server"] != parser.ChangeRemoved || paths["gitlab.server_name"] != parser.ChangeAdded {
+		t.Errorf("expected the diff to show the rename of server, got %v", changes)
+	}
+	if content, _ := os.ReadFile(path); string(content) != config {
+		t.Error("expected --dry-run to leave the file unchanged")
+	}
+
+	if _, _, err := migrateFile(path, false); err != nil {
+		t.Fatalf("migrateFile failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if strings.Count(string(content), `"vm"`) != 1 || !strings.HasPrefix(string(content), "schema_version = 2\n") {
+		t.Errorf("expected the file to be upgraded, got:\n%s", content)
+	}
+	if migrated, _, err := migrateFile(path, false); err != nil || len(migrated) != 0 {
+		t.Errorf("expected nothing left to migrate, got %v, %v", migrated, err)
+	}
+}
+
+func TestMigrateNestWithIncludes(t *testing.T) {
+	originalDryRun := migrateDryRun
+	defer func() { migrateDryRun = originalDryRun }()
+	migrateDryRun = false
+
+	root := t.TempDir()
+	config := "include \"../_shared/runner.fly\"\n\n" + strings.Replace(policyEggConfig, "disk   = 20", "disk   = 20\n    type   = \"vm\"", 1)
+	writeNestFile(t, root, "Eggs/my-app/config.fly", config)
+	writeNestFile(t, root, "Eggs/_shared/runner.fly", "runner {\n  tags = [\"docker\"]\n}\n")
+	writeNestFile(t, root, "Eggs/broken/config.fly", "egg \"broken\" {\n")
+	for _, dir := range []string{"Jobs", "UF"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(root)
+
+	// The broken file is reported without stopping the migration of the others
+	err := runMigrate(migrateCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 file(s) failed to migrate") || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected only the broken file to fail, got %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(root, "Eggs", "my-app", "config.fly"))
+	if !strings.Contains(string(content), "include \"../_shared/runner.fly\"\n") || strings.Count(string(content), `"vm"`) != 1 {
+		t.Errorf("expected the Egg migrated with its include kept, got:\n%s", content)
+	}
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/polar-gosling/gosling/internal/cost"
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/spf13/cobra"
+)
+
+// Supported values for the global --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+	// outputSARIF reports findings as SARIF 2.1.0 for code-scanning tools.
+	// Only the commands passed to enableSARIF support it.
+	outputSARIF = "sarif"
+)
+
+// sarifAnnotation marks the commands that support --output sarif
+const sarifAnnotation = "gosling.sarif"
+
+// outputFormat holds the value of the global --output flag
+var outputFormat = outputText
+
+// enableSARIF lets cmd accept --output sarif
+func enableSARIF(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[sarifAnnotation] = "true"
+}
+
+// validateOutputFormat checks that --output holds a value cmd supports
+func validateOutputFormat(cmd *cobra.Command) error {
+	switch outputFormat {
+	case outputText, outputJSON, outputYAML:
+		return nil
+	case outputSARIF:
+		if cmd.Annotations[sarifAnnotation] != "true" {
+			return fmt.Errorf("--output sarif is only supported by validate and lint")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid output format %q: must be one of text, json, yaml", outputFormat)
+	}
+}
+
+// isStructuredOutput reports whether results should be emitted as JSON or YAML
+func isStructuredOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML || outputFormat == outputSARIF
+}
+
+// msgOut returns the writer used for human-oriented progress messages.
+// In structured mode these go to stderr so stdout stays machine-readable.
+func msgOut() io.Writer {
+	if isStructuredOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// useColor reports whether text written to w may use ANSI colors: w is a
+// terminal and NO_COLOR is not set
+func useColor(w io.Writer) bool {
+	return isTerminal(w) && os.Getenv("NO_COLOR") == ""
+}
+
+// indent prefixes every non-empty line of s
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeStructured encodes v to w in the selected structured format.
+// YAML output is derived from the JSON encoding so both formats share
+// the same field names.
+func writeStructured(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	if outputFormat == outputYAML {
+		data, err = yaml.JSONToYAML(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode output as YAML: %w", err)
+		}
+	} else {
+		data = append(data, '\n')
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// planOutput is the stable machine-readable representation of a deployment plan
+type planOutput struct {
+	ID           string                 `json:"id"`
+	EggName      string                 `json:"egg_name"`
+	PlanType     string                 `json:"plan_type"`
+	Status       string                 `json:"status"`
+	ConfigHash   string                 `json:"config_hash"`
+	CreatedAt    time.Time              `json:"created_at"`
+	AppliedAt    *time.Time             `json:"applied_at,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	SigningKeyID string                 `json:"signing_key_id,omitempty"`
+}
+
+// newPlanOutput converts a deployment plan to its output representation
+func newPlanOutput(plan *deployer.DeploymentPlan) *planOutput {
+	if plan == nil {
+		return nil
+	}
+	return &planOutput{
+		ID:           plan.ID,
+		EggName:      plan.EggName,
+		PlanType:     plan.PlanType,
+		Status:       plan.Status,
+		ConfigHash:   plan.ConfigHash,
+		CreatedAt:    plan.CreatedAt,
+		AppliedAt:    plan.AppliedAt,
+		Metadata:     plan.Metadata,
+		SigningKeyID: plan.SigningKeyID,
+	}
+}
+
+// resourcesOutput is the stable machine-readable representation of egg resources
+type resourcesOutput struct {
+	CPU      int    `json:"cpu"`
+	Memory   int    `json:"memory"`
+	Disk     int    `json:"disk"`
+	GPU      int    `json:"gpu,omitempty"`
+	GPUType  string `json:"gpu_type,omitempty"`
+	Platform string `json:"platform,omitempty"`
+}
+
+func newResourcesOutput(r deployer.ResourceConfig) resourcesOutput {
+	return resourcesOutput{CPU: r.CPU, Memory: r.Memory, Disk: r.Disk, GPU: r.GPU, GPUType: r.GPUType, Platform: r.Platform}
+}
+
+// autoscalingOutput is the stable machine-readable representation of an
+// egg's autoscaler
+type autoscalingOutput struct {
+	Min              int    `json:"min"`
+	Max              int    `json:"max"`
+	ScaleUpThreshold int    `json:"scale_up_threshold"`
+	ScaleDownDelay   string `json:"scale_down_delay"`
+}
+
+// newAutoscalingOutput returns nil for an egg without an autoscaler
+func newAutoscalingOutput(a *deployer.AutoscalingConfig) *autoscalingOutput {
+	if a == nil {
+		return nil
+	}
+	return &autoscalingOutput{Min: a.Min, Max: a.Max, ScaleUpThreshold: a.ScaleUpThreshold, ScaleDownDelay: a.ScaleDownDelay.String()}
+}
+
+// kubernetesOutput is the stable machine-readable representation of the
+// cluster of kubernetes runners
+type kubernetesOutput struct {
+	Namespace        string            `json:"namespace"`
+	NodeSelector     map[string]string `json:"node_selector,omitempty"`
+	KubeconfigSecret string            `json:"kubeconfig_secret"`
+}
+
+// newKubernetesOutput returns nil for an egg not deployed to a cluster
+func newKubernetesOutput(k *deployer.KubernetesConfig) *kubernetesOutput {
+	if k == nil {
+		return nil
+	}
+	return &kubernetesOutput{Namespace: k.Namespace, NodeSelector: k.NodeSelector, KubeconfigSecret: k.KubeconfigSecret}
+}
+
+// costOutput is the stable machine-readable representation of a monthly cost estimate
+type costOutput struct {
+	Compute  float64 `json:"compute"`
+	Storage  float64 `json:"storage"`
+	Monthly  float64 `json:"monthly"`
+	Currency string  `json:"currency"`
+}
+
+func newCostOutput(e *cost.Estimate) *costOutput {
+	return &costOutput{Compute: e.Compute, Storage: e.Storage, Monthly: e.Monthly, Currency: cost.Currency}
+}
+
+// add adds other to c; a nil other is ignored
+func (c *costOutput) add(other *costOutput) {
+	if other == nil {
+		return
+	}
+	c.Compute = roundCents(c.Compute + other.Compute)
+	c.Storage = roundCents(c.Storage + other.Storage)
+	c.Monthly = roundCents(c.Monthly + other.Monthly)
+}
+
+func (c *costOutput) String() string {
+	return fmt.Sprintf("%.2f %s/month (compute %.2f, storage %.2f)", c.Monthly, c.Currency, c.Compute, c.Storage)
+}
+
+// roundCents rounds an amount to whole cents, hiding float sum artifacts
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestValidateOutputFormat(t *testing.T) {
+	original := outputFormat
+	defer func() { outputFormat = original }()
+
+	for _, format := range []string{outputText, outputJSON, outputYAML} {
+		outputFormat = format
+		if err := validateOutputFormat(statusCmd); err != nil {
+			t.Errorf("expected %q to be accepted, got %v", format, err)
+		}
+	}
+
+	outputFormat = "xml"
+	if err := validateOutputFormat(statusCmd); err == nil {
+		t.Error("expected error for unsupported output format")
+	}
+
+	outputFormat = outputSARIF
+	if err := validateOutputFormat(statusCmd); err == nil {
+		t.Error("expected sarif to be rejected by status")
+	}
+	for _, cmd := range []*cobra.Command{validateCmd, lintCmd} {
+		if err := validateOutputFormat(cmd); err != nil {
+			t.Errorf("expected sarif to be accepted by %s, got %v", cmd.Name(), err)
+		}
+	}
+}
+
+func TestWriteStructured(t *testing.T) {
+	original := outputFormat
+	defer func() { outputFormat = original }()
+
+	result := &validateOutput{
+		Files: []*fileValidationOutput{
+			{Path: "Eggs/my-app/config.fly", Valid: true},
+		},
+		ValidCount: 1,
+	}
+
+	outputFormat = outputJSON
+	var jsonBuf bytes.Buffer
+	if err := writeStructured(&jsonBuf, result); err != nil {
+		t.Fatalf("writeStructured(json) failed: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if decoded["valid_count"] != float64(1) {
+		t.Errorf("expected valid_count 1, got %v", decoded["valid_count"])
+	}
+
+	outputFormat = outputYAML
+	var yamlBuf bytes.Buffer
+	if err := writeStructured(&yamlBuf, result); err != nil {
+		t.Fatalf("writeStructured(yaml) failed: %v", err)
+	}
+	yamlOut := yamlBuf.String()
+	if !strings.Contains(yamlOut, "valid_count: 1") {
+		t.Errorf("expected YAML to contain 'valid_count: 1', got:\n%s", yamlOut)
+	}
+	if !strings.Contains(yamlOut, "path: Eggs/my-app/config.fly") {
+		t.Errorf("expected YAML to contain file path, got:\n%s", yamlOut)
+	}
+}
+
+func TestValidateJSONOutput(t *testing.T) {
+	originalFormat := outputFormat
+	originalPath := validatePath
+	defer func() {
+		outputFormat = originalFormat
+		validatePath = originalPath
+	}()
+
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"Eggs/bad-app", "Jobs", "UF"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	badConfig := `egg "bad-app" {
+  type = "invalid"
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "Eggs", "bad-app", "config.fly"), []byte(badConfig), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	outputFormat = outputJSON
+	validatePath = tmpDir
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	rOut, wOut, _ := os.Pipe()
+	_, wErr, _ := os.Pipe()
+	os.Stdout, os.Stderr = wOut, wErr
+
+	runErr := runValidate(validateCmd, []string{})
+
+	wOut.Close()
+	wErr.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+
+	var stdout bytes.Buffer
+	if _, err := stdout.ReadFrom(rOut); err != nil {
+		t.Fatalf("failed to read stdout: %v", err)
+	}
+
+	if runErr == nil {
+		t.Error("expected non-nil error for invalid configuration")
+	}
+
+	var report validateOutput
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("stdout is not valid JSON: %v\n%s", err, stdout.String())
+	}
+	if report.ErrorCount != 1 || len(report.Files) != 1 {
+		t.Errorf("expected 1 file with 1 error, got %+v", report)
+	}
+	if report.Files[0].Valid || report.Files[0].Error == "" {
+		t.Errorf("expected file to be reported invalid with an error, got %+v", report.Files[0])
+	}
+}
+
+func TestDeployJSONOutputOnFailure(t *testing.T) {
+	originalFormat := outputFormat
+	originalURL, originalKey, originalCloud, originalRegion := deployAPIURL, deployAPIKey, deployCloud, deployRegion
+	defer func() {
+		outputFormat = originalFormat
+		deployAPIURL, deployAPIKey, deployCloud, deployRegion = originalURL, originalKey, originalCloud, originalRegion
+	}()
+
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", policyEggConfig)
+	writeNestFile(t, root, "Eggs/other-app/config.fly", strings.Replace(policyEggConfig, `egg "my-app"`, `egg "other-app"`, 1))
+	for _, dir := range []string{"Jobs", "UF"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	t.Chdir(root)
+	t.Setenv("GOSLING_CONFIG", filepath.Join(root, "gosling.yaml"))
+
+	// The Eggs are configured for Yandex Cloud, so deploying to AWS fails
+	outputFormat = outputJSON
+	deployAPIURL, deployAPIKey, deployCloud, deployRegion = "http://127.0.0.1:1", "key", "aws", "us-east-1"
+
+	oldStdout := os.Stdout
+	rOut, wOut, _ := os.Pipe()
+	os.Stdout = wOut
+	runErr := runDeploy(deployCmd, []string{})
+	wOut.Close()
+	os.Stdout = oldStdout
+
+	var stdout bytes.Buffer
+	if _, err := stdout.ReadFrom(rOut); err != nil {
+		t.Fatalf("failed to read stdout: %v", err)
+	}
+	if runErr == nil {
+		t.Error("expected non-nil error for a failed Egg")
+	}
+
+	var report deployOutput
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("stdout is not valid JSON: %v\n%s", err, stdout.String())
+	}
+	if len(report.Eggs) != 2 || report.Eggs[0].Status != deployStatusFailed || report.Eggs[0].Error == "" || report.Eggs[1].Status != deployStatusSkipped {
+		t.Errorf("expected my-app failed and other-app skipped, got %+v", report.Eggs)
+	}
+}
+
+func TestValidateFilesConcurrentOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+	jobsDir := filepath.Join(tmpDir, "Jobs")
+	if err := os.MkdirAll(jobsDir, 0755); err != nil {
+		t.Fatalf("failed to create Jobs: %v", err)
+	}
+
+	var files []string
+	for i := 0; i < 40; i++ {
+		schedule := "0 2 * * *"
+		if i%3 == 0 {
+			schedule = "0 99 * * *"
+		}
+		content := fmt.Sprintf("job \"job-%d\" {\n  schedule = %q\n  script = \"echo %d\"\n  runner {\n    type = \"vm\"\n    tags = [\"docker\"]\n  }\n}\n", i, schedule, i)
+		path := filepath.Join(jobsDir, fmt.Sprintf("job-%02d.fly", i))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write job: %v", err)
+		}
+		files = append(files, path)
+	}
+
+	serial := validateFiles(files, 1, nil)
+	parallel := validateFiles(files, 8, nil)
+
+	if len(parallel) != len(files) {
+		t.Fatalf("expected %d results, got %d", len(files), len(parallel))
+	}
+	for i := range files {
+		if serial[i].Path != parallel[i].Path || serial[i].Valid != parallel[i].Valid || serial[i].Error != parallel[i].Error {
+			t.Errorf("result %d differs: serial=%+v parallel=%+v", i, serial[i], parallel[i])
+		}
+		if wantValid := i%3 != 0; parallel[i].Valid != wantValid {
+			t.Errorf("expected %s valid=%v, got %v (%s)", parallel[i].Path, wantValid, parallel[i].Valid, parallel[i].Error)
+		}
+	}
+}
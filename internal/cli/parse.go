@@ -1,18 +1,30 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/polar-gosling/gosling/internal/parser"
 	"github.com/spf13/cobra"
 )
 
 var (
-	parseType string
+	parseType    string
+	parseNest    bool
+	parseStrict  bool
+	parseLenient bool
+	parseOut     string
+	parseCompact bool
 )
 
+// stdinFilename names a configuration read from stdin in positions and
+// errors. Its includes are resolved from the current directory.
+const stdinFilename = "<stdin>"
+
 // parseCmd represents the parse command
 var parseCmd = &cobra.Command{
 	Use:   "parse [file]",
@@ -22,24 +34,79 @@ var parseCmd = &cobra.Command{
 The JSON output contains the complete parsed configuration structure with snake_case field names
 for Python compatibility. This command is used by MotherGoose backend to parse .fly files.
 
+With --nest the argument is a Nest directory (default: current directory). Every
+.fly file under Eggs/, Jobs/ and UF/ is parsed into a single JSON document keyed
+by path relative to the Nest, with the file, line and column of each block.
+Files that fail to parse or validate are listed under "errors".
+
+--strict also rejects attributes and blocks the schema does not describe.
+--lenient reads values of the wrong type as the expected type where the
+intent is clear ("3" for a number, 3 for a string), with a warning on
+stderr, so the JSON output has the types MotherGoose expects while older
+configurations are migrated.
+
+With - as the file the configuration is read from stdin, so callers such as
+MotherGoose need no temporary files. --out writes the JSON to a file
+instead of stdout, replacing it atomically so readers never see a partial
+document, and --compact leaves out the indentation.
+
 Example:
   gosling parse Eggs/my-app/config.fly --type egg
   gosling parse Jobs/rotate-secrets.fly --type job
-  gosling parse UF/config.fly --type uglyfox`,
-	Args: cobra.ExactArgs(1),
+  gosling parse UF/config.fly --type uglyfox
+  gosling parse --nest ./my-nest
+  gosling parse Eggs/my-app/config.fly --lenient
+  cat config.fly | gosling parse - --out config.json --compact`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if parseNest {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: runParse,
 }
 
 func init() {
 	rootCmd.AddCommand(parseCmd)
 	parseCmd.Flags().StringVarP(&parseType, "type", "t", "", "Configuration type (egg, job, uglyfox, eggsbucket)")
+	parseCmd.Flags().BoolVar(&parseNest, "nest", false, "Parse every .fly file in a Nest into one JSON document")
+	parseCmd.Flags().BoolVar(&parseStrict, "strict", false, "Also reject attributes and blocks the schema does not know")
+	parseCmd.Flags().BoolVar(&parseLenient, "lenient", false, "Read values of the wrong type, such as \"3\" for a number, with a warning")
+	parseCmd.Flags().StringVar(&parseOut, "out", "", "Write the JSON to this file instead of stdout")
+	parseCmd.Flags().BoolVar(&parseCompact, "compact", false, "Write the JSON without indentation")
+	parseCmd.MarkFlagsMutuallyExclusive("type", "nest")
+	parseCmd.MarkFlagsMutuallyExclusive("strict", "lenient")
 }
 
 func runParse(cmd *cobra.Command, args []string) error {
+	if parseNest {
+		root := "."
+		if len(args) == 1 {
+			root = args[0]
+		}
+		return runParseNest(root)
+	}
+
 	filePath := args[0]
 
 	// Parse the .fly file
-	config, err := parser.ParseAndValidate(filePath)
+	var config *parser.Config
+	var result *parser.ValidationResult
+	var err error
+	if filePath == "-" {
+		content, readErr := io.ReadAll(cmd.InOrStdin())
+		if readErr != nil {
+			return fmt.Errorf("failed to read configuration from stdin: %w", readErr)
+		}
+		config, result, err = parseAndValidateContent(content, stdinFilename)
+	} else {
+		config, result, err = parseAndValidate(filePath)
+	}
+	if result != nil {
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", warning)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing file: %v\n", err)
 		return fmt.Errorf("parse failed")
@@ -54,19 +121,191 @@ func runParse(cmd *cobra.Command, args []string) error {
 	}
 
 	// Convert to JSON-serializable structure with snake_case
-	jsonData := configToJSON(config)
+	return writeParseOutput(configToJSON(config))
+}
 
-	// Output JSON to stdout
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(jsonData); err != nil {
+// writeParseOutput writes the JSON of parse to stdout or the --out file,
+// indented unless --compact
+func writeParseOutput(v interface{}) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	if !parseCompact {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(v); err != nil {
 		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
 		return fmt.Errorf("json encoding failed")
 	}
 
+	if parseOut == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return writeFileAtomic(parseOut, buf.Bytes(), 0644)
+}
+
+// writeFileAtomic replaces path with data by renaming a file written next to
+// it, so readers see either the old or the new content
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
 	return nil
 }
 
+// nestParseOutput is the JSON document produced by parse --nest
+type nestParseOutput struct {
+	Root   string                            `json:"root"`
+	Files  map[string]map[string]interface{} `json:"files"`
+	Errors map[string]string                 `json:"errors,omitempty"`
+	// Warnings lists the validation warnings of each file
+	Warnings map[string][]string `json:"warnings,omitempty"`
+}
+
+func runParseNest(root string) error {
+	output, err := parseNestDir(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return fmt.Errorf("parse failed")
+	}
+
+	if err := writeParseOutput(output); err != nil {
+		return err
+	}
+
+	if len(output.Errors) > 0 {
+		return fmt.Errorf("%d file(s) failed to parse", len(output.Errors))
+	}
+	return nil
+}
+
+// parseNestDir parses and validates every .fly file in the Nest at root.
+// Environment overlays are validated merged over their base file but
+// reported with their own blocks.
+func parseNestDir(root string) (*nestParseOutput, error) {
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	files, err := findFlyFiles(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find .fly files: %w", err)
+	}
+
+	output := &nestParseOutput{
+		Root:     root,
+		Files:    make(map[string]map[string]interface{}),
+		Errors:   make(map[string]string),
+		Warnings: make(map[string][]string),
+	}
+	for _, file := range files {
+		relPath, err := filepath.Rel(root, file)
+		if err != nil {
+			relPath = file
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		config, result, err := parseNestFile(file)
+		if result != nil {
+			for _, warning := range result.Warnings {
+				output.Warnings[relPath] = append(output.Warnings[relPath], warning.Error())
+			}
+		}
+		if err != nil {
+			output.Errors[relPath] = err.Error()
+			continue
+		}
+		output.Files[relPath] = configToJSONWithPositions(config, relPath)
+	}
+	return output, nil
+}
+
+func parseNestFile(filePath string) (*parser.Config, *parser.ValidationResult, error) {
+	basePath, env, ok := parser.SplitOverlayPath(filePath)
+	if !ok {
+		return parseAndValidate(filePath)
+	}
+	if _, err := os.Stat(basePath); err != nil {
+		return parseAndValidate(filePath)
+	}
+
+	merged, err := parser.NewParser().ParseFileForEnv(basePath, env)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse error: %w", err)
+	}
+	result := newParseValidator(merged).Validate()
+	if !result.IsValid() {
+		return nil, result, fmt.Errorf("validation error: %w", result)
+	}
+	config, err := parser.NewParser().ParseFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse error: %w", err)
+	}
+	if parseLenient {
+		// The overlay alone is incomplete, so only its values are converted;
+		// the warnings were reported for the merged configuration
+		parser.NewLenientValidator(config).Validate()
+	}
+	return config, result, nil
+}
+
+// parseAndValidate is parser.ParseAndValidate with the validator --strict
+// or --lenient select
+func parseAndValidate(filePath string) (*parser.Config, *parser.ValidationResult, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse error: failed to read file %s: %w", filePath, err)
+	}
+	return parseAndValidateContent(content, filePath)
+}
+
+// parseAndValidateContent is parseAndValidate for content read elsewhere,
+// such as stdin
+func parseAndValidateContent(content []byte, filename string) (*parser.Config, *parser.ValidationResult, error) {
+	if !parseStrict && !parseLenient {
+		return parser.ParseAndValidateContent(content, filename)
+	}
+	config, err := parser.NewParser().Parse(content, filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse error: %w", err)
+	}
+	result := newParseValidator(config).Validate()
+	if !result.IsValid() {
+		return nil, result, fmt.Errorf("validation error: %w", result)
+	}
+	return config, result, nil
+}
+
+// newParseValidator returns the validator --strict or --lenient select
+func newParseValidator(config *parser.Config) *parser.Validator {
+	switch {
+	case parseStrict:
+		return parser.NewStrictValidator(config)
+	case parseLenient:
+		return parser.NewLenientValidator(config)
+	}
+	return parser.NewValidator(config)
+}
+
 func validateConfigType(config *parser.Config, expectedType string) error {
 	if len(config.Blocks) == 0 {
 		return fmt.Errorf("configuration file is empty")
@@ -94,6 +333,36 @@ func configToJSON(config *parser.Config) map[string]interface{} {
 	}
 }
 
+// configToJSONWithPositions is configToJSON with the position of every block.
+// Positions report file as given, since parsed positions may be absolute.
+func configToJSONWithPositions(config *parser.Config, file string) map[string]interface{} {
+	blocks := make([]map[string]interface{}, 0, len(config.Blocks))
+	for i := range config.Blocks {
+		blocks = append(blocks, blockToJSONWithPosition(&config.Blocks[i], file))
+	}
+
+	return map[string]interface{}{
+		"blocks": blocks,
+	}
+}
+
+func blockToJSONWithPosition(block *parser.Block, file string) map[string]interface{} {
+	result := blockToJSON(block)
+	result["position"] = map[string]interface{}{
+		"file":   file,
+		"line":   block.Position.Line,
+		"column": block.Position.Column,
+	}
+	if len(block.Blocks) > 0 {
+		nestedBlocks := make([]map[string]interface{}, 0, len(block.Blocks))
+		for i := range block.Blocks {
+			nestedBlocks = append(nestedBlocks, blockToJSONWithPosition(&block.Blocks[i], file))
+		}
+		result["blocks"] = nestedBlocks
+	}
+	return result
+}
+
 // blockToJSON converts a Block to a JSON-serializable map with snake_case field names
 func blockToJSON(block *parser.Block) map[string]interface{} {
 	result := map[string]interface{}{
@@ -128,7 +397,11 @@ func valueToJSON(val *parser.Value) interface{} {
 	case parser.StringType:
 		return val.Raw.(string)
 	case parser.NumberType:
-		return val.Raw.(float64)
+		// Exact, even for IDs beyond the precision of float64
+		if n, ok := val.Raw.(parser.Number); ok {
+			return json.Number(n.String())
+		}
+		return val.Raw
 	case parser.BoolType:
 		return val.Raw.(bool)
 	case parser.ListType:
@@ -494,6 +494,7 @@ egg "complex-app" {
     cache {
       type = "s3"
       bucket = "runner-cache"
+      credentials_secret = "yc-lockbox://gitlab/cache-credentials"
     }
   }
 
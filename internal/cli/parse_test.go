@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
 )
 
 func TestParseCommand(t *testing.T) {
@@ -238,7 +240,7 @@ egg "my-app" {
 			}
 
 			// Parse the file
-			config, err := parser.ParseAndValidate(tmpFile)
+			config, _, err := parser.ParseAndValidate(tmpFile)
 			if err != nil {
 				if !tt.expectError {
 					t.Fatalf("Parse failed: %v", err)
@@ -336,6 +338,14 @@ func TestValueToJSON(t *testing.T) {
 			},
 			expected: float64(42),
 		},
+		{
+			name: "parsed number value",
+			value: &parser.Value{
+				Type: parser.NumberType,
+				Raw:  mustParseNumber(t, "123456789012345678"),
+			},
+			expected: json.Number("123456789012345678"),
+		},
 		{
 			name: "bool value",
 			value: &parser.Value{
@@ -592,7 +602,7 @@ egg "test-app" {
 	}
 
 	// Parse and convert to JSON
-	config, err := parser.ParseAndValidate(tmpFile)
+	config, _, err := parser.ParseAndValidate(tmpFile)
 	if err != nil {
 		t.Fatalf("Parse failed: %v", err)
 	}
@@ -643,3 +653,127 @@ egg "test-app" {
 		t.Errorf("Expected at least 4 nested blocks, got %d", len(nestedBlocks))
 	}
 }
+
+func TestParseNest(t *testing.T) {
+	eggContent := `
+egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags = ["docker"]
+    concurrent = 1
+  }
+
+  gitlab {
+    project_id = 12345
+    server_name = "gitlab.com"
+    token_secret = "yc-lockbox://gitlab/runner-token"
+  }
+}
+`
+	overlayContent := `
+egg "my-app" {
+  resources {
+    memory = 8192
+  }
+}
+`
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", eggContent)
+	writeNestFile(t, root, "Eggs/my-app/config.prod.fly", overlayContent)
+	writeNestFile(t, root, "Eggs/_shared/defaults.fly", "not a config")
+	writeNestFile(t, root, "Jobs/broken.fly", `job "broken" {`)
+
+	output, err := parseNestDir(root)
+	if err != nil {
+		t.Fatalf("parseNestDir failed: %v", err)
+	}
+
+	if len(output.Files) != 2 {
+		t.Fatalf("expected 2 parsed files, got %d: %v", len(output.Files), output.Files)
+	}
+	if _, ok := output.Errors["Jobs/broken.fly"]; !ok || len(output.Errors) != 1 {
+		t.Errorf("expected only Jobs/broken.fly to fail, got %v", output.Errors)
+	}
+
+	blocks := output.Files["Eggs/my-app/config.fly"]["blocks"].([]map[string]interface{})
+	position := blocks[0]["position"].(map[string]interface{})
+	if position["file"] != "Eggs/my-app/config.fly" || position["line"] != 2 {
+		t.Errorf("unexpected egg position %v", position)
+	}
+	nested := blocks[0]["blocks"].([]map[string]interface{})
+	if _, ok := nested[0]["position"]; !ok {
+		t.Error("expected positions on nested blocks")
+	}
+
+	overlay := output.Files["Eggs/my-app/config.prod.fly"]["blocks"].([]map[string]interface{})
+	if len(overlay) != 1 || overlay[0]["type"] != "egg" {
+		t.Errorf("expected the overlay's own blocks, got %v", overlay)
+	}
+}
+
+func TestParseStdinToFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "config.json")
+	parseOut, parseCompact = out, true
+	t.Cleanup(func() { parseOut, parseCompact = "", false })
+
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader(policyEggConfig))
+	if err := runParse(cmd, []string{"-"}); err != nil {
+		t.Fatalf("runParse failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("expected the JSON to be written to --out: %v", err)
+	}
+	if bytes.Count(data, []byte("\n")) != 1 {
+		t.Errorf("expected compact JSON on one line, got:\n%s", data)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if blocks, _ := decoded["blocks"].([]interface{}); len(blocks) != 1 {
+		t.Errorf("expected the egg block, got %v", decoded)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(out)); len(entries) != 1 {
+		t.Errorf("expected no temporary files left, got %v", entries)
+	}
+
+	cmd.SetIn(strings.NewReader(`egg "broken" {`))
+	if err := runParse(cmd, []string{"-"}); err == nil {
+		t.Error("expected invalid stdin to fail")
+	}
+}
+
+func writeNestFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func mustParseNumber(t *testing.T, text string) parser.Number {
+	t.Helper()
+	n, err := parser.ParseNumber(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	planShowEgg    string
+	planShowPlanID string
+	planShowRaw    bool
+	planShowAPIURL string
+	planShowAPIKey string
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Preview a deployment with its estimated cost",
+	Long: `Show what 'gosling deploy' would do for every Egg of the Nest without
+changing anything. This is the same as 'gosling deploy --dry-run'.
+
+--egg, --eggs, --path and --changed-since narrow the plan to some Eggs,
+as for deploy. Otherwise the plan also lists the changed Jobs and the
+attributes of UF/config.fly that differ from the UglyFox configuration
+MotherGoose applies.
+
+Each Egg is listed with its estimated monthly cost and the Nest-wide total.
+Estimates use approximate on-demand list prices in USD for Yandex Cloud and
+AWS: a VM runner is billed as one instance running all month, a serverless
+runner as 100 hours of execution per concurrent job.
+
+Example:
+  gosling plan --api-url ... --api-key ...
+  gosling plan --cloud yandex --region ru-central1-a --api-url ... --api-key ...
+  gosling plan --env prod --cloud aws --region us-east-1 --output json
+  gosling plan --changed-since origin/main`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deployDryRun = true
+		return runDeploy(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	planCmd.Flags().StringVar(&deployCloud, "cloud", "", "Cloud provider: yandex, aws, or azure")
+	planCmd.Flags().StringVar(&deployRegion, "region", "", "Cloud region")
+	planCmd.Flags().StringVar(&deployAPIURL, "api-url", "", "MotherGoose API URL")
+	planCmd.Flags().StringVar(&deployAPIKey, "api-key", "", "MotherGoose API key")
+	planCmd.Flags().StringVar(&deployEnv, "env", "", "Environment overlay to apply (e.g. prod for config.prod.fly)")
+	planCmd.Flags().StringVar(&deploySignKey, "sign-key", "", "Ed25519 private key (PEM file or secret reference) to sign plans with (default: $GOSLING_SIGN_KEY)")
+	planCmd.Flags().BoolVar(&deployQuotas, "check-quotas", false, "Check the cloud's quotas (yandex, aws)")
+	planCmd.Flags().BoolVar(&deployOverrideCloud, "override-cloud", false, "Plan for --cloud/--region even where the Eggs' cloud blocks differ")
+	planCmd.Flags().IntVarP(&deployConcurrency, "concurrency", "j", 4, "Number of Eggs to plan in parallel")
+	planCmd.Flags().BoolVar(&deployFailFast, "fail-fast", false, "Stop starting Eggs after the first one fails")
+	addSelectionFlags(planCmd)
+	addPolicyFlags(planCmd)
+
+	planCmd.AddCommand(planShowCmd)
+	planShowCmd.Flags().StringVar(&planShowEgg, "egg", "", "Egg whose plan to show")
+	planShowCmd.Flags().StringVar(&planShowPlanID, "plan", "", "Plan ID (default: the Egg's latest plan)")
+	planShowCmd.Flags().BoolVar(&planShowRaw, "raw", false, "Write the plan binary to stdout as stored")
+	planShowCmd.Flags().StringVar(&planShowAPIURL, "api-url", "", "MotherGoose API URL")
+	planShowCmd.Flags().StringVar(&planShowAPIKey, "api-key", "", "MotherGoose API key")
+	mustMarkRequired(planShowCmd, "egg")
+	mustRegisterEggCompletion(planShowCmd, completeEggNames(true))
+}
+
+// planShowCmd represents the plan show command
+var planShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show a submitted deployment plan",
+	Long: `Fetch a deployment plan from MotherGoose and show its metadata and the
+resources it plans, decoded from the plan binary.
+
+--raw writes the plan binary exactly as stored, e.g. to inspect an OpenTofu
+plan with 'tofu show'.
+
+Example:
+  gosling plan show --egg my-app
+  gosling plan show --egg my-app --plan 3f2a9c01-... --output json
+  gosling plan show --egg my-app --raw > plan.bin`,
+	Args: cobra.NoArgs,
+	RunE: runPlanShow,
+}
+
+// planShowOutput is the machine-readable result of `gosling plan show`
+type planShowOutput struct {
+	*planOutput
+	RollbackOf string             `json:"rollback_of,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	BinarySize int                `json:"binary_size"`
+	Content    *planContentOutput `json:"content,omitempty"` // Absent unless the binary was generated by deploy
+}
+
+// planContentOutput is the decoded plan binary
+type planContentOutput struct {
+	RunnerType  string             `json:"runner_type"`
+	Cloud       string             `json:"cloud"`
+	Region      string             `json:"region"`
+	Resources   resourcesOutput    `json:"resources"`
+	Autoscaling *autoscalingOutput `json:"autoscaling,omitempty"`
+	Kubernetes  *kubernetesOutput  `json:"kubernetes,omitempty"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+func runPlanShow(cmd *cobra.Command, args []string) error {
+	if planShowRaw && isStructuredOutput() {
+		return fmt.Errorf("--raw cannot be combined with --output %s", outputFormat)
+	}
+	conn, err := resolveAPI(planShowAPIURL, planShowAPIKey)
+	if err != nil {
+		return err
+	}
+	client := conn.client()
+	plan, err := lookupPlan(commandContext(cmd), client, planShowEgg, planShowPlanID)
+	if err != nil {
+		return err
+	}
+
+	if planShowRaw {
+		_, err := os.Stdout.Write(plan.PlanBinary)
+		return err
+	}
+	result := newPlanShowOutput(plan)
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, result)
+	}
+	printPlan(os.Stdout, result)
+	return nil
+}
+
+func newPlanShowOutput(plan *deployer.DeploymentPlan) *planShowOutput {
+	result := &planShowOutput{
+		planOutput: newPlanOutput(plan),
+		RollbackOf: plan.RollbackPlan,
+		Error:      plan.Error,
+		BinarySize: len(plan.PlanBinary),
+	}
+	var content planContent
+	decoder := json.NewDecoder(bytes.NewReader(plan.PlanBinary))
+	decoder.DisallowUnknownFields()
+	if len(plan.PlanBinary) > 0 && decoder.Decode(&content) == nil {
+		result.Content = &planContentOutput{
+			RunnerType:  string(content.RunnerType),
+			Cloud:       string(content.Cloud.Provider),
+			Region:      content.Cloud.Region,
+			Resources:   newResourcesOutput(content.Resources),
+			Autoscaling: newAutoscalingOutput(content.Autoscaling),
+			Kubernetes:  newKubernetesOutput(content.Kubernetes),
+			GeneratedAt: time.Unix(content.Timestamp, 0).UTC(),
+		}
+	}
+	return result
+}
+
+// printPlan prints a plan with its metadata and planned resources
+func printPlan(w io.Writer, plan *planShowOutput) {
+	fmt.Fprintf(w, "Plan:         %s\n", plan.ID)
+	fmt.Fprintf(w, "Egg:          %s\n", plan.EggName)
+	fmt.Fprintf(w, "Status:       %s\n", plan.Status)
+	if plan.Error != "" {
+		fmt.Fprintf(w, "Error:        %s\n", plan.Error)
+	}
+	fmt.Fprintf(w, "Type:         %s\n", plan.PlanType)
+	fmt.Fprintf(w, "Config Hash:  %s\n", plan.ConfigHash)
+	fmt.Fprintf(w, "Created At:   %s\n", plan.CreatedAt.Format(time.RFC3339))
+	if plan.AppliedAt != nil {
+		fmt.Fprintf(w, "Applied At:   %s\n", plan.AppliedAt.Format(time.RFC3339))
+	}
+	if plan.RollbackOf != "" {
+		fmt.Fprintf(w, "Rollback To:  %s\n", plan.RollbackOf)
+	}
+	if plan.SigningKeyID != "" {
+		fmt.Fprintf(w, "Signed By:    %s\n", plan.SigningKeyID)
+	}
+
+	if len(plan.Metadata) > 0 {
+		fmt.Fprintln(w, "\nMetadata:")
+		keys := make([]string, 0, len(plan.Metadata))
+		for key := range plan.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "  %s: %v\n", key, plan.Metadata[key])
+		}
+	}
+
+	if plan.Content == nil {
+		fmt.Fprintf(w, "\nPlan binary: %d bytes, not generated by gosling deploy (use --raw to save it)\n", plan.BinarySize)
+		return
+	}
+	c := plan.Content
+	fmt.Fprintln(w, "\nPlanned Resources:")
+	fmt.Fprintf(w, "  Runner Type:  %s\n", c.RunnerType)
+	fmt.Fprintf(w, "  Cloud:        %s\n", c.Cloud)
+	fmt.Fprintf(w, "  Region:       %s\n", c.Region)
+	fmt.Fprintf(w, "  CPU:          %d\n", c.Resources.CPU)
+	fmt.Fprintf(w, "  Memory:       %d MB\n", c.Resources.Memory)
+	fmt.Fprintf(w, "  Disk:         %d GB\n", c.Resources.Disk)
+	if c.Resources.GPU > 0 {
+		fmt.Fprintf(w, "  GPU:          %d x %s\n", c.Resources.GPU, c.Resources.GPUType)
+	}
+	if c.Resources.Platform != "" {
+		fmt.Fprintf(w, "  Platform:     %s\n", c.Resources.Platform)
+	}
+	if a := c.Autoscaling; a != nil {
+		fmt.Fprintf(w, "  Autoscaling:  %d-%d runners, up at %d%%, down after %s\n", a.Min, a.Max, a.ScaleUpThreshold, a.ScaleDownDelay)
+	}
+	if k := c.Kubernetes; k != nil {
+		fmt.Fprintf(w, "  Namespace:    %s\n", k.Namespace)
+		if len(k.NodeSelector) > 0 {
+			labels := make([]string, 0, len(k.NodeSelector))
+			for label, value := range k.NodeSelector {
+				labels = append(labels, label+"="+value)
+			}
+			sort.Strings(labels)
+			fmt.Fprintf(w, "  Nodes:        %s\n", strings.Join(labels, ", "))
+		}
+	}
+	fmt.Fprintf(w, "  Generated At: %s\n", c.GeneratedAt.Format(time.RFC3339))
+}
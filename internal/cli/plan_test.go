@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+func TestPlanShow(t *testing.T) {
+	binary, err := generatePlanBinary(&deployer.EggConfig{
+		Name:        "my-app",
+		Type:        deployer.RunnerTypeVM,
+		Cloud:       deployer.CloudConfig{Provider: deployer.CloudProviderYandex, Region: "ru-central1-a"},
+		Resources:   deployer.ResourceConfig{CPU: 4, Memory: 8192, Disk: 50},
+		Autoscaling: &deployer.AutoscalingConfig{Min: 1, Max: 10, ScaleUpThreshold: 80, ScaleDownDelay: 10 * time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := &deployer.DeploymentPlan{ID: "plan-2", EggName: "my-app", Status: deployer.PlanStatusApplied, CreatedAt: time.Now(), PlanBinary: binary,
+		Metadata: map[string]interface{}{"runner_type": "vm", metadataTriggeredBy: "alice"}}
+	client := NewMockMotherGooseClient()
+	client.DeploymentPlans["my-app"] = []*deployer.DeploymentPlan{
+		{ID: "plan-1", EggName: "my-app", Status: deployer.PlanStatusApplied, CreatedAt: time.Now().Add(-time.Hour), PlanBinary: []byte("opaque")},
+		latest,
+	}
+	client.EggStatuses["my-app"] = &mothergoose.EggStatus{EggName: "my-app", LatestPlan: latest}
+
+	plan, err := lookupPlan(context.Background(), client, "my-app", "")
+	if err != nil {
+		t.Fatalf("lookupPlan failed: %v", err)
+	}
+	result := newPlanShowOutput(plan)
+	if result.Content == nil {
+		t.Fatalf("expected the plan binary to be decoded, got %+v", result)
+	}
+	if result.Content.Region != "ru-central1-a" || result.Content.Resources.Memory != 8192 ||
+		result.Content.Autoscaling == nil || result.Content.Autoscaling.ScaleDownDelay != "10m0s" {
+		t.Errorf("unexpected plan content %+v", result.Content)
+	}
+	var out bytes.Buffer
+	printPlan(&out, result)
+	for _, want := range []string{"Plan:         plan-2", "  runner_type: vm\n  triggered_by: alice", "Memory:       8192 MB", "Autoscaling:  1-10 runners, up at 80%, down after 10m0s"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	plan, err = lookupPlan(context.Background(), client, "my-app", "plan-1")
+	if err != nil {
+		t.Fatalf("lookupPlan failed: %v", err)
+	}
+	result = newPlanShowOutput(plan)
+	if result.Content != nil || result.BinarySize != len("opaque") {
+		t.Errorf("expected an undecoded binary of 6 bytes, got %+v", result)
+	}
+	out.Reset()
+	printPlan(&out, result)
+	if !strings.Contains(out.String(), "6 bytes") {
+		t.Errorf("expected the binary size in the output, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/polar-gosling/gosling/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	policySkip       []string
+	policySkipReason string
+)
+
+// addPolicyFlags adds the policy override flags shared by validate and deploy
+func addPolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&policySkip, "policy-skip", nil, "Skip the named policy for this run (repeatable; requires --policy-skip-reason)")
+	cmd.Flags().StringVar(&policySkipReason, "policy-skip-reason", "", "Why the skipped policies do not apply")
+}
+
+// loadPolicies loads the Nest's Policies/ directory and applies --policy-skip.
+// Every skipped policy is reported together with the override reason.
+func loadPolicies(nestRoot string) (*policy.Engine, error) {
+	engine, err := policy.Load(filepath.Join(nestRoot, policy.DirName))
+	if err != nil {
+		return nil, err
+	}
+	if err := engine.Skip(policySkip, policySkipReason); err != nil {
+		return nil, err
+	}
+	for _, name := range engine.Skipped() {
+		logger("policy").Warn(fmt.Sprintf("Skipping policy %q: %s", name, policySkipReason), "policy", name)
+	}
+	return engine, nil
+}
+
+// nestPolicyViolations evaluates the policies against the Egg configurations
+// merged with the env overlay, as deploy sees them. With selected, only the
+// Eggs of those names are checked, so an unrelated Egg does not block a
+// targeted deploy; nil checks every Egg.
+func nestPolicyViolations(engine *policy.Engine, eggsDir, env string, selected []string) ([]policy.Violation, error) {
+	dirs, err := listEggDirs(eggsDir)
+	if err != nil {
+		return nil, err
+	}
+	var names map[string]bool
+	if selected != nil {
+		names = make(map[string]bool, len(selected))
+		for _, name := range selected {
+			names[name] = true
+		}
+	}
+	var violations []policy.Violation
+	p := parser.NewParser()
+	for _, dir := range dirs {
+		config, err := p.ParseFileForEnv(dir.ConfigPath, env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", dir.ConfigPath, err)
+		}
+		if names != nil {
+			config = selectedEggBlocks(config, dir.Name, names)
+		}
+		violations = append(violations, engine.Evaluate(config, env)...)
+	}
+	return violations, nil
+}
+
+// selectedEggBlocks returns config with only the egg blocks named in names,
+// naming them as deploy does
+func selectedEggBlocks(config *parser.Config, dirName string, names map[string]bool) *parser.Config {
+	eggBlocks := 0
+	for i := range config.Blocks {
+		if config.Blocks[i].Type == "egg" {
+			eggBlocks++
+		}
+	}
+	filtered := *config
+	filtered.Blocks = nil
+	for i := range config.Blocks {
+		block := &config.Blocks[i]
+		if block.Type == "egg" && names[expandedEggName(dirName, block, eggBlocks)] {
+			filtered.Blocks = append(filtered.Blocks, *block)
+		}
+	}
+	return &filtered
+}
+
+// policyError summarises violations as a single error
+func policyError(violations []policy.Violation) error {
+	lines := make([]string, 0, len(violations))
+	for _, v := range violations {
+		lines = append(lines, "  "+v.String())
+	}
+	return fmt.Errorf("%d policy violation(s):\n%s\nUse --policy-skip <name> --policy-skip-reason \"...\" to override",
+		len(violations), strings.Join(lines, "\n"))
+}
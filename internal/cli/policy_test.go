@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const memoryPolicy = `
+policy "dev-memory-limit" {
+  environments = ["dev"]
+
+  rule {
+    attribute = "resources.memory"
+    max       = 4096
+  }
+}
+`
+
+const policyEggConfig = `
+egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags = ["docker"]
+    concurrent = 2
+    idle_timeout = "10m"
+  }
+
+  gitlab {
+    project_id = 12345
+    server_name = "gitlab.com"
+    token_secret = "yc-lockbox://gitlab/runner-token"
+  }
+}
+`
+
+func TestPoliciesEnforced(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Policies/limits.fly", memoryPolicy)
+	writeNestFile(t, root, "Eggs/my-app/config.fly", policyEggConfig)
+	writeNestFile(t, root, "Eggs/my-app/config.dev.fly", "egg \"my-app\" {\n  resources {\n    memory = 8192\n  }\n}\n")
+	writeNestFile(t, root, "Eggs/other-app/config.fly", strings.Replace(policyEggConfig, `egg "my-app"`, `egg "other-app"`, 1))
+	t.Cleanup(func() { policySkip, policySkipReason = nil, "" })
+
+	engine, err := loadPolicies(root)
+	if err != nil {
+		t.Fatalf("loadPolicies failed: %v", err)
+	}
+
+	results := validateFiles([]string{
+		filepath.Join(root, "Eggs", "my-app", "config.fly"),
+		filepath.Join(root, "Eggs", "my-app", "config.dev.fly"),
+	}, 2, engine)
+	if !results[0].Valid {
+		t.Errorf("base config should satisfy the dev-only policy: %s", results[0].Error)
+	}
+	if results[1].Valid || len(results[1].PolicyViolations) != 1 || !strings.Contains(results[1].Error, "dev-memory-limit") {
+		t.Errorf("dev overlay should violate the policy, got %+v", results[1])
+	}
+
+	violations, err := nestPolicyViolations(engine, filepath.Join(root, "Eggs"), "dev", nil)
+	if err != nil || len(violations) != 1 {
+		t.Fatalf("expected one violation for deploy --env dev, got %v (%v)", violations, err)
+	}
+	if err := policyError(violations); !strings.Contains(err.Error(), "--policy-skip") {
+		t.Errorf("expected override hint in %v", err)
+	}
+
+	// A targeted deploy is not blocked by the Eggs it leaves alone
+	if violations, err := nestPolicyViolations(engine, filepath.Join(root, "Eggs"), "dev", []string{"other-app"}); err != nil || len(violations) != 0 {
+		t.Errorf("expected no violations for deploy --egg other-app, got %v (%v)", violations, err)
+	}
+	if violations, _ := nestPolicyViolations(engine, filepath.Join(root, "Eggs"), "dev", []string{"my-app"}); len(violations) != 1 {
+		t.Errorf("expected the violation for deploy --egg my-app, got %v", violations)
+	}
+
+	// Skipping requires a reason
+	policySkip = []string{"dev-memory-limit"}
+	if _, err := loadPolicies(root); err == nil {
+		t.Error("expected error for --policy-skip without a reason")
+	}
+	policySkipReason = "load test needs 8 GB"
+	engine, err = loadPolicies(root)
+	if err != nil {
+		t.Fatalf("loadPolicies failed: %v", err)
+	}
+	if violations, _ := nestPolicyViolations(engine, filepath.Join(root, "Eggs"), "dev", nil); len(violations) != 0 {
+		t.Errorf("expected skipped policy to pass, got %v", violations)
+	}
+}
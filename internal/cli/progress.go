@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/telemetry"
+)
+
+// Values for eggDeployOutput.Status of Eggs that were not deployed
+const (
+	deployStatusFailed  = "failed"
+	deployStatusSkipped = "skipped" // Not started after a failure with --fail-fast
+)
+
+// eggDeployFunc deploys a single Egg, logging its progress to log
+type eggDeployFunc func(ctx context.Context, log *slog.Logger, egg *deployer.EggConfig) (*eggDeployOutput, error)
+
+// deployAll deploys eggs with a bounded pool of workers. A status line is
+// logged to w as each Egg is queued, starts and finishes; its own messages
+// are buffered and written together when it finishes, so they do not
+// interleave with those of other Eggs.
+//
+// Every Egg is attempted and the failures are reported together in the
+// returned error. With failFast no Egg is started after the first failure.
+// Results are in the same order as eggs, and include the failed and skipped
+// Eggs.
+func deployAll(ctx context.Context, w io.Writer, eggs []*deployer.EggConfig, concurrency int, failFast bool, deploy eggDeployFunc) ([]*eggDeployOutput, error) {
+	results := make([]*eggDeployOutput, len(eggs))
+	errs := make([]error, len(eggs))
+	if concurrency > len(eggs) {
+		concurrency = len(eggs)
+	}
+
+	var mu sync.Mutex
+	log := newLogger(w, "deploy")
+	for _, egg := range eggs {
+		log.Info(fmt.Sprintf("⏳ %s: queued", egg.Name), "egg", egg.Name, "status", "queued")
+	}
+
+	var stopped atomic.Bool
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				egg := eggs[idx]
+				if stopped.Load() {
+					continue
+				}
+				mu.Lock()
+				log.Info(fmt.Sprintf("🚀 %s: deploying", egg.Name), "egg", egg.Name, "status", "deploying")
+				mu.Unlock()
+
+				var buf bytes.Buffer
+				result, err := deploy(ctx, newLogger(&buf, "deploy").With("egg", egg.Name), egg)
+				if err != nil {
+					result = &eggDeployOutput{EggName: egg.Name, Status: deployStatusFailed, Error: err.Error()}
+					errs[idx] = err
+					if failFast {
+						stopped.Store(true)
+					}
+				}
+				results[idx] = result
+				telemetry.RecordDeploy(ctx, result.Status)
+
+				mu.Lock()
+				log.Info(fmt.Sprintf("=== Egg: %s ===", egg.Name), "egg", egg.Name)
+				w.Write(buf.Bytes())
+				if err != nil {
+					log.Error(fmt.Sprintf("%s: failed: %v", egg.Name, err), "egg", egg.Name, "status", deployStatusFailed)
+				} else {
+					log.Info(fmt.Sprintf("✅ %s: done (%s)", egg.Name, result.Status), "egg", egg.Name, "status", result.Status)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for idx := range eggs {
+		if stopped.Load() {
+			break
+		}
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
+
+	var failures []string
+	var first error
+	for idx, egg := range eggs {
+		switch {
+		case results[idx] == nil:
+			log.Info(fmt.Sprintf("⏭️  %s: skipped", egg.Name), "egg", egg.Name, "status", deployStatusSkipped)
+			results[idx] = &eggDeployOutput{EggName: egg.Name, Status: deployStatusSkipped}
+		case errs[idx] != nil:
+			if first == nil {
+				first = fmt.Errorf("failed to deploy egg %s: %w", egg.Name, errs[idx])
+			}
+			failures = append(failures, fmt.Sprintf("  %s: %v", egg.Name, errs[idx]))
+		}
+	}
+	if failFast && first != nil {
+		return results, first
+	}
+	if len(failures) > 0 {
+		return results, fmt.Errorf("%d of %d Egg(s) failed to deploy:\n%s", len(failures), len(eggs), strings.Join(failures, "\n"))
+	}
+	return results, nil
+}
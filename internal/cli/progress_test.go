@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func progressEggs(names ...string) []*deployer.EggConfig {
+	eggs := make([]*deployer.EggConfig, len(names))
+	for i, name := range names {
+		eggs[i] = &deployer.EggConfig{Name: name}
+	}
+	return eggs
+}
+
+func TestDeployAllRunsInParallel(t *testing.T) {
+	eggs := progressEggs("a", "b", "c")
+	var started sync.WaitGroup
+	started.Add(len(eggs))
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+
+	var out bytes.Buffer
+	results, err := deployAll(context.Background(), &out, eggs, len(eggs), false,
+		func(ctx context.Context, log *slog.Logger, egg *deployer.EggConfig) (*eggDeployOutput, error) {
+			started.Done()
+			select {
+			case <-allStarted:
+			case <-time.After(5 * time.Second):
+				return nil, fmt.Errorf("eggs were not deployed in parallel")
+			}
+			log.Info("deploying " + egg.Name)
+			log.Info("stored " + egg.Name)
+			return &eggDeployOutput{EggName: egg.Name, Status: deployStatusApplied}, nil
+		})
+	if err != nil {
+		t.Fatalf("deployAll failed: %v", err)
+	}
+	for i, result := range results {
+		if result.EggName != eggs[i].Name || result.Status != deployStatusApplied {
+			t.Errorf("result %d = %+v, want %s applied", i, result, eggs[i].Name)
+		}
+	}
+	for _, egg := range eggs {
+		for _, want := range []string{
+			egg.Name + ": queued",
+			egg.Name + ": deploying",
+			fmt.Sprintf("deploying %s\nstored %s\n", egg.Name, egg.Name), // Not interleaved
+			egg.Name + ": done (applied)",
+		} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+			}
+		}
+	}
+}
+
+func TestDeployAllFailures(t *testing.T) {
+	deploy := func(ctx context.Context, log *slog.Logger, egg *deployer.EggConfig) (*eggDeployOutput, error) {
+		if egg.Name == "b" || egg.Name == "d" {
+			return nil, fmt.Errorf("quota exceeded")
+		}
+		return &eggDeployOutput{EggName: egg.Name, Status: deployStatusApplied}, nil
+	}
+	statuses := func(results []*eggDeployOutput) string {
+		var s []string
+		for _, result := range results {
+			s = append(s, result.EggName+"="+result.Status)
+		}
+		return strings.Join(s, " ")
+	}
+
+	t.Run("all eggs attempted", func(t *testing.T) {
+		results, err := deployAll(context.Background(), io.Discard, progressEggs("a", "b", "c", "d"), 2, false, deploy)
+		want := "2 of 4 Egg(s) failed to deploy:\n  b: quota exceeded\n  d: quota exceeded"
+		if err == nil || err.Error() != want {
+			t.Fatalf("expected error %q, got %v", want, err)
+		}
+		if got := statuses(results); got != "a=applied b=failed c=applied d=failed" {
+			t.Errorf("unexpected results %s", got)
+		}
+		if results[1].Error != "quota exceeded" {
+			t.Errorf("expected the failure in the result, got %q", results[1].Error)
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		var out bytes.Buffer
+		results, err := deployAll(context.Background(), &out, progressEggs("a", "b", "c", "d"), 1, true, deploy)
+		if err == nil || err.Error() != "failed to deploy egg b: quota exceeded" {
+			t.Fatalf("expected the first failure, got %v", err)
+		}
+		if got := statuses(results); got != "a=applied b=failed c=skipped d=skipped" {
+			t.Errorf("unexpected results %s", got)
+		}
+		if !strings.Contains(out.String(), "c: skipped") {
+			t.Errorf("expected skipped Eggs in the output, got:\n%s", out.String())
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// prompter asks questions for the interactive (-i) mode of the add commands.
+// Invalid answers are reported and the question is asked again.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	return &prompter{in: bufio.NewReader(in), out: out}
+}
+
+// ask prompts for a string. An empty answer selects def; validate may be nil.
+func (p *prompter) ask(question, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "? %s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "? %s: ", question)
+		}
+
+		line, err := p.in.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			if errors.Is(err, io.EOF) {
+				return "", fmt.Errorf("input ended before %q was answered", question)
+			}
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if validate != nil {
+			if verr := validate(answer); verr != nil {
+				fmt.Fprintf(p.out, "  ❌ %v\n", verr)
+				if err != nil {
+					return "", verr
+				}
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+// askInt prompts for an integer within [min, max]
+func (p *prompter) askInt(question string, def, min, max int) (int, error) {
+	answer, err := p.ask(question, strconv.Itoa(def), func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", s)
+		}
+		if n < min || n > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(answer)
+}
+
+// choose prompts for one of options
+func (p *prompter) choose(question string, options []string, def string) (string, error) {
+	return p.ask(fmt.Sprintf("%s (%s)", question, strings.Join(options, "/")), def, func(s string) error {
+		for _, option := range options {
+			if s == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(options, ", "))
+	})
+}
+
+// confirm prompts for a yes/no answer
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	defAnswer := "n"
+	if def {
+		defAnswer = "y"
+	}
+	answer, err := p.ask(question+" (y/n)", defAnswer, func(s string) error {
+		switch strings.ToLower(s) {
+		case "y", "yes", "n", "no":
+			return nil
+		}
+		return fmt.Errorf("answer y or n")
+	})
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/polar-gosling/gosling/internal/git"
+	"github.com/polar-gosling/gosling/internal/gitlab"
+	"github.com/spf13/cobra"
+)
+
+var (
+	publishCommit      bool
+	publishPush        bool
+	publishMR          bool
+	publishBranch      string
+	publishRemote      string
+	publishGitLabURL   string
+	publishGitLabToken string
+	publishGitLabConn  gitlabConnFlags
+)
+
+// addPublishFlags registers the flags that commit, push and open a merge
+// request for the files a command creates
+func addPublishFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&publishCommit, "commit", false, "Commit the new configuration")
+	cmd.Flags().BoolVar(&publishPush, "push", false, "Commit on a new branch and push it (implies --commit)")
+	cmd.Flags().BoolVar(&publishMR, "mr", false, "Push and open a GitLab merge request (implies --push)")
+	cmd.Flags().StringVar(&publishBranch, "branch", "", "Branch to commit to (default: current branch, or gosling/<change> with --push)")
+	cmd.Flags().StringVar(&publishRemote, "remote", "origin", "Git remote to push to")
+	cmd.Flags().StringVar(&publishGitLabURL, "gitlab-url", "", "GitLab URL for --mr (default: https://<remote host>)")
+	cmd.Flags().StringVar(&publishGitLabToken, "gitlab-token", "", "GitLab token with api scope for --mr (default: $GITLAB_TOKEN)")
+	publishGitLabConn.register(cmd)
+}
+
+// nestPublisher commits and publishes a change to the Nest. It is prepared
+// before any file is written so a missing token or repository fails early.
+type nestPublisher struct {
+	repo   *git.Repo
+	branch string // Branch the change is committed to
+	target string // Branch the merge request targets
+	push   bool
+	mr     bool
+	token  string
+}
+
+// preparePublish checks the publish flags and switches to the change's branch.
+// It returns nil when no publish flag is set.
+func preparePublish(nestRoot, defaultBranch string) (*nestPublisher, error) {
+	p := &nestPublisher{mr: publishMR, push: publishPush || publishMR}
+	if !publishCommit && !p.push {
+		return nil, nil
+	}
+	if p.mr {
+		p.token = publishGitLabToken
+		if p.token == "" {
+			p.token = os.Getenv("GITLAB_TOKEN")
+		}
+		if p.token == "" {
+			return nil, fmt.Errorf("--mr needs a GitLab token: use --gitlab-token or GITLAB_TOKEN")
+		}
+	}
+
+	repo, err := git.Open(nestRoot)
+	if err != nil {
+		return nil, err
+	}
+	p.repo = repo
+	if p.target, err = repo.CurrentBranch(); err != nil {
+		return nil, err
+	}
+
+	p.branch = publishBranch
+	if p.branch == "" && p.push {
+		p.branch = defaultBranch
+	}
+	if p.branch == "" || p.branch == p.target {
+		if p.mr {
+			return nil, fmt.Errorf("--mr needs a branch other than %s", p.target)
+		}
+		p.branch = p.target
+		return p, nil
+	}
+	if err := repo.CreateBranch(p.branch); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// publish commits paths and, as requested, pushes the branch and opens a
+// merge request described by description
+func (p *nestPublisher) publish(ctx context.Context, message, description string, paths ...string) error {
+	if err := p.repo.Commit(message, paths...); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Committed to %s: %s\n", p.branch, message)
+	if !p.push {
+		return nil
+	}
+
+	if err := p.repo.Push(publishRemote, p.branch); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Pushed %s to %s\n", p.branch, publishRemote)
+	if !p.mr {
+		return nil
+	}
+
+	remoteURL, err := p.repo.RemoteURL(publishRemote)
+	if err != nil {
+		return err
+	}
+	remote, err := git.ParseRemote(remoteURL)
+	if err != nil {
+		return err
+	}
+	baseURL := publishGitLabURL
+	if baseURL == "" {
+		baseURL = "https://" + remote.Host
+	}
+	opts, err := publishGitLabConn.options(ctx, "")
+	if err != nil {
+		return err
+	}
+	client, err := gitlab.NewClient(baseURL, p.token, opts...)
+	if err != nil {
+		return err
+	}
+	mr, err := client.CreateMergeRequest(ctx, &gitlab.MergeRequestConfig{
+		Project:            remote.Project,
+		SourceBranch:       p.branch,
+		TargetBranch:       p.target,
+		Title:              message,
+		Description:        description,
+		RemoveSourceBranch: true,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Opened merge request !%d: %s\n", mr.IID, mr.WebURL)
+	return nil
+}
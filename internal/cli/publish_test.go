@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/git"
+)
+
+// setPublishFlags sets the publish flags for one test
+func setPublishFlags(t *testing.T, commit, push, mr bool, gitlabURL string) {
+	t.Helper()
+	publishCommit, publishPush, publishMR = commit, push, mr
+	publishBranch, publishRemote = "", "origin"
+	publishGitLabURL, publishGitLabToken = gitlabURL, "glpat-test"
+	t.Cleanup(func() {
+		publishCommit, publishPush, publishMR = false, false, false
+		publishGitLabURL, publishGitLabToken = "", ""
+	})
+}
+
+func TestPublishOpensMergeRequest(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// origin names a GitLab project but pushes to a local bare repository
+	bare := filepath.Join(t.TempDir(), "nest.git")
+	root := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "--bare", bare},
+		{"init", "-q", "-b", "main", root},
+		{"-C", root, "remote", "add", "origin", "https://gitlab.example.com/platform/nest.git"},
+		{"-C", root, "remote", "set-url", "--push", "origin", bare},
+		{"-C", root, "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		if _, err := git.Run(".", args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/platform%2Fnest/merge_requests" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		json.NewEncoder(w).Encode(map[string]interface{}{"iid": 7, "web_url": "https://gitlab.example.com/platform/nest/-/merge_requests/7"})
+	}))
+	t.Cleanup(server.Close)
+	setPublishFlags(t, false, false, true, server.URL)
+
+	publisher, err := preparePublish(root, "gosling/add-egg-my-app")
+	if err != nil {
+		t.Fatalf("preparePublish failed: %v", err)
+	}
+	writeNestFile(t, root, "Eggs/my-app/config.fly", policyEggConfig)
+	configPath := filepath.Join(root, "Eggs", "my-app", "config.fly")
+	if err := publisher.publish(context.Background(), "feat(eggs): add egg my-app", "Adds my-app", configPath); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	if _, err := git.Run(bare, "rev-parse", "--verify", "gosling/add-egg-my-app"); err != nil {
+		t.Errorf("branch not pushed: %v", err)
+	}
+	for key, want := range map[string]interface{}{
+		"source_branch":        "gosling/add-egg-my-app",
+		"target_branch":        "main",
+		"title":                "feat(eggs): add egg my-app",
+		"remove_source_branch": true,
+	} {
+		if request[key] != want {
+			t.Errorf("merge request %s = %v, want %v", key, request[key], want)
+		}
+	}
+}
+
+func TestPreparePublish(t *testing.T) {
+	setPublishFlags(t, false, false, false, "")
+	if p, err := preparePublish(t.TempDir(), "gosling/x"); p != nil || err != nil {
+		t.Errorf("expected no publisher without flags, got %v, %v", p, err)
+	}
+
+	setPublishFlags(t, false, false, true, "")
+	publishGitLabToken = ""
+	t.Setenv("GITLAB_TOKEN", "")
+	if _, err := preparePublish(t.TempDir(), "gosling/x"); err == nil || !strings.Contains(err.Error(), "GitLab token") {
+		t.Errorf("expected missing token error, got %v", err)
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	if _, err := git.Run(".", "init", "-q", "-b", "main", root); err != nil {
+		t.Fatal(err)
+	}
+	setPublishFlags(t, false, false, true, "")
+	publishBranch = "main"
+	t.Cleanup(func() { publishBranch = "" })
+	if _, err := preparePublish(root, "gosling/x"); err == nil || !strings.Contains(err.Error(), "other than main") {
+		t.Errorf("expected error for --mr on the target branch, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+// quotaSource returns the quotas of a cloud account
+type quotaSource interface {
+	Quotas(ctx context.Context, provider deployer.CloudProvider, region string) ([]deployer.Quota, error)
+}
+
+// checkQuotas fails when the resources requested by all eggs together exceed
+// the quota available in the target cloud
+func checkQuotas(ctx context.Context, source quotaSource, eggs []*deployer.EggConfig, provider deployer.CloudProvider, region string) error {
+	log := logger("quota")
+	log.Info(fmt.Sprintf("Checking %s quotas in %s", provider, region), "cloud", provider, "region", region)
+	quotas, err := source.Quotas(ctx, provider, region)
+	if err != nil {
+		return fmt.Errorf("failed to check quotas: %w", err)
+	}
+
+	shortfalls := deployer.CheckQuotas(eggs, quotas)
+	if len(shortfalls) == 0 {
+		log.Info(fmt.Sprintf("✅ %d quota(s) leave room for %d Egg(s)", len(quotas), len(eggs)))
+		return nil
+	}
+	lines := make([]string, 0, len(shortfalls))
+	for _, s := range shortfalls {
+		lines = append(lines, "  "+s.String())
+	}
+	return fmt.Errorf("the Eggs would exceed %d %s quota(s):\n%s\nRequest a quota increase or reduce the Eggs' resources",
+		len(shortfalls), provider, strings.Join(lines, "\n"))
+}
+
+// groupByPlacement groups eggs by cloud provider and region, in the order
+// each placement first appears, as quotas apply per region
+func groupByPlacement(eggs []*deployer.EggConfig) [][]*deployer.EggConfig {
+	var groups [][]*deployer.EggConfig
+	index := make(map[deployer.CloudConfig]int)
+	for _, egg := range eggs {
+		i, ok := index[egg.Cloud]
+		if !ok {
+			i = len(groups)
+			index[egg.Cloud] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], egg)
+	}
+	return groups
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+type fakeQuotaSource []deployer.Quota
+
+func (f fakeQuotaSource) Quotas(ctx context.Context, provider deployer.CloudProvider, region string) ([]deployer.Quota, error) {
+	return f, nil
+}
+
+func TestCheckQuotas(t *testing.T) {
+	eggs := []*deployer.EggConfig{
+		{Name: "a", Type: deployer.RunnerTypeVM, Resources: deployer.ResourceConfig{CPU: 8, Memory: 16384, Disk: 100}},
+		{Name: "b", Type: deployer.RunnerTypeVM, Resources: deployer.ResourceConfig{CPU: 8, Memory: 16384, Disk: 100}},
+	}
+	source := fakeQuotaSource{
+		{Kind: deployer.QuotaVCPUs, Name: "compute.instanceCores.count", Limit: 20, Usage: 8},
+		{Kind: deployer.QuotaMemoryGB, Name: "compute.instanceMemory.size", Limit: 256},
+	}
+
+	err := checkQuotas(context.Background(), source, eggs, deployer.CloudProviderYandex, "ru-central1-a")
+	if err == nil {
+		t.Fatal("checkQuotas() succeeded, want the vCPU quota exceeded")
+	}
+	if !strings.Contains(err.Error(), "compute.instanceCores.count): requested 16, available 12 of 20") {
+		t.Errorf("error = %v", err)
+	}
+
+	if err := checkQuotas(context.Background(), source, eggs[:1], deployer.CloudProviderYandex, "ru-central1-a"); err != nil {
+		t.Errorf("checkQuotas() error = %v for Eggs within quota", err)
+	}
+}
+
+func TestGroupByPlacement(t *testing.T) {
+	egg := func(name string, provider deployer.CloudProvider, region string) *deployer.EggConfig {
+		return &deployer.EggConfig{Name: name, Cloud: deployer.CloudConfig{Provider: provider, Region: region}}
+	}
+	groups := groupByPlacement([]*deployer.EggConfig{
+		egg("a", deployer.CloudProviderYandex, "ru-central1-a"),
+		egg("b", deployer.CloudProviderAWS, "us-east-1"),
+		egg("c", deployer.CloudProviderYandex, "ru-central1-a"),
+	})
+	if len(groups) != 2 || len(groups[0]) != 2 || groups[0][1].Name != "c" || groups[1][0].Name != "b" {
+		t.Errorf("unexpected groups: %v", groups)
+	}
+}
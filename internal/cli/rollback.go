@@ -2,7 +2,13 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/polar-gosling/gosling/internal/deployer"
@@ -11,17 +17,36 @@ import (
 )
 
 var (
-	rollbackTo     string
-	rollbackEgg    string
-	rollbackAPIURL string
-	rollbackAPIKey string
+	rollbackTo      string
+	rollbackEgg     string
+	rollbackAPIURL  string
+	rollbackAPIKey  string
+	rollbackWait    bool
+	rollbackNoWait  bool
+	rollbackTimeout time.Duration
+	rollbackYes     bool
+	rollbackDryRun  bool
 )
 
 var rollbackCmd = &cobra.Command{
 	Use:   "rollback",
 	Short: "Rollback a deployment",
-	Long:  "Rollback a deployment to a previous state.",
-	RunE:  runRollback,
+	Long: `Rollback a deployment to a previous state.
+
+Without --to the Egg is rolled back to the plan applied before the current
+one. MotherGoose creates a rollback plan, and gosling waits (up to --timeout)
+until it is applied or has failed; with --no-wait it returns as soon as the
+rollback has started. Either way the rollback plan ID is shown.
+
+The fields the rollback would restore are listed before asking for
+confirmation. --dry-run stops there; --yes skips the question, which is
+required when stdin or stdout is not a terminal (e.g. in CI).
+
+Example:
+  gosling rollback --egg my-app
+  gosling rollback --egg my-app --dry-run
+  gosling rollback --egg my-app --to 3f2a9c01-... --yes --no-wait`,
+	RunE: audited(runRollback),
 }
 
 func init() {
@@ -30,15 +55,25 @@ func init() {
 	rollbackCmd.Flags().StringVar(&rollbackEgg, "egg", "", "Egg name")
 	rollbackCmd.Flags().StringVar(&rollbackAPIURL, "api-url", "", "MotherGoose API URL")
 	rollbackCmd.Flags().StringVar(&rollbackAPIKey, "api-key", "", "MotherGoose API key")
+	rollbackCmd.Flags().BoolVar(&rollbackWait, "wait", true, "Wait for the rollback to complete")
+	rollbackCmd.Flags().BoolVar(&rollbackNoWait, "no-wait", false, "Return as soon as the rollback has started")
+	rollbackCmd.Flags().DurationVar(&rollbackTimeout, "timeout", 30*time.Minute, "How long to wait for the rollback to complete")
+	rollbackCmd.Flags().BoolVarP(&rollbackYes, "yes", "y", false, "Roll back without asking for confirmation")
+	rollbackCmd.Flags().BoolVar(&rollbackDryRun, "dry-run", false, "Show what the rollback would restore without rolling back")
+	rollbackCmd.MarkFlagsMutuallyExclusive("wait", "no-wait")
+	rollbackCmd.MarkFlagsMutuallyExclusive("yes", "dry-run")
 	mustMarkRequired(rollbackCmd, "egg")
-	mustMarkRequired(rollbackCmd, "api-url")
-	mustMarkRequired(rollbackCmd, "api-key")
+	mustRegisterEggCompletion(rollbackCmd, completeEggNames(true))
 }
 
 func runRollback(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := commandContext(cmd)
 
-	client := mothergoose.NewClient(rollbackAPIURL, rollbackAPIKey)
+	conn, err := resolveAPI(rollbackAPIURL, rollbackAPIKey)
+	if err != nil {
+		return err
+	}
+	client := conn.client()
 
 	// Get current deployment status
 	status, err := client.GetEggStatus(ctx, rollbackEgg)
@@ -50,8 +85,9 @@ func runRollback(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no deployment found for egg: %s", rollbackEgg)
 	}
 
+	log := logger("rollback")
 	currentPlan := status.LatestPlan
-	fmt.Printf("Current plan: %s\n", currentPlan.ID)
+	log.Info(fmt.Sprintf("Current plan: %s", currentPlan.ID), "plan_id", currentPlan.ID)
 
 	var targetPlan *deployer.DeploymentPlan
 	if rollbackTo != "" {
@@ -72,26 +108,220 @@ func runRollback(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no previous plan found")
 	}
 
-	fmt.Printf("\n=== Rollback Plan ===\n")
-	fmt.Printf("Target Plan ID: %s\n", targetPlan.ID)
-	fmt.Printf("Created At: %s\n", targetPlan.CreatedAt.Format(time.RFC3339))
-	fmt.Printf("\nRollback egg '%s' from %s to %s\n", rollbackEgg, currentPlan.ID[:8], targetPlan.ID[:8])
-	fmt.Print("Continue? (yes/no): ")
-	var response string
-	if _, err := fmt.Scanln(&response); err != nil {
-		return fmt.Errorf("failed to read input: %w", err)
+	result := &rollbackOutput{
+		EggName:       rollbackEgg,
+		CurrentPlanID: currentPlan.ID,
+		TargetPlan:    newPlanOutput(targetPlan),
+		Changes:       diffPlans(currentPlan, targetPlan),
 	}
-	if response != "yes" && response != "y" {
-		fmt.Println("Rollback cancelled")
+
+	// The preview is shown even with --quiet: it is what --yes or the prompt confirms
+	w := msgOut()
+	fmt.Fprintf(w, "\n=== Rollback Plan ===\n")
+	fmt.Fprintf(w, "Target Plan ID: %s\n", targetPlan.ID)
+	fmt.Fprintf(w, "Created At: %s\n", targetPlan.CreatedAt.Format(time.RFC3339))
+	printPlanChanges(w, result.Changes)
+	fmt.Fprintf(w, "\nRollback egg '%s' from %s to %s\n", rollbackEgg, shortID(currentPlan.ID), shortID(targetPlan.ID))
+
+	if rollbackDryRun {
+		log.Info("Dry run: nothing was rolled back")
+		result.Status = rollbackStatusDryRun
+		if isStructuredOutput() {
+			return writeStructured(os.Stdout, result)
+		}
 		return nil
 	}
+	confirmed, err := confirmRollback(os.Stdin, w)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		log.Info("Rollback cancelled")
+		result.Status = rollbackStatusCancelled
+		if isStructuredOutput() {
+			return writeStructured(os.Stdout, result)
+		}
+		return nil
+	}
+
+	log.Info("Performing rollback...", "target_plan_id", targetPlan.ID)
+	rollbackPlan, err := performRollback(ctx, log, client, rollbackEgg, targetPlan.ID, rollbackWait && !rollbackNoWait, rollbackTimeout)
+	if rollbackPlan != nil {
+		result.RollbackPlanID = rollbackPlan.ID
+	}
+	switch {
+	case err != nil && rollbackPlan == nil:
+		return err
+	case err != nil:
+		result.Status = rollbackStatusFailed
+	case rollbackPlan.Status == deployer.PlanStatusApplied:
+		result.Status = rollbackStatusCompleted
+	default:
+		result.Status = rollbackStatusInitiated
+	}
+	if isStructuredOutput() {
+		if werr := writeStructured(os.Stdout, result); werr != nil {
+			return werr
+		}
+	}
+	return err
+}
+
+// confirmRollback asks on w whether to go ahead, reading the answer from in.
+// With --yes there is no question; without a terminal to ask on it is an error.
+func confirmRollback(in *os.File, w io.Writer) (bool, error) {
+	if rollbackYes {
+		return true, nil
+	}
+	if !isTerminal(in) || !isTerminal(os.Stdout) {
+		return false, fmt.Errorf("refusing to ask for confirmation without a terminal; pass --yes to roll back or --dry-run to preview")
+	}
+	fmt.Fprint(w, "Continue? (yes/no): ")
+	var response string
+	if _, err := fmt.Fscanln(in, &response); err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+	return response == "yes" || response == "y", nil
+}
+
+// performRollback starts the rollback of eggName to targetPlanID and, when
+// wait is set, polls the rollback plan until it is applied or has failed or
+// timeout has passed. The rollback plan is returned once it has been
+// created, also with an error if it did not complete.
+func performRollback(ctx context.Context, log *slog.Logger, client mothergoose.MotherGooseClient, eggName, targetPlanID string, wait bool, timeout time.Duration) (*deployer.DeploymentPlan, error) {
+	plan, err := client.RollbackEgg(ctx, eggName, targetPlanID)
+	if err != nil {
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("Rollback plan: %s", plan.ID), "plan_id", plan.ID)
+	if !wait {
+		log.Info("Rollback initiated successfully")
+		log.Info("Use 'gosling status --egg " + eggName + "' to check rollback status")
+		return plan, nil
+	}
+
+	log.Info(fmt.Sprintf("Waiting for rollback plan %s to be applied...", shortID(plan.ID)), "plan_id", plan.ID, "timeout", timeout)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	applied, err := mothergoose.WaitForPlan(waitCtx, client, eggName, plan.ID, planPollInterval)
+	if err != nil {
+		return applied, fmt.Errorf("rollback of egg %s did not complete: %w", eggName, err)
+	}
+	log.Info("Rollback completed successfully", "plan_id", applied.ID)
+	return applied, nil
+}
+
+// Values for rollbackOutput.Status
+const (
+	rollbackStatusInitiated = "initiated" // Started with --no-wait
+	rollbackStatusCompleted = "completed"
+	rollbackStatusFailed    = "failed"
+	rollbackStatusCancelled = "cancelled"
+	rollbackStatusDryRun    = "dry_run"
+)
+
+// rollbackOutput is the machine-readable result of `gosling rollback`
+type rollbackOutput struct {
+	EggName        string       `json:"egg_name"`
+	CurrentPlanID  string       `json:"current_plan_id"`
+	TargetPlan     *planOutput  `json:"target_plan"`
+	Changes        []planChange `json:"changes"`
+	RollbackPlanID string       `json:"rollback_plan_id,omitempty"` // Plan MotherGoose created to roll back
+	Status         string       `json:"status"`
+}
+
+// planChange is a field that rolling back would restore
+type planChange struct {
+	Field   string `json:"field"`
+	Current string `json:"current"`
+	Target  string `json:"target"`
+}
+
+// diffPlans lists the fields that differ between the current and target
+// plans: the config hash, plan type and metadata, and the settings recorded
+// in a plan binary generated by deploy
+func diffPlans(current, target *deployer.DeploymentPlan) []planChange {
+	cur, tgt := planFields(current), planFields(target)
+	keys := make([]string, 0, len(cur)+len(tgt))
+	for key := range cur {
+		keys = append(keys, key)
+	}
+	for key := range tgt {
+		if _, ok := cur[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []planChange{}
+	for _, key := range keys {
+		if cur[key] != tgt[key] {
+			changes = append(changes, planChange{Field: key, Current: cur[key], Target: tgt[key]})
+		}
+	}
+	return changes
+}
+
+// planFields flattens the comparable contents of a plan into dotted field names
+func planFields(plan *deployer.DeploymentPlan) map[string]string {
+	fields := map[string]string{
+		"config_hash": plan.ConfigHash,
+		"plan_type":   plan.PlanType,
+	}
+	for key, value := range plan.Metadata {
+		fields["metadata."+key] = fmt.Sprint(value)
+	}
 
-	fmt.Println("\nPerforming rollback...")
-	fmt.Println("Note: Rollback status updates are managed by MotherGoose backend")
-	fmt.Printf("Target plan: %s\n", targetPlan.ID)
-	fmt.Println("\nRollback initiated successfully")
-	fmt.Println("Use 'gosling status --egg " + rollbackEgg + "' to check rollback status")
-	return nil
+	var binary map[string]interface{}
+	if json.Unmarshal(plan.PlanBinary, &binary) != nil {
+		return fields // An OpenTofu plan, or none
+	}
+	var flatten func(prefix string, v interface{})
+	flatten = func(prefix string, v interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			for key, value := range m {
+				flatten(prefix+"."+strings.ToLower(key), value)
+			}
+			return
+		}
+		fields[prefix] = fmt.Sprint(v)
+	}
+	for key, value := range binary {
+		// Differ between any two plans without being restored
+		if key == "timestamp" || key == "egg_name" {
+			continue
+		}
+		flatten(key, value)
+	}
+	return fields
+}
+
+// printPlanChanges prints the fields a rollback would restore
+func printPlanChanges(w io.Writer, changes []planChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No recorded differences from the current plan")
+		return
+	}
+	fmt.Fprintln(w, "Changes:")
+	for _, change := range changes {
+		fmt.Fprintf(w, "  %s: %s → %s\n", change.Field, emptyDash(change.Current), emptyDash(change.Target))
+	}
+}
+
+// emptyDash shows an unset value as "-"
+func emptyDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// shortID truncates a plan ID for display
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
 }
 
 func findPreviousPlan(plans []*deployer.DeploymentPlan, currentPlanID string) (*deployer.DeploymentPlan, error) {
@@ -100,7 +330,7 @@ func findPreviousPlan(plans []*deployer.DeploymentPlan, currentPlanID string) (*
 		if plan.ID == currentPlanID {
 			continue
 		}
-		if plan.Status == "applied" {
+		if plan.Status == deployer.PlanStatusApplied {
 			if previousPlan == nil || plan.AppliedAt.After(*previousPlan.AppliedAt) {
 				previousPlan = plan
 			}
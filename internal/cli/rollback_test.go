@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+// failingRollbackClient creates rollback plans that fail to apply
+type failingRollbackClient struct {
+	*MockMotherGooseClient
+}
+
+func (c failingRollbackClient) RollbackEgg(ctx context.Context, eggName, targetPlanID string) (*deployer.DeploymentPlan, error) {
+	plan, err := c.MockMotherGooseClient.RollbackEgg(ctx, eggName, targetPlanID)
+	if err != nil {
+		return nil, err
+	}
+	plan.Status = deployer.PlanStatusFailed
+	plan.Error = "instance group not found"
+	return plan, nil
+}
+
+func TestPerformRollback(t *testing.T) {
+	t.Run("wait", func(t *testing.T) {
+		client := NewMockMotherGooseClient()
+		var out bytes.Buffer
+		plan, err := performRollback(context.Background(), newLogger(&out, "rollback"), client, "my-app", "plan-1", true, time.Second)
+		if err != nil {
+			t.Fatalf("performRollback failed: %v", err)
+		}
+		if client.RollbackCalls != 1 || plan.RollbackPlan != "plan-1" || plan.Status != deployer.PlanStatusApplied {
+			t.Errorf("expected an applied rollback plan targeting plan-1, got %d call(s) and %+v", client.RollbackCalls, plan)
+		}
+		for _, want := range []string{"Rollback plan: rollback-1", "Rollback completed successfully"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+			}
+		}
+	})
+
+	t.Run("no wait", func(t *testing.T) {
+		client := failingRollbackClient{NewMockMotherGooseClient()}
+		var out bytes.Buffer
+		plan, err := performRollback(context.Background(), newLogger(&out, "rollback"), client, "my-app", "plan-1", false, time.Second)
+		if err != nil {
+			t.Fatalf("performRollback failed: %v", err)
+		}
+		if plan.ID != "rollback-1" || client.GetDeploymentPlanCalls != 0 {
+			t.Errorf("expected the rollback plan without polling, got %+v after %d poll(s)", plan, client.GetDeploymentPlanCalls)
+		}
+		if !strings.Contains(out.String(), "Rollback initiated successfully") {
+			t.Errorf("expected the rollback to be reported as initiated, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		client := failingRollbackClient{NewMockMotherGooseClient()}
+		plan, err := performRollback(context.Background(), newLogger(io.Discard, "rollback"), client, "my-app", "plan-1", true, time.Second)
+		want := "rollback of egg my-app did not complete: deployment plan rollback-1 failed: instance group not found"
+		if err == nil || err.Error() != want {
+			t.Fatalf("expected error %q, got %v", want, err)
+		}
+		if plan == nil || plan.ID != "rollback-1" {
+			t.Errorf("expected the failed rollback plan, got %+v", plan)
+		}
+	})
+}
+
+func TestDiffPlans(t *testing.T) {
+	plan := func(hash string, cpu int, region string) *deployer.DeploymentPlan {
+		egg := &deployer.EggConfig{
+			Name:      "my-app",
+			Type:      deployer.RunnerTypeVM,
+			Cloud:     deployer.CloudConfig{Provider: deployer.CloudProviderYandex, Region: region},
+			Resources: deployer.ResourceConfig{CPU: cpu, Memory: 4096},
+		}
+		binary, err := generatePlanBinary(egg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &deployer.DeploymentPlan{
+			ConfigHash: hash,
+			PlanType:   "runner",
+			PlanBinary: binary,
+			Metadata:   map[string]interface{}{"region": region},
+		}
+	}
+
+	got := diffPlans(plan("bbb", 4, "ru-central1-b"), plan("aaa", 2, "ru-central1-a"))
+	want := []planChange{
+		{Field: "cloud.region", Current: "ru-central1-b", Target: "ru-central1-a"},
+		{Field: "config_hash", Current: "bbb", Target: "aaa"},
+		{Field: "metadata.region", Current: "ru-central1-b", Target: "ru-central1-a"},
+		{Field: "resources.cpu", Current: "4", Target: "2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffPlans =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Plans without a JSON binary are compared by hash and metadata
+	got = diffPlans(&deployer.DeploymentPlan{ConfigHash: "bbb", PlanBinary: []byte{0x1f, 0x8b}}, &deployer.DeploymentPlan{ConfigHash: "bbb"})
+	if len(got) != 0 {
+		t.Errorf("expected no changes, got %+v", got)
+	}
+}
+
+func TestConfirmRollbackWithoutTerminal(t *testing.T) {
+	defer func() { rollbackYes = false }()
+	in, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	defer pw.Close()
+
+	rollbackYes = false
+	if _, err := confirmRollback(in, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "pass --yes") {
+		t.Errorf("expected a refusal to prompt, got %v", err)
+	}
+
+	rollbackYes = true
+	if ok, err := confirmRollback(in, &bytes.Buffer{}); !ok || err != nil {
+		t.Errorf("expected --yes to confirm, got %v, %v", ok, err)
+	}
+}
@@ -1,10 +1,14 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/polar-gosling/gosling/internal/telemetry"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -22,16 +26,58 @@ var rootCmd = &cobra.Command{
 It provides commands to bootstrap Nest repositories, manage Egg configurations,
 and deploy runners across multiple cloud providers.`,
 	Version: Version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(cmd); err != nil {
+			return err
+		}
+		if err := validateLogFlags(); err != nil {
+			return err
+		}
+		if err := setupTelemetry(cmd); err != nil {
+			return err
+		}
+		ctx, span := telemetry.StartSpan(commandContext(cmd), cmd.CommandPath())
+		cmd.SetContext(ctx)
+		commandSpan = span
+		return nil
+	},
 }
 
+// commandSpan traces the running command; it is nil until flags are validated
+var commandSpan trace.Span
+
+// telemetryFlushTimeout bounds sending buffered spans and metrics on exit
+const telemetryFlushTimeout = 5 * time.Second
+
 // Execute runs the root command
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	err := rootCmd.ExecuteContext(context.Background())
+	if commandSpan != nil {
+		telemetry.EndSpan(commandSpan, err)
+	}
+	if shutdownTelemetry != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+		if serr := shutdownTelemetry(ctx); serr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Failed to send telemetry: %v\n", serr)
+		}
+		cancel()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, friendlyError(err))
 		os.Exit(1)
 	}
 }
 
+// commandContext returns the context of a running command, which carries
+// its trace span
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
 // mustMarkRequired marks a flag as required and panics if the flag doesn't exist.
 // This is intentional: missing required flags are programming errors caught at startup.
 func mustMarkRequired(cmd *cobra.Command, flag string) {
@@ -43,4 +89,12 @@ func mustMarkRequired(cmd *cobra.Command, flag string) {
 func init() {
 	// Set version template
 	rootCmd.SetVersionTemplate(fmt.Sprintf("Gosling version %s (commit: %s, built: %s)\n", Version, GitCommit, BuildDate))
+
+	// Global output format: text for humans, json/yaml for scripts and CI
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: text, json, yaml, or sarif (validate and lint)")
+
+	// Global logging flags: how much progress to show, and in which format
+	rootCmd.PersistentFlags().BoolVarP(&logVerbose, "verbose", "v", false, "Show debug messages, with their component and details")
+	rootCmd.PersistentFlags().BoolVarP(&logQuiet, "quiet", "q", false, "Only show warnings and errors")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logFormatText, "Log format: text or json (JSON logs go to stderr)")
 }
@@ -8,6 +8,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/polar-gosling/gosling/internal/gitlab"
 	"github.com/polar-gosling/gosling/internal/runner"
 	"github.com/spf13/cobra"
 )
@@ -17,12 +18,16 @@ var (
 	runnerID                string
 	runnerTokenSecret       string
 	runnerGitLabServer      string
+	runnerProjectID         int
+	runnerGroupID           int
+	runnerRegistration      string
 	runnerTags              string
 	runnerAgentVersion      string
 	runnerMotherGooseURL    string
 	runnerAPIKey            string
 	runnerMetricsInterval   time.Duration
 	runnerHeartbeatInterval time.Duration
+	runnerGitLabConn        gitlabConnFlags
 )
 
 var runnerCmd = &cobra.Command{
@@ -32,7 +37,20 @@ var runnerCmd = &cobra.Command{
 
 This command is the entrypoint used inside deployed runner containers/VMs.
 It registers the GitLab Runner Agent with GitLab, manages the agent process
-lifecycle, synchronizes the agent version, and reports health metrics.`,
+lifecycle, synchronizes the agent version, and reports health metrics.
+
+By default the runner is created with the runner creation API (POST /user/runners)
+when --project-id or --group-id is given and the GitLab server supports it
+(15.10+); the token then needs the create_runner scope. Without a project or
+group, on older servers, or when the GitLab version cannot be read with the
+token (registration tokens cannot call the API), the deprecated registration
+token flow is used. Use --registration to choose explicitly.
+
+Self-hosted GitLab servers with a private CA, mutual TLS or an egress proxy
+are reached with --gitlab-ca-cert, --gitlab-client-cert/--gitlab-client-key
+and --gitlab-proxy.
+
+With --metrics-addr, Prometheus metrics are served at http://<addr>/metrics.`,
 	RunE: runRunner,
 }
 
@@ -42,31 +60,53 @@ func init() {
 	runnerCmd.Flags().StringVar(&runnerID, "runner-id", "", "Unique runner ID (defaults to runner-<egg-name>)")
 	runnerCmd.Flags().StringVar(&runnerTokenSecret, "token-secret", "", "Secret URI for the GitLab runner token (e.g., yc-lockbox://gitlab/gitlab.com/my-app/runner-token)")
 	runnerCmd.Flags().StringVar(&runnerGitLabServer, "gitlab-server", "gitlab.com", "GitLab server FQDN")
+	runnerCmd.Flags().IntVar(&runnerProjectID, "project-id", 0, "GitLab project to create the runner for (authentication-token registration)")
+	runnerCmd.Flags().IntVar(&runnerGroupID, "group-id", 0, "GitLab group to create the runner for (authentication-token registration)")
+	runnerCmd.Flags().StringVar(&runnerRegistration, "registration", string(gitlab.RegistrationAuto), "Registration method: auto, registration-token or authentication-token")
 	runnerCmd.Flags().StringVar(&runnerTags, "tags", "", "Comma-separated runner tags")
 	runnerCmd.Flags().StringVar(&runnerAgentVersion, "agent-version", "", "Required GitLab Runner Agent version (uses latest if not specified)")
 	runnerCmd.Flags().StringVar(&runnerMotherGooseURL, "mothergoose-url", "", "MotherGoose API URL for metrics reporting")
 	runnerCmd.Flags().StringVar(&runnerAPIKey, "api-key", "", "MotherGoose API key")
 	runnerCmd.Flags().DurationVar(&runnerMetricsInterval, "metrics-interval", 30*time.Second, "How often to report full metrics to MotherGoose")
 	runnerCmd.Flags().DurationVar(&runnerHeartbeatInterval, "heartbeat-interval", 30*time.Second, "How often to send heartbeat pings to MotherGoose")
+	addMetricsFlag(runnerCmd)
+	runnerGitLabConn.register(runnerCmd)
 	mustMarkRequired(runnerCmd, "egg-name")
 	mustMarkRequired(runnerCmd, "token-secret")
 }
 
 func runRunner(cmd *cobra.Command, args []string) error {
+	registration, err := gitlab.ParseRegistrationMethod(runnerRegistration)
+	if err != nil {
+		return err
+	}
+	if runnerProjectID != 0 && runnerGroupID != 0 {
+		return fmt.Errorf("--project-id and --group-id cannot be used together")
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	gitlabOptions, err := runnerGitLabConn.options(ctx, "")
+	if err != nil {
+		return err
+	}
+
 	cfg := &runner.Config{
 		EggName:           runnerEggName,
 		RunnerID:          runnerID,
 		TokenSecretURI:    runnerTokenSecret,
 		GitLabServer:      runnerGitLabServer,
+		ProjectID:         runnerProjectID,
+		GroupID:           runnerGroupID,
+		Registration:      registration,
 		Tags:              runner.ParseTags(runnerTags),
 		AgentVersion:      runnerAgentVersion,
 		MotherGooseURL:    runnerMotherGooseURL,
 		APIKey:            runnerAPIKey,
 		MetricsInterval:   runnerMetricsInterval,
 		HeartbeatInterval: runnerHeartbeatInterval,
+		GitLabOptions:     gitlabOptions,
 	}
 
 	mgr, err := runner.New(cfg)
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/spf13/cobra"
+)
+
+// runnerStateStale selects runners by heartbeat age rather than by the state
+// MotherGoose reports
+const runnerStateStale = "stale"
+
+var (
+	runnersListEgg        string
+	runnersListState      string
+	runnersListStaleAfter time.Duration
+	runnersListAPIURL     string
+	runnersListAPIKey     string
+)
+
+// runnerListCmd represents the runner list command
+var runnerListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List runners and their last heartbeat",
+	Long: `List the runners known to MotherGoose with their state and the age of
+their last heartbeat.
+
+--state keeps only runners in a state reported by MotherGoose (e.g. active,
+paused, draining). --state stale instead keeps the runners whose last
+heartbeat is at least --stale-after old, whatever their state.
+
+Example:
+  gosling runner list
+  gosling runners list --egg my-app --state stale
+  gosling runner list --state paused -o json`,
+	Args: cobra.NoArgs,
+	RunE: runRunnerList,
+}
+
+func init() {
+	runnerCmd.AddCommand(runnerListCmd)
+	runnerCmd.Aliases = append(runnerCmd.Aliases, "runners")
+	runnerListCmd.Flags().StringVar(&runnersListEgg, "egg", "", "Only list runners of this Egg")
+	runnerListCmd.Flags().StringVar(&runnersListState, "state", "", "Only list runners in this state, or \"stale\" for runners without a recent heartbeat")
+	runnerListCmd.Flags().DurationVar(&runnersListStaleAfter, "stale-after", 90*time.Second, "Heartbeat age at which a runner is stale")
+	runnerListCmd.Flags().StringVar(&runnersListAPIURL, "api-url", "", "MotherGoose API URL")
+	runnerListCmd.Flags().StringVar(&runnersListAPIKey, "api-key", "", "MotherGoose API key")
+	mustRegisterEggCompletion(runnerListCmd, completeEggNames(true))
+}
+
+// runnerListOutput is the machine-readable result of `gosling runner list`
+type runnerListOutput struct {
+	Runners []*runnerOutput `json:"runners"`
+}
+
+// runnerOutput is a runner with the age of its last heartbeat
+type runnerOutput struct {
+	*mothergoose.Runner
+	HeartbeatAgeSeconds int64 `json:"heartbeat_age_seconds"`
+	Stale               bool  `json:"stale"`
+}
+
+func runRunnerList(cmd *cobra.Command, args []string) error {
+	conn, err := resolveAPI(runnersListAPIURL, runnersListAPIKey)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := listRunners(commandContext(cmd), conn.client(), runnersListEgg, runnersListState, runnersListStaleAfter, now)
+	if err != nil {
+		return err
+	}
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, result)
+	}
+	return printRunners(os.Stdout, result, runnersListStaleAfter, now)
+}
+
+// listRunners lists the runners of eggName (or of every Egg) in state, where
+// the state "stale" selects runners whose last heartbeat is at least
+// staleAfter old at now
+func listRunners(ctx context.Context, client mothergoose.MotherGooseClient, eggName, state string, staleAfter time.Duration, now time.Time) (*runnerListOutput, error) {
+	filter := mothergoose.RunnerFilter{EggName: eggName, State: state}
+	if state == runnerStateStale {
+		filter.State = ""
+		// A runner whose heartbeat is exactly staleAfter old is stale too
+		filter.HeartbeatBefore = now.Add(-staleAfter).Add(time.Nanosecond)
+	}
+	runners, err := client.ListRunners(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &runnerListOutput{Runners: make([]*runnerOutput, 0, len(runners))}
+	for _, runner := range runners {
+		age := now.Sub(runner.LastHeartbeat)
+		result.Runners = append(result.Runners, &runnerOutput{
+			Runner:              runner,
+			HeartbeatAgeSeconds: int64(age / time.Second),
+			Stale:               age >= staleAfter,
+		})
+	}
+	return result, nil
+}
+
+// printRunners writes the runners as a table, coloring heartbeat ages like
+// `gosling status --watch`
+func printRunners(w io.Writer, result *runnerListOutput, staleAfter time.Duration, now time.Time) error {
+	if len(result.Runners) == 0 {
+		fmt.Fprintln(w, "No runners found")
+		return nil
+	}
+	color := useColor(w)
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "EGG NAME\tRUNNER ID\tTYPE\tSTATE\tREGION\tLAST HEARTBEAT")
+	for _, runner := range result.Runners {
+		age := now.Sub(runner.LastHeartbeat)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			runner.EggName,
+			runner.ID,
+			runner.Type,
+			runner.State,
+			runner.Region,
+			paint(heartbeatColor(age, staleAfter), heartbeatAge(age, staleAfter)))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+func TestListRunners(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	client := NewMockMotherGooseClient()
+	client.EggStatuses["my-app"] = &mothergoose.EggStatus{ActiveRunners: []*mothergoose.Runner{
+		{ID: "runner-1", EggName: "my-app", State: "active", LastHeartbeat: now.Add(-10 * time.Second)},
+		{ID: "runner-2", EggName: "my-app", State: "paused", LastHeartbeat: now.Add(-90 * time.Second)},
+	}}
+	client.EggStatuses["other"] = &mothergoose.EggStatus{ActiveRunners: []*mothergoose.Runner{
+		{ID: "runner-3", EggName: "other", State: "active", LastHeartbeat: now.Add(-time.Hour)},
+	}}
+	ctx := context.Background()
+
+	ids := func(result *runnerListOutput) []string {
+		var ids []string
+		for _, runner := range result.Runners {
+			ids = append(ids, runner.ID)
+		}
+		return ids
+	}
+	tests := []struct {
+		egg, state string
+		want       []string
+	}{
+		{"", "", []string{"runner-1", "runner-2", "runner-3"}},
+		{"my-app", "", []string{"runner-1", "runner-2"}},
+		{"", "active", []string{"runner-1", "runner-3"}},
+		{"", "stale", []string{"runner-2", "runner-3"}},
+		{"my-app", "stale", []string{"runner-2"}},
+	}
+	for _, tt := range tests {
+		result, err := listRunners(ctx, client, tt.egg, tt.state, 90*time.Second, now)
+		if err != nil {
+			t.Fatalf("listRunners(%q, %q) failed: %v", tt.egg, tt.state, err)
+		}
+		if got := strings.Join(ids(result), ","); got != strings.Join(tt.want, ",") {
+			t.Errorf("listRunners(%q, %q) = %s, want %s", tt.egg, tt.state, got, strings.Join(tt.want, ","))
+		}
+	}
+
+	result, err := listRunners(ctx, client, "my-app", "", 90*time.Second, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := result.Runners[1]; !r.Stale || r.HeartbeatAgeSeconds != 90 {
+		t.Errorf("expected runner-2 to be stale at 90s, got %+v", r)
+	}
+
+	var buf bytes.Buffer
+	if err := printRunners(&buf, result, 90*time.Second, now); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "1m30s ago (stale)") || !strings.Contains(buf.String(), "10s ago") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
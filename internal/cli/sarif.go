@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/diagnostic"
+)
+
+// SARIF 2.1.0, the subset read by GitHub code scanning and GitLab's
+// vulnerability report. See https://docs.oasis-open.org/sarif/sarif/v2.1.0/
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifSourceRoot is the base of the file URIs: the Nest, which code
+	// scanning tools resolve to the repository checkout
+	sarifSourceRoot = "%SRCROOT%"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// writeSARIF writes diagnostics as a SARIF log. Files are located relative
+// to root; describe returns the description of a rule ID.
+func writeSARIF(w io.Writer, diags []diagnostic.Diagnostic, root string, describe func(code string) string) error {
+	driver := sarifDriver{Name: "gosling", Version: Version, InformationURI: "https://github.com/polar-gosling/gosling", Rules: []sarifRule{}}
+	run := sarifRun{Results: []sarifResult{}}
+
+	rules := make(map[string]sarifRule)
+	for _, d := range diags {
+		if d.Code != "" {
+			if _, ok := rules[d.Code]; !ok {
+				rules[d.Code] = sarifRule{ID: d.Code, ShortDescription: sarifMessage{Text: describe(d.Code)}, HelpURI: d.URL}
+			}
+		}
+		run.Results = append(run.Results, sarifResultOf(d, root))
+	}
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, rule)
+	}
+	sort.Slice(driver.Rules, func(i, j int) bool { return driver.Rules[i].ID < driver.Rules[j].ID })
+	run.Tool.Driver = driver
+
+	data, err := json.MarshalIndent(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode SARIF: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func sarifResultOf(d diagnostic.Diagnostic, root string) sarifResult {
+	result := sarifResult{RuleID: d.Code, Level: "error", Message: sarifMessage{Text: d.Message}}
+	if d.Severity == diagnostic.SeverityWarning {
+		result.Level = "warning"
+	}
+	if d.Detail != "" {
+		result.Message.Text += ": " + d.Detail
+	}
+	if d.File == "" {
+		return result
+	}
+
+	artifact := sarifArtifactLocation{URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(d.File)}).String()}
+	if rel, err := filepath.Rel(root, d.File); err == nil && root != "" && !strings.HasPrefix(rel, "..") {
+		artifact = sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: sarifSourceRoot}
+	}
+	location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact}}
+	if d.Line > 0 {
+		location.PhysicalLocation.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column, EndColumn: d.EndColumn}
+	}
+	result.Locations = []sarifLocation{location}
+	return result
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/diagnostic"
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+func TestValidateSARIF(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", strings.Replace(policyEggConfig, `type = "vm"`, `type = "container"`, 1))
+	writeNestFile(t, root, "Eggs/broken/config.fly", "egg \"broken\" {\n  type = \n}\n")
+
+	report := &validateOutput{Files: validateFiles([]string{
+		filepath.Join(root, "Eggs", "my-app", "config.fly"),
+		filepath.Join(root, "Eggs", "broken", "config.fly"),
+	}, 1, nil)}
+	var buf bytes.Buffer
+	if err := writeSARIF(&buf, report.diagnostics(), root, describeValidationCode); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF: %v\n%s", err, buf.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF log: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", run.Results)
+	}
+
+	value := run.Results[0]
+	location := value.Locations[0].PhysicalLocation
+	if value.RuleID != parser.CodeValue || value.Level != "error" ||
+		location.ArtifactLocation.URI != "Eggs/my-app/config.fly" || location.ArtifactLocation.URIBaseID != "%SRCROOT%" ||
+		location.Region.StartLine != 3 || location.Region.StartColumn != 10 {
+		t.Errorf("unexpected result for the invalid type: %+v", value)
+	}
+	if syntax := run.Results[1]; syntax.RuleID != parser.CodeSyntax || syntax.Locations[0].PhysicalLocation.Region.StartLine != 2 {
+		t.Errorf("unexpected result for the syntax error: %+v", syntax)
+	}
+
+	if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != parser.CodeSyntax ||
+		run.Tool.Driver.Rules[1].HelpURI != parser.CodeURL(parser.CodeValue) || run.Tool.Driver.Rules[1].ShortDescription.Text == "" {
+		t.Errorf("unexpected rules: %+v", run.Tool.Driver.Rules)
+	}
+}
+
+func TestSARIFLintFinding(t *testing.T) {
+	var buf bytes.Buffer
+	diags := []diagnostic.Diagnostic{{Severity: diagnostic.SeverityWarning, Code: "missing-idle-timeout", Message: "no idle_timeout", File: "/elsewhere/config.fly", Line: 4, Column: 3}}
+	if err := writeSARIF(&buf, diags, "/nest", describeLintRule); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	result := log.Runs[0].Results[0]
+	if result.Level != "warning" || result.Locations[0].PhysicalLocation.ArtifactLocation.URI != "file:///elsewhere/config.fly" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if rule := log.Runs[0].Tool.Driver.Rules[0]; rule.ID != "missing-idle-timeout" || rule.ShortDescription.Text == "" {
+		t.Errorf("unexpected rule: %+v", rule)
+	}
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/polar-gosling/gosling/internal/git"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var (
+	scaleEgg        string
+	scaleConcurrent int
+	scaleUglyFox    []string
+	scaleCondition  string
+	scaleCommit     bool
+	scaleDeploy     bool
+)
+
+// scaleCmd represents the scale command
+var scaleCmd = &cobra.Command{
+	Use:   "scale",
+	Short: "Adjust runner pool sizes without editing .fly files",
+	Long: `Change an Egg's runner concurrency or the UglyFox pool sizes in place.
+
+The attribute is rewritten in the .fly file, keeping comments and formatting,
+and the result is validated before it is saved.
+
+--uglyfox takes a dotted path below the uglyfox block. apex and nadir paths
+refer to the runners_condition selected with --condition, which may be
+omitted when there is only one.
+
+--egg takes the name deploy gives the Egg. Instances of count or for_each
+share their block's runner.concurrent, so they are scaled by editing it.
+
+With --commit the changed file is committed to git; with --deploy (Eggs
+only) the Nest is deployed afterwards using the deploy flags.
+
+Example:
+  gosling scale --egg my-app --concurrent 10
+  gosling scale --uglyfox apex.max_count=20 --uglyfox nadir.min_count=2
+  gosling scale --uglyfox apex.max_count=40 --condition heavy --commit
+  gosling scale --egg my-app --concurrent 10 --commit --deploy --cloud aws --region us-east-1`,
+	Args: cobra.NoArgs,
+	RunE: audited(runScale),
+}
+
+func init() {
+	rootCmd.AddCommand(scaleCmd)
+	scaleCmd.Flags().StringVar(&scaleEgg, "egg", "", "Egg to scale")
+	scaleCmd.Flags().IntVar(&scaleConcurrent, "concurrent", 0, "Number of concurrent jobs per runner of the Egg")
+	scaleCmd.Flags().StringArrayVar(&scaleUglyFox, "uglyfox", nil, "UglyFox setting as path=value, e.g. apex.max_count=20 (repeatable)")
+	scaleCmd.Flags().StringVar(&scaleCondition, "condition", "", "runners_condition to change with --uglyfox")
+	scaleCmd.Flags().BoolVar(&scaleCommit, "commit", false, "Commit the changed file")
+	scaleCmd.Flags().BoolVar(&scaleDeploy, "deploy", false, "Deploy the Nest after scaling an Egg")
+	scaleCmd.Flags().StringVar(&deployCloud, "cloud", "", "Cloud provider for --deploy: yandex, aws, or azure")
+	scaleCmd.Flags().StringVar(&deployRegion, "region", "", "Cloud region for --deploy")
+	scaleCmd.Flags().StringVar(&deployAPIURL, "api-url", "", "MotherGoose API URL for --deploy")
+	scaleCmd.Flags().StringVar(&deployAPIKey, "api-key", "", "MotherGoose API key for --deploy")
+	scaleCmd.Flags().StringVar(&deployEnv, "env", "", "Environment overlay to deploy with --deploy")
+	scaleCmd.MarkFlagsMutuallyExclusive("egg", "uglyfox")
+	scaleCmd.MarkFlagsRequiredTogether("egg", "concurrent")
+	scaleCmd.MarkFlagsMutuallyExclusive("uglyfox", "deploy")
+	mustRegisterEggCompletion(scaleCmd, completeEggNames(false))
+}
+
+// scaleSetting is a single attribute to change
+type scaleSetting struct {
+	Path  []string
+	Value int
+}
+
+func runScale(cmd *cobra.Command, args []string) error {
+	if scaleEgg == "" && len(scaleUglyFox) == 0 {
+		return fmt.Errorf("either --egg with --concurrent or --uglyfox must be specified")
+	}
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+
+	var filePath, summary string
+	if scaleEgg != "" {
+		if scaleConcurrent < 1 {
+			return fmt.Errorf("--concurrent must be at least 1")
+		}
+		var label string
+		if filePath, label, err = scaleEggTarget(filepath.Join(nestRoot, "Eggs"), scaleEgg); err != nil {
+			return err
+		}
+		if strings.HasSuffix(filePath, parser.JSONExtension) {
+			return fmt.Errorf("%s uses the JSON syntax, which scale cannot edit; set concurrent in the file instead", filePath)
+		}
+		if err := scaleFile(filePath, func(body *hclwrite.Body) error {
+			return scaleEggConcurrency(body, label, scaleConcurrent)
+		}); err != nil {
+			return err
+		}
+		summary = fmt.Sprintf("Scale %s to %d concurrent jobs", scaleEgg, scaleConcurrent)
+	} else {
+		settings, err := parseScaleSettings(scaleUglyFox)
+		if err != nil {
+			return err
+		}
+		filePath = filepath.Join(nestRoot, "UF", "config.fly")
+		if err := scaleFile(filePath, func(body *hclwrite.Body) error {
+			return scaleUglyFoxPools(body, scaleCondition, settings)
+		}); err != nil {
+			return err
+		}
+		summary = "Scale UglyFox pools: " + strings.Join(scaleUglyFox, ", ")
+	}
+
+	relPath, _ := filepath.Rel(nestRoot, filePath)
+	log := logger("scale")
+	log.Info(fmt.Sprintf("✅ Updated %s", relPath), "path", relPath)
+
+	if scaleCommit {
+		repo, err := git.Open(nestRoot)
+		if err != nil {
+			return err
+		}
+		if err := repo.Commit(summary, filePath); err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("✅ Committed: %s", summary))
+	}
+	if scaleDeploy {
+		return runDeploy(cmd, nil)
+	}
+	return nil
+}
+
+// scaleEggTarget resolves the Egg called name the way deploy does, returning
+// its config file and the label of its block. Instances of count or for_each
+// share one block and cannot be scaled one at a time.
+func scaleEggTarget(eggsDir, name string) (string, string, error) {
+	dir, _, block, err := resolveEggBlock(eggsDir, name, "")
+	if err != nil {
+		// An eggsbucket has no egg block; its directory names it
+		if configPath, ok := eggConfigPath(filepath.Join(eggsDir, name)); ok {
+			return configPath, name, nil
+		}
+		return "", "", err
+	}
+	if block.Repeated {
+		return "", "", fmt.Errorf("egg %q is an instance of count or for_each in %s: every instance shares its runner.concurrent, so edit it there instead", name, dir.ConfigPath)
+	}
+	label := name
+	if len(block.Labels) > 0 {
+		label = block.Labels[0]
+	}
+	return dir.ConfigPath, label, nil
+}
+
+// parseScaleSettings parses --uglyfox path=value arguments
+func parseScaleSettings(args []string) ([]scaleSetting, error) {
+	settings := make([]scaleSetting, 0, len(args))
+	for _, arg := range args {
+		path, value, ok := strings.Cut(arg, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid --uglyfox %q: expected path=value, e.g. apex.max_count=20", arg)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --uglyfox %q: value must be an integer", arg)
+		}
+		settings = append(settings, scaleSetting{Path: strings.Split(strings.TrimSpace(path), "."), Value: n})
+	}
+	return settings, nil
+}
+
+// scaleFile applies edit to a .fly file, validates the result and saves it.
+// hclwrite keeps comments and formatting outside the changed attributes.
+func scaleFile(filePath string, edit func(*hclwrite.Body) error) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	file, diags := hclwrite.ParseConfig(content, filePath, hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse %s: %s", filePath, diags.Error())
+	}
+	if err := edit(file.Body()); err != nil {
+		return fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	updated := file.Bytes()
+	if _, _, err := parser.ParseAndValidateContent(updated, filePath); err != nil {
+		return fmt.Errorf("refusing to save %s: %w", filePath, err)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", filePath, err)
+	}
+	if err := os.WriteFile(filePath, updated, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// scaleEggConcurrency sets runner.concurrent of the egg or eggsbucket block named eggName
+func scaleEggConcurrency(body *hclwrite.Body, eggName string, concurrent int) error {
+	for _, block := range body.Blocks() {
+		if block.Type() != "egg" && block.Type() != "eggsbucket" {
+			continue
+		}
+		if labels := block.Labels(); len(labels) == 0 || labels[0] != eggName {
+			continue
+		}
+		runner := block.Body().FirstMatchingBlock("runner", nil)
+		if runner == nil {
+			return fmt.Errorf("%s %q has no runner block in this file (is it in an include?)", block.Type(), eggName)
+		}
+		runner.Body().SetAttributeValue("concurrent", cty.NumberIntVal(int64(concurrent)))
+		return nil
+	}
+	return fmt.Errorf("no egg or eggsbucket block named %q", eggName)
+}
+
+// scaleUglyFoxPools applies settings below the uglyfox block. Paths that do
+// not start with a block of uglyfox itself are resolved in the selected
+// runners_condition.
+func scaleUglyFoxPools(body *hclwrite.Body, condition string, settings []scaleSetting) error {
+	uglyfox := body.FirstMatchingBlock("uglyfox", nil)
+	if uglyfox == nil {
+		return fmt.Errorf("no uglyfox block")
+	}
+
+	for _, setting := range settings {
+		target := uglyfox.Body()
+		blocks := setting.Path[:len(setting.Path)-1]
+		if len(blocks) > 0 && target.FirstMatchingBlock(blocks[0], nil) == nil {
+			cond, err := selectRunnersCondition(target, condition)
+			if err != nil {
+				return err
+			}
+			target = cond.Body()
+		}
+		for _, name := range blocks {
+			next := target.FirstMatchingBlock(name, nil)
+			if next == nil {
+				return fmt.Errorf("no %s block for %s", name, strings.Join(setting.Path, "."))
+			}
+			target = next.Body()
+		}
+		target.SetAttributeValue(setting.Path[len(setting.Path)-1], cty.NumberIntVal(int64(setting.Value)))
+	}
+	return nil
+}
+
+// selectRunnersCondition returns the runners_condition named condition, or
+// the only one if condition is empty
+func selectRunnersCondition(uglyfox *hclwrite.Body, condition string) (*hclwrite.Block, error) {
+	var names []string
+	var found []*hclwrite.Block
+	for _, block := range uglyfox.Blocks() {
+		if block.Type() != "runners_condition" || len(block.Labels()) == 0 {
+			continue
+		}
+		names = append(names, block.Labels()[0])
+		if condition == "" || block.Labels()[0] == condition {
+			found = append(found, block)
+		}
+	}
+	sort.Strings(names)
+	switch {
+	case len(names) == 0:
+		return nil, fmt.Errorf("no runners_condition block")
+	case len(found) == 0:
+		return nil, fmt.Errorf("runners_condition %q not found: known conditions are %v", condition, names)
+	case len(found) > 1:
+		return nil, fmt.Errorf("several runners_condition blocks, select one with --condition: %v", names)
+	}
+	return found[0], nil
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func TestScaleEggConcurrency(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", "# Owned by team X\n"+policyEggConfig)
+	path := filepath.Join(root, "Eggs", "my-app", "config.fly")
+
+	err := scaleFile(path, func(body *hclwrite.Body) error {
+		return scaleEggConcurrency(body, "my-app", 10)
+	})
+	if err != nil {
+		t.Fatalf("scaleFile failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	for _, want := range []string{"# Owned by team X", "concurrent   = 10", `idle_timeout = "10m"`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected %q in scaled file:\n%s", want, content)
+		}
+	}
+
+	err = scaleFile(path, func(body *hclwrite.Body) error {
+		return scaleEggConcurrency(body, "other-app", 10)
+	})
+	if err == nil {
+		t.Error("expected error for an Egg that is not in the file")
+	}
+}
+
+func TestScaleEggTarget(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/app/config.fly", policyEggConfig)
+	writeNestFile(t, root, "Eggs/workers/config.fly", strings.Replace(policyEggConfig, `egg "my-app" {`, "egg \"worker-${count.index}\" {\n  count = 2\n", 1))
+	eggsDir := filepath.Join(root, "Eggs")
+
+	// An Egg is named after its directory even when its label differs
+	path, label, err := scaleEggTarget(eggsDir, "app")
+	if err != nil || path != filepath.Join(eggsDir, "app", "config.fly") || label != "my-app" {
+		t.Errorf("expected Eggs/app labeled my-app, got %s, %s, %v", path, label, err)
+	}
+	if _, _, err := scaleEggTarget(eggsDir, "worker-1"); err == nil || !strings.Contains(err.Error(), "instance of count or for_each") {
+		t.Errorf("expected instances of count to be rejected, got %v", err)
+	}
+	if _, _, err := scaleEggTarget(eggsDir, "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected an unknown Egg to be reported, got %v", err)
+	}
+}
+
+func TestScaleUglyFoxPools(t *testing.T) {
+	root := t.TempDir()
+	opts := uglyFoxOptions{
+		FailedThreshold:  3,
+		MaxAge:           "24h",
+		CheckInterval:    "5m",
+		Eggs:             []string{"my-app"},
+		ApexMax:          10,
+		ApexMin:          2,
+		NadirMax:         5,
+		NadirIdleTimeout: "30m",
+	}
+	writeNestFile(t, root, "UF/config.fly", generateUglyFoxConfig(opts))
+	path := filepath.Join(root, "UF", "config.fly")
+	scale := func(args ...string) error {
+		settings, err := parseScaleSettings(args)
+		if err != nil {
+			return err
+		}
+		return scaleFile(path, func(body *hclwrite.Body) error {
+			return scaleUglyFoxPools(body, "", settings)
+		})
+	}
+
+	if err := scale("apex.max_count=20", "pruning.failed_threshold=5"); err != nil {
+		t.Fatalf("scale failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	for _, want := range []string{"max_count        = 20", "failed_threshold = 5", "# Active runners"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected %q in scaled file:\n%s", want, content)
+		}
+	}
+
+	// Values outside the schema are refused and the file is left alone
+	if err := scale("apex.max_count=5000"); err == nil {
+		t.Error("expected validation error for apex.max_count=5000")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(content) {
+		t.Error("file changed despite the validation error")
+	}
+
+	for _, bad := range []string{"apex.max_count", "apex.max_count=lots", "surge.max_count=3"} {
+		if err := scale(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schemaType         string
+	schemaExportFormat string
+	schemaExportOut    string
+	schemaExportStrict bool
+)
+
+// Supported values for schema export --format
+const schemaFormatJSONSchema = "jsonschema"
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Show the schema of .fly block types",
+	Long: `Show the schema used to validate .fly configuration blocks.
+
+The schema lists each block's labels, attributes (type, required, ranges,
+allowed values) and nested blocks. Use --output json to feed it to editor
+tooling.
+
+Example:
+  gosling schema
+  gosling schema --type egg
+  gosling schema --type egg --output json`,
+	RunE: runSchema,
+}
+
+// schemaExportCmd represents the schema export command
+var schemaExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the .fly schema as JSON Schema",
+	Long: `Export the schema of .fly block types as JSON Schema (draft 2020-12) for
+editor autocomplete and validation outside Gosling.
+
+Each document describes a block in HCL's JSON syntax, where labels are object
+keys: {"egg": {"my-app": {"type": "vm", ...}}}. Types, required attributes and
+blocks, ranges, allowed values and duration formats are described; checks
+across attributes (e.g. provider regions) are only run by gosling validate.
+With --strict, unknown attributes and blocks are rejected, as by
+gosling validate --strict.
+
+With --out, each block type is written to <dir>/<type>.schema.json.
+Otherwise the document of --type is printed, or an object of the documents of
+every block type by type.
+
+Example:
+  gosling schema export --format jsonschema --out schemas
+  gosling schema export --type egg > egg.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: runSchemaExport,
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.AddCommand(schemaExportCmd)
+	schemaCmd.PersistentFlags().StringVarP(&schemaType, "type", "t", "", "Block type to show (default: all)")
+
+	schemaExportCmd.Flags().StringVar(&schemaExportFormat, "format", schemaFormatJSONSchema, "Export format: jsonschema")
+	schemaExportCmd.Flags().StringVar(&schemaExportOut, "out", "", "Directory to write one <type>.schema.json per block type to")
+	schemaExportCmd.Flags().BoolVar(&schemaExportStrict, "strict", false, "Reject unknown attributes and blocks")
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	blockSchemas, err := selectedSchemas()
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		if schemaType != "" {
+			return writeStructured(os.Stdout, blockSchemas[0])
+		}
+		return writeStructured(os.Stdout, map[string]interface{}{"schemas": blockSchemas})
+	}
+
+	for i, schema := range blockSchemas {
+		if i > 0 {
+			fmt.Println()
+		}
+		printBlockSchema(schema, "", "")
+	}
+	return nil
+}
+
+// selectedSchemas returns the schema of --type, or of every block type
+func selectedSchemas() ([]*parser.BlockSchema, error) {
+	if schemaType != "" {
+		schema, ok := parser.LookupSchema(schemaType)
+		if !ok {
+			return nil, fmt.Errorf("unknown block type %q: must be one of %s", schemaType, strings.Join(parser.SchemaTypes(), ", "))
+		}
+		return []*parser.BlockSchema{schema}, nil
+	}
+	var blockSchemas []*parser.BlockSchema
+	for _, t := range parser.SchemaTypes() {
+		schema, _ := parser.LookupSchema(t)
+		blockSchemas = append(blockSchemas, schema)
+	}
+	return blockSchemas, nil
+}
+
+func runSchemaExport(cmd *cobra.Command, args []string) error {
+	if schemaExportFormat != schemaFormatJSONSchema {
+		return fmt.Errorf("invalid format %q: must be jsonschema", schemaExportFormat)
+	}
+	blockSchemas, err := selectedSchemas()
+	if err != nil {
+		return err
+	}
+
+	docs := make(map[string]interface{}, len(blockSchemas))
+	for _, schema := range blockSchemas {
+		docs[schema.Type] = parser.JSONSchema(schema, schemaExportStrict)
+	}
+	if schemaExportOut == "" {
+		if schemaType != "" {
+			return writeStructured(os.Stdout, docs[schemaType])
+		}
+		return writeStructured(os.Stdout, docs)
+	}
+
+	if err := os.MkdirAll(schemaExportOut, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	for _, schema := range blockSchemas {
+		path := filepath.Join(schemaExportOut, schema.Type+".schema.json")
+		var buf bytes.Buffer
+		if err := writeStructured(&buf, docs[schema.Type]); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Fprintf(msgOut(), "📄 %s\n", path)
+	}
+	return nil
+}
+
+// printBlockSchema prints a block schema as an indented tree
+func printBlockSchema(schema *parser.BlockSchema, indent, occurrence string) {
+	header := schema.Type
+	if schema.Label != "" {
+		header += fmt.Sprintf(" \"<%s>\"", schema.Label)
+	}
+	if occurrence != "" {
+		header += " (" + occurrence + ")"
+	}
+	if schema.Description != "" {
+		header += " - " + schema.Description
+	}
+	fmt.Printf("%s%s\n", indent, header)
+
+	inner := indent + "  "
+	for _, attr := range schema.Attributes {
+		var details []string
+		details = append(details, string(attr.Type))
+		if attr.Required {
+			details = append(details, "required")
+		}
+		if attr.Format != "" {
+			details = append(details, attr.Format)
+		}
+		if attr.MinDuration != "" && attr.MaxDuration != "" {
+			details = append(details, attr.MinDuration+".."+attr.MaxDuration)
+		}
+		if attr.Min != nil && attr.Max != nil {
+			details = append(details, fmt.Sprintf("%v..%v", *attr.Min, *attr.Max))
+		}
+		if len(attr.Enum) > 0 {
+			details = append(details, strings.Join(attr.Enum, "|"))
+		}
+		if attr.Deprecated != nil {
+			details = append(details, "deprecated")
+		}
+		line := fmt.Sprintf("%s%s: %s", inner, attr.Name, strings.Join(details, ", "))
+		if attr.Description != "" {
+			line += " - " + attr.Description
+		}
+		fmt.Println(line)
+	}
+
+	for _, nested := range schema.Blocks {
+		occurrence := "optional"
+		switch {
+		case nested.AnyType && nested.MinItems > 0:
+			occurrence = fmt.Sprintf("%d or more, any name", nested.MinItems)
+		case nested.AnyType:
+			occurrence = "any number, any name"
+		case nested.Multiple && nested.MinItems > 0:
+			occurrence = fmt.Sprintf("%d or more", nested.MinItems)
+		case nested.Multiple:
+			occurrence = "any number"
+		case nested.Required:
+			occurrence = "required"
+		}
+		if nested.Deprecated != nil {
+			occurrence += ", deprecated"
+		}
+		printBlockSchema(nested.Schema, inner, occurrence)
+	}
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/git"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deployOnlyEgg      []string
+	deployOnlyEggs     []string
+	deployChangedSince string
+	deployPaths        []string
+)
+
+// addSelectionFlags adds the flags shared by deploy and plan that pick which Eggs to deploy
+func addSelectionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&deployOnlyEgg, "egg", nil, "Deploy only this Egg (repeatable)")
+	cmd.Flags().StringSliceVar(&deployOnlyEggs, "eggs", nil, "Deploy only these Eggs (comma-separated)")
+	cmd.Flags().StringVar(&deployChangedSince, "changed-since", "", "Deploy only Eggs changed since this git revision")
+	cmd.Flags().StringArrayVar(&deployPaths, "path", nil, "Deploy only Eggs whose directory matches this glob, e.g. 'Eggs/team-a-*' (repeatable)")
+}
+
+// eggSelection picks the Eggs to deploy. Each filter that is set narrows the
+// selection; with none set every Egg is deployed.
+type eggSelection struct {
+	Names        []string // --egg and --eggs
+	ChangedSince string   // --changed-since: git revision to compare the Nest with
+	Paths        []string // --path: globs relative to the Nest root
+}
+
+func (s eggSelection) isSet() bool {
+	return len(s.Names) > 0 || s.ChangedSince != "" || len(s.Paths) > 0
+}
+
+// filter returns the Eggs matching every filter, in their original order
+func (s eggSelection) filter(nestRoot string, eggs []*deployer.EggConfig) ([]*deployer.EggConfig, error) {
+	var names map[string]bool
+	if len(s.Names) > 0 {
+		known := make(map[string]bool, len(eggs))
+		for _, egg := range eggs {
+			known[egg.Name] = true
+		}
+		names = make(map[string]bool, len(s.Names))
+		for _, name := range s.Names {
+			if !known[name] {
+				return nil, fmt.Errorf("egg %q not found in the Nest", name)
+			}
+			names[name] = true
+		}
+	}
+
+	patterns := make([]string, len(s.Paths))
+	for i, pattern := range s.Paths {
+		patterns[i] = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if _, err := path.Match(patterns[i], ""); err != nil {
+			return nil, fmt.Errorf("invalid --path pattern %q: %w", pattern, err)
+		}
+	}
+
+	var changed map[string]bool
+	if s.ChangedSince != "" {
+		var all bool
+		var err error
+		if changed, all, err = changedEggs(nestRoot, s.ChangedSince); err != nil {
+			return nil, err
+		}
+		if all {
+			changed = nil
+		}
+	}
+
+	var selected []*deployer.EggConfig
+	for _, egg := range eggs {
+		if names != nil && !names[egg.Name] {
+			continue
+		}
+		if len(patterns) > 0 && !matchesAny(patterns, "Eggs/"+egg.Name) {
+			continue
+		}
+		if changed != nil && !changed[egg.Name] {
+			continue
+		}
+		selected = append(selected, egg)
+	}
+	return selected, nil
+}
+
+// matchesAny reports whether one of patterns matches the Egg directory dir or
+// its config.fly
+func matchesAny(patterns []string, dir string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, dir); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, dir+"/config.fly"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// changedEggs returns the names of the Eggs whose directory changed since rev.
+// A change to a shared include directory (Eggs/_*) or to the Nest's presets
+// may affect any Egg, so all is reported instead.
+func changedEggs(nestRoot, rev string) (changed map[string]bool, all bool, err error) {
+	files, err := git.ChangedFiles(nestRoot, rev, "Eggs", parser.PresetsDirName)
+	if err != nil {
+		return nil, false, err
+	}
+	changed = make(map[string]bool)
+	for _, file := range files {
+		parts := strings.Split(file, "/")
+		if parts[0] == parser.PresetsDirName {
+			return nil, true, nil
+		}
+		if len(parts) < 3 {
+			continue // A file directly in Eggs/
+		}
+		if strings.HasPrefix(parts[1], "_") {
+			return nil, true, nil
+		}
+		changed[parts[1]] = true
+	}
+	return changed, false, nil
+}
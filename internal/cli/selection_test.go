@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/git"
+)
+
+func selectedNames(eggs []*deployer.EggConfig) []string {
+	names := []string{}
+	for _, egg := range eggs {
+		names = append(names, egg.Name)
+	}
+	return names
+}
+
+func TestEggSelectionFilter(t *testing.T) {
+	var eggs []*deployer.EggConfig
+	for _, name := range []string{"team-a-api", "team-a-web", "team-b-api"} {
+		eggs = append(eggs, &deployer.EggConfig{Name: name})
+	}
+	tests := []struct {
+		name      string
+		selection eggSelection
+		want      []string
+		wantErr   string
+	}{
+		{name: "names", selection: eggSelection{Names: []string{"team-b-api", "team-a-api"}}, want: []string{"team-a-api", "team-b-api"}},
+		{name: "unknown name", selection: eggSelection{Names: []string{"team-c"}}, wantErr: `egg "team-c" not found in the Nest`},
+		{name: "directory glob", selection: eggSelection{Paths: []string{"Eggs/team-a-*"}}, want: []string{"team-a-api", "team-a-web"}},
+		{name: "config glob", selection: eggSelection{Paths: []string{"Eggs/*-api/config.fly"}}, want: []string{"team-a-api", "team-b-api"}},
+		{name: "trailing slash", selection: eggSelection{Paths: []string{"Eggs/team-b-*/"}}, want: []string{"team-b-api"}},
+		{name: "no match", selection: eggSelection{Paths: []string{"Eggs/team-c-*"}}, want: []string{}},
+		{
+			name:      "filters combine",
+			selection: eggSelection{Names: []string{"team-a-api", "team-b-api"}, Paths: []string{"Eggs/team-a-*"}},
+			want:      []string{"team-a-api"},
+		},
+		{name: "bad pattern", selection: eggSelection{Paths: []string{"Eggs/[team"}}, wantErr: `invalid --path pattern "Eggs/[team"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := tt.selection.filter(t.TempDir(), eggs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("filter failed: %v", err)
+			}
+			if got := selectedNames(selected); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEggSelectionChangedSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	var eggs []*deployer.EggConfig
+	for _, name := range []string{"my-app", "my-api", "my-web"} {
+		writeNestFile(t, root, filepath.Join("Eggs", name, "config.fly"), policyEggConfig)
+		eggs = append(eggs, &deployer.EggConfig{Name: name})
+	}
+	writeNestFile(t, root, "Eggs/_shared/defaults.fly", "")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main", root},
+		{"-C", root, "add", "."},
+		{"-C", root, "commit", "-q", "-m", "initial"},
+	} {
+		if _, err := git.Run(".", args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changedSince := eggSelection{ChangedSince: "HEAD"}
+
+	selected, err := changedSince.filter(root, eggs)
+	if err != nil {
+		t.Fatalf("filter failed: %v", err)
+	}
+	if got := selectedNames(selected); len(got) != 0 {
+		t.Errorf("expected no changed Eggs, got %v", got)
+	}
+
+	// An untracked overlay in one Egg and a modified config.fly in another
+	writeNestFile(t, root, "Eggs/my-api/config.prod.fly", policyEggConfig)
+	writeNestFile(t, root, "Eggs/my-web/config.fly", policyEggConfig+"\n")
+	selected, err = changedSince.filter(root, eggs)
+	if err != nil {
+		t.Fatalf("filter failed: %v", err)
+	}
+	if got, want := selectedNames(selected), []string{"my-api", "my-web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Shared includes may affect every Egg
+	writeNestFile(t, root, "Eggs/_shared/defaults.fly", "# changed\n")
+	selected, err = changedSince.filter(root, eggs)
+	if err != nil {
+		t.Fatalf("filter failed: %v", err)
+	}
+	if got := selectedNames(selected); len(got) != len(eggs) {
+		t.Errorf("expected all Eggs after a shared include changed, got %v", got)
+	}
+
+	if _, err := (eggSelection{ChangedSince: "no-such-ref"}).filter(root, eggs); err == nil || !strings.Contains(err.Error(), "unknown git revision") {
+		t.Errorf("expected unknown revision error, got %v", err)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
@@ -12,17 +15,32 @@ import (
 )
 
 var (
-	statusEgg    string
-	statusAll    bool
-	statusAPIURL string
-	statusAPIKey string
+	statusEgg        string
+	statusAll        bool
+	statusAPIURL     string
+	statusAPIKey     string
+	statusWatch      bool
+	statusInterval   time.Duration
+	statusStaleAfter time.Duration
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show deployment status",
-	Long:  "Show the current deployment status for eggs.",
-	RunE:  runStatus,
+	Long: `Show the current deployment status for eggs. With --all, the Jobs
+registered with MotherGoose are listed after the Eggs.
+
+With --watch, a dashboard of Egg statuses and active runners is redrawn every
+--interval until interrupted. Runner heartbeats are colored green when fresh,
+yellow when getting old and red once older than --stale-after. With
+--metrics-addr, Prometheus metrics are served at http://<addr>/metrics while
+watching.
+
+Example:
+  gosling status --egg my-app
+  gosling status --all --watch --interval 10s
+  gosling status --all --watch --metrics-addr localhost:9464`,
+	RunE: runStatus,
 }
 
 func init() {
@@ -31,17 +49,44 @@ func init() {
 	statusCmd.Flags().BoolVar(&statusAll, "all", false, "Show all eggs")
 	statusCmd.Flags().StringVar(&statusAPIURL, "api-url", "", "MotherGoose API URL")
 	statusCmd.Flags().StringVar(&statusAPIKey, "api-key", "", "MotherGoose API key")
-	mustMarkRequired(statusCmd, "api-url")
-	mustMarkRequired(statusCmd, "api-key")
+	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "Keep refreshing a live dashboard until interrupted")
+	statusCmd.Flags().DurationVar(&statusInterval, "interval", 5*time.Second, "Refresh interval for --watch")
+	statusCmd.Flags().DurationVar(&statusStaleAfter, "stale-after", 90*time.Second, "Heartbeat age at which --watch marks a runner stale")
+	addMetricsFlag(statusCmd)
+	mustRegisterEggCompletion(statusCmd, completeEggNames(true))
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := commandContext(cmd)
 	if statusEgg == "" && !statusAll {
 		return fmt.Errorf("either --egg or --all flag must be specified")
 	}
+	if statusWatch {
+		if isStructuredOutput() {
+			return fmt.Errorf("--watch cannot be combined with --output %s", outputFormat)
+		}
+		if statusInterval <= 0 || statusStaleAfter <= 0 {
+			return fmt.Errorf("--interval and --stale-after must be positive")
+		}
+	} else if metricsAddr != "" {
+		return fmt.Errorf("--metrics-addr requires --watch")
+	}
 
-	client := mothergoose.NewClient(statusAPIURL, statusAPIKey)
+	conn, err := resolveAPI(statusAPIURL, statusAPIKey)
+	if err != nil {
+		return err
+	}
+	client := conn.client(mothergoose.WithResponseCache())
+
+	if statusWatch {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		eggName := statusEgg
+		if statusAll {
+			eggName = ""
+		}
+		return watchStatus(ctx, client, eggName, os.Stdout, statusInterval, statusStaleAfter)
+	}
 
 	if statusAll {
 		return showAllStatus(ctx, client)
@@ -49,7 +94,50 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	return showEggStatus(ctx, client, statusEgg)
 }
 
+// eggStatusOutput is the machine-readable result of `gosling status --egg`
+type eggStatusOutput struct {
+	EggName           string                `json:"egg_name"`
+	Deployed          bool                  `json:"deployed"`
+	LatestPlan        *planOutput           `json:"latest_plan,omitempty"`
+	ActiveRunners     []*mothergoose.Runner `json:"active_runners"`
+	DeploymentHistory []*planOutput         `json:"deployment_history"`
+}
+
+// eggSummaryOutput is a single row of `gosling status --all`
+type eggSummaryOutput struct {
+	EggName    string     `json:"egg_name"`
+	Status     string     `json:"status"`
+	PlanID     string     `json:"plan_id,omitempty"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty"`
+	ConfigHash string     `json:"config_hash,omitempty"`
+}
+
+func newEggStatusOutput(eggName string, status *mothergoose.EggStatus) *eggStatusOutput {
+	out := &eggStatusOutput{
+		EggName:           eggName,
+		Deployed:          status.LatestPlan != nil,
+		LatestPlan:        newPlanOutput(status.LatestPlan),
+		ActiveRunners:     status.ActiveRunners,
+		DeploymentHistory: make([]*planOutput, 0, len(status.DeploymentHistory)),
+	}
+	if out.ActiveRunners == nil {
+		out.ActiveRunners = []*mothergoose.Runner{}
+	}
+	for _, plan := range status.DeploymentHistory {
+		out.DeploymentHistory = append(out.DeploymentHistory, newPlanOutput(plan))
+	}
+	return out
+}
+
 func showEggStatus(ctx context.Context, client mothergoose.MotherGooseClient, eggName string) error {
+	if isStructuredOutput() {
+		status, err := client.GetEggStatus(ctx, eggName)
+		if err != nil {
+			return fmt.Errorf("failed to get egg status: %w", err)
+		}
+		return writeStructured(os.Stdout, newEggStatusOutput(eggName, status))
+	}
+
 	fmt.Printf("=== Deployment Status for Egg: %s ===\n\n", eggName)
 	status, err := client.GetEggStatus(ctx, eggName)
 	if err != nil {
@@ -125,9 +213,30 @@ func showAllStatus(ctx context.Context, client mothergoose.MotherGooseClient) er
 	if err != nil {
 		return fmt.Errorf("failed to list eggs: %w", err)
 	}
+	jobs, err := listJobStatus(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		summaries := make([]*eggSummaryOutput, 0, len(eggs))
+		for _, egg := range eggs {
+			summary := &eggSummaryOutput{EggName: egg.Name, Status: "not deployed"}
+			status, err := client.GetEggStatus(ctx, egg.Name)
+			if err == nil && status.LatestPlan != nil {
+				summary.Status = status.LatestPlan.Status
+				summary.PlanID = status.LatestPlan.ID
+				summary.AppliedAt = status.LatestPlan.AppliedAt
+				summary.ConfigHash = status.LatestPlan.ConfigHash
+			}
+			summaries = append(summaries, summary)
+		}
+		return writeStructured(os.Stdout, map[string]interface{}{"eggs": summaries, "jobs": jobs})
+	}
 
 	if len(eggs) == 0 {
 		fmt.Println("No eggs found")
+		printJobStatus(jobs)
 		return nil
 	}
 
@@ -161,5 +270,23 @@ func showAllStatus(ctx context.Context, client mothergoose.MotherGooseClient) er
 		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", eggName, latestPlan.Status, planID, appliedStr, configHash)
 	}
 	w.Flush()
+	printJobStatus(jobs)
 	return nil
 }
+
+// printJobStatus prints the Jobs registered with MotherGoose, if any
+func printJobStatus(jobs []*jobStatusOutput) {
+	if len(jobs) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("=== Jobs ===")
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB NAME\tSCHEDULE\tRUNNER TYPE\tRUNNER TAGS")
+	fmt.Fprintln(w, "--------\t--------\t-----------\t-----------")
+	for _, job := range jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", job.JobName, job.Schedule, job.RunnerType, strings.Join(job.RunnerTags, ","))
+	}
+	w.Flush()
+}
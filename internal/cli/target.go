@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+// deployTarget is the cloud and region requested with --cloud and --region
+// (or GOSLING_CLOUD, GOSLING_REGION and the profile); either may be empty
+type deployTarget struct {
+	Cloud    deployer.CloudProvider
+	Region   string
+	Override bool // --override-cloud: the target replaces the Eggs' cloud blocks
+}
+
+// reconcile decides where egg is deployed. The Egg's cloud block is used and
+// the target only fills in what it leaves unset. A target that disagrees with
+// the cloud block is an error, unless Override is set, in which case the
+// target replaces it.
+func (t deployTarget) reconcile(egg *deployer.EggConfig) error {
+	cloudConflict := t.Cloud != "" && egg.Cloud.Provider != "" && t.Cloud != egg.Cloud.Provider
+	regionConflict := t.Region != "" && egg.Cloud.Region != "" && t.Region != egg.Cloud.Region
+
+	if (cloudConflict || regionConflict) && !t.Override {
+		return fmt.Errorf("egg %s is configured for %s but %s was requested; fix --cloud/--region or pass --override-cloud to deploy it there anyway",
+			egg.Name, placement(egg.Cloud.Provider, egg.Cloud.Region), placement(t.Cloud, t.Region))
+	}
+	if cloudConflict && t.Region == "" {
+		return fmt.Errorf("egg %s: --override-cloud to %s needs --region, as %s is a %s region",
+			egg.Name, t.Cloud, egg.Cloud.Region, egg.Cloud.Provider)
+	}
+	if cloudConflict || regionConflict {
+		fmt.Fprintf(msgOut(), "⚠️  Deploying egg %s to %s instead of %s from its config\n",
+			egg.Name, placement(t.Cloud, t.Region), placement(egg.Cloud.Provider, egg.Cloud.Region))
+	}
+
+	if t.Cloud != "" {
+		egg.Cloud.Provider = t.Cloud
+	}
+	if t.Region != "" {
+		egg.Cloud.Region = t.Region
+	}
+	if egg.Cloud.Provider == "" {
+		return fmt.Errorf("egg %s has no cloud provider: set cloud.provider in its config or pass --cloud", egg.Name)
+	}
+	if egg.Cloud.Region == "" {
+		return fmt.Errorf("egg %s has no region: set cloud.region in its config or pass --region", egg.Name)
+	}
+	return nil
+}
+
+// placement formats a cloud and region, either of which may be unset
+func placement(cloud deployer.CloudProvider, region string) string {
+	switch {
+	case cloud == "":
+		return "region " + region
+	case region == "":
+		return string(cloud)
+	}
+	return fmt.Sprintf("%s/%s", cloud, region)
+}
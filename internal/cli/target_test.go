@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func TestDeployTargetReconcile(t *testing.T) {
+	yandex := deployer.CloudConfig{Provider: deployer.CloudProviderYandex, Region: "ru-central1-a"}
+	tests := []struct {
+		name    string
+		target  deployTarget
+		config  deployer.CloudConfig
+		want    deployer.CloudConfig
+		wantErr string
+	}{
+		{name: "config only", config: yandex, want: yandex},
+		{name: "matching flags", target: deployTarget{Cloud: "yandex", Region: "ru-central1-a"}, config: yandex, want: yandex},
+		{
+			name:   "flags fill in an unset region",
+			target: deployTarget{Region: "ru-central1-b"},
+			config: deployer.CloudConfig{Provider: deployer.CloudProviderYandex},
+			want:   deployer.CloudConfig{Provider: deployer.CloudProviderYandex, Region: "ru-central1-b"},
+		},
+		{
+			name:    "cloud conflict",
+			target:  deployTarget{Cloud: "aws", Region: "us-east-1"},
+			config:  yandex,
+			wantErr: "egg my-app is configured for yandex/ru-central1-a but aws/us-east-1 was requested",
+		},
+		{
+			name:    "region conflict",
+			target:  deployTarget{Region: "ru-central1-b"},
+			config:  yandex,
+			wantErr: "but region ru-central1-b was requested",
+		},
+		{
+			name:   "override",
+			target: deployTarget{Cloud: "aws", Region: "us-east-1", Override: true},
+			config: yandex,
+			want:   deployer.CloudConfig{Provider: deployer.CloudProviderAWS, Region: "us-east-1"},
+		},
+		{
+			name:    "override cloud without region",
+			target:  deployTarget{Cloud: "aws", Override: true},
+			config:  yandex,
+			wantErr: "--override-cloud to aws needs --region",
+		},
+		{
+			name:    "no cloud anywhere",
+			config:  deployer.CloudConfig{Region: "ru-central1-a"},
+			wantErr: "egg my-app has no cloud provider",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			egg := &deployer.EggConfig{Name: "my-app", Cloud: tt.config}
+			err := tt.target.reconcile(egg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reconcile failed: %v", err)
+			}
+			if egg.Cloud != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, egg.Cloud)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// eggTemplateExt is the extension of Egg templates in the Nest's Templates/
+const eggTemplateExt = ".fly.tmpl"
+
+// eggTemplate generates an Egg's config.fly with text/template. Templates
+// read the Egg name as {{ .name }} and variables given with --set as
+// {{ .<variable> }}; a variable that is not set is empty.
+type eggTemplate struct {
+	Name   string
+	Source string
+	Path   string // File in Templates/, empty for built-in templates
+}
+
+// templateFuncs are the functions available to Egg templates in addition to
+// the text/template builtins
+var templateFuncs = template.FuncMap{
+	// default returns value, or def if value is empty: {{ default "2" .cpu }}
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+	// required fails rendering if value is empty: {{ required "project_id" .project_id }}
+	"required": func(name, value string) (string, error) {
+		if value == "" {
+			return "", fmt.Errorf("variable %q is required (--set %s=...)", name, name)
+		}
+		return value, nil
+	},
+	"defaultRegion": defaultRegion,
+	"secretScheme":  secretScheme,
+}
+
+// builtinEggTemplates are available in every Nest. A template in the Nest's
+// Templates/ with the same name replaces the built-in one.
+var builtinEggTemplates = []eggTemplate{
+	// Variables: provider, region, cpu, memory, disk, concurrent, project_id, server_name
+	{
+		Name: "docker-vm",
+		Source: `{{- $provider := default "yandex" .provider -}}
+# Egg Configuration: {{ .name }}
+# Template: docker-vm
+# Runner Type: vm
+# Cloud Provider: {{ $provider }}
+
+egg "{{ .name }}" {
+  type = "vm"
+
+  cloud {
+    provider = "{{ $provider }}"
+    region   = "{{ default (defaultRegion $provider) .region }}"
+  }
+
+  resources {
+    cpu    = {{ default "2" .cpu }}
+    memory = {{ default "4096" .memory }}  # MB
+    disk   = {{ default "20" .disk }}  # GB
+  }
+
+  runner {
+    tags         = ["docker", "linux"]
+    concurrent   = {{ default "3" .concurrent }}
+    idle_timeout = "10m"
+  }
+
+  gitlab {
+{{- if .project_id }}
+    project_id   = {{ .project_id }}
+{{- else }}
+    # TODO: Set your GitLab project ID
+    project_id   = 0
+{{- end }}
+    server_name  = "{{ default "gitlab.com" .server_name }}"
+    token_secret = "{{ secretScheme $provider }}://gitlab-tokens/{{ .name }}-runner-token"
+  }
+
+  environment {
+    DOCKER_DRIVER = "overlay2"
+  }
+}
+`,
+	},
+	// Variables: region, memory, project_id, server_name
+	{
+		Name: "serverless-lambda",
+		Source: `# Egg Configuration: {{ .name }}
+# Template: serverless-lambda
+# Runner Type: serverless
+# Cloud Provider: aws
+
+egg "{{ .name }}" {
+  type = "serverless"
+
+  cloud {
+    provider = "aws"
+    region   = "{{ default "us-east-1" .region }}"
+  }
+
+  resources {
+    cpu    = 1
+    memory = {{ default "2048" .memory }}  # MB, 128 to 10240
+    disk   = 10  # GB
+  }
+
+  runner {
+    tags         = ["serverless", "linux"]
+    concurrent   = 1
+    idle_timeout = "5m"
+  }
+
+  gitlab {
+{{- if .project_id }}
+    project_id   = {{ .project_id }}
+{{- else }}
+    # TODO: Set your GitLab project ID
+    project_id   = 0
+{{- end }}
+    server_name  = "{{ default "gitlab.com" .server_name }}"
+    token_secret = "aws-sm://gitlab-tokens/{{ .name }}-runner-token"
+  }
+}
+`,
+	},
+	// One job per GPU. Variables: provider, region, gpu_count, cpu, memory,
+	// disk, project_id, server_name
+	{
+		Name: "gpu-runner",
+		Source: `{{- $provider := default "yandex" .provider -}}
+{{- $gpus := default "1" .gpu_count -}}
+# Egg Configuration: {{ .name }}
+# Template: gpu-runner
+# Runner Type: vm
+# Cloud Provider: {{ $provider }}
+
+egg "{{ .name }}" {
+  type = "vm"
+
+  cloud {
+    provider = "{{ $provider }}"
+    region   = "{{ default (defaultRegion $provider) .region }}"
+  }
+
+  resources {
+    cpu    = {{ default "8" .cpu }}
+    memory = {{ default "49152" .memory }}  # MB
+    disk   = {{ default "200" .disk }}  # GB, room for CUDA images and datasets
+  }
+
+  runner {
+    tags         = ["gpu", "cuda", "linux"]
+    concurrent   = {{ $gpus }}  # one job per GPU
+    idle_timeout = "30m"
+  }
+
+  gitlab {
+{{- if .project_id }}
+    project_id   = {{ .project_id }}
+{{- else }}
+    # TODO: Set your GitLab project ID
+    project_id   = 0
+{{- end }}
+    server_name  = "{{ default "gitlab.com" .server_name }}"
+    token_secret = "{{ secretScheme $provider }}://gitlab-tokens/{{ .name }}-runner-token"
+  }
+
+  environment {
+    GPU_COUNT                  = "{{ $gpus }}"
+    NVIDIA_VISIBLE_DEVICES     = "all"
+    NVIDIA_DRIVER_CAPABILITIES = "compute,utility"
+  }
+}
+`,
+	},
+}
+
+// loadEggTemplates returns the built-in templates and those in the Nest's
+// Templates/ directory, by name
+func loadEggTemplates(nestRoot string) (map[string]eggTemplate, error) {
+	templates := make(map[string]eggTemplate, len(builtinEggTemplates))
+	for _, tmpl := range builtinEggTemplates {
+		templates[tmpl.Name] = tmpl
+	}
+
+	paths, err := filepath.Glob(filepath.Join(nestRoot, "Templates", "*"+eggTemplateExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), eggTemplateExt)
+		templates[name] = eggTemplate{Name: name, Source: string(content), Path: path}
+	}
+	return templates, nil
+}
+
+// findEggTemplate returns the template called name
+func findEggTemplate(nestRoot, name string) (eggTemplate, error) {
+	templates, err := loadEggTemplates(nestRoot)
+	if err != nil {
+		return eggTemplate{}, err
+	}
+	tmpl, ok := templates[name]
+	if !ok {
+		names := make([]string, 0, len(templates))
+		for n := range templates {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return eggTemplate{}, fmt.Errorf("unknown template %q: must be one of %s", name, strings.Join(names, ", "))
+	}
+	return tmpl, nil
+}
+
+// parseTemplateVars parses --set key=value pairs
+func parseTemplateVars(sets []string) (map[string]string, error) {
+	vars := make(map[string]string, len(sets))
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok || !isTemplateVarName(key) {
+			return nil, fmt.Errorf("invalid --set %q: must be <variable>=<value>, with a variable name of letters, digits and underscores", set)
+		}
+		if key == "name" {
+			return nil, fmt.Errorf("invalid --set %q: the Egg name is given as the argument", set)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// isTemplateVarName reports whether key can be used as {{ .key }} in a template
+func isTemplateVarName(key string) bool {
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		return false
+	}
+	for _, ch := range key {
+		if !((ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// renderEggTemplate generates the configuration of the Egg eggName, to be
+// written at configPath, and checks that it parses as a single egg block with
+// that name
+func renderEggTemplate(tmpl eggTemplate, eggName, configPath string, vars map[string]string) (string, error) {
+	source := tmpl.Name
+	if tmpl.Path != "" {
+		source = tmpl.Path
+	}
+	t, err := template.New(tmpl.Name).Funcs(templateFuncs).Option("missingkey=zero").Parse(tmpl.Source)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %s: %w", source, err)
+	}
+
+	data := make(map[string]string, len(vars)+1)
+	for key, value := range vars {
+		data[key] = value
+	}
+	data["name"] = eggName
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", source, err)
+	}
+
+	config, err := parser.NewParser().Parse(buf.Bytes(), configPath)
+	if err != nil {
+		return "", fmt.Errorf("template %s generated an invalid configuration: %w", source, err)
+	}
+	if len(config.Blocks) != 1 || config.Blocks[0].Type != "egg" || len(config.Blocks[0].Labels) == 0 || config.Blocks[0].Labels[0] != eggName {
+		return "", fmt.Errorf("template %s must generate a single egg %q block", source, eggName)
+	}
+	return buf.String(), nil
+}
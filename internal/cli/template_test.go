@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+func TestBuiltinEggTemplates(t *testing.T) {
+	for _, tmpl := range builtinEggTemplates {
+		t.Run(tmpl.Name, func(t *testing.T) {
+			content, err := renderEggTemplate(tmpl, "my-app", "config.fly", map[string]string{"project_id": "42"})
+			if err != nil {
+				t.Fatalf("render failed: %v", err)
+			}
+			config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := parser.NewValidator(config).Validate(); !result.IsValid() {
+				t.Errorf("generated config should be valid: %s\n%s", result.Error(), content)
+			}
+		})
+	}
+}
+
+func TestRenderEggTemplate(t *testing.T) {
+	root := t.TempDir()
+	tmpl, err := findEggTemplate(root, "gpu-runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := renderEggTemplate(tmpl, "ml-train", "config.fly", map[string]string{"gpu_count": "2", "provider": "aws"})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	for _, want := range []string{`egg "ml-train"`, `GPU_COUNT                  = "2"`, "concurrent   = 2", `region   = "us-east-1"`, "aws-sm://"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in:\n%s", want, content)
+		}
+	}
+
+	if _, err := renderEggTemplate(tmpl, "ml-train", "config.fly", map[string]string{"gpu_count": "2 GPUs"}); err == nil {
+		t.Error("expected an error for a configuration that does not parse")
+	}
+
+	// A Nest template replaces the built-in one of the same name
+	writeNestFile(t, root, "Templates/gpu-runner.fly.tmpl", `egg "{{ .name }}" {
+  type = "{{ required "type" .type }}"
+}
+`)
+	tmpl, err = findEggTemplate(root, "gpu-runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Path != filepath.Join(root, "Templates", "gpu-runner.fly.tmpl") {
+		t.Errorf("expected the Nest template, got %+v", tmpl)
+	}
+	if _, err := renderEggTemplate(tmpl, "ml-train", "config.fly", nil); err == nil || !strings.Contains(err.Error(), `"type" is required`) {
+		t.Errorf("expected a missing variable error, got %v", err)
+	}
+	if _, err := findEggTemplate(root, "nope"); err == nil || !strings.Contains(err.Error(), "docker-vm, gpu-runner, serverless-lambda") {
+		t.Errorf("expected the available templates to be listed, got %v", err)
+	}
+}
+
+func TestParseTemplateVars(t *testing.T) {
+	vars, err := parseTemplateVars([]string{"gpu_count=2", "tags=a,b", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars["gpu_count"] != "2" || vars["tags"] != "a,b" || vars["empty"] != "" {
+		t.Errorf("unexpected variables %v", vars)
+	}
+	for _, bad := range []string{"gpu_count", "=2", "gpu-count=2", "1x=2", "name=other"} {
+		if _, err := parseTemplateVars([]string{bad}); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
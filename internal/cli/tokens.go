@@ -0,0 +1,418 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/gitlab"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/polar-gosling/gosling/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rotateAll         bool
+	rotateEgg         string
+	rotateAPIURL      string
+	rotateAPIKey      string
+	rotateGitLabURL   string
+	rotateGitLabToken string
+	rotateGracePeriod time.Duration
+	rotateDryRun      bool
+	rotateGitLabConn  gitlabConnFlags
+)
+
+// rotateTokensCmd represents the rotate-tokens command
+var rotateTokensCmd = &cobra.Command{
+	Use:   "rotate-tokens",
+	Short: "Replace the GitLab runner tokens of Eggs",
+	Long: `Rotate the runner authentication tokens of the Eggs and EggsBuckets of the
+Nest. For each GitLab project or group served by an Egg:
+
+  1. A runner is created in GitLab with the tags of the current one, which
+     returns a new runner authentication token (glrt-...)
+  2. The token is written to the token_secret of the gitlab block
+  3. MotherGoose is told to restart the Egg's runners with the new token
+  4. Once --grace-period has passed, the previous runners of the Egg are
+     deleted from GitLab, which invalidates their tokens
+
+The previous runners are the ones named gosling-runner-<egg>. They keep
+working during the grace period so running jobs can finish. With
+--grace-period 0 they are deleted right away. If a step fails for an Egg,
+its previous runners are left alone.
+
+token_secret must be writable: keychain://, file://, vault:// (VAULT_ADDR,
+VAULT_TOKEN) or yc-lockbox:// (YC_TOKEN, YC_IAM_TOKEN or
+YC_SERVICE_ACCOUNT_KEY_FILE). The GitLab token needs the create_runner
+scope and must be allowed to manage the runners of the projects or groups.
+
+Example:
+  gosling rotate-tokens --all
+  gosling rotate-tokens --egg my-app --grace-period 1h
+  gosling rotate-tokens --all --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runRotateTokens,
+}
+
+func init() {
+	rootCmd.AddCommand(rotateTokensCmd)
+	rotateTokensCmd.Flags().BoolVar(&rotateAll, "all", false, "Rotate the tokens of every Egg")
+	rotateTokensCmd.Flags().StringVar(&rotateEgg, "egg", "", "Only rotate the tokens of this Egg")
+	rotateTokensCmd.Flags().StringVar(&rotateAPIURL, "api-url", "", "MotherGoose API URL")
+	rotateTokensCmd.Flags().StringVar(&rotateAPIKey, "api-key", "", "MotherGoose API key")
+	rotateTokensCmd.Flags().StringVar(&rotateGitLabURL, "gitlab-url", "", "GitLab URL (default: https://<server_name> of each Egg)")
+	rotateTokensCmd.Flags().StringVar(&rotateGitLabToken, "gitlab-token", "", "GitLab token with create_runner scope (default: $GITLAB_TOKEN)")
+	rotateTokensCmd.Flags().DurationVar(&rotateGracePeriod, "grace-period", 15*time.Minute, "How long the previous tokens keep working")
+	rotateTokensCmd.Flags().BoolVar(&rotateDryRun, "dry-run", false, "Show which tokens would be rotated without rotating them")
+	rotateTokensCmd.MarkFlagsOneRequired("all", "egg")
+	rotateTokensCmd.MarkFlagsMutuallyExclusive("all", "egg")
+	rotateGitLabConn.register(rotateTokensCmd)
+	mustRegisterEggCompletion(rotateTokensCmd, completeEggNames(false))
+}
+
+// Token rotation outcomes
+const (
+	rotationRotated = "rotated"
+	rotationPlanned = "planned"
+	rotationSkipped = "skipped"
+	rotationFailed  = "failed"
+)
+
+// tokenRotationResult is the outcome of rotating the token of one project or group
+type tokenRotationResult struct {
+	EggName           string `json:"egg_name"`
+	Server            string `json:"server"`
+	ProjectID         int    `json:"project_id,omitempty"`
+	GroupID           int    `json:"group_id,omitempty"`
+	RunnerID          int    `json:"runner_id,omitempty"`
+	PreviousRunnerIDs []int  `json:"previous_runner_ids"`
+	Revoked           bool   `json:"revoked"`
+	Action            string `json:"action"`
+	Message           string `json:"message,omitempty"`
+
+	client tokenRotationClient
+}
+
+// rotateTokensOutput is the machine-readable result of `gosling rotate-tokens`
+type rotateTokensOutput struct {
+	DryRun     bool                   `json:"dry_run"`
+	GraceUntil time.Time              `json:"grace_until"`
+	Results    []*tokenRotationResult `json:"results"`
+}
+
+// tokenRotationClient is the part of gitlab.Client used to rotate runner tokens
+type tokenRotationClient interface {
+	ListProjectRunners(ctx context.Context, projectID int) ([]*gitlab.Runner, error)
+	ListGroupRunners(ctx context.Context, groupID int) ([]*gitlab.Runner, error)
+	GetRunner(ctx context.Context, runnerID int) (*gitlab.Runner, error)
+	RegisterRunner(ctx context.Context, config *gitlab.RunnerConfig) (*gitlab.Runner, error)
+	DeleteRunner(ctx context.Context, runnerID int) error
+}
+
+func runRotateTokens(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+	targets, err := collectWebhookTargets(filepath.Join(nestRoot, "Eggs"), rotateEgg)
+	if err != nil {
+		return err
+	}
+
+	var mg mothergoose.MotherGooseClient
+	if !rotateDryRun {
+		conn, err := resolveAPI(rotateAPIURL, rotateAPIKey)
+		if err != nil {
+			return err
+		}
+		mg = conn.client()
+	}
+	token := rotateGitLabToken
+	if token == "" {
+		token = os.Getenv("GITLAB_TOKEN")
+	}
+	if token == "" {
+		return fmt.Errorf("a GitLab token is required: use --gitlab-token or GITLAB_TOKEN")
+	}
+
+	// Eggs on the same server may still trust different CAs
+	clients := make(map[[2]string]tokenRotationClient)
+	clientFor := func(target webhookTarget) (tokenRotationClient, error) {
+		key := [2]string{target.Server, target.CACert}
+		if client, ok := clients[key]; ok {
+			return client, nil
+		}
+		baseURL := rotateGitLabURL
+		if baseURL == "" {
+			baseURL = "https://" + target.Server
+		}
+		opts, err := rotateGitLabConn.options(ctx, target.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("egg %s: %w", target.Egg, err)
+		}
+		opts = append(opts, gitlab.WithRegistrationMethod(gitlab.RegistrationAuthenticationToken))
+		client, err := gitlab.NewClient(baseURL, token, opts...)
+		if err != nil {
+			return nil, err
+		}
+		clients[key] = client
+		return client, nil
+	}
+
+	log := logger("rotate-tokens")
+	out, err := rotateTokens(ctx, log, targets, clientFor, mg, rotateGracePeriod, rotateDryRun)
+	if out != nil {
+		if isStructuredOutput() {
+			if werr := writeStructured(os.Stdout, out); werr != nil {
+				return werr
+			}
+		} else if perr := printTokenRotations(out); perr != nil {
+			return perr
+		}
+	}
+	return err
+}
+
+// rotateTokens rotates the runner token of every target, then waits for
+// gracePeriod and deletes the previous runners of the rotated targets. A
+// target that fails does not stop the others; the first failure is returned
+// along with the results.
+func rotateTokens(ctx context.Context, log *slog.Logger, targets []webhookTarget, clientFor func(webhookTarget) (tokenRotationClient, error), mg mothergoose.MotherGooseClient, gracePeriod time.Duration, dryRun bool) (*rotateTokensOutput, error) {
+	out := &rotateTokensOutput{
+		DryRun:     dryRun,
+		GraceUntil: time.Now().Add(gracePeriod).UTC().Truncate(time.Second),
+		Results:    []*tokenRotationResult{},
+	}
+
+	var first error
+	fail := func(result *tokenRotationResult, err error) {
+		result.Action = rotationFailed
+		result.Message = err.Error()
+		log.Error(fmt.Sprintf("Egg %s: token rotation failed: %v", result.EggName, err), "egg", result.EggName, "action", result.Action)
+		if first == nil {
+			first = fmt.Errorf("failed to rotate the token of egg %s: %w", result.EggName, err)
+		}
+	}
+	// Instances of a count or for_each Egg usually share one token_secret.
+	// A secret holds one token, so they are rotated together to one runner.
+	var order []string
+	shared := make(map[string][]int)
+	for i, target := range targets {
+		result := &tokenRotationResult{EggName: target.Egg, Server: target.Server, ProjectID: target.ProjectID, GroupID: target.GroupID, PreviousRunnerIDs: []int{}}
+		out.Results = append(out.Results, result)
+		switch {
+		case target.TokenSecret == "":
+			result.Action = rotationSkipped
+			result.Message = "no token_secret"
+			continue
+		case target.ProjectID == 0 && target.GroupID == 0:
+			result.Action = rotationSkipped
+			result.Message = "no project_id"
+			continue
+		}
+		if _, ok := shared[target.TokenSecret]; !ok {
+			order = append(order, target.TokenSecret)
+		}
+		shared[target.TokenSecret] = append(shared[target.TokenSecret], i)
+	}
+
+	for _, secret := range order {
+		members := make([]webhookTarget, 0, len(shared[secret]))
+		results := make([]*tokenRotationResult, 0, len(shared[secret]))
+		for _, i := range shared[secret] {
+			members = append(members, targets[i])
+			results = append(results, out.Results[i])
+		}
+		if err := checkSharedSecret(members); err != nil {
+			for _, result := range results {
+				fail(result, err)
+			}
+			continue
+		}
+		client, err := clientFor(members[0])
+		if err != nil {
+			for _, result := range results {
+				fail(result, err)
+			}
+			continue
+		}
+		for _, result := range results {
+			result.client = client
+		}
+		if err := rotateToken(ctx, log, client, mg, members, results, out.GraceUntil, dryRun); err != nil {
+			for _, result := range results {
+				fail(result, err)
+			}
+		}
+	}
+
+	var pending []*tokenRotationResult
+	for _, result := range out.Results {
+		if result.Action == rotationRotated && len(result.PreviousRunnerIDs) > 0 {
+			pending = append(pending, result)
+		}
+	}
+	if len(pending) == 0 {
+		return out, first
+	}
+	if gracePeriod > 0 {
+		log.Info(fmt.Sprintf("Waiting %s before deleting the previous runners", gracePeriod), "grace_until", out.GraceUntil)
+		select {
+		case <-ctx.Done():
+			return out, fmt.Errorf("interrupted before the previous runners were deleted; delete them in GitLab or run rotate-tokens again: %w", ctx.Err())
+		case <-time.After(gracePeriod):
+		}
+	}
+	for _, result := range pending {
+		for _, id := range result.PreviousRunnerIDs {
+			if err := result.client.DeleteRunner(ctx, id); err != nil {
+				fail(result, err)
+				break
+			}
+		}
+		if result.Action == rotationRotated {
+			result.Revoked = true
+			log.Info(fmt.Sprintf("Egg %s: deleted previous runners %v", result.EggName, result.PreviousRunnerIDs), "egg", result.EggName)
+		}
+	}
+	return out, first
+}
+
+// checkSharedSecret makes sure the Eggs sharing a token_secret register in
+// the same place, since they will share one runner
+func checkSharedSecret(members []webhookTarget) error {
+	for _, member := range members[1:] {
+		if member.Server != members[0].Server || member.ProjectID != members[0].ProjectID || member.GroupID != members[0].GroupID {
+			return fmt.Errorf("token_secret %s is shared by eggs %s and %s on different GitLab projects; give each its own", member.TokenSecret, members[0].Egg, member.Egg)
+		}
+	}
+	return nil
+}
+
+// rotateToken creates one new runner for the Eggs sharing a token_secret,
+// stores its token and tells MotherGoose about each Egg. The previous runners
+// are recorded in results but not deleted.
+func rotateToken(ctx context.Context, log *slog.Logger, client tokenRotationClient, mg mothergoose.MotherGooseClient, members []webhookTarget, results []*tokenRotationResult, graceUntil time.Time, dryRun bool) error {
+	target := members[0]
+	var runners []*gitlab.Runner
+	var err error
+	if target.GroupID != 0 {
+		runners, err = client.ListGroupRunners(ctx, target.GroupID)
+	} else {
+		runners, err = client.ListProjectRunners(ctx, target.ProjectID)
+	}
+	if err != nil {
+		return err
+	}
+	var previous []int
+	for i, member := range members {
+		for _, runner := range runners {
+			if runner.Description == "gosling-runner-"+member.Egg {
+				results[i].PreviousRunnerIDs = append(results[i].PreviousRunnerIDs, runner.ID)
+				previous = append(previous, runner.ID)
+			}
+		}
+	}
+
+	// The new runner takes over the jobs of the previous one, so it needs its tags
+	var tags []string
+	if len(previous) > 0 {
+		runner, err := client.GetRunner(ctx, previous[0])
+		if err != nil {
+			return err
+		}
+		tags = runner.Tags
+	}
+
+	if dryRun {
+		for i, member := range members {
+			results[i].Action = rotationPlanned
+			log.Info(fmt.Sprintf("Egg %s: would create a runner and write its token to %s", member.Egg, member.TokenSecret), "egg", member.Egg, "action", results[i].Action)
+		}
+		return nil
+	}
+
+	runner, err := client.RegisterRunner(ctx, &gitlab.RunnerConfig{
+		ProjectID:   target.ProjectID,
+		GroupID:     target.GroupID,
+		Description: "gosling-runner-" + target.Egg,
+		Tags:        tags,
+		RunUntagged: len(tags) == 0,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := secrets.Store(ctx, target.TokenSecret, runner.Token); err != nil {
+		// Nothing uses the new runner yet
+		if derr := client.DeleteRunner(ctx, runner.ID); derr != nil {
+			log.Warn(fmt.Sprintf("Egg %s: failed to delete unused runner %d: %v", target.Egg, runner.ID, derr), "egg", target.Egg)
+		}
+		return err
+	}
+
+	for i, member := range members {
+		result := results[i]
+		result.RunnerID = runner.ID
+		err := mg.RotateEggToken(ctx, &mothergoose.TokenRotation{
+			EggName:           member.Egg,
+			ServerName:        member.Server,
+			ProjectID:         member.ProjectID,
+			GroupID:           member.GroupID,
+			TokenSecret:       member.TokenSecret,
+			RunnerID:          runner.ID,
+			PreviousRunnerIDs: result.PreviousRunnerIDs,
+			GraceUntil:        graceUntil,
+		})
+		switch {
+		case mothergoose.IsNotFound(err):
+			// The token is already stored; runners read it when they restart
+			result.Message = "MotherGoose does not support token rotation; restart the Egg's runners"
+			log.Warn(fmt.Sprintf("Egg %s: MotherGoose does not support token rotation; runners pick up the new token when they restart", member.Egg), "egg", member.Egg)
+		case err != nil:
+			return fmt.Errorf("new token stored in %s but MotherGoose was not updated: %w", member.TokenSecret, err)
+		}
+		result.Action = rotationRotated
+		log.Info(fmt.Sprintf("Egg %s: token rotated (runner %d)", member.Egg, runner.ID), "egg", member.Egg, "action", result.Action, "runner_id", runner.ID)
+	}
+	return nil
+}
+
+func printTokenRotations(out *rotateTokensOutput) error {
+	if len(out.Results) == 0 {
+		fmt.Println("No GitLab projects found in the Nest")
+		return nil
+	}
+	if out.DryRun {
+		fmt.Println("Dry run: no tokens were rotated")
+		fmt.Println()
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EGG\tSERVER\tTARGET\tRUNNER\tPREVIOUS\tACTION")
+	for _, r := range out.Results {
+		target := fmt.Sprintf("project %d", r.ProjectID)
+		if r.GroupID != 0 {
+			target = fmt.Sprintf("group %d", r.GroupID)
+		}
+		previous := make([]string, len(r.PreviousRunnerIDs))
+		for i, id := range r.PreviousRunnerIDs {
+			previous[i] = fmt.Sprint(id)
+		}
+		if len(previous) > 0 && r.Revoked {
+			previous = append(previous, "(deleted)")
+		}
+		action := r.Action
+		if r.Message != "" {
+			action += " (" + r.Message + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", r.EggName, r.Server, target, r.RunnerID, strings.Join(previous, " "), action)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/gitlab"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+// fakeRunnerClient keeps the runners of each project and group in memory
+type fakeRunnerClient struct {
+	runners    map[int][]*gitlab.Runner // by project or group ID
+	nextID     int
+	created    []*gitlab.RunnerConfig
+	deleted    []int
+	failCreate bool
+}
+
+func (f *fakeRunnerClient) ListProjectRunners(ctx context.Context, projectID int) ([]*gitlab.Runner, error) {
+	return f.runners[projectID], nil
+}
+
+func (f *fakeRunnerClient) ListGroupRunners(ctx context.Context, groupID int) ([]*gitlab.Runner, error) {
+	return f.runners[groupID], nil
+}
+
+func (f *fakeRunnerClient) GetRunner(ctx context.Context, runnerID int) (*gitlab.Runner, error) {
+	for _, runners := range f.runners {
+		for _, runner := range runners {
+			if runner.ID == runnerID {
+				return runner, nil
+			}
+		}
+	}
+	return nil, errors.New("404 Not Found")
+}
+
+func (f *fakeRunnerClient) RegisterRunner(ctx context.Context, config *gitlab.RunnerConfig) (*gitlab.Runner, error) {
+	if f.failCreate {
+		return nil, errors.New("403 Forbidden")
+	}
+	f.created = append(f.created, config)
+	f.nextID++
+	runner := &gitlab.Runner{ID: f.nextID, Token: "glrt-" + config.Description, Description: config.Description, Tags: config.Tags}
+	owner := config.ProjectID + config.GroupID
+	f.runners[owner] = append(f.runners[owner], runner)
+	return runner, nil
+}
+
+func (f *fakeRunnerClient) DeleteRunner(ctx context.Context, runnerID int) error {
+	f.deleted = append(f.deleted, runnerID)
+	return nil
+}
+
+func TestRotateTokens(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "my-app-token")
+	groupTokenFile := filepath.Join(dir, "platform-token")
+	client := &fakeRunnerClient{nextID: 100, runners: map[int][]*gitlab.Runner{
+		42: {
+			{ID: 1, Description: "gosling-runner-my-app", Tags: []string{"docker", "linux"}},
+			{ID: 2, Description: "someone-elses-runner"},
+		},
+		7: {{ID: 3, Description: "gosling-runner-platform"}},
+	}}
+	mg := NewMockMotherGooseClient()
+	targets := []webhookTarget{
+		{Egg: "my-app", Server: "gitlab.com", ProjectID: 42, TokenSecret: "file://" + tokenFile},
+		{Egg: "no-secret", Server: "gitlab.com", ProjectID: 43},
+		{Egg: "platform", Server: "gitlab.com", GroupID: 7, TokenSecret: "file://" + groupTokenFile},
+	}
+	clientFor := func(webhookTarget) (tokenRotationClient, error) { return client, nil }
+	log := newLogger(io.Discard, "rotate-tokens")
+	ctx := context.Background()
+
+	// A dry run reports the previous runners without changing anything
+	out, err := rotateTokens(ctx, log, targets, clientFor, nil, time.Hour, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if got := out.Results[0]; got.Action != rotationPlanned || !reflect.DeepEqual(got.PreviousRunnerIDs, []int{1}) {
+		t.Errorf("unexpected dry run result %+v", got)
+	}
+	if len(client.created) != 0 || len(client.deleted) != 0 {
+		t.Fatalf("dry run changed runners: created %v, deleted %v", client.created, client.deleted)
+	}
+
+	out, err = rotateTokens(ctx, log, targets, clientFor, mg, 0, false)
+	if err != nil {
+		t.Fatalf("rotateTokens failed: %v", err)
+	}
+	actions := make([]string, len(out.Results))
+	for i, result := range out.Results {
+		actions[i] = result.Action
+	}
+	if want := []string{rotationRotated, rotationSkipped, rotationRotated}; !reflect.DeepEqual(actions, want) {
+		t.Errorf("actions = %v, want %v", actions, want)
+	}
+
+	// The new runner inherits the tags of the previous one
+	if created := client.created[0]; created.ProjectID != 42 || !reflect.DeepEqual(created.Tags, []string{"docker", "linux"}) || created.RunUntagged {
+		t.Errorf("unexpected project runner %+v", created)
+	}
+	if created := client.created[1]; created.GroupID != 7 || !created.RunUntagged {
+		t.Errorf("unexpected group runner %+v", created)
+	}
+	if data, _ := os.ReadFile(tokenFile); strings.TrimSpace(string(data)) != "glrt-gosling-runner-my-app" {
+		t.Errorf("token file = %q", data)
+	}
+	if len(mg.TokenRotations) != 2 || mg.TokenRotations[0].RunnerID != 101 || !reflect.DeepEqual(mg.TokenRotations[0].PreviousRunnerIDs, []int{1}) {
+		t.Errorf("unexpected MotherGoose rotations %+v", mg.TokenRotations)
+	}
+	if !reflect.DeepEqual(client.deleted, []int{1, 3}) || !out.Results[0].Revoked {
+		t.Errorf("expected previous runners 1 and 3 deleted, got %v", client.deleted)
+	}
+}
+
+func TestRotateTokensFailure(t *testing.T) {
+	client := &fakeRunnerClient{nextID: 1, runners: map[int][]*gitlab.Runner{42: {{ID: 1, Description: "gosling-runner-my-app"}}}}
+	targets := []webhookTarget{{Egg: "my-app", Server: "gitlab.com", ProjectID: 42, TokenSecret: "env://RUNNER_TOKEN"}}
+	clientFor := func(webhookTarget) (tokenRotationClient, error) { return client, nil }
+	log := newLogger(io.Discard, "rotate-tokens")
+	mg := NewMockMotherGooseClient()
+
+	// The token cannot be stored, so the new runner is deleted and the old one kept
+	out, err := rotateTokens(context.Background(), log, targets, clientFor, mg, 0, false)
+	if err == nil {
+		t.Fatal("expected error for a read-only token_secret")
+	}
+	if out.Results[0].Action != rotationFailed || out.Results[0].RunnerID != 0 {
+		t.Errorf("unexpected result %+v", out.Results[0])
+	}
+	if !reflect.DeepEqual(client.deleted, []int{2}) {
+		t.Errorf("expected only the unused new runner 2 deleted, got %v", client.deleted)
+	}
+	if len(mg.TokenRotations) != 0 {
+		t.Errorf("MotherGoose should not be told about a failed rotation: %+v", mg.TokenRotations)
+	}
+
+	client.failCreate = true
+	if _, err := rotateTokens(context.Background(), log, targets, clientFor, mg, 0, false); err == nil {
+		t.Error("expected error when GitLab refuses to create the runner")
+	}
+}
+
+func TestRotateTokensOldMotherGoose(t *testing.T) {
+	client := &fakeRunnerClient{nextID: 10, runners: map[int][]*gitlab.Runner{42: {}}}
+	targets := []webhookTarget{{Egg: "my-app", Server: "gitlab.com", ProjectID: 42, TokenSecret: "file://" + filepath.Join(t.TempDir(), "token")}}
+	clientFor := func(webhookTarget) (tokenRotationClient, error) { return client, nil }
+	mg := &notFoundRotationClient{NewMockMotherGooseClient()}
+
+	out, err := rotateTokens(context.Background(), newLogger(io.Discard, "rotate-tokens"), targets, clientFor, mg, 0, false)
+	if err != nil {
+		t.Fatalf("rotateTokens failed: %v", err)
+	}
+	if result := out.Results[0]; result.Action != rotationRotated || result.Message == "" {
+		t.Errorf("expected a rotation with a warning, got %+v", result)
+	}
+}
+
+// notFoundRotationClient is a MotherGoose without the token rotation API
+type notFoundRotationClient struct {
+	*MockMotherGooseClient
+}
+
+func (c *notFoundRotationClient) RotateEggToken(ctx context.Context, rotation *mothergoose.TokenRotation) error {
+	return &mothergoose.HTTPError{StatusCode: 404, Status: "404 Not Found"}
+}
+
+func TestRotateTokensForEach(t *testing.T) {
+	root := t.TempDir()
+	tokenFile := filepath.Join(root, "worker-token")
+	config := strings.Replace(policyEggConfig, `egg "my-app" {`, "egg \"worker-${each.key}\" {\n  for_each = [\"a\", \"b\"]\n", 1)
+	config = strings.Replace(config, "yc-lockbox://gitlab/runner-token", "file://"+tokenFile, 1)
+	writeNestFile(t, root, "Eggs/workers/config.fly", config)
+	eggsDir := filepath.Join(root, "Eggs")
+
+	// Each instance registers its own runner, named after the expanded Egg
+	targets, err := collectWebhookTargets(eggsDir, "")
+	if err != nil {
+		t.Fatalf("collectWebhookTargets failed: %v", err)
+	}
+	want := []webhookTarget{
+		{Egg: "worker-a", Server: "gitlab.com", ProjectID: 12345, TokenSecret: "file://" + tokenFile},
+		{Egg: "worker-b", Server: "gitlab.com", ProjectID: 12345, TokenSecret: "file://" + tokenFile},
+	}
+	if !reflect.DeepEqual(targets, want) {
+		t.Fatalf("got %+v, want %+v", targets, want)
+	}
+	if only, err := collectWebhookTargets(eggsDir, "worker-b"); err != nil || len(only) != 1 || only[0].Egg != "worker-b" {
+		t.Errorf("expected only worker-b, got %+v (%v)", only, err)
+	}
+
+	client := &fakeRunnerClient{nextID: 10, runners: map[int][]*gitlab.Runner{12345: {
+		{ID: 1, Description: "gosling-runner-worker-a", Tags: []string{"docker"}},
+		{ID: 2, Description: "gosling-runner-worker-b", Tags: []string{"docker"}},
+	}}}
+	clientFor := func(webhookTarget) (tokenRotationClient, error) { return client, nil }
+	mg := NewMockMotherGooseClient()
+
+	// The instances share the secret, so they share one new runner
+	out, err := rotateTokens(context.Background(), newLogger(io.Discard, "rotate-tokens"), targets, clientFor, mg, 0, false)
+	if err != nil {
+		t.Fatalf("rotateTokens failed: %v", err)
+	}
+	if len(client.created) != 1 {
+		t.Fatalf("expected one new runner, got %+v", client.created)
+	}
+	for i, result := range out.Results {
+		if result.Action != rotationRotated || result.RunnerID != 11 || !reflect.DeepEqual(result.PreviousRunnerIDs, []int{i + 1}) || !result.Revoked {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+	if len(mg.TokenRotations) != 2 || mg.TokenRotations[1].EggName != "worker-b" {
+		t.Errorf("unexpected MotherGoose rotations %+v", mg.TokenRotations)
+	}
+	if !reflect.DeepEqual(client.deleted, []int{1, 2}) {
+		t.Errorf("expected previous runners 1 and 2 deleted, got %v", client.deleted)
+	}
+
+	// Sharing a secret across projects cannot work with one runner
+	targets[1].ProjectID = 54321
+	if _, err := rotateTokens(context.Background(), newLogger(io.Discard, "rotate-tokens"), targets, clientFor, mg, 0, true); err == nil {
+		t.Error("expected error for a token_secret shared across projects")
+	}
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// uglyFoxBlock is the Block of the changes reported for the UglyFox configuration
+const uglyFoxBlock = "uglyfox"
+
+// uglyFoxDeployOutput describes what deploy did (or would do) with UF/config.fly
+type uglyFoxDeployOutput struct {
+	Status  string          `json:"status"`
+	Changes []parser.Change `json:"changes"`
+	Error   string          `json:"error,omitempty"` // Why the configuration was not updated
+}
+
+// parseUglyFoxConfig parses UF/config.fly (or config.fly.json), merging the
+// overlay for env over it when env is set. Overlaid configurations are
+// validated. A Nest without one returns nil.
+func parseUglyFoxConfig(ufDir, env string) (*deployer.UglyFoxConfig, error) {
+	configPath, ok := eggConfigPath(ufDir)
+	if !ok {
+		return nil, nil
+	}
+	config, err := parser.NewParser().ParseFileForEnv(configPath, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+	if env != "" {
+		if result := parser.NewValidator(config).Validate(); !result.IsValid() {
+			return nil, fmt.Errorf("invalid %s configuration for %s: %s", env, configPath, result.Error())
+		}
+	}
+	for i := range config.Blocks {
+		if config.Blocks[i].Type != "uglyfox" {
+			continue
+		}
+		parsed, err := deployer.ParseUglyFox(&config.Blocks[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", configPath, err)
+		}
+		uf, err := deployer.NewConverter().UglyFoxToConfig(parsed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", configPath, err)
+		}
+		return uf, nil
+	}
+	return nil, fmt.Errorf("no uglyfox block found in %s", configPath)
+}
+
+// deployedUglyFoxConfig returns the UglyFox configuration MotherGoose applies,
+// or nil if none was deployed yet
+func deployedUglyFoxConfig(ctx context.Context, client mothergoose.MotherGooseClient) (*deployer.UglyFoxConfig, error) {
+	current, err := client.GetUglyFoxConfig(ctx)
+	if mothergoose.IsNotFound(err) {
+		return nil, nil
+	}
+	return current, err
+}
+
+// deployUglyFox updates the UglyFox configuration of MotherGoose to desired
+// unless it is already applied. With dryRun nothing is changed.
+func deployUglyFox(ctx context.Context, log *slog.Logger, desired *deployer.UglyFoxConfig, client mothergoose.MotherGooseClient, dryRun bool) (*uglyFoxDeployOutput, error) {
+	current, err := deployedUglyFoxConfig(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	result := &uglyFoxDeployOutput{Changes: diffUglyFoxConfigs(current, desired)}
+	for _, change := range result.Changes {
+		log.Info(fmt.Sprintf("UglyFox: %s", change), "path", change.Path, "kind", change.Kind)
+	}
+	switch {
+	case len(result.Changes) == 0:
+		result.Status = deployStatusUnchanged
+		log.Info("UglyFox: unchanged", "status", result.Status)
+	case dryRun:
+		result.Status = deployStatusPlanned
+		log.Info("UglyFox: configuration would be updated", "status", result.Status)
+	default:
+		if err := client.UpdateUglyFoxConfig(ctx, desired); err != nil {
+			result.Status = deployStatusFailed
+			result.Error = err.Error()
+			return result, fmt.Errorf("failed to deploy uglyfox configuration: %w", err)
+		}
+		result.Status = deployStatusApplied
+		log.Info("UglyFox: configuration updated", "status", result.Status)
+	}
+	return result, nil
+}
+
+// diffUglyFoxConfigs compares two UglyFox configurations field by field,
+// using the attribute paths of UF/config.fly. Either may be nil.
+func diffUglyFoxConfigs(old, new *deployer.UglyFoxConfig) []parser.Change {
+	oldFields, newFields := uglyFoxFields(old), uglyFoxFields(new)
+	paths := make([]string, 0, len(oldFields)+len(newFields))
+	for path := range oldFields {
+		paths = append(paths, path)
+	}
+	for path := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := []parser.Change{}
+	for _, path := range paths {
+		oldVal, inOld := oldFields[path]
+		newVal, inNew := newFields[path]
+		switch {
+		case !inOld:
+			changes = append(changes, parser.Change{Block: uglyFoxBlock, Path: path, Kind: parser.ChangeAdded, New: newVal})
+		case !inNew:
+			changes = append(changes, parser.Change{Block: uglyFoxBlock, Path: path, Kind: parser.ChangeRemoved, Old: oldVal})
+		case oldVal != newVal:
+			changes = append(changes, parser.Change{Block: uglyFoxBlock, Path: path, Kind: parser.ChangeModified, Old: oldVal, New: newVal})
+		}
+	}
+	return changes
+}
+
+// uglyFoxFields flattens config into its attribute paths and values
+func uglyFoxFields(config *deployer.UglyFoxConfig) map[string]string {
+	fields := make(map[string]string)
+	if config == nil {
+		return fields
+	}
+	fields["pruning.failed_threshold"] = strconv.Itoa(config.Pruning.FailedThreshold)
+	fields["pruning.max_age"] = config.Pruning.MaxAge.String()
+	fields["pruning.check_interval"] = config.Pruning.CheckInterval.String()
+
+	addPool := func(path string, pool deployer.PoolConfig) {
+		fields[path+".max_count"] = strconv.Itoa(pool.MaxCount)
+		fields[path+".min_count"] = strconv.Itoa(pool.MinCount)
+		if pool.CPUThreshold != 0 {
+			fields[path+".cpu_threshold"] = strconv.FormatFloat(pool.CPUThreshold, 'g', -1, 64)
+		}
+		if pool.MemoryThreshold != 0 {
+			fields[path+".memory_threshold"] = strconv.FormatFloat(pool.MemoryThreshold, 'g', -1, 64)
+		}
+		if pool.IdleTimeout != 0 {
+			fields[path+".idle_timeout"] = pool.IdleTimeout.String()
+		}
+	}
+	for _, condition := range config.Conditions {
+		path := "runners_condition." + condition.Name
+		fields[path+".eggs_entities"] = "[" + strings.Join(condition.EggsEntities, ", ") + "]"
+		addPool(path+".apex", condition.Apex)
+		addPool(path+".nadir", condition.Nadir)
+	}
+	if config.Apex != nil {
+		addPool("apex", *config.Apex)
+	}
+	if config.Nadir != nil {
+		addPool("nadir", *config.Nadir)
+	}
+	for _, rule := range config.Policies {
+		path := "policies.rule." + rule.Name
+		fields[path+".condition"] = rule.Condition
+		fields[path+".action"] = rule.Action
+	}
+	return fields
+}
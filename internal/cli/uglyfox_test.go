@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+const testUglyFoxNestConfig = `uglyfox {
+  pruning {
+    failed_threshold = 3
+    max_age          = "24h"
+    check_interval   = "5m"
+  }
+
+  apex {
+    max_count = 10
+    min_count = 2
+  }
+
+  nadir {
+    max_count    = 5
+    min_count    = 0
+    idle_timeout = "30m"
+  }
+}
+`
+
+func TestParseUglyFoxConfig(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "UF/config.fly", testUglyFoxNestConfig)
+	writeNestFile(t, root, "UF/config.prod.fly", `uglyfox {
+  pruning {
+    max_age = "48h"
+  }
+}
+`)
+
+	config, err := parseUglyFoxConfig(filepath.Join(root, "UF"), "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Pruning.MaxAge != 48*time.Hour || config.Apex == nil || config.Nadir.IdleTimeout != 30*time.Minute {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	if config, err := parseUglyFoxConfig(filepath.Join(t.TempDir(), "UF"), ""); err != nil || config != nil {
+		t.Errorf("expected no config without UF/config.fly, got %v, %v", config, err)
+	}
+}
+
+func TestDeployUglyFox(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "UF/config.fly", testUglyFoxNestConfig)
+	desired, err := parseUglyFoxConfig(filepath.Join(root, "UF"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewMockMotherGooseClient()
+	log := newLogger(io.Discard, "deploy")
+	ctx := context.Background()
+
+	result, err := deployUglyFox(ctx, log, desired, client, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != deployStatusPlanned || client.UglyFox != nil {
+		t.Errorf("expected a planned update, got %s", result.Status)
+	}
+	if len(result.Changes) == 0 || result.Changes[0].Kind != parser.ChangeAdded {
+		t.Errorf("expected every attribute to be added, got %v", result.Changes)
+	}
+
+	if result, err = deployUglyFox(ctx, log, desired, client, false); err != nil || result.Status != deployStatusApplied {
+		t.Fatalf("expected the config to be applied, got %v, %v", result, err)
+	}
+	if result, err = deployUglyFox(ctx, log, desired, client, false); err != nil || result.Status != deployStatusUnchanged {
+		t.Errorf("expected the config to be unchanged, got %v, %v", result, err)
+	}
+
+	writeNestFile(t, root, "UF/config.fly", strings.Replace(testUglyFoxNestConfig, `"30m"`, `"1h"`, 1))
+	desired, err = parseUglyFoxConfig(filepath.Join(root, "UF"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = deployUglyFox(ctx, log, desired, client, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].String() != "~ nadir.idle_timeout: 30m0s → 1h0m0s" {
+		t.Errorf("expected the idle timeout change, got %v", result.Changes)
+	}
+}
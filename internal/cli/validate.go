@@ -1,18 +1,32 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/polar-gosling/gosling/internal/diagnostic"
 	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/polar-gosling/gosling/internal/policy"
+	"github.com/polar-gosling/gosling/internal/telemetry"
 	"github.com/spf13/cobra"
 )
 
 var (
-	validatePath string
-	validateAll  bool
+	validatePath        string
+	validateAll         bool
+	validateConcurrency int
+	validateStrict      bool
+	validateLenient     bool
+	// validateWarningsAsErrors fails files that only have warnings
+	validateWarningsAsErrors bool
 )
 
 // validateCmd represents the validate command
@@ -24,10 +38,61 @@ var validateCmd = &cobra.Command{
 Without arguments, validates all .fly files in the Nest repository.
 With a file argument, validates only that specific file.
 
+Files may pull in shared fragments with include "path" (relative to the
+including file). Directories starting with "_", such as Eggs/_shared, hold
+such fragments and are not validated on their own.
+
+Environment overlays (e.g. Eggs/my-app/config.prod.fly) are validated merged
+over their base config.fly, exactly as 'gosling deploy --env prod' sees them.
+
+resources { preset = "medium" } fills in cpu, memory and disk from the
+built-in presets (small, medium, large, xlarge) or those defined in
+Presets/*.fly; attributes set next to the preset take precedence.
+
+The cloud, resources and runner blocks of Defaults/config.fly are merged
+into every egg, which may override any of their attributes. Each valid egg
+lists the values it inherits; 'gosling explain --egg' shows where every
+attribute of an egg comes from.
+
+The runners_condition blocks of UF/config.fly may only list Eggs and
+EggsBuckets that exist under Eggs/. When the whole Nest is validated, egg
+names and GitLab project IDs must also be unique across files, and the
+runner tags of every job must all be offered by at least one egg's runner.
+
+Egg and EggsBucket configurations must also satisfy the Nest's policies
+(Policies/*.fly). A policy can be skipped with --policy-skip and a mandatory
+--policy-skip-reason.
+
+With --strict, attributes and blocks the schema does not describe are errors
+too, with a suggestion when the name looks like a typo (concurent = 3: did
+you mean "concurrent"?).
+
+With --lenient, values of the wrong type are read as the expected type where
+the intent is clear ("3" for a number, 3 for a string, "true" for a bool) and
+reported as warnings, to ease migrating older configurations. gosling deploy
+always rejects them.
+
+Deprecated attributes, recommended attributes left unset and valid but
+suspicious values (concurrent = 1 on an 8 vCPU VM) are reported as warnings,
+which fail validation only with --warnings-as-errors.
+
+Every syntax error in a file is reported at once, with the source line it
+is on; in JSON and YAML output, syntax_errors locates each of them. With
+--output sarif, every problem is written as a SARIF 2.1.0 result for code
+scanning tools.
+
+Files are parsed and validated concurrently; results are always reported
+in the same order regardless of --concurrency.
+
 Example:
   gosling validate
   gosling validate Eggs/my-app/config.fly
-  gosling validate --all`,
+  gosling validate --all
+  gosling validate --strict
+  gosling validate --lenient
+  gosling validate --warnings-as-errors
+  gosling validate --concurrency 16
+  gosling validate --output sarif > gosling.sarif`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runValidate,
 }
@@ -36,10 +101,130 @@ func init() {
 	rootCmd.AddCommand(validateCmd)
 	validateCmd.Flags().StringVarP(&validatePath, "path", "p", "", "Path to Nest repository (default: current directory)")
 	validateCmd.Flags().BoolVarP(&validateAll, "all", "a", false, "Validate all .fly files in the repository")
+	validateCmd.Flags().IntVarP(&validateConcurrency, "concurrency", "j", runtime.NumCPU(), "Number of files to validate in parallel")
+	validateCmd.Flags().BoolVar(&validateStrict, "strict", false, "Also report attributes and blocks the schema does not know")
+	validateCmd.Flags().BoolVar(&validateLenient, "lenient", false, "Read values of the wrong type, such as \"3\" for a number, with a warning")
+	validateCmd.Flags().BoolVar(&validateWarningsAsErrors, "warnings-as-errors", false, "Fail validation on warnings too")
+	validateCmd.MarkFlagsMutuallyExclusive("strict", "lenient")
+	addPolicyFlags(validateCmd)
+	enableSARIF(validateCmd)
+}
+
+// validateOutput is the machine-readable result of `gosling validate`
+type validateOutput struct {
+	Files           []*fileValidationOutput `json:"files"`
+	NestErrors      []string                `json:"nest_errors,omitempty"`
+	ValidCount      int                     `json:"valid_count"`
+	ErrorCount      int                     `json:"error_count"`
+	SkippedPolicies []string                `json:"skipped_policies,omitempty"`
+
+	// nestDiagnostics are the NestErrors, kept for SARIF output
+	nestDiagnostics []diagnostic.Diagnostic
+}
+
+// fileValidationOutput is the validation outcome for a single .fly file
+type fileValidationOutput struct {
+	Path             string             `json:"path"`
+	Valid            bool               `json:"valid"`
+	Error            string             `json:"error,omitempty"`
+	PolicyViolations []policy.Violation `json:"policy_violations,omitempty"`
+	// SyntaxErrors locates each syntax error when the file failed to parse
+	SyntaxErrors []syntaxErrorOutput `json:"syntax_errors,omitempty"`
+	// Warnings are problems that do not fail validation unless
+	// --warnings-as-errors is set
+	Warnings []string `json:"warnings,omitempty"`
+	// Inherited are the effective values eggs take from Defaults/config.fly
+	Inherited []string `json:"inherited,omitempty"`
+
+	// file is the absolute path of the file
+	file string
+	// message is the human-readable outcome printed in text mode, followed
+	// by diagnostics, the parse or validation errors and warnings, if any
+	message     string
+	diagnostics []diagnostic.Diagnostic
+	// config is the parsed file, kept for the Nest-wide checks. It is nil
+	// for environment overlays and files that failed to parse.
+	config *parser.Config
+}
+
+// diagnostics returns every problem of the report: parse, validation and
+// cross-file errors, and policy violations coded with the policy name
+func (o *validateOutput) diagnostics() []diagnostic.Diagnostic {
+	var diags []diagnostic.Diagnostic
+	for _, fileResult := range o.Files {
+		for _, d := range fileResult.diagnostics {
+			if d.File == "" {
+				d.File = fileResult.file
+			}
+			diags = append(diags, d)
+		}
+		for _, v := range fileResult.PolicyViolations {
+			diags = append(diags, diagnostic.Diagnostic{
+				Severity: diagnostic.SeverityError,
+				Code:     v.Policy,
+				Message:  fmt.Sprintf("%s violates policy %q: %s", v.Block, v.Policy, v.Message),
+				File:     v.File,
+				Line:     v.Line,
+				Column:   v.Column,
+			})
+		}
+	}
+	return append(diags, o.nestDiagnostics...)
+}
+
+// describeValidationCode describes the rule of a validate finding: a
+// diagnostic code or a policy
+func describeValidationCode(code string) string {
+	if title := parser.CodeTitle(code); title != "" {
+		return title
+	}
+	return fmt.Sprintf("Policy %s (Policies/*.fly)", code)
+}
+
+// syntaxErrorOutput is a single syntax error in a .fly file
+type syntaxErrorOutput struct {
+	Code    string `json:"code"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Summary string `json:"summary"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// syntaxErrors lists the syntax errors of a parse error, if it has any
+func syntaxErrors(err error) []syntaxErrorOutput {
+	var syntaxErr *parser.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return nil
+	}
+	var out []syntaxErrorOutput
+	for _, diag := range syntaxErr.Diagnostics {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		entry := syntaxErrorOutput{Code: parser.DiagnosticCode(diag), Summary: diag.Summary, Detail: diag.Detail}
+		if diag.Subject != nil {
+			entry.File = diag.Subject.Filename
+			entry.Line = diag.Subject.Start.Line
+			entry.Column = diag.Subject.Start.Column
+		}
+		out = append(out, entry)
+	}
+	return out
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
+	if validateConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", validateConcurrency)
+	}
+
 	var filesToValidate []string
+	var engine *policy.Engine
+	// Diagnostics show file names relative to the Nest, or to the working
+	// directory for a single file
+	var displayRoot string
+	// Cross-file references are only checked when the whole Nest is validated
+	wholeNest := len(args) == 0
 
 	if len(args) > 0 {
 		// Validate specific file
@@ -49,6 +234,14 @@ func runValidate(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to resolve file path: %w", err)
 		}
 		filesToValidate = append(filesToValidate, absPath)
+		displayRoot, _ = os.Getwd()
+
+		// Policies apply when the file belongs to a Nest
+		if nestRoot, err := findNestRoot(); err == nil {
+			if engine, err = loadPolicies(nestRoot); err != nil {
+				return err
+			}
+		}
 	} else {
 		// Find Nest root
 		nestRoot := validatePath
@@ -60,112 +253,260 @@ func runValidate(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		// Find all .fly files
+		displayRoot = nestRoot
+
 		var err error
+		if engine, err = loadPolicies(nestRoot); err != nil {
+			return err
+		}
+		// Broken presets and defaults fail even when no Egg uses them yet
+		if _, err := parser.LoadPresets(filepath.Join(nestRoot, parser.PresetsDirName)); err != nil {
+			return err
+		}
+		if _, err := parser.LoadDefaults(filepath.Join(nestRoot, parser.DefaultsDirName, parser.DefaultsFileName)); err != nil {
+			return err
+		}
+
+		// Find all .fly files
 		filesToValidate, err = findFlyFiles(nestRoot)
 		if err != nil {
 			return fmt.Errorf("failed to find .fly files: %w", err)
 		}
 
 		if len(filesToValidate) == 0 {
-			fmt.Println("⚠️  No .fly files found in the repository")
+			fmt.Fprintln(msgOut(), "⚠️  No .fly files found in the repository")
+			if outputFormat == outputSARIF {
+				return writeSARIF(os.Stdout, nil, displayRoot, describeValidationCode)
+			}
+			if isStructuredOutput() {
+				return writeStructured(os.Stdout, &validateOutput{Files: []*fileValidationOutput{}})
+			}
 			return nil
 		}
 	}
 
-	fmt.Printf("Validating %d file(s)...\n\n", len(filesToValidate))
+	w := msgOut()
+	fmt.Fprintf(w, "Validating %d file(s)...\n\n", len(filesToValidate))
 
-	// Validate each file
-	p := parser.NewParser()
-	hasErrors := false
-	validCount := 0
-	errorCount := 0
-
-	for _, filePath := range filesToValidate {
-		relPath, _ := filepath.Rel(validatePath, filePath)
-		if relPath == "" {
-			relPath = filePath
+	report := &validateOutput{
+		Files:           validateFiles(filesToValidate, validateConcurrency, engine),
+		SkippedPolicies: engine.Skipped(),
+	}
+	renderer := diagnostic.NewRenderer(displayRoot, useColor(w))
+	for _, fileResult := range report.Files {
+		fmt.Fprintf(w, "📄 %s\n", fileResult.Path)
+		fmt.Fprintf(w, "   %s\n", fileResult.message)
+		for _, inherited := range fileResult.Inherited {
+			fmt.Fprintf(w, "   ↳ %s\n", inherited)
 		}
-
-		fmt.Printf("📄 %s\n", relPath)
-
-		config, err := p.ParseFile(filePath)
-		if err != nil {
-			fmt.Printf("   ❌ Parse error: %v\n\n", err)
-			hasErrors = true
-			errorCount++
-			continue
+		if len(fileResult.diagnostics) > 0 {
+			var b strings.Builder
+			if err := renderer.Render(&b, fileResult.diagnostics); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "\n%s", indent(b.String(), "   "))
 		}
-
-		// Perform semantic validation
-		if err := validateConfig(config, filePath); err != nil {
-			fmt.Printf("   ❌ Validation error: %v\n\n", err)
-			hasErrors = true
-			errorCount++
-			continue
+		fmt.Fprintln(w)
+		if fileResult.Valid {
+			report.ValidCount++
+		} else {
+			report.ErrorCount++
 		}
+	}
 
-		fmt.Printf("   ✅ Valid\n\n")
-		validCount++
+	if wholeNest {
+		var configs []*parser.Config
+		for _, fileResult := range report.Files {
+			if fileResult.config != nil {
+				configs = append(configs, fileResult.config)
+			}
+		}
+		if result := parser.ValidateNest(configs); !result.IsValid() {
+			fmt.Fprintln(w, "🔗 Cross-file references")
+			for _, err := range result.Errors {
+				report.NestErrors = append(report.NestErrors, err.Error())
+				report.nestDiagnostics = append(report.nestDiagnostics, diagnostic.FromValidationError(err))
+				fmt.Fprintf(w, "   ❌ %s\n", err)
+			}
+			fmt.Fprintln(w)
+			report.ErrorCount += len(result.Errors)
+		}
 	}
 
 	// Print summary
-	fmt.Println(strings.Repeat("─", 50))
-	fmt.Printf("Summary: %d valid, %d errors\n", validCount, errorCount)
+	fmt.Fprintln(w, strings.Repeat("─", 50))
+	fmt.Fprintf(w, "Summary: %d valid, %d errors\n", report.ValidCount, report.ErrorCount)
+
+	if outputFormat == outputSARIF {
+		if err := writeSARIF(os.Stdout, report.diagnostics(), displayRoot, describeValidationCode); err != nil {
+			return err
+		}
+	} else if isStructuredOutput() {
+		if err := writeStructured(os.Stdout, report); err != nil {
+			return err
+		}
+	}
 
-	if hasErrors {
-		return fmt.Errorf("validation failed with %d error(s)", errorCount)
+	if report.ErrorCount > 0 {
+		return fmt.Errorf("validation failed with %d error(s)", report.ErrorCount)
 	}
 
-	fmt.Println("✅ All files validated successfully!")
+	fmt.Fprintln(w, "✅ All files validated successfully!")
 	return nil
 }
 
-func findFlyFiles(root string) ([]string, error) {
-	var files []string
+// validateFiles parses and validates files with a bounded pool of workers,
+// checking them against engine's policies when it is not nil.
+// Results are returned in the same order as files.
+func validateFiles(files []string, concurrency int, engine *policy.Engine) []*fileValidationOutput {
+	results := make([]*fileValidationOutput, len(files))
+	if concurrency > len(files) {
+		concurrency = len(files)
+	}
 
-	// Search in Eggs directory
-	eggsDir := filepath.Join(root, "Eggs")
-	if info, err := os.Stat(eggsDir); err == nil && info.IsDir() {
-		err := filepath.Walk(eggsDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// hclparse.Parser caches files and is not safe for concurrent use
+			p := parser.NewParser()
+			for idx := range indexes {
+				results[idx] = validateFile(p, files[idx], engine)
+				telemetry.RecordValidation(context.Background(), results[idx].Valid)
 			}
-			if !info.IsDir() && strings.HasSuffix(path, ".fly") {
-				files = append(files, path)
-			}
-			return nil
-		})
-		if err != nil {
-			return nil, err
+		}()
+	}
+	for idx := range files {
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// validateFile parses and validates a single .fly file
+func validateFile(p *parser.Parser, filePath string, engine *policy.Engine) *fileValidationOutput {
+	relPath, _ := filepath.Rel(validatePath, filePath)
+	if relPath == "" {
+		relPath = filePath
+	}
+	fileResult := &fileValidationOutput{Path: relPath, file: filePath}
+
+	// Environment overlays are validated merged over their base file
+	configPath, env := filePath, ""
+	if basePath, overlayEnv, ok := parser.SplitOverlayPath(filePath); ok {
+		if _, err := os.Stat(basePath); err == nil {
+			configPath, env = basePath, overlayEnv
 		}
 	}
 
-	// Search in Jobs directory
-	jobsDir := filepath.Join(root, "Jobs")
-	if info, err := os.Stat(jobsDir); err == nil && info.IsDir() {
-		err := filepath.Walk(jobsDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if !info.IsDir() && strings.HasSuffix(path, ".fly") {
-				files = append(files, path)
+	config, err := p.ParseFileForEnv(configPath, env)
+	if err != nil {
+		fileResult.Error = fmt.Sprintf("parse error: %v", err)
+		fileResult.SyntaxErrors = syntaxErrors(err)
+		fileResult.message = "❌ Parse error"
+		fileResult.diagnostics = diagnostic.FromError(err)
+		return fileResult
+	}
+	if env == "" {
+		fileResult.config = config
+	}
+
+	// Perform semantic validation
+	warnings, err := validateConfig(config, configPath)
+	for _, warning := range warnings {
+		fileResult.Warnings = append(fileResult.Warnings, warning.Error())
+		d := diagnostic.FromValidationError(warning)
+		if validateWarningsAsErrors {
+			d.Severity = diagnostic.SeverityError
+		}
+		fileResult.diagnostics = append(fileResult.diagnostics, d)
+	}
+	if err != nil {
+		fileResult.Error = fmt.Sprintf("validation error: %v", err)
+		fileResult.message = "❌ Validation error"
+		fileResult.diagnostics = append(fileResult.diagnostics, diagnostic.FromError(err)...)
+		return fileResult
+	}
+	if validateWarningsAsErrors && len(warnings) > 0 {
+		fileResult.Error = fmt.Sprintf("validation error: %d warning(s) treated as errors", len(warnings))
+		fileResult.message = "❌ Warnings treated as errors"
+		return fileResult
+	}
+
+	if violations := engine.Evaluate(config, env); len(violations) > 0 {
+		messages := make([]string, 0, len(violations))
+		for _, v := range violations {
+			messages = append(messages, v.String())
+		}
+		fileResult.PolicyViolations = violations
+		fileResult.Error = "policy violation: " + strings.Join(messages, "; ")
+		fileResult.message = "❌ Policy violation:\n      " + strings.Join(messages, "\n      ")
+		return fileResult
+	}
+
+	fileResult.Valid = true
+	fileResult.message = "✅ Valid"
+	fileResult.Inherited = inheritedDefaults(config, configPath)
+	return fileResult
+}
+
+// inheritedDefaults describes the attributes the eggs of config, the file
+// at filePath, take from the Defaults/config.fly of their Nest
+func inheritedDefaults(config *parser.Config, filePath string) []string {
+	eggsDir := filepath.Dir(filepath.Dir(filePath))
+	if filepath.Base(eggsDir) != "Eggs" {
+		return nil
+	}
+	nestRoot := filepath.Dir(eggsDir)
+	origins := map[string]string{
+		absPath(filepath.Join(nestRoot, parser.DefaultsDirName, parser.DefaultsFileName)): originDefaults,
+	}
+
+	var inherited []string
+	for i := range config.Blocks {
+		egg := &config.Blocks[i]
+		if egg.Type != "egg" || len(egg.Labels) == 0 {
+			continue
+		}
+		var sources []attributeSource
+		collectAttributeSources(egg, "", nestRoot, origins, &sources)
+		sort.Slice(sources, func(i, j int) bool { return sources[i].Path < sources[j].Path })
+		for _, source := range sources {
+			if source.Origin == originDefaults {
+				inherited = append(inherited, fmt.Sprintf("egg %q: %s = %s (%s:%d)",
+					egg.Labels[0], source.Path, source.Value, source.File, source.Line))
 			}
-			return nil
-		})
-		if err != nil {
-			return nil, err
 		}
 	}
+	return inherited
+}
+
+// findFlyFiles returns the .fly files under Eggs, Jobs and UF. Directories
+// starting with "_" (e.g. Eggs/_shared) hold include fragments rather than
+// standalone configurations and are skipped.
+func findFlyFiles(root string) ([]string, error) {
+	var files []string
 
-	// Search in UF directory
-	ufDir := filepath.Join(root, "UF")
-	if info, err := os.Stat(ufDir); err == nil && info.IsDir() {
-		err := filepath.Walk(ufDir, func(path string, info os.FileInfo, err error) error {
+	for _, dir := range []string{"Eggs", "Jobs", "UF"} {
+		dirPath := filepath.Join(root, dir)
+		if info, err := os.Stat(dirPath); err != nil || !info.IsDir() {
+			continue
+		}
+		err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
-			if !info.IsDir() && strings.HasSuffix(path, ".fly") {
+			if info.IsDir() {
+				if path != dirPath && strings.HasPrefix(info.Name(), "_") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if parser.IsFlyFile(path) {
 				files = append(files, path)
 			}
 			return nil
@@ -178,23 +519,37 @@ func findFlyFiles(root string) ([]string, error) {
 	return files, nil
 }
 
-func validateConfig(config *parser.Config, filePath string) error {
+// validateConfig validates config, the file at filePath, returning the
+// validation warnings along with the error, if any
+func validateConfig(config *parser.Config, filePath string) ([]*parser.ValidationError, error) {
 	if len(config.Blocks) == 0 {
-		return fmt.Errorf("configuration file is empty")
+		return nil, fmt.Errorf("configuration file is empty")
 	}
 
+	fileName := filepath.Base(filePath)
+	dirName := filepath.Base(filepath.Dir(filePath))
+	parentDir := filepath.Base(filepath.Dir(filepath.Dir(filePath)))
+
 	// Use the parser's comprehensive validator
 	validator := parser.NewValidator(config)
+	if validateStrict {
+		validator = parser.NewStrictValidator(config)
+	} else if validateLenient {
+		validator = parser.NewLenientValidator(config)
+	}
 	result := validator.Validate()
 
+	// The runners_condition blocks of UF/config.fly must name Eggs of the same Nest
+	eggsDir := filepath.Join(filepath.Dir(filepath.Dir(filePath)), "Eggs")
+	if info, err := os.Stat(eggsDir); err == nil && info.IsDir() && dirName == "UF" && fileName == "config.fly" {
+		parser.CheckEggsEntities(config, localEggNames(eggsDir), result)
+	}
+
 	if !result.IsValid() {
-		return fmt.Errorf("%s", result.Error())
+		return result.Warnings, result
 	}
 
 	// Additional file-location-based validation
-	fileName := filepath.Base(filePath)
-	dirName := filepath.Base(filepath.Dir(filePath))
-	parentDir := filepath.Base(filepath.Dir(filepath.Dir(filePath)))
 
 	// Determine expected block type
 	var expectedBlockType string
@@ -211,10 +566,10 @@ func validateConfig(config *parser.Config, filePath string) error {
 	if expectedBlockType != "" {
 		for _, block := range config.Blocks {
 			if block.Type != expectedBlockType {
-				return fmt.Errorf("unexpected block type %q (expected %q)", block.Type, expectedBlockType)
+				return result.Warnings, fmt.Errorf("unexpected block type %q (expected %q)", block.Type, expectedBlockType)
 			}
 		}
 	}
 
-	return nil
+	return result.Warnings, nil
 }
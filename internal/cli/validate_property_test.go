@@ -54,7 +54,7 @@ func TestEggConfigurationValidation(t *testing.T) {
 				}
 
 				// Validate the configuration
-				validationErr := validateConfig(config, configPath)
+				_, validationErr := validateConfig(config, configPath)
 
 				// Invalid configurations should fail validation
 				if validationErr == nil {
@@ -82,7 +82,7 @@ func TestEggConfigurationValidation(t *testing.T) {
 func genInvalidationType() gopter.Gen {
 	return gen.OneConstOf(
 		"missing_type",             // Missing required 'type' attribute
-		"invalid_type_value",       // type is not 'vm' or 'serverless'
+		"invalid_type_value",       // type is not a runner type
 		"missing_cloud_block",      // Missing required 'cloud' nested block
 		"missing_resources_block",  // Missing required 'resources' nested block
 		"missing_runner_block",     // Missing required 'runner' nested block
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/diagnostic"
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+const uglyFoxNestConfig = `
+uglyfox {
+  pruning {
+    failed_threshold = 3
+    max_age = "24h"
+    check_interval = "5m"
+  }
+
+  runners_condition "default" {
+    eggs_entities = ["my-app", "old-app"]
+
+    apex {
+      max_count = 10
+      min_count = 2
+    }
+
+    nadir {
+      max_count = 5
+      min_count = 0
+      idle_timeout = "30m"
+    }
+  }
+}
+`
+
+func TestValidateUglyFoxEggsEntities(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", policyEggConfig)
+	writeNestFile(t, root, "UF/config.fly", uglyFoxNestConfig)
+
+	results := validateFiles([]string{filepath.Join(root, "UF", "config.fly")}, 1, nil)
+	if results[0].Valid || !strings.Contains(results[0].Error, `egg "old-app" is not defined in the Nest`) {
+		t.Fatalf("expected old-app to be reported, got %+v", results[0])
+	}
+
+	writeNestFile(t, root, "Eggs/old-app/config.fly", strings.ReplaceAll(policyEggConfig, "my-app", "old-app"))
+	results = validateFiles([]string{filepath.Join(root, "UF", "config.fly")}, 1, nil)
+	if !results[0].Valid {
+		t.Errorf("UF config should be valid once old-app exists: %s", results[0].Error)
+	}
+}
+
+func TestValidateUglyFoxJSONEgg(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/generated/config.fly.json", generatedEggJSON)
+	writeNestFile(t, root, "UF/config.fly", strings.ReplaceAll(uglyFoxNestConfig, `"my-app", "old-app"`, `"generated"`))
+
+	results := validateFiles([]string{filepath.Join(root, "UF", "config.fly")}, 1, nil)
+	if !results[0].Valid {
+		t.Errorf("UF config referencing a JSON Egg should be valid: %s", results[0].Error)
+	}
+
+	targets, err := collectWebhookTargets(filepath.Join(root, "Eggs"), "generated")
+	if err != nil || len(targets) != 1 || targets[0].ProjectID != 12345 {
+		t.Errorf("expected the JSON Egg's GitLab project, got %+v (%v)", targets, err)
+	}
+}
+
+func TestValidateNestReferences(t *testing.T) {
+	originalFormat := outputFormat
+	originalPath := validatePath
+	defer func() {
+		outputFormat = originalFormat
+		validatePath = originalPath
+	}()
+
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", policyEggConfig)
+	writeNestFile(t, root, "Eggs/copy/config.fly", policyEggConfig)
+	writeNestFile(t, root, "Jobs/rotate.fly", `
+job "rotate" {
+  schedule = "0 2 * * *"
+
+  runner {
+    type = "vm"
+    tags = ["gpu"]
+  }
+
+  script = "echo rotate"
+}
+`)
+
+	outputFormat = outputJSON
+	validatePath = root
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	rOut, wOut, _ := os.Pipe()
+	_, wErr, _ := os.Pipe()
+	os.Stdout, os.Stderr = wOut, wErr
+
+	runErr := runValidate(validateCmd, []string{})
+
+	wOut.Close()
+	wErr.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+
+	var stdout bytes.Buffer
+	if _, err := stdout.ReadFrom(rOut); err != nil {
+		t.Fatalf("failed to read stdout: %v", err)
+	}
+	if runErr == nil {
+		t.Error("expected an error for cross-file reference problems")
+	}
+
+	var report validateOutput
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("stdout is not valid JSON: %v\n%s", err, stdout.String())
+	}
+	if report.ValidCount != 3 {
+		t.Errorf("expected every file to be valid on its own, got %+v", report.Files)
+	}
+	// The copied egg repeats the name and project ID; the job's tag is not offered
+	if len(report.NestErrors) != 3 || report.ErrorCount != 3 {
+		t.Fatalf("expected 3 Nest errors, got %d: %v", report.ErrorCount, report.NestErrors)
+	}
+	joined := strings.Join(report.NestErrors, "\n")
+	for _, want := range []string{`duplicate egg name "my-app"`, "project_id 12345", `tags [gpu] of job "rotate"`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in Nest errors:\n%s", want, joined)
+		}
+	}
+}
+
+func TestValidateReportsEverySyntaxError(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", `egg "my-app" {
+  type = "vm"
+  tags = ["docker", "${tag}"]
+
+  cloud {
+    provider = "aws"
+    region   = "eu-${zone}"
+  }
+}
+`)
+
+	results := validateFiles([]string{filepath.Join(root, "Eggs", "my-app", "config.fly")}, 1, nil)
+	got := results[0].SyntaxErrors
+	if len(got) != 2 || got[0].Line != 3 || got[1].Line != 7 {
+		t.Fatalf("expected syntax errors on lines 3 and 7, got %+v", got)
+	}
+	if got[0].Code != parser.CodeExpression || len(results[0].diagnostics) != 2 {
+		t.Errorf("expected two %s diagnostics, got %+v", parser.CodeExpression, results[0].diagnostics)
+	}
+}
+
+func TestValidateLenient(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", strings.Replace(policyEggConfig, "project_id = 12345", `project_id = "12345"`, 1))
+	files := []string{filepath.Join(root, "Eggs", "my-app", "config.fly")}
+
+	if results := validateFiles(files, 1, nil); results[0].Valid {
+		t.Fatal("expected a quoted project_id to fail without --lenient")
+	}
+
+	validateLenient = true
+	defer func() { validateLenient = false }()
+	result := validateFiles(files, 1, nil)[0]
+	if !result.Valid {
+		t.Fatalf("expected the file to be valid with --lenient, got %s", result.Error)
+	}
+	if len(result.Warnings) != 1 || len(result.diagnostics) != 1 || result.diagnostics[0].Severity != diagnostic.SeverityWarning {
+		t.Errorf("expected one warning for project_id, got %v", result.Warnings)
+	}
+}
+
+func TestValidateWarningsAsErrors(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", strings.NewReplacer("cpu    = 2", "cpu    = 16", "memory = 4096", "memory = 16384").Replace(policyEggConfig))
+	files := []string{filepath.Join(root, "Eggs", "my-app", "config.fly")}
+
+	result := validateFiles(files, 1, nil)[0]
+	if !result.Valid || len(result.Warnings) != 1 {
+		t.Fatalf("expected a valid file with one warning, got %s, %v", result.Error, result.Warnings)
+	}
+
+	validateWarningsAsErrors = true
+	defer func() { validateWarningsAsErrors = false }()
+	result = validateFiles(files, 1, nil)[0]
+	if result.Valid || len(result.diagnostics) != 1 || result.diagnostics[0].Severity != diagnostic.SeverityError {
+		t.Errorf("expected the warning to fail validation as an error, got %+v", result.diagnostics)
+	}
+}
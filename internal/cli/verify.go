@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+	"github.com/polar-gosling/gosling/internal/signing"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyEgg       string
+	verifyPlanID    string
+	verifyFile      string
+	verifyPublicKey string
+	verifyAPIURL    string
+	verifyAPIKey    string
+)
+
+// verifyPlanCmd represents the verify-plan command
+var verifyPlanCmd = &cobra.Command{
+	Use:   "verify-plan",
+	Short: "Verify the signature of a deployment plan",
+	Long: `Verify that a deployment plan was signed by a trusted key and has not been
+modified since it was signed (see 'gosling deploy --sign-key').
+
+The plan is fetched from MotherGoose (the Egg's latest plan unless --plan is
+given) or read from a JSON file. --public-key is a PEM file holding one or
+more trusted Ed25519 public keys.
+
+The command exits with a non-zero status if the plan is unsigned, signed by
+an unknown key or tampered with.
+
+Example:
+  gosling verify-plan --egg my-app --public-key plan-signing.pub
+  gosling verify-plan --egg my-app --plan 3f2a... --public-key trusted.pem -o json
+  gosling verify-plan --file plan.json --public-key plan-signing.pub`,
+	RunE: runVerifyPlan,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyPlanCmd)
+	verifyPlanCmd.Flags().StringVar(&verifyEgg, "egg", "", "Egg whose plan to verify")
+	verifyPlanCmd.Flags().StringVar(&verifyPlanID, "plan", "", "Plan ID (default: the Egg's latest plan)")
+	verifyPlanCmd.Flags().StringVar(&verifyFile, "file", "", "Read the plan from a JSON file instead of MotherGoose")
+	verifyPlanCmd.Flags().StringVar(&verifyPublicKey, "public-key", "", "PEM file of trusted Ed25519 public keys")
+	verifyPlanCmd.Flags().StringVar(&verifyAPIURL, "api-url", "", "MotherGoose API URL")
+	verifyPlanCmd.Flags().StringVar(&verifyAPIKey, "api-key", "", "MotherGoose API key")
+	mustMarkRequired(verifyPlanCmd, "public-key")
+	mustRegisterEggCompletion(verifyPlanCmd, completeEggNames(true))
+}
+
+// verifyPlanOutput is the machine-readable result of `gosling verify-plan`
+type verifyPlanOutput struct {
+	PlanID       string `json:"plan_id"`
+	EggName      string `json:"egg_name"`
+	ConfigHash   string `json:"config_hash"`
+	SigningKeyID string `json:"signing_key_id,omitempty"`
+	Valid        bool   `json:"valid"`
+	Error        string `json:"error,omitempty"`
+}
+
+func runVerifyPlan(cmd *cobra.Command, args []string) error {
+	keys, err := signing.LoadPublicKeys(verifyPublicKey)
+	if err != nil {
+		return err
+	}
+
+	var plan *deployer.DeploymentPlan
+	if verifyFile != "" {
+		plan, err = readPlanFile(verifyFile)
+	} else {
+		plan, err = fetchPlan(context.Background())
+	}
+	if err != nil {
+		return err
+	}
+
+	result := verifyPlan(plan, keys)
+	if isStructuredOutput() {
+		if err := writeStructured(os.Stdout, result); err != nil {
+			return err
+		}
+	} else if result.Valid {
+		fmt.Printf("✅ Plan %s for egg %s is signed by trusted key %s\n", result.PlanID, result.EggName, result.SigningKeyID)
+	} else {
+		fmt.Printf("❌ Plan %s for egg %s: %s\n", result.PlanID, result.EggName, result.Error)
+	}
+
+	if !result.Valid {
+		return fmt.Errorf("plan verification failed")
+	}
+	return nil
+}
+
+// verifyPlan checks a plan's signature against the trusted keys
+func verifyPlan(plan *deployer.DeploymentPlan, keys map[string]ed25519.PublicKey) *verifyPlanOutput {
+	result := &verifyPlanOutput{
+		PlanID:       plan.ID,
+		EggName:      plan.EggName,
+		ConfigHash:   plan.ConfigHash,
+		SigningKeyID: plan.SigningKeyID,
+	}
+	if err := signing.VerifyPlan(plan, keys); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Valid = true
+	return result
+}
+
+// fetchPlan retrieves the plan to verify from MotherGoose
+func fetchPlan(ctx context.Context) (*deployer.DeploymentPlan, error) {
+	if verifyEgg == "" {
+		return nil, fmt.Errorf("--egg is required unless --file is given")
+	}
+	conn, err := resolveAPI(verifyAPIURL, verifyAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	return lookupPlan(ctx, conn.client(), verifyEgg, verifyPlanID)
+}
+
+// lookupPlan retrieves a plan of an Egg, or its latest plan if planID is empty
+func lookupPlan(ctx context.Context, client mothergoose.MotherGooseClient, eggName, planID string) (*deployer.DeploymentPlan, error) {
+	if planID != "" {
+		plan, err := client.GetDeploymentPlan(ctx, eggName, planID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get plan: %w", err)
+		}
+		return plan, nil
+	}
+	status, err := client.GetEggStatus(ctx, eggName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get egg status: %w", err)
+	}
+	if status.LatestPlan == nil {
+		return nil, fmt.Errorf("no deployment found for egg: %s", eggName)
+	}
+	return status.LatestPlan, nil
+}
+
+// readPlanFile reads a plan in the JSON form returned by MotherGoose
+func readPlanFile(path string) (*deployer.DeploymentPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+	var plan deployer.DeploymentPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file %s: %w", path, err)
+	}
+	return &plan, nil
+}
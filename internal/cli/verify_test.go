@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/signing"
+)
+
+func TestSignedDeployVerifies(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "plan-signing.key")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GOSLING_SIGN_KEY", keyPath)
+	signingKey, err := loadSigningKey("")
+	if err != nil {
+		t.Fatalf("loadSigningKey failed: %v", err)
+	}
+
+	client := NewMockMotherGooseClient()
+	egg := &deployer.EggConfig{Name: "my-app", Type: deployer.RunnerTypeVM}
+	result, err := deployEgg(context.Background(), newLogger(io.Discard, "deploy"), egg, deployer.CloudProviderYandex, "ru-central1-a", client, signingKey)
+	if err != nil {
+		t.Fatalf("deployEgg failed: %v", err)
+	}
+	if client.CreatePlanCalls != 1 || client.ApplyPlanCalls != 1 || result.SigningKeyID != signing.KeyID(pub) {
+		t.Fatalf("expected one applied plan signed by %s, got %d plan(s), %d apply call(s) and %+v", signing.KeyID(pub), client.CreatePlanCalls, client.ApplyPlanCalls, result)
+	}
+
+	// Round-trip through the JSON form MotherGoose returns
+	data, err := json.Marshal(client.DeploymentPlans["my-app"][0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	if err := os.WriteFile(planPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := readPlanFile(planPath)
+	if err != nil {
+		t.Fatalf("readPlanFile failed: %v", err)
+	}
+
+	keys := map[string]ed25519.PublicKey{signing.KeyID(pub): pub}
+	if out := verifyPlan(plan, keys); !out.Valid {
+		t.Errorf("expected valid plan, got %+v", out)
+	}
+	plan.PlanBinary = append(plan.PlanBinary, ' ')
+	if out := verifyPlan(plan, keys); out.Valid || out.Error == "" {
+		t.Errorf("expected tampered plan to fail, got %+v", out)
+	}
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+// ANSI escape sequences used by the status dashboard
+const (
+	ansiReset       = "\033[0m"
+	ansiRed         = "\033[31m"
+	ansiGreen       = "\033[32m"
+	ansiYellow      = "\033[33m"
+	ansiDim         = "\033[2m"
+	ansiClearScreen = "\033[H\033[2J"
+)
+
+// dashboard is a snapshot of the Eggs shown by `gosling status --watch`
+type dashboard struct {
+	Eggs      []*dashboardEgg
+	FetchedAt time.Time
+}
+
+// dashboardEgg is the status of a single Egg on the dashboard
+type dashboardEgg struct {
+	Name    string
+	Status  string
+	PlanID  string
+	Runners []*mothergoose.Runner
+	Err     error
+}
+
+// watchStatus redraws the status dashboard every interval until ctx is done
+func watchStatus(ctx context.Context, client mothergoose.MotherGooseClient, eggName string, out io.Writer, interval, staleAfter time.Duration) error {
+	tty := isTerminal(out)
+	color := useColor(out)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		d, err := collectDashboard(ctx, client, eggName)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if tty {
+			fmt.Fprint(out, ansiClearScreen)
+		}
+		fmt.Fprintf(out, "=== Gosling status · every %s · Ctrl+C to exit ===\n\n", interval)
+		if err != nil {
+			// Keep watching: MotherGoose may only be briefly unreachable
+			fmt.Fprintf(out, "⚠️  Refresh failed at %s: %v\n", time.Now().Format("15:04:05"), err)
+		} else {
+			renderDashboard(out, d, staleAfter, color)
+		}
+		if !tty {
+			fmt.Fprintln(out)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectDashboard fetches the status of one Egg, or of every Egg if eggName is empty
+func collectDashboard(ctx context.Context, client mothergoose.MotherGooseClient, eggName string) (*dashboard, error) {
+	names := []string{eggName}
+	if eggName == "" {
+		eggs, err := client.ListEggs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list eggs: %w", err)
+		}
+		names = names[:0]
+		for _, egg := range eggs {
+			names = append(names, egg.Name)
+		}
+		sort.Strings(names)
+	}
+
+	d := &dashboard{FetchedAt: time.Now()}
+	for _, name := range names {
+		egg := &dashboardEgg{Name: name, Status: "not deployed"}
+		status, err := client.GetEggStatus(ctx, name)
+		switch {
+		case err != nil:
+			egg.Status = "unknown"
+			egg.Err = err
+		case status.LatestPlan != nil:
+			egg.Status = status.LatestPlan.Status
+			egg.PlanID = status.LatestPlan.ID
+			egg.Runners = status.ActiveRunners
+		default:
+			egg.Runners = status.ActiveRunners
+		}
+		d.Eggs = append(d.Eggs, egg)
+	}
+	return d, nil
+}
+
+// renderDashboard prints the Egg and runner tables. Colored cells are kept in
+// the last column so escape sequences do not break the alignment.
+func renderDashboard(w io.Writer, d *dashboard, staleAfter time.Duration, color bool) {
+	paint := func(code, s string) string {
+		if !color || code == "" {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	fmt.Fprintf(w, "Eggs (%d) at %s\n", len(d.Eggs), d.FetchedAt.Format("15:04:05"))
+	if len(d.Eggs) == 0 {
+		fmt.Fprintln(w, "No eggs found")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "EGG NAME\tPLAN ID\tRUNNERS\tSTALE\tSTATUS")
+	var runners []*mothergoose.Runner
+	for _, egg := range d.Eggs {
+		stale := 0
+		for _, runner := range egg.Runners {
+			if d.FetchedAt.Sub(runner.LastHeartbeat) >= staleAfter {
+				stale++
+			}
+		}
+		planID := "-"
+		if egg.PlanID != "" {
+			planID = shortID(egg.PlanID)
+		}
+		status := egg.Status
+		if egg.Err != nil {
+			status += " (" + egg.Err.Error() + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", egg.Name, planID, len(egg.Runners), stale, paint(planStatusColor(egg.Status), status))
+		runners = append(runners, egg.Runners...)
+	}
+	tw.Flush()
+
+	if len(runners) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nActive Runners (%d)\n", len(runners))
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "EGG NAME\tRUNNER ID\tTYPE\tSTATE\tREGION\tLAST HEARTBEAT")
+	for _, runner := range runners {
+		age := d.FetchedAt.Sub(runner.LastHeartbeat)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			runner.EggName,
+			shortRunnerID(runner.ID),
+			runner.Type,
+			runner.State,
+			runner.Region,
+			paint(heartbeatColor(age, staleAfter), heartbeatAge(age, staleAfter)))
+	}
+	tw.Flush()
+}
+
+// planStatusColor maps a plan status to its dashboard color
+func planStatusColor(status string) string {
+	switch status {
+	case "applied":
+		return ansiGreen
+	case "pending":
+		return ansiYellow
+	case "failed", "rolled_back", "unknown":
+		return ansiRed
+	default:
+		return ansiDim
+	}
+}
+
+// heartbeatColor is green for fresh heartbeats, yellow past half of
+// staleAfter and red once the runner is stale
+func heartbeatColor(age, staleAfter time.Duration) string {
+	switch {
+	case age >= staleAfter:
+		return ansiRed
+	case age >= staleAfter/2:
+		return ansiYellow
+	default:
+		return ansiGreen
+	}
+}
+
+// heartbeatAge describes how long ago a runner last sent a heartbeat
+func heartbeatAge(age, staleAfter time.Duration) string {
+	if age < 0 {
+		age = 0
+	}
+	s := age.Truncate(time.Second).String() + " ago"
+	if age >= staleAfter {
+		s += " (stale)"
+	}
+	return s
+}
+
+// shortRunnerID truncates a runner ID for display
+func shortRunnerID(id string) string {
+	if len(id) > 12 {
+		return id[:12] + "..."
+	}
+	return id
+}
+
+// isTerminal reports whether w is an interactive terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0 && !strings.EqualFold(os.Getenv("TERM"), "dumb")
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+func newWatchClient(now time.Time) *MockMotherGooseClient {
+	client := NewMockMotherGooseClient()
+	client.EggConfigs["web"] = &deployer.EggConfig{Name: "web"}
+	client.EggConfigs["api"] = &deployer.EggConfig{Name: "api"}
+	client.EggStatuses["api"] = &mothergoose.EggStatus{
+		EggName:    "api",
+		LatestPlan: &deployer.DeploymentPlan{ID: "3f2a9c01-plan", Status: "applied"},
+		ActiveRunners: []*mothergoose.Runner{
+			{ID: "runner-fresh", EggName: "api", State: "active", LastHeartbeat: now.Add(-10 * time.Second)},
+			{ID: "runner-stale", EggName: "api", State: "active", LastHeartbeat: now.Add(-5 * time.Minute)},
+		},
+	}
+	return client
+}
+
+func TestRenderDashboard(t *testing.T) {
+	now := time.Now()
+	d, err := collectDashboard(context.Background(), newWatchClient(now), "")
+	if err != nil {
+		t.Fatalf("collectDashboard failed: %v", err)
+	}
+	if len(d.Eggs) != 2 || d.Eggs[0].Name != "api" || d.Eggs[1].Status != "not deployed" {
+		t.Fatalf("unexpected dashboard: %+v", d.Eggs)
+	}
+
+	var buf bytes.Buffer
+	renderDashboard(&buf, d, 90*time.Second, false)
+	out := buf.String()
+	for _, want := range []string{"3f2a9c01", "applied", "runner-fresh", "5m0s ago (stale)", "Active Runners (2)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in dashboard:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\033[") {
+		t.Errorf("expected no escape sequences without color:\n%s", out)
+	}
+
+	buf.Reset()
+	renderDashboard(&buf, d, 90*time.Second, true)
+	if !strings.Contains(buf.String(), ansiRed+"5m0s ago (stale)"+ansiReset) {
+		t.Errorf("expected stale heartbeat in red:\n%s", buf.String())
+	}
+}
+
+func TestWatchStatusStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := newWatchClient(time.Now())
+	var buf bytes.Buffer
+	if err := watchStatus(ctx, client, "api", &buf, 10*time.Millisecond, 90*time.Second); err != nil {
+		t.Fatalf("watchStatus failed: %v", err)
+	}
+	if frames := strings.Count(buf.String(), "=== Gosling status"); frames < 2 {
+		t.Errorf("expected the dashboard to refresh, got %d frame(s)", frames)
+	}
+	if client.ListEggsCalls != 0 {
+		t.Errorf("expected a single-Egg watch not to list eggs, got %d call(s)", client.ListEggsCalls)
+	}
+}
@@ -0,0 +1,410 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/polar-gosling/gosling/internal/gitlab"
+	"github.com/polar-gosling/gosling/internal/parser"
+	"github.com/polar-gosling/gosling/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	webhooksEgg         string
+	webhooksAPIURL      string
+	webhooksAPIKey      string
+	webhooksURL         string
+	webhooksSecret      string
+	webhooksGitLabURL   string
+	webhooksGitLabToken string
+	webhooksRemove      bool
+	webhooksDryRun      bool
+	webhooksGitLabConn  gitlabConnFlags
+)
+
+// webhooksCmd represents the webhooks command
+var webhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Manage the GitLab webhooks that notify MotherGoose",
+}
+
+// webhooksSyncCmd represents the webhooks sync command
+var webhooksSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Ensure every Egg's GitLab project has a MotherGoose webhook",
+	Long: `Reconcile the webhooks of the GitLab projects served by the Eggs and
+EggsBuckets of the Nest, so push, pipeline and merge request events reach the
+MotherGoose webhook endpoint (<api-url>/webhooks/gitlab, or --webhook-url).
+
+A missing webhook is created, one with the wrong events is updated and
+duplicates are deleted; running the command again changes nothing. With
+--remove the MotherGoose webhooks are deleted instead. Webhooks pointing
+elsewhere are never touched. Eggs serving a whole group (group_id) and
+repositories without a project_id yet are skipped.
+
+--secret is the token GitLab sends in X-Gitlab-Token. It may be a secret
+reference, and {egg} is replaced by the Egg name to use per-Egg secrets.
+GitLab does not return webhook tokens, so a changed secret only reaches
+webhooks that are created or updated.
+
+The GitLab API of each project is https://<server_name> unless --gitlab-url
+is set, and its certificate is verified against the ca_cert of the gitlab
+block unless --gitlab-ca-cert is set.
+
+Example:
+  gosling webhooks sync --secret 'yc-lockbox://webhooks/{egg}-secret'
+  gosling webhooks sync --egg my-app --dry-run
+  gosling webhooks sync --remove`,
+	Args: cobra.NoArgs,
+	RunE: runWebhooksSync,
+}
+
+func init() {
+	rootCmd.AddCommand(webhooksCmd)
+	webhooksCmd.AddCommand(webhooksSyncCmd)
+	webhooksSyncCmd.Flags().StringVar(&webhooksEgg, "egg", "", "Only sync the webhooks of this Egg")
+	webhooksSyncCmd.Flags().StringVar(&webhooksAPIURL, "api-url", "", "MotherGoose API URL")
+	webhooksSyncCmd.Flags().StringVar(&webhooksAPIKey, "api-key", "", "MotherGoose API key")
+	webhooksSyncCmd.Flags().StringVar(&webhooksURL, "webhook-url", "", "Webhook endpoint (default: <api-url>/webhooks/gitlab)")
+	webhooksSyncCmd.Flags().StringVar(&webhooksSecret, "secret", "", "Webhook secret token or secret reference (default: $GOSLING_WEBHOOK_SECRET)")
+	webhooksSyncCmd.Flags().StringVar(&webhooksGitLabURL, "gitlab-url", "", "GitLab URL (default: https://<server_name> of each Egg)")
+	webhooksSyncCmd.Flags().StringVar(&webhooksGitLabToken, "gitlab-token", "", "GitLab token with api scope (default: $GITLAB_TOKEN)")
+	webhooksSyncCmd.Flags().BoolVar(&webhooksRemove, "remove", false, "Delete the MotherGoose webhooks instead")
+	webhooksSyncCmd.Flags().BoolVar(&webhooksDryRun, "dry-run", false, "Show what would change without changing it")
+	webhooksGitLabConn.register(webhooksSyncCmd)
+	mustRegisterEggCompletion(webhooksSyncCmd, completeEggNames(false))
+}
+
+// Webhook reconciliation outcomes
+const (
+	webhookCreated   = "created"
+	webhookUpdated   = "updated"
+	webhookUnchanged = "unchanged"
+	webhookRemoved   = "removed"
+	webhookAbsent    = "absent"
+	webhookSkipped   = "skipped"
+)
+
+// webhookTarget is a GitLab project served by an Egg
+type webhookTarget struct {
+	Egg         string
+	Server      string
+	ProjectID   int
+	GroupID     int    // Set instead of ProjectID for Eggs serving a group
+	CACert      string // ca_cert of the gitlab block
+	TokenSecret string // token_secret of the gitlab block
+}
+
+// webhookResult is the outcome of reconciling one project
+type webhookResult struct {
+	EggName   string `json:"egg_name"`
+	Server    string `json:"server"`
+	ProjectID int    `json:"project_id,omitempty"`
+	HookID    int    `json:"hook_id,omitempty"`
+	Action    string `json:"action"`
+	Message   string `json:"message,omitempty"`
+}
+
+// webhooksSyncOutput is the machine-readable result of `gosling webhooks sync`
+type webhooksSyncOutput struct {
+	WebhookURL string           `json:"webhook_url"`
+	DryRun     bool             `json:"dry_run"`
+	Results    []*webhookResult `json:"results"`
+}
+
+// webhookClient is the part of gitlab.Client used to reconcile webhooks
+type webhookClient interface {
+	ListWebhooks(ctx context.Context, projectID int) ([]*gitlab.Webhook, error)
+	CreateWebhook(ctx context.Context, projectID int, config *gitlab.WebhookConfig) (int, error)
+	UpdateWebhook(ctx context.Context, projectID, hookID int, config *gitlab.WebhookConfig) error
+	DeleteWebhook(ctx context.Context, projectID, hookID int) error
+}
+
+func runWebhooksSync(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	nestRoot, err := findNestRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find Nest repository: %w", err)
+	}
+	targets, err := collectWebhookTargets(filepath.Join(nestRoot, "Eggs"), webhooksEgg)
+	if err != nil {
+		return err
+	}
+
+	hookURL := webhooksURL
+	if hookURL == "" {
+		conn, err := resolveAPI(webhooksAPIURL, webhooksAPIKey)
+		if err != nil {
+			return err
+		}
+		hookURL = strings.TrimSuffix(conn.APIURL, "/") + "/webhooks/gitlab"
+	}
+	secretRef := webhooksSecret
+	if secretRef == "" {
+		secretRef = os.Getenv("GOSLING_WEBHOOK_SECRET")
+	}
+	if secretRef == "" && !webhooksRemove {
+		return fmt.Errorf("a webhook secret is required: use --secret or GOSLING_WEBHOOK_SECRET")
+	}
+	token := webhooksGitLabToken
+	if token == "" {
+		token = os.Getenv("GITLAB_TOKEN")
+	}
+	if token == "" {
+		return fmt.Errorf("a GitLab token is required: use --gitlab-token or GITLAB_TOKEN")
+	}
+
+	// Eggs on the same server may still trust different CAs
+	clients := make(map[[2]string]webhookClient)
+	out := &webhooksSyncOutput{WebhookURL: hookURL, DryRun: webhooksDryRun, Results: []*webhookResult{}}
+	for _, target := range targets {
+		key := [2]string{target.Server, target.CACert}
+		client, ok := clients[key]
+		if !ok {
+			baseURL := webhooksGitLabURL
+			if baseURL == "" {
+				baseURL = "https://" + target.Server
+			}
+			opts, err := webhooksGitLabConn.options(ctx, target.CACert)
+			if err != nil {
+				return fmt.Errorf("egg %s: %w", target.Egg, err)
+			}
+			c, err := gitlab.NewClient(baseURL, token, opts...)
+			if err != nil {
+				return err
+			}
+			client, clients[key] = c, c
+		}
+
+		var config *gitlab.WebhookConfig
+		if !webhooksRemove && target.ProjectID != 0 {
+			secret, err := resolveWebhookSecret(ctx, secretRef, target.Egg)
+			if err != nil {
+				return err
+			}
+			config = mothergooseWebhook(hookURL, secret)
+		}
+		result, err := reconcileWebhook(ctx, client, target, hookURL, config, webhooksDryRun)
+		if err != nil {
+			return fmt.Errorf("egg %s, project %d: %w", target.Egg, target.ProjectID, err)
+		}
+		out.Results = append(out.Results, result)
+	}
+
+	if isStructuredOutput() {
+		return writeStructured(os.Stdout, out)
+	}
+	if len(out.Results) == 0 {
+		fmt.Println("No GitLab projects found in the Nest")
+		return nil
+	}
+	if webhooksDryRun {
+		fmt.Println("Dry run: no webhooks were changed")
+		fmt.Println()
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EGG\tSERVER\tPROJECT\tHOOK\tACTION")
+	for _, r := range out.Results {
+		action := r.Action
+		if r.Message != "" {
+			action += " (" + r.Message + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", r.EggName, r.Server, r.ProjectID, r.HookID, action)
+	}
+	return w.Flush()
+}
+
+// collectWebhookTargets returns the GitLab projects of the egg blocks and
+// eggsbucket repositories under eggsDir, optionally only those of eggName.
+// Eggs stamped out with count or for_each are named by their labels, and
+// eggName selects either an Egg directory or one of its instances.
+func collectWebhookTargets(eggsDir, eggName string) ([]webhookTarget, error) {
+	dirs, err := listEggDirs(eggsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []webhookTarget
+	found := false
+	p := parser.NewParser()
+	for _, dir := range dirs {
+		selected := eggName == "" || dir.Name == eggName
+		config, err := p.ParseFile(dir.ConfigPath)
+		if err != nil {
+			if !selected {
+				// Only searched for an instance named eggName
+				continue
+			}
+			return nil, fmt.Errorf("failed to parse %s: %w", dir.ConfigPath, err)
+		}
+
+		eggBlocks := 0
+		for i := range config.Blocks {
+			if config.Blocks[i].Type == "egg" {
+				eggBlocks++
+			}
+		}
+		add := func(name string, gl *parser.Block) {
+			if selected || name == eggName {
+				found = true
+				targets = append(targets, newWebhookTarget(name, gl))
+			}
+		}
+		if selected {
+			found = true
+		}
+		for i := range config.Blocks {
+			block := &config.Blocks[i]
+			switch block.Type {
+			case "egg":
+				if gl, ok := block.GetBlock("gitlab"); ok {
+					add(expandedEggName(dir.Name, block, eggBlocks), gl)
+				}
+			case "eggsbucket":
+				repos, ok := block.GetBlock("repositories")
+				if !ok {
+					continue
+				}
+				for _, repo := range repos.GetBlocks("repo") {
+					if gl, ok := repo.GetBlock("gitlab"); ok {
+						add(dir.Name, gl)
+					}
+				}
+			}
+		}
+	}
+	if eggName != "" && !found {
+		return nil, fmt.Errorf("egg %q not found in %s", eggName, eggsDir)
+	}
+
+	sort.SliceStable(targets, func(i, j int) bool { return targets[i].Egg < targets[j].Egg })
+	return targets, nil
+}
+
+func newWebhookTarget(eggName string, gl *parser.Block) webhookTarget {
+	target := webhookTarget{Egg: eggName}
+	if server, ok := gl.GetAttribute("server_name"); ok {
+		target.Server, _ = server.AsString()
+	}
+	if projectID, ok := gl.GetAttribute("project_id"); ok {
+		target.ProjectID, _ = projectID.AsInt()
+	}
+	if groupID, ok := gl.GetAttribute("group_id"); ok {
+		target.GroupID, _ = groupID.AsInt()
+	}
+	if caCert, ok := gl.GetAttribute("ca_cert"); ok {
+		target.CACert, _ = caCert.AsString()
+	}
+	if tokenSecret, ok := gl.GetAttribute("token_secret"); ok {
+		target.TokenSecret, _ = tokenSecret.AsString()
+	}
+	return target
+}
+
+// resolveWebhookSecret expands {egg} in ref and resolves it if it is a secret reference
+func resolveWebhookSecret(ctx context.Context, ref, eggName string) (string, error) {
+	ref = strings.ReplaceAll(ref, "{egg}", eggName)
+	if !secrets.IsReference(ref) {
+		return ref, nil
+	}
+	secret, err := secrets.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve webhook secret of egg %s: %w", eggName, err)
+	}
+	return secret, nil
+}
+
+// mothergooseWebhook is the webhook MotherGoose expects on every project
+func mothergooseWebhook(hookURL, secret string) *gitlab.WebhookConfig {
+	return &gitlab.WebhookConfig{
+		URL:                   hookURL,
+		Token:                 secret,
+		PushEvents:            true,
+		MergeRequestsEvents:   true,
+		PipelineEvents:        true,
+		EnableSSLVerification: true,
+	}
+}
+
+// reconcileWebhook brings the webhooks of target pointing at hookURL in line
+// with config, or deletes them all when config is nil. Other webhooks of the
+// project are left alone.
+func reconcileWebhook(ctx context.Context, client webhookClient, target webhookTarget, hookURL string, config *gitlab.WebhookConfig, dryRun bool) (*webhookResult, error) {
+	result := &webhookResult{EggName: target.Egg, Server: target.Server, ProjectID: target.ProjectID}
+	if target.ProjectID == 0 {
+		result.Action = webhookSkipped
+		result.Message = "no project_id"
+		if target.GroupID != 0 {
+			result.Message = fmt.Sprintf("serves group %d; configure a group webhook in GitLab", target.GroupID)
+		}
+		return result, nil
+	}
+
+	hooks, err := client.ListWebhooks(ctx, target.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	var matching []*gitlab.Webhook
+	for _, hook := range hooks {
+		if hook.URL == hookURL {
+			matching = append(matching, hook)
+		}
+	}
+
+	// Delete every matching hook when removing, otherwise keep the first
+	keep := 1
+	if config == nil {
+		keep = 0
+	}
+	if len(matching) > keep {
+		for _, hook := range matching[keep:] {
+			if !dryRun {
+				if err := client.DeleteWebhook(ctx, target.ProjectID, hook.ID); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	switch {
+	case config == nil && len(matching) == 0:
+		result.Action = webhookAbsent
+	case config == nil:
+		result.Action = webhookRemoved
+		result.HookID = matching[0].ID
+	case len(matching) == 0:
+		result.Action = webhookCreated
+		if !dryRun {
+			if result.HookID, err = client.CreateWebhook(ctx, target.ProjectID, config); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		hook := matching[0]
+		result.HookID = hook.ID
+		if hook.PushEvents == config.PushEvents &&
+			hook.MergeRequestsEvents == config.MergeRequestsEvents &&
+			hook.PipelineEvents == config.PipelineEvents &&
+			hook.EnableSSLVerification == config.EnableSSLVerification {
+			result.Action = webhookUnchanged
+		} else {
+			result.Action = webhookUpdated
+			if !dryRun {
+				if err := client.UpdateWebhook(ctx, target.ProjectID, hook.ID, config); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(matching) > 1 {
+			result.Action = webhookUpdated
+			result.Message = fmt.Sprintf("deleted %d duplicate(s)", len(matching)-1)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/gitlab"
+)
+
+// fakeWebhookClient keeps the webhooks of each project in memory
+type fakeWebhookClient struct {
+	hooks  map[int][]*gitlab.Webhook
+	nextID int
+	calls  int
+}
+
+func (f *fakeWebhookClient) ListWebhooks(ctx context.Context, projectID int) ([]*gitlab.Webhook, error) {
+	return f.hooks[projectID], nil
+}
+
+func (f *fakeWebhookClient) CreateWebhook(ctx context.Context, projectID int, config *gitlab.WebhookConfig) (int, error) {
+	f.calls++
+	f.nextID++
+	f.hooks[projectID] = append(f.hooks[projectID], &gitlab.Webhook{
+		ID:                    f.nextID,
+		URL:                   config.URL,
+		PushEvents:            config.PushEvents,
+		MergeRequestsEvents:   config.MergeRequestsEvents,
+		PipelineEvents:        config.PipelineEvents,
+		EnableSSLVerification: config.EnableSSLVerification,
+	})
+	return f.nextID, nil
+}
+
+func (f *fakeWebhookClient) UpdateWebhook(ctx context.Context, projectID, hookID int, config *gitlab.WebhookConfig) error {
+	f.calls++
+	for _, hook := range f.hooks[projectID] {
+		if hook.ID == hookID {
+			hook.PushEvents = config.PushEvents
+			hook.MergeRequestsEvents = config.MergeRequestsEvents
+			hook.PipelineEvents = config.PipelineEvents
+			hook.EnableSSLVerification = config.EnableSSLVerification
+		}
+	}
+	return nil
+}
+
+func (f *fakeWebhookClient) DeleteWebhook(ctx context.Context, projectID, hookID int) error {
+	f.calls++
+	var kept []*gitlab.Webhook
+	for _, hook := range f.hooks[projectID] {
+		if hook.ID != hookID {
+			kept = append(kept, hook)
+		}
+	}
+	f.hooks[projectID] = kept
+	return nil
+}
+
+func TestReconcileWebhook(t *testing.T) {
+	const hookURL = "https://mothergoose.example.com/webhooks/gitlab"
+	ctx := context.Background()
+	client := &fakeWebhookClient{nextID: 100, hooks: map[int][]*gitlab.Webhook{
+		1: {{ID: 1, URL: "https://ci.example.com/hook", PushEvents: true}},
+		2: {{ID: 2, URL: hookURL, PushEvents: true}, {ID: 3, URL: hookURL}},
+	}}
+	config := mothergooseWebhook(hookURL, "secret")
+
+	reconcile := func(projectID int, config *gitlab.WebhookConfig, dryRun bool) *webhookResult {
+		t.Helper()
+		result, err := reconcileWebhook(ctx, client, webhookTarget{Egg: "my-app", Server: "gitlab.com", ProjectID: projectID}, hookURL, config, dryRun)
+		if err != nil {
+			t.Fatalf("reconcileWebhook(%d) failed: %v", projectID, err)
+		}
+		return result
+	}
+
+	// A dry run reports the change without making it
+	if r := reconcile(1, config, true); r.Action != webhookCreated || client.calls != 0 {
+		t.Errorf("dry run: got %+v after %d calls", r, client.calls)
+	}
+	if r := reconcile(1, config, false); r.Action != webhookCreated || r.HookID != 101 {
+		t.Errorf("expected hook created, got %+v", r)
+	}
+	if r := reconcile(2, config, false); r.Action != webhookUpdated || r.HookID != 2 || len(client.hooks[2]) != 1 {
+		t.Errorf("expected hook 2 updated and duplicate deleted, got %+v, hooks %v", r, client.hooks[2])
+	}
+
+	// A second sync changes nothing
+	calls := client.calls
+	for _, projectID := range []int{1, 2} {
+		if r := reconcile(projectID, config, false); r.Action != webhookUnchanged {
+			t.Errorf("project %d: expected unchanged, got %+v", projectID, r)
+		}
+	}
+	if client.calls != calls {
+		t.Errorf("expected no changes on resync, got %d calls", client.calls-calls)
+	}
+
+	if r := reconcile(1, nil, false); r.Action != webhookRemoved || len(client.hooks[1]) != 1 || client.hooks[1][0].ID != 1 {
+		t.Errorf("expected only the MotherGoose hook removed, got %+v, hooks %v", r, client.hooks[1])
+	}
+	if r := reconcile(1, nil, false); r.Action != webhookAbsent {
+		t.Errorf("expected absent, got %+v", r)
+	}
+	group := webhookTarget{Egg: "platform", Server: "gitlab.com", GroupID: 7}
+	if r, err := reconcileWebhook(ctx, client, group, hookURL, config, false); err != nil || r.Action != webhookSkipped {
+		t.Errorf("expected group egg skipped, got %+v (%v)", r, err)
+	}
+}
+
+func TestCollectWebhookTargets(t *testing.T) {
+	root := t.TempDir()
+	writeNestFile(t, root, "Eggs/my-app/config.fly", policyEggConfig)
+	writeNestFile(t, root, "Eggs/platform/config.fly", generateEggsBucketConfig("platform", "vm", "yandex", "ru-central1-a", []string{"auth-service"}))
+	eggsDir := filepath.Join(root, "Eggs")
+
+	targets, err := collectWebhookTargets(eggsDir, "")
+	if err != nil {
+		t.Fatalf("collectWebhookTargets failed: %v", err)
+	}
+	want := []webhookTarget{
+		{Egg: "my-app", Server: "gitlab.com", ProjectID: 12345, TokenSecret: "yc-lockbox://gitlab/runner-token"},
+		{Egg: "platform", Server: "gitlab.com", TokenSecret: "yc-lockbox://gitlab-tokens/auth-service-runner-token"},
+	}
+	if !reflect.DeepEqual(targets, want) {
+		t.Errorf("got %+v, want %+v", targets, want)
+	}
+
+	if targets, err := collectWebhookTargets(eggsDir, "my-app"); err != nil || len(targets) != 1 {
+		t.Errorf("expected only my-app, got %+v (%v)", targets, err)
+	}
+	if _, err := collectWebhookTargets(eggsDir, "missing"); err == nil {
+		t.Error("expected error for unknown egg")
+	}
+}
+
+func TestResolveWebhookSecret(t *testing.T) {
+	t.Setenv("MY_APP_WEBHOOK", "s3cret")
+	if got, err := resolveWebhookSecret(context.Background(), "env://MY_APP_WEBHOOK", "my-app"); err != nil || got != "s3cret" {
+		t.Errorf("got %q (%v)", got, err)
+	}
+	if got, _ := resolveWebhookSecret(context.Background(), "plain-{egg}", "my-app"); got != "plain-my-app" {
+		t.Errorf("expected {egg} expanded, got %q", got)
+	}
+}
@@ -0,0 +1,98 @@
+// Package cost estimates the monthly cloud cost of Egg runners.
+//
+// Estimates use approximate on-demand list prices in USD and are meant to
+// compare configurations, not to predict a bill: discounts, network traffic
+// and taxes are not included.
+package cost
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+// Currency is the currency of every price and estimate
+const Currency = "USD"
+
+// HoursPerMonth is the number of hours billed for an always-on VM
+const HoursPerMonth = 730
+
+// DefaultServerlessHours is the assumed number of hours a serverless runner
+// executes jobs per month for each concurrent job slot
+const DefaultServerlessHours = 100
+
+// Pricing holds the prices of one cloud provider
+type Pricing struct {
+	VCPUHour     float64 // Per vCPU per hour of a VM
+	MemoryGBHour float64 // Per GB of VM memory per hour
+	DiskGBMonth  float64 // Per GB of VM boot disk per month
+	GBSecond     float64 // Per GB of serverless memory per second of execution
+}
+
+// Catalog maps cloud providers to their prices
+type Catalog map[deployer.CloudProvider]Pricing
+
+// DefaultCatalog returns the built-in prices for Yandex Cloud and AWS
+func DefaultCatalog() Catalog {
+	return Catalog{
+		// Compute Cloud Intel Ice Lake at 100% vCPU with network SSD,
+		// Serverless Containers
+		deployer.CloudProviderYandex: {
+			VCPUHour:     0.0125,
+			MemoryGBHour: 0.0033,
+			DiskGBMonth:  0.1330,
+			GBSecond:     0.0000200,
+		},
+		// EC2 general purpose instances in us-east-1 with gp3 volumes, Lambda on x86
+		deployer.CloudProviderAWS: {
+			VCPUHour:     0.0316,
+			MemoryGBHour: 0.0042,
+			DiskGBMonth:  0.0800,
+			GBSecond:     0.0000166667,
+		},
+	}
+}
+
+// Estimate is the estimated monthly cost of one Egg
+type Estimate struct {
+	Compute float64 // VM CPU and memory, or serverless execution time
+	Storage float64 // VM disks
+	Monthly float64 // Compute and Storage
+}
+
+// Estimate returns the monthly cost of egg's runners on provider. A VM
+// runner is billed as one instance running all month; a serverless runner
+// is billed for DefaultServerlessHours per concurrent job slot. Kubernetes
+// runners cost nothing extra: their pods run on the nodes of an existing
+// cluster.
+func (c Catalog) Estimate(egg *deployer.EggConfig, provider deployer.CloudProvider) (*Estimate, error) {
+	pricing, ok := c[provider]
+	if !ok {
+		return nil, fmt.Errorf("no pricing for cloud provider %q", provider)
+	}
+
+	memoryGB := float64(egg.Resources.Memory) / 1024
+	estimate := &Estimate{}
+	switch egg.Type {
+	case deployer.RunnerTypeVM:
+		estimate.Compute = HoursPerMonth * (float64(egg.Resources.CPU)*pricing.VCPUHour + memoryGB*pricing.MemoryGBHour)
+		estimate.Storage = float64(egg.Resources.Disk) * pricing.DiskGBMonth
+	case deployer.RunnerTypeServerless:
+		slots := max(egg.Runner.Concurrent, 1)
+		estimate.Compute = float64(slots) * DefaultServerlessHours * 3600 * memoryGB * pricing.GBSecond
+	case deployer.RunnerTypeKubernetes:
+		// Billed as part of the cluster
+	default:
+		return nil, fmt.Errorf("unknown runner type %q", egg.Type)
+	}
+	estimate.Compute = round(estimate.Compute)
+	estimate.Storage = round(estimate.Storage)
+	estimate.Monthly = round(estimate.Compute + estimate.Storage)
+	return estimate, nil
+}
+
+// round rounds an amount to whole cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,73 @@
+package cost
+
+import (
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name     string
+		egg      deployer.EggConfig
+		provider deployer.CloudProvider
+		want     Estimate
+	}{
+		{
+			name: "yandex vm",
+			egg: deployer.EggConfig{
+				Type:      deployer.RunnerTypeVM,
+				Resources: deployer.ResourceConfig{CPU: 2, Memory: 4096, Disk: 40},
+			},
+			provider: deployer.CloudProviderYandex,
+			want:     Estimate{Compute: 27.89, Storage: 5.32, Monthly: 33.21},
+		},
+		{
+			name: "aws serverless",
+			egg: deployer.EggConfig{
+				Type:      deployer.RunnerTypeServerless,
+				Resources: deployer.ResourceConfig{CPU: 1, Memory: 2048, Disk: 10},
+				Runner:    deployer.RunnerConfig{Concurrent: 2},
+			},
+			provider: deployer.CloudProviderAWS,
+			want:     Estimate{Compute: 24, Monthly: 24},
+		},
+		{
+			name: "kubernetes",
+			egg: deployer.EggConfig{
+				Type:      deployer.RunnerTypeKubernetes,
+				Resources: deployer.ResourceConfig{CPU: 2, Memory: 4096, Disk: 20},
+			},
+			provider: deployer.CloudProviderYandex,
+			want:     Estimate{},
+		},
+		{
+			name: "serverless without concurrency counts one slot",
+			egg: deployer.EggConfig{
+				Type:      deployer.RunnerTypeServerless,
+				Resources: deployer.ResourceConfig{Memory: 1024},
+			},
+			provider: deployer.CloudProviderYandex,
+			want:     Estimate{Compute: 7.2, Monthly: 7.2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DefaultCatalog().Estimate(&tt.egg, tt.provider)
+			if err != nil {
+				t.Fatalf("Estimate() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("Estimate() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateUnknownProvider(t *testing.T) {
+	egg := &deployer.EggConfig{Type: deployer.RunnerTypeVM}
+	if _, err := DefaultCatalog().Estimate(egg, deployer.CloudProviderAzure); err == nil {
+		t.Error("Estimate() succeeded for a provider without pricing")
+	}
+}
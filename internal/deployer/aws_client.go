@@ -2,6 +2,7 @@ package deployer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -9,15 +10,23 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
 )
 
+// ec2StandardVCPUQuotaCode is the Service Quotas code of "Running On-Demand
+// Standard (A, C, D, H, I, M, R, T, Z) instances", counted in vCPUs
+const ec2StandardVCPUQuotaCode = "L-1216C47A"
+
 // AWSClient wraps the AWS SDK for Go v2 for deploying backend infrastructure
 // Note: Individual runner deployment is handled by MotherGoose using OpenTofu
 type AWSClient struct {
+	unimplementedProvisioner
 	cfg      aws.Config
 	lambda   *lambda.Client
 	dynamodb *dynamodb.Client
 	s3       *s3.Client
+	quotas   *servicequotas.Client
 }
 
 // NewAWSClient creates a new AWS client
@@ -32,19 +41,43 @@ func NewAWSClient(ctx context.Context, region string) (*AWSClient, error) {
 		lambda:   lambda.NewFromConfig(cfg),
 		dynamodb: dynamodb.NewFromConfig(cfg),
 		s3:       s3.NewFromConfig(cfg),
+		quotas:   servicequotas.NewFromConfig(cfg),
 	}, nil
 }
 
 // DeployBackendInfrastructure deploys MotherGoose, UglyFox, DynamoDB, and S3 buckets
-func (c *AWSClient) DeployBackendInfrastructure(ctx context.Context) error {
+func (c *AWSClient) DeployBackendInfrastructure(ctx context.Context, cfg *BackendConfig, done func(BackendResource)) error {
 	// TODO: Implement deployment of:
-	// - MotherGoose Lambda function
-	// - UglyFox Lambda function
+	// - MotherGoose and UglyFox Lambda functions
 	// - DynamoDB tables (runners, eggs, jobs, audit_logs, deployment_plans, tofu_versions, runner_metrics)
-	// - S3 buckets (tofu-states, tofu-binaries, tofu-cache)
-	// - API Gateway
+	// - IAM roles, API Gateway, EventBridge schedules
 	// - SQS queues for Celery
-	return fmt.Errorf("not yet implemented")
+	return provisionBackend(ctx, c, cfg, done)
+}
+
+// CreateBucket creates an S3 bucket, enabling versioning if requested
+func (c *AWSClient) CreateBucket(ctx context.Context, b BackendBucket) error {
+	input := &s3.CreateBucketInput{Bucket: aws.String(b.Name)}
+	// us-east-1 is the default location and must not be given explicitly
+	if c.cfg.Region != "us-east-1" {
+		input.CreateBucketConfiguration = &s3types.CreateBucketConfiguration{
+			LocationConstraint: s3types.BucketLocationConstraint(c.cfg.Region),
+		}
+	}
+	if _, err := c.s3.CreateBucket(ctx, input); err != nil {
+		var owned *s3types.BucketAlreadyOwnedByYou
+		if !errors.As(err, &owned) {
+			return err
+		}
+	}
+	if !b.Versioning {
+		return nil
+	}
+	_, err := c.s3.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+		Bucket:                  aws.String(b.Name),
+		VersioningConfiguration: &s3types.VersioningConfiguration{Status: s3types.BucketVersioningStatusEnabled},
+	})
+	return err
 }
 
 // GetStatus retrieves the status of infrastructure resources
@@ -52,3 +85,40 @@ func (c *AWSClient) GetStatus(ctx context.Context, resourceID string) (string, e
 	// TODO: Implement status checking for backend infrastructure
 	return "", fmt.Errorf("not yet implemented")
 }
+
+// Quotas returns the EC2 On-Demand vCPU quota and the Lambda concurrency
+// available to new functions. EC2 does not report vCPU usage here, so only
+// the limit is checked.
+func (c *AWSClient) Quotas(ctx context.Context) ([]Quota, error) {
+	vcpus, err := c.quotas.GetServiceQuota(ctx, &servicequotas.GetServiceQuotaInput{
+		ServiceCode: aws.String("ec2"),
+		QuotaCode:   aws.String(ec2StandardVCPUQuotaCode),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get EC2 vCPU quota: %w", err)
+	}
+	if vcpus.Quota == nil {
+		return nil, fmt.Errorf("EC2 vCPU quota %s not found", ec2StandardVCPUQuotaCode)
+	}
+	settings, err := c.lambda.GetAccountSettings(ctx, &lambda.GetAccountSettingsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Lambda account settings: %w", err)
+	}
+
+	quotas := []Quota{{
+		Kind:  QuotaVCPUs,
+		Name:  "ec2/" + ec2StandardVCPUQuotaCode,
+		Limit: aws.ToFloat64(vcpus.Quota.Value),
+	}}
+	if limit := settings.AccountLimit; limit != nil {
+		concurrency := float64(limit.ConcurrentExecutions)
+		quotas = append(quotas, Quota{
+			Kind:  QuotaServerlessConcurrency,
+			Name:  "lambda/ConcurrentExecutions",
+			Limit: concurrency,
+			// Concurrency reserved by other functions is not available
+			Usage: concurrency - float64(aws.ToInt32(limit.UnreservedConcurrentExecutions)),
+		})
+	}
+	return quotas, nil
+}
@@ -0,0 +1,65 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// defaultAzureResourceGroup is used when AZURE_RESOURCE_GROUP is not set
+const defaultAzureResourceGroup = "gosling"
+
+// AzureClient deploys backend infrastructure to Azure (VMs and Azure Functions)
+// Note: Individual runner deployment is handled by MotherGoose using OpenTofu
+type AzureClient struct {
+	unimplementedProvisioner
+	subscriptionID string
+	resourceGroup  string
+	region         string
+}
+
+// NewAzureClient creates a new Azure client
+func NewAzureClient(ctx context.Context, region string) (*AzureClient, error) {
+	if !parser.IsValidAzureRegion(region) {
+		return nil, fmt.Errorf("unsupported Azure region: %s", region)
+	}
+
+	// Credentials follow the Azure CLI conventions: AZURE_SUBSCRIPTION_ID selects
+	// the subscription, and AZURE_CLIENT_ID/AZURE_TENANT_ID/AZURE_CLIENT_SECRET
+	// or a managed identity authenticate the deployment
+	subscriptionID := os.Getenv("AZURE_SUBSCRIPTION_ID")
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("AZURE_SUBSCRIPTION_ID is not set")
+	}
+
+	resourceGroup := os.Getenv("AZURE_RESOURCE_GROUP")
+	if resourceGroup == "" {
+		resourceGroup = defaultAzureResourceGroup
+	}
+
+	return &AzureClient{
+		subscriptionID: subscriptionID,
+		resourceGroup:  resourceGroup,
+		region:         region,
+	}, nil
+}
+
+// DeployBackendInfrastructure deploys MotherGoose, UglyFox, Cosmos DB, and Blob Storage
+func (c *AzureClient) DeployBackendInfrastructure(ctx context.Context, cfg *BackendConfig, done func(BackendResource)) error {
+	// TODO: Implement deployment of:
+	// - MotherGoose Azure Function
+	// - UglyFox Azure Function
+	// - Cosmos DB tables (runners, eggs, jobs, audit_logs, deployment_plans, tofu_versions, runner_metrics)
+	// - Blob Storage containers (tofu-states, tofu-binaries, tofu-cache)
+	// - API Management
+	// - Service Bus queues for Celery
+	return provisionBackend(ctx, c, cfg, done)
+}
+
+// GetStatus retrieves the status of infrastructure resources
+func (c *AzureClient) GetStatus(ctx context.Context, resourceID string) (string, error) {
+	// TODO: Implement status checking for backend infrastructure
+	return "", fmt.Errorf("not yet implemented")
+}
@@ -0,0 +1,418 @@
+package deployer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// errNotImplemented is returned by provisioning steps a cloud client does not support yet
+var errNotImplemented = errors.New("not yet implemented")
+
+// BackendConfig is the MotherGoose backend infrastructure declared by a
+// mothergoose block
+type BackendConfig struct {
+	APIGateway      BackendAPIGateway
+	Functions       []BackendFunction
+	Queues          []BackendQueue
+	Triggers        []BackendTrigger
+	Database        BackendDatabase
+	Buckets         []BackendBucket
+	ServiceAccounts []BackendServiceAccount
+}
+
+// BackendAPIGateway is the API gateway in front of the MotherGoose API
+type BackendAPIGateway struct {
+	Name        string
+	OpenAPISpec string
+	Auth        *BackendFunction // Webhook authentication function, if any
+}
+
+// BackendFunction is a serverless function or container
+type BackendFunction struct {
+	Role           string // Block that declared it, e.g. fastapi_app
+	Name           string
+	Runtime        string
+	Image          string
+	Command        []string
+	Memory         int // MB
+	Timeout        int // Seconds
+	MinInstances   int
+	MaxInstances   int
+	Env            map[string]string
+	ServiceAccount string
+}
+
+// BackendQueue is a message queue
+type BackendQueue struct {
+	Name              string
+	VisibilityTimeout int // Seconds
+	MessageRetention  int // Seconds
+	MaxReceives       int
+	DeadLetterQueue   string // Name of the queue receiving failed messages
+}
+
+// BackendTrigger calls a function endpoint on a schedule
+type BackendTrigger struct {
+	Name           string
+	Schedule       string
+	TargetFunction string
+	Endpoint       string
+	Method         string
+	ServiceAccount string
+}
+
+// BackendDatabase is the MotherGoose database
+type BackendDatabase struct {
+	Type string // ydb or dynamodb
+	Name string
+	Mode string
+}
+
+// BackendBucket is an object storage bucket
+type BackendBucket struct {
+	Name       string
+	Versioning bool
+}
+
+// BackendServiceAccount is an IAM identity with roles
+type BackendServiceAccount struct {
+	Name  string
+	Roles []string
+}
+
+// backendDatabaseTypes lists the database type each provider supports
+var backendDatabaseTypes = map[CloudProvider]string{
+	CloudProviderYandex: "ydb",
+	CloudProviderAWS:    "dynamodb",
+}
+
+// backendProvisioning lists the resource kinds each provider's client can
+// create; the other steps of its backendProvisioner are unimplemented
+var backendProvisioning = map[CloudProvider][]string{
+	CloudProviderAWS: {"bucket"},
+}
+
+// CheckProvider reports declarations of c that provider cannot provision
+func (c *BackendConfig) CheckProvider(provider CloudProvider) error {
+	dbType := c.Database.Type
+	if dbType == "" {
+		return nil
+	}
+	supported, ok := backendDatabaseTypes[provider]
+	if !ok {
+		return fmt.Errorf("MotherGoose databases are not supported on %s yet", provider)
+	}
+	if supported != dbType {
+		return fmt.Errorf("database type %q is not supported on %s (use %q)", dbType, provider, supported)
+	}
+	return nil
+}
+
+// CheckProvisioning reports the resources of c that the client of provider
+// cannot create yet, so that bootstrap fails before creating anything
+// rather than leaving half a backend behind
+func (c *BackendConfig) CheckProvisioning(provider CloudProvider) error {
+	supported := make(map[string]bool)
+	for _, kind := range backendProvisioning[provider] {
+		supported[kind] = true
+	}
+	var missing []string
+	seen := make(map[string]bool)
+	for _, r := range c.Resources() {
+		if !supported[r.Kind] && !seen[r.Kind] {
+			seen[r.Kind] = true
+			missing = append(missing, r.Kind)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if len(supported) == 0 {
+		return fmt.Errorf("provider %s not supported yet: bootstrap cannot create MotherGoose resources there", provider)
+	}
+	return fmt.Errorf("provider %s not supported yet for %s resources; only %s can be created",
+		provider, strings.Join(missing, ", "), strings.Join(backendProvisioning[provider], ", "))
+}
+
+// ParseMotherGoose parses a mothergoose block into a BackendConfig. The
+// block is expected to have passed parser.ValidateMotherGoose.
+func ParseMotherGoose(block *parser.Block) (*BackendConfig, error) {
+	if block.Type != "mothergoose" {
+		return nil, fmt.Errorf("expected 'mothergoose' block, got '%s'", block.Type)
+	}
+	cfg := &BackendConfig{}
+	var err error
+
+	if accounts, ok := block.GetBlock("service_accounts"); ok {
+		for i := range accounts.Blocks {
+			account := BackendServiceAccount{Name: attrString(&accounts.Blocks[i], "name")}
+			if account.Roles, err = attrStringList(&accounts.Blocks[i], "roles"); err != nil {
+				return nil, err
+			}
+			cfg.ServiceAccounts = append(cfg.ServiceAccounts, account)
+		}
+	}
+
+	if db, ok := block.GetBlock("database"); ok {
+		cfg.Database = BackendDatabase{
+			Type: attrString(db, "type"),
+			Name: attrString(db, "name"),
+			Mode: attrString(db, "mode"),
+		}
+	}
+
+	if queues, ok := block.GetBlock("message_queues"); ok {
+		for i := range queues.Blocks {
+			q := &queues.Blocks[i]
+			cfg.Queues = append(cfg.Queues, BackendQueue{
+				Name:              attrString(q, "name"),
+				VisibilityTimeout: attrInt(q, "visibility_timeout"),
+				MessageRetention:  attrInt(q, "message_retention"),
+				MaxReceives:       attrInt(q, "max_receives"),
+				DeadLetterQueue:   attrString(q, "dead_letter_queue"),
+			})
+		}
+	}
+
+	if storage, ok := block.GetBlock("storage"); ok {
+		for i := range storage.Blocks {
+			b := &storage.Blocks[i]
+			bucket := BackendBucket{Name: attrString(b, "name")}
+			if val, ok := b.GetAttribute("versioning"); ok {
+				if bucket.Versioning, err = val.AsBool(); err != nil {
+					return nil, fmt.Errorf("invalid versioning of bucket %s: %w", bucket.Name, err)
+				}
+			}
+			cfg.Buckets = append(cfg.Buckets, bucket)
+		}
+	}
+
+	for _, role := range parser.MotherGooseFunctions {
+		if fnBlock, ok := block.GetBlock(role); ok {
+			fn, err := parseBackendFunction(fnBlock)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Functions = append(cfg.Functions, *fn)
+		}
+	}
+
+	if gateway, ok := block.GetBlock("api_gateway"); ok {
+		cfg.APIGateway = BackendAPIGateway{
+			Name:        attrString(gateway, "name"),
+			OpenAPISpec: attrString(gateway, "openapi_spec"),
+		}
+		if auth, ok := gateway.GetBlock("auth_function"); ok {
+			if cfg.APIGateway.Auth, err = parseBackendFunction(auth); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if triggers, ok := block.GetBlock("triggers"); ok {
+		for i := range triggers.Blocks {
+			t := &triggers.Blocks[i]
+			cfg.Triggers = append(cfg.Triggers, BackendTrigger{
+				Name:           attrString(t, "name"),
+				Schedule:       attrString(t, "schedule"),
+				TargetFunction: attrString(t, "target_function"),
+				Endpoint:       attrString(t, "endpoint"),
+				Method:         attrString(t, "method"),
+				ServiceAccount: attrString(t, "service_account"),
+			})
+		}
+	}
+
+	return cfg, nil
+}
+
+func parseBackendFunction(block *parser.Block) (*BackendFunction, error) {
+	fn := &BackendFunction{
+		Role:           block.Type,
+		Name:           attrString(block, "name"),
+		Runtime:        attrString(block, "runtime"),
+		Image:          attrString(block, "image"),
+		Memory:         attrInt(block, "memory"),
+		Timeout:        attrInt(block, "timeout"),
+		MinInstances:   attrInt(block, "min_instances"),
+		MaxInstances:   attrInt(block, "max_instances"),
+		ServiceAccount: attrString(block, "service_account"),
+		Env:            make(map[string]string),
+	}
+	var err error
+	if fn.Command, err = attrStringList(block, "command"); err != nil {
+		return nil, err
+	}
+	if envBlock, ok := block.GetBlock("env"); ok {
+		if fn.Env, err = parseEnvironmentBlock(envBlock); err != nil {
+			return nil, err
+		}
+	}
+	return fn, nil
+}
+
+// attrString returns a string attribute, or "" when it is absent
+func attrString(block *parser.Block, name string) string {
+	val, ok := block.GetAttribute(name)
+	if !ok {
+		return ""
+	}
+	s, _ := val.AsString()
+	return s
+}
+
+// attrInt returns a number attribute, or 0 when it is absent
+func attrInt(block *parser.Block, name string) int {
+	val, ok := block.GetAttribute(name)
+	if !ok {
+		return 0
+	}
+	n, _ := val.AsInt()
+	return n
+}
+
+// attrStringList returns a list of strings attribute, or nil when it is absent
+func attrStringList(block *parser.Block, name string) ([]string, error) {
+	val, ok := block.GetAttribute(name)
+	if !ok {
+		return nil, nil
+	}
+	list, err := val.AsList()
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		s, err := item.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		items = append(items, s)
+	}
+	return items, nil
+}
+
+// BackendResource identifies one resource of a BackendConfig
+type BackendResource struct {
+	Kind string // service_account, database, queue, bucket, function, api_gateway or trigger
+	Name string
+}
+
+func (r BackendResource) String() string {
+	return r.Kind + " " + r.Name
+}
+
+// backendStep provisions one resource
+type backendStep struct {
+	resource BackendResource
+	run      func(ctx context.Context, p backendProvisioner) error
+}
+
+// steps returns the provisioning steps in dependency order: identities and
+// storage first, dead-letter queues before the queues using them, functions
+// before the gateway and triggers that call them
+func (c *BackendConfig) steps() []backendStep {
+	var steps []backendStep
+	add := func(kind, name string, run func(ctx context.Context, p backendProvisioner) error) {
+		steps = append(steps, backendStep{resource: BackendResource{Kind: kind, Name: name}, run: run})
+	}
+
+	for _, sa := range c.ServiceAccounts {
+		add("service_account", sa.Name, func(ctx context.Context, p backendProvisioner) error { return p.CreateServiceAccount(ctx, sa) })
+	}
+	if c.Database.Name != "" {
+		db := c.Database
+		add("database", db.Name, func(ctx context.Context, p backendProvisioner) error { return p.CreateDatabase(ctx, db) })
+	}
+	for _, deadLetter := range []bool{true, false} {
+		for _, q := range c.Queues {
+			if (q.DeadLetterQueue == "") != deadLetter {
+				continue
+			}
+			add("queue", q.Name, func(ctx context.Context, p backendProvisioner) error { return p.CreateQueue(ctx, q) })
+		}
+	}
+	for _, b := range c.Buckets {
+		add("bucket", b.Name, func(ctx context.Context, p backendProvisioner) error { return p.CreateBucket(ctx, b) })
+	}
+	functions := c.Functions
+	if c.APIGateway.Auth != nil {
+		functions = append(functions[:len(functions):len(functions)], *c.APIGateway.Auth)
+	}
+	for _, fn := range functions {
+		add("function", fn.Name, func(ctx context.Context, p backendProvisioner) error { return p.DeployFunction(ctx, fn) })
+	}
+	if c.APIGateway.Name != "" {
+		gw := c.APIGateway
+		add("api_gateway", gw.Name, func(ctx context.Context, p backendProvisioner) error { return p.CreateAPIGateway(ctx, gw) })
+	}
+	for _, t := range c.Triggers {
+		add("trigger", t.Name, func(ctx context.Context, p backendProvisioner) error { return p.CreateTrigger(ctx, t) })
+	}
+	return steps
+}
+
+// Resources returns the resources of c in the order they are provisioned
+func (c *BackendConfig) Resources() []BackendResource {
+	steps := c.steps()
+	resources := make([]BackendResource, len(steps))
+	for i, step := range steps {
+		resources[i] = step.resource
+	}
+	return resources
+}
+
+// backendProvisioner creates the resources of a BackendConfig in one cloud
+type backendProvisioner interface {
+	CreateServiceAccount(ctx context.Context, sa BackendServiceAccount) error
+	CreateDatabase(ctx context.Context, db BackendDatabase) error
+	CreateQueue(ctx context.Context, q BackendQueue) error
+	CreateBucket(ctx context.Context, b BackendBucket) error
+	DeployFunction(ctx context.Context, fn BackendFunction) error
+	CreateAPIGateway(ctx context.Context, gw BackendAPIGateway) error
+	CreateTrigger(ctx context.Context, t BackendTrigger) error
+}
+
+// unimplementedProvisioner is embedded by cloud clients so each step can be
+// implemented on its own
+type unimplementedProvisioner struct{}
+
+func (unimplementedProvisioner) CreateServiceAccount(context.Context, BackendServiceAccount) error {
+	return errNotImplemented
+}
+func (unimplementedProvisioner) CreateDatabase(context.Context, BackendDatabase) error {
+	return errNotImplemented
+}
+func (unimplementedProvisioner) CreateQueue(context.Context, BackendQueue) error {
+	return errNotImplemented
+}
+func (unimplementedProvisioner) CreateBucket(context.Context, BackendBucket) error {
+	return errNotImplemented
+}
+func (unimplementedProvisioner) DeployFunction(context.Context, BackendFunction) error {
+	return errNotImplemented
+}
+func (unimplementedProvisioner) CreateAPIGateway(context.Context, BackendAPIGateway) error {
+	return errNotImplemented
+}
+func (unimplementedProvisioner) CreateTrigger(context.Context, BackendTrigger) error {
+	return errNotImplemented
+}
+
+// provisionBackend runs the steps of cfg with p, calling done after each
+// resource is created. It stops at the first failure.
+func provisionBackend(ctx context.Context, p backendProvisioner, cfg *BackendConfig, done func(BackendResource)) error {
+	for _, step := range cfg.steps() {
+		if err := step.run(ctx, p); err != nil {
+			return fmt.Errorf("failed to create %s: %w", step.resource, err)
+		}
+		if done != nil {
+			done(step.resource)
+		}
+	}
+	return nil
+}
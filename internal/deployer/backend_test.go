@@ -0,0 +1,192 @@
+package deployer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+const backendConfig = `
+mothergoose {
+  api_gateway {
+    name = "polar-gosling-api"
+
+    auth_function {
+      name    = "webhook-auth"
+      runtime = "python312"
+      memory  = 128
+    }
+  }
+
+  fastapi_app {
+    name   = "mothergoose-api"
+    image  = "cr.yandex/polar-gosling/mothergoose:latest"
+    memory = 512
+
+    env {
+      LOG_LEVEL = "INFO"
+    }
+  }
+
+  message_queues {
+    webhook_queue {
+      name              = "mothergoose-webhooks"
+      dead_letter_queue = "mothergoose-dlq"
+    }
+
+    dead_letter_queue {
+      name = "mothergoose-dlq"
+    }
+  }
+
+  triggers {
+    git_sync {
+      name            = "git-sync-trigger"
+      schedule        = "*/5 * * * *"
+      target_function = "mothergoose-api"
+    }
+  }
+
+  database {
+    type = "ydb"
+    name = "polar-gosling-db"
+  }
+
+  storage {
+    state_bucket {
+      name       = "polar-gosling-state"
+      versioning = true
+    }
+  }
+
+  service_accounts {
+    mothergoose {
+      name  = "mothergoose-sa"
+      roles = ["ydb.editor", "storage.editor"]
+    }
+  }
+}
+`
+
+func parseBackendConfig(t *testing.T) *BackendConfig {
+	t.Helper()
+	config, err := parser.NewParser().Parse([]byte(backendConfig), "mothergoose.fly")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	cfg, err := ParseMotherGoose(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseMotherGoose() error = %v", err)
+	}
+	return cfg
+}
+
+func TestParseMotherGoose(t *testing.T) {
+	cfg := parseBackendConfig(t)
+
+	if got := cfg.ServiceAccounts[0].Roles; !reflect.DeepEqual(got, []string{"ydb.editor", "storage.editor"}) {
+		t.Errorf("roles = %v", got)
+	}
+	if fn := cfg.Functions[0]; fn.Role != "fastapi_app" || fn.Memory != 512 || fn.Env["LOG_LEVEL"] != "INFO" {
+		t.Errorf("function = %+v", fn)
+	}
+	if cfg.APIGateway.Auth == nil || cfg.APIGateway.Auth.Name != "webhook-auth" {
+		t.Errorf("auth function = %+v", cfg.APIGateway.Auth)
+	}
+	if !cfg.Buckets[0].Versioning {
+		t.Error("bucket versioning not parsed")
+	}
+}
+
+func TestBackendResourcesOrder(t *testing.T) {
+	var got []string
+	for _, r := range parseBackendConfig(t).Resources() {
+		got = append(got, r.String())
+	}
+	want := []string{
+		"service_account mothergoose-sa",
+		"database polar-gosling-db",
+		"queue mothergoose-dlq",
+		"queue mothergoose-webhooks",
+		"bucket polar-gosling-state",
+		"function mothergoose-api",
+		"function webhook-auth",
+		"api_gateway polar-gosling-api",
+		"trigger git-sync-trigger",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resources() = %v, want %v", got, want)
+	}
+}
+
+// bucketProvisioner only supports buckets
+type bucketProvisioner struct {
+	unimplementedProvisioner
+	buckets []string
+}
+
+func (p *bucketProvisioner) CreateBucket(ctx context.Context, b BackendBucket) error {
+	p.buckets = append(p.buckets, b.Name)
+	return nil
+}
+
+func TestProvisionBackend(t *testing.T) {
+	cfg := &BackendConfig{Buckets: []BackendBucket{{Name: "state"}, {Name: "binaries"}}}
+	p := &bucketProvisioner{}
+	var done []string
+	if err := provisionBackend(context.Background(), p, cfg, func(r BackendResource) { done = append(done, r.Name) }); err != nil {
+		t.Fatalf("provisionBackend() error = %v", err)
+	}
+	if !reflect.DeepEqual(p.buckets, []string{"state", "binaries"}) || !reflect.DeepEqual(done, p.buckets) {
+		t.Errorf("created %v, reported %v", p.buckets, done)
+	}
+
+	cfg.Queues = []BackendQueue{{Name: "webhooks"}}
+	err := provisionBackend(context.Background(), p, cfg, nil)
+	if !errors.Is(err, errNotImplemented) || !strings.Contains(err.Error(), "queue webhooks") {
+		t.Errorf("provisionBackend() error = %v, want the queue step to fail", err)
+	}
+}
+
+func TestBackendCheckProvider(t *testing.T) {
+	cfg := &BackendConfig{Database: BackendDatabase{Type: "ydb", Name: "db"}}
+	if err := cfg.CheckProvider(CloudProviderYandex); err != nil {
+		t.Errorf("CheckProvider(yandex) error = %v", err)
+	}
+	if err := cfg.CheckProvider(CloudProviderAWS); err == nil {
+		t.Error("CheckProvider(aws) accepted a YDB database")
+	}
+	if err := cfg.CheckProvider(CloudProviderAzure); err == nil || !strings.Contains(err.Error(), "not supported on azure yet") {
+		t.Errorf("CheckProvider(azure) error = %v, want databases rejected on Azure", err)
+	}
+}
+
+func TestBackendCheckProvisioning(t *testing.T) {
+	buckets := &BackendConfig{Buckets: []BackendBucket{{Name: "state"}}}
+	if err := buckets.CheckProvisioning(CloudProviderAWS); err != nil {
+		t.Errorf("CheckProvisioning(aws) of buckets error = %v", err)
+	}
+
+	cfg := parseBackendConfig(t)
+	err := cfg.CheckProvisioning(CloudProviderAWS)
+	if err == nil || !strings.Contains(err.Error(), "service_account, database, queue, function, api_gateway, trigger") {
+		t.Errorf("CheckProvisioning(aws) error = %v, want every kind but bucket reported", err)
+	}
+	for _, provider := range []CloudProvider{CloudProviderYandex, CloudProviderAzure} {
+		if err := buckets.CheckProvisioning(provider); err == nil || !strings.Contains(err.Error(), "provider "+string(provider)+" not supported yet") {
+			t.Errorf("CheckProvisioning(%s) error = %v", provider, err)
+		}
+	}
+
+	// Nothing is created when a step is unsupported
+	d := &Deployer{}
+	if err := d.DeployBackendInfrastructure(context.Background(), CloudProviderYandex, "ru-central1-a", buckets, func(BackendResource) {
+		t.Error("a resource was created")
+	}); err == nil {
+		t.Error("DeployBackendInfrastructure(yandex) succeeded")
+	}
+}
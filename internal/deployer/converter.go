@@ -2,6 +2,7 @@ package deployer
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/polar-gosling/gosling/internal/parser"
@@ -21,13 +22,25 @@ func NewConverter() *Converter {
 type CloudInfo struct {
 	Provider string
 	Region   string
+	Network  NetworkInfo
+}
+
+// NetworkInfo represents the network block of a cloud block from parser
+type NetworkInfo struct {
+	VPCID          string
+	SubnetID       string
+	SecurityGroups []string
+	PublicIP       *bool // nil when not set
 }
 
 // ResourceInfo represents resource configuration from parser
 type ResourceInfo struct {
-	CPU    int
-	Memory int
-	Disk   int
+	CPU      int
+	Memory   int
+	Disk     int
+	GPU      int
+	GPUType  string
+	Platform string
 }
 
 // RunnerInfo represents runner configuration from parser
@@ -35,12 +48,48 @@ type RunnerInfo struct {
 	Tags        []string
 	Concurrent  int
 	IdleTimeout string
+	JobTimeout  string // Serverless runners only
+	Image       string // VM runners only
+	ImageID     string // VM runners only
+	OS          string
+	Arch        string
+	Privileged  bool
+	DockerDinD  bool
+	Cache       *CacheInfo // Nil without a cache block
+}
+
+// CacheInfo represents the cache block of a runner from parser
+type CacheInfo struct {
+	Type              string
+	Bucket            string
+	Path              string
+	ServerAddress     string
+	CredentialsSecret string
+	Shared            bool
+}
+
+// AutoscalingInfo represents autoscaling configuration from parser
+type AutoscalingInfo struct {
+	Min              int
+	Max              int
+	ScaleUpThreshold int    // Zero when not set
+	ScaleDownDelay   string // Empty when not set
+}
+
+// KubernetesInfo represents the kubernetes block of an egg from parser
+type KubernetesInfo struct {
+	Namespace        string
+	NodeSelector     map[string]string
+	KubeconfigSecret string
 }
 
 // GitLabInfo represents GitLab configuration from parser
 type GitLabInfo struct {
 	ProjectID   int
+	GroupID     int
+	ServerName  string
 	TokenSecret string
+	CACert      string
 }
 
 // RepositoryInfo represents a repository in an EggsBucket from parser
@@ -58,6 +107,10 @@ type ParsedEggConfig struct {
 	Runner      RunnerInfo
 	GitLab      GitLabInfo
 	Environment map[string]string
+	Autoscaling *AutoscalingInfo // Nil without an autoscaling block
+	Kubernetes  *KubernetesInfo  // Nil without a kubernetes block
+	// Positions locates the fields in the .fly file for error messages
+	Positions Positions
 }
 
 // ParsedEggsBucketConfig represents a parsed EggsBucket configuration
@@ -69,6 +122,45 @@ type ParsedEggsBucketConfig struct {
 	Runner       RunnerInfo
 	Repositories []RepositoryInfo
 	Environment  map[string]string
+	Kubernetes   *KubernetesInfo // Nil without a kubernetes block
+	// Positions locates the fields in the .fly file for error messages
+	Positions Positions
+}
+
+// Positions records where the blocks and attributes of a parsed Egg or
+// EggsBucket are set, keyed by their path within it: "" for the egg block
+// itself, "runner" for a nested block, "runner.idle_timeout" for an
+// attribute and "repositories.<repo>.gitlab.project_id" inside a repo.
+type Positions map[string]parser.Position
+
+// record adds block and its attributes under path
+func (p Positions) record(path string, block *parser.Block) {
+	p[path] = block.Position
+	prefix := path
+	if prefix != "" {
+		prefix += "."
+	}
+	for name, val := range block.Attributes {
+		p[prefix+name] = val.Position
+	}
+}
+
+// errorf formats an error prefixed with the position of path, or of the
+// closest enclosing block when path is not set
+func (p Positions) errorf(path, format string, args ...any) error {
+	for {
+		if pos, ok := p[path]; ok {
+			return fmt.Errorf("%s: "+format, append([]any{pos}, args...)...)
+		}
+		if path == "" {
+			return fmt.Errorf(format, args...)
+		}
+		if i := strings.LastIndex(path, "."); i >= 0 {
+			path = path[:i]
+		} else {
+			path = ""
+		}
+	}
 }
 
 // ParseEgg parses an Egg block into a ParsedEggConfig
@@ -78,19 +170,24 @@ func ParseEgg(block *parser.Block) (*ParsedEggConfig, error) {
 	}
 
 	if len(block.Labels) == 0 {
-		return nil, fmt.Errorf("egg block must have a name label")
+		return nil, fmt.Errorf("%s: egg block must have a name label", block.Position)
 	}
 
 	egg := &ParsedEggConfig{
 		Name:        block.Labels[0],
 		Environment: make(map[string]string),
+		Positions:   make(Positions),
+	}
+	egg.Positions.record("", block)
+	for i := range block.Blocks {
+		egg.Positions.record(block.Blocks[i].Type, &block.Blocks[i])
 	}
 
 	// Parse type
 	if typeVal, ok := block.GetAttribute("type"); ok {
 		typeStr, err := typeVal.AsString()
 		if err != nil {
-			return nil, fmt.Errorf("invalid type: %w", err)
+			return nil, fmt.Errorf("%s: invalid type: %w", typeVal.Position, err)
 		}
 		egg.Type = typeStr
 	}
@@ -140,6 +237,24 @@ func ParseEgg(block *parser.Block) (*ParsedEggConfig, error) {
 		egg.Environment = env
 	}
 
+	// Parse autoscaling block
+	if autoscalingBlock, ok := block.GetBlock("autoscaling"); ok {
+		autoscaling, err := parseAutoscalingBlock(autoscalingBlock)
+		if err != nil {
+			return nil, err
+		}
+		egg.Autoscaling = autoscaling
+	}
+
+	// Parse kubernetes block
+	if kubernetesBlock, ok := block.GetBlock("kubernetes"); ok {
+		kubernetes, err := parseKubernetesBlock(kubernetesBlock)
+		if err != nil {
+			return nil, err
+		}
+		egg.Kubernetes = kubernetes
+	}
+
 	return egg, nil
 }
 
@@ -150,19 +265,36 @@ func ParseEggsBucket(block *parser.Block) (*ParsedEggsBucketConfig, error) {
 	}
 
 	if len(block.Labels) == 0 {
-		return nil, fmt.Errorf("eggsbucket block must have a name label")
+		return nil, fmt.Errorf("%s: eggsbucket block must have a name label", block.Position)
 	}
 
 	bucket := &ParsedEggsBucketConfig{
 		Name:        block.Labels[0],
 		Environment: make(map[string]string),
+		Positions:   make(Positions),
+	}
+	bucket.Positions.record("", block)
+	for i := range block.Blocks {
+		bucket.Positions.record(block.Blocks[i].Type, &block.Blocks[i])
+	}
+	if repositories, ok := block.GetBlock("repositories"); ok {
+		for _, repo := range repositories.GetBlocks("repo") {
+			if len(repo.Labels) == 0 {
+				continue
+			}
+			path := "repositories." + repo.Labels[0]
+			bucket.Positions.record(path, &repo)
+			if gitlab, ok := repo.GetBlock("gitlab"); ok {
+				bucket.Positions.record(path+".gitlab", gitlab)
+			}
+		}
 	}
 
 	// Parse type
 	if typeVal, ok := block.GetAttribute("type"); ok {
 		typeStr, err := typeVal.AsString()
 		if err != nil {
-			return nil, fmt.Errorf("invalid type: %w", err)
+			return nil, fmt.Errorf("%s: invalid type: %w", typeVal.Position, err)
 		}
 		bucket.Type = typeStr
 	}
@@ -212,9 +344,65 @@ func ParseEggsBucket(block *parser.Block) (*ParsedEggsBucketConfig, error) {
 		bucket.Environment = env
 	}
 
+	// Parse kubernetes block
+	if kubernetesBlock, ok := block.GetBlock("kubernetes"); ok {
+		kubernetes, err := parseKubernetesBlock(kubernetesBlock)
+		if err != nil {
+			return nil, err
+		}
+		bucket.Kubernetes = kubernetes
+	}
+
 	return bucket, nil
 }
 
+// ParseJob parses a job block into a JobConfig
+func ParseJob(block *parser.Block) (*JobConfig, error) {
+	if block.Type != "job" {
+		return nil, fmt.Errorf("expected 'job' block, got '%s'", block.Type)
+	}
+
+	if len(block.Labels) == 0 {
+		return nil, fmt.Errorf("%s: job block must have a name label", block.Position)
+	}
+
+	job := &JobConfig{Name: block.Labels[0]}
+
+	if scheduleVal, ok := block.GetAttribute("schedule"); ok {
+		schedule, err := scheduleVal.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid schedule: %w", scheduleVal.Position, err)
+		}
+		job.Schedule = schedule
+	}
+
+	if scriptVal, ok := block.GetAttribute("script"); ok {
+		script, err := scriptVal.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid script: %w", scriptVal.Position, err)
+		}
+		job.Script = script
+	}
+
+	// The runner block of a job only selects a runner by type and tags
+	if runnerBlock, ok := block.GetBlock("runner"); ok {
+		if typeVal, ok := runnerBlock.GetAttribute("type"); ok {
+			typeStr, err := typeVal.AsString()
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid type: %w", typeVal.Position, err)
+			}
+			job.Runner.Type = RunnerType(typeStr)
+		}
+		runner, err := parseRunnerBlock(runnerBlock)
+		if err != nil {
+			return nil, err
+		}
+		job.Runner.Tags = runner.Tags
+	}
+
+	return job, nil
+}
+
 // Helper functions to parse nested blocks
 
 func parseCloudBlock(block *parser.Block) (CloudInfo, error) {
@@ -223,7 +411,7 @@ func parseCloudBlock(block *parser.Block) (CloudInfo, error) {
 	if providerVal, ok := block.GetAttribute("provider"); ok {
 		provider, err := providerVal.AsString()
 		if err != nil {
-			return cloud, fmt.Errorf("invalid provider: %w", err)
+			return cloud, fmt.Errorf("%s: invalid provider: %w", providerVal.Position, err)
 		}
 		cloud.Provider = provider
 	}
@@ -231,21 +419,75 @@ func parseCloudBlock(block *parser.Block) (CloudInfo, error) {
 	if regionVal, ok := block.GetAttribute("region"); ok {
 		region, err := regionVal.AsString()
 		if err != nil {
-			return cloud, fmt.Errorf("invalid region: %w", err)
+			return cloud, fmt.Errorf("%s: invalid region: %w", regionVal.Position, err)
 		}
 		cloud.Region = region
 	}
 
+	if networkBlock, ok := block.GetBlock("network"); ok {
+		network, err := parseNetworkBlock(networkBlock)
+		if err != nil {
+			return cloud, err
+		}
+		cloud.Network = network
+	}
+
 	return cloud, nil
 }
 
+func parseNetworkBlock(block *parser.Block) (NetworkInfo, error) {
+	network := NetworkInfo{}
+
+	if vpcVal, ok := block.GetAttribute("vpc_id"); ok {
+		vpcID, err := vpcVal.AsString()
+		if err != nil {
+			return network, fmt.Errorf("%s: invalid vpc_id: %w", vpcVal.Position, err)
+		}
+		network.VPCID = vpcID
+	}
+
+	if subnetVal, ok := block.GetAttribute("subnet_id"); ok {
+		subnetID, err := subnetVal.AsString()
+		if err != nil {
+			return network, fmt.Errorf("%s: invalid subnet_id: %w", subnetVal.Position, err)
+		}
+		network.SubnetID = subnetID
+	}
+
+	if groupsVal, ok := block.GetAttribute("security_groups"); ok {
+		groupsList, err := groupsVal.AsList()
+		if err != nil {
+			return network, fmt.Errorf("%s: invalid security_groups: %w", groupsVal.Position, err)
+		}
+		groups := make([]string, len(groupsList))
+		for i, groupVal := range groupsList {
+			group, err := groupVal.AsString()
+			if err != nil {
+				return network, fmt.Errorf("%s: invalid security group at index %d: %w", groupVal.Position, i, err)
+			}
+			groups[i] = group
+		}
+		network.SecurityGroups = groups
+	}
+
+	if publicIPVal, ok := block.GetAttribute("public_ip"); ok {
+		publicIP, err := publicIPVal.AsBool()
+		if err != nil {
+			return network, fmt.Errorf("%s: invalid public_ip: %w", publicIPVal.Position, err)
+		}
+		network.PublicIP = &publicIP
+	}
+
+	return network, nil
+}
+
 func parseResourcesBlock(block *parser.Block) (ResourceInfo, error) {
 	resources := ResourceInfo{}
 
 	if cpuVal, ok := block.GetAttribute("cpu"); ok {
 		cpu, err := cpuVal.AsInt()
 		if err != nil {
-			return resources, fmt.Errorf("invalid cpu: %w", err)
+			return resources, fmt.Errorf("%s: invalid cpu: %w", cpuVal.Position, err)
 		}
 		resources.CPU = cpu
 	}
@@ -253,7 +495,7 @@ func parseResourcesBlock(block *parser.Block) (ResourceInfo, error) {
 	if memoryVal, ok := block.GetAttribute("memory"); ok {
 		memory, err := memoryVal.AsInt()
 		if err != nil {
-			return resources, fmt.Errorf("invalid memory: %w", err)
+			return resources, fmt.Errorf("%s: invalid memory: %w", memoryVal.Position, err)
 		}
 		resources.Memory = memory
 	}
@@ -261,11 +503,35 @@ func parseResourcesBlock(block *parser.Block) (ResourceInfo, error) {
 	if diskVal, ok := block.GetAttribute("disk"); ok {
 		disk, err := diskVal.AsInt()
 		if err != nil {
-			return resources, fmt.Errorf("invalid disk: %w", err)
+			return resources, fmt.Errorf("%s: invalid disk: %w", diskVal.Position, err)
 		}
 		resources.Disk = disk
 	}
 
+	if gpuVal, ok := block.GetAttribute("gpu"); ok {
+		gpu, err := gpuVal.AsInt()
+		if err != nil {
+			return resources, fmt.Errorf("%s: invalid gpu: %w", gpuVal.Position, err)
+		}
+		resources.GPU = gpu
+	}
+
+	if gpuTypeVal, ok := block.GetAttribute("gpu_type"); ok {
+		gpuType, err := gpuTypeVal.AsString()
+		if err != nil {
+			return resources, fmt.Errorf("%s: invalid gpu_type: %w", gpuTypeVal.Position, err)
+		}
+		resources.GPUType = gpuType
+	}
+
+	if platformVal, ok := block.GetAttribute("platform"); ok {
+		platform, err := platformVal.AsString()
+		if err != nil {
+			return resources, fmt.Errorf("%s: invalid platform: %w", platformVal.Position, err)
+		}
+		resources.Platform = platform
+	}
+
 	return resources, nil
 }
 
@@ -275,13 +541,13 @@ func parseRunnerBlock(block *parser.Block) (RunnerInfo, error) {
 	if tagsVal, ok := block.GetAttribute("tags"); ok {
 		tagsList, err := tagsVal.AsList()
 		if err != nil {
-			return runner, fmt.Errorf("invalid tags: %w", err)
+			return runner, fmt.Errorf("%s: invalid tags: %w", tagsVal.Position, err)
 		}
 		tags := make([]string, len(tagsList))
 		for i, tagVal := range tagsList {
 			tag, err := tagVal.AsString()
 			if err != nil {
-				return runner, fmt.Errorf("invalid tag at index %d: %w", i, err)
+				return runner, fmt.Errorf("%s: invalid tag at index %d: %w", tagVal.Position, i, err)
 			}
 			tags[i] = tag
 		}
@@ -291,7 +557,7 @@ func parseRunnerBlock(block *parser.Block) (RunnerInfo, error) {
 	if concurrentVal, ok := block.GetAttribute("concurrent"); ok {
 		concurrent, err := concurrentVal.AsInt()
 		if err != nil {
-			return runner, fmt.Errorf("invalid concurrent: %w", err)
+			return runner, fmt.Errorf("%s: invalid concurrent: %w", concurrentVal.Position, err)
 		}
 		runner.Concurrent = concurrent
 	}
@@ -299,33 +565,210 @@ func parseRunnerBlock(block *parser.Block) (RunnerInfo, error) {
 	if idleTimeoutVal, ok := block.GetAttribute("idle_timeout"); ok {
 		idleTimeout, err := idleTimeoutVal.AsString()
 		if err != nil {
-			return runner, fmt.Errorf("invalid idle_timeout: %w", err)
+			return runner, fmt.Errorf("%s: invalid idle_timeout: %w", idleTimeoutVal.Position, err)
 		}
 		runner.IdleTimeout = idleTimeout
 	}
 
+	if jobTimeoutVal, ok := block.GetAttribute("job_timeout"); ok {
+		jobTimeout, err := jobTimeoutVal.AsString()
+		if err != nil {
+			return runner, fmt.Errorf("%s: invalid job_timeout: %w", jobTimeoutVal.Position, err)
+		}
+		runner.JobTimeout = jobTimeout
+	}
+
+	if imageVal, ok := block.GetAttribute("image"); ok {
+		image, err := imageVal.AsString()
+		if err != nil {
+			return runner, fmt.Errorf("%s: invalid image: %w", imageVal.Position, err)
+		}
+		runner.Image = image
+	}
+
+	if imageIDVal, ok := block.GetAttribute("image_id"); ok {
+		imageID, err := imageIDVal.AsString()
+		if err != nil {
+			return runner, fmt.Errorf("%s: invalid image_id: %w", imageIDVal.Position, err)
+		}
+		runner.ImageID = imageID
+	}
+
+	if osVal, ok := block.GetAttribute("os"); ok {
+		runnerOS, err := osVal.AsString()
+		if err != nil {
+			return runner, fmt.Errorf("%s: invalid os: %w", osVal.Position, err)
+		}
+		runner.OS = runnerOS
+	}
+
+	if archVal, ok := block.GetAttribute("arch"); ok {
+		arch, err := archVal.AsString()
+		if err != nil {
+			return runner, fmt.Errorf("%s: invalid arch: %w", archVal.Position, err)
+		}
+		runner.Arch = arch
+	}
+
+	for name, dst := range map[string]*bool{
+		"privileged":  &runner.Privileged,
+		"docker_dind": &runner.DockerDinD,
+	} {
+		if val, ok := block.GetAttribute(name); ok {
+			b, err := val.AsBool()
+			if err != nil {
+				return runner, fmt.Errorf("%s: invalid %s: %w", val.Position, name, err)
+			}
+			*dst = b
+		}
+	}
+
+	if cacheBlock, ok := block.GetBlock("cache"); ok {
+		cache, err := parseCacheBlock(cacheBlock)
+		if err != nil {
+			return runner, err
+		}
+		runner.Cache = cache
+	}
+
 	return runner, nil
 }
 
+func parseCacheBlock(block *parser.Block) (*CacheInfo, error) {
+	cache := &CacheInfo{}
+
+	for name, dst := range map[string]*string{
+		"type":               &cache.Type,
+		"bucket":             &cache.Bucket,
+		"path":               &cache.Path,
+		"server_address":     &cache.ServerAddress,
+		"credentials_secret": &cache.CredentialsSecret,
+	} {
+		if val, ok := block.GetAttribute(name); ok {
+			str, err := val.AsString()
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid cache %s: %w", val.Position, name, err)
+			}
+			*dst = str
+		}
+	}
+
+	if sharedVal, ok := block.GetAttribute("shared"); ok {
+		shared, err := sharedVal.AsBool()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid cache shared: %w", sharedVal.Position, err)
+		}
+		cache.Shared = shared
+	}
+
+	return cache, nil
+}
+
+func parseAutoscalingBlock(block *parser.Block) (*AutoscalingInfo, error) {
+	autoscaling := &AutoscalingInfo{}
+
+	for name, dst := range map[string]*int{
+		"min":                &autoscaling.Min,
+		"max":                &autoscaling.Max,
+		"scale_up_threshold": &autoscaling.ScaleUpThreshold,
+	} {
+		if val, ok := block.GetAttribute(name); ok {
+			n, err := val.AsInt()
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid %s: %w", val.Position, name, err)
+			}
+			*dst = n
+		}
+	}
+
+	if delayVal, ok := block.GetAttribute("scale_down_delay"); ok {
+		delay, err := delayVal.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid scale_down_delay: %w", delayVal.Position, err)
+		}
+		autoscaling.ScaleDownDelay = delay
+	}
+
+	return autoscaling, nil
+}
+
+func parseKubernetesBlock(block *parser.Block) (*KubernetesInfo, error) {
+	kubernetes := &KubernetesInfo{}
+
+	for name, dst := range map[string]*string{
+		"namespace":         &kubernetes.Namespace,
+		"kubeconfig_secret": &kubernetes.KubeconfigSecret,
+	} {
+		if val, ok := block.GetAttribute(name); ok {
+			str, err := val.AsString()
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid %s: %w", val.Position, name, err)
+			}
+			*dst = str
+		}
+	}
+
+	if selectorVal, ok := block.GetAttribute("node_selector"); ok {
+		labels, err := selectorVal.AsMap()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid node_selector: %w", selectorVal.Position, err)
+		}
+		kubernetes.NodeSelector = make(map[string]string, len(labels))
+		for label, labelVal := range labels {
+			value, err := labelVal.AsString()
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid node_selector label %q: %w", labelVal.Position, label, err)
+			}
+			kubernetes.NodeSelector[label] = value
+		}
+	}
+
+	return kubernetes, nil
+}
+
 func parseGitLabBlock(block *parser.Block) (GitLabInfo, error) {
 	gitlab := GitLabInfo{}
 
 	if projectIDVal, ok := block.GetAttribute("project_id"); ok {
 		projectID, err := projectIDVal.AsInt()
 		if err != nil {
-			return gitlab, fmt.Errorf("invalid project_id: %w", err)
+			return gitlab, fmt.Errorf("%s: invalid project_id: %w", projectIDVal.Position, err)
 		}
 		gitlab.ProjectID = projectID
 	}
 
+	if groupIDVal, ok := block.GetAttribute("group_id"); ok {
+		groupID, err := groupIDVal.AsInt()
+		if err != nil {
+			return gitlab, fmt.Errorf("%s: invalid group_id: %w", groupIDVal.Position, err)
+		}
+		gitlab.GroupID = groupID
+	}
+
+	if serverNameVal, ok := block.GetAttribute("server_name"); ok {
+		serverName, err := serverNameVal.AsString()
+		if err != nil {
+			return gitlab, fmt.Errorf("%s: invalid server_name: %w", serverNameVal.Position, err)
+		}
+		gitlab.ServerName = serverName
+	}
+
 	if tokenSecretVal, ok := block.GetAttribute("token_secret"); ok {
 		tokenSecret, err := tokenSecretVal.AsString()
 		if err != nil {
-			return gitlab, fmt.Errorf("invalid token_secret: %w", err)
+			return gitlab, fmt.Errorf("%s: invalid token_secret: %w", tokenSecretVal.Position, err)
 		}
 		gitlab.TokenSecret = tokenSecret
 	}
 
+	if caCertVal, ok := block.GetAttribute("ca_cert"); ok {
+		caCert, err := caCertVal.AsString()
+		if err != nil {
+			return gitlab, fmt.Errorf("%s: invalid ca_cert: %w", caCertVal.Position, err)
+		}
+		gitlab.CACert = caCert
+	}
+
 	return gitlab, nil
 }
 
@@ -335,7 +778,7 @@ func parseEnvironmentBlock(block *parser.Block) (map[string]string, error) {
 	for key, val := range block.Attributes {
 		strVal, err := val.AsString()
 		if err != nil {
-			return nil, fmt.Errorf("invalid environment variable %s: %w", key, err)
+			return nil, fmt.Errorf("%s: invalid environment variable %s: %w", val.Position, key, err)
 		}
 		env[key] = strVal
 	}
@@ -349,7 +792,7 @@ func parseRepositoriesBlock(block *parser.Block) ([]RepositoryInfo, error) {
 
 	for i, repoBlock := range repoBlocks {
 		if len(repoBlock.Labels) == 0 {
-			return nil, fmt.Errorf("repo block must have a name label")
+			return nil, fmt.Errorf("%s: repo block must have a name label", repoBlock.Position)
 		}
 
 		repo := RepositoryInfo{
@@ -374,66 +817,100 @@ func parseRepositoriesBlock(block *parser.Block) ([]RepositoryInfo, error) {
 // EggToVMConfig converts a parsed Egg configuration to a VM deployment configuration
 func (c *Converter) EggToVMConfig(egg *ParsedEggConfig) (*VMConfig, error) {
 	if egg.Type != "vm" {
-		return nil, fmt.Errorf("egg type must be 'vm', got '%s'", egg.Type)
+		return nil, egg.Positions.errorf("type", "egg type must be 'vm', got '%s'", egg.Type)
 	}
 
 	// Parse cloud provider
 	provider, err := parseCloudProvider(egg.Cloud.Provider)
 	if err != nil {
-		return nil, err
+		return nil, egg.Positions.errorf("cloud.provider", "%w", err)
 	}
 
 	// Parse idle timeout
 	idleTimeout, err := time.ParseDuration(egg.Runner.IdleTimeout)
 	if err != nil {
-		return nil, fmt.Errorf("invalid idle timeout: %w", err)
+		return nil, egg.Positions.errorf("runner.idle_timeout", "invalid idle timeout: %w", err)
+	}
+
+	vmSize, err := vmSizeFor(provider, egg.Resources.CPU, egg.Resources.Memory)
+	if err != nil {
+		return nil, egg.Positions.errorf("resources", "%w", err)
+	}
+
+	resources, err := vmResources(egg.Resources, provider, egg.Positions)
+	if err != nil {
+		return nil, err
+	}
+
+	autoscaling, err := autoscalingConfig(egg.Autoscaling, egg.Positions)
+	if err != nil {
+		return nil, err
 	}
 
+	runner := RunnerConfig{
+		Tags:        egg.Runner.Tags,
+		Concurrent:  egg.Runner.Concurrent,
+		IdleTimeout: idleTimeout,
+		Image:       egg.Runner.Image,
+		ImageID:     egg.Runner.ImageID,
+		OS:          egg.Runner.OS,
+		Arch:        egg.Runner.Arch,
+		Privileged:  egg.Runner.Privileged,
+		DockerDinD:  egg.Runner.DockerDinD,
+		Cache:       cacheConfig(egg.Runner.Cache),
+	}
 	return &VMConfig{
 		EggName: egg.Name,
 		Cloud: CloudConfig{
 			Provider: provider,
 			Region:   egg.Cloud.Region,
 		},
-		Resources: ResourceConfig{
-			CPU:    egg.Resources.CPU,
-			Memory: egg.Resources.Memory,
-			Disk:   egg.Resources.Disk,
-		},
-		Runner: RunnerConfig{
-			Tags:        egg.Runner.Tags,
-			Concurrent:  egg.Runner.Concurrent,
-			IdleTimeout: idleTimeout,
-		},
+		Resources: resources,
+		VMSize:    vmSize,
+		Network:   vmNetwork(egg.Cloud.Network),
+		Runner:    runner,
 		GitLab: GitLabConfig{
 			ProjectID:   egg.GitLab.ProjectID,
+			GroupID:     egg.GitLab.GroupID,
+			ServerName:  egg.GitLab.ServerName,
 			TokenSecret: egg.GitLab.TokenSecret,
+			CACert:      egg.GitLab.CACert,
 		},
-		Environment: egg.Environment,
+		Environment: runnerEnvironment(egg.Environment, runner, "docker"),
+		Autoscaling: autoscaling,
 	}, nil
 }
 
 // EggToServerlessConfig converts a parsed Egg configuration to a serverless deployment configuration
 func (c *Converter) EggToServerlessConfig(egg *ParsedEggConfig) (*ServerlessConfig, error) {
 	if egg.Type != "serverless" {
-		return nil, fmt.Errorf("egg type must be 'serverless', got '%s'", egg.Type)
+		return nil, egg.Positions.errorf("type", "egg type must be 'serverless', got '%s'", egg.Type)
 	}
 
 	// Parse cloud provider
 	provider, err := parseCloudProvider(egg.Cloud.Provider)
 	if err != nil {
-		return nil, err
+		return nil, egg.Positions.errorf("cloud.provider", "%w", err)
 	}
 
 	// Parse idle timeout
 	idleTimeout, err := time.ParseDuration(egg.Runner.IdleTimeout)
 	if err != nil {
-		return nil, fmt.Errorf("invalid idle timeout: %w", err)
+		return nil, egg.Positions.errorf("runner.idle_timeout", "invalid idle timeout: %w", err)
 	}
 
-	// Serverless runners have a maximum timeout of 60 minutes
-	timeout := 60 * time.Minute
+	timeout, err := serverlessJobTimeout(egg.Runner, egg.Cloud.Provider, egg.Positions)
+	if err != nil {
+		return nil, err
+	}
 
+	runner := RunnerConfig{
+		Tags:        egg.Runner.Tags,
+		Concurrent:  egg.Runner.Concurrent,
+		IdleTimeout: idleTimeout,
+		Arch:        egg.Runner.Arch,
+		Cache:       cacheConfig(egg.Runner.Cache),
+	}
 	return &ServerlessConfig{
 		EggName: egg.Name,
 		Cloud: CloudConfig{
@@ -445,36 +922,116 @@ func (c *Converter) EggToServerlessConfig(egg *ParsedEggConfig) (*ServerlessConf
 			Memory: egg.Resources.Memory,
 			Disk:   egg.Resources.Disk,
 		},
-		Runner: RunnerConfig{
-			Tags:        egg.Runner.Tags,
-			Concurrent:  egg.Runner.Concurrent,
-			IdleTimeout: idleTimeout,
-		},
+		Runner: runner,
 		GitLab: GitLabConfig{
 			ProjectID:   egg.GitLab.ProjectID,
+			GroupID:     egg.GitLab.GroupID,
+			ServerName:  egg.GitLab.ServerName,
 			TokenSecret: egg.GitLab.TokenSecret,
+			CACert:      egg.GitLab.CACert,
 		},
-		Environment: egg.Environment,
+		Environment: runnerEnvironment(egg.Environment, runner, ""),
 		Timeout:     timeout,
 	}, nil
 }
 
+// EggToK8sConfig converts a parsed Egg configuration to the deployment of a
+// kubernetes runner
+func (c *Converter) EggToK8sConfig(egg *ParsedEggConfig) (*K8sConfig, error) {
+	if egg.Type != "kubernetes" {
+		return nil, egg.Positions.errorf("type", "egg type must be 'kubernetes', got '%s'", egg.Type)
+	}
+
+	// Parse cloud provider
+	provider, err := parseCloudProvider(egg.Cloud.Provider)
+	if err != nil {
+		return nil, egg.Positions.errorf("cloud.provider", "%w", err)
+	}
+
+	// Parse idle timeout
+	idleTimeout, err := time.ParseDuration(egg.Runner.IdleTimeout)
+	if err != nil {
+		return nil, egg.Positions.errorf("runner.idle_timeout", "invalid idle timeout: %w", err)
+	}
+
+	kubernetes, err := kubernetesConfig(egg.Kubernetes, egg.Runner, egg.Positions)
+	if err != nil {
+		return nil, err
+	}
+
+	runner := RunnerConfig{
+		Tags:        egg.Runner.Tags,
+		Concurrent:  egg.Runner.Concurrent,
+		IdleTimeout: idleTimeout,
+		OS:          egg.Runner.OS,
+		Arch:        egg.Runner.Arch,
+		Privileged:  egg.Runner.Privileged,
+		DockerDinD:  egg.Runner.DockerDinD,
+		Cache:       cacheConfig(egg.Runner.Cache),
+	}
+	return &K8sConfig{
+		EggName: egg.Name,
+		Cloud: CloudConfig{
+			Provider: provider,
+			Region:   egg.Cloud.Region,
+		},
+		Resources: ResourceConfig{
+			CPU:    egg.Resources.CPU,
+			Memory: egg.Resources.Memory,
+			Disk:   egg.Resources.Disk,
+		},
+		Kubernetes: kubernetes,
+		Runner:     runner,
+		GitLab: GitLabConfig{
+			ProjectID:   egg.GitLab.ProjectID,
+			GroupID:     egg.GitLab.GroupID,
+			ServerName:  egg.GitLab.ServerName,
+			TokenSecret: egg.GitLab.TokenSecret,
+			CACert:      egg.GitLab.CACert,
+		},
+		Environment: runnerEnvironment(egg.Environment, runner, "kubernetes"),
+	}, nil
+}
+
 // EggsBucketToVMConfigs converts a parsed EggsBucket configuration to multiple VM deployment configurations
 func (c *Converter) EggsBucketToVMConfigs(bucket *ParsedEggsBucketConfig) ([]*VMConfig, error) {
 	if bucket.Type != "vm" {
-		return nil, fmt.Errorf("eggsbucket type must be 'vm', got '%s'", bucket.Type)
+		return nil, bucket.Positions.errorf("type", "eggsbucket type must be 'vm', got '%s'", bucket.Type)
 	}
 
 	// Parse cloud provider
 	provider, err := parseCloudProvider(bucket.Cloud.Provider)
 	if err != nil {
-		return nil, err
+		return nil, bucket.Positions.errorf("cloud.provider", "%w", err)
 	}
 
 	// Parse idle timeout
 	idleTimeout, err := time.ParseDuration(bucket.Runner.IdleTimeout)
 	if err != nil {
-		return nil, fmt.Errorf("invalid idle timeout: %w", err)
+		return nil, bucket.Positions.errorf("runner.idle_timeout", "invalid idle timeout: %w", err)
+	}
+
+	vmSize, err := vmSizeFor(provider, bucket.Resources.CPU, bucket.Resources.Memory)
+	if err != nil {
+		return nil, bucket.Positions.errorf("resources", "%w", err)
+	}
+
+	resources, err := vmResources(bucket.Resources, provider, bucket.Positions)
+	if err != nil {
+		return nil, err
+	}
+
+	runner := RunnerConfig{
+		Tags:        bucket.Runner.Tags,
+		Concurrent:  bucket.Runner.Concurrent,
+		IdleTimeout: idleTimeout,
+		Image:       bucket.Runner.Image,
+		ImageID:     bucket.Runner.ImageID,
+		OS:          bucket.Runner.OS,
+		Arch:        bucket.Runner.Arch,
+		Privileged:  bucket.Runner.Privileged,
+		DockerDinD:  bucket.Runner.DockerDinD,
+		Cache:       cacheConfig(bucket.Runner.Cache),
 	}
 
 	// Create a VM config for each repository in the bucket
@@ -486,47 +1043,167 @@ func (c *Converter) EggsBucketToVMConfigs(bucket *ParsedEggsBucketConfig) ([]*VM
 				Provider: provider,
 				Region:   bucket.Cloud.Region,
 			},
-			Resources: ResourceConfig{
-				CPU:    bucket.Resources.CPU,
-				Memory: bucket.Resources.Memory,
-				Disk:   bucket.Resources.Disk,
-			},
-			Runner: RunnerConfig{
-				Tags:        bucket.Runner.Tags,
-				Concurrent:  bucket.Runner.Concurrent,
-				IdleTimeout: idleTimeout,
-			},
+			Resources: resources,
+			VMSize:    vmSize,
+			Network:   vmNetwork(bucket.Cloud.Network),
+			Runner:    runner,
 			GitLab: GitLabConfig{
 				ProjectID:   repo.GitLab.ProjectID,
+				ServerName:  repo.GitLab.ServerName,
 				TokenSecret: repo.GitLab.TokenSecret,
+				CACert:      repo.GitLab.CACert,
 			},
-			Environment: bucket.Environment,
+			Environment: runnerEnvironment(bucket.Environment, runner, "docker"),
 		}
 	}
 
 	return configs, nil
 }
 
+// cacheConfig returns the cache of a runner, or nil without one
+func cacheConfig(cache *CacheInfo) *CacheConfig {
+	if cache == nil {
+		return nil
+	}
+	return &CacheConfig{
+		Type:              cache.Type,
+		Bucket:            cache.Bucket,
+		Path:              cache.Path,
+		ServerAddress:     cache.ServerAddress,
+		CredentialsSecret: cache.CredentialsSecret,
+		Shared:            cache.Shared,
+	}
+}
+
+// runnerEnvironment returns the environment of a runner using executor
+// (docker, kubernetes, or "" for serverless runners): environment with the
+// variables configuring its cache and privileged containers, which
+// environment may override
+func runnerEnvironment(environment map[string]string, runner RunnerConfig, executor string) map[string]string {
+	env := make(map[string]string)
+	if runner.Cache != nil {
+		env = runner.Cache.Environment()
+	}
+	if executor != "" && runner.Privileged {
+		env[strings.ToUpper(executor)+"_PRIVILEGED"] = "true"
+	}
+	if executor != "" && runner.DockerDinD {
+		// Jobs reach the docker:dind service over TLS with the certificates
+		// it writes to the shared /certs/client volume
+		env["DOCKER_TLS_CERTDIR"] = "/certs"
+		if executor == "docker" {
+			env["DOCKER_VOLUMES"] = "/certs/client"
+		}
+	}
+	if len(env) == 0 {
+		return environment
+	}
+	for key, value := range environment {
+		env[key] = value
+	}
+	return env
+}
+
+// kubernetesConfig returns the cluster of kubernetes runners. The os and
+// arch of the runner select the nodes of their job pods unless its
+// node_selector sets those labels itself.
+func kubernetesConfig(kubernetes *KubernetesInfo, runner RunnerInfo, positions Positions) (KubernetesConfig, error) {
+	if kubernetes == nil {
+		return KubernetesConfig{}, positions.errorf("", "kubernetes runners require a kubernetes block")
+	}
+	selector := make(map[string]string, len(kubernetes.NodeSelector)+2)
+	for label, value := range map[string]string{"kubernetes.io/os": runner.OS, "kubernetes.io/arch": runner.Arch} {
+		if value != "" {
+			selector[label] = value
+		}
+	}
+	for label, value := range kubernetes.NodeSelector {
+		selector[label] = value
+	}
+	if len(selector) == 0 {
+		selector = nil
+	}
+	return KubernetesConfig{
+		Namespace:        kubernetes.Namespace,
+		NodeSelector:     selector,
+		KubeconfigSecret: kubernetes.KubeconfigSecret,
+	}, nil
+}
+
+// autoscalingConfig returns the autoscaler of an egg's VM runners with the
+// defaults of the attributes it leaves out, or nil without one
+func autoscalingConfig(autoscaling *AutoscalingInfo, positions Positions) (*AutoscalingConfig, error) {
+	if autoscaling == nil {
+		return nil, nil
+	}
+	config := &AutoscalingConfig{
+		Min:              autoscaling.Min,
+		Max:              autoscaling.Max,
+		ScaleUpThreshold: autoscaling.ScaleUpThreshold,
+		ScaleDownDelay:   parser.DefaultScaleDownDelay,
+	}
+	if config.ScaleUpThreshold == 0 {
+		config.ScaleUpThreshold = parser.DefaultScaleUpThreshold
+	}
+	if autoscaling.ScaleDownDelay != "" {
+		delay, err := time.ParseDuration(autoscaling.ScaleDownDelay)
+		if err != nil {
+			return nil, positions.errorf("autoscaling.scale_down_delay", "invalid scale down delay: %w", err)
+		}
+		config.ScaleDownDelay = delay
+	}
+	if config.Min > config.Max {
+		return nil, positions.errorf("autoscaling.min", "autoscaling min (%d) cannot be greater than max (%d)", config.Min, config.Max)
+	}
+	return config, nil
+}
+
+// serverlessJobTimeout returns the job_timeout of a serverless runner, or
+// parser.MaxServerlessJobTimeout when it sets none
+func serverlessJobTimeout(runner RunnerInfo, provider string, positions Positions) (time.Duration, error) {
+	if runner.JobTimeout == "" {
+		return parser.MaxServerlessJobTimeout, nil
+	}
+	timeout, err := time.ParseDuration(runner.JobTimeout)
+	if err != nil {
+		return 0, positions.errorf("runner.job_timeout", "invalid job timeout: %w", err)
+	}
+	if limit := parser.ServerlessJobTimeout(provider); timeout <= 0 || timeout > limit {
+		return 0, positions.errorf("runner.job_timeout", "job timeout must be positive and at most %d minutes for %s serverless runners, got %s", int(limit/time.Minute), provider, runner.JobTimeout)
+	}
+	return timeout, nil
+}
+
 // EggsBucketToServerlessConfigs converts a parsed EggsBucket configuration to multiple serverless deployment configurations
 func (c *Converter) EggsBucketToServerlessConfigs(bucket *ParsedEggsBucketConfig) ([]*ServerlessConfig, error) {
 	if bucket.Type != "serverless" {
-		return nil, fmt.Errorf("eggsbucket type must be 'serverless', got '%s'", bucket.Type)
+		return nil, bucket.Positions.errorf("type", "eggsbucket type must be 'serverless', got '%s'", bucket.Type)
 	}
 
 	// Parse cloud provider
 	provider, err := parseCloudProvider(bucket.Cloud.Provider)
 	if err != nil {
-		return nil, err
+		return nil, bucket.Positions.errorf("cloud.provider", "%w", err)
 	}
 
 	// Parse idle timeout
 	idleTimeout, err := time.ParseDuration(bucket.Runner.IdleTimeout)
 	if err != nil {
-		return nil, fmt.Errorf("invalid idle timeout: %w", err)
+		return nil, bucket.Positions.errorf("runner.idle_timeout", "invalid idle timeout: %w", err)
+	}
+
+	timeout, err := serverlessJobTimeout(bucket.Runner, bucket.Cloud.Provider, bucket.Positions)
+	if err != nil {
+		return nil, err
 	}
 
-	// Serverless runners have a maximum timeout of 60 minutes
-	timeout := 60 * time.Minute
+	runner := RunnerConfig{
+		Tags:        bucket.Runner.Tags,
+		Concurrent:  bucket.Runner.Concurrent,
+		IdleTimeout: idleTimeout,
+		Arch:        bucket.Runner.Arch,
+		Cache:       cacheConfig(bucket.Runner.Cache),
+	}
 
 	// Create a serverless config for each repository in the bucket
 	configs := make([]*ServerlessConfig, len(bucket.Repositories))
@@ -542,16 +1219,14 @@ func (c *Converter) EggsBucketToServerlessConfigs(bucket *ParsedEggsBucketConfig
 				Memory: bucket.Resources.Memory,
 				Disk:   bucket.Resources.Disk,
 			},
-			Runner: RunnerConfig{
-				Tags:        bucket.Runner.Tags,
-				Concurrent:  bucket.Runner.Concurrent,
-				IdleTimeout: idleTimeout,
-			},
+			Runner: runner,
 			GitLab: GitLabConfig{
 				ProjectID:   repo.GitLab.ProjectID,
+				ServerName:  repo.GitLab.ServerName,
 				TokenSecret: repo.GitLab.TokenSecret,
+				CACert:      repo.GitLab.CACert,
 			},
-			Environment: bucket.Environment,
+			Environment: runnerEnvironment(bucket.Environment, runner, ""),
 			Timeout:     timeout,
 		}
 	}
@@ -559,6 +1234,70 @@ func (c *Converter) EggsBucketToServerlessConfigs(bucket *ParsedEggsBucketConfig
 	return configs, nil
 }
 
+// EggsBucketToK8sConfigs converts a parsed EggsBucket configuration to the
+// deployments of a kubernetes runner for each of its repositories
+func (c *Converter) EggsBucketToK8sConfigs(bucket *ParsedEggsBucketConfig) ([]*K8sConfig, error) {
+	if bucket.Type != "kubernetes" {
+		return nil, bucket.Positions.errorf("type", "eggsbucket type must be 'kubernetes', got '%s'", bucket.Type)
+	}
+
+	// Parse cloud provider
+	provider, err := parseCloudProvider(bucket.Cloud.Provider)
+	if err != nil {
+		return nil, bucket.Positions.errorf("cloud.provider", "%w", err)
+	}
+
+	// Parse idle timeout
+	idleTimeout, err := time.ParseDuration(bucket.Runner.IdleTimeout)
+	if err != nil {
+		return nil, bucket.Positions.errorf("runner.idle_timeout", "invalid idle timeout: %w", err)
+	}
+
+	kubernetes, err := kubernetesConfig(bucket.Kubernetes, bucket.Runner, bucket.Positions)
+	if err != nil {
+		return nil, err
+	}
+
+	runner := RunnerConfig{
+		Tags:        bucket.Runner.Tags,
+		Concurrent:  bucket.Runner.Concurrent,
+		IdleTimeout: idleTimeout,
+		OS:          bucket.Runner.OS,
+		Arch:        bucket.Runner.Arch,
+		Privileged:  bucket.Runner.Privileged,
+		DockerDinD:  bucket.Runner.DockerDinD,
+		Cache:       cacheConfig(bucket.Runner.Cache),
+	}
+
+	// Create a kubernetes config for each repository in the bucket
+	configs := make([]*K8sConfig, len(bucket.Repositories))
+	for i, repo := range bucket.Repositories {
+		configs[i] = &K8sConfig{
+			EggName: fmt.Sprintf("%s-%s", bucket.Name, repo.Name),
+			Cloud: CloudConfig{
+				Provider: provider,
+				Region:   bucket.Cloud.Region,
+			},
+			Resources: ResourceConfig{
+				CPU:    bucket.Resources.CPU,
+				Memory: bucket.Resources.Memory,
+				Disk:   bucket.Resources.Disk,
+			},
+			Kubernetes: kubernetes,
+			Runner:     runner,
+			GitLab: GitLabConfig{
+				ProjectID:   repo.GitLab.ProjectID,
+				ServerName:  repo.GitLab.ServerName,
+				TokenSecret: repo.GitLab.TokenSecret,
+				CACert:      repo.GitLab.CACert,
+			},
+			Environment: runnerEnvironment(bucket.Environment, runner, "kubernetes"),
+		}
+	}
+
+	return configs, nil
+}
+
 // parseCloudProvider converts a string cloud provider to CloudProvider type
 func parseCloudProvider(provider string) (CloudProvider, error) {
 	switch provider {
@@ -566,7 +1305,57 @@ func parseCloudProvider(provider string) (CloudProvider, error) {
 		return CloudProviderYandex, nil
 	case "aws":
 		return CloudProviderAWS, nil
+	case "azure":
+		return CloudProviderAzure, nil
 	default:
 		return "", fmt.Errorf("unsupported cloud provider: %s", provider)
 	}
 }
+
+// vmResources returns the resources of a VM runner. A GPU type decides the
+// platform when none is set.
+func vmResources(resources ResourceInfo, provider CloudProvider, positions Positions) (ResourceConfig, error) {
+	config := ResourceConfig{
+		CPU:      resources.CPU,
+		Memory:   resources.Memory,
+		Disk:     resources.Disk,
+		GPU:      resources.GPU,
+		GPUType:  resources.GPUType,
+		Platform: resources.Platform,
+	}
+	if resources.GPU == 0 {
+		return config, nil
+	}
+	platform, ok := parser.GPUPlatform(string(provider), resources.GPUType)
+	if !ok {
+		return config, positions.errorf("resources.gpu_type", "unsupported %s GPU type %q", provider, resources.GPUType)
+	}
+	if config.Platform == "" {
+		config.Platform = platform
+	}
+	return config, nil
+}
+
+// vmNetwork returns the network of a VM runner, which has a public IP
+// address unless the network block says otherwise
+func vmNetwork(network NetworkInfo) NetworkConfig {
+	return NetworkConfig{
+		VPCID:           network.VPCID,
+		SubnetID:        network.SubnetID,
+		SecurityGroups:  network.SecurityGroups,
+		DisablePublicIP: network.PublicIP != nil && !*network.PublicIP,
+	}
+}
+
+// vmSizeFor returns the provider instance size for the requested resources.
+// Only Azure uses named sizes; other providers size VMs from CPU and memory directly.
+func vmSizeFor(provider CloudProvider, cpu, memory int) (string, error) {
+	if provider != CloudProviderAzure {
+		return "", nil
+	}
+	size, err := parser.AzureVMSize(cpu, memory)
+	if err != nil {
+		return "", err
+	}
+	return size.Name, nil
+}
@@ -653,3 +653,46 @@ func TestSDKClientCreation(t *testing.T) {
 		}
 	})
 }
+
+// TestAzureVMConversion tests that Azure VM eggs are mapped to the smallest fitting VM size
+func TestAzureVMConversion(t *testing.T) {
+	ctx := context.Background()
+
+	egg := generateValidEggConfig("vm", "aws")
+	egg.Cloud = CloudInfo{Provider: "azure", Region: "westeurope"}
+	egg.Resources = ResourceInfo{CPU: 2, Memory: 6144, Disk: 30}
+
+	converter := NewConverter()
+	vmConfig, err := converter.EggToVMConfig(egg)
+	if err != nil {
+		t.Fatalf("Conversion error: %v", err)
+	}
+	if vmConfig.Cloud.Provider != CloudProviderAzure {
+		t.Errorf("expected provider azure, got %s", vmConfig.Cloud.Provider)
+	}
+	if vmConfig.VMSize != "Standard_B2ms" {
+		t.Errorf("expected VM size Standard_B2ms, got %q", vmConfig.VMSize)
+	}
+
+	egg.Resources = ResourceInfo{CPU: 128, Memory: 524288, Disk: 30}
+	if _, err := converter.EggToVMConfig(egg); err == nil {
+		t.Error("expected error when no Azure VM size fits the requested resources")
+	}
+
+	t.Setenv("AZURE_SUBSCRIPTION_ID", "")
+	if _, err := NewAzureClient(ctx, "westeurope"); err == nil {
+		t.Error("expected error without AZURE_SUBSCRIPTION_ID")
+	}
+
+	t.Setenv("AZURE_SUBSCRIPTION_ID", "00000000-0000-0000-0000-000000000000")
+	if _, err := NewAzureClient(ctx, "moon-central"); err == nil {
+		t.Error("expected error for unsupported Azure region")
+	}
+	client, err := NewAzureClient(ctx, "westeurope")
+	if err != nil {
+		t.Fatalf("NewAzureClient failed: %v", err)
+	}
+	if client.resourceGroup != defaultAzureResourceGroup {
+		t.Errorf("expected default resource group %q, got %q", defaultAzureResourceGroup, client.resourceGroup)
+	}
+}
@@ -0,0 +1,375 @@
+package deployer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+const positionEggConfig = `egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  runner {
+    tags = ["docker"]
+    concurrent = 2
+%s
+  }
+}
+`
+
+func TestConverterErrorPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		runner  string
+		convert func(*Converter, *ParsedEggConfig) error
+		wantErr string
+	}{
+		{
+			name:    "invalid idle timeout",
+			runner:  `    idle_timeout = "ten minutes"`,
+			wantErr: "config.fly:12:20: invalid idle timeout",
+		},
+		{
+			name:    "missing idle timeout falls back to the runner block",
+			wantErr: "config.fly:9:3: invalid idle timeout",
+		},
+		{
+			name:   "wrong type",
+			runner: `    idle_timeout = "10m"`,
+			convert: func(c *Converter, egg *ParsedEggConfig) error {
+				_, err := c.EggToServerlessConfig(egg)
+				return err
+			},
+			wantErr: "config.fly:2:10: egg type must be 'serverless'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parser.NewParser().Parse([]byte(fmt.Sprintf(positionEggConfig, tt.runner)), "config.fly")
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			egg, err := ParseEgg(&config.Blocks[0])
+			if err != nil {
+				t.Fatalf("ParseEgg failed: %v", err)
+			}
+
+			convert := tt.convert
+			if convert == nil {
+				convert = func(c *Converter, egg *ParsedEggConfig) error {
+					_, err := c.EggToVMConfig(egg)
+					return err
+				}
+			}
+			if err := convert(NewConverter(), egg); err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("expected error starting with %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConverterErrorWithoutPositions(t *testing.T) {
+	// Configurations built in code have no positions to report
+	egg := &ParsedEggConfig{Name: "my-app", Type: "vm", Cloud: CloudInfo{Provider: "gcp"}}
+	_, err := NewConverter().EggToVMConfig(egg)
+	if err == nil || err.Error() != "unsupported cloud provider: gcp" {
+		t.Errorf("expected an unpositioned provider error, got %v", err)
+	}
+}
+
+func TestServerlessJobTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		runner   string
+		want     time.Duration
+		wantErr  string
+	}{
+		{name: "default", provider: "yandex", want: 60 * time.Minute},
+		{name: "set", provider: "yandex", runner: `    job_timeout = "30m"`, want: 30 * time.Minute},
+		{name: "above the AWS Lambda limit", provider: "aws", runner: `    job_timeout = "20m"`, wantErr: "config.fly:13:19: job timeout must be positive and at most 15 minutes"},
+		{name: "invalid", provider: "yandex", runner: `    job_timeout = "half an hour"`, wantErr: "config.fly:13:19: invalid job timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.NewReplacer(`"vm"`, `"serverless"`, `"yandex"`, `"`+tt.provider+`"`).
+				Replace(fmt.Sprintf(positionEggConfig, "    idle_timeout = \"10m\"\n"+tt.runner))
+			config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			egg, err := ParseEgg(&config.Blocks[0])
+			if err != nil {
+				t.Fatalf("ParseEgg failed: %v", err)
+			}
+
+			sc, err := NewConverter().EggToServerlessConfig(egg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("expected error starting with %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EggToServerlessConfig failed: %v", err)
+			}
+			if sc.Timeout != tt.want {
+				t.Errorf("Timeout = %s, want %s", sc.Timeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestVMConfigGPU(t *testing.T) {
+	content := strings.Replace(fmt.Sprintf(positionEggConfig, `    idle_timeout = "10m"`), "  runner {",
+		"  resources {\n    cpu      = 8\n    memory   = 16384\n    disk     = 100\n    gpu      = 2\n    gpu_type = \"a100\"\n  }\n\n  runner {", 1)
+	config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	egg, err := ParseEgg(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseEgg failed: %v", err)
+	}
+
+	vm, err := NewConverter().EggToVMConfig(egg)
+	if err != nil {
+		t.Fatalf("EggToVMConfig failed: %v", err)
+	}
+	want := ResourceConfig{CPU: 8, Memory: 16384, Disk: 100, GPU: 2, GPUType: "a100", Platform: "gpu-standard-v3"}
+	if vm.Resources != want {
+		t.Errorf("Resources = %+v, want %+v", vm.Resources, want)
+	}
+
+	egg.Resources.GPUType = "h100"
+	if _, err := NewConverter().EggToVMConfig(egg); err == nil || !strings.HasPrefix(err.Error(), "config.fly:14:16: unsupported yandex GPU type") {
+		t.Errorf("expected a positioned GPU type error, got %v", err)
+	}
+}
+
+func TestVMConfigNetwork(t *testing.T) {
+	content := strings.Replace(fmt.Sprintf(positionEggConfig, `    idle_timeout = "10m"`), `    region   = "ru-central1-a"`,
+		"    region   = \"ru-central1-a\"\n\n    network {\n      subnet_id       = \"e9bk2a8q0o2v4c0r1j3s\"\n      security_groups = [\"enp1\", \"enp2\"]\n      public_ip       = false\n    }", 1)
+	config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	egg, err := ParseEgg(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseEgg failed: %v", err)
+	}
+
+	vm, err := NewConverter().EggToVMConfig(egg)
+	if err != nil {
+		t.Fatalf("EggToVMConfig failed: %v", err)
+	}
+	if vm.Network.SubnetID != "e9bk2a8q0o2v4c0r1j3s" || strings.Join(vm.Network.SecurityGroups, ",") != "enp1,enp2" || !vm.Network.DisablePublicIP {
+		t.Errorf("unexpected network %+v", vm.Network)
+	}
+
+	// Without a network block runners keep their public IP address
+	egg.Cloud.Network = NetworkInfo{}
+	if vm, err = NewConverter().EggToVMConfig(egg); err != nil || vm.Network.DisablePublicIP {
+		t.Errorf("expected a public IP address by default, got %+v, %v", vm, err)
+	}
+}
+
+func TestVMConfigImage(t *testing.T) {
+	content := fmt.Sprintf(positionEggConfig, "    idle_timeout = \"10m\"\n    image        = \"ubuntu-22-04-docker\"\n    os           = \"linux\"\n    arch         = \"arm64\"")
+	config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	egg, err := ParseEgg(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseEgg failed: %v", err)
+	}
+
+	vm, err := NewConverter().EggToVMConfig(egg)
+	if err != nil {
+		t.Fatalf("EggToVMConfig failed: %v", err)
+	}
+	if vm.Runner.Image != "ubuntu-22-04-docker" || vm.Runner.ImageID != "" {
+		t.Errorf("unexpected runner image %q, image_id %q", vm.Runner.Image, vm.Runner.ImageID)
+	}
+	if vm.Runner.OS != "linux" || vm.Runner.Arch != "arm64" {
+		t.Errorf("unexpected runner os %q, arch %q", vm.Runner.OS, vm.Runner.Arch)
+	}
+
+	egg.Runner.Image, egg.Runner.ImageID = "", "fd8kdq6d0p8sij7h5qe3"
+	if vm, err = NewConverter().EggToVMConfig(egg); err != nil || vm.Runner.ImageID != "fd8kdq6d0p8sij7h5qe3" {
+		t.Errorf("expected the image_id to carry through, got %+v, %v", vm, err)
+	}
+}
+
+func TestVMConfigAutoscaling(t *testing.T) {
+	content := strings.Replace(fmt.Sprintf(positionEggConfig, `    idle_timeout = "10m"`), "  }\n}\n",
+		"  }\n\n  autoscaling {\n    min = 1\n    max = 10\n  }\n}\n", 1)
+	config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	egg, err := ParseEgg(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseEgg failed: %v", err)
+	}
+
+	vm, err := NewConverter().EggToVMConfig(egg)
+	if err != nil {
+		t.Fatalf("EggToVMConfig failed: %v", err)
+	}
+	want := AutoscalingConfig{Min: 1, Max: 10, ScaleUpThreshold: parser.DefaultScaleUpThreshold, ScaleDownDelay: parser.DefaultScaleDownDelay}
+	if vm.Autoscaling == nil || *vm.Autoscaling != want {
+		t.Errorf("expected autoscaling %v with the defaults, got %v", want, vm.Autoscaling)
+	}
+
+	egg.Autoscaling.Min = 20
+	if _, err := NewConverter().EggToVMConfig(egg); err == nil || !strings.Contains(err.Error(), "config.fly:16:11: autoscaling min (20) cannot be greater than max (10)") {
+		t.Errorf("expected a positioned min error, got %v", err)
+	}
+
+	egg.Autoscaling = nil
+	if vm, err = NewConverter().EggToVMConfig(egg); err != nil || vm.Autoscaling != nil {
+		t.Errorf("expected no autoscaling without the block, got %v, %v", vm, err)
+	}
+}
+
+func TestRunnerCache(t *testing.T) {
+	cache := "    idle_timeout = \"10m\"\n\n    cache {\n      type               = \"s3\"\n      bucket             = \"runner-cache\"\n" +
+		"      server_address     = \"storage.yandexcloud.net\"\n      credentials_secret = \"yc-lockbox://gitlab/cache\"\n      shared             = true\n    }"
+	config, err := parser.NewParser().Parse([]byte(fmt.Sprintf(positionEggConfig, cache)), "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	egg, err := ParseEgg(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseEgg failed: %v", err)
+	}
+	egg.Environment = map[string]string{"CACHE_SHARED": "false", "LOG_LEVEL": "info"}
+
+	vm, err := NewConverter().EggToVMConfig(egg)
+	if err != nil {
+		t.Fatalf("EggToVMConfig failed: %v", err)
+	}
+	want := CacheConfig{Type: "s3", Bucket: "runner-cache", ServerAddress: "storage.yandexcloud.net", CredentialsSecret: "yc-lockbox://gitlab/cache", Shared: true}
+	if vm.Runner.Cache == nil || *vm.Runner.Cache != want {
+		t.Errorf("expected cache %+v, got %+v", want, vm.Runner.Cache)
+	}
+	for key, value := range map[string]string{
+		"CACHE_TYPE":                       "s3",
+		"CACHE_S3_BUCKET_NAME":             "runner-cache",
+		"CACHE_S3_SERVER_ADDRESS":          "storage.yandexcloud.net",
+		"GOSLING_CACHE_CREDENTIALS_SECRET": "yc-lockbox://gitlab/cache",
+		"CACHE_SHARED":                     "false", // The egg's environment wins
+		"LOG_LEVEL":                        "info",
+	} {
+		if vm.Environment[key] != value {
+			t.Errorf("Environment[%s] = %q, want %q", key, vm.Environment[key], value)
+		}
+	}
+	if len(egg.Environment) != 2 {
+		t.Errorf("expected the egg's environment to be left unchanged, got %v", egg.Environment)
+	}
+
+	if env := (CacheConfig{Type: "local", Path: "/cache"}).Environment(); len(env) != 1 || env["CACHE_DIR"] != "/cache" {
+		t.Errorf("unexpected local cache environment %v", env)
+	}
+}
+
+func TestEggToK8sConfig(t *testing.T) {
+	content := strings.Replace(fmt.Sprintf(positionEggConfig, "    idle_timeout = \"10m\"\n    arch         = \"arm64\""), `type = "vm"`, `type = "kubernetes"`, 1)
+	content = strings.Replace(content, "  }\n}\n", "  }\n\n  kubernetes {\n    namespace         = \"ci\"\n    node_selector     = { pool = \"runners\" }\n"+
+		"    kubeconfig_secret = \"vault://secret/k8s/kubeconfig\"\n  }\n}\n", 1)
+	config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	egg, err := ParseEgg(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseEgg failed: %v", err)
+	}
+
+	k8s, err := NewConverter().EggToK8sConfig(egg)
+	if err != nil {
+		t.Fatalf("EggToK8sConfig failed: %v", err)
+	}
+	if k8s.Kubernetes.Namespace != "ci" || k8s.Kubernetes.KubeconfigSecret != "vault://secret/k8s/kubeconfig" {
+		t.Errorf("unexpected cluster %+v", k8s.Kubernetes)
+	}
+	want := map[string]string{"pool": "runners", "kubernetes.io/arch": "arm64"}
+	if fmt.Sprint(k8s.Kubernetes.NodeSelector) != fmt.Sprint(want) {
+		t.Errorf("expected node selector %v, got %v", want, k8s.Kubernetes.NodeSelector)
+	}
+	if k8s.Runner.IdleTimeout != 10*time.Minute || k8s.Runner.Arch != "arm64" {
+		t.Errorf("unexpected runner %+v", k8s.Runner)
+	}
+
+	egg.Kubernetes = nil
+	if _, err := NewConverter().EggToK8sConfig(egg); err == nil || !strings.Contains(err.Error(), "config.fly:1:1: kubernetes runners require a kubernetes block") {
+		t.Errorf("expected an error locating the egg block, got %v", err)
+	}
+	egg.Type = "vm"
+	if _, err := NewConverter().EggToK8sConfig(egg); err == nil || !strings.Contains(err.Error(), "egg type must be 'kubernetes'") {
+		t.Errorf("expected a type error, got %v", err)
+	}
+}
+
+func TestRunnerEnvironmentPrivileged(t *testing.T) {
+	runner := RunnerConfig{Privileged: true, DockerDinD: true}
+	env := runnerEnvironment(map[string]string{"DOCKER_TLS_CERTDIR": ""}, runner, "docker")
+	want := map[string]string{"DOCKER_PRIVILEGED": "true", "DOCKER_VOLUMES": "/certs/client", "DOCKER_TLS_CERTDIR": ""}
+	if fmt.Sprint(env) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, env)
+	}
+
+	env = runnerEnvironment(nil, runner, "kubernetes")
+	want = map[string]string{"KUBERNETES_PRIVILEGED": "true", "DOCKER_TLS_CERTDIR": "/certs"}
+	if fmt.Sprint(env) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, env)
+	}
+
+	if env := runnerEnvironment(nil, runner, ""); env != nil {
+		t.Errorf("expected serverless runners to get no privileged variables, got %v", env)
+	}
+}
+
+func TestParseJob(t *testing.T) {
+	config, err := parser.NewParser().Parse([]byte(`job "rotate-secrets" {
+  schedule = "0 2 * * *"
+  script   = "gosling rotate-tokens --all"
+
+  runner {
+    type = "vm"
+    tags = ["privileged", "linux"]
+  }
+}
+`), "rotate-secrets.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	job, err := ParseJob(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseJob failed: %v", err)
+	}
+	if job.Name != "rotate-secrets" || job.Schedule != "0 2 * * *" || job.Script != "gosling rotate-tokens --all" {
+		t.Errorf("unexpected job %+v", job)
+	}
+	if job.Runner.Type != RunnerTypeVM || len(job.Runner.Tags) != 2 || job.Runner.Tags[1] != "linux" {
+		t.Errorf("unexpected job runner %+v", job.Runner)
+	}
+
+	if _, err := ParseJob(&parser.Block{Type: "egg"}); err == nil {
+		t.Error("expected an error for an egg block")
+	}
+}
@@ -3,6 +3,8 @@ package deployer
 import (
 	"context"
 	"fmt"
+
+	"github.com/polar-gosling/gosling/internal/telemetry"
 )
 
 // Deployer is the main deployer for ecosystem infrastructure (MotherGoose, UglyFox, databases)
@@ -10,6 +12,7 @@ import (
 type Deployer struct {
 	awsClient    *AWSClient
 	yandexClient *YandexCloudClient
+	azureClient  *AzureClient
 }
 
 // NewDeployer creates a new deployer instance
@@ -18,7 +21,16 @@ func NewDeployer(ctx context.Context) (*Deployer, error) {
 }
 
 // DeployBackendInfrastructure deploys the backend infrastructure (MotherGoose, UglyFox, databases)
-func (d *Deployer) DeployBackendInfrastructure(ctx context.Context, provider CloudProvider, region string) error {
+// declared by cfg, calling done after each resource is created
+func (d *Deployer) DeployBackendInfrastructure(ctx context.Context, provider CloudProvider, region string, cfg *BackendConfig, done func(BackendResource)) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "deployer.DeployBackendInfrastructure", telemetry.CloudAttributes(string(provider), region)...)
+	defer func() { telemetry.EndSpan(span, err) }()
+	if err := cfg.CheckProvider(provider); err != nil {
+		return err
+	}
+	if err := cfg.CheckProvisioning(provider); err != nil {
+		return err
+	}
 	switch provider {
 	case CloudProviderAWS:
 		if d.awsClient == nil {
@@ -28,7 +40,7 @@ func (d *Deployer) DeployBackendInfrastructure(ctx context.Context, provider Clo
 			}
 			d.awsClient = client
 		}
-		return d.awsClient.DeployBackendInfrastructure(ctx)
+		return d.awsClient.DeployBackendInfrastructure(ctx, cfg, done)
 
 	case CloudProviderYandex:
 		if d.yandexClient == nil {
@@ -38,7 +50,17 @@ func (d *Deployer) DeployBackendInfrastructure(ctx context.Context, provider Clo
 			}
 			d.yandexClient = client
 		}
-		return d.yandexClient.DeployBackendInfrastructure(ctx)
+		return d.yandexClient.DeployBackendInfrastructure(ctx, cfg, done)
+
+	case CloudProviderAzure:
+		if d.azureClient == nil {
+			client, err := NewAzureClient(ctx, region)
+			if err != nil {
+				return fmt.Errorf("failed to create Azure client: %w", err)
+			}
+			d.azureClient = client
+		}
+		return d.azureClient.DeployBackendInfrastructure(ctx, cfg, done)
 
 	default:
 		return fmt.Errorf("unsupported cloud provider: %s", provider)
@@ -46,7 +68,9 @@ func (d *Deployer) DeployBackendInfrastructure(ctx context.Context, provider Clo
 }
 
 // GetStatus retrieves the current status of infrastructure
-func (d *Deployer) GetStatus(ctx context.Context, provider CloudProvider, region, resourceID string) (string, error) {
+func (d *Deployer) GetStatus(ctx context.Context, provider CloudProvider, region, resourceID string) (status string, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "deployer.GetStatus", telemetry.CloudAttributes(string(provider), region)...)
+	defer func() { telemetry.EndSpan(span, err) }()
 	switch provider {
 	case CloudProviderAWS:
 		if d.awsClient == nil {
@@ -68,7 +92,67 @@ func (d *Deployer) GetStatus(ctx context.Context, provider CloudProvider, region
 		}
 		return d.yandexClient.GetStatus(ctx, resourceID)
 
+	case CloudProviderAzure:
+		if d.azureClient == nil {
+			client, err := NewAzureClient(ctx, region)
+			if err != nil {
+				return "", fmt.Errorf("failed to create Azure client: %w", err)
+			}
+			d.azureClient = client
+		}
+		return d.azureClient.GetStatus(ctx, resourceID)
+
 	default:
 		return "", fmt.Errorf("unsupported cloud provider: %s", provider)
 	}
 }
+
+// Quotas returns the quotas of the target cloud account that limit runners
+func (d *Deployer) Quotas(ctx context.Context, provider CloudProvider, region string) (quotas []Quota, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "deployer.Quotas", telemetry.CloudAttributes(string(provider), region)...)
+	defer func() { telemetry.EndSpan(span, err) }()
+	switch provider {
+	case CloudProviderAWS:
+		if d.awsClient == nil {
+			client, err := NewAWSClient(ctx, region)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create AWS client: %w", err)
+			}
+			d.awsClient = client
+		}
+		return d.awsClient.Quotas(ctx)
+
+	case CloudProviderYandex:
+		if d.yandexClient == nil {
+			client, err := NewYandexCloudClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create Yandex Cloud client: %w", err)
+			}
+			d.yandexClient = client
+		}
+		return d.yandexClient.Quotas(ctx)
+
+	default:
+		return nil, fmt.Errorf("quota checks are not supported for %s", provider)
+	}
+}
+
+// Images returns the boot disk images VM runners of provider can start from
+func (d *Deployer) Images(ctx context.Context, provider CloudProvider) (images []Image, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "deployer.Images", telemetry.CloudAttributes(string(provider), "")...)
+	defer func() { telemetry.EndSpan(span, err) }()
+	switch provider {
+	case CloudProviderYandex:
+		if d.yandexClient == nil {
+			client, err := NewYandexCloudClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create Yandex Cloud client: %w", err)
+			}
+			d.yandexClient = client
+		}
+		return d.yandexClient.Images(ctx)
+
+	default:
+		return nil, fmt.Errorf("listing images is not supported for %s", provider)
+	}
+}
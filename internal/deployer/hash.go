@@ -0,0 +1,139 @@
+package deployer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigHashVersion identifies the canonical form hashed by ConfigHash. Bump it
+// only when the canonical form of existing configurations has to change.
+const ConfigHashVersion = "v3"
+
+// ConfigHash returns a stable hash of an Egg configuration, prefixed with
+// ConfigHashVersion (e.g. "v3:3f2a...")
+func ConfigHash(egg *EggConfig) string {
+	sum := sha256.Sum256([]byte(CanonicalEggConfig(egg)))
+	return ConfigHashVersion + ":" + hex.EncodeToString(sum[:])
+}
+
+// CanonicalEggConfig renders the fields of an Egg that determine its deployed
+// runners as sorted "key=value" lines. Fields are listed explicitly so adding a
+// field to EggConfig does not change existing hashes, and zero values are
+// omitted so a new optional field only counts once it is set. Runner tags are
+// sorted because their order has no effect on GitLab.
+func CanonicalEggConfig(egg *EggConfig) string {
+	fields := make(map[string]string)
+	for key, value := range map[string]string{
+		"name":                egg.Name,
+		"type":                string(egg.Type),
+		"cloud.provider":      string(egg.Cloud.Provider),
+		"cloud.region":        egg.Cloud.Region,
+		"gitlab.token_secret": egg.GitLab.TokenSecret,
+		"gitlab.ca_cert":      egg.GitLab.CACert,
+		"gitlab.server_name":  egg.GitLab.ServerName,
+		"resources.gpu_type":  egg.Resources.GPUType,
+		"resources.platform":  egg.Resources.Platform,
+		"network.vpc_id":      egg.Network.VPCID,
+		"network.subnet_id":   egg.Network.SubnetID,
+		"runner.image":        egg.Runner.Image,
+		"runner.image_id":     egg.Runner.ImageID,
+		"runner.os":           egg.Runner.OS,
+		"runner.arch":         egg.Runner.Arch,
+	} {
+		if value != "" {
+			fields[key] = strconv.Quote(value)
+		}
+	}
+	for key, value := range map[string]int{
+		"resources.cpu":     egg.Resources.CPU,
+		"resources.memory":  egg.Resources.Memory,
+		"resources.disk":    egg.Resources.Disk,
+		"resources.gpu":     egg.Resources.GPU,
+		"runner.concurrent": egg.Runner.Concurrent,
+		"gitlab.project_id": egg.GitLab.ProjectID,
+		"gitlab.group_id":   egg.GitLab.GroupID,
+	} {
+		if value != 0 {
+			fields[key] = strconv.Itoa(value)
+		}
+	}
+	if egg.Runner.IdleTimeout != 0 {
+		fields["runner.idle_timeout"] = strconv.Quote(egg.Runner.IdleTimeout.String())
+	}
+	if egg.Runner.JobTimeout != 0 {
+		fields["runner.job_timeout"] = strconv.Quote(egg.Runner.JobTimeout.String())
+	}
+	if len(egg.Runner.Tags) > 0 {
+		tags := make([]string, len(egg.Runner.Tags))
+		for i, tag := range egg.Runner.Tags {
+			tags[i] = strconv.Quote(tag)
+		}
+		sort.Strings(tags)
+		fields["runner.tags"] = "[" + strings.Join(tags, ",") + "]"
+	}
+	if len(egg.Network.SecurityGroups) > 0 {
+		groups := make([]string, len(egg.Network.SecurityGroups))
+		for i, group := range egg.Network.SecurityGroups {
+			groups[i] = strconv.Quote(group)
+		}
+		sort.Strings(groups)
+		fields["network.security_groups"] = "[" + strings.Join(groups, ",") + "]"
+	}
+	if egg.Network.DisablePublicIP {
+		fields["network.public_ip"] = "false"
+	}
+	if egg.Runner.Privileged {
+		fields["runner.privileged"] = "true"
+	}
+	if egg.Runner.DockerDinD {
+		fields["runner.docker_dind"] = "true"
+	}
+	if c := egg.Runner.Cache; c != nil {
+		for key, value := range map[string]string{
+			"runner.cache.type":               c.Type,
+			"runner.cache.bucket":             c.Bucket,
+			"runner.cache.path":               c.Path,
+			"runner.cache.server_address":     c.ServerAddress,
+			"runner.cache.credentials_secret": c.CredentialsSecret,
+		} {
+			if value != "" {
+				fields[key] = strconv.Quote(value)
+			}
+		}
+		if c.Shared {
+			fields["runner.cache.shared"] = "true"
+		}
+	}
+	if a := egg.Autoscaling; a != nil {
+		fields["autoscaling.min"] = strconv.Itoa(a.Min)
+		fields["autoscaling.max"] = strconv.Itoa(a.Max)
+		fields["autoscaling.scale_up_threshold"] = strconv.Itoa(a.ScaleUpThreshold)
+		fields["autoscaling.scale_down_delay"] = strconv.Quote(a.ScaleDownDelay.String())
+	}
+	if k := egg.Kubernetes; k != nil {
+		fields["kubernetes.namespace"] = strconv.Quote(k.Namespace)
+		fields["kubernetes.kubeconfig_secret"] = strconv.Quote(k.KubeconfigSecret)
+		for label, value := range k.NodeSelector {
+			fields["kubernetes.node_selector."+strconv.Quote(label)] = strconv.Quote(value)
+		}
+	}
+	for key, value := range egg.Environment {
+		fields["environment."+strconv.Quote(key)] = strconv.Quote(value)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("gosling-egg-config/" + ConfigHashVersion + "\n")
+	for _, key := range keys {
+		b.WriteString(key + "=" + fields[key] + "\n")
+	}
+	return b.String()
+}
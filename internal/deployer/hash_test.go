@@ -0,0 +1,104 @@
+package deployer
+
+import (
+	"testing"
+	"time"
+)
+
+func hashTestEgg() *EggConfig {
+	return &EggConfig{
+		Name:      "my-app",
+		Type:      RunnerTypeVM,
+		Cloud:     CloudConfig{Provider: CloudProviderYandex, Region: "ru-central1-a"},
+		Resources: ResourceConfig{CPU: 2, Memory: 4096, Disk: 20},
+		Runner: RunnerConfig{
+			Tags:        []string{"docker", "linux"},
+			Concurrent:  3,
+			IdleTimeout: 10 * time.Minute,
+		},
+		GitLab:      GitLabConfig{ProjectID: 12345, TokenSecret: "yc-lockbox://gitlab/token"},
+		Environment: map[string]string{"LOG_LEVEL": "info", "REGION": "ru"},
+	}
+}
+
+func TestConfigHashStable(t *testing.T) {
+	// Changing this value redeploys every Egg; bump ConfigHashVersion instead
+	const want = "v3:0b4454b3de17791af516966099306d415426a067ac77f1077f18a1b93b37dd73"
+	hash := ConfigHash(hashTestEgg())
+	if hash != want {
+		t.Fatalf("expected hash %s, got %s", want, hash)
+	}
+
+	reordered := hashTestEgg()
+	reordered.Runner.Tags = []string{"linux", "docker"}
+	if got := ConfigHash(reordered); got != hash {
+		t.Errorf("tag order changed the hash: %s != %s", got, hash)
+	}
+
+	// Maps are rebuilt in a different insertion order on every run
+	for i := 0; i < 20; i++ {
+		egg := hashTestEgg()
+		egg.Environment = map[string]string{"REGION": "ru", "LOG_LEVEL": "info"}
+		if got := ConfigHash(egg); got != hash {
+			t.Fatalf("environment order changed the hash: %s != %s", got, hash)
+		}
+	}
+}
+
+func TestConfigHashDetectsChanges(t *testing.T) {
+	base := ConfigHash(hashTestEgg())
+	changes := map[string]func(*EggConfig){
+		"memory":       func(e *EggConfig) { e.Resources.Memory = 8192 },
+		"region":       func(e *EggConfig) { e.Cloud.Region = "ru-central1-b" },
+		"idle timeout": func(e *EggConfig) { e.Runner.IdleTimeout = 15 * time.Minute },
+		"job timeout":  func(e *EggConfig) { e.Runner.JobTimeout = 30 * time.Minute },
+		"tag":          func(e *EggConfig) { e.Runner.Tags = append(e.Runner.Tags, "gpu") },
+		"environment":  func(e *EggConfig) { e.Environment["LOG_LEVEL"] = "debug" },
+		"token":        func(e *EggConfig) { e.GitLab.TokenSecret = "vault://gitlab/token" },
+		"ca cert":      func(e *EggConfig) { e.GitLab.CACert = "vault://gitlab/ca" },
+		"server name":  func(e *EggConfig) { e.GitLab.ServerName = "gitlab.internal.example" },
+		"subnet":       func(e *EggConfig) { e.Network.SubnetID = "e9bk2a8q0o2v4c0r1j3s" },
+		"public ip":    func(e *EggConfig) { e.Network.DisablePublicIP = true },
+		"image":        func(e *EggConfig) { e.Runner.Image = "ubuntu-22-04-docker" },
+		"autoscaling":  func(e *EggConfig) { e.Autoscaling = &AutoscalingConfig{Min: 1, Max: 10} },
+		"cache":        func(e *EggConfig) { e.Runner.Cache = &CacheConfig{Type: "local"} },
+		"os":           func(e *EggConfig) { e.Runner.OS = "windows" },
+		"arch":         func(e *EggConfig) { e.Runner.Arch = "arm64" },
+		"kubernetes":   func(e *EggConfig) { e.Kubernetes = &KubernetesConfig{Namespace: "ci"} },
+		"privileged":   func(e *EggConfig) { e.Runner.Privileged = true },
+		"docker_dind":  func(e *EggConfig) { e.Runner.DockerDinD = true },
+	}
+	for name, change := range changes {
+		egg := hashTestEgg()
+		change(egg)
+		if ConfigHash(egg) == base {
+			t.Errorf("changing %s did not change the hash", name)
+		}
+	}
+}
+
+func TestCanonicalEggConfig(t *testing.T) {
+	egg := hashTestEgg()
+	egg.Resources.Disk = 0
+	egg.Environment = map[string]string{"A": "line\nbreak"}
+	egg.Runner.JobTimeout = 30 * time.Minute
+
+	want := `gosling-egg-config/v3
+cloud.provider="yandex"
+cloud.region="ru-central1-a"
+environment."A"="line\nbreak"
+gitlab.project_id=12345
+gitlab.token_secret="yc-lockbox://gitlab/token"
+name="my-app"
+resources.cpu=2
+resources.memory=4096
+runner.concurrent=3
+runner.idle_timeout="10m0s"
+runner.job_timeout="30m0s"
+runner.tags=["docker","linux"]
+type="vm"
+`
+	if got := CanonicalEggConfig(egg); got != want {
+		t.Errorf("unexpected canonical form:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package deployer
+
+import (
+	"sort"
+	"time"
+)
+
+// Image is a boot disk image VM runners can start from
+type Image struct {
+	ID          string
+	Name        string
+	Family      string // Runners set runner.image to the family to use its latest image
+	Description string
+	CreatedAt   time.Time
+}
+
+// LatestImages keeps the newest image of each family, and every image without
+// a family, sorted by family and then name
+func LatestImages(images []Image) []Image {
+	latest := make(map[string]int)
+	var result []Image
+	for _, image := range images {
+		if image.Family == "" {
+			result = append(result, image)
+			continue
+		}
+		if i, ok := latest[image.Family]; ok {
+			if image.CreatedAt.After(result[i].CreatedAt) {
+				result[i] = image
+			}
+			continue
+		}
+		latest[image.Family] = len(result)
+		result = append(result, image)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Family != result[j].Family {
+			return result[i].Family < result[j].Family
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
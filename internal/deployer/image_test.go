@@ -0,0 +1,28 @@
+package deployer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatestImages(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	images := []Image{
+		{ID: "fd8ubuntu1", Name: "ubuntu-22-04-docker-v20240501", Family: "ubuntu-22-04-docker", CreatedAt: day(1)},
+		{ID: "fd8custom1", Name: "custom-runner", CreatedAt: day(2)},
+		{ID: "fd8ubuntu3", Name: "ubuntu-22-04-docker-v20240503", Family: "ubuntu-22-04-docker", CreatedAt: day(3)},
+		{ID: "fd8debian2", Name: "debian-12-v20240502", Family: "debian-12", CreatedAt: day(2)},
+		{ID: "fd8ubuntu2", Name: "ubuntu-22-04-docker-v20240502", Family: "ubuntu-22-04-docker", CreatedAt: day(2)},
+	}
+
+	got := LatestImages(images)
+	want := []string{"fd8custom1", "fd8debian2", "fd8ubuntu3"}
+	if len(got) != len(want) {
+		t.Fatalf("LatestImages() = %+v, want IDs %v", got, want)
+	}
+	for i, id := range want {
+		if got[i].ID != id {
+			t.Errorf("LatestImages()[%d].ID = %s, want %s", i, got[i].ID, id)
+		}
+	}
+}
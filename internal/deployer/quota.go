@@ -0,0 +1,71 @@
+package deployer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// QuotaKind identifies what a quota limits, independent of the cloud
+type QuotaKind string
+
+const (
+	QuotaVCPUs                 QuotaKind = "vcpus"
+	QuotaMemoryGB              QuotaKind = "memory_gb"
+	QuotaDiskGB                QuotaKind = "disk_gb"
+	QuotaServerlessConcurrency QuotaKind = "serverless_concurrency"
+)
+
+// Quota is a limit of the target cloud account, converted to the units of its kind
+type Quota struct {
+	Kind  QuotaKind
+	Name  string  // Cloud name of the quota, e.g. compute.instanceCores.count
+	Limit float64 // Maximum allowed
+	Usage float64 // Already used, zero when the cloud does not report usage
+}
+
+// Available returns how much of the quota is left
+func (q Quota) Available() float64 {
+	return q.Limit - q.Usage
+}
+
+// QuotaShortfall is a quota the Eggs would exceed
+type QuotaShortfall struct {
+	Quota
+	Requested float64
+}
+
+func (s QuotaShortfall) String() string {
+	return fmt.Sprintf("%s (%s): requested %g, available %g of %g", s.Kind, s.Name, s.Requested, s.Available(), s.Limit)
+}
+
+// QuotaDemand returns what eggs need of each kind of quota. Every VM Egg runs
+// one instance; serverless Eggs need their concurrent jobs as concurrency.
+// Kubernetes Eggs run in existing clusters and need none.
+func QuotaDemand(eggs []*EggConfig) map[QuotaKind]float64 {
+	demand := make(map[QuotaKind]float64)
+	for _, egg := range eggs {
+		switch egg.Type {
+		case RunnerTypeVM:
+			demand[QuotaVCPUs] += float64(egg.Resources.CPU)
+			demand[QuotaMemoryGB] += float64(egg.Resources.Memory) / 1024
+			demand[QuotaDiskGB] += float64(egg.Resources.Disk)
+		case RunnerTypeServerless:
+			demand[QuotaServerlessConcurrency] += float64(max(egg.Runner.Concurrent, 1))
+		}
+	}
+	return demand
+}
+
+// CheckQuotas returns the quotas that the combined demand of eggs would
+// exceed, sorted by kind
+func CheckQuotas(eggs []*EggConfig, quotas []Quota) []QuotaShortfall {
+	demand := QuotaDemand(eggs)
+	var shortfalls []QuotaShortfall
+	for _, quota := range quotas {
+		if requested := demand[quota.Kind]; requested > quota.Available() {
+			shortfalls = append(shortfalls, QuotaShortfall{Quota: quota, Requested: requested})
+		}
+	}
+	sort.Slice(shortfalls, func(i, j int) bool { return shortfalls[i].Kind < shortfalls[j].Kind })
+	return shortfalls
+}
@@ -0,0 +1,36 @@
+package deployer
+
+import (
+	"testing"
+)
+
+func TestCheckQuotas(t *testing.T) {
+	eggs := []*EggConfig{
+		{Name: "a", Type: RunnerTypeVM, Resources: ResourceConfig{CPU: 4, Memory: 8192, Disk: 80}},
+		{Name: "b", Type: RunnerTypeVM, Resources: ResourceConfig{CPU: 4, Memory: 8192, Disk: 80}},
+		{Name: "c", Type: RunnerTypeServerless, Resources: ResourceConfig{Memory: 2048}, Runner: RunnerConfig{Concurrent: 5}},
+	}
+
+	quotas := []Quota{
+		{Kind: QuotaVCPUs, Name: "compute.instanceCores.count", Limit: 16, Usage: 10},
+		{Kind: QuotaMemoryGB, Name: "compute.instanceMemory.size", Limit: 64},
+		{Kind: QuotaDiskGB, Name: "compute.ssdDisks.size", Limit: 200, Usage: 40},
+		{Kind: QuotaServerlessConcurrency, Name: "lambda/ConcurrentExecutions", Limit: 10, Usage: 5},
+	}
+
+	shortfalls := CheckQuotas(eggs, quotas)
+	if len(shortfalls) != 1 {
+		t.Fatalf("CheckQuotas() = %v, want only the vCPU quota", shortfalls)
+	}
+	if s := shortfalls[0]; s.Kind != QuotaVCPUs || s.Requested != 8 || s.Available() != 6 {
+		t.Errorf("shortfall = %+v, want 8 vCPUs requested with 6 available", s)
+	}
+}
+
+func TestCheckQuotasIgnoresUnknownKinds(t *testing.T) {
+	eggs := []*EggConfig{{Name: "a", Type: RunnerTypeServerless}}
+	quotas := []Quota{{Kind: QuotaVCPUs, Name: "compute.instanceCores.count", Limit: 1}}
+	if shortfalls := CheckQuotas(eggs, quotas); len(shortfalls) != 0 {
+		t.Errorf("CheckQuotas() = %v, want none", shortfalls)
+	}
+}
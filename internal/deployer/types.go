@@ -2,6 +2,7 @@ package deployer
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -11,6 +12,7 @@ type CloudProvider string
 const (
 	CloudProviderYandex CloudProvider = "yandex"
 	CloudProviderAWS    CloudProvider = "aws"
+	CloudProviderAzure  CloudProvider = "azure"
 )
 
 // RunnerType represents the type of runner
@@ -19,6 +21,7 @@ type RunnerType string
 const (
 	RunnerTypeVM         RunnerType = "vm"
 	RunnerTypeServerless RunnerType = "serverless"
+	RunnerTypeKubernetes RunnerType = "kubernetes"
 )
 
 // CloudConfig represents cloud provider configuration
@@ -29,9 +32,21 @@ type CloudConfig struct {
 
 // ResourceConfig represents resource requirements
 type ResourceConfig struct {
-	CPU    int // Number of CPU cores
-	Memory int // Memory in MB
-	Disk   int // Disk size in GB
+	CPU      int    // Number of CPU cores
+	Memory   int    // Memory in MB
+	Disk     int    // Disk size in GB
+	GPU      int    `json:",omitempty"` // Number of GPUs of a VM runner
+	GPUType  string `json:",omitempty"` // GPU model (e.g. t4, a100)
+	Platform string `json:",omitempty"` // Yandex platform or AWS instance family (e.g. standard-v3, c7g)
+}
+
+// NetworkConfig represents the network VM runners are placed in. Empty IDs
+// are left to the variables of the generated deployment.
+type NetworkConfig struct {
+	VPCID           string
+	SubnetID        string
+	SecurityGroups  []string
+	DisablePublicIP bool // Runners get a public IP address unless set
 }
 
 // RunnerConfig represents runner-specific configuration
@@ -39,12 +54,80 @@ type RunnerConfig struct {
 	Tags        []string
 	Concurrent  int
 	IdleTimeout time.Duration
+	JobTimeout  time.Duration `json:",omitempty"` // Serverless runners only; zero for the provider's limit
+	Image       string        // Image family of VM runners; empty for the default
+	ImageID     string        // Provider image ID of VM runners, set instead of Image
+	OS          string        `json:",omitempty"` // linux, windows or macos; empty for linux
+	Arch        string        `json:",omitempty"` // amd64 or arm64; empty for amd64
+	Privileged  bool          `json:",omitempty"` // Job containers run privileged
+	DockerDinD  bool          `json:",omitempty"` // Jobs get a Docker-in-Docker daemon
+	Cache       *CacheConfig  `json:",omitempty"` // Nil without a cache block
+}
+
+// CacheConfig represents the cache shared by a runner's jobs
+type CacheConfig struct {
+	Type              string // s3, gcs or local
+	Bucket            string `json:",omitempty"`
+	Path              string `json:",omitempty"` // Prefix in the bucket, or directory of a local cache
+	ServerAddress     string `json:",omitempty"` // S3-compatible endpoint
+	CredentialsSecret string `json:",omitempty"` // Secret URI, resolved by the runner
+	Shared            bool   `json:",omitempty"`
+}
+
+// Environment returns the variables configuring the cache of the GitLab
+// Runner Agent: the CACHE_* variables of gitlab-runner, and
+// GOSLING_CACHE_CREDENTIALS_SECRET for the runner to resolve
+func (c CacheConfig) Environment() map[string]string {
+	env := make(map[string]string)
+	switch c.Type {
+	case "s3":
+		env["CACHE_TYPE"] = c.Type
+		env["CACHE_S3_BUCKET_NAME"] = c.Bucket
+		if c.ServerAddress != "" {
+			env["CACHE_S3_SERVER_ADDRESS"] = c.ServerAddress
+		}
+	case "gcs":
+		env["CACHE_TYPE"] = c.Type
+		env["CACHE_GCS_BUCKET_NAME"] = c.Bucket
+	case "local":
+		if c.Path != "" {
+			env["CACHE_DIR"] = c.Path
+		}
+		return env
+	}
+	if c.Path != "" {
+		env["CACHE_PATH"] = c.Path
+	}
+	if c.Shared {
+		env["CACHE_SHARED"] = "true"
+	}
+	if c.CredentialsSecret != "" {
+		env["GOSLING_CACHE_CREDENTIALS_SECRET"] = c.CredentialsSecret
+	}
+	return env
+}
+
+// AutoscalingConfig represents the autoscaler MotherGoose provisions for the
+// VM runners of an egg, apart from the UglyFox pools
+type AutoscalingConfig struct {
+	Min              int
+	Max              int
+	ScaleUpThreshold int           // Percentage of busy job slots above which a runner is added
+	ScaleDownDelay   time.Duration // How long load stays below the threshold before a runner is removed
+}
+
+// String summarizes the autoscaler, e.g. "1-10 runners, up at 80%, down after 10m0s"
+func (a AutoscalingConfig) String() string {
+	return fmt.Sprintf("%d-%d runners, up at %d%%, down after %s", a.Min, a.Max, a.ScaleUpThreshold, a.ScaleDownDelay)
 }
 
 // GitLabConfig represents GitLab integration configuration
 type GitLabConfig struct {
 	ProjectID   int
-	TokenSecret string // Secret URI (yc-lockbox://, aws-sm://, vault://)
+	GroupID     int    // Set instead of ProjectID for a group runner
+	ServerName  string // GitLab server hostname
+	TokenSecret string // Secret URI (yc-lockbox://, aws-sm://, azure-kv://, vault://)
+	CACert      string // CA certificate of a self-hosted server: PEM or secret URI
 }
 
 // EggConfig represents a complete Egg configuration
@@ -53,9 +136,12 @@ type EggConfig struct {
 	Type        RunnerType
 	Cloud       CloudConfig
 	Resources   ResourceConfig
+	Network     NetworkConfig
 	Runner      RunnerConfig
 	GitLab      GitLabConfig
 	Environment map[string]string
+	Autoscaling *AutoscalingConfig `json:",omitempty"` // Set for VM runners with an autoscaling block
+	Kubernetes  *KubernetesConfig  `json:",omitempty"` // Set for kubernetes runners
 }
 
 // EggsBucketConfig represents a configuration for multiple repositories
@@ -69,6 +155,63 @@ type EggsBucketConfig struct {
 	Environment  map[string]string
 }
 
+// JobConfig represents a self-management task from Jobs/<name>.fly, run by
+// MotherGoose on schedule on a runner matching its type and tags
+type JobConfig struct {
+	Name     string
+	Schedule string // Cron expression
+	Script   string
+	Runner   JobRunnerConfig
+}
+
+// JobRunnerConfig selects the runner a job executes on
+type JobRunnerConfig struct {
+	Type RunnerType
+	Tags []string
+}
+
+// UglyFoxConfig represents the runner lifecycle configuration of
+// UF/config.fly that UglyFox applies: when runners are pruned, how the apex
+// and nadir pools are sized and the policy rules
+type UglyFoxConfig struct {
+	Pruning    PruningConfig
+	Conditions []RunnersConditionConfig `json:",omitempty"` // Pools per group of Eggs
+	Apex       *PoolConfig              `json:",omitempty"` // Set with Nadir when one pair of pools applies to every Egg
+	Nadir      *PoolConfig              `json:",omitempty"`
+	Policies   []PolicyRuleConfig       `json:",omitempty"`
+}
+
+// PruningConfig represents when UglyFox terminates failed or old runners
+type PruningConfig struct {
+	FailedThreshold int
+	MaxAge          time.Duration
+	CheckInterval   time.Duration
+}
+
+// PoolConfig represents the sizing of an apex (active) or nadir (idle) pool
+type PoolConfig struct {
+	MaxCount        int
+	MinCount        int
+	CPUThreshold    float64       `json:",omitempty"` // Apex: CPU percentage that triggers promotion
+	MemoryThreshold float64       `json:",omitempty"` // Apex: memory percentage that triggers promotion
+	IdleTimeout     time.Duration `json:",omitempty"` // Nadir: idle time before demotion
+}
+
+// RunnersConditionConfig represents the pools of a group of Eggs
+type RunnersConditionConfig struct {
+	Name         string
+	EggsEntities []string
+	Apex         PoolConfig
+	Nadir        PoolConfig
+}
+
+// PolicyRuleConfig represents a runner lifecycle policy rule
+type PolicyRuleConfig struct {
+	Name      string
+	Condition string
+	Action    string
+}
+
 // RepositoryConfig represents a single repository in an EggsBucket
 type RepositoryConfig struct {
 	Name   string
@@ -80,6 +223,29 @@ type VMConfig struct {
 	EggName     string
 	Cloud       CloudConfig
 	Resources   ResourceConfig
+	VMSize      string // Provider instance size, set for Azure (e.g. Standard_B2s)
+	Network     NetworkConfig
+	Runner      RunnerConfig
+	GitLab      GitLabConfig
+	Environment map[string]string
+	Autoscaling *AutoscalingConfig // Nil without an autoscaling block
+}
+
+// KubernetesConfig represents the existing cluster kubernetes runners are
+// deployed to
+type KubernetesConfig struct {
+	Namespace        string
+	NodeSelector     map[string]string `json:",omitempty"` // Node labels of the job pods
+	KubeconfigSecret string            // Secret URI, resolved by MotherGoose
+}
+
+// K8sConfig represents the deployment of a runner using the Kubernetes
+// executor in an existing cluster. Resources are the requests of each job pod.
+type K8sConfig struct {
+	EggName     string
+	Cloud       CloudConfig
+	Resources   ResourceConfig
+	Kubernetes  KubernetesConfig
 	Runner      RunnerConfig
 	GitLab      GitLabConfig
 	Environment map[string]string
@@ -139,7 +305,19 @@ type DeploymentPlan struct {
 	ConfigHash   string
 	CreatedAt    time.Time
 	AppliedAt    *time.Time
-	Status       string // "pending", "applied", "rolled_back"
+	Status       string // One of the PlanStatus values
+	Error        string // Why a failed plan could not be applied
 	RollbackPlan string // ID of the plan to rollback to
 	Metadata     map[string]interface{}
+	Signature    []byte // Ed25519 signature, see signing.SignPlan; empty if unsigned
+	SigningKeyID string // ID of the key that produced Signature
 }
+
+// Values for DeploymentPlan.Status
+const (
+	PlanStatusPending    = "pending"  // Submitted, not yet applied
+	PlanStatusApplying   = "applying" // MotherGoose is applying the plan
+	PlanStatusApplied    = "applied"
+	PlanStatusFailed     = "failed"
+	PlanStatusRolledBack = "rolled_back"
+)
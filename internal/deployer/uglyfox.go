@@ -0,0 +1,302 @@
+package deployer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// PruningInfo represents a pruning block from parser
+type PruningInfo struct {
+	FailedThreshold int
+	MaxAge          string
+	CheckInterval   string
+}
+
+// PoolInfo represents an apex or nadir block from parser
+type PoolInfo struct {
+	MaxCount        int
+	MinCount        int
+	CPUThreshold    float64 // Apex only
+	MemoryThreshold float64 // Apex only
+	IdleTimeout     string  // Nadir only
+}
+
+// RunnersConditionInfo represents a runners_condition block from parser
+type RunnersConditionInfo struct {
+	Name         string
+	EggsEntities []string
+	Apex         PoolInfo
+	Nadir        PoolInfo
+}
+
+// PolicyRuleInfo represents a rule block of policies from parser
+type PolicyRuleInfo struct {
+	Name      string
+	Condition string
+	Action    string
+}
+
+// ParsedUglyFoxConfig represents a parsed uglyfox configuration
+type ParsedUglyFoxConfig struct {
+	Pruning    PruningInfo
+	Conditions []RunnersConditionInfo
+	Apex       *PoolInfo // Nil when the pools are sized per runners_condition
+	Nadir      *PoolInfo // Nil when the pools are sized per runners_condition
+	Rules      []PolicyRuleInfo
+	// Positions locates the fields in the .fly file for error messages, with
+	// paths such as "pruning.max_age" or "runners_condition.<name>.nadir"
+	Positions Positions
+}
+
+// ParseUglyFox parses an uglyfox block into a ParsedUglyFoxConfig
+func ParseUglyFox(block *parser.Block) (*ParsedUglyFoxConfig, error) {
+	if block.Type != "uglyfox" {
+		return nil, fmt.Errorf("expected 'uglyfox' block, got '%s'", block.Type)
+	}
+
+	uf := &ParsedUglyFoxConfig{Positions: make(Positions)}
+	uf.Positions.record("", block)
+
+	if pruningBlock, ok := block.GetBlock("pruning"); ok {
+		uf.Positions.record("pruning", pruningBlock)
+		pruning, err := parsePruningBlock(pruningBlock)
+		if err != nil {
+			return nil, err
+		}
+		uf.Pruning = pruning
+	}
+
+	conditionBlocks := block.GetBlocks("runners_condition")
+	for i := range conditionBlocks {
+		conditionBlock := &conditionBlocks[i]
+		if len(conditionBlock.Labels) == 0 {
+			return nil, fmt.Errorf("%s: runners_condition block must have a name label", conditionBlock.Position)
+		}
+		condition := RunnersConditionInfo{Name: conditionBlock.Labels[0]}
+		path := "runners_condition." + condition.Name
+		uf.Positions.record(path, conditionBlock)
+
+		if val, ok := conditionBlock.GetAttribute("eggs_entities"); ok {
+			entities, err := stringList(val, "eggs_entities")
+			if err != nil {
+				return nil, err
+			}
+			condition.EggsEntities = entities
+		}
+		for _, pool := range []struct {
+			name string
+			info *PoolInfo
+		}{{"apex", &condition.Apex}, {"nadir", &condition.Nadir}} {
+			if poolBlock, ok := conditionBlock.GetBlock(pool.name); ok {
+				uf.Positions.record(path+"."+pool.name, poolBlock)
+				info, err := parsePoolBlock(poolBlock)
+				if err != nil {
+					return nil, err
+				}
+				*pool.info = info
+			}
+		}
+		uf.Conditions = append(uf.Conditions, condition)
+	}
+
+	if apexBlock, ok := block.GetBlock("apex"); ok {
+		uf.Positions.record("apex", apexBlock)
+		apex, err := parsePoolBlock(apexBlock)
+		if err != nil {
+			return nil, err
+		}
+		uf.Apex = &apex
+	}
+
+	if nadirBlock, ok := block.GetBlock("nadir"); ok {
+		uf.Positions.record("nadir", nadirBlock)
+		nadir, err := parsePoolBlock(nadirBlock)
+		if err != nil {
+			return nil, err
+		}
+		uf.Nadir = &nadir
+	}
+
+	if policiesBlock, ok := block.GetBlock("policies"); ok {
+		ruleBlocks := policiesBlock.GetBlocks("rule")
+		for i := range ruleBlocks {
+			ruleBlock := &ruleBlocks[i]
+			rule := PolicyRuleInfo{}
+			if len(ruleBlock.Labels) > 0 {
+				rule.Name = ruleBlock.Labels[0]
+			}
+			for name, field := range map[string]*string{"condition": &rule.Condition, "action": &rule.Action} {
+				if val, ok := ruleBlock.GetAttribute(name); ok {
+					str, err := val.AsString()
+					if err != nil {
+						return nil, fmt.Errorf("%s: invalid %s: %w", val.Position, name, err)
+					}
+					*field = str
+				}
+			}
+			uf.Rules = append(uf.Rules, rule)
+		}
+	}
+
+	return uf, nil
+}
+
+func parsePruningBlock(block *parser.Block) (PruningInfo, error) {
+	pruning := PruningInfo{}
+
+	if val, ok := block.GetAttribute("failed_threshold"); ok {
+		threshold, err := val.AsInt()
+		if err != nil {
+			return pruning, fmt.Errorf("%s: invalid failed_threshold: %w", val.Position, err)
+		}
+		pruning.FailedThreshold = threshold
+	}
+
+	if val, ok := block.GetAttribute("max_age"); ok {
+		maxAge, err := val.AsString()
+		if err != nil {
+			return pruning, fmt.Errorf("%s: invalid max_age: %w", val.Position, err)
+		}
+		pruning.MaxAge = maxAge
+	}
+
+	if val, ok := block.GetAttribute("check_interval"); ok {
+		interval, err := val.AsString()
+		if err != nil {
+			return pruning, fmt.Errorf("%s: invalid check_interval: %w", val.Position, err)
+		}
+		pruning.CheckInterval = interval
+	}
+
+	return pruning, nil
+}
+
+func parsePoolBlock(block *parser.Block) (PoolInfo, error) {
+	pool := PoolInfo{}
+
+	for name, field := range map[string]*int{"max_count": &pool.MaxCount, "min_count": &pool.MinCount} {
+		if val, ok := block.GetAttribute(name); ok {
+			count, err := val.AsInt()
+			if err != nil {
+				return pool, fmt.Errorf("%s: invalid %s: %w", val.Position, name, err)
+			}
+			*field = count
+		}
+	}
+
+	for name, field := range map[string]*float64{"cpu_threshold": &pool.CPUThreshold, "memory_threshold": &pool.MemoryThreshold} {
+		if val, ok := block.GetAttribute(name); ok {
+			threshold, err := val.AsNumber()
+			if err != nil {
+				return pool, fmt.Errorf("%s: invalid %s: %w", val.Position, name, err)
+			}
+			*field = threshold
+		}
+	}
+
+	if val, ok := block.GetAttribute("idle_timeout"); ok {
+		idleTimeout, err := val.AsString()
+		if err != nil {
+			return pool, fmt.Errorf("%s: invalid idle_timeout: %w", val.Position, err)
+		}
+		pool.IdleTimeout = idleTimeout
+	}
+
+	return pool, nil
+}
+
+// stringList returns the strings of a list attribute
+func stringList(val parser.Value, name string) ([]string, error) {
+	list, err := val.AsList()
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid %s: %w", val.Position, name, err)
+	}
+	strs := make([]string, len(list))
+	for i, elem := range list {
+		str, err := elem.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid %s at index %d: %w", elem.Position, name, i, err)
+		}
+		strs[i] = str
+	}
+	return strs, nil
+}
+
+// UglyFoxToConfig converts a parsed uglyfox configuration to the runner
+// lifecycle configuration sent to MotherGoose
+func (c *Converter) UglyFoxToConfig(uf *ParsedUglyFoxConfig) (*UglyFoxConfig, error) {
+	maxAge, err := time.ParseDuration(uf.Pruning.MaxAge)
+	if err != nil {
+		return nil, uf.Positions.errorf("pruning.max_age", "invalid max age: %w", err)
+	}
+	checkInterval, err := time.ParseDuration(uf.Pruning.CheckInterval)
+	if err != nil {
+		return nil, uf.Positions.errorf("pruning.check_interval", "invalid check interval: %w", err)
+	}
+
+	config := &UglyFoxConfig{
+		Pruning: PruningConfig{
+			FailedThreshold: uf.Pruning.FailedThreshold,
+			MaxAge:          maxAge,
+			CheckInterval:   checkInterval,
+		},
+	}
+	for _, condition := range uf.Conditions {
+		path := "runners_condition." + condition.Name
+		apex, err := poolConfig(condition.Apex, path+".apex", uf.Positions)
+		if err != nil {
+			return nil, err
+		}
+		nadir, err := poolConfig(condition.Nadir, path+".nadir", uf.Positions)
+		if err != nil {
+			return nil, err
+		}
+		config.Conditions = append(config.Conditions, RunnersConditionConfig{
+			Name:         condition.Name,
+			EggsEntities: condition.EggsEntities,
+			Apex:         apex,
+			Nadir:        nadir,
+		})
+	}
+	if uf.Apex != nil {
+		apex, err := poolConfig(*uf.Apex, "apex", uf.Positions)
+		if err != nil {
+			return nil, err
+		}
+		config.Apex = &apex
+	}
+	if uf.Nadir != nil {
+		nadir, err := poolConfig(*uf.Nadir, "nadir", uf.Positions)
+		if err != nil {
+			return nil, err
+		}
+		config.Nadir = &nadir
+	}
+	for _, rule := range uf.Rules {
+		config.Policies = append(config.Policies, PolicyRuleConfig(rule))
+	}
+	return config, nil
+}
+
+// poolConfig converts an apex or nadir pool found at path
+func poolConfig(pool PoolInfo, path string, positions Positions) (PoolConfig, error) {
+	if pool.MinCount > pool.MaxCount {
+		return PoolConfig{}, positions.errorf(path+".min_count", "min_count (%d) exceeds max_count (%d)", pool.MinCount, pool.MaxCount)
+	}
+	config := PoolConfig{
+		MaxCount:        pool.MaxCount,
+		MinCount:        pool.MinCount,
+		CPUThreshold:    pool.CPUThreshold,
+		MemoryThreshold: pool.MemoryThreshold,
+	}
+	if pool.IdleTimeout != "" {
+		idleTimeout, err := time.ParseDuration(pool.IdleTimeout)
+		if err != nil {
+			return PoolConfig{}, positions.errorf(path+".idle_timeout", "invalid idle timeout: %w", err)
+		}
+		config.IdleTimeout = idleTimeout
+	}
+	return config, nil
+}
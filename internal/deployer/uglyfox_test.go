@@ -0,0 +1,99 @@
+package deployer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+const testUglyFoxConfig = `uglyfox {
+  pruning {
+    failed_threshold = 3
+    max_age          = "24h"
+    check_interval   = "5m"
+  }
+
+  runners_condition "default" {
+    eggs_entities = ["my-app", "my-api"]
+
+    apex {
+      max_count     = 10
+      min_count     = 2
+      cpu_threshold = 80
+    }
+
+    nadir {
+      max_count    = 5
+      min_count    = %s
+      idle_timeout = "30m"
+    }
+  }
+
+  policies {
+    rule "terminate_old_failed" {
+      condition = "failed_count >= 3 AND age > 1h"
+      action    = "terminate"
+    }
+  }
+}
+`
+
+func parseTestUglyFox(t *testing.T, nadirMin string) *ParsedUglyFoxConfig {
+	t.Helper()
+	config, err := parser.NewParser().Parse([]byte(strings.Replace(testUglyFoxConfig, "%s", nadirMin, 1)), "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	uf, err := ParseUglyFox(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseUglyFox failed: %v", err)
+	}
+	return uf
+}
+
+func TestUglyFoxToConfig(t *testing.T) {
+	uf := parseTestUglyFox(t, "0")
+	config, err := NewConverter().UglyFoxToConfig(uf)
+	if err != nil {
+		t.Fatalf("UglyFoxToConfig failed: %v", err)
+	}
+
+	if config.Pruning != (PruningConfig{FailedThreshold: 3, MaxAge: 24 * time.Hour, CheckInterval: 5 * time.Minute}) {
+		t.Errorf("unexpected pruning %+v", config.Pruning)
+	}
+	if len(config.Conditions) != 1 {
+		t.Fatalf("expected one runners_condition, got %+v", config.Conditions)
+	}
+	condition := config.Conditions[0]
+	if condition.Name != "default" || strings.Join(condition.EggsEntities, ",") != "my-app,my-api" {
+		t.Errorf("unexpected condition %+v", condition)
+	}
+	if condition.Apex != (PoolConfig{MaxCount: 10, MinCount: 2, CPUThreshold: 80}) {
+		t.Errorf("unexpected apex pool %+v", condition.Apex)
+	}
+	if condition.Nadir != (PoolConfig{MaxCount: 5, IdleTimeout: 30 * time.Minute}) {
+		t.Errorf("unexpected nadir pool %+v", condition.Nadir)
+	}
+	if config.Apex != nil || config.Nadir != nil {
+		t.Error("expected no Nest-wide pools")
+	}
+	if len(config.Policies) != 1 || config.Policies[0] != (PolicyRuleConfig{Name: "terminate_old_failed", Condition: "failed_count >= 3 AND age > 1h", Action: "terminate"}) {
+		t.Errorf("unexpected policies %+v", config.Policies)
+	}
+}
+
+func TestUglyFoxToConfigErrorPositions(t *testing.T) {
+	_, err := NewConverter().UglyFoxToConfig(parseTestUglyFox(t, "6"))
+	if err == nil || !strings.Contains(err.Error(), "config.fly:19:22: min_count (6) exceeds max_count (5)") {
+		t.Errorf("expected the nadir min_count position, got %v", err)
+	}
+
+	uf := parseTestUglyFox(t, "0")
+	uf.Pruning.MaxAge = "a day"
+	_, err = NewConverter().UglyFoxToConfig(uf)
+	if err == nil || !strings.HasPrefix(err.Error(), "config.fly:4:24: invalid max age") {
+		t.Errorf("expected the max_age position, got %v", err)
+	}
+}
@@ -3,13 +3,33 @@ package deployer
 import (
 	"context"
 	"fmt"
+	"os"
 
+	"github.com/yandex-cloud/go-genproto/yandex/cloud/compute/v1"
+	quotamanager "github.com/yandex-cloud/go-genproto/yandex/cloud/quotamanager/v1"
+	"github.com/yandex-cloud/go-genproto/yandex/cloud/resourcemanager/v1"
 	ycsdk "github.com/yandex-cloud/go-sdk"
 )
 
+// yandexComputeQuotas maps the Compute Cloud quotas checked before deploy to
+// their kind and the factor converting the quota unit to the kind's unit
+var yandexComputeQuotas = []struct {
+	id     string
+	kind   QuotaKind
+	factor float64
+}{
+	{"compute.instanceCores.count", QuotaVCPUs, 1},
+	{"compute.instanceMemory.size", QuotaMemoryGB, 1.0 / (1 << 30)},
+	{"compute.ssdDisks.size", QuotaDiskGB, 1.0 / (1 << 30)},
+}
+
+// yandexStandardImages is the folder of the public Yandex Cloud images
+const yandexStandardImages = "standard-images"
+
 // YandexCloudClient wraps the Yandex Cloud Go SDK for deploying backend infrastructure
 // Note: Individual runner deployment is handled by MotherGoose using OpenTofu
 type YandexCloudClient struct {
+	unimplementedProvisioner
 	sdk      *ycsdk.SDK
 	folderID string
 }
@@ -27,10 +47,9 @@ func NewYandexCloudClient(ctx context.Context) (*YandexCloudClient, error) {
 		return nil, fmt.Errorf("failed to create Yandex Cloud SDK: %w", err)
 	}
 
-	// Get folder ID from environment variable or default
-	folderID := ""
 	// The folder ID should be provided via environment variable YC_FOLDER_ID
 	// or through the service account configuration
+	folderID := os.Getenv("YC_FOLDER_ID")
 
 	return &YandexCloudClient{
 		sdk:      sdk,
@@ -39,7 +58,7 @@ func NewYandexCloudClient(ctx context.Context) (*YandexCloudClient, error) {
 }
 
 // DeployBackendInfrastructure deploys MotherGoose, UglyFox, YDB, and S3 buckets
-func (c *YandexCloudClient) DeployBackendInfrastructure(ctx context.Context) error {
+func (c *YandexCloudClient) DeployBackendInfrastructure(ctx context.Context, cfg *BackendConfig, done func(BackendResource)) error {
 	// TODO: Implement deployment of:
 	// - MotherGoose Cloud Function
 	// - UglyFox Cloud Function
@@ -47,7 +66,7 @@ func (c *YandexCloudClient) DeployBackendInfrastructure(ctx context.Context) err
 	// - S3 buckets (tofu-states, tofu-binaries, tofu-cache)
 	// - API Gateway
 	// - YMQ queues for Celery
-	return fmt.Errorf("not yet implemented")
+	return provisionBackend(ctx, c, cfg, done)
 }
 
 // GetStatus retrieves the status of infrastructure resources
@@ -55,3 +74,67 @@ func (c *YandexCloudClient) GetStatus(ctx context.Context, resourceID string) (s
 	// TODO: Implement status checking for backend infrastructure
 	return "", fmt.Errorf("not yet implemented")
 }
+
+// Quotas returns the Compute Cloud quotas of the cloud containing the folder.
+// Quotas are set per cloud, so usage includes every folder of the cloud.
+func (c *YandexCloudClient) Quotas(ctx context.Context) ([]Quota, error) {
+	if c.folderID == "" {
+		return nil, fmt.Errorf("YC_FOLDER_ID is not set")
+	}
+	folder, err := c.sdk.ResourceManager().Folder().Get(ctx, &resourcemanager.GetFolderRequest{FolderId: c.folderID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folder %s: %w", c.folderID, err)
+	}
+	cloud := &quotamanager.Resource{Id: folder.GetCloudId(), Type: "resource-manager.cloud"}
+
+	quotas := make([]Quota, 0, len(yandexComputeQuotas))
+	for _, q := range yandexComputeQuotas {
+		limit, err := c.sdk.QuotaManager().QuotaLimit().Get(ctx, &quotamanager.GetQuotaLimitRequest{Resource: cloud, QuotaId: q.id})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get quota %s: %w", q.id, err)
+		}
+		quotas = append(quotas, Quota{
+			Kind:  q.kind,
+			Name:  q.id,
+			Limit: limit.GetLimit().GetValue() * q.factor,
+			Usage: limit.GetUsage().GetValue() * q.factor,
+		})
+	}
+	return quotas, nil
+}
+
+// Images returns the ready images of the public image folder and, when
+// YC_FOLDER_ID is set, of the folder runners are deployed to
+func (c *YandexCloudClient) Images(ctx context.Context) ([]Image, error) {
+	folders := []string{yandexStandardImages}
+	if c.folderID != "" {
+		folders = append(folders, c.folderID)
+	}
+	var images []Image
+	for _, folderID := range folders {
+		req := &compute.ListImagesRequest{FolderId: folderID, PageSize: 1000}
+		for {
+			resp, err := c.sdk.Compute().Image().List(ctx, req)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list images of folder %s: %w", folderID, err)
+			}
+			for _, image := range resp.GetImages() {
+				if image.GetStatus() != compute.Image_READY {
+					continue
+				}
+				images = append(images, Image{
+					ID:          image.GetId(),
+					Name:        image.GetName(),
+					Family:      image.GetFamily(),
+					Description: image.GetDescription(),
+					CreatedAt:   image.GetCreatedAt().AsTime(),
+				})
+			}
+			if resp.GetNextPageToken() == "" {
+				break
+			}
+			req.PageToken = resp.GetNextPageToken()
+		}
+	}
+	return images, nil
+}
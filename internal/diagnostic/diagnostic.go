@@ -0,0 +1,294 @@
+// Package diagnostic renders problems found in .fly files for people: the
+// offending source line with the problem underlined, its severity in color
+// and its code, which links to the documentation of the problem.
+package diagnostic
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// Severity is how serious a diagnostic is
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem at a position of a .fly file
+type Diagnostic struct {
+	Severity Severity
+	Code     string // e.g. GSL2003 or a lint rule ID; empty when the problem has none
+	URL      string // Documentation of Code, if any
+	Message  string
+	Detail   string // Further explanation, if any
+	File     string // Empty when the problem has no position
+	Line     int    // 1-based
+	Column   int    // 1-based
+	// EndColumn is where the problem ends on Line. When zero, the word or
+	// quoted string starting at Column is underlined.
+	EndColumn int
+}
+
+// FromError returns the diagnostics of an error returned by the parser or
+// validator, or a single diagnostic without a position for any other error
+func FromError(err error) []Diagnostic {
+	var syntaxErr *parser.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		var diags []Diagnostic
+		for _, diag := range syntaxErr.Diagnostics {
+			diags = append(diags, fromHCL(diag))
+		}
+		return diags
+	}
+	var result *parser.ValidationResult
+	if errors.As(err, &result) {
+		diags := make([]Diagnostic, 0, len(result.Errors))
+		for _, verr := range result.Errors {
+			diags = append(diags, FromValidationError(verr))
+		}
+		return diags
+	}
+	return []Diagnostic{{Severity: SeverityError, Message: err.Error()}}
+}
+
+// FromValidationError returns the diagnostic of a validation error or
+// warning
+func FromValidationError(verr *parser.ValidationError) Diagnostic {
+	d := Diagnostic{
+		Severity: SeverityError,
+		Code:     verr.Code,
+		Message:  verr.Message,
+		File:     verr.Position.File,
+		Line:     verr.Position.Line,
+		Column:   verr.Position.Column,
+	}
+	if verr.Severity == parser.SeverityWarning {
+		d.Severity = SeverityWarning
+	}
+	if d.Code != "" {
+		d.URL = parser.CodeURL(d.Code)
+	}
+	return d
+}
+
+func fromHCL(diag *hcl.Diagnostic) Diagnostic {
+	code := parser.DiagnosticCode(diag)
+	d := Diagnostic{
+		Severity: SeverityError,
+		Code:     code,
+		URL:      parser.CodeURL(code),
+		Message:  diag.Summary,
+		Detail:   diag.Detail,
+	}
+	if diag.Severity == hcl.DiagWarning {
+		d.Severity = SeverityWarning
+	}
+	if diag.Subject != nil {
+		d.File = diag.Subject.Filename
+		d.Line = diag.Subject.Start.Line
+		d.Column = diag.Subject.Start.Column
+		if diag.Subject.End.Line == d.Line && diag.Subject.End.Column > d.Column {
+			d.EndColumn = diag.Subject.End.Column
+		}
+	}
+	return d
+}
+
+// ANSI escape sequences of the rendered diagnostics
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[1;31m"
+	ansiYellow = "\033[1;33m"
+	ansiBlue   = "\033[1;34m"
+)
+
+// Renderer writes diagnostics with the source lines they are on:
+//
+//	error[GSL2007]: type must be one of [vm serverless kubernetes], got "container"
+//	  --> Eggs/my-app/config.fly:2:10
+//	   |
+//	 2 |   type = "container"
+//	   |          ^^^^^^^^^^^
+//	   = see https://github.com/polar-gosling/gosling/blob/main/docs/diagnostics.md#gsl2007
+type Renderer struct {
+	// Color enables ANSI colors, for terminals
+	Color bool
+	// Root, when set, is the directory file names are shown relative to
+	Root string
+
+	sources map[string][]string // Lines of the files read so far, nil if unreadable
+}
+
+// NewRenderer returns a renderer showing file names relative to root
+func NewRenderer(root string, color bool) *Renderer {
+	return &Renderer{Color: color, Root: root, sources: make(map[string][]string)}
+}
+
+// Render writes diagnostics to w, separated by blank lines
+func (r *Renderer) Render(w io.Writer, diags []Diagnostic) error {
+	var b strings.Builder
+	for i, d := range diags {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		r.render(&b, d)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (r *Renderer) render(b *strings.Builder, d Diagnostic) {
+	color := ansiRed
+	if d.Severity == SeverityWarning {
+		color = ansiYellow
+	}
+	heading := string(d.Severity)
+	if d.Code != "" {
+		heading += "[" + d.Code + "]"
+	}
+	fmt.Fprintf(b, "%s: %s\n", r.paint(color, heading), r.paint(ansiBold, d.Message))
+
+	line := r.sourceLine(d.File, d.Line)
+	gutter := strings.Repeat(" ", len(fmt.Sprint(d.Line)))
+	if d.File != "" {
+		location := r.displayName(d.File)
+		if d.Line > 0 {
+			location += fmt.Sprintf(":%d:%d", d.Line, d.Column)
+		}
+		fmt.Fprintf(b, "%s%s %s\n", gutter, r.paint(ansiBlue, "-->"), location)
+	}
+	if line != nil {
+		bar := r.paint(ansiBlue, "|")
+		fmt.Fprintf(b, "%s %s\n", gutter, bar)
+		fmt.Fprintf(b, "%s %s %s\n", r.paint(ansiBlue, fmt.Sprint(d.Line)), bar, *line)
+		prefix, width := underline(*line, d.Column, d.EndColumn)
+		fmt.Fprintf(b, "%s %s %s%s\n", gutter, bar, prefix, r.paint(color, strings.Repeat("^", width)))
+	}
+	if d.Detail != "" {
+		fmt.Fprintf(b, "%s %s note: %s\n", gutter, r.paint(ansiBlue, "="), d.Detail)
+	}
+	if d.URL != "" {
+		fmt.Fprintf(b, "%s %s see %s\n", gutter, r.paint(ansiBlue, "="), d.URL)
+	}
+}
+
+func (r *Renderer) paint(color, s string) string {
+	if !r.Color {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// displayName shows a file relative to the renderer's root when inside it
+func (r *Renderer) displayName(file string) string {
+	if r.Root == "" {
+		return file
+	}
+	rel, err := filepath.Rel(r.Root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+	return rel
+}
+
+// sourceLine returns a line of a file, or nil if it cannot be read
+func (r *Renderer) sourceLine(file string, n int) *string {
+	if file == "" || n < 1 {
+		return nil
+	}
+	if r.sources == nil {
+		r.sources = make(map[string][]string)
+	}
+	lines, ok := r.sources[file]
+	if !ok {
+		lines = readLines(file)
+		r.sources[file] = lines
+	}
+	if n > len(lines) {
+		return nil
+	}
+	return &lines[n-1]
+}
+
+func readLines(file string) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	return lines
+}
+
+// underline returns the indentation of the carets under line, which keeps
+// its tabs so the carets line up, and how many carets to draw
+func underline(line string, column, endColumn int) (string, int) {
+	runes := []rune(line)
+	start := column - 1
+	if start < 0 {
+		start = 0
+	}
+	if start > len(runes) {
+		start = len(runes)
+	}
+	var prefix strings.Builder
+	for _, c := range runes[:start] {
+		if c == '\t' {
+			prefix.WriteRune('\t')
+		} else {
+			prefix.WriteRune(' ')
+		}
+	}
+
+	width := endColumn - column
+	if endColumn == 0 {
+		width = wordLength(string(runes[start:]))
+	}
+	if width < 1 {
+		width = 1
+	}
+	return prefix.String(), width
+}
+
+// wordLength returns the length of the quoted string or word s starts with
+func wordLength(s string) int {
+	if strings.HasPrefix(s, `"`) {
+		escaped := false
+		for i, c := range s[1:] {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				return utf8.RuneCountInString(s[:i+2])
+			}
+		}
+		return utf8.RuneCountInString(s)
+	}
+	n := 0
+	for _, c := range s {
+		if c != '_' && c != '-' && c != '.' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			break
+		}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,102 @@
+package diagnostic
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+func TestRenderValidationError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.fly")
+	content := "egg \"my-app\" {\n\ttype = \"container\"\n}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := parser.NewParser().Parse([]byte(content), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags := FromError(parser.NewValidator(config).Validate())
+	var typeDiag *Diagnostic
+	for i := range diags {
+		if diags[i].Code == parser.CodeValue {
+			typeDiag = &diags[i]
+		}
+	}
+	if typeDiag == nil {
+		t.Fatalf("expected a %s diagnostic, got %+v", parser.CodeValue, diags)
+	}
+
+	var b strings.Builder
+	if err := NewRenderer(dir, false).Render(&b, []Diagnostic{*typeDiag}); err != nil {
+		t.Fatal(err)
+	}
+	want := `error[GSL2007]: type must be one of [vm serverless kubernetes], got "container"
+ --> config.fly:2:9
+  |
+2 | 	type = "container"
+  | 	       ^^^^^^^^^^^
+  = see https://github.com/polar-gosling/gosling/blob/main/docs/diagnostics.md#gsl2007
+`
+	if b.String() != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestRenderSyntaxErrors(t *testing.T) {
+	content := []byte("egg \"my-app\" {\n  tags = [\"${tag}\"]\n  type = \n}\n")
+	_, err := parser.NewParser().Parse(content, "missing.fly")
+	diags := FromError(err)
+	if len(diags) != 2 || diags[0].Code != parser.CodeExpression || diags[1].Code != parser.CodeSyntax {
+		t.Fatalf("expected an unknown variable and a syntax error, got %+v", diags)
+	}
+
+	var b strings.Builder
+	if err := NewRenderer("", true).Render(&b, diags); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	// The source cannot be read, so only the location is shown
+	if strings.Contains(out, " | ") || !strings.Contains(out, "missing.fly:2:14") {
+		t.Errorf("unexpected rendering:\n%s", out)
+	}
+	if !strings.Contains(out, ansiRed+"error[GSL1001]"+ansiReset) || !strings.Contains(out, ` note: There is no variable named "tag"`) {
+		t.Errorf("expected colored severities and notes, got:\n%q", out)
+	}
+}
+
+func TestRenderWithoutPosition(t *testing.T) {
+	var b strings.Builder
+	diags := FromError(os.ErrNotExist)
+	if err := NewRenderer("", false).Render(&b, diags); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "error: file does not exist\n" {
+		t.Errorf("Render() = %q", b.String())
+	}
+}
+
+func TestUnderline(t *testing.T) {
+	for _, tt := range []struct {
+		line      string
+		column    int
+		endColumn int
+		prefix    string
+		width     int
+	}{
+		{`  region = "eu-central-1" # AWS`, 12, 0, "           ", 14},
+		{`  runner {`, 3, 0, "  ", 6},
+		{`	cpu = 2`, 2, 0, "\t", 3},
+		{`  tags = [`, 10, 0, "         ", 1},
+		{`  a = "x"`, 3, 10, "  ", 7},
+	} {
+		prefix, width := underline(tt.line, tt.column, tt.endColumn)
+		if prefix != tt.prefix || width != tt.width {
+			t.Errorf("underline(%q, %d, %d) = %q, %d, want %q, %d", tt.line, tt.column, tt.endColumn, prefix, width, tt.prefix, tt.width)
+		}
+	}
+}
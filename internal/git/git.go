@@ -0,0 +1,149 @@
+// Package git runs the git commands used to commit and publish Nest changes.
+//
+// It shells out to the git binary so the user's configuration (identity,
+// credential helpers, SSH keys, hooks) applies exactly as on the command line.
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// Run runs a git command in dir and returns its standard output
+func Run(dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// ChangedFiles lists the files under pathspecs that differ between rev and the
+// working tree of dir, including untracked files. Paths are relative to dir.
+func ChangedFiles(dir, rev string, pathspecs ...string) ([]string, error) {
+	if _, err := Run(dir, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown git revision %q", rev)
+	}
+	changed, err := Run(dir, append([]string{"diff", "--name-only", "--relative", rev, "--"}, pathspecs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	untracked, err := Run(dir, append([]string{"ls-files", "--others", "--exclude-standard", "--"}, pathspecs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	var files []string
+	for _, line := range strings.Split(string(changed)+string(untracked), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// Repo is a git working tree
+type Repo struct {
+	dir string
+}
+
+// Open returns the repository containing dir
+func Open(dir string) (*Repo, error) {
+	out, err := Run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	return &Repo{dir: strings.TrimSpace(string(out))}, nil
+}
+
+// Dir returns the top-level directory of the working tree
+func (r *Repo) Dir() string {
+	return r.dir
+}
+
+// run runs a git command at the top of the working tree and returns its trimmed output
+func (r *Repo) run(args ...string) (string, error) {
+	out, err := Run(r.dir, args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// CurrentBranch returns the checked out branch
+func (r *Repo) CurrentBranch() (string, error) {
+	branch, err := r.run("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch (detached HEAD?): %w", err)
+	}
+	return branch, nil
+}
+
+// CreateBranch creates a branch at HEAD and checks it out
+func (r *Repo) CreateBranch(name string) error {
+	if _, err := r.run("checkout", "-b", name); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// Commit stages paths and commits only them, leaving other staged changes alone
+func (r *Repo) Commit(message string, paths ...string) error {
+	if _, err := r.run(append([]string{"add", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if _, err := r.run(append([]string{"commit", "-m", message, "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// Push pushes branch to remote and sets it as the upstream
+func (r *Repo) Push(remote, branch string) error {
+	if _, err := r.run("push", "--set-upstream", remote, branch); err != nil {
+		return fmt.Errorf("failed to push %s to %s: %w", branch, remote, err)
+	}
+	return nil
+}
+
+// RemoteURL returns the fetch URL of a remote
+func (r *Repo) RemoteURL(remote string) (string, error) {
+	u, err := r.run("remote", "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to get URL of remote %s: %w", remote, err)
+	}
+	return u, nil
+}
+
+// Remote identifies a project on a Git hosting server
+type Remote struct {
+	Host    string // Host name, without port
+	Project string // Full project path, e.g. "group/subgroup/nest"
+}
+
+// ParseRemote extracts the host and project path from a remote URL in HTTPS,
+// ssh:// or scp-like (git@host:group/project.git) form
+func ParseRemote(remoteURL string) (*Remote, error) {
+	var host, path string
+	if strings.Contains(remoteURL, "://") {
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remote URL %q: %w", remoteURL, err)
+		}
+		host, path = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(remoteURL, ":"); ok {
+		host, path = at[strings.LastIndex(at, "@")+1:], rest
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return nil, fmt.Errorf("cannot determine host and project from remote URL %q", remoteURL)
+	}
+	return &Remote{Host: host, Project: path}, nil
+}
@@ -9,24 +9,40 @@ import (
 
 // Client wraps the GitLab Go SDK
 type Client struct {
-	client *gitlab.Client
+	client       *gitlab.Client
+	registration RegistrationMethod
+	detected     RegistrationMethod
+	transport    transportOptions
 }
 
 // NewClient creates a new GitLab client
-func NewClient(baseURL, token string) (*Client, error) {
-	client, err := gitlab.NewClient(token, gitlab.WithBaseURL(baseURL))
+func NewClient(baseURL, token string, opts ...ClientOption) (*Client, error) {
+	c := &Client{
+		registration: RegistrationAuto,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	httpClient, err := c.transport.httpClient(baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GitLab client: %w", err)
+		return nil, fmt.Errorf("failed to configure GitLab connection: %w", err)
 	}
 
-	return &Client{
-		client: client,
-	}, nil
+	client, err := gitlab.NewClient(token, gitlab.WithBaseURL(baseURL), gitlab.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitLab client: %w", err)
+	}
+	c.client = client
+	return c, nil
 }
 
-// RunnerConfig represents GitLab runner configuration
+// RunnerConfig represents GitLab runner configuration. Token is the
+// registration token and is only used by the registration token flow;
+// runner creation needs ProjectID or GroupID instead.
 type RunnerConfig struct {
 	ProjectID   int
+	GroupID     int
 	Token       string
 	Description string
 	Tags        []string
@@ -34,6 +50,15 @@ type RunnerConfig struct {
 	Locked      bool
 }
 
+// GroupRunnerConfig represents a GitLab runner serving every project in a group
+type GroupRunnerConfig struct {
+	GroupID     int
+	Description string
+	Tags        []string
+	RunUntagged bool
+	Locked      bool
+}
+
 // Runner represents a registered GitLab runner
 type Runner struct {
 	ID          int
@@ -43,6 +68,22 @@ type Runner struct {
 	Tags        []string
 }
 
+// MergeRequestConfig describes a merge request to open
+type MergeRequestConfig struct {
+	Project            string // Project ID or full path, e.g. "group/nest"
+	SourceBranch       string
+	TargetBranch       string
+	Title              string
+	Description        string
+	RemoveSourceBranch bool
+}
+
+// MergeRequest represents an opened merge request
+type MergeRequest struct {
+	IID    int
+	WebURL string
+}
+
 // WebhookConfig represents GitLab webhook configuration
 type WebhookConfig struct {
 	URL                   string
@@ -53,8 +94,27 @@ type WebhookConfig struct {
 	EnableSSLVerification bool
 }
 
-// RegisterRunner registers a new runner with GitLab
+// Webhook represents a project webhook as configured in GitLab
+type Webhook struct {
+	ID                    int
+	URL                   string
+	PushEvents            bool
+	MergeRequestsEvents   bool
+	PipelineEvents        bool
+	EnableSSLVerification bool
+}
+
+// RegisterRunner registers a new runner with GitLab, using runner creation or
+// the registration token flow according to the client's registration method
 func (c *Client) RegisterRunner(ctx context.Context, config *RunnerConfig) (*Runner, error) {
+	if c.resolveRegistrationMethod(ctx, config) == RegistrationAuthenticationToken {
+		return c.createRunner(ctx, config)
+	}
+	return c.registerWithToken(config)
+}
+
+// registerWithToken registers a runner with a registration token (POST /runners)
+func (c *Client) registerWithToken(config *RunnerConfig) (*Runner, error) {
 	// Register the runner using the GitLab API
 	options := &gitlab.RegisterNewRunnerOptions{
 		Token:       gitlab.Ptr(config.Token),
@@ -84,6 +144,18 @@ func (c *Client) RegisterRunner(ctx context.Context, config *RunnerConfig) (*Run
 	}, nil
 }
 
+// RegisterGroupRunner creates a group runner. The client's token must belong to
+// a user who owns the group and has the create_runner scope.
+func (c *Client) RegisterGroupRunner(ctx context.Context, config *GroupRunnerConfig) (*Runner, error) {
+	return c.createRunner(ctx, &RunnerConfig{
+		GroupID:     config.GroupID,
+		Description: config.Description,
+		Tags:        config.Tags,
+		RunUntagged: config.RunUntagged,
+		Locked:      config.Locked,
+	})
+}
+
 // UnregisterRunner removes a runner from GitLab
 func (c *Client) UnregisterRunner(ctx context.Context, runnerID int) error {
 	options := &gitlab.DeleteRegisteredRunnerOptions{
@@ -96,15 +168,57 @@ func (c *Client) UnregisterRunner(ctx context.Context, runnerID int) error {
 	return nil
 }
 
+// DeleteRunner removes a runner by ID and invalidates its authentication
+// token. Unlike UnregisterRunner it authenticates with the client's user
+// token, which must be allowed to manage the runner.
+func (c *Client) DeleteRunner(ctx context.Context, runnerID int) error {
+	if _, err := c.client.Runners.RemoveRunner(runnerID, gitlab.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete runner %d: %w", runnerID, err)
+	}
+	return nil
+}
+
+// CurrentUser returns the username the client's token authenticates as
+func (c *Client) CurrentUser(ctx context.Context) (string, error) {
+	user, _, err := c.client.Users.CurrentUser(gitlab.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to get current user: %w", err)
+	}
+	return user.Username, nil
+}
+
+// CreateMergeRequest opens a merge request
+func (c *Client) CreateMergeRequest(ctx context.Context, config *MergeRequestConfig) (*MergeRequest, error) {
+	options := &gitlab.CreateMergeRequestOptions{
+		Title:              gitlab.Ptr(config.Title),
+		Description:        gitlab.Ptr(config.Description),
+		SourceBranch:       gitlab.Ptr(config.SourceBranch),
+		TargetBranch:       gitlab.Ptr(config.TargetBranch),
+		RemoveSourceBranch: gitlab.Ptr(config.RemoveSourceBranch),
+	}
+
+	mr, _, err := c.client.MergeRequests.CreateMergeRequest(config.Project, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merge request: %w", err)
+	}
+
+	return &MergeRequest{
+		IID:    int(mr.IID),
+		WebURL: mr.WebURL,
+	}, nil
+}
+
 // GetRunner retrieves runner details from GitLab
 func (c *Client) GetRunner(ctx context.Context, runnerID int) (*Runner, error) {
-	runner, _, err := c.client.Runners.GetRunnerDetails(runnerID)
+	runner, _, err := c.client.Runners.GetRunnerDetails(runnerID, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get runner details: %w", err)
 	}
 
-	// Extract tags - may not be available in all API versions
-	tags := []string{}
+	tags := runner.TagList
+	if tags == nil {
+		tags = []string{}
+	}
 
 	return &Runner{
 		ID:          int(runner.ID),
@@ -152,6 +266,51 @@ func (c *Client) GetWebhook(ctx context.Context, projectID, hookID int) (*gitlab
 	return hook, nil
 }
 
+// ListWebhooks lists the webhooks of a GitLab project
+func (c *Client) ListWebhooks(ctx context.Context, projectID int) ([]*Webhook, error) {
+	options := &gitlab.ListProjectHooksOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	hooks, _, err := c.client.Projects.ListProjectHooks(projectID, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	result := make([]*Webhook, len(hooks))
+	for i, h := range hooks {
+		result[i] = &Webhook{
+			ID:                    int(h.ID),
+			URL:                   h.URL,
+			PushEvents:            h.PushEvents,
+			MergeRequestsEvents:   h.MergeRequestsEvents,
+			PipelineEvents:        h.PipelineEvents,
+			EnableSSLVerification: h.EnableSSLVerification,
+		}
+	}
+	return result, nil
+}
+
+// UpdateWebhook replaces the settings of a project webhook
+func (c *Client) UpdateWebhook(ctx context.Context, projectID, hookID int, config *WebhookConfig) error {
+	options := &gitlab.EditProjectHookOptions{
+		URL:                   gitlab.Ptr(config.URL),
+		Token:                 gitlab.Ptr(config.Token),
+		PushEvents:            gitlab.Ptr(config.PushEvents),
+		MergeRequestsEvents:   gitlab.Ptr(config.MergeRequestsEvents),
+		PipelineEvents:        gitlab.Ptr(config.PipelineEvents),
+		EnableSSLVerification: gitlab.Ptr(config.EnableSSLVerification),
+	}
+
+	_, _, err := c.client.Projects.EditProjectHook(projectID, int64(hookID), options, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return nil
+}
+
 // ListProjectRunners lists all runners for a project
 func (c *Client) ListProjectRunners(ctx context.Context, projectID int) ([]*Runner, error) {
 	options := &gitlab.ListProjectRunnersOptions{
@@ -181,6 +340,33 @@ func (c *Client) ListProjectRunners(ctx context.Context, projectID int) ([]*Runn
 	return result, nil
 }
 
+// ListGroupRunners lists the group runners of a group
+func (c *Client) ListGroupRunners(ctx context.Context, groupID int) ([]*Runner, error) {
+	options := &gitlab.ListGroupsRunnersOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+		},
+		Type: gitlab.Ptr("group_type"),
+	}
+
+	runners, _, err := c.client.Runners.ListGroupsRunners(groupID, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group runners: %w", err)
+	}
+
+	result := make([]*Runner, len(runners))
+	for i, r := range runners {
+		result[i] = &Runner{
+			ID:          int(r.ID),
+			Description: r.Description,
+			Active:      !r.Paused,
+			Tags:        []string{},
+		}
+	}
+
+	return result, nil
+}
+
 // UpdateRunner updates runner configuration
 func (c *Client) UpdateRunner(ctx context.Context, runnerID int, description string, tags []string) error {
 	options := &gitlab.UpdateRunnerDetailsOptions{
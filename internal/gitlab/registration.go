@@ -0,0 +1,135 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// RegistrationMethod selects how runners are registered with GitLab
+type RegistrationMethod string
+
+const (
+	// RegistrationAuto uses runner creation when the GitLab version supports it
+	// and a project or group is given, and the registration token flow otherwise
+	RegistrationAuto RegistrationMethod = "auto"
+	// RegistrationToken uses the deprecated registration token flow (POST /runners)
+	RegistrationToken RegistrationMethod = "registration-token"
+	// RegistrationAuthenticationToken creates the runner with POST /user/runners,
+	// which returns a runner authentication token (glrt-...)
+	RegistrationAuthenticationToken RegistrationMethod = "authentication-token"
+)
+
+// runnerCreationMinVersion is the first GitLab version with POST /user/runners
+var runnerCreationMinVersion = [2]int{15, 10}
+
+// ParseRegistrationMethod converts a string to a RegistrationMethod
+func ParseRegistrationMethod(s string) (RegistrationMethod, error) {
+	switch RegistrationMethod(s) {
+	case RegistrationAuto, RegistrationToken, RegistrationAuthenticationToken:
+		return RegistrationMethod(s), nil
+	default:
+		return "", fmt.Errorf("invalid registration method %q: must be one of %s, %s, %s",
+			s, RegistrationAuto, RegistrationToken, RegistrationAuthenticationToken)
+	}
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithRegistrationMethod sets how RegisterRunner registers runners (default: auto)
+func WithRegistrationMethod(method RegistrationMethod) ClientOption {
+	return func(c *Client) {
+		c.registration = method
+	}
+}
+
+// resolveRegistrationMethod returns the configured method, detecting it from
+// the GitLab version in auto mode. Auto mode keeps the registration token flow
+// when config has no project or group to create the runner for, or when the
+// version cannot be read: a registration token is not an API token, so
+// GET /version answers 401 to it. A detected method is cached.
+func (c *Client) resolveRegistrationMethod(ctx context.Context, config *RunnerConfig) RegistrationMethod {
+	if c.registration != RegistrationAuto && c.registration != "" {
+		return c.registration
+	}
+	if config.ProjectID == 0 && config.GroupID == 0 {
+		return RegistrationToken
+	}
+	if c.detected != "" {
+		return c.detected
+	}
+
+	version, _, err := c.client.Version.GetVersion(gitlab.WithContext(ctx))
+	if err != nil {
+		return RegistrationToken
+	}
+	c.detected = RegistrationToken
+	if supportsRunnerCreation(version.Version) {
+		c.detected = RegistrationAuthenticationToken
+	}
+	return c.detected
+}
+
+// supportsRunnerCreation reports whether a GitLab version such as "16.5.1-ee"
+// has the runner creation API
+func supportsRunnerCreation(version string) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return false
+	}
+	if major != runnerCreationMinVersion[0] {
+		return major > runnerCreationMinVersion[0]
+	}
+	return minor >= runnerCreationMinVersion[1]
+}
+
+// createRunner creates a project or group runner with POST /user/runners. The
+// client's token must have the create_runner scope.
+func (c *Client) createRunner(ctx context.Context, config *RunnerConfig) (*Runner, error) {
+	options := &gitlab.CreateUserRunnerOptions{
+		Description: gitlab.Ptr(config.Description),
+		TagList:     &config.Tags,
+		RunUntagged: gitlab.Ptr(config.RunUntagged),
+		Locked:      gitlab.Ptr(config.Locked),
+	}
+	switch {
+	case config.GroupID != 0:
+		options.RunnerType = gitlab.Ptr("group_type")
+		options.GroupID = gitlab.Ptr(int64(config.GroupID))
+	case config.ProjectID != 0:
+		options.RunnerType = gitlab.Ptr("project_type")
+		options.ProjectID = gitlab.Ptr(int64(config.ProjectID))
+	default:
+		return nil, fmt.Errorf("a project or group ID is required to create a runner")
+	}
+
+	runner, _, err := c.client.Users.CreateUserRunner(options, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner: %w", err)
+	}
+
+	tags := config.Tags
+	if len(tags) == 0 {
+		tags = []string{}
+	}
+
+	return &Runner{
+		ID:          int(runner.ID),
+		Token:       runner.Token,
+		Description: config.Description,
+		Active:      true,
+		Tags:        tags,
+	}, nil
+}
@@ -0,0 +1,143 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSupportsRunnerCreation(t *testing.T) {
+	tests := map[string]bool{
+		"15.9.3":     false,
+		"15.10.0":    true,
+		"16.5.1-ee":  true,
+		"14.10.5":    false,
+		"17.0.0-pre": true,
+		"unknown":    false,
+	}
+	for version, want := range tests {
+		if got := supportsRunnerCreation(version); got != want {
+			t.Errorf("supportsRunnerCreation(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+// newTestServer serves the GitLab version endpoint and records which runner
+// registration endpoint was called. An empty version answers 401, as GitLab
+// does for a registration token.
+func newTestServer(t *testing.T, version string, calls *[]string) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/version", func(w http.ResponseWriter, r *http.Request) {
+		if version == "" {
+			http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"version": version})
+	})
+	mux.HandleFunc("/api/v4/user/runners", func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, r.URL.Path)
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["runner_type"] != "group_type" || body["group_id"] != float64(7) {
+			t.Errorf("unexpected runner creation request: %v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 12, "token": "glrt-abc"})
+	})
+	mux.HandleFunc("/api/v4/runners", func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, r.URL.Path)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 13, "token": "legacy"})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return client
+}
+
+func TestRegisterRunnerDetectsMethod(t *testing.T) {
+	ctx := context.Background()
+	config := &RunnerConfig{Token: "reg", Description: "egg", GroupID: 7}
+
+	var calls []string
+	client := newTestServer(t, "16.5.1-ee", &calls)
+	runner, err := client.RegisterRunner(ctx, config)
+	if err != nil {
+		t.Fatalf("RegisterRunner failed: %v", err)
+	}
+	if runner.Token != "glrt-abc" || len(calls) != 1 || calls[0] != "/api/v4/user/runners" {
+		t.Errorf("expected runner creation on GitLab 16.5, got token %q and calls %v", runner.Token, calls)
+	}
+
+	calls = nil
+	client = newTestServer(t, "15.4.0", &calls)
+	runner, err = client.RegisterRunner(ctx, config)
+	if err != nil {
+		t.Fatalf("RegisterRunner failed: %v", err)
+	}
+	if runner.Token != "legacy" || len(calls) != 1 || calls[0] != "/api/v4/runners" {
+		t.Errorf("expected registration token flow on GitLab 15.4, got token %q and calls %v", runner.Token, calls)
+	}
+
+	// An explicit method overrides detection
+	calls = nil
+	client = newTestServer(t, "16.5.1-ee", &calls)
+	WithRegistrationMethod(RegistrationToken)(client)
+	if _, err := client.RegisterRunner(ctx, config); err != nil {
+		t.Fatalf("RegisterRunner failed: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "/api/v4/runners" {
+		t.Errorf("expected registration token flow when set explicitly, got calls %v", calls)
+	}
+}
+
+func TestRegisterRunnerFallsBackToRegistrationToken(t *testing.T) {
+	ctx := context.Background()
+
+	// The registration token cannot read the GitLab version
+	var calls []string
+	client := newTestServer(t, "", &calls)
+	runner, err := client.RegisterRunner(ctx, &RunnerConfig{Token: "reg", Description: "egg", GroupID: 7})
+	if err != nil {
+		t.Fatalf("RegisterRunner failed: %v", err)
+	}
+	if runner.Token != "legacy" || len(calls) != 1 || calls[0] != "/api/v4/runners" {
+		t.Errorf("expected registration token flow when /version is unauthorized, got token %q and calls %v", runner.Token, calls)
+	}
+
+	// Runner creation needs a project or group, even on new servers
+	calls = nil
+	client = newTestServer(t, "16.5.1-ee", &calls)
+	if _, err := client.RegisterRunner(ctx, &RunnerConfig{Token: "reg", Description: "egg"}); err != nil {
+		t.Fatalf("RegisterRunner failed: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "/api/v4/runners" {
+		t.Errorf("expected registration token flow without a project or group, got calls %v", calls)
+	}
+}
+
+func TestCreateRunnerRequiresScope(t *testing.T) {
+	var calls []string
+	client := newTestServer(t, "16.5.1-ee", &calls)
+	WithRegistrationMethod(RegistrationAuthenticationToken)(client)
+	if _, err := client.RegisterRunner(context.Background(), &RunnerConfig{Description: "egg"}); err == nil {
+		t.Error("expected error without project or group ID")
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected no API calls, got %v", calls)
+	}
+}
+
+func TestParseRegistrationMethod(t *testing.T) {
+	if m, err := ParseRegistrationMethod("authentication-token"); err != nil || m != RegistrationAuthenticationToken {
+		t.Errorf("unexpected result %q, %v", m, err)
+	}
+	if _, err := ParseRegistrationMethod("oauth"); err == nil {
+		t.Error("expected error for unknown method")
+	}
+}
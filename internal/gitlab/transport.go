@@ -0,0 +1,95 @@
+package gitlab
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/polar-gosling/gosling/internal/telemetry"
+)
+
+// transportOptions configures TLS and proxying for self-hosted GitLab servers
+type transportOptions struct {
+	rootCAs            []byte
+	clientCert         []byte
+	clientKey          []byte
+	proxy              string
+	insecureSkipVerify bool
+}
+
+// WithRootCAs trusts the PEM encoded CA certificates in addition to the system roots
+func WithRootCAs(pemCerts []byte) ClientOption {
+	return func(c *Client) {
+		c.transport.rootCAs = pemCerts
+	}
+}
+
+// WithClientCertificate presents a PEM encoded client certificate and key (mutual TLS)
+func WithClientCertificate(certPEM, keyPEM []byte) ClientOption {
+	return func(c *Client) {
+		c.transport.clientCert = certPEM
+		c.transport.clientKey = keyPEM
+	}
+}
+
+// WithProxy sends requests through proxyURL instead of the proxy from
+// HTTPS_PROXY and related environment variables
+func WithProxy(proxyURL string) ClientOption {
+	return func(c *Client) {
+		c.transport.proxy = proxyURL
+	}
+}
+
+// WithInsecureSkipVerify disables verification of the server certificate.
+// Anyone on the network path can then read the token; prefer WithRootCAs.
+func WithInsecureSkipVerify() ClientOption {
+	return func(c *Client) {
+		c.transport.insecureSkipVerify = true
+	}
+}
+
+// httpClient returns the HTTP client for the options. Its requests are traced
+// with OpenTelemetry.
+func (t *transportOptions) httpClient(baseURL string) (*http.Client, error) {
+	if t.rootCAs == nil && t.clientCert == nil && t.proxy == "" && !t.insecureSkipVerify {
+		return &http.Client{Transport: telemetry.Transport("gitlab", nil)}, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if t.rootCAs != nil {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(t.rootCAs) {
+			return nil, fmt.Errorf("no PEM certificates found in CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if t.clientCert != nil {
+		cert, err := tls.X509KeyPair(t.clientCert, t.clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if t.insecureSkipVerify {
+		fmt.Fprintf(os.Stderr, "⚠️  WARNING: TLS certificate verification is DISABLED for %s.\n", baseURL)
+		fmt.Fprintln(os.Stderr, "⚠️  The GitLab token can be intercepted; configure the server's CA certificate instead.")
+		tlsConfig.InsecureSkipVerify = true
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if t.proxy != "" {
+		proxyURL, err := url.Parse(t.proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", t.proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Client{Transport: telemetry.Transport("gitlab", transport)}, nil
+}
@@ -0,0 +1,87 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newTLSServer serves the current user endpoint over TLS with a self-signed certificate
+func newTLSServer(t *testing.T) (*httptest.Server, []byte) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "username": "gosling"})
+	}))
+	t.Cleanup(server.Close)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server, caPEM
+}
+
+func TestWithRootCAs(t *testing.T) {
+	server, caPEM := newTLSServer(t)
+	ctx := context.Background()
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := client.CurrentUser(ctx); err == nil {
+		t.Error("expected an untrusted certificate to be rejected")
+	}
+
+	client, err = NewClient(server.URL, "token", WithRootCAs(caPEM))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if user, err := client.CurrentUser(ctx); err != nil || user != "gosling" {
+		t.Errorf("expected the custom CA to be trusted, got %q (%v)", user, err)
+	}
+
+	client, err = NewClient(server.URL, "token", WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := client.CurrentUser(ctx); err != nil {
+		t.Errorf("expected verification to be skipped, got %v", err)
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "username": "gosling"})
+	}))
+	t.Cleanup(proxy.Close)
+
+	client, err := NewClient("http://gitlab.internal.example", "token", WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := client.CurrentUser(context.Background()); err != nil {
+		t.Fatalf("CurrentUser failed: %v", err)
+	}
+	if len(proxied) != 1 {
+		t.Fatalf("expected one proxied request, got %v", proxied)
+	}
+	if u, _ := url.Parse(proxied[0]); u.Host != "gitlab.internal.example" {
+		t.Errorf("expected request for gitlab.internal.example, got %s", proxied[0])
+	}
+}
+
+func TestTransportOptionErrors(t *testing.T) {
+	for name, opt := range map[string]ClientOption{
+		"no PEM certificates": WithRootCAs([]byte("not a certificate")),
+		"client certificate":  WithClientCertificate([]byte("cert"), []byte("key")),
+		"proxy URL":           WithProxy("::not a url"),
+	} {
+		if _, err := NewClient("https://gitlab.example.com", "token", opt); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("expected %q error, got %v", name, err)
+		}
+	}
+}
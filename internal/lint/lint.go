@@ -0,0 +1,323 @@
+// Package lint implements advisory checks for .fly configurations.
+//
+// Lint rules are separate from the hard validation in the parser package:
+// a configuration can be valid (deployable) and still trigger lint findings
+// for risky or unusual settings. Each rule has a stable ID, a default
+// severity, and can be enabled, disabled, or re-graded per Nest via a
+// .goslinglint.fly file or CLI flags.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// ConfigFileName is the name of the lint configuration file in the Nest root
+const ConfigFileName = ".goslinglint.fly"
+
+// Severity is the severity level of a lint finding
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// ParseSeverity converts a string to a Severity
+func ParseSeverity(s string) (Severity, error) {
+	switch Severity(s) {
+	case SeverityWarning, SeverityError:
+		return Severity(s), nil
+	default:
+		return "", fmt.Errorf("invalid severity %q: must be 'warning' or 'error'", s)
+	}
+}
+
+// Issue is a problem reported by a rule check
+type Issue struct {
+	Position parser.Position
+	Message  string
+}
+
+// Rule is a single lint check applied to every top-level block
+type Rule struct {
+	ID               string
+	Description      string
+	Severity         Severity
+	EnabledByDefault bool
+	Check            func(block *parser.Block) []Issue
+}
+
+// Finding is a rule violation with its effective severity
+type Finding struct {
+	RuleID   string   `json:"rule_id"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s [%s] %s", f.File, f.Line, f.Column, f.Severity, f.RuleID, f.Message)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Rule)
+)
+
+// Register adds a rule to the global registry. It panics on duplicate IDs,
+// since that is a programming error caught at startup.
+func Register(rule Rule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[rule.ID]; exists {
+		panic(fmt.Sprintf("lint rule %q registered twice", rule.ID))
+	}
+	registry[rule.ID] = rule
+}
+
+// Rules returns all registered rules sorted by ID
+func Rules() []Rule {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	rules := make([]Rule, 0, len(registry))
+	for _, rule := range registry {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// lookupRule returns a registered rule by ID
+func lookupRule(id string) (Rule, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	rule, ok := registry[id]
+	return rule, ok
+}
+
+// Config controls which rules run and at what severity
+type Config struct {
+	enabled    map[string]bool
+	severities map[string]Severity
+}
+
+// NewConfig returns a Config that uses every rule's defaults
+func NewConfig() *Config {
+	return &Config{
+		enabled:    make(map[string]bool),
+		severities: make(map[string]Severity),
+	}
+}
+
+// Enable turns on the given rules
+func (c *Config) Enable(ids ...string) error {
+	for _, id := range ids {
+		if _, ok := lookupRule(id); !ok {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+		c.enabled[id] = true
+	}
+	return nil
+}
+
+// Disable turns off the given rules
+func (c *Config) Disable(ids ...string) error {
+	for _, id := range ids {
+		if _, ok := lookupRule(id); !ok {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+		c.enabled[id] = false
+	}
+	return nil
+}
+
+// SetSeverity overrides the severity of a rule
+func (c *Config) SetSeverity(id string, severity Severity) error {
+	if _, ok := lookupRule(id); !ok {
+		return fmt.Errorf("unknown lint rule %q", id)
+	}
+	c.severities[id] = severity
+	return nil
+}
+
+// isEnabled reports whether a rule should run under this config
+func (c *Config) isEnabled(rule Rule) bool {
+	if enabled, ok := c.enabled[rule.ID]; ok {
+		return enabled
+	}
+	return rule.EnabledByDefault
+}
+
+// severityOf returns the effective severity of a rule under this config
+func (c *Config) severityOf(rule Rule) Severity {
+	if severity, ok := c.severities[rule.ID]; ok {
+		return severity
+	}
+	return rule.Severity
+}
+
+// LoadConfig reads a .goslinglint.fly file. A missing file yields the default config.
+//
+// Example:
+//
+//	lint {
+//	  enable  = ["plain-vault-secret"]
+//	  disable = ["missing-idle-timeout"]
+//
+//	  rule "concurrent-exceeds-cpu" {
+//	    severity = "error"
+//	  }
+//	}
+func LoadConfig(path string) (*Config, error) {
+	cfg := NewConfig()
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return cfg, nil
+	}
+
+	p := parser.NewParser()
+	config, err := p.ParseFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lint config: %w", err)
+	}
+
+	for i := range config.Blocks {
+		block := &config.Blocks[i]
+		if block.Type != "lint" {
+			return nil, fmt.Errorf("%s: unexpected block %q in lint config (expected 'lint')", block.Position, block.Type)
+		}
+
+		if err := applyRuleList(block, "enable", cfg.Enable); err != nil {
+			return nil, err
+		}
+		if err := applyRuleList(block, "disable", cfg.Disable); err != nil {
+			return nil, err
+		}
+
+		for _, ruleBlock := range block.GetBlocks("rule") {
+			if len(ruleBlock.Labels) != 1 {
+				return nil, fmt.Errorf("%s: rule block must have exactly one label (the rule ID)", ruleBlock.Position)
+			}
+			id := ruleBlock.Labels[0]
+			if severityVal, ok := ruleBlock.GetAttribute("severity"); ok {
+				severityStr, err := severityVal.AsString()
+				if err != nil {
+					return nil, fmt.Errorf("%s: severity must be a string", severityVal.Position)
+				}
+				severity, err := ParseSeverity(severityStr)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", severityVal.Position, err)
+				}
+				if err := cfg.SetSeverity(id, severity); err != nil {
+					return nil, fmt.Errorf("%s: %w", ruleBlock.Position, err)
+				}
+			}
+			if enabledVal, ok := ruleBlock.GetAttribute("enabled"); ok {
+				enabled, err := enabledVal.AsBool()
+				if err != nil {
+					return nil, fmt.Errorf("%s: enabled must be a bool", enabledVal.Position)
+				}
+				if enabled {
+					err = cfg.Enable(id)
+				} else {
+					err = cfg.Disable(id)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", ruleBlock.Position, err)
+				}
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+func applyRuleList(block *parser.Block, attr string, apply func(ids ...string) error) error {
+	val, ok := block.GetAttribute(attr)
+	if !ok {
+		return nil
+	}
+	list, err := val.AsList()
+	if err != nil {
+		return fmt.Errorf("%s: %s must be a list of rule IDs", val.Position, attr)
+	}
+	for _, item := range list {
+		id, err := item.AsString()
+		if err != nil {
+			return fmt.Errorf("%s: rule ID must be a string", item.Position)
+		}
+		if err := apply(id); err != nil {
+			return fmt.Errorf("%s: %w", item.Position, err)
+		}
+	}
+	return nil
+}
+
+// Linter runs the enabled rules against parsed configurations
+type Linter struct {
+	config *Config
+}
+
+// New creates a Linter. A nil config uses every rule's defaults.
+func New(config *Config) *Linter {
+	if config == nil {
+		config = NewConfig()
+	}
+	return &Linter{config: config}
+}
+
+// Lint runs all enabled rules against every top-level block of config.
+// Findings are ordered by position and rule ID.
+func (l *Linter) Lint(config *parser.Config) []Finding {
+	var findings []Finding
+	for _, rule := range Rules() {
+		if !l.config.isEnabled(rule) {
+			continue
+		}
+		severity := l.config.severityOf(rule)
+		for i := range config.Blocks {
+			for _, issue := range rule.Check(&config.Blocks[i]) {
+				findings = append(findings, Finding{
+					RuleID:   rule.ID,
+					Severity: severity,
+					File:     issue.Position.File,
+					Line:     issue.Position.Line,
+					Column:   issue.Position.Column,
+					Message:  issue.Message,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.RuleID < b.RuleID
+	})
+	return findings
+}
+
+// HasErrors reports whether any finding has error severity
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+const riskyEgg = `
+egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "aws"
+    region   = "us-east-1"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags       = ["docker"]
+    concurrent = 16
+  }
+
+  gitlab {
+    project_id   = 12345
+    server_name  = "gitlab.com"
+    token_secret = "yc-lockbox://gitlab/runner-token"
+  }
+}
+`
+
+const cleanEgg = `
+egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags         = ["docker"]
+    concurrent   = 3
+    idle_timeout = "10m"
+  }
+
+  gitlab {
+    project_id   = 12345
+    server_name  = "gitlab.com"
+    token_secret = "yc-lockbox://gitlab/runner-token"
+  }
+}
+`
+
+func parseConfig(t *testing.T, content string) *parser.Config {
+	t.Helper()
+	config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return config
+}
+
+func findingIDs(findings []Finding) map[string]Severity {
+	ids := make(map[string]Severity)
+	for _, f := range findings {
+		ids[f.RuleID] = f.Severity
+	}
+	return ids
+}
+
+func TestLintDefaultRules(t *testing.T) {
+	findings := New(nil).Lint(parseConfig(t, riskyEgg))
+	ids := findingIDs(findings)
+
+	expected := map[string]Severity{
+		RuleConcurrentExceedsCPU:  SeverityWarning,
+		RuleMissingIdleTimeout:    SeverityWarning,
+		RuleSecretBackendMismatch: SeverityError,
+	}
+	for id, severity := range expected {
+		got, ok := ids[id]
+		if !ok {
+			t.Errorf("expected finding for rule %s", id)
+			continue
+		}
+		if got != severity {
+			t.Errorf("expected rule %s to have severity %s, got %s", id, severity, got)
+		}
+	}
+	if !HasErrors(findings) {
+		t.Error("expected HasErrors to be true")
+	}
+
+	for _, f := range findings {
+		if f.RuleID == RuleConcurrentExceedsCPU && f.Line != 18 {
+			t.Errorf("expected concurrent finding on line 18, got %d", f.Line)
+		}
+	}
+}
+
+func TestLintCleanConfig(t *testing.T) {
+	findings := New(nil).Lint(parseConfig(t, cleanEgg))
+	if len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+func TestLintPlainVaultSecret(t *testing.T) {
+	content := `
+egg "my-app" {
+  type = "serverless"
+  cloud {
+    provider = "aws"
+    region   = "us-east-1"
+  }
+  runner {
+    tags         = ["docker"]
+    concurrent   = 1
+    idle_timeout = "5m"
+  }
+  gitlab {
+    project_id   = 1
+    server_name  = "gitlab.com"
+    token_secret = "vault://gitlab/runner-token"
+  }
+}
+`
+	ids := findingIDs(New(nil).Lint(parseConfig(t, content)))
+	if _, ok := ids[RulePlainVaultSecret]; !ok {
+		t.Error("expected plain-vault-secret finding for vault:// on aws")
+	}
+	if _, ok := ids[RuleSecretBackendMismatch]; ok {
+		t.Error("vault:// should not be reported as a backend mismatch")
+	}
+}
+
+func TestLintPrivilegedShared(t *testing.T) {
+	privileged := strings.Replace(cleanEgg, `idle_timeout = "10m"`, "idle_timeout = \"10m\"\n    privileged   = true\n    docker_dind  = true", 1)
+	if findings := New(nil).Lint(parseConfig(t, privileged)); len(findings) != 0 {
+		t.Errorf("expected no findings for a single project's runners, got %v", findings)
+	}
+
+	group := strings.Replace(privileged, "project_id   = 12345", "group_id     = 42", 1)
+	findings := New(nil).Lint(parseConfig(t, group))
+	if len(findings) != 2 {
+		t.Fatalf("expected privileged and docker_dind findings, got %v", findings)
+	}
+	for _, f := range findings {
+		if f.RuleID != RulePrivilegedShared || f.Severity != SeverityWarning || !strings.Contains(f.Message, "shared by the projects of its GitLab group") {
+			t.Errorf("unexpected finding %v", f)
+		}
+	}
+
+	bucket := `
+eggsbucket "builds" {
+  type = "vm"
+  runner {
+    tags         = ["docker"]
+    concurrent   = 1
+    idle_timeout = "5m"
+    privileged   = true
+  }
+}
+`
+	findings = New(nil).Lint(parseConfig(t, bucket))
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "runner.privileged is set on runners shared by the repositories of eggsbucket builds") {
+		t.Errorf("expected a privileged finding for the eggsbucket, got %v", findings)
+	}
+}
+
+func TestLintConfigEnableDisableAndSeverity(t *testing.T) {
+	cfg := NewConfig()
+	if err := cfg.Disable(RuleMissingIdleTimeout); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if err := cfg.SetSeverity(RuleConcurrentExceedsCPU, SeverityError); err != nil {
+		t.Fatalf("SetSeverity failed: %v", err)
+	}
+
+	ids := findingIDs(New(cfg).Lint(parseConfig(t, riskyEgg)))
+	if _, ok := ids[RuleMissingIdleTimeout]; ok {
+		t.Error("expected disabled rule to produce no findings")
+	}
+	if ids[RuleConcurrentExceedsCPU] != SeverityError {
+		t.Errorf("expected severity override to error, got %s", ids[RuleConcurrentExceedsCPU])
+	}
+
+	if err := cfg.Enable("no-such-rule"); err == nil {
+		t.Error("expected error when enabling an unknown rule")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+
+	// Missing file yields defaults
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig on missing file failed: %v", err)
+	}
+	if len(cfg.enabled) != 0 || len(cfg.severities) != 0 {
+		t.Error("expected default config for missing file")
+	}
+
+	content := `
+lint {
+  disable = ["missing-idle-timeout"]
+
+  rule "concurrent-exceeds-cpu" {
+    severity = "error"
+  }
+}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write lint config: %v", err)
+	}
+	cfg, err = LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.enabled[RuleMissingIdleTimeout] {
+		t.Error("expected missing-idle-timeout to be disabled")
+	}
+	if cfg.severities[RuleConcurrentExceedsCPU] != SeverityError {
+		t.Error("expected concurrent-exceeds-cpu severity to be error")
+	}
+
+	bad := `
+lint {
+  rule "no-such-rule" {
+    severity = "error"
+  }
+}
+`
+	if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+		t.Fatalf("failed to write lint config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected error for unknown rule in lint config")
+	}
+}
@@ -0,0 +1,261 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// Built-in rule IDs
+const (
+	RuleConcurrentExceedsCPU  = "concurrent-exceeds-cpu"
+	RuleMissingIdleTimeout    = "missing-idle-timeout"
+	RuleSecretBackendMismatch = "secret-backend-mismatch"
+	RulePlainVaultSecret      = "plain-vault-secret"
+	RulePrivilegedShared      = "privileged-shared-runner"
+)
+
+// maxConcurrentPerCPU is the concurrency-to-vCPU ratio above which jobs
+// tend to starve each other on a single runner
+const maxConcurrentPerCPU = 4
+
+func init() {
+	Register(Rule{
+		ID:               RuleConcurrentExceedsCPU,
+		Description:      fmt.Sprintf("runner.concurrent should not exceed %d jobs per vCPU", maxConcurrentPerCPU),
+		Severity:         SeverityWarning,
+		EnabledByDefault: true,
+		Check:            checkConcurrentExceedsCPU,
+	})
+	Register(Rule{
+		ID:               RuleMissingIdleTimeout,
+		Description:      "runner blocks should set idle_timeout so idle runners are reclaimed",
+		Severity:         SeverityWarning,
+		EnabledByDefault: true,
+		Check:            checkMissingIdleTimeout,
+	})
+	Register(Rule{
+		ID:               RuleSecretBackendMismatch,
+		Description:      "token_secret must use a secret backend reachable from the egg's cloud provider",
+		Severity:         SeverityError,
+		EnabledByDefault: true,
+		Check:            checkSecretBackendMismatch,
+	})
+	Register(Rule{
+		ID:               RulePlainVaultSecret,
+		Description:      "prefer the provider's native secret manager over vault:// on AWS",
+		Severity:         SeverityWarning,
+		EnabledByDefault: true,
+		Check:            checkPlainVaultSecret,
+	})
+	Register(Rule{
+		ID:               RulePrivilegedShared,
+		Description:      "privileged and docker_dind runners should not be shared by several projects",
+		Severity:         SeverityWarning,
+		EnabledByDefault: true,
+		Check:            checkPrivilegedShared,
+	})
+}
+
+// isRunnerHost reports whether a block declares runners (egg or eggsbucket)
+func isRunnerHost(block *parser.Block) bool {
+	return block.Type == "egg" || block.Type == "eggsbucket"
+}
+
+// providerOf returns the cloud.provider value of a block, or "" if unset
+func providerOf(block *parser.Block) string {
+	cloudBlock, ok := block.GetBlock("cloud")
+	if !ok {
+		return ""
+	}
+	providerVal, ok := cloudBlock.GetAttribute("provider")
+	if !ok {
+		return ""
+	}
+	provider, _ := providerVal.AsString()
+	return provider
+}
+
+// gitlabBlocks returns every gitlab block of an egg or eggsbucket
+func gitlabBlocks(block *parser.Block) []*parser.Block {
+	var blocks []*parser.Block
+	if gitlabBlock, ok := block.GetBlock("gitlab"); ok {
+		blocks = append(blocks, gitlabBlock)
+	}
+	if reposBlock, ok := block.GetBlock("repositories"); ok {
+		for i := range reposBlock.Blocks {
+			if reposBlock.Blocks[i].Type != "repo" {
+				continue
+			}
+			if gitlabBlock, ok := reposBlock.Blocks[i].GetBlock("gitlab"); ok {
+				blocks = append(blocks, gitlabBlock)
+			}
+		}
+	}
+	return blocks
+}
+
+func checkConcurrentExceedsCPU(block *parser.Block) []Issue {
+	if !isRunnerHost(block) {
+		return nil
+	}
+	resourcesBlock, ok := block.GetBlock("resources")
+	if !ok {
+		return nil
+	}
+	runnerBlock, ok := block.GetBlock("runner")
+	if !ok {
+		return nil
+	}
+	cpuVal, ok := resourcesBlock.GetAttribute("cpu")
+	if !ok {
+		return nil
+	}
+	concurrentVal, ok := runnerBlock.GetAttribute("concurrent")
+	if !ok {
+		return nil
+	}
+	cpu, err := cpuVal.AsNumber()
+	if err != nil {
+		return nil
+	}
+	concurrent, err := concurrentVal.AsNumber()
+	if err != nil {
+		return nil
+	}
+	if concurrent <= cpu*maxConcurrentPerCPU {
+		return nil
+	}
+	return []Issue{{
+		Position: concurrentVal.Position,
+		Message: fmt.Sprintf("runner.concurrent (%v) exceeds %dx resources.cpu (%v); jobs will compete for CPU",
+			concurrent, maxConcurrentPerCPU, cpu),
+	}}
+}
+
+func checkMissingIdleTimeout(block *parser.Block) []Issue {
+	if !isRunnerHost(block) {
+		return nil
+	}
+	runnerBlock, ok := block.GetBlock("runner")
+	if !ok {
+		return nil
+	}
+	if _, ok := runnerBlock.GetAttribute("idle_timeout"); ok {
+		return nil
+	}
+	return []Issue{{
+		Position: runnerBlock.Position,
+		Message:  "runner block has no idle_timeout; idle runners will not be reclaimed",
+	}}
+}
+
+// nativeSecretSchemes maps each provider to the secret URI scheme of its native secret manager
+var nativeSecretSchemes = map[string]string{
+	"yandex": "yc-lockbox://",
+	"aws":    "aws-sm://",
+	"azure":  "azure-kv://",
+}
+
+func checkSecretBackendMismatch(block *parser.Block) []Issue {
+	if !isRunnerHost(block) {
+		return nil
+	}
+	provider := providerOf(block)
+	native, ok := nativeSecretSchemes[provider]
+	if !ok {
+		return nil
+	}
+
+	var issues []Issue
+	for _, gitlabBlock := range gitlabBlocks(block) {
+		secretVal, ok := gitlabBlock.GetAttribute("token_secret")
+		if !ok {
+			continue
+		}
+		secret, err := secretVal.AsString()
+		if err != nil {
+			continue
+		}
+		for otherProvider, scheme := range nativeSecretSchemes {
+			if otherProvider != provider && strings.HasPrefix(secret, scheme) {
+				issues = append(issues, Issue{
+					Position: secretVal.Position,
+					Message: fmt.Sprintf("token_secret uses %s (%s) but the egg runs on %s; use %s instead",
+						scheme, otherProvider, provider, native),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+func checkPlainVaultSecret(block *parser.Block) []Issue {
+	if !isRunnerHost(block) || providerOf(block) != "aws" {
+		return nil
+	}
+
+	var issues []Issue
+	for _, gitlabBlock := range gitlabBlocks(block) {
+		secretVal, ok := gitlabBlock.GetAttribute("token_secret")
+		if !ok {
+			continue
+		}
+		secret, err := secretVal.AsString()
+		if err != nil || !strings.HasPrefix(secret, "vault://") {
+			continue
+		}
+		issues = append(issues, Issue{
+			Position: secretVal.Position,
+			Message:  "token_secret uses vault:// on aws; prefer aws-sm:// so runners can read it with their IAM role",
+		})
+	}
+	return issues
+}
+
+// sharedBy describes who shares the runners of an egg or eggsbucket: the
+// repositories of an eggsbucket or the projects of a group runner. It
+// returns "" for runners of a single project.
+func sharedBy(block *parser.Block) string {
+	if block.Type == "eggsbucket" {
+		return "the repositories of eggsbucket " + strings.Join(block.Labels, " ")
+	}
+	if gitlabBlock, ok := block.GetBlock("gitlab"); ok {
+		if _, ok := gitlabBlock.GetAttribute("group_id"); ok {
+			return "the projects of its GitLab group"
+		}
+	}
+	return ""
+}
+
+func checkPrivilegedShared(block *parser.Block) []Issue {
+	if !isRunnerHost(block) {
+		return nil
+	}
+	runnerBlock, ok := block.GetBlock("runner")
+	if !ok {
+		return nil
+	}
+	shared := sharedBy(block)
+	if shared == "" {
+		return nil
+	}
+
+	var issues []Issue
+	for _, name := range []string{"privileged", "docker_dind"} {
+		val, ok := runnerBlock.GetAttribute(name)
+		if !ok {
+			continue
+		}
+		if enabled, err := val.AsBool(); err != nil || !enabled {
+			continue
+		}
+		issues = append(issues, Issue{
+			Position: val.Position,
+			Message: fmt.Sprintf("runner.%s is set on runners shared by %s; a job of any of them can take over the host and the others' jobs, so give each project its own egg",
+				name, shared),
+		})
+	}
+	return issues
+}
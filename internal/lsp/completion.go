@@ -0,0 +1,156 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// completion returns the block types, attributes or values that can be
+// written at pos
+func (d *document) completion(pos Position) []CompletionItem {
+	c := d.scan(d.offset(pos))
+	schema, ok := schemaOf(c.blocks)
+	if !ok {
+		return []CompletionItem{}
+	}
+
+	if hasToken(c.line, hclsyntax.TokenEqual) {
+		if schema == nil || len(c.line) == 0 || c.line[0].Type != hclsyntax.TokenIdent {
+			return []CompletionItem{}
+		}
+		attr, ok := schema.Attribute(word(c.line[0]))
+		if !ok {
+			return []CompletionItem{}
+		}
+		quoted := hasToken(c.line, hclsyntax.TokenOQuote)
+		return d.valueCompletion(attr, quoted)
+	}
+	if len(c.line) > 1 || (len(c.line) == 1 && c.line[0].Type != hclsyntax.TokenIdent) {
+		return []CompletionItem{} // Labels, or a value without "="
+	}
+
+	if schema == nil {
+		return topLevelCompletion()
+	}
+	return bodyCompletion(schema)
+}
+
+// topLevelCompletion offers the registered block types, include and
+// variable
+func topLevelCompletion() []CompletionItem {
+	items := []CompletionItem{{
+		Label:            "include",
+		Kind:             kindStruct,
+		Detail:           "include directive",
+		Documentation:    markdown("Merges the blocks of another .fly file, relative to this one."),
+		InsertText:       `include "${1:path}"`,
+		InsertTextFormat: formatSnippet,
+	}, {
+		Label:            "variable",
+		Kind:             kindStruct,
+		Detail:           "variable block",
+		Documentation:    markdown("Declares a value expressions in this file and the files including it refer to as `var.<name>`."),
+		InsertText:       "variable \"${1:name}\" {\n\tdefault = $0\n}",
+		InsertTextFormat: formatSnippet,
+	}}
+	for _, blockType := range parser.SchemaTypes() {
+		schema, _ := parser.LookupSchema(blockType)
+		items = append(items, blockItem(schema))
+	}
+	return items
+}
+
+// bodyCompletion offers the attributes and nested blocks of a block
+func bodyCompletion(schema *parser.BlockSchema) []CompletionItem {
+	items := []CompletionItem{}
+	if schema.Open {
+		return items
+	}
+	for i := range schema.Attributes {
+		attr := &schema.Attributes[i]
+		detail := string(attr.Type)
+		if attr.Required {
+			detail += " (required)"
+		}
+		items = append(items, CompletionItem{
+			Label:            attr.Name,
+			Kind:             kindProperty,
+			Detail:           detail,
+			Documentation:    markdown(attributeDoc(attr)),
+			InsertText:       attributeSnippet(attr),
+			InsertTextFormat: formatSnippet,
+		})
+	}
+	for _, nested := range schema.Blocks {
+		if nested.AnyType {
+			continue // Named by the user
+		}
+		items = append(items, blockItem(nested.Schema))
+	}
+	return items
+}
+
+// valueCompletion offers the allowed values of an attribute, or the preset
+// names for preset
+func (d *document) valueCompletion(attr *parser.AttributeSchema, quoted bool) []CompletionItem {
+	values := attr.Enum
+	detail := "allowed value"
+	if attr.Name == "preset" {
+		values = d.presets().Names()
+		detail = "resource preset"
+	}
+	items := []CompletionItem{}
+	for _, value := range values {
+		item := CompletionItem{Label: value, Kind: kindEnumMember, Detail: detail}
+		if !quoted {
+			item.InsertText = `"` + value + `"`
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// blockItem completes a block with its labels and braces
+func blockItem(schema *parser.BlockSchema) CompletionItem {
+	snippet := schema.Type + " {\n\t$0\n}"
+	if schema.Label != "" {
+		snippet = fmt.Sprintf("%s \"${1:%s}\" {\n\t$0\n}", schema.Type, schema.Label)
+	}
+	return CompletionItem{
+		Label:            schema.Type,
+		Kind:             kindStruct,
+		Detail:           "block",
+		Documentation:    markdown(schema.Description),
+		InsertText:       snippet,
+		InsertTextFormat: formatSnippet,
+	}
+}
+
+// attributeSnippet completes an attribute with a placeholder for its value
+func attributeSnippet(attr *parser.AttributeSchema) string {
+	switch {
+	case len(attr.Enum) > 0:
+		return fmt.Sprintf("%s = \"${1|%s|}\"", attr.Name, strings.Join(attr.Enum, ","))
+	case attr.Type == parser.AttrNumber || attr.Type == parser.AttrInteger:
+		return attr.Name + " = $1"
+	case attr.Type == parser.AttrBool:
+		return attr.Name + " = ${1|true,false|}"
+	case attr.Type == parser.AttrList || attr.Type == parser.AttrStringList:
+		return attr.Name + " = [$1]"
+	case attr.Type == parser.AttrMap:
+		return attr.Name + " = {\n\t$0\n}"
+	default:
+		return attr.Name + ` = "$1"`
+	}
+}
+
+// markdown returns documentation in Markdown, or nil when there is none
+func markdown(value string) *MarkupContent {
+	if value == "" {
+		return nil
+	}
+	return &MarkupContent{Kind: "markdown", Value: value}
+}
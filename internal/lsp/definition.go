@@ -0,0 +1,114 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// referenceAttributes name MotherGoose resources by the value of their name
+// attribute
+var referenceAttributes = map[string]bool{
+	"target_function":   true,
+	"dead_letter_queue": true,
+	"service_account":   true,
+}
+
+// definition resolves the reference at pos: an included file, a preset, an
+// Egg listed in eggs_entities, a MotherGoose resource, or a variable
+func (d *document) definition(pos Position) []Location {
+	locations := []Location{}
+	i, ok := d.tokenAt(d.offset(pos))
+	if ok && d.tokens[i].Type == hclsyntax.TokenIdent && i >= 2 &&
+		d.tokens[i-1].Type == hclsyntax.TokenDot && word(d.tokens[i-2]) == "var" {
+		return d.variableDefinition(locations, word(d.tokens[i]))
+	}
+	if !ok || d.tokens[i].Type != hclsyntax.TokenQuotedLit {
+		return locations
+	}
+	value := word(d.tokens[i])
+	stmt, _ := d.statement(i)
+
+	if len(stmt) > 0 && stmt[0].Type == hclsyntax.TokenIdent && word(stmt[0]) == "include" {
+		path := value
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(d.path), path)
+		}
+		return appendFile(locations, path)
+	}
+
+	name, _, ok := quotedValue(stmt)
+	if !ok {
+		if list, ok := d.enclosingList(i); ok && list == "eggs_entities" {
+			if root, ok := d.nestRoot(); ok {
+				return appendFile(locations, filepath.Join(root, "Eggs", value, "config.fly"))
+			}
+		}
+		return locations
+	}
+
+	switch {
+	case name == "preset":
+		preset, ok := d.presets()[value]
+		if ok && preset.Position.File != "" {
+			locations = append(locations, Location{URI: pathToURI(preset.Position.File), Range: lineRange(preset.Position.Line - 1)})
+		}
+	case referenceAttributes[name]:
+		// The resource whose name attribute has the value
+		for j, tok := range d.tokens {
+			if tok.Type != hclsyntax.TokenQuotedLit || j == i || word(tok) != value {
+				continue
+			}
+			if stmt, _ := d.statement(j); len(stmt) > 0 {
+				if name, _, ok := quotedValue(stmt); ok && name == "name" {
+					locations = append(locations, Location{URI: pathToURI(d.path), Range: hclRange(tok.Range)})
+				}
+			}
+		}
+	}
+	return locations
+}
+
+// variableDefinition adds the variable block declaring name in the
+// document. Variables of included files are not followed.
+func (d *document) variableDefinition(locations []Location, name string) []Location {
+	for j := 0; j+2 < len(d.tokens); j++ {
+		if d.tokens[j].Type == hclsyntax.TokenIdent && word(d.tokens[j]) == "variable" &&
+			d.tokens[j+1].Type == hclsyntax.TokenOQuote && word(d.tokens[j+2]) == name {
+			locations = append(locations, Location{URI: pathToURI(d.path), Range: hclRange(d.tokens[j].Range)})
+		}
+	}
+	return locations
+}
+
+// enclosingList returns the attribute whose list value contains token i
+func (d *document) enclosingList(i int) (string, bool) {
+	depth := 0
+	for j := i - 1; j >= 0; j-- {
+		switch d.tokens[j].Type {
+		case hclsyntax.TokenCBrack:
+			depth++
+		case hclsyntax.TokenOBrack:
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if j >= 2 && d.tokens[j-1].Type == hclsyntax.TokenEqual && d.tokens[j-2].Type == hclsyntax.TokenIdent {
+				return word(d.tokens[j-2]), true
+			}
+			return "", false
+		case hclsyntax.TokenOBrace, hclsyntax.TokenCBrace:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// appendFile adds the start of a file to locations if it exists
+func appendFile(locations []Location, path string) []Location {
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return locations
+	}
+	return append(locations, Location{URI: pathToURI(path), Range: lineRange(0)})
+}
@@ -0,0 +1,364 @@
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// document is the text of a .fly file being edited. It is analysed from its
+// tokens rather than its syntax tree, so completion and hover keep working
+// while the text does not parse.
+type document struct {
+	path   string
+	text   []byte
+	lines  []int // Byte offset of the start of each line
+	tokens hclsyntax.Tokens
+}
+
+func newDocument(path, text string) *document {
+	d := &document{path: path, text: []byte(text), lines: []int{0}}
+	for i, b := range d.text {
+		if b == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	d.tokens, _ = hclsyntax.LexConfig(d.text, path, hcl.Pos{Line: 1, Column: 1})
+	return d
+}
+
+// offset converts a position to a byte offset, clamped to its line
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	end := len(d.text)
+	if pos.Line+1 < len(d.lines) {
+		end = d.lines[pos.Line+1] - 1
+	}
+	if offset := d.lines[pos.Line] + pos.Character; offset < end {
+		return offset
+	}
+	return end
+}
+
+// hclRange converts an HCL range to an LSP range
+func hclRange(r hcl.Range) Range {
+	return Range{
+		Start: Position{Line: r.Start.Line - 1, Character: r.Start.Column - 1},
+		End:   Position{Line: r.End.Line - 1, Character: r.End.Column - 1},
+	}
+}
+
+// lineRange is the range of the start of a line
+func lineRange(line int) Range {
+	if line < 0 {
+		line = 0
+	}
+	return Range{Start: Position{Line: line}, End: Position{Line: line}}
+}
+
+// cursor describes the syntactic context at an offset
+type cursor struct {
+	blocks []string         // Types of the enclosing blocks, outermost first; "" for an object
+	line   hclsyntax.Tokens // Tokens of the statement before the offset
+}
+
+// scan walks the tokens before offset to find the context there
+func (d *document) scan(offset int) cursor {
+	var c cursor
+	for _, tok := range d.tokens {
+		if tok.Range.Start.Byte >= offset || tok.Type == hclsyntax.TokenEOF {
+			break
+		}
+		switch tok.Type {
+		case hclsyntax.TokenNewline:
+			c.line = nil
+		case hclsyntax.TokenOBrace:
+			if blockType, ok := blockTypeOf(c.line); ok {
+				c.blocks = append(c.blocks, blockType)
+				c.line = nil
+				continue
+			}
+			c.blocks = append(c.blocks, "")
+			c.line = append(c.line, tok)
+		case hclsyntax.TokenCBrace:
+			if len(c.blocks) > 0 {
+				c.blocks = c.blocks[:len(c.blocks)-1]
+			}
+			c.line = append(c.line, tok)
+		default:
+			c.line = append(c.line, tok)
+		}
+	}
+	return c
+}
+
+// blockTypeOf returns the type of the block opened by a statement ending
+// with "{", which is a type followed by quoted or bare labels
+func blockTypeOf(line hclsyntax.Tokens) (string, bool) {
+	if len(line) == 0 || line[0].Type != hclsyntax.TokenIdent {
+		return "", false
+	}
+	for _, tok := range line[1:] {
+		switch tok.Type {
+		case hclsyntax.TokenOQuote, hclsyntax.TokenCQuote, hclsyntax.TokenQuotedLit, hclsyntax.TokenIdent:
+		default:
+			return "", false
+		}
+	}
+	return string(line[0].Bytes), true
+}
+
+// tokenAt returns the index of the token containing offset
+func (d *document) tokenAt(offset int) (int, bool) {
+	for i, tok := range d.tokens {
+		if tok.Type == hclsyntax.TokenEOF {
+			break
+		}
+		if tok.Range.Start.Byte <= offset && offset < tok.Range.End.Byte {
+			return i, true
+		}
+		// The cursor right after a word still refers to it
+		if offset == tok.Range.End.Byte && (tok.Type == hclsyntax.TokenIdent || tok.Type == hclsyntax.TokenQuotedLit) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// schemaOf resolves the schema of the innermost block of a path of block
+// types. It returns nil and true at the top level, and false inside objects
+// and blocks without a schema.
+func schemaOf(blocks []string) (*parser.BlockSchema, bool) {
+	if len(blocks) == 0 {
+		return nil, true
+	}
+	schema, ok := parser.LookupSchema(blocks[0])
+	if !ok {
+		return nil, false
+	}
+	for _, blockType := range blocks[1:] {
+		if blockType == "" || schema.Open {
+			return nil, false
+		}
+		nested, ok := nestedSchema(schema, blockType)
+		if !ok {
+			return nil, false
+		}
+		schema = nested
+	}
+	return schema, true
+}
+
+// nestedSchema returns the schema of a block nested in one of schema
+func nestedSchema(schema *parser.BlockSchema, blockType string) (*parser.BlockSchema, bool) {
+	if nested, ok := schema.NestedBlock(blockType); ok {
+		return nested.Schema, true
+	}
+	for _, nested := range schema.Blocks {
+		if nested.AnyType {
+			return nested.Schema, true
+		}
+	}
+	return nil, false
+}
+
+// diagnostics parses and validates the document. Files that only hold part
+// of a configuration (environment overlays, include fragments in
+// directories starting with "_", presets and policies) are only parsed.
+func (d *document) diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	config, err := parser.NewParser().Parse(d.text, d.path)
+	if err != nil {
+		var syntaxErr *parser.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return append(diagnostics, Diagnostic{Range: d.errorRange(err), Severity: severityError, Source: "gosling", Message: err.Error()})
+		}
+		for _, diag := range syntaxErr.Diagnostics {
+			r := lineRange(0)
+			if diag.Subject != nil && diag.Subject.Filename == d.path {
+				r = hclRange(*diag.Subject)
+			}
+			message := diag.Summary
+			if diag.Detail != "" {
+				message += ": " + diag.Detail
+			}
+			diagnostics = append(diagnostics, Diagnostic{Range: r, Severity: severityError, Source: "gosling", Message: message})
+		}
+		return diagnostics
+	}
+	if !d.complete(config) {
+		return diagnostics
+	}
+
+	var result *parser.ValidationResult
+	if isMotherGoose(config) {
+		result = parser.ValidateMotherGoose(config)
+	} else {
+		result = parser.NewValidator(config).Validate()
+	}
+	for _, verr := range append(result.Errors, result.Warnings...) {
+		r := lineRange(0)
+		if verr.Position.File == d.path && verr.Position.Line > 0 {
+			r = lineRange(verr.Position.Line - 1)
+			r.Start.Character = verr.Position.Column - 1
+			r.End = d.wordEnd(r.Start)
+		}
+		severity := severityError
+		if verr.Severity == parser.SeverityWarning {
+			severity = severityWarning
+		}
+		diagnostics = append(diagnostics, Diagnostic{Range: r, Severity: severity, Source: "gosling", Message: verr.Message})
+	}
+	return diagnostics
+}
+
+// complete reports whether the document is a whole configuration to validate
+func (d *document) complete(config *parser.Config) bool {
+	if _, _, ok := parser.SplitOverlayPath(d.path); ok {
+		return false
+	}
+	for dir := filepath.Dir(d.path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if strings.HasPrefix(filepath.Base(dir), "_") {
+			return false
+		}
+	}
+	for _, block := range config.Blocks {
+		if _, ok := parser.LookupSchema(block.Type); !ok {
+			return false
+		}
+	}
+	return len(config.Blocks) > 0
+}
+
+// isMotherGoose reports whether config declares the MotherGoose backend,
+// whose references are checked on top of its schema
+func isMotherGoose(config *parser.Config) bool {
+	for _, block := range config.Blocks {
+		if block.Type == "mothergoose" {
+			return true
+		}
+	}
+	return false
+}
+
+// errorRange locates an error whose message starts with a position of the
+// document, such as "config.fly:3:5: ..."
+func (d *document) errorRange(err error) Range {
+	var line, column int
+	rest := strings.TrimPrefix(err.Error(), d.path+":")
+	if rest == err.Error() {
+		return lineRange(0)
+	}
+	for _, target := range []*int{&line, &column} {
+		n := 0
+		for len(rest) > 0 && rest[0] >= '0' && rest[0] <= '9' {
+			n = n*10 + int(rest[0]-'0')
+			rest = rest[1:]
+		}
+		*target = n
+		rest = strings.TrimPrefix(rest, ":")
+	}
+	if line == 0 {
+		return lineRange(0)
+	}
+	r := lineRange(line - 1)
+	if column > 0 {
+		r.Start.Character = column - 1
+		r.End = d.wordEnd(r.Start)
+	}
+	return r
+}
+
+// wordEnd returns the end of the word or quoted string starting at pos
+func (d *document) wordEnd(pos Position) Position {
+	start := d.offset(pos)
+	if i, ok := d.tokenAt(start); ok {
+		tok := d.tokens[i]
+		if tok.Type == hclsyntax.TokenOQuote && i+2 < len(d.tokens) {
+			tok = d.tokens[i+2]
+		}
+		return Position{Line: tok.Range.End.Line - 1, Character: tok.Range.End.Column - 1}
+	}
+	return pos
+}
+
+// nestRoot returns the Nest containing the document: the nearest ancestor
+// directory with an Eggs directory
+func (d *document) nestRoot() (string, bool) {
+	for dir := filepath.Dir(d.path); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(filepath.Join(dir, "Eggs")); err == nil && info.IsDir() {
+			return dir, true
+		}
+		if dir == filepath.Dir(dir) {
+			return "", false
+		}
+	}
+}
+
+// presets returns the presets available to the document
+func (d *document) presets() parser.PresetCatalog {
+	if root, ok := d.nestRoot(); ok {
+		if catalog, err := parser.LoadPresets(filepath.Join(root, parser.PresetsDirName)); err == nil {
+			return catalog
+		}
+	}
+	return parser.BuiltinPresets()
+}
+
+// statement returns the tokens of the statement containing token i, and the
+// index of token i in it
+func (d *document) statement(i int) (hclsyntax.Tokens, int) {
+	start := i
+	for start > 0 && !isStatementBoundary(d.tokens[start-1]) {
+		start--
+	}
+	end := i
+	for end < len(d.tokens) && !isStatementBoundary(d.tokens[end]) {
+		end++
+	}
+	return d.tokens[start:end], i - start
+}
+
+func isStatementBoundary(tok hclsyntax.Token) bool {
+	switch tok.Type {
+	case hclsyntax.TokenNewline, hclsyntax.TokenOBrace, hclsyntax.TokenCBrace, hclsyntax.TokenEOF:
+		return true
+	}
+	return false
+}
+
+// quotedValue returns the string of the statement name = "value", if the
+// statement is one
+func quotedValue(stmt hclsyntax.Tokens) (name, value string, ok bool) {
+	if len(stmt) != 5 || stmt[0].Type != hclsyntax.TokenIdent || stmt[1].Type != hclsyntax.TokenEqual ||
+		stmt[2].Type != hclsyntax.TokenOQuote || stmt[3].Type != hclsyntax.TokenQuotedLit {
+		return "", "", false
+	}
+	return string(stmt[0].Bytes), string(stmt[3].Bytes), true
+}
+
+// hasToken reports whether tokens contain one of type t
+func hasToken(tokens hclsyntax.Tokens, t hclsyntax.TokenType) bool {
+	for _, tok := range tokens {
+		if tok.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// word returns the text of a token
+func word(tok hclsyntax.Token) string {
+	return string(bytes.TrimSpace(tok.Bytes))
+}
@@ -0,0 +1,129 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// hover documents the attribute or block type at pos
+func (d *document) hover(pos Position) *Hover {
+	i, ok := d.tokenAt(d.offset(pos))
+	if !ok || d.tokens[i].Type != hclsyntax.TokenIdent {
+		return nil
+	}
+	stmt, idx := d.statement(i)
+	if idx != 0 {
+		return nil // Only the first word names an attribute or block
+	}
+	tok := d.tokens[i]
+	name := word(tok)
+	c := d.scan(tok.Range.Start.Byte)
+	r := hclRange(tok.Range)
+
+	if len(stmt) > 1 && stmt[1].Type == hclsyntax.TokenEqual {
+		schema, ok := schemaOf(c.blocks)
+		if !ok || schema == nil {
+			return nil
+		}
+		attr, ok := schema.Attribute(name)
+		if !ok {
+			return nil
+		}
+		return &Hover{Contents: *markdown(attributeDoc(attr)), Range: &r}
+	}
+
+	// A block header is followed by its "{"
+	if _, ok := blockTypeOf(stmt); !ok || d.tokens[i+len(stmt)].Type != hclsyntax.TokenOBrace {
+		return nil
+	}
+	var schema *parser.BlockSchema
+	if len(c.blocks) == 0 {
+		schema, ok = parser.LookupSchema(name)
+	} else if parent, found := schemaOf(c.blocks); found && parent != nil && !parent.Open {
+		schema, ok = nestedSchema(parent, name)
+	} else {
+		ok = false
+	}
+	if !ok {
+		return nil
+	}
+	return &Hover{Contents: *markdown(blockDoc(schema)), Range: &r}
+}
+
+// attributeDoc describes an attribute in Markdown
+func attributeDoc(attr *parser.AttributeSchema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** `%s`", attr.Name, attr.Type)
+	if attr.Required {
+		b.WriteString(" (required)")
+	}
+	if attr.Description != "" {
+		fmt.Fprintf(&b, "\n\n%s", attr.Description)
+	}
+
+	var facts []string
+	switch {
+	case attr.Min != nil && attr.Max != nil:
+		facts = append(facts, fmt.Sprintf("Range: %g to %g", *attr.Min, *attr.Max))
+	case attr.Min != nil:
+		facts = append(facts, fmt.Sprintf("Minimum: %g", *attr.Min))
+	case attr.Max != nil:
+		facts = append(facts, fmt.Sprintf("Maximum: %g", *attr.Max))
+	}
+	if len(attr.Enum) > 0 {
+		facts = append(facts, "Allowed values: `"+strings.Join(attr.Enum, "`, `")+"`")
+	}
+	if attr.Format != "" {
+		format := "Format: " + attr.Format
+		switch {
+		case attr.MinDuration != "" && attr.MaxDuration != "":
+			format += fmt.Sprintf(" (%s to %s)", attr.MinDuration, attr.MaxDuration)
+		case attr.MinDuration != "":
+			format += fmt.Sprintf(" (at least %s)", attr.MinDuration)
+		case attr.MaxDuration != "":
+			format += fmt.Sprintf(" (at most %s)", attr.MaxDuration)
+		}
+		facts = append(facts, format)
+	}
+	if len(facts) > 0 {
+		b.WriteString("\n\n" + strings.Join(facts, "  \n"))
+	}
+	return b.String()
+}
+
+// blockDoc describes a block in Markdown
+func blockDoc(schema *parser.BlockSchema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** block", schema.Type)
+	if schema.Label != "" {
+		fmt.Fprintf(&b, " \"<%s>\"", schema.Label)
+	}
+	if schema.Description != "" {
+		fmt.Fprintf(&b, "\n\n%s", schema.Description)
+	}
+	if schema.Open {
+		return b.String()
+	}
+
+	var attrs, blocks []string
+	for _, attr := range schema.Attributes {
+		name := "`" + attr.Name + "`"
+		if attr.Required {
+			name += " (required)"
+		}
+		attrs = append(attrs, name)
+	}
+	for _, nested := range schema.Blocks {
+		blocks = append(blocks, "`"+nested.Schema.Type+"`")
+	}
+	if len(attrs) > 0 {
+		b.WriteString("\n\nAttributes: " + strings.Join(attrs, ", "))
+	}
+	if len(blocks) > 0 {
+		b.WriteString("\n\nBlocks: " + strings.Join(blocks, ", "))
+	}
+	return b.String()
+}
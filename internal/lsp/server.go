@@ -0,0 +1,227 @@
+// Package lsp implements a language server for .fly files: diagnostics from
+// the parser and validator, completion and hover documentation from the
+// schema registry, and go-to-definition for references to presets, included
+// files, Eggs and MotherGoose resources.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Server is a language server for .fly files speaking JSON-RPC over a
+// stream, usually stdin and stdout. Requests are handled one at a time.
+type Server struct {
+	version      string
+	out          io.Writer
+	docs         map[string]string // Text of the open documents by URI
+	initialized  bool
+	shuttingDown bool
+}
+
+// NewServer returns a server reporting version to clients
+func NewServer(version string) *Server {
+	return &Server{version: version, docs: make(map[string]string)}
+}
+
+// errExitWithoutShutdown is returned when the client exits without shutdown
+var errExitWithoutShutdown = errors.New("client exited without shutdown")
+
+// Serve handles the messages read from r, writing responses to w, until the
+// client sends exit or r is closed
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	reader := bufio.NewReader(r)
+	for {
+		body, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shuttingDown {
+				return errExitWithoutShutdown
+			}
+			return nil
+		}
+		if err := s.handle(&req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a request or notification
+func (s *Server) handle(req *request) error {
+	if !s.initialized && req.Method != "initialize" {
+		if req.ID == nil {
+			return nil // Notifications before initialize are dropped
+		}
+		return s.reply(req.ID, nil, &responseError{Code: codeServerNotInitialized, Message: "server not initialized"})
+	}
+
+	switch req.Method {
+	case "initialize":
+		s.initialized = true
+		return s.reply(req.ID, initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:   textDocumentSyncOptions{OpenClose: true, Change: syncFull, Save: saveOptions{IncludeText: true}},
+				CompletionProvider: completionOptions{TriggerCharacters: []string{"\"", " "}},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "gosling", Version: s.version},
+		}, nil)
+	case "initialized":
+		return nil
+	case "shutdown":
+		s.shuttingDown = true
+		return s.reply(req.ID, nil, nil)
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		// Full sync: the last change holds the whole document
+		s.docs[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didSave":
+		var params didSaveParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		if params.Text != nil {
+			s.docs[params.TextDocument.URI] = *params.Text
+		}
+		return s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return s.reply(req.ID, nil, &responseError{Code: codeInvalidParams, Message: err.Error()})
+		}
+		text, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return s.reply(req.ID, nil, nil)
+		}
+		doc := newDocument(uriToPath(params.TextDocument.URI), text)
+		switch req.Method {
+		case "textDocument/completion":
+			return s.reply(req.ID, doc.completion(params.Position), nil)
+		case "textDocument/hover":
+			return s.reply(req.ID, doc.hover(params.Position), nil)
+		default:
+			return s.reply(req.ID, doc.definition(params.Position), nil)
+		}
+
+	default:
+		if req.ID == nil {
+			return nil // Unknown notifications, such as $/cancelRequest, are ignored
+		}
+		return s.reply(req.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method})
+	}
+}
+
+// publishDiagnostics sends the diagnostics of an open document
+func (s *Server) publishDiagnostics(uri string) error {
+	diagnostics := newDocument(uriToPath(uri), s.docs[uri]).diagnostics()
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rpcErr *responseError) error {
+	if id == nil && rpcErr == nil {
+		return nil // Notifications get no response
+	}
+	return s.write(response{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write sends a message with its Content-Length header
+func (s *Server) write(msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// readMessage reads the body of the next message
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read message header: %w", err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	return body, nil
+}
+
+// uriToPath converts a file URI to a path
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/") // file:///C:/Nest
+	}
+	return filepath.FromSlash(path)
+}
+
+// pathToURI converts a path to a file URI
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,342 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const eggConfig = `egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "aws"
+    region   = "eu-central-1"
+  }
+
+  resources {
+    preset = "medium"
+  }
+
+  runner {
+    tags       = ["docker"]
+    concurrent = 2
+  }
+
+  gitlab {
+    project_id   = 42
+    server_name  = "gitlab.com"
+    token_secret = "aws-sm://gitlab/runner-token"
+  }
+}
+`
+
+// session is a scripted conversation with a server
+type session struct {
+	in  bytes.Buffer
+	ids int
+}
+
+func (s *session) send(method string, params interface{}, request bool) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+	if request {
+		s.ids++
+		msg["id"] = s.ids
+	}
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(&s.in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *session) open(uri, text string) {
+	s.send("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "fly", "version": 1, "text": text},
+	}, false)
+}
+
+func (s *session) at(method, uri string, line, character int) {
+	s.send(method, map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}, true)
+}
+
+// run serves the session, returning the responses by ID and the
+// notifications in order
+func (s *session) run(t *testing.T) (map[int]json.RawMessage, []json.RawMessage) {
+	t.Helper()
+	s.send("shutdown", nil, true)
+	s.send("exit", nil, false)
+	var out bytes.Buffer
+	if err := NewServer("test").Serve(&s.in, &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := make(map[int]json.RawMessage)
+	var notifications []json.RawMessage
+	r := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		var msg struct {
+			ID     *int            `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("invalid message %s: %v", body, err)
+		}
+		if msg.ID != nil {
+			responses[*msg.ID] = msg.Result
+		} else {
+			notifications = append(notifications, msg.Params)
+		}
+	}
+	return responses, notifications
+}
+
+func newSession() *session {
+	s := &session{}
+	s.send("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, true)
+	s.send("initialized", map[string]interface{}{}, false)
+	return s
+}
+
+// writeNest creates a Nest with the given files
+func writeNest(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestServeDiagnostics(t *testing.T) {
+	root := writeNest(t, map[string]string{"Eggs/my-app/config.fly": eggConfig})
+	uri := pathToURI(filepath.Join(root, "Eggs", "my-app", "config.fly"))
+
+	s := newSession()
+	s.open(uri, eggConfig)
+	s.open(uri, strings.Replace(eggConfig, `type = "vm"`, `type = "container"`, 1))
+	s.open(uri, "egg \"my-app\" {\n  type = \n}\n")
+	_, notifications := s.run(t)
+
+	if len(notifications) != 3 {
+		t.Fatalf("got %d notifications, want 3", len(notifications))
+	}
+	var results []publishDiagnosticsParams
+	for _, n := range notifications {
+		var params publishDiagnosticsParams
+		if err := json.Unmarshal(n, &params); err != nil {
+			t.Fatal(err)
+		}
+		if params.URI != uri {
+			t.Errorf("diagnostics for %s, want %s", params.URI, uri)
+		}
+		results = append(results, params)
+	}
+
+	if len(results[0].Diagnostics) != 0 {
+		t.Errorf("valid config got diagnostics %+v", results[0].Diagnostics)
+	}
+	if len(results[1].Diagnostics) != 1 {
+		t.Fatalf("invalid type got diagnostics %+v, want 1", results[1].Diagnostics)
+	}
+	if d := results[1].Diagnostics[0]; d.Range.Start.Line != 1 || !strings.Contains(d.Message, "container") {
+		t.Errorf("invalid type diagnostic = %+v, want one on line 1 naming the value", d)
+	}
+	if len(results[2].Diagnostics) == 0 || results[2].Diagnostics[0].Range.Start.Line != 1 {
+		t.Errorf("syntax error got diagnostics %+v, want one on line 1", results[2].Diagnostics)
+	}
+}
+
+func TestServeDiagnosticsSkipsOverlays(t *testing.T) {
+	s := newSession()
+	s.open("file:///nest/Eggs/my-app/config.prod.fly", "egg \"my-app\" {\n  type = \"serverless\"\n}\n")
+	_, notifications := s.run(t)
+
+	var params publishDiagnosticsParams
+	if err := json.Unmarshal(notifications[0], &params); err != nil {
+		t.Fatal(err)
+	}
+	if len(params.Diagnostics) != 0 {
+		t.Errorf("overlay got diagnostics %+v, want none", params.Diagnostics)
+	}
+}
+
+func TestServeCompletion(t *testing.T) {
+	root := writeNest(t, map[string]string{
+		"Eggs/my-app/config.fly": eggConfig,
+		"Presets/gpu.fly":        "preset \"gpu-large\" {\n  cpu    = 8\n  memory = 32768\n  disk   = 200\n}\n",
+	})
+	uri := pathToURI(filepath.Join(root, "Eggs", "my-app", "config.fly"))
+	text := strings.Replace(eggConfig, `preset = "medium"`, `preset = "`, 1)
+
+	s := newSession()
+	s.open(uri, text)
+	s.at("textDocument/completion", uri, 0, 0)  // Top level
+	s.at("textDocument/completion", uri, 4, 4)  // Start of provider in cloud
+	s.at("textDocument/completion", uri, 9, 14) // Value of preset
+	s.at("textDocument/completion", uri, 1, 9)  // Value of type, before its quote
+	responses, _ := s.run(t)
+
+	labels := func(id int) []string {
+		var items []CompletionItem
+		if err := json.Unmarshal(responses[id], &items); err != nil {
+			t.Fatalf("invalid completion %s: %v", responses[id], err)
+		}
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+	for _, tt := range []struct {
+		id   int
+		want []string
+	}{
+		{2, []string{"include", "variable", "egg", "mothergoose"}},
+		{3, []string{"provider", "region"}},
+		{4, []string{"small", "medium", "gpu-large"}},
+		{5, []string{"vm", "serverless"}},
+	} {
+		got := labels(tt.id)
+		for _, want := range tt.want {
+			if !contains(got, want) {
+				t.Errorf("completion %d = %v, missing %q", tt.id, got, want)
+			}
+		}
+	}
+	if got := labels(3); contains(got, "egg") {
+		t.Errorf("completion in cloud = %v, offers top-level blocks", got)
+	}
+}
+
+func TestServeHover(t *testing.T) {
+	uri := "file:///nest/Eggs/my-app/config.fly"
+	s := newSession()
+	s.open(uri, eggConfig)
+	s.at("textDocument/hover", uri, 13, 5) // tags
+	s.at("textDocument/hover", uri, 3, 3)  // cloud
+	s.at("textDocument/hover", uri, 1, 12) // The value of type
+	responses, _ := s.run(t)
+
+	var tags, cloud Hover
+	if err := json.Unmarshal(responses[2], &tags); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(tags.Contents.Value, "**tags** `list(string)`") {
+		t.Errorf("hover on tags = %q", tags.Contents.Value)
+	}
+	if err := json.Unmarshal(responses[3], &cloud); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cloud.Contents.Value, "**cloud** block") || !strings.Contains(cloud.Contents.Value, "`provider` (required)") {
+		t.Errorf("hover on cloud = %q", cloud.Contents.Value)
+	}
+	if string(responses[4]) != "null" {
+		t.Errorf("hover on a value = %s, want null", responses[4])
+	}
+}
+
+func TestServeDefinition(t *testing.T) {
+	root := writeNest(t, map[string]string{
+		"Eggs/my-app/config.fly": eggConfig,
+		"Eggs/_shared/base.fly":  "",
+		"Presets/gpu.fly":        "\npreset \"gpu-large\" {\n  cpu    = 8\n  memory = 32768\n  disk   = 200\n}\n",
+	})
+	eggPath := filepath.Join(root, "Eggs", "my-app", "config.fly")
+	uri := pathToURI(eggPath)
+	text := "include \"../_shared/base.fly\"\n" + strings.Replace(eggConfig, `preset = "medium"`, `preset = "gpu-large"`, 1)
+	bucketURI := pathToURI(filepath.Join(root, "EggsBucket", "config.fly"))
+	motherGooseURI := pathToURI(filepath.Join(root, "MotherGoose", "config.fly"))
+
+	s := newSession()
+	s.open(uri, text)
+	s.open(bucketURI, "eggs_bucket \"runners\" {\n  eggs_entities = [\n    \"my-app\",\n  ]\n}\n")
+	s.open(motherGooseURI, "mothergoose {\n  fastapi_app {\n    name            = \"api\"\n    service_account = \"mothergoose-sa\"\n  }\n  service_accounts {\n    mothergoose {\n      name = \"mothergoose-sa\"\n    }\n  }\n}\n")
+	s.at("textDocument/definition", uri, 0, 12)
+	s.at("textDocument/definition", uri, 10, 16)
+	s.at("textDocument/definition", bucketURI, 2, 7)
+	s.at("textDocument/definition", motherGooseURI, 3, 25)
+	s.at("textDocument/definition", uri, 1, 1) // Not a reference
+	responses, _ := s.run(t)
+
+	for _, tt := range []struct {
+		id   int
+		uri  string
+		line int
+	}{
+		{2, pathToURI(filepath.Join(root, "Eggs", "_shared", "base.fly")), 0},
+		{3, pathToURI(filepath.Join(root, "Presets", "gpu.fly")), 1},
+		{4, uri, 0},
+		{5, motherGooseURI, 7},
+	} {
+		var locations []Location
+		if err := json.Unmarshal(responses[tt.id], &locations); err != nil {
+			t.Fatal(err)
+		}
+		if len(locations) != 1 || locations[0].URI != tt.uri || locations[0].Range.Start.Line != tt.line {
+			t.Errorf("definition %d = %+v, want %s line %d", tt.id, locations, tt.uri, tt.line)
+		}
+	}
+	if string(responses[6]) != "[]" {
+		t.Errorf("definition of a block type = %s, want []", responses[6])
+	}
+}
+
+func TestServeVariableDefinition(t *testing.T) {
+	root := writeNest(t, map[string]string{"Eggs/my-app/config.fly": ""})
+	uri := pathToURI(filepath.Join(root, "Eggs", "my-app", "config.fly"))
+	text := "variable \"cpu\" {\n  default = 4\n}\n\n" + strings.Replace(eggConfig, `preset = "medium"`, `cpu = var.cpu`, 1)
+
+	s := newSession()
+	s.open(uri, text)
+	s.at("textDocument/definition", uri, 13, 15)
+	responses, _ := s.run(t)
+
+	var locations []Location
+	if err := json.Unmarshal(responses[2], &locations); err != nil {
+		t.Fatal(err)
+	}
+	if len(locations) != 1 || locations[0].URI != uri || locations[0].Range.Start.Line != 0 {
+		t.Errorf("definition = %+v, want %s line 0", locations, uri)
+	}
+}
+
+func TestServeRequiresInitialize(t *testing.T) {
+	var in, out bytes.Buffer
+	body := `{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{}}`
+	fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	body = `{"jsonrpc":"2.0","method":"exit"}`
+	fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+
+	if err := NewServer("test").Serve(&in, &out); err != errExitWithoutShutdown {
+		t.Errorf("Serve() error = %v, want %v", err, errExitWithoutShutdown)
+	}
+	if !strings.Contains(out.String(), `"code":-32002`) {
+		t.Errorf("response = %s, want server not initialized", out.String())
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package mothergoose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/polar-gosling/gosling/internal/audit"
+)
+
+// RecordAuditEntry sends an audited CLI action to POST /audit. MotherGoose
+// stores it next to the deployment history of the affected Eggs.
+func (c *Client) RecordAuditEntry(ctx context.Context, entry *audit.Entry) error {
+	url := fmt.Sprintf("%s/audit", c.baseURL)
+
+	err := c.doRequestWithRetry(ctx, "POST", url, entry, nil)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package mothergoose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/audit"
+)
+
+func TestRecordAuditEntry(t *testing.T) {
+	var received audit.Entry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/audit" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	entry := &audit.Entry{Command: "gosling deploy", User: "alice", Outcome: audit.OutcomeSuccess}
+	if err := client.RecordAuditEntry(context.Background(), entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if received.Command != "gosling deploy" || received.User != "alice" {
+		t.Errorf("expected the entry to be posted, got %+v", received)
+	}
+}
@@ -0,0 +1,155 @@
+package mothergoose
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBackendUnavailable is returned, wrapped, for requests refused by an open
+// circuit breaker
+var ErrBackendUnavailable = errors.New("MotherGoose backend unavailable")
+
+// CircuitBreakerPolicy controls when the client stops sending requests to a
+// failing MotherGoose host
+type CircuitBreakerPolicy struct {
+	// Threshold is the number of consecutive 5xx responses that opens the
+	// circuit; zero disables the breaker
+	Threshold int
+	// Cooldown is how long an open circuit refuses requests before letting a
+	// single probe through
+	Cooldown time.Duration
+}
+
+// DefaultCircuitBreakerPolicy is the policy used unless WithCircuitBreaker is given
+var DefaultCircuitBreakerPolicy = CircuitBreakerPolicy{
+	Threshold: 5,
+	Cooldown:  30 * time.Second,
+}
+
+// WithCircuitBreaker sets when the client stops sending requests to a failing host
+func WithCircuitBreaker(policy CircuitBreakerPolicy) ClientOption {
+	return func(c *Client) {
+		c.breaker = newCircuitBreaker(policy)
+	}
+}
+
+// CircuitState is the state of a host's circuit breaker
+type CircuitState string
+
+// Circuit breaker states
+const (
+	// CircuitClosed lets requests through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen refuses requests until the cooldown has passed
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe through to test the host
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitStatus describes a host's circuit breaker
+type CircuitStatus struct {
+	Host  string
+	State CircuitState
+	// Failures is the number of consecutive 5xx responses from the host
+	Failures int
+	// RetryAt is when an open circuit lets the next probe through
+	RetryAt time.Time
+}
+
+// circuit is the breaker state of one host
+type circuit struct {
+	failures int
+	openedAt time.Time
+	probing  bool // a half-open probe is in flight
+}
+
+// circuitBreaker tracks the circuits of the hosts a client talks to; it is
+// safe for concurrent use
+type circuitBreaker struct {
+	policy   CircuitBreakerPolicy
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+func newCircuitBreaker(policy CircuitBreakerPolicy) *circuitBreaker {
+	return &circuitBreaker{policy: policy, circuits: make(map[string]*circuit), now: time.Now}
+}
+
+// state returns the state of c, which the caller must have locked
+func (b *circuitBreaker) state(c *circuit) CircuitState {
+	switch {
+	case b.policy.Threshold <= 0 || c.failures < b.policy.Threshold:
+		return CircuitClosed
+	case b.now().Sub(c.openedAt) < b.policy.Cooldown:
+		return CircuitOpen
+	default:
+		return CircuitHalfOpen
+	}
+}
+
+// allow reports whether a request to host may be sent. In the half-open state
+// only one probe is let through at a time.
+func (b *circuitBreaker) allow(host string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[host]
+	if !ok {
+		return nil
+	}
+	switch b.state(c) {
+	case CircuitOpen:
+		retryAt := c.openedAt.Add(b.policy.Cooldown)
+		return fmt.Errorf("%w: %s returned %d consecutive server errors; not retrying before %s",
+			ErrBackendUnavailable, host, c.failures, retryAt.Format(time.RFC3339))
+	case CircuitHalfOpen:
+		if c.probing {
+			return fmt.Errorf("%w: %s is being probed after %d consecutive server errors", ErrBackendUnavailable, host, c.failures)
+		}
+		c.probing = true
+	}
+	return nil
+}
+
+// record updates host's circuit with the outcome of a request. Server errors
+// count against the threshold, any other response closes the circuit, and
+// errors without a response leave it as it is.
+func (b *circuitBreaker) record(host string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[host]
+	if !ok {
+		c = &circuit{}
+		b.circuits[host] = c
+	}
+	probe := c.probing
+	c.probing = false
+
+	var httpErr *HTTPError
+	switch {
+	case err == nil || (errors.As(err, &httpErr) && httpErr.StatusCode < 500):
+		c.failures = 0
+	case httpErr != nil:
+		c.failures++
+		if probe || c.failures == b.policy.Threshold {
+			c.openedAt = b.now()
+		}
+	}
+}
+
+// status returns the circuit breaker status of host
+func (b *circuitBreaker) status(host string) CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := CircuitStatus{Host: host, State: CircuitClosed}
+	if c, ok := b.circuits[host]; ok {
+		status.State = b.state(c)
+		status.Failures = c.failures
+		if status.State != CircuitClosed {
+			status.RetryAt = c.openedAt.Add(b.policy.Cooldown)
+		}
+	}
+	return status
+}
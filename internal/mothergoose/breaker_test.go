@@ -0,0 +1,111 @@
+package mothergoose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerStates(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker := newCircuitBreaker(CircuitBreakerPolicy{Threshold: 2, Cooldown: time.Minute})
+	breaker.now = func() time.Time { return now }
+	serverErr := &HTTPError{StatusCode: http.StatusBadGateway}
+
+	breaker.record("a", serverErr)
+	if status := breaker.status("a"); status.State != CircuitClosed || status.Failures != 1 {
+		t.Errorf("expected a closed circuit with 1 failure, got %+v", status)
+	}
+	breaker.record("a", serverErr)
+	if err := breaker.allow("a"); !errors.Is(err, ErrBackendUnavailable) {
+		t.Errorf("expected an open circuit to refuse requests, got %v", err)
+	}
+	if err := breaker.allow("b"); err != nil {
+		t.Errorf("expected other hosts to be unaffected, got %v", err)
+	}
+
+	// After the cooldown a single probe is let through
+	now = now.Add(time.Minute)
+	if status := breaker.status("a"); status.State != CircuitHalfOpen {
+		t.Errorf("expected a half-open circuit, got %+v", status)
+	}
+	if err := breaker.allow("a"); err != nil {
+		t.Fatalf("expected the probe to be allowed, got %v", err)
+	}
+	if err := breaker.allow("a"); !errors.Is(err, ErrBackendUnavailable) {
+		t.Errorf("expected a second concurrent probe to be refused, got %v", err)
+	}
+
+	// A failed probe opens the circuit for another cooldown
+	breaker.record("a", serverErr)
+	if status := breaker.status("a"); status.State != CircuitOpen || !status.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected the circuit to reopen, got %+v", status)
+	}
+
+	// A probe that gets no response leaves the circuit half-open
+	now = now.Add(time.Minute)
+	breaker.allow("a")
+	breaker.record("a", errors.New("connection refused"))
+	if err := breaker.allow("a"); err != nil {
+		t.Errorf("expected another probe to be allowed, got %v", err)
+	}
+
+	// Any response but a server error closes it
+	breaker.record("a", &HTTPError{StatusCode: http.StatusNotFound})
+	if status := breaker.status("a"); status.State != CircuitClosed || status.Failures != 0 {
+		t.Errorf("expected a closed circuit, got %+v", status)
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key",
+		WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond}),
+		WithCircuitBreaker(CircuitBreakerPolicy{Threshold: 3, Cooldown: time.Hour}))
+	_, err := client.GetEggStatus(context.Background(), "test-egg")
+	if !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("expected the circuit to open during retries, got %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests before the circuit opened, got %d", requests.Load())
+	}
+	if status := client.CircuitStatus(); status.State != CircuitOpen || status.Failures != 3 {
+		t.Errorf("unexpected circuit status %+v", status)
+	}
+
+	if _, err := client.ListEggs(context.Background()); !errors.Is(err, ErrBackendUnavailable) {
+		t.Errorf("expected other requests to fail fast, got %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected no requests while the circuit is open, got %d", requests.Load())
+	}
+}
+
+func TestClientCircuitBreakerDisabled(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key", WithMaxRetries(0), WithCircuitBreaker(CircuitBreakerPolicy{}))
+	for i := 0; i < 10; i++ {
+		if _, err := client.GetEggStatus(context.Background(), "test-egg"); errors.Is(err, ErrBackendUnavailable) {
+			t.Fatal("expected a disabled breaker to let every request through")
+		}
+	}
+	if requests.Load() != 10 {
+		t.Errorf("expected 10 requests, got %d", requests.Load())
+	}
+}
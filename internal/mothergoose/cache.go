@@ -0,0 +1,95 @@
+package mothergoose
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCachedResponses bounds the response cache; when it is full an arbitrary
+// entry is evicted
+const maxCachedResponses = 1024
+
+// WithResponseCache caches the responses of GET requests such as GetEggStatus
+// and ListEggs. Responses with an ETag are revalidated with If-None-Match, so
+// an unchanged resource costs MotherGoose a 304 without a body; a Cache-Control
+// max-age lets the client skip the request entirely until it expires. Responses
+// marked no-store are never cached.
+func WithResponseCache() ClientOption {
+	return func(c *Client) {
+		c.cache = &responseCache{entries: make(map[string]*cachedResponse)}
+	}
+}
+
+// cachedResponse is the body of a GET response with its validators
+type cachedResponse struct {
+	etag    string
+	body    []byte
+	expires time.Time // zero when the response must be revalidated before reuse
+}
+
+// fresh reports whether the response may be reused without asking MotherGoose
+func (r *cachedResponse) fresh(now time.Time) bool {
+	return now.Before(r.expires)
+}
+
+// responseCache maps request URLs to their last response; it is safe for
+// concurrent use
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+// get returns the cached response for url, or nil
+func (c *responseCache) get(url string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[url]
+}
+
+// store caches body as the response to url if header allows it, and forgets
+// any earlier response otherwise
+func (c *responseCache) store(url string, header http.Header, body []byte, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	maxAge, cacheable := cacheLifetime(header.Get("Cache-Control"))
+	etag := header.Get("ETag")
+	if !cacheable || (etag == "" && maxAge <= 0) {
+		delete(c.entries, url)
+		return
+	}
+	if _, ok := c.entries[url]; !ok && len(c.entries) >= maxCachedResponses {
+		for key := range c.entries {
+			delete(c.entries, key)
+			break
+		}
+	}
+	entry := &cachedResponse{etag: etag, body: body}
+	if maxAge > 0 {
+		entry.expires = now.Add(maxAge)
+	}
+	c.entries[url] = entry
+}
+
+// cacheLifetime reads a Cache-Control header, returning how long a response
+// stays fresh and whether it may be stored at all
+func cacheLifetime(cacheControl string) (time.Duration, bool) {
+	var maxAge time.Duration
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			return 0, false
+		case "no-cache":
+			return 0, true
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return maxAge, true
+}
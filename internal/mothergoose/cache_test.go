@@ -0,0 +1,121 @@
+package mothergoose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheETag(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		json.NewEncoder(w).Encode(EggStatus{EggName: "test-egg", ConfigHash: "abc"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key", WithResponseCache())
+	for i := 0; i < 3; i++ {
+		status, err := client.GetEggStatus(context.Background(), "test-egg")
+		if err != nil {
+			t.Fatalf("GetEggStatus failed: %v", err)
+		}
+		if status.ConfigHash != "abc" {
+			t.Errorf("request %d: expected the cached status, got %+v", i, status)
+		}
+	}
+	if requests != 3 || notModified != 2 {
+		t.Errorf("expected 3 requests with 2 revalidated, got %d requests and %d 304s", requests, notModified)
+	}
+}
+
+func TestResponseCacheMaxAge(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "private, max-age=60")
+		json.NewEncoder(w).Encode(EggStatus{EggName: "test-egg"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key", WithResponseCache())
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetEggStatus(context.Background(), "test-egg"); err != nil {
+			t.Fatalf("GetEggStatus failed: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected fresh responses to skip the request, got %d requests", requests)
+	}
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") != "" {
+			t.Error("expected no conditional request without WithResponseCache")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=60")
+		json.NewEncoder(w).Encode(EggStatus{EggName: "test-egg"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key")
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetEggStatus(context.Background(), "test-egg"); err != nil {
+			t.Fatalf("GetEggStatus failed: %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestResponseCacheStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		cacheControl string
+		etag         string
+		stored       bool
+		fresh        bool
+	}{
+		{"etag only", "", `"v1"`, true, false},
+		{"max-age", "max-age=30", "", true, true},
+		{"no-cache", "no-cache, max-age=30", `"v1"`, true, false},
+		{"no-store", "no-store", `"v1"`, false, false},
+		{"no validators", "", "", false, false},
+		{"zero max-age", "max-age=0", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &responseCache{entries: map[string]*cachedResponse{"/eggs": {etag: `"old"`}}}
+			header := http.Header{}
+			if tt.cacheControl != "" {
+				header.Set("Cache-Control", tt.cacheControl)
+			}
+			if tt.etag != "" {
+				header.Set("ETag", tt.etag)
+			}
+			cache.store("/eggs", header, []byte("{}"), now)
+			entry := cache.get("/eggs")
+			if (entry != nil) != tt.stored {
+				t.Fatalf("expected stored=%v, got entry %+v", tt.stored, entry)
+			}
+			if entry != nil && entry.fresh(now.Add(time.Second)) != tt.fresh {
+				t.Errorf("expected fresh=%v", tt.fresh)
+			}
+		})
+	}
+}
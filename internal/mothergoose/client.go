@@ -7,20 +7,35 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/polar-gosling/gosling/internal/telemetry"
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
 )
 
 // Compile-time check to ensure Client implements MotherGooseClient interface
 var _ MotherGooseClient = (*Client)(nil)
 
-// Client implements the MotherGooseClient interface for communicating with MotherGoose API
+// Client implements the MotherGooseClient interface for communicating with MotherGoose API.
+// A Client is safe for concurrent use; share one to reuse its connections.
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
-	apiKey     string
-	maxRetries int
+	baseURL     string
+	httpClient  *http.Client
+	apiKey      string
+	maxRetries  int
+	retryPolicy RetryPolicy
+	limiter     *rate.Limiter
+	cache       *responseCache
+	oidc        *OIDCConfig
+	breaker     *circuitBreaker
+	tokens      oauth2.TokenSource
+	// noBulk is set once MotherGoose turns out not to serve POST /eggs/bulk
+	noBulk atomic.Bool
 }
 
 // ClientOption is a functional option for configuring the Client
@@ -53,14 +68,20 @@ func NewClient(baseURL, apiKey string, opts ...ClientOption) *Client {
 		baseURL: baseURL,
 		apiKey:  apiKey,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newTransport(),
 		},
-		maxRetries: 3,
+		maxRetries:  3,
+		retryPolicy: DefaultRetryPolicy,
+		breaker:     newCircuitBreaker(DefaultCircuitBreakerPolicy),
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
+	if client.oidc != nil {
+		client.tokens = client.oidc.tokenSource(client.httpClient)
+	}
 
 	return client
 }
@@ -99,24 +120,29 @@ func (c *Client) GetEggStatus(ctx context.Context, eggName string) (*EggStatus,
 	return &status, nil
 }
 
-// ListEggs lists all Egg configurations
+// ListEggs lists all Egg configurations, following pagination until the last page
 func (c *Client) ListEggs(ctx context.Context) ([]*deployer.EggConfig, error) {
-	url := fmt.Sprintf("%s/eggs", c.baseURL)
-
 	var eggs []*deployer.EggConfig
-	err := c.doRequestWithRetry(ctx, "GET", url, nil, &eggs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list eggs: %w", err)
+	pager := NewEggsPager(c, ListEggsOptions{})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		eggs = append(eggs, page...)
 	}
 
 	return eggs, nil
 }
 
-// CreateOrUpdateEgg creates or updates an Egg configuration
+// CreateOrUpdateEgg creates or updates an Egg configuration. Every attempt
+// carries the same Idempotency-Key so MotherGoose can discard duplicate POSTs.
 func (c *Client) CreateOrUpdateEgg(ctx context.Context, config *deployer.EggConfig) error {
 	url := fmt.Sprintf("%s/eggs", c.baseURL)
 
-	err := c.doRequestWithRetry(ctx, "POST", url, config, nil)
+	header := http.Header{}
+	header.Set("Idempotency-Key", uuid.NewString())
+	err := c.doRequestWithHeaders(ctx, "POST", url, header, config, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create or update egg: %w", err)
 	}
@@ -124,6 +150,79 @@ func (c *Client) CreateOrUpdateEgg(ctx context.Context, config *deployer.EggConf
 	return nil
 }
 
+// DeleteEgg deletes an Egg configuration along with its deployment plans.
+// DELETE is idempotent, so the request is retried like a GET; IsNotFound
+// reports an Egg that does not exist.
+func (c *Client) DeleteEgg(ctx context.Context, eggName string) error {
+	url := fmt.Sprintf("%s/eggs/%s", c.baseURL, eggName)
+
+	err := c.doRequestWithRetry(ctx, "DELETE", url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete egg: %w", err)
+	}
+
+	return nil
+}
+
+// bulkEggsBatchSize is the most Eggs sent in one POST /eggs/bulk request
+const bulkEggsBatchSize = 100
+
+// bulkEggsRequest is the JSON body sent to POST /eggs/bulk
+type bulkEggsRequest struct {
+	Eggs []*deployer.EggConfig `json:"eggs"`
+}
+
+// CreateOrUpdateEggs creates or updates several Egg configurations in batches
+// of up to 100 per request. Against a MotherGoose without the bulk endpoint it
+// falls back to one CreateOrUpdateEgg call per Egg.
+func (c *Client) CreateOrUpdateEggs(ctx context.Context, configs []*deployer.EggConfig) error {
+	url := fmt.Sprintf("%s/eggs/bulk", c.baseURL)
+
+	for start := 0; start < len(configs); start += bulkEggsBatchSize {
+		batch := configs[start:min(start+bulkEggsBatchSize, len(configs))]
+		if !c.noBulk.Load() {
+			header := http.Header{}
+			header.Set("Idempotency-Key", uuid.NewString())
+			err := c.doRequestWithHeaders(ctx, "POST", url, header, bulkEggsRequest{Eggs: batch}, nil)
+			if err == nil {
+				continue
+			}
+			if !isNotImplemented(err) {
+				return fmt.Errorf("failed to create or update eggs: %w", err)
+			}
+			c.noBulk.Store(true)
+		}
+		for _, config := range batch {
+			if err := c.CreateOrUpdateEgg(ctx, config); err != nil {
+				return fmt.Errorf("egg %s: %w", config.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isNotImplemented reports whether err is MotherGoose answering that it does
+// not serve an endpoint
+func isNotImplemented(err error) bool {
+	httpErr, ok := err.(*HTTPError)
+	if !ok {
+		return false
+	}
+	switch httpErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// CreateDeploymentPlan submits a deployment plan for an Egg, like
+// SubmitDeploymentPlan without returning the registered plan
+func (c *Client) CreateDeploymentPlan(ctx context.Context, plan *deployer.DeploymentPlan) error {
+	_, err := c.SubmitDeploymentPlan(ctx, plan)
+	return err
+}
+
 // GetDeploymentPlan retrieves a specific deployment plan
 func (c *Client) GetDeploymentPlan(ctx context.Context, eggName, planID string) (*deployer.DeploymentPlan, error) {
 	url := fmt.Sprintf("%s/eggs/%s/plans/%s", c.baseURL, eggName, planID)
@@ -137,14 +236,29 @@ func (c *Client) GetDeploymentPlan(ctx context.Context, eggName, planID string)
 	return &plan, nil
 }
 
-// ListDeploymentPlans lists all deployment plans for an Egg
-func (c *Client) ListDeploymentPlans(ctx context.Context, eggName string) ([]*deployer.DeploymentPlan, error) {
-	url := fmt.Sprintf("%s/eggs/%s/plans", c.baseURL, eggName)
+// DeleteDeploymentPlan deletes a deployment plan of an Egg from its history.
+// The request is retried like DeleteEgg.
+func (c *Client) DeleteDeploymentPlan(ctx context.Context, eggName, planID string) error {
+	url := fmt.Sprintf("%s/eggs/%s/plans/%s", c.baseURL, eggName, planID)
 
-	var plans []*deployer.DeploymentPlan
-	err := c.doRequestWithRetry(ctx, "GET", url, nil, &plans)
+	err := c.doRequestWithRetry(ctx, "DELETE", url, nil, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list deployment plans: %w", err)
+		return fmt.Errorf("failed to delete deployment plan: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeploymentPlans lists all deployment plans for an Egg, following pagination until the last page
+func (c *Client) ListDeploymentPlans(ctx context.Context, eggName string) ([]*deployer.DeploymentPlan, error) {
+	var plans []*deployer.DeploymentPlan
+	pager := NewDeploymentPlansPager(c, eggName, ListDeploymentPlansOptions{})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, page...)
 	}
 
 	return plans, nil
@@ -176,20 +290,59 @@ func (c *Client) ReportRunnerMetrics(ctx context.Context, runnerID string, paylo
 
 // doRequestWithRetry performs an HTTP request with retry logic
 func (c *Client) doRequestWithRetry(ctx context.Context, method, url string, body interface{}, result interface{}) error {
+	return c.doRequestWithHeaders(ctx, method, url, nil, body, result)
+}
+
+// doRequestWithHeaders performs an HTTP request with extra headers, retrying
+// according to the client's retry policy
+func (c *Client) doRequestWithHeaders(ctx context.Context, method, url string, header http.Header, body interface{}, result interface{}) (err error) {
 	var lastErr error
+	var waited time.Duration
+	retries := 0
+
+	if method == http.MethodGet && c.cache != nil {
+		if cached := c.cache.get(url); cached != nil && cached.fresh(time.Now()) {
+			return decodeResult(cached.body, result)
+		}
+	}
+
+	host := urlHost(url)
+	ctx, span := telemetry.StartRequest(ctx, "mothergoose", method, urlPath(url))
+	defer func() { telemetry.EndRequest(span, retries, err) }()
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
-			// Exponential backoff: 1s, 2s, 4s, etc.
-			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+			// Exponential backoff unless the server asked for a specific delay
+			backoff := c.retryPolicy.backoff(attempt)
+			if httpErr, ok := lastErr.(*HTTPError); ok && httpErr.RetryAfter > 0 {
+				backoff = c.retryPolicy.retryAfter(httpErr.RetryAfter)
+			}
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+				return fmt.Errorf("retry in %s would pass the deadline after %d attempt(s): %w", backoff, attempt, lastErr)
+			}
+			if budget := c.retryPolicy.Budget; budget > 0 && waited+backoff > budget {
+				return fmt.Errorf("retry budget of %s exhausted after %d attempt(s): %w", budget, attempt, lastErr)
+			}
+			waited += backoff
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
 			case <-time.After(backoff):
 			}
+			retries = attempt
+			telemetry.RecordRetry(ctx, "mothergoose", method)
+		}
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return fmt.Errorf("rate limit: %w", err)
+			}
+		}
+		if err := c.breaker.allow(host); err != nil {
+			return err
 		}
 
-		err := c.doRequest(ctx, method, url, body, result)
+		err := c.doRequest(telemetry.WithAttempt(ctx, attempt), method, url, header, body, result)
+		c.breaker.record(host, err)
 		if err == nil {
 			return nil
 		}
@@ -203,6 +356,11 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, url string, bod
 			}
 		}
 
+		// Don't retry when the identity provider rejected the credentials
+		if isCredentialsRejected(err) {
+			return err
+		}
+
 		// Don't retry on context cancellation
 		if ctx.Err() != nil {
 			return ctx.Err()
@@ -213,7 +371,7 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, url string, bod
 }
 
 // doRequest performs a single HTTP request
-func (c *Client) doRequest(ctx context.Context, method, url string, body interface{}, result interface{}) error {
+func (c *Client) doRequest(ctx context.Context, method, url string, header http.Header, body interface{}, result interface{}) error {
 	var reqBody io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
@@ -228,11 +386,26 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	authorization, err := c.authorization()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	var cached *cachedResponse
+	if method == http.MethodGet && c.cache != nil {
+		if cached = c.cache.get(url); cached != nil && cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+	}
 
-	resp, err := c.httpClient.Do(req)
+	httpClient := *c.httpClient
+	httpClient.Transport = telemetry.Transport("mothergoose", httpClient.Transport)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -244,32 +417,89 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	// An unchanged resource is served from the cache
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		validators := resp.Header.Clone()
+		if validators.Get("ETag") == "" {
+			validators.Set("ETag", cached.etag)
+		}
+		c.cache.store(url, validators, cached.body, time.Now())
+		return decodeResult(cached.body, result)
+	}
+
 	// Check for non-2xx status codes
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return &HTTPError{
+		httpErr := &HTTPError{
 			StatusCode: resp.StatusCode,
 			Status:     resp.Status,
 			Body:       string(respBody),
+			API:        parseAPIError(resp.StatusCode, respBody),
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			httpErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
 		}
+		return httpErr
 	}
 
-	// Decode response if result is provided
-	if result != nil && len(respBody) > 0 {
-		if err := json.Unmarshal(respBody, result); err != nil {
+	if method == http.MethodGet && c.cache != nil {
+		c.cache.store(url, resp.Header, respBody, time.Now())
+	}
+
+	return decodeResult(respBody, result)
+}
+
+// decodeResult decodes a JSON response body into result, if result is provided
+func decodeResult(body []byte, result interface{}) error {
+	if result != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, result); err != nil {
 			return fmt.Errorf("failed to decode response: %w", err)
 		}
 	}
-
 	return nil
 }
 
+// urlPath returns the path of rawURL, for span attributes
+func urlPath(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// urlHost returns the host of rawURL, which keys the circuit breaker
+func urlHost(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// CircuitStatus returns the circuit breaker status of the MotherGoose host
+func (c *Client) CircuitStatus() CircuitStatus {
+	return c.breaker.status(urlHost(c.baseURL))
+}
+
 // HTTPError represents an HTTP error response
 type HTTPError struct {
 	StatusCode int
 	Status     string
 	Body       string
+	// RetryAfter is the delay requested by a 429 or 503 response's Retry-After header
+	RetryAfter time.Duration
+	// API is the decoded error envelope, or nil if Body is not one
+	API *APIError
 }
 
 func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d: %s - %s", e.StatusCode, e.Status, e.Body)
 }
+
+// Unwrap returns the decoded error envelope, so errors.As finds an *APIError
+func (e *HTTPError) Unwrap() error {
+	if e.API == nil {
+		return nil
+	}
+	return e.API
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -193,6 +197,130 @@ func TestCreateOrUpdateEgg(t *testing.T) {
 	}
 }
 
+func TestDeleteEgg(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/eggs/test-egg" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key", WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond}))
+	if err := client.DeleteEgg(context.Background(), "test-egg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected the DELETE to be retried once, got %d attempts", attempts)
+	}
+}
+
+func TestDeleteDeploymentPlan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Errorf("expected DELETE request, got %s", r.Method)
+		}
+		if r.URL.Path != "/eggs/test-egg/plans/plan-1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "plan not found", "code": "not_found"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	if err := client.DeleteDeploymentPlan(context.Background(), "test-egg", "plan-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := client.DeleteDeploymentPlan(context.Background(), "test-egg", "plan-2")
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestCreateDeploymentPlan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("expected POST request, got %s", r.Method)
+		}
+
+		if r.URL.Path != "/eggs/test-egg/plans" {
+			t.Errorf("expected path '/eggs/test-egg/plans', got '%s'", r.URL.Path)
+		}
+
+		if key := r.Header.Get("Idempotency-Key"); key != "plan-123" {
+			t.Errorf("expected the plan ID as Idempotency-Key, got '%s'", key)
+		}
+
+		var plan deployer.DeploymentPlan
+		if err := json.NewDecoder(r.Body).Decode(&plan); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if string(plan.Signature) != "sig" || plan.SigningKeyID != "key-1" {
+			t.Errorf("expected signature to be sent, got %q from %q", plan.Signature, plan.SigningKeyID)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	plan := &deployer.DeploymentPlan{
+		ID:           "plan-123",
+		EggName:      "test-egg",
+		Signature:    []byte("sig"),
+		SigningKeyID: "key-1",
+	}
+
+	if err := client.CreateDeploymentPlan(context.Background(), plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRunnerActions(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("expected POST request, got %s", r.Method)
+		}
+		paths = append(paths, r.URL.Path)
+		json.NewEncoder(w).Encode([]*Runner{{ID: "runner-1", State: "paused"}})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	ctx := context.Background()
+
+	runners, err := client.PauseRunners(ctx, "my-app", "runner-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runners) != 1 || runners[0].State != "paused" {
+		t.Errorf("unexpected runners: %+v", runners)
+	}
+	if _, err := client.ResumeRunners(ctx, "my-app", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.DrainRunners(ctx, "my-app", "runner-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"/eggs/my-app/runners/runner-1/pause", "/eggs/my-app/runners/resume", "/eggs/my-app/runners/runner-1/drain"}
+	for i, path := range want {
+		if i >= len(paths) || paths[i] != path {
+			t.Errorf("request %d: expected path %s, got %v", i, path, paths)
+		}
+	}
+}
+
 func TestGetDeploymentPlan(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "GET" {
@@ -393,3 +521,431 @@ func TestContextCancellation(t *testing.T) {
 		t.Errorf("expected context.DeadlineExceeded, got %v", ctx.Err())
 	}
 }
+
+func TestListEggsPagination(t *testing.T) {
+	var cursors []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eggs" {
+			t.Errorf("expected path '/eggs', got '%s'", r.URL.Path)
+		}
+		if r.URL.Query().Get("page_size") != "2" {
+			t.Errorf("expected page_size=2, got '%s'", r.URL.Query().Get("page_size"))
+		}
+
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+
+		var page EggsPage
+		switch cursor {
+		case "":
+			page = EggsPage{
+				Eggs:       []*deployer.EggConfig{{Name: "egg-1"}, {Name: "egg-2"}},
+				NextCursor: "c2",
+			}
+		case "c2":
+			page = EggsPage{
+				Eggs: []*deployer.EggConfig{{Name: "egg-3"}},
+			}
+		default:
+			t.Errorf("unexpected cursor %q", cursor)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	ctx := context.Background()
+
+	pager := NewEggsPager(client, ListEggsOptions{PageSize: 2})
+	var names []string
+	for pager.More() {
+		eggs, err := pager.NextPage(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, egg := range eggs {
+			names = append(names, egg.Name)
+		}
+	}
+
+	if len(names) != 3 || names[2] != "egg-3" {
+		t.Errorf("expected eggs [egg-1 egg-2 egg-3], got %v", names)
+	}
+	if len(cursors) != 2 || cursors[1] != "c2" {
+		t.Errorf("expected cursors ['' c2], got %v", cursors)
+	}
+	if _, err := pager.NextPage(ctx); err == nil {
+		t.Error("expected error when fetching past the last page")
+	}
+}
+
+func TestListStopsOnRepeatedCursor(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Alternates between two cursors forever
+		next := "a"
+		if r.URL.Query().Get("cursor") == "a" {
+			next = "b"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"items": [], "next_cursor": %q}`, next)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	ctx := context.Background()
+	lists := map[string]func() error{
+		"eggs":    func() error { _, err := client.ListEggs(ctx); return err },
+		"plans":   func() error { _, err := client.ListDeploymentPlans(ctx, "test-egg"); return err },
+		"jobs":    func() error { _, err := client.ListJobs(ctx); return err },
+		"runners": func() error { _, err := client.ListRunners(ctx, RunnerFilter{}); return err },
+	}
+	for name, list := range lists {
+		requests = 0
+		if err := list(); err == nil || !strings.Contains(err.Error(), `cursor "a" twice`) {
+			t.Errorf("%s: expected repeated cursor error, got %v", name, err)
+		}
+		if requests != 3 {
+			t.Errorf("%s: expected to stop after 3 pages, got %d", name, requests)
+		}
+	}
+}
+
+func TestListDeploymentPlansFollowsPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eggs/test-egg/plans" {
+			t.Errorf("expected path '/eggs/test-egg/plans', got '%s'", r.URL.Path)
+		}
+		if r.URL.Query().Get("page_size") != "100" {
+			t.Errorf("expected default page_size=100, got '%s'", r.URL.Query().Get("page_size"))
+		}
+
+		page := DeploymentPlansPage{Plans: []*deployer.DeploymentPlan{{ID: "plan-2"}}}
+		if r.URL.Query().Get("cursor") == "" {
+			page = DeploymentPlansPage{
+				Plans:      []*deployer.DeploymentPlan{{ID: "plan-1"}},
+				NextCursor: "next",
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	plans, err := client.ListDeploymentPlans(context.Background(), "test-egg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(plans) != 2 || plans[0].ID != "plan-1" || plans[1].ID != "plan-2" {
+		t.Errorf("expected plans [plan-1 plan-2], got %d plans", len(plans))
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(EggStatus{EggName: "test-egg"})
+	}))
+	defer server.Close()
+
+	// The policy alone would retry almost immediately; Retry-After must win
+	client := NewClient(server.URL, "test-api-key", WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Second}))
+	if _, err := client.GetEggStatus(context.Background(), "test-egg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(times) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(times))
+	}
+	if waited := times[1].Sub(times[0]); waited < 900*time.Millisecond {
+		t.Errorf("expected the retry to wait for Retry-After, waited %s", waited)
+	}
+}
+
+func TestRetryAfterCapped(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(EggStatus{EggName: "test-egg"})
+	}))
+	defer server.Close()
+
+	// An hour-long Retry-After is capped at MaxDelay
+	client := NewClient(server.URL, "test-api-key", WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 20 * time.Millisecond}))
+	start := time.Now()
+	if _, err := client.GetEggStatus(context.Background(), "test-egg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected Retry-After to be capped, took %s", elapsed)
+	}
+
+	// A wait that cannot finish before the deadline fails without sleeping
+	attempts = 0
+	client = NewClient(server.URL, "test-api-key", WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: time.Minute}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start = time.Now()
+	_, err := client.GetEggStatus(ctx, "test-egg")
+	if err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected to fail before the deadline, took %s", elapsed)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{BaseDelay: 20 * time.Millisecond, MaxDelay: time.Second, Budget: 50 * time.Millisecond}
+	client := NewClient(server.URL, "test-api-key", WithMaxRetries(10), WithRetryPolicy(policy))
+	_, err := client.GetEggStatus(context.Background(), "test-egg")
+	if err == nil || !strings.Contains(err.Error(), "retry budget") {
+		t.Fatalf("expected retry budget error, got %v", err)
+	}
+	// Waits of 20ms and 40ms exceed the 50ms budget, so only one retry happens
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := policy.backoff(attempt); got != want {
+			t.Errorf("attempt %d: expected %s, got %s", attempt, want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(2); got < time.Second || got > 2*time.Second {
+			t.Fatalf("jittered backoff %s outside [1s, 2s]", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Mon, 01 Jan 2024 12:00:30 GMT": 30 * time.Second,
+		"Mon, 01 Jan 2024 11:00:00 GMT": 0,
+	}
+	for header, want := range tests {
+		if got := parseRetryAfter(header, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestCreateOrUpdateEggIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key", WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond}))
+	if err := client.CreateOrUpdateEgg(context.Background(), &deployer.EggConfig{Name: "test-egg"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("expected the same non-empty key on both attempts, got %q", keys)
+	}
+
+	if err := client.CreateOrUpdateEgg(context.Background(), &deployer.EggConfig{Name: "test-egg"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys[2] == keys[0] {
+		t.Error("expected a new key for a separate call")
+	}
+}
+
+func TestGetServerVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(ServerVersion{Version: "2.3.0", APIVersion: "1.4"})
+	}))
+	defer server.Close()
+
+	version, err := NewClient(server.URL, "key").GetServerVersion(context.Background())
+	if err != nil {
+		t.Fatalf("GetServerVersion failed: %v", err)
+	}
+	if version.Version != "2.3.0" || !version.IsCompatible() {
+		t.Errorf("unexpected version %+v", version)
+	}
+	for _, apiVersion := range []string{"2.0", "", "beta"} {
+		if (&ServerVersion{APIVersion: apiVersion}).IsCompatible() {
+			t.Errorf("API version %q should not be compatible", apiVersion)
+		}
+	}
+}
+
+func TestNewClientTransport(t *testing.T) {
+	client := NewClient("https://api.example.com", "key", WithMaxIdleConnsPerHost(8))
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", client.httpClient.Transport)
+	}
+	if !transport.ForceAttemptHTTP2 {
+		t.Error("expected HTTP/2 to be attempted")
+	}
+	if transport.MaxIdleConnsPerHost != 8 {
+		t.Errorf("expected 8 idle connections per host, got %d", transport.MaxIdleConnsPerHost)
+	}
+	if NewClient("https://api.example.com", "key").httpClient.Transport == transport {
+		t.Error("expected each client to have its own transport")
+	}
+}
+
+func TestCreateOrUpdateEggs(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/eggs/bulk" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Idempotency-Key") == "" {
+			t.Error("expected an Idempotency-Key header")
+		}
+		var req bulkEggsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		mu.Lock()
+		batches = append(batches, len(req.Eggs))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	configs := make([]*deployer.EggConfig, 250)
+	for i := range configs {
+		configs[i] = &deployer.EggConfig{Name: fmt.Sprintf("egg-%d", i)}
+	}
+	if err := NewClient(server.URL, "key").CreateOrUpdateEggs(context.Background(), configs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batches) != 3 || batches[0] != 100 || batches[1] != 100 || batches[2] != 50 {
+		t.Errorf("expected batches of 100, 100 and 50 eggs, got %v", batches)
+	}
+}
+
+func TestCreateOrUpdateEggsFallback(t *testing.T) {
+	var bulkCalls, eggCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eggs/bulk":
+			bulkCalls++
+			w.WriteHeader(http.StatusNotFound)
+		case "/eggs":
+			eggCalls++
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key")
+	configs := []*deployer.EggConfig{{Name: "egg-a"}, {Name: "egg-b"}}
+	for i := 0; i < 2; i++ {
+		if err := client.CreateOrUpdateEggs(context.Background(), configs); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if bulkCalls != 1 {
+		t.Errorf("expected the bulk endpoint to be tried once, got %d calls", bulkCalls)
+	}
+	if eggCalls != 4 {
+		t.Errorf("expected 4 single-egg calls, got %d", eggCalls)
+	}
+}
+
+func TestCreateOrUpdateEggsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL, "key").CreateOrUpdateEggs(context.Background(), []*deployer.EggConfig{{Name: "egg-a"}})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected the bulk request's HTTP 400, got %v", err)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		json.NewEncoder(w).Encode(EggStatus{EggName: "test-egg"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key", WithRateLimit(20, 1))
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetEggStatus(context.Background(), "test-egg"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected 5 requests at 20/s to take at least 200ms, took %s", elapsed)
+	}
+	if calls.Load() != 5 {
+		t.Errorf("expected 5 requests, got %d", calls.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GetEggStatus(ctx, "test-egg"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a canceled wait to fail with context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package mothergoose
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error codes of the MotherGoose error envelope
+const (
+	CodeNotFound   = "not_found"
+	CodeConflict   = "conflict"
+	CodeValidation = "validation_error"
+)
+
+// APIError is the JSON error envelope of a MotherGoose error response:
+//
+//	{"error": "egg \"api\" not found", "code": "not_found", "details": ...}
+//
+// It is reached from an *HTTPError with errors.As.
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int `json:"-"`
+	// Message is the human-readable description of the error
+	Message string `json:"error"`
+	// Code is the machine-readable error code, such as CodeNotFound
+	Code string `json:"code,omitempty"`
+	// Details is any further information, such as the invalid fields of a
+	// validation error; its shape depends on the code
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+}
+
+// FieldErrors returns the details of a validation error that maps field names
+// to messages, or nil if the details have another shape
+func (e *APIError) FieldErrors() map[string]string {
+	var fields map[string]string
+	if len(e.Details) == 0 || json.Unmarshal(e.Details, &fields) != nil {
+		return nil
+	}
+	return fields
+}
+
+// parseAPIError decodes the error envelope of a response body, returning nil
+// if the body is not one
+func parseAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Message == "" {
+		return nil
+	}
+	return apiErr
+}
+
+// statusCode returns the HTTP status of the MotherGoose error response in
+// err's chain, and the envelope's code if it had one
+func statusCode(err error) (int, string) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode, apiErr.Code
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode, ""
+	}
+	return 0, ""
+}
+
+// IsNotFound reports whether err is MotherGoose answering that the requested
+// Egg, plan or runner does not exist
+func IsNotFound(err error) bool {
+	status, code := statusCode(err)
+	return code == CodeNotFound || status == http.StatusNotFound
+}
+
+// IsConflict reports whether err is MotherGoose refusing a request that
+// conflicts with the current state, such as a plan already being applied
+func IsConflict(err error) bool {
+	status, code := statusCode(err)
+	return code == CodeConflict || status == http.StatusConflict
+}
+
+// IsValidation reports whether err is MotherGoose rejecting an invalid request
+func IsValidation(err error) bool {
+	status, code := statusCode(err)
+	return code == CodeValidation || status == http.StatusUnprocessableEntity
+}
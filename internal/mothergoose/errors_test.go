@@ -0,0 +1,71 @@
+package mothergoose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error": "invalid egg configuration", "code": "validation_error", "details": {"resources.cpu": "must be at least 1"}}`))
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL, "key").CreateOrUpdateEgg(context.Background(), nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError in %v", err)
+	}
+	if apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Message != "invalid egg configuration" || apiErr.Code != CodeValidation {
+		t.Errorf("unexpected API error %+v", apiErr)
+	}
+	if fields := apiErr.FieldErrors(); fields["resources.cpu"] != "must be at least 1" {
+		t.Errorf("unexpected field errors %v", fields)
+	}
+	if !IsValidation(err) || IsNotFound(err) || IsConflict(err) {
+		t.Errorf("expected only IsValidation to match %v", err)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected the HTTPError to stay in the chain, got %v", err)
+	}
+}
+
+func TestAPIErrorPredicates(t *testing.T) {
+	tests := []struct {
+		name                           string
+		err                            error
+		notFound, conflict, validation bool
+	}{
+		{"404 without envelope", &HTTPError{StatusCode: 404, Body: "page not found"}, true, false, false},
+		{"409", &HTTPError{StatusCode: 409}, false, true, false},
+		{"code on a 400", &HTTPError{StatusCode: 400, API: &APIError{StatusCode: 400, Message: "bad", Code: CodeValidation}}, false, false, true},
+		{"code on a 400 conflict", &HTTPError{StatusCode: 400, API: &APIError{StatusCode: 400, Message: "busy", Code: CodeConflict}}, false, true, false},
+		{"server error", &HTTPError{StatusCode: 500}, false, false, false},
+		{"other error", errors.New("connection refused"), false, false, false},
+		{"nil", nil, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if IsNotFound(tt.err) != tt.notFound || IsConflict(tt.err) != tt.conflict || IsValidation(tt.err) != tt.validation {
+				t.Errorf("got IsNotFound=%v IsConflict=%v IsValidation=%v", IsNotFound(tt.err), IsConflict(tt.err), IsValidation(tt.err))
+			}
+		})
+	}
+}
+
+func TestParseAPIError(t *testing.T) {
+	for _, body := range []string{"", "Bad Gateway", `{"message": "not our envelope"}`, `["error"]`} {
+		if apiErr := parseAPIError(502, []byte(body)); apiErr != nil {
+			t.Errorf("parseAPIError(%q) = %+v, want nil", body, apiErr)
+		}
+	}
+	if (&HTTPError{StatusCode: 502}).Unwrap() != nil {
+		t.Error("expected no wrapped error without an envelope")
+	}
+}
@@ -39,15 +39,69 @@ type MotherGooseClient interface {
 	// CreateOrUpdateEgg creates or updates an Egg configuration
 	CreateOrUpdateEgg(ctx context.Context, config *deployer.EggConfig) error
 
+	// CreateOrUpdateEggs creates or updates several Egg configurations at once
+	CreateOrUpdateEggs(ctx context.Context, configs []*deployer.EggConfig) error
+
+	// DeleteEgg deletes an Egg configuration along with its deployment plans
+	DeleteEgg(ctx context.Context, eggName string) error
+
+	// CreateDeploymentPlan submits a deployment plan for an Egg
+	CreateDeploymentPlan(ctx context.Context, plan *deployer.DeploymentPlan) error
+
+	// SubmitDeploymentPlan submits a deployment plan for an Egg and returns it as registered
+	SubmitDeploymentPlan(ctx context.Context, plan *deployer.DeploymentPlan) (*deployer.DeploymentPlan, error)
+
+	// ApplyPlan asks MotherGoose to apply a registered deployment plan
+	ApplyPlan(ctx context.Context, eggName, planID string) (*deployer.DeploymentPlan, error)
+
+	// RollbackEgg rolls an Egg back to a previous plan and returns the rollback plan
+	RollbackEgg(ctx context.Context, eggName, targetPlanID string) (*deployer.DeploymentPlan, error)
+
 	// GetDeploymentPlan retrieves a specific deployment plan
 	GetDeploymentPlan(ctx context.Context, eggName, planID string) (*deployer.DeploymentPlan, error)
 
 	// ListDeploymentPlans lists all deployment plans for an Egg
 	ListDeploymentPlans(ctx context.Context, eggName string) ([]*deployer.DeploymentPlan, error)
 
+	// DeleteDeploymentPlan deletes a deployment plan of an Egg from its history
+	DeleteDeploymentPlan(ctx context.Context, eggName, planID string) error
+
+	// CreateOrUpdateJob creates or updates a Job (self-management task)
+	CreateOrUpdateJob(ctx context.Context, job *deployer.JobConfig) error
+
+	// ListJobs lists all Jobs
+	ListJobs(ctx context.Context) ([]*deployer.JobConfig, error)
+
+	// GetUglyFoxConfig retrieves the runner lifecycle configuration UglyFox applies
+	GetUglyFoxConfig(ctx context.Context) (*deployer.UglyFoxConfig, error)
+
+	// UpdateUglyFoxConfig replaces the runner lifecycle configuration UglyFox applies
+	UpdateUglyFoxConfig(ctx context.Context, config *deployer.UglyFoxConfig) error
+
+	// RotateEggToken reports that the runner token of an Egg was replaced
+	RotateEggToken(ctx context.Context, rotation *TokenRotation) error
+
 	// SendHeartbeat sends a liveness ping for the given runner ID.
 	SendHeartbeat(ctx context.Context, runnerID string, payload HeartbeatPayload) error
 
 	// ReportRunnerMetrics posts a full metrics snapshot for the given runner ID.
 	ReportRunnerMetrics(ctx context.Context, runnerID string, payload RunnerMetricsPayload) error
+
+	// ListRunners lists the runners selected by filter
+	ListRunners(ctx context.Context, filter RunnerFilter) ([]*Runner, error)
+
+	// GetRunner retrieves a single runner by ID
+	GetRunner(ctx context.Context, runnerID string) (*Runner, error)
+
+	// PauseRunners stops a runner (or every runner if runnerID is empty) of an Egg from accepting jobs.
+	PauseRunners(ctx context.Context, eggName, runnerID string) ([]*Runner, error)
+
+	// ResumeRunners lets paused or draining runners of an Egg accept jobs again.
+	ResumeRunners(ctx context.Context, eggName, runnerID string) ([]*Runner, error)
+
+	// DrainRunners pauses runners of an Egg and retires them once their running jobs finish.
+	DrainRunners(ctx context.Context, eggName, runnerID string) ([]*Runner, error)
+
+	// StreamLogs streams runner and job logs for an Egg, calling handle for each entry.
+	StreamLogs(ctx context.Context, eggName string, opts LogStreamOptions, handle func(*LogEntry) error) error
 }
@@ -0,0 +1,48 @@
+package mothergoose
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+// CreateOrUpdateJob creates or updates a Job (self-management task). Every
+// attempt carries the same Idempotency-Key so MotherGoose can discard
+// duplicate POSTs.
+func (c *Client) CreateOrUpdateJob(ctx context.Context, job *deployer.JobConfig) error {
+	url := fmt.Sprintf("%s/jobs", c.baseURL)
+
+	header := http.Header{}
+	header.Set("Idempotency-Key", uuid.NewString())
+	err := c.doRequestWithHeaders(ctx, "POST", url, header, job, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create or update job: %w", err)
+	}
+
+	return nil
+}
+
+// ListJobs lists all Jobs, following pagination until the last page
+func (c *Client) ListJobs(ctx context.Context) ([]*deployer.JobConfig, error) {
+	var jobs []*deployer.JobConfig
+	cursor := ""
+	seen := seenCursors{}
+	for {
+		var page []*deployer.JobConfig
+		next, err := c.getPage(ctx, pageURL(fmt.Sprintf("%s/jobs", c.baseURL), DefaultPageSize, cursor), &page)
+		if err == nil {
+			err = seen.add(next)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		jobs = append(jobs, page...)
+		if next == "" {
+			return jobs, nil
+		}
+		cursor = next
+	}
+}
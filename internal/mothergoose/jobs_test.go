@@ -0,0 +1,64 @@
+package mothergoose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func TestCreateOrUpdateJob(t *testing.T) {
+	var received deployer.JobConfig
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/jobs" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Idempotency-Key") == "" {
+			t.Error("expected an Idempotency-Key header")
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	job := &deployer.JobConfig{
+		Name:     "rotate-secrets",
+		Schedule: "0 2 * * *",
+		Script:   "gosling rotate-tokens --all",
+		Runner:   deployer.JobRunnerConfig{Type: deployer.RunnerTypeVM, Tags: []string{"privileged"}},
+	}
+	if err := NewClient(server.URL, "key").CreateOrUpdateJob(context.Background(), job); err != nil {
+		t.Fatalf("CreateOrUpdateJob failed: %v", err)
+	}
+	if received.Name != job.Name || received.Schedule != job.Schedule || received.Runner.Tags[0] != "privileged" {
+		t.Errorf("unexpected job received: %+v", received)
+	}
+}
+
+func TestListJobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"items":       []*deployer.JobConfig{{Name: "cleanup"}},
+				"next_cursor": "page-2",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode([]*deployer.JobConfig{{Name: "rotate-secrets"}})
+	}))
+	defer server.Close()
+
+	jobs, err := NewClient(server.URL, "key").ListJobs(context.Background())
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Name != "cleanup" || jobs[1].Name != "rotate-secrets" {
+		t.Errorf("expected both pages of jobs, got %+v", jobs)
+	}
+}
@@ -0,0 +1,54 @@
+package mothergoose
+
+import (
+	"context"
+	"fmt"
+)
+
+// RunnerAction is an operator action that changes whether runners accept jobs
+type RunnerAction string
+
+const (
+	// RunnerActionPause stops runners from picking up new jobs; running jobs continue
+	RunnerActionPause RunnerAction = "pause"
+	// RunnerActionResume lets paused or draining runners pick up jobs again
+	RunnerActionResume RunnerAction = "resume"
+	// RunnerActionDrain pauses runners and retires them once their running jobs finish
+	RunnerActionDrain RunnerAction = "drain"
+)
+
+// PauseRunners pauses a runner of an Egg, or every runner if runnerID is empty,
+// and returns the affected runners
+func (c *Client) PauseRunners(ctx context.Context, eggName, runnerID string) ([]*Runner, error) {
+	return c.runnerAction(ctx, eggName, runnerID, RunnerActionPause)
+}
+
+// ResumeRunners resumes a runner of an Egg, or every runner if runnerID is
+// empty, and returns the affected runners
+func (c *Client) ResumeRunners(ctx context.Context, eggName, runnerID string) ([]*Runner, error) {
+	return c.runnerAction(ctx, eggName, runnerID, RunnerActionResume)
+}
+
+// DrainRunners drains a runner of an Egg, or every runner if runnerID is
+// empty, and returns the affected runners
+func (c *Client) DrainRunners(ctx context.Context, eggName, runnerID string) ([]*Runner, error) {
+	return c.runnerAction(ctx, eggName, runnerID, RunnerActionDrain)
+}
+
+// runnerAction posts to /eggs/{name}/runners/{action} or, for a single
+// runner, /eggs/{name}/runners/{id}/{action}. Every action is idempotent, so
+// the request is retried like a GET.
+func (c *Client) runnerAction(ctx context.Context, eggName, runnerID string, action RunnerAction) ([]*Runner, error) {
+	url := fmt.Sprintf("%s/eggs/%s/runners/%s", c.baseURL, eggName, action)
+	if runnerID != "" {
+		url = fmt.Sprintf("%s/eggs/%s/runners/%s/%s", c.baseURL, eggName, runnerID, action)
+	}
+
+	var runners []*Runner
+	err := c.doRequestWithRetry(ctx, "POST", url, nil, &runners)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s runners: %w", action, err)
+	}
+
+	return runners, nil
+}
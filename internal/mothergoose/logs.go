@@ -0,0 +1,258 @@
+package mothergoose
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/telemetry"
+)
+
+// defaultLogRetryDelay is the reconnect delay used until the server sends a retry: field
+const defaultLogRetryDelay = time.Second
+
+// maxLogRetryDelay caps the exponential reconnect backoff
+const maxLogRetryDelay = 30 * time.Second
+
+// logEndEvent is the SSE event type the server sends when a non-following stream is complete
+const logEndEvent = "end"
+
+// errStopLogStream signals that a stream ended and should not be resumed
+var errStopLogStream = errors.New("log stream ended")
+
+// LogEntry is a single line of runner or job output
+type LogEntry struct {
+	ID        string    `json:"id,omitempty"`
+	RunnerID  string    `json:"runner_id"`
+	JobID     string    `json:"job_id,omitempty"`
+	Stream    string    `json:"stream,omitempty"` // "stdout" or "stderr"
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+}
+
+// LogStreamOptions controls which logs are streamed
+type LogStreamOptions struct {
+	// RunnerID limits the stream to a single runner; empty streams all runners of the Egg
+	RunnerID string
+	// Follow keeps the stream open and reconnects until the context is cancelled
+	Follow bool
+}
+
+// StreamLogs streams logs for an Egg from GET /eggs/{name}/logs and calls
+// handle for every entry. The server may answer with Server-Sent Events
+// (text/event-stream) or newline-delimited JSON (application/x-ndjson).
+//
+// When following, dropped connections are resumed with the Last-Event-ID of the
+// last received entry, backing off exponentially between consecutive failures.
+// StreamLogs returns ctx.Err() when the context is cancelled, or the first
+// error returned by handle.
+func (c *Client) StreamLogs(ctx context.Context, eggName string, opts LogStreamOptions, handle func(*LogEntry) error) error {
+	query := url.Values{}
+	if opts.RunnerID != "" {
+		query.Set("runner_id", opts.RunnerID)
+	}
+	if opts.Follow {
+		query.Set("follow", "true")
+	}
+	endpoint := fmt.Sprintf("%s/eggs/%s/logs", c.baseURL, eggName)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	// Streams stay open indefinitely, so the client-wide timeout must not apply
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	streamClient.Transport = telemetry.Transport("mothergoose", streamClient.Transport)
+
+	state := &logStreamState{retryDelay: defaultLogRetryDelay}
+	failures := 0
+
+	for {
+		received := state.received
+		err := c.streamLogsOnce(ctx, &streamClient, endpoint, state, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, errStopLogStream) || (err == nil && !opts.Follow) {
+			return nil
+		}
+		var handlerErr *logHandlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		if httpErr, ok := err.(*HTTPError); ok {
+			if httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 && httpErr.StatusCode != 429 {
+				return fmt.Errorf("failed to stream logs: %w", err)
+			}
+		}
+
+		// Reset the backoff once a connection delivered entries
+		if state.received > received {
+			failures = 0
+		}
+		if err != nil {
+			failures++
+			if failures > c.maxRetries {
+				return fmt.Errorf("failed to stream logs after %d retries: %w", c.maxRetries, err)
+			}
+		}
+
+		backoff := state.retryDelay
+		for i := 1; i < failures && backoff < maxLogRetryDelay; i++ {
+			backoff *= 2
+		}
+		if backoff > maxLogRetryDelay {
+			backoff = maxLogRetryDelay
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// logStreamState carries resume information across reconnects
+type logStreamState struct {
+	lastEventID string
+	retryDelay  time.Duration
+	received    int
+}
+
+// logHandlerError wraps an error returned by the caller's handler so it is
+// not mistaken for a connection failure
+type logHandlerError struct {
+	err error
+}
+
+func (e *logHandlerError) Error() string {
+	return e.err.Error()
+}
+
+// streamLogsOnce opens a single log connection and reads it until it closes
+func (c *Client) streamLogsOnce(ctx context.Context, httpClient *http.Client, endpoint string, state *logStreamState, handle func(*LogEntry) error) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	authorization, err := c.authorization()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "text/event-stream, application/x-ndjson")
+	req.Header.Set("Cache-Control", "no-cache")
+	if state.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", state.lastEventID)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return &HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       string(body),
+			API:        parseAPIError(resp.StatusCode, body),
+		}
+	}
+
+	deliver := func(data, id string) error {
+		var entry LogEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return fmt.Errorf("failed to decode log entry: %w", err)
+		}
+		if entry.ID == "" {
+			entry.ID = id
+		}
+		if entry.ID != "" {
+			state.lastEventID = entry.ID
+		}
+		state.received++
+		if err := handle(&entry); err != nil {
+			return &logHandlerError{err: err}
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/x-ndjson") {
+		return readNDJSON(resp.Body, deliver)
+	}
+	return readSSE(resp.Body, state, deliver)
+}
+
+// readNDJSON reads newline-delimited JSON log entries
+func readNDJSON(body io.Reader, deliver func(data, id string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := deliver(line, ""); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readSSE reads a Server-Sent Events stream of log entries
+func readSSE(body io.Reader, state *logStreamState, deliver func(data, id string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var eventType, eventID string
+	var data []string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			// Blank line dispatches the event
+			if eventType == logEndEvent {
+				return errStopLogStream
+			}
+			if len(data) > 0 && (eventType == "" || eventType == "log") {
+				if err := deliver(strings.Join(data, "\n"), eventID); err != nil {
+					return err
+				}
+			}
+			eventType, eventID, data = "", "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// Comment, used by servers as keep-alive
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			eventID = value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				state.retryDelay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return scanner.Err()
+}
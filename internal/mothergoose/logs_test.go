@@ -0,0 +1,149 @@
+package mothergoose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamLogsSSE(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eggs/test-egg/logs" {
+			t.Errorf("expected path '/eggs/test-egg/logs', got '%s'", r.URL.Path)
+		}
+		if r.URL.Query().Get("runner_id") != "runner-1" {
+			t.Errorf("expected runner_id=runner-1, got '%s'", r.URL.Query().Get("runner_id"))
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "id: 1\ndata: {\"runner_id\":\"runner-1\",\"message\":\"first\"}\n\n")
+		fmt.Fprint(w, "event: log\nid: 2\ndata: {\"runner_id\":\"runner-1\",\n")
+		fmt.Fprint(w, "data: \"message\":\"second\"}\n\n")
+		fmt.Fprint(w, "event: end\ndata: {}\n\n")
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+
+	var messages []string
+	var ids []string
+	err := client.StreamLogs(context.Background(), "test-egg", LogStreamOptions{RunnerID: "runner-1"}, func(entry *LogEntry) error {
+		messages = append(messages, entry.Message)
+		ids = append(ids, entry.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(messages) != 2 || messages[0] != "first" || messages[1] != "second" {
+		t.Errorf("expected messages [first second], got %v", messages)
+	}
+	if len(ids) != 2 || ids[1] != "2" {
+		t.Errorf("expected event IDs to be copied into entries, got %v", ids)
+	}
+}
+
+func TestStreamLogsNDJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprint(w, "{\"runner_id\":\"r1\",\"message\":\"a\"}\n\n{\"runner_id\":\"r2\",\"message\":\"b\"}\n")
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+
+	count := 0
+	err := client.StreamLogs(context.Background(), "test-egg", LogStreamOptions{}, func(entry *LogEntry) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 entries, got %d", count)
+	}
+}
+
+func TestStreamLogsFollowReconnects(t *testing.T) {
+	var connections atomic.Int32
+	lastEventIDs := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("follow") != "true" {
+			t.Errorf("expected follow=true, got '%s'", r.URL.Query().Get("follow"))
+		}
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		n := connections.Add(1)
+		fmt.Fprint(w, "retry: 10\n\n")
+		fmt.Fprintf(w, "id: %d\ndata: {\"message\":\"line %d\"}\n\n", n, n)
+		// Closing the response simulates a dropped connection
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := 0
+	err := client.StreamLogs(ctx, "test-egg", LogStreamOptions{Follow: true}, func(entry *LogEntry) error {
+		received++
+		if received == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if first := <-lastEventIDs; first != "" {
+		t.Errorf("expected no Last-Event-ID on first connection, got %q", first)
+	}
+	if second := <-lastEventIDs; second != "1" {
+		t.Errorf("expected Last-Event-ID 1 on reconnect, got %q", second)
+	}
+}
+
+func TestStreamLogsHandlerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"message\":\"x\"}\n\n")
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	stopErr := errors.New("stop")
+	err := client.StreamLogs(context.Background(), "test-egg", LogStreamOptions{Follow: true}, func(entry *LogEntry) error {
+		return stopErr
+	})
+	if !errors.Is(err, stopErr) {
+		t.Errorf("expected handler error to be returned, got %v", err)
+	}
+}
+
+func TestStreamLogsClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	err := client.StreamLogs(context.Background(), "missing", LogStreamOptions{Follow: true}, func(entry *LogEntry) error {
+		return nil
+	})
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 HTTPError without retries, got %v", err)
+	}
+}
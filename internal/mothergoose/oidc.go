@@ -0,0 +1,79 @@
+package mothergoose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// jwtBearerAssertion is the client_assertion_type of a JWT client assertion (RFC 7523)
+const jwtBearerAssertion = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// OIDCConfig configures the OAuth 2.0 client credentials flow used to
+// authenticate to MotherGoose with short-lived access tokens instead of an API key
+type OIDCConfig struct {
+	// TokenURL is the identity provider's token endpoint
+	TokenURL string
+	ClientID string
+	// ClientSecret authenticates the client; leave it empty when using ClientAssertion
+	ClientSecret string
+	// ClientAssertion is a signed JWT, such as a GitLab CI ID token, sent as
+	// the client's credentials instead of ClientSecret
+	ClientAssertion string
+	Scopes          []string
+}
+
+// WithOIDC authenticates with access tokens from the OIDC client credentials
+// flow instead of the API key. Tokens are requested on first use and again
+// shortly before they expire.
+func WithOIDC(config OIDCConfig) ClientOption {
+	return func(c *Client) {
+		c.oidc = &config
+	}
+}
+
+// tokenSource returns a concurrency-safe source of access tokens that
+// requests them through httpClient and reuses each until it expires
+func (o *OIDCConfig) tokenSource(httpClient *http.Client) oauth2.TokenSource {
+	config := &clientcredentials.Config{
+		ClientID:     o.ClientID,
+		ClientSecret: o.ClientSecret,
+		TokenURL:     o.TokenURL,
+		Scopes:       o.Scopes,
+	}
+	if o.ClientAssertion != "" {
+		config.AuthStyle = oauth2.AuthStyleInParams
+		config.EndpointParams = url.Values{
+			"client_assertion_type": {jwtBearerAssertion},
+			"client_assertion":      {o.ClientAssertion},
+		}
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
+	return config.TokenSource(ctx)
+}
+
+// authorization returns the Authorization header of a request: a bearer
+// access token with WithOIDC, the API key otherwise
+func (c *Client) authorization() (string, error) {
+	if c.tokens == nil {
+		return fmt.Sprintf("Bearer %s", c.apiKey), nil
+	}
+	token, err := c.tokens.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+	return fmt.Sprintf("%s %s", token.Type(), token.AccessToken), nil
+}
+
+// isCredentialsRejected reports whether err is the token endpoint refusing
+// the client's credentials, which retrying cannot fix
+func isCredentialsRejected(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	return errors.As(err, &retrieveErr) && retrieveErr.Response != nil &&
+		retrieveErr.Response.StatusCode >= 400 && retrieveErr.Response.StatusCode < 500
+}
@@ -0,0 +1,143 @@
+package mothergoose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTokenServer serves access tokens numbered by request, checking each
+// token request with check
+func newTokenServer(t *testing.T, expiresIn int, check func(r *http.Request)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var issued atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse token request: %v", err)
+		}
+		if r.PostForm.Get("grant_type") != "client_credentials" {
+			t.Errorf("unexpected grant_type %q", r.PostForm.Get("grant_type"))
+		}
+		check(r)
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token-" + string(rune('0'+n)),
+			"token_type":   "bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func TestOIDCClientSecret(t *testing.T) {
+	tokens, issued := newTokenServer(t, 3600, func(r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "gosling" || secret != "s3cr3t" {
+			t.Errorf("expected client credentials in basic auth, got %q %q", id, secret)
+		}
+		if r.PostForm.Get("scope") != "deploy status" {
+			t.Errorf("unexpected scope %q", r.PostForm.Get("scope"))
+		}
+	})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token-1" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+		json.NewEncoder(w).Encode(EggStatus{EggName: "test-egg"})
+	}))
+	defer api.Close()
+
+	client := NewClient(api.URL, "", WithOIDC(OIDCConfig{
+		TokenURL:     tokens.URL,
+		ClientID:     "gosling",
+		ClientSecret: "s3cr3t",
+		Scopes:       []string{"deploy", "status"},
+	}))
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetEggStatus(context.Background(), "test-egg"); err != nil {
+			t.Fatalf("GetEggStatus failed: %v", err)
+		}
+	}
+	if issued.Load() != 1 {
+		t.Errorf("expected the token to be reused, got %d token requests", issued.Load())
+	}
+}
+
+func TestOIDCRefresh(t *testing.T) {
+	// Tokens expiring within the refresh margin are requested anew each time
+	tokens, issued := newTokenServer(t, 1, func(*http.Request) {})
+	var seen []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(EggStatus{EggName: "test-egg"})
+	}))
+	defer api.Close()
+
+	client := NewClient(api.URL, "", WithOIDC(OIDCConfig{TokenURL: tokens.URL, ClientID: "gosling", ClientSecret: "s3cr3t"}))
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetEggStatus(context.Background(), "test-egg"); err != nil {
+			t.Fatalf("GetEggStatus failed: %v", err)
+		}
+	}
+	if issued.Load() != 2 || len(seen) != 2 || seen[0] == seen[1] {
+		t.Errorf("expected a new token per request, got %d token requests and headers %q", issued.Load(), seen)
+	}
+}
+
+func TestOIDCClientAssertion(t *testing.T) {
+	tokens, _ := newTokenServer(t, 3600, func(r *http.Request) {
+		if _, _, ok := r.BasicAuth(); ok {
+			t.Error("expected no basic auth with a client assertion")
+		}
+		if r.PostForm.Get("client_id") != "gosling" {
+			t.Errorf("unexpected client_id %q", r.PostForm.Get("client_id"))
+		}
+		if r.PostForm.Get("client_assertion_type") != jwtBearerAssertion || r.PostForm.Get("client_assertion") != "id.token.jwt" {
+			t.Errorf("unexpected client assertion %q of type %q", r.PostForm.Get("client_assertion"), r.PostForm.Get("client_assertion_type"))
+		}
+		if r.PostForm.Has("client_secret") {
+			t.Error("expected no client_secret with a client assertion")
+		}
+	})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(EggStatus{EggName: "test-egg"})
+	}))
+	defer api.Close()
+
+	client := NewClient(api.URL, "", WithOIDC(OIDCConfig{TokenURL: tokens.URL, ClientID: "gosling", ClientAssertion: "id.token.jwt"}))
+	if _, err := client.GetEggStatus(context.Background(), "test-egg"); err != nil {
+		t.Fatalf("GetEggStatus failed: %v", err)
+	}
+}
+
+func TestOIDCRejectedCredentials(t *testing.T) {
+	var attempts atomic.Int32
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client"}`))
+	}))
+	defer tokens.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected no API request without a token")
+	}))
+	defer api.Close()
+
+	client := NewClient(api.URL, "", WithOIDC(OIDCConfig{TokenURL: tokens.URL, ClientID: "gosling", ClientSecret: "wrong"}),
+		WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond}))
+	_, err := client.GetEggStatus(context.Background(), "test-egg")
+	if !isCredentialsRejected(err) {
+		t.Fatalf("expected rejected credentials, got %v", err)
+	}
+	// oauth2 tries the credentials in the Authorization header, then in the form
+	if attempts.Load() != 2 {
+		t.Errorf("expected no retries, got %d token requests", attempts.Load())
+	}
+}
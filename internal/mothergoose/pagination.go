@@ -0,0 +1,215 @@
+package mothergoose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+// DefaultPageSize is the page size used when a list option leaves PageSize unset
+const DefaultPageSize = 100
+
+// ListEggsOptions controls pagination of GET /eggs
+type ListEggsOptions struct {
+	// PageSize is the maximum number of eggs per page (default DefaultPageSize)
+	PageSize int
+	// Cursor is the opaque cursor returned by the previous page; empty for the first page
+	Cursor string
+}
+
+// ListDeploymentPlansOptions controls pagination of GET /eggs/{name}/plans
+type ListDeploymentPlansOptions struct {
+	// PageSize is the maximum number of plans per page (default DefaultPageSize)
+	PageSize int
+	// Cursor is the opaque cursor returned by the previous page; empty for the first page
+	Cursor string
+}
+
+// EggsPage is a single page of eggs
+type EggsPage struct {
+	Eggs       []*deployer.EggConfig `json:"items"`
+	NextCursor string                `json:"next_cursor"`
+}
+
+// DeploymentPlansPage is a single page of deployment plans
+type DeploymentPlansPage struct {
+	Plans      []*deployer.DeploymentPlan `json:"items"`
+	NextCursor string                     `json:"next_cursor"`
+}
+
+// ListEggsPage retrieves a single page of eggs
+func (c *Client) ListEggsPage(ctx context.Context, opts ListEggsOptions) (*EggsPage, error) {
+	endpoint := pageURL(fmt.Sprintf("%s/eggs", c.baseURL), opts.PageSize, opts.Cursor)
+
+	page := &EggsPage{}
+	next, err := c.getPage(ctx, endpoint, &page.Eggs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list eggs: %w", err)
+	}
+	page.NextCursor = next
+
+	return page, nil
+}
+
+// ListDeploymentPlansPage retrieves a single page of deployment plans for an Egg
+func (c *Client) ListDeploymentPlansPage(ctx context.Context, eggName string, opts ListDeploymentPlansOptions) (*DeploymentPlansPage, error) {
+	endpoint := pageURL(fmt.Sprintf("%s/eggs/%s/plans", c.baseURL, eggName), opts.PageSize, opts.Cursor)
+
+	page := &DeploymentPlansPage{}
+	next, err := c.getPage(ctx, endpoint, &page.Plans)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployment plans: %w", err)
+	}
+	page.NextCursor = next
+
+	return page, nil
+}
+
+// EggsPager iterates over all pages of GET /eggs
+type EggsPager struct {
+	client *Client
+	opts   ListEggsOptions
+	seen   seenCursors
+	done   bool
+}
+
+// NewEggsPager creates a pager starting at opts.Cursor
+func NewEggsPager(client *Client, opts ListEggsOptions) *EggsPager {
+	return &EggsPager{client: client, opts: opts, seen: newSeenCursors(opts.Cursor)}
+}
+
+// More reports whether there are pages left to fetch
+func (p *EggsPager) More() bool {
+	return !p.done
+}
+
+// NextPage fetches the next page of eggs
+func (p *EggsPager) NextPage(ctx context.Context) ([]*deployer.EggConfig, error) {
+	if p.done {
+		return nil, fmt.Errorf("no more pages")
+	}
+	page, err := p.client.ListEggsPage(ctx, p.opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.seen.add(page.NextCursor); err != nil {
+		p.done = true
+		return nil, fmt.Errorf("failed to list eggs: %w", err)
+	}
+	p.opts.Cursor = page.NextCursor
+	p.done = page.NextCursor == ""
+	return page.Eggs, nil
+}
+
+// DeploymentPlansPager iterates over all pages of GET /eggs/{name}/plans
+type DeploymentPlansPager struct {
+	client  *Client
+	eggName string
+	opts    ListDeploymentPlansOptions
+	seen    seenCursors
+	done    bool
+}
+
+// NewDeploymentPlansPager creates a pager starting at opts.Cursor
+func NewDeploymentPlansPager(client *Client, eggName string, opts ListDeploymentPlansOptions) *DeploymentPlansPager {
+	return &DeploymentPlansPager{client: client, eggName: eggName, opts: opts, seen: newSeenCursors(opts.Cursor)}
+}
+
+// More reports whether there are pages left to fetch
+func (p *DeploymentPlansPager) More() bool {
+	return !p.done
+}
+
+// NextPage fetches the next page of deployment plans
+func (p *DeploymentPlansPager) NextPage(ctx context.Context) ([]*deployer.DeploymentPlan, error) {
+	if p.done {
+		return nil, fmt.Errorf("no more pages")
+	}
+	page, err := p.client.ListDeploymentPlansPage(ctx, p.eggName, p.opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.seen.add(page.NextCursor); err != nil {
+		p.done = true
+		return nil, fmt.Errorf("failed to list deployment plans: %w", err)
+	}
+	p.opts.Cursor = page.NextCursor
+	p.done = page.NextCursor == ""
+	return page.Plans, nil
+}
+
+// seenCursors holds the cursors a listing has visited. A server that hands
+// out a cursor twice would otherwise be paged through forever.
+type seenCursors map[string]bool
+
+// newSeenCursors starts a listing at cursor
+func newSeenCursors(cursor string) seenCursors {
+	seen := seenCursors{}
+	seen.add(cursor)
+	return seen
+}
+
+// add records the next cursor, failing if the listing already visited it
+func (s seenCursors) add(cursor string) error {
+	if cursor == "" {
+		return nil
+	}
+	if s[cursor] {
+		return fmt.Errorf("server returned cursor %q twice", cursor)
+	}
+	s[cursor] = true
+	return nil
+}
+
+// pageURL adds pagination query parameters to a list endpoint
+func pageURL(endpoint string, pageSize int, cursor string) string {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	query := url.Values{}
+	query.Set("page_size", strconv.Itoa(pageSize))
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	return endpoint + "?" + query.Encode()
+}
+
+// getPage fetches a list endpoint and decodes its items into items, returning
+// the next cursor. Servers without pagination return a bare JSON array, which
+// is treated as a single, final page.
+func (c *Client) getPage(ctx context.Context, endpoint string, items interface{}) (string, error) {
+	var raw json.RawMessage
+	if err := c.doRequestWithRetry(ctx, "GET", endpoint, nil, &raw); err != nil {
+		return "", err
+	}
+
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return "", nil
+	}
+	if trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, items); err != nil {
+			return "", fmt.Errorf("failed to decode response: %w", err)
+		}
+		return "", nil
+	}
+
+	var page struct {
+		Items      json.RawMessage `json:"items"`
+		NextCursor string          `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(trimmed, &page); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(page.Items) > 0 {
+		if err := json.Unmarshal(page.Items, items); err != nil {
+			return "", fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return page.NextCursor, nil
+}
@@ -0,0 +1,107 @@
+package mothergoose
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+// SubmitDeploymentPlan registers a deployment plan for an Egg and returns the
+// plan as MotherGoose recorded it. The plan ID is used as the Idempotency-Key
+// so a retried POST is not recorded twice.
+func (c *Client) SubmitDeploymentPlan(ctx context.Context, plan *deployer.DeploymentPlan) (*deployer.DeploymentPlan, error) {
+	url := fmt.Sprintf("%s/eggs/%s/plans", c.baseURL, plan.EggName)
+
+	header := http.Header{}
+	header.Set("Idempotency-Key", plan.ID)
+	var registered deployer.DeploymentPlan
+	err := c.doRequestWithHeaders(ctx, "POST", url, header, plan, &registered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit deployment plan: %w", err)
+	}
+
+	// Older servers answer 201 without a body
+	if registered.ID == "" {
+		registered = *plan
+	}
+	return &registered, nil
+}
+
+// ApplyPlan asks MotherGoose to apply a registered deployment plan and returns
+// the plan, usually still pending or applying. Applying a plan twice has no
+// further effect, so the request is retried like a GET.
+func (c *Client) ApplyPlan(ctx context.Context, eggName, planID string) (*deployer.DeploymentPlan, error) {
+	url := fmt.Sprintf("%s/eggs/%s/plans/%s/apply", c.baseURL, eggName, planID)
+
+	var plan deployer.DeploymentPlan
+	err := c.doRequestWithRetry(ctx, "POST", url, nil, &plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply deployment plan: %w", err)
+	}
+
+	return &plan, nil
+}
+
+// rollbackRequest is the JSON body sent to POST /eggs/{name}/rollback
+type rollbackRequest struct {
+	TargetPlanID string `json:"target_plan_id"`
+}
+
+// RollbackEgg asks MotherGoose to roll an Egg back to a previously applied
+// plan and returns the rollback plan it creates, whose RollbackPlan is
+// targetPlanID. Every attempt carries the same Idempotency-Key so a retried
+// POST starts only one rollback.
+func (c *Client) RollbackEgg(ctx context.Context, eggName, targetPlanID string) (*deployer.DeploymentPlan, error) {
+	url := fmt.Sprintf("%s/eggs/%s/rollback", c.baseURL, eggName)
+
+	header := http.Header{}
+	header.Set("Idempotency-Key", uuid.NewString())
+	var plan deployer.DeploymentPlan
+	err := c.doRequestWithHeaders(ctx, "POST", url, header, rollbackRequest{TargetPlanID: targetPlanID}, &plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll back egg: %w", err)
+	}
+	if plan.ID == "" {
+		return nil, fmt.Errorf("failed to roll back egg: MotherGoose returned no rollback plan")
+	}
+
+	return &plan, nil
+}
+
+// WaitForPlan polls a deployment plan every interval until it is applied,
+// has failed or was rolled back, or ctx is done. The plan is returned in its
+// last known state (only its ID if it was never fetched); unless it was
+// applied, an error is returned with it.
+func WaitForPlan(ctx context.Context, client MotherGooseClient, eggName, planID string, interval time.Duration) (*deployer.DeploymentPlan, error) {
+	last := &deployer.DeploymentPlan{ID: planID, EggName: eggName}
+	for {
+		plan, err := client.GetDeploymentPlan(ctx, eggName, planID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, fmt.Errorf("gave up waiting for deployment plan %s (status %q): %w", planID, last.Status, ctx.Err())
+			}
+			return last, err
+		}
+		last = plan
+
+		switch plan.Status {
+		case deployer.PlanStatusApplied:
+			return plan, nil
+		case deployer.PlanStatusFailed, deployer.PlanStatusRolledBack:
+			if plan.Error != "" {
+				return plan, fmt.Errorf("deployment plan %s %s: %s", planID, plan.Status, plan.Error)
+			}
+			return plan, fmt.Errorf("deployment plan %s %s", planID, plan.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return plan, fmt.Errorf("gave up waiting for deployment plan %s (status %q): %w", planID, plan.Status, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
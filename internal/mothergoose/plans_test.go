@@ -0,0 +1,157 @@
+package mothergoose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func TestSubmitDeploymentPlan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/eggs/test-egg/plans" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var plan deployer.DeploymentPlan
+		if err := json.NewDecoder(r.Body).Decode(&plan); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if plan.ID == "plan-old-server" {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		plan.Status = deployer.PlanStatusPending
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(plan)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	registered, err := client.SubmitDeploymentPlan(context.Background(), &deployer.DeploymentPlan{ID: "plan-123", EggName: "test-egg"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if registered.ID != "plan-123" || registered.Status != deployer.PlanStatusPending {
+		t.Errorf("expected the registered plan, got %+v", registered)
+	}
+
+	// Without a response body the submitted plan is returned
+	registered, err = client.SubmitDeploymentPlan(context.Background(), &deployer.DeploymentPlan{ID: "plan-old-server", EggName: "test-egg"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if registered.ID != "plan-old-server" {
+		t.Errorf("expected the submitted plan, got %+v", registered)
+	}
+}
+
+func TestApplyPlan(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/eggs/test-egg/plans/plan-123/apply" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(deployer.DeploymentPlan{ID: "plan-123", Status: deployer.PlanStatusApplying})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key", WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond}))
+	plan, err := client.ApplyPlan(context.Background(), "test-egg", "plan-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 2 || plan.Status != deployer.PlanStatusApplying {
+		t.Errorf("expected a retried apply returning the applying plan, got %d attempt(s) and %+v", attempts, plan)
+	}
+}
+
+func TestWaitForPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		planErr  string
+		wantErr  string
+	}{
+		{name: "applied", statuses: []string{"pending", "applying", "applied"}},
+		{name: "failed", statuses: []string{"applying", "failed"}, planErr: "quota exceeded", wantErr: "deployment plan plan-123 failed: quota exceeded"},
+		{name: "rolled back", statuses: []string{"rolled_back"}, wantErr: "deployment plan plan-123 rolled_back"},
+		{name: "timeout", statuses: []string{"pending"}, wantErr: `gave up waiting for deployment plan plan-123 (status "pending")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[min(polls, len(tt.statuses)-1)]
+				polls++
+				json.NewEncoder(w).Encode(deployer.DeploymentPlan{ID: "plan-123", Status: status, Error: tt.planErr})
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			plan, err := WaitForPlan(ctx, NewClient(server.URL, "test-api-key"), "test-egg", "plan-123", time.Millisecond)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if plan.Status != deployer.PlanStatusApplied || polls != len(tt.statuses) {
+				t.Errorf("expected the applied plan after %d polls, got %+v after %d", len(tt.statuses), plan, polls)
+			}
+		})
+	}
+}
+
+func TestRollbackEgg(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/eggs/test-egg/rollback" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Idempotency-Key") == "" {
+			t.Error("expected an Idempotency-Key header")
+		}
+		var req rollbackRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if req.TargetPlanID == "plan-missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(deployer.DeploymentPlan{
+			ID:           "plan-rollback",
+			EggName:      "test-egg",
+			Status:       deployer.PlanStatusPending,
+			RollbackPlan: req.TargetPlanID,
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+	plan, err := client.RollbackEgg(context.Background(), "test-egg", "plan-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.ID != "plan-rollback" || plan.RollbackPlan != "plan-123" {
+		t.Errorf("expected the rollback plan targeting plan-123, got %+v", plan)
+	}
+
+	if _, err := client.RollbackEgg(context.Background(), "test-egg", "plan-missing"); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+}
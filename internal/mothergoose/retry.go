@@ -0,0 +1,79 @@
+package mothergoose
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls the backoff between retried requests
+type RetryPolicy struct {
+	// BaseDelay is the wait before the first retry; it doubles on each attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the computed backoff and any Retry-After asked for by the server
+	MaxDelay time.Duration
+	// Jitter randomly shortens each backoff by up to this fraction (0 to 1)
+	Jitter float64
+	// Budget is the total time a request may spend waiting between retries;
+	// zero means no limit beyond the maximum number of retries
+	Budget time.Duration
+}
+
+// DefaultRetryPolicy is the policy used unless WithRetryPolicy is given
+var DefaultRetryPolicy = RetryPolicy{
+	BaseDelay: 1 * time.Second,
+	MaxDelay:  30 * time.Second,
+}
+
+// WithRetryPolicy sets the backoff between retries
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// backoff returns the wait before the given retry attempt (1 for the first retry)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay
+}
+
+// retryAfter caps a server's Retry-After delay at MaxDelay so a misbehaving
+// server or proxy cannot stall the client
+func (p RetryPolicy) retryAfter(delay time.Duration) time.Duration {
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package mothergoose
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// RunnerFilter selects the runners returned by ListRunners. MotherGoose
+// applies the filters server-side; the client applies them again so that
+// older servers that ignore a filter return the same runners.
+type RunnerFilter struct {
+	// EggName keeps only the runners of this Egg
+	EggName string
+	// State keeps only runners in this state, such as "active" or "paused"
+	State string
+	// HeartbeatBefore keeps only runners whose last heartbeat is older than this
+	HeartbeatBefore time.Time
+}
+
+// Matches reports whether runner passes the filter
+func (f RunnerFilter) Matches(runner *Runner) bool {
+	if f.EggName != "" && runner.EggName != f.EggName {
+		return false
+	}
+	if f.State != "" && runner.State != f.State {
+		return false
+	}
+	if !f.HeartbeatBefore.IsZero() && !runner.LastHeartbeat.Before(f.HeartbeatBefore) {
+		return false
+	}
+	return true
+}
+
+// query returns the filter as query parameters of GET /runners
+func (f RunnerFilter) query() url.Values {
+	query := url.Values{}
+	if f.EggName != "" {
+		query.Set("egg", f.EggName)
+	}
+	if f.State != "" {
+		query.Set("state", f.State)
+	}
+	if !f.HeartbeatBefore.IsZero() {
+		query.Set("heartbeat_before", f.HeartbeatBefore.UTC().Format(time.RFC3339Nano))
+	}
+	return query
+}
+
+// ListRunners lists the runners selected by filter, following pagination
+// until the last page
+func (c *Client) ListRunners(ctx context.Context, filter RunnerFilter) ([]*Runner, error) {
+	query := filter.query()
+	var runners []*Runner
+	cursor := ""
+	seen := seenCursors{}
+	for {
+		endpoint := pageURL(fmt.Sprintf("%s/runners", c.baseURL), DefaultPageSize, cursor)
+		if encoded := query.Encode(); encoded != "" {
+			endpoint += "&" + encoded
+		}
+
+		var page []*Runner
+		next, err := c.getPage(ctx, endpoint, &page)
+		if err == nil {
+			err = seen.add(next)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list runners: %w", err)
+		}
+		for _, runner := range page {
+			if filter.Matches(runner) {
+				runners = append(runners, runner)
+			}
+		}
+		if next == "" {
+			return runners, nil
+		}
+		cursor = next
+	}
+}
+
+// GetRunner retrieves a single runner by ID
+func (c *Client) GetRunner(ctx context.Context, runnerID string) (*Runner, error) {
+	url := fmt.Sprintf("%s/runners/%s", c.baseURL, runnerID)
+
+	var runner Runner
+	err := c.doRequestWithRetry(ctx, "GET", url, nil, &runner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runner: %w", err)
+	}
+
+	return &runner, nil
+}
@@ -0,0 +1,81 @@
+package mothergoose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListRunners(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/runners" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("cursor") == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"items": []*Runner{
+					{ID: "runner-1", EggName: "my-app", State: "active", LastHeartbeat: now.Add(-5 * time.Minute)},
+					// An older server ignoring the filters returns other runners too
+					{ID: "runner-2", EggName: "other", State: "active", LastHeartbeat: now.Add(-5 * time.Minute)},
+				},
+				"next_cursor": "page-2",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"items": []*Runner{
+				{ID: "runner-3", EggName: "my-app", State: "active", LastHeartbeat: now.Add(-10 * time.Second)},
+				{ID: "runner-4", EggName: "my-app", State: "active", LastHeartbeat: now.Add(-2 * time.Minute)},
+			},
+		})
+	}))
+	defer server.Close()
+
+	filter := RunnerFilter{EggName: "my-app", State: "active", HeartbeatBefore: now.Add(-time.Minute)}
+	runners, err := NewClient(server.URL, "key").ListRunners(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+	if len(runners) != 2 || runners[0].ID != "runner-1" || runners[1].ID != "runner-4" {
+		t.Errorf("expected runner-1 and runner-4, got %+v", runners)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("expected 2 pages, got %d", len(queries))
+	}
+	want := "egg=my-app&heartbeat_before=2024-01-01T11%3A59%3A00Z&state=active"
+	for _, query := range queries {
+		if len(query) < len(want) || query[len(query)-len(want):] != want {
+			t.Errorf("expected the filters in query %q", query)
+		}
+	}
+}
+
+func TestGetRunner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/runners/runner-1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "runner not found", "code": "not_found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(Runner{ID: "runner-1", EggName: "my-app", State: "paused"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key")
+	runner, err := client.GetRunner(context.Background(), "runner-1")
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if runner.EggName != "my-app" || runner.State != "paused" {
+		t.Errorf("unexpected runner %+v", runner)
+	}
+	if _, err := client.GetRunner(context.Background(), "runner-2"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
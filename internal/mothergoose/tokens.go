@@ -0,0 +1,39 @@
+package mothergoose
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenRotation tells MotherGoose that the runner authentication token of
+// an Egg's GitLab project or group was replaced, so it can restart the Egg's
+// runners with the token now stored at TokenSecret
+type TokenRotation struct {
+	EggName     string `json:"egg_name"`
+	ServerName  string `json:"server_name"`
+	ProjectID   int    `json:"project_id,omitempty"`
+	GroupID     int    `json:"group_id,omitempty"`
+	TokenSecret string `json:"token_secret"`
+	// RunnerID is the GitLab ID of the runner the new token belongs to
+	RunnerID int `json:"runner_id"`
+	// PreviousRunnerIDs are the GitLab runners whose tokens stop working at GraceUntil
+	PreviousRunnerIDs []int     `json:"previous_runner_ids"`
+	GraceUntil        time.Time `json:"grace_until"`
+}
+
+// RotateEggToken reports a runner token rotation of an Egg. Every attempt
+// carries the same Idempotency-Key so MotherGoose can discard duplicate POSTs.
+func (c *Client) RotateEggToken(ctx context.Context, rotation *TokenRotation) error {
+	url := fmt.Sprintf("%s/eggs/%s/token-rotations", c.baseURL, rotation.EggName)
+
+	header := http.Header{}
+	header.Set("Idempotency-Key", uuid.NewString())
+	if err := c.doRequestWithHeaders(ctx, "POST", url, header, rotation, nil); err != nil {
+		return fmt.Errorf("failed to report token rotation of egg %s: %w", rotation.EggName, err)
+	}
+	return nil
+}
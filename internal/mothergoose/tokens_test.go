@@ -0,0 +1,53 @@
+package mothergoose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRotateEggToken(t *testing.T) {
+	var received TokenRotation
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/eggs/my-app/token-rotations" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Idempotency-Key") == "" {
+			t.Error("expected an Idempotency-Key header")
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	rotation := &TokenRotation{
+		EggName:           "my-app",
+		ServerName:        "gitlab.com",
+		ProjectID:         42,
+		TokenSecret:       "vault://secret/gitlab-tokens/my-app/token",
+		RunnerID:          101,
+		PreviousRunnerIDs: []int{100},
+		GraceUntil:        time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := NewClient(server.URL, "key").RotateEggToken(context.Background(), rotation); err != nil {
+		t.Fatalf("RotateEggToken failed: %v", err)
+	}
+	if received.RunnerID != 101 || len(received.PreviousRunnerIDs) != 1 || !received.GraceUntil.Equal(rotation.GraceUntil) {
+		t.Errorf("unexpected rotation received: %+v", received)
+	}
+}
+
+func TestRotateEggTokenNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL, "key").RotateEggToken(context.Background(), &TokenRotation{EggName: "my-app"})
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package mothergoose
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultMaxIdleConnsPerHost is the number of idle connections kept open to
+// MotherGoose, enough for deploy's parallel Eggs to reuse connections
+const DefaultMaxIdleConnsPerHost = 32
+
+// newTransport returns the transport used unless WithHTTPClient is given: it
+// negotiates HTTP/2 and keeps connections to MotherGoose alive between requests
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = 90 * time.Second
+	return transport
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections to MotherGoose are
+// kept for reuse. It has no effect on a client given by WithHTTPClient whose
+// transport is not an *http.Transport.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(c *Client) {
+		if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
+			transport.MaxIdleConnsPerHost = n
+		}
+	}
+}
+
+// WithRateLimit limits the client to requestsPerSecond requests, allowing
+// bursts of up to burst requests. Retries count against the limit; requests
+// wait for their turn or until their context is done.
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return func(c *Client) {
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	}
+}
@@ -0,0 +1,35 @@
+package mothergoose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+// GetUglyFoxConfig retrieves the runner lifecycle configuration UglyFox
+// applies; IsNotFound reports that none was deployed yet
+func (c *Client) GetUglyFoxConfig(ctx context.Context) (*deployer.UglyFoxConfig, error) {
+	url := fmt.Sprintf("%s/uglyfox/config", c.baseURL)
+
+	var config deployer.UglyFoxConfig
+	err := c.doRequestWithRetry(ctx, "GET", url, nil, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get uglyfox config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// UpdateUglyFoxConfig replaces the runner lifecycle configuration UglyFox
+// applies. PUT is idempotent, so the request is retried like a GET.
+func (c *Client) UpdateUglyFoxConfig(ctx context.Context, config *deployer.UglyFoxConfig) error {
+	url := fmt.Sprintf("%s/uglyfox/config", c.baseURL)
+
+	err := c.doRequestWithRetry(ctx, "PUT", url, config, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update uglyfox config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package mothergoose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func TestUglyFoxConfig(t *testing.T) {
+	var stored *deployer.UglyFoxConfig
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/uglyfox/config" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		switch r.Method {
+		case "GET":
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error": "no uglyfox config", "code": "not_found"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(stored)
+		case "PUT":
+			stored = &deployer.UglyFoxConfig{}
+			json.NewDecoder(r.Body).Decode(stored)
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key")
+	ctx := context.Background()
+	if _, err := client.GetUglyFoxConfig(ctx); !IsNotFound(err) {
+		t.Fatalf("expected a not found error before the first update, got %v", err)
+	}
+
+	config := &deployer.UglyFoxConfig{
+		Pruning: deployer.PruningConfig{FailedThreshold: 3, MaxAge: 24 * time.Hour, CheckInterval: 5 * time.Minute},
+		Apex:    &deployer.PoolConfig{MaxCount: 10, MinCount: 2},
+		Nadir:   &deployer.PoolConfig{MaxCount: 5, IdleTimeout: 30 * time.Minute},
+	}
+	if err := client.UpdateUglyFoxConfig(ctx, config); err != nil {
+		t.Fatalf("UpdateUglyFoxConfig failed: %v", err)
+	}
+	got, err := client.GetUglyFoxConfig(ctx)
+	if err != nil {
+		t.Fatalf("GetUglyFoxConfig failed: %v", err)
+	}
+	if got.Pruning != config.Pruning || *got.Nadir != *config.Nadir {
+		t.Errorf("expected the updated config, got %+v", got)
+	}
+}
@@ -0,0 +1,36 @@
+package mothergoose
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// APIMajorVersion is the MotherGoose API major version this client speaks
+const APIMajorVersion = 1
+
+// ServerVersion is the version information reported by MotherGoose
+type ServerVersion struct {
+	Version    string `json:"version"`
+	APIVersion string `json:"api_version"`
+}
+
+// GetServerVersion retrieves the MotherGoose server and API version
+func (c *Client) GetServerVersion(ctx context.Context) (*ServerVersion, error) {
+	url := fmt.Sprintf("%s/version", c.baseURL)
+
+	var version ServerVersion
+	err := c.doRequestWithRetry(ctx, "GET", url, nil, &version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	return &version, nil
+}
+
+// IsCompatible reports whether the server speaks API major version APIMajorVersion
+func (v *ServerVersion) IsCompatible() bool {
+	major, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(v.APIVersion, "v"), ".", 2)[0])
+	return err == nil && major == APIMajorVersion
+}
@@ -2,7 +2,13 @@ package parser
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
 	"strings"
+	"unicode"
+
+	"github.com/zclconf/go-cty/cty"
 )
 
 // Position represents a location in the source file
@@ -26,6 +32,11 @@ type Node interface {
 type Config struct {
 	Position Position
 	Blocks   []Block
+	// SchemaVersion is the schema_version the file declares, 0 if none
+	SchemaVersion int
+
+	schemaVersionPos Position
+	variables        map[string]cty.Value // Declared and included variables, for including files
 }
 
 func (c *Config) Pos() Position {
@@ -48,6 +59,7 @@ type Block struct {
 	Labels     []string         // Block labels (e.g., ["my-app"] for egg "my-app")
 	Attributes map[string]Value // Direct attributes
 	Blocks     []Block          // Nested blocks
+	Repeated   bool             // An instance of a block with count or for_each
 }
 
 func (b *Block) Pos() Position {
@@ -56,31 +68,50 @@ func (b *Block) Pos() Position {
 
 func (b *Block) String() string {
 	var sb strings.Builder
+	b.writeTo(&sb, "")
+	return sb.String()
+}
+
+// writeTo prints the block at the given indentation. Attributes are emitted
+// in sorted order so the output is deterministic, and multi-line strings are
+// emitted as heredocs so scripts survive a parse→print→parse round-trip.
+func (b *Block) writeTo(sb *strings.Builder, indent string) {
+	sb.WriteString(indent)
 	sb.WriteString(b.Type)
 	for _, label := range b.Labels {
 		sb.WriteString(fmt.Sprintf(" %q", label))
 	}
 	sb.WriteString(" {\n")
 
+	inner := indent + "  "
+
 	// Write attributes
-	for key, val := range b.Attributes {
-		sb.WriteString(fmt.Sprintf("  %s = %s\n", key, val.String()))
+	keys := make([]string, 0, len(b.Attributes))
+	for key := range b.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		val := b.Attributes[key]
+		sb.WriteString(inner)
+		sb.WriteString(key)
+		sb.WriteString(" = ")
+		if val.Type == StringType && isHeredocCandidate(val.Raw.(string)) {
+			writeHeredoc(sb, val.Raw.(string), inner)
+		} else {
+			sb.WriteString(val.String())
+		}
+		sb.WriteString("\n")
 	}
 
 	// Write nested blocks
-	for _, nested := range b.Blocks {
-		lines := strings.Split(nested.String(), "\n")
-		for _, line := range lines {
-			if line != "" {
-				sb.WriteString("  ")
-				sb.WriteString(line)
-				sb.WriteString("\n")
-			}
-		}
+	for i := range b.Blocks {
+		b.Blocks[i].writeTo(sb, inner)
+		sb.WriteString("\n")
 	}
 
+	sb.WriteString(indent)
 	sb.WriteString("}")
-	return sb.String()
 }
 
 // GetAttribute retrieves an attribute by name
@@ -142,7 +173,7 @@ func (vt ValueType) String() string {
 type Value struct {
 	Position Position
 	Type     ValueType
-	Raw      interface{} // Actual value: string, float64, bool, []Value, map[string]Value
+	Raw      interface{} // Actual value: string, Number (or float64), bool, []Value, map[string]Value
 }
 
 func (v *Value) Pos() Position {
@@ -152,8 +183,11 @@ func (v *Value) Pos() Position {
 func (v *Value) String() string {
 	switch v.Type {
 	case StringType:
-		return fmt.Sprintf("%q", v.Raw.(string))
+		return quoteString(v.Raw.(string))
 	case NumberType:
+		if n, err := v.number(); err == nil {
+			return n.String()
+		}
 		return fmt.Sprintf("%v", v.Raw)
 	case BoolType:
 		return fmt.Sprintf("%v", v.Raw)
@@ -166,8 +200,14 @@ func (v *Value) String() string {
 		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
 	case MapType:
 		m := v.Raw.(map[string]Value)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
 		var pairs []string
-		for k, val := range m {
+		for _, k := range keys {
+			val := m[k]
 			pairs = append(pairs, fmt.Sprintf("%s = %s", k, val.String()))
 		}
 		return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
@@ -184,21 +224,51 @@ func (v *Value) AsString() (string, error) {
 	return v.Raw.(string), nil
 }
 
+// number returns the value as a Number. Parsed values hold a Number, but
+// values built in code may hold a float64.
+func (v *Value) number() (Number, error) {
+	if v.Type != NumberType {
+		return Number{}, fmt.Errorf("expected number, got %s at %s", v.Type, v.Position)
+	}
+	switch raw := v.Raw.(type) {
+	case Number:
+		return raw, nil
+	case float64:
+		return NewNumber(raw), nil
+	}
+	return Number{}, fmt.Errorf("invalid number %v at %s", v.Raw, v.Position)
+}
+
 // AsNumber returns the value as a float64
 func (v *Value) AsNumber() (float64, error) {
-	if v.Type != NumberType {
-		return 0, fmt.Errorf("expected number, got %s at %s", v.Type, v.Position)
+	n, err := v.number()
+	if err != nil {
+		return 0, err
 	}
-	return v.Raw.(float64), nil
+	return n.Float64(), nil
 }
 
-// AsInt returns the value as an int
+// AsBigFloat returns the exact value of a number
+func (v *Value) AsBigFloat() (*big.Float, error) {
+	n, err := v.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.BigFloat(), nil
+}
+
+// AsInt returns the value as an int. Numbers with a fractional part are
+// rejected rather than truncated.
 func (v *Value) AsInt() (int, error) {
-	num, err := v.AsNumber()
+	n, err := v.number()
 	if err != nil {
 		return 0, err
 	}
-	return int(num), nil
+	i, ok := n.Int64()
+	if !ok || i < math.MinInt || i > math.MaxInt {
+		return 0, fmt.Errorf("expected integer, got %s at %s", n, v.Position)
+	}
+	return int(i), nil
 }
 
 // AsBool returns the value as a bool
@@ -235,7 +305,9 @@ func (v *Value) Equals(other *Value) bool {
 	case StringType:
 		return v.Raw.(string) == other.Raw.(string)
 	case NumberType:
-		return v.Raw.(float64) == other.Raw.(float64)
+		n, err := v.number()
+		o, otherErr := other.number()
+		return err == nil && otherErr == nil && n.Equal(o)
 	case BoolType:
 		return v.Raw.(bool) == other.Raw.(bool)
 	case ListType:
@@ -267,3 +339,79 @@ func (v *Value) Equals(other *Value) bool {
 		return false
 	}
 }
+
+// quoteString renders s as a quoted HCL string literal. Template sequences
+// are escaped so the literal text is preserved when parsed again.
+func quoteString(s string) string {
+	quoted := fmt.Sprintf("%q", s)
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
+
+// isHeredocCandidate reports whether s can be printed as a heredoc and read
+// back unchanged. HCL heredocs always end with a newline, so only multi-line
+// strings that already end with one qualify.
+func isHeredocCandidate(s string) bool {
+	if !strings.HasSuffix(s, "\n") || strings.Count(s, "\n") < 2 {
+		return false
+	}
+	return !strings.Contains(s, "\r")
+}
+
+// writeHeredoc prints s as a heredoc whose body is indented one level deeper
+// than the attribute. The indented <<- form is used when the content has at
+// least one unindented line, because HCL strips the common leading
+// whitespace; otherwise the plain << form keeps the content verbatim.
+func writeHeredoc(sb *strings.Builder, s, indent string) {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	marker := heredocMarker(lines)
+
+	flush := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && !unicode.IsSpace([]rune(line)[0]) {
+			flush = true
+			break
+		}
+	}
+
+	if flush {
+		sb.WriteString("<<-" + marker + "\n")
+	} else {
+		sb.WriteString("<<" + marker + "\n")
+	}
+
+	for _, line := range lines {
+		line = strings.ReplaceAll(line, "${", "$${")
+		line = strings.ReplaceAll(line, "%{", "%%{")
+		// Whitespace-only lines are not considered by HCL when stripping
+		// indentation, so they must be written without the extra prefix.
+		if flush && strings.TrimSpace(line) != "" {
+			sb.WriteString(indent + "  ")
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	if flush {
+		sb.WriteString(indent)
+	}
+	sb.WriteString(marker)
+}
+
+// heredocMarker picks a delimiter that does not collide with any content line
+func heredocMarker(lines []string) string {
+	marker := "EOT"
+	for i := 1; ; i++ {
+		collision := false
+		for _, line := range lines {
+			if strings.TrimSpace(line) == marker {
+				collision = true
+				break
+			}
+		}
+		if !collision {
+			return marker
+		}
+		marker = fmt.Sprintf("EOT%d", i)
+	}
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Diagnostic codes identify the kind of a problem in a .fly file. Each is
+// documented in docs/diagnostics.md, at CodeURL(code).
+const (
+	CodeSyntax           = "GSL1001"
+	CodeUnsupported      = "GSL1002"
+	CodeExpression       = "GSL1003"
+	CodeLabels           = "GSL2001"
+	CodeInvalidName      = "GSL2002"
+	CodeMissingAttribute = "GSL2003"
+	CodeMissingBlock     = "GSL2004"
+	CodeDuplicateBlock   = "GSL2005"
+	CodeType             = "GSL2006"
+	CodeValue            = "GSL2007"
+	CodeRange            = "GSL2008"
+	CodeDuration         = "GSL2009"
+	CodeUnknown          = "GSL2010"
+	CodeConstraint       = "GSL3001"
+	CodeDeprecated       = "GSL4001"
+	CodeRecommended      = "GSL4002"
+	CodeSuspicious       = "GSL4003"
+)
+
+// codeTitles summarise the problem each code reports
+var codeTitles = map[string]string{
+	CodeSyntax:           "Not valid HCL",
+	CodeUnsupported:      "Expression or value not supported in .fly files",
+	CodeExpression:       "Expression that cannot be evaluated",
+	CodeLabels:           "Wrong number of block labels",
+	CodeInvalidName:      "Label that is not a valid name",
+	CodeMissingAttribute: "Required attribute not set",
+	CodeMissingBlock:     "Required nested block missing, or too few of them",
+	CodeDuplicateBlock:   "Nested block that may occur once repeated",
+	CodeType:             "Attribute value of the wrong type",
+	CodeValue:            "Value not among the allowed ones",
+	CodeRange:            "Number out of range",
+	CodeDuration:         "Invalid or out of range duration",
+	CodeUnknown:          "Attribute or block the schema does not describe",
+	CodeConstraint:       "Failed check of a block or of references between blocks",
+	CodeDeprecated:       "Deprecated attribute or block",
+	CodeRecommended:      "Recommended attribute not set",
+	CodeSuspicious:       "Valid value that is likely a mistake",
+}
+
+// CodeTitle summarises the problem a diagnostic code reports, or returns ""
+// for an unknown code
+func CodeTitle(code string) string {
+	return codeTitles[code]
+}
+
+// codesDocURL is where the diagnostic codes are documented
+const codesDocURL = "https://github.com/polar-gosling/gosling/blob/main/docs/diagnostics.md"
+
+// CodeURL returns the documentation of a diagnostic code
+func CodeURL(code string) string {
+	return codesDocURL + "#" + strings.ToLower(code)
+}
+
+// DiagnosticCode returns the code of a diagnostic of a SyntaxError
+func DiagnosticCode(diag *hcl.Diagnostic) string {
+	if code, ok := diag.Extra.(diagnosticCode); ok {
+		return string(code)
+	}
+	return CodeSyntax
+}
+
+// diagnosticCode is the Extra of the diagnostics the parser adds to HCL's
+type diagnosticCode string
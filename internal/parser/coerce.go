@@ -0,0 +1,73 @@
+package parser
+
+import "fmt"
+
+// A lenient validator reads values of the wrong type where the intent is
+// clear, such as "3" for a number or 3 for a string, to ease migrating
+// configurations written before types were checked. Each such value is
+// replaced in the configuration by the value of the expected type and
+// reported as a warning.
+
+// coerce returns val converted to typ, and whether it could be converted.
+// Only strings, numbers and bools are converted, into one another.
+func coerce(val Value, typ AttrType) (Value, bool) {
+	switch typ {
+	case AttrString:
+		switch val.Type {
+		case NumberType, BoolType:
+			return Value{Position: val.Position, Type: StringType, Raw: val.String()}, true
+		}
+	case AttrNumber, AttrInteger:
+		if str, err := val.AsString(); err == nil {
+			num, err := ParseNumber(str)
+			if err != nil || num.BigFloat().IsInf() {
+				return val, false
+			}
+			return Value{Position: val.Position, Type: NumberType, Raw: num}, true
+		}
+	case AttrBool:
+		if str, err := val.AsString(); err == nil {
+			if str != "true" && str != "false" {
+				return val, false
+			}
+			return Value{Position: val.Position, Type: BoolType, Raw: str == "true"}, true
+		}
+	}
+	return val, false
+}
+
+// coerceAttribute converts the attribute of block described by attr to its
+// type, when the validator is lenient and the value can be converted
+func (v *Validator) coerceAttribute(block *Block, attr *AttributeSchema) {
+	if !v.lenient {
+		return
+	}
+	val, ok := block.GetAttribute(attr.Name)
+	if !ok {
+		return
+	}
+	if coerced, ok := coerce(val, attr.Type); ok {
+		want := article(string(attr.Type)) + " " + string(attr.Type)
+		if attr.Type == AttrInteger {
+			want = "a whole number"
+		}
+		block.Attributes[attr.Name] = coerced
+		v.result.addWarning(val.Position, attr.Name, CodeType,
+			fmt.Sprintf("%s must be %s, got %s; read as %s", attr.Name, want, val.String(), coerced.String()))
+		return
+	}
+	if attr.Type != AttrStringList {
+		return
+	}
+	list, err := val.AsList()
+	if err != nil {
+		return
+	}
+	for i, elem := range list {
+		if coerced, ok := coerce(elem, AttrString); ok {
+			list[i] = coerced
+			v.result.addWarning(elem.Position, fmt.Sprintf("%s[%d]", attr.Name, i), CodeType,
+				fmt.Sprintf("%s[%d] must be a string, got %s; read as %s", attr.Name, i, elem.String(), coerced.String()))
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronFieldError describes why a cron expression is invalid.
+// Offset is the byte offset of the offending field within the expression,
+// which lets the validator report the exact column in the .fly file.
+type CronFieldError struct {
+	Field   string // "minute", "hour", "day-of-month", ...; empty for whole-expression errors
+	Value   string
+	Offset  int
+	Message string
+}
+
+func (e *CronFieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s field %q: %s", e.Field, e.Value, e.Message)
+}
+
+// cronField describes the allowed values for one position in a cron expression
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int // Optional symbolic names (JAN, MON, ...)
+	question bool           // Whether "?" is allowed (day-of-month/day-of-week)
+}
+
+var (
+	cronMonthNames = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+	cronWeekdayNames = map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}
+
+	cronSecond     = cronField{name: "second", min: 0, max: 59}
+	cronMinute     = cronField{name: "minute", min: 0, max: 59}
+	cronHour       = cronField{name: "hour", min: 0, max: 23}
+	cronDayOfMonth = cronField{name: "day-of-month", min: 1, max: 31, question: true}
+	cronMonth      = cronField{name: "month", min: 1, max: 12, names: cronMonthNames}
+	// Both 0 and 7 mean Sunday
+	cronDayOfWeek = cronField{name: "day-of-week", min: 0, max: 7, names: cronWeekdayNames, question: true}
+)
+
+// cronMacros are the supported predefined schedules
+var cronMacros = map[string]bool{
+	"@yearly":   true,
+	"@annually": true,
+	"@monthly":  true,
+	"@weekly":   true,
+	"@daily":    true,
+	"@midnight": true,
+	"@hourly":   true,
+}
+
+// ValidateCronExpression checks a cron expression and returns a *CronFieldError
+// describing the first invalid field, or nil if the expression is valid.
+//
+// Supported forms:
+//   - 5 fields: minute hour day-of-month month day-of-week
+//   - 6 fields: second minute hour day-of-month month day-of-week
+//   - macros: @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly
+//   - @every <duration>, e.g. "@every 1h30m"
+func ValidateCronExpression(expr string) *CronFieldError {
+	trimmed := strings.TrimSpace(expr)
+	if trimmed == "" {
+		return &CronFieldError{Message: "cron expression is empty"}
+	}
+	leading := strings.Index(expr, trimmed)
+
+	if strings.HasPrefix(trimmed, "@") {
+		return validateCronMacro(trimmed, leading)
+	}
+
+	fields, offsets := splitCronFields(expr)
+
+	var layout []cronField
+	switch len(fields) {
+	case 5:
+		layout = []cronField{cronMinute, cronHour, cronDayOfMonth, cronMonth, cronDayOfWeek}
+	case 6:
+		layout = []cronField{cronSecond, cronMinute, cronHour, cronDayOfMonth, cronMonth, cronDayOfWeek}
+	default:
+		return &CronFieldError{
+			Offset:  leading,
+			Message: fmt.Sprintf("expected 5 or 6 fields, got %d", len(fields)),
+		}
+	}
+
+	for i, field := range fields {
+		if msg := layout[i].validate(field); msg != "" {
+			return &CronFieldError{
+				Field:   layout[i].name,
+				Value:   field,
+				Offset:  offsets[i],
+				Message: msg,
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateCronMacro(expr string, offset int) *CronFieldError {
+	if cronMacros[expr] {
+		return nil
+	}
+
+	parts := strings.Fields(expr)
+	if parts[0] == "@every" {
+		if len(parts) != 2 {
+			return &CronFieldError{Offset: offset, Message: "@every requires exactly one duration, e.g. \"@every 1h\""}
+		}
+		d, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return &CronFieldError{
+				Field:   "duration",
+				Value:   parts[1],
+				Offset:  offset + strings.Index(expr, parts[1]),
+				Message: "expected Go duration like 30m or 1h",
+			}
+		}
+		if d < time.Minute {
+			return &CronFieldError{
+				Field:   "duration",
+				Value:   parts[1],
+				Offset:  offset + strings.Index(expr, parts[1]),
+				Message: "interval must be at least 1m",
+			}
+		}
+		return nil
+	}
+
+	return &CronFieldError{
+		Offset:  offset,
+		Message: fmt.Sprintf("unknown macro %q (supported: @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly, @every <duration>)", parts[0]),
+	}
+}
+
+// splitCronFields splits expr on whitespace, returning each field and its byte offset
+func splitCronFields(expr string) ([]string, []int) {
+	var fields []string
+	var offsets []int
+	start := -1
+	for i, ch := range expr {
+		if ch == ' ' || ch == '\t' {
+			if start >= 0 {
+				fields = append(fields, expr[start:i])
+				offsets = append(offsets, start)
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, expr[start:])
+		offsets = append(offsets, start)
+	}
+	return fields, offsets
+}
+
+// validate checks a single field and returns an error message, or "" if valid
+func (f cronField) validate(field string) string {
+	if field == "?" {
+		if f.question {
+			return ""
+		}
+		return "'?' is only allowed in day-of-month and day-of-week"
+	}
+
+	for _, item := range strings.Split(field, ",") {
+		if item == "" {
+			return "empty list element"
+		}
+		if msg := f.validateItem(item); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// validateItem checks one comma-separated element: *, N, A-B, with an optional /step
+func (f cronField) validateItem(item string) string {
+	rangePart := item
+	if idx := strings.Index(item, "/"); idx >= 0 {
+		rangePart = item[:idx]
+		stepStr := item[idx+1:]
+		step, err := strconv.Atoi(stepStr)
+		if err != nil || step <= 0 {
+			return fmt.Sprintf("invalid step %q: must be a positive integer", stepStr)
+		}
+		if step > f.max-f.min+1 {
+			return fmt.Sprintf("step %d exceeds the range %d-%d", step, f.min, f.max)
+		}
+	}
+
+	if rangePart == "*" {
+		return ""
+	}
+
+	if idx := strings.Index(rangePart, "-"); idx >= 0 {
+		low, msg := f.parseValue(rangePart[:idx])
+		if msg != "" {
+			return msg
+		}
+		high, msg := f.parseValue(rangePart[idx+1:])
+		if msg != "" {
+			return msg
+		}
+		if low > high {
+			return fmt.Sprintf("range start %d is greater than range end %d", low, high)
+		}
+		return ""
+	}
+
+	_, msg := f.parseValue(rangePart)
+	return msg
+}
+
+// parseValue resolves a number or symbolic name and checks it against the field bounds
+func (f cronField) parseValue(s string) (int, string) {
+	if s == "" {
+		return 0, "missing value"
+	}
+	if f.names != nil {
+		if v, ok := f.names[strings.ToUpper(s)]; ok {
+			return v, ""
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Sprintf("%q is not a number", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Sprintf("value %d out of range %d-%d", v, f.min, f.max)
+	}
+	return v, ""
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestValidateCronExpression(t *testing.T) {
+	tests := []struct {
+		name      string
+		expr      string
+		wantField string
+		wantValid bool
+	}{
+		{name: "daily at 2am", expr: "0 2 * * *", wantValid: true},
+		{name: "every 15 minutes", expr: "*/15 * * * *", wantValid: true},
+		{name: "ranges and lists", expr: "0,30 9-17 * * 1-5", wantValid: true},
+		{name: "stepped range", expr: "0 8-20/2 * * *", wantValid: true},
+		{name: "month and weekday names", expr: "0 0 1 JAN,jul MON-FRI", wantValid: true},
+		{name: "sunday as 7", expr: "0 0 * * 7", wantValid: true},
+		{name: "question mark", expr: "0 0 ? * MON", wantValid: true},
+		{name: "six fields with seconds", expr: "30 0 2 * * *", wantValid: true},
+		{name: "daily macro", expr: "@daily", wantValid: true},
+		{name: "hourly macro", expr: "@hourly", wantValid: true},
+		{name: "every macro", expr: "@every 1h30m", wantValid: true},
+		{name: "minute out of range", expr: "99 2 * * *", wantField: "minute"},
+		{name: "hour out of range", expr: "0 99 * * *", wantField: "hour"},
+		{name: "day of month zero", expr: "0 0 0 * *", wantField: "day-of-month"},
+		{name: "month out of range", expr: "0 0 1 13 *", wantField: "month"},
+		{name: "weekday out of range", expr: "0 0 * * 8", wantField: "day-of-week"},
+		{name: "unknown weekday name", expr: "0 0 * * FUNDAY", wantField: "day-of-week"},
+		{name: "reversed range", expr: "0 17-9 * * *", wantField: "hour"},
+		{name: "zero step", expr: "*/0 * * * *", wantField: "minute"},
+		{name: "question mark in hour", expr: "0 ? * * *", wantField: "hour"},
+		{name: "empty list element", expr: "0, * * * *", wantField: "minute"},
+		{name: "too few fields", expr: "invalid cron"},
+		{name: "too many fields", expr: "0 0 0 0 0 0 0"},
+		{name: "unknown macro", expr: "@fortnightly"},
+		{name: "every with bad duration", expr: "@every ten minutes"},
+		{name: "every too short", expr: "@every 10s", wantField: "duration"},
+		{name: "empty", expr: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCronExpression(tt.expr)
+			if tt.wantValid {
+				if err != nil {
+					t.Errorf("expected %q to be valid, got: %v", tt.expr, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected %q to be invalid", tt.expr)
+			}
+			if tt.wantField != "" && err.Field != tt.wantField {
+				t.Errorf("expected error in field %q, got %q (%v)", tt.wantField, err.Field, err)
+			}
+		})
+	}
+}
+
+func TestValidateCronExpressionOffset(t *testing.T) {
+	err := ValidateCronExpression("0  99 * * *")
+	if err == nil {
+		t.Fatal("expected error for invalid hour")
+	}
+	if err.Offset != 3 {
+		t.Errorf("expected offset 3 for hour field, got %d", err.Offset)
+	}
+}
+
+func TestValidateJobConfigCronFieldPosition(t *testing.T) {
+	content := []byte(`job "rotate-secrets" {
+  schedule = "0 99 * * *"
+  script = "echo test"
+
+  runner {
+    type = "vm"
+    tags = ["privileged"]
+  }
+}
+`)
+
+	parser := NewParser()
+	config, err := parser.Parse(content, "test.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	result := NewValidator(config).Validate()
+	if result.IsValid() {
+		t.Fatal("Expected validation to fail for out-of-range hour")
+	}
+
+	var scheduleErr *ValidationError
+	for _, e := range result.Errors {
+		if e.Field == "schedule" {
+			scheduleErr = e
+			break
+		}
+	}
+	if scheduleErr == nil {
+		t.Fatalf("Expected validation error for 'schedule' field, got: %v", result.Error())
+	}
+
+	// `  schedule = "` is 14 characters, the hour field starts 2 bytes into the string
+	if scheduleErr.Position.Line != 2 || scheduleErr.Position.Column != 17 {
+		t.Errorf("Expected error at 2:17, got %d:%d", scheduleErr.Position.Line, scheduleErr.Position.Column)
+	}
+}
@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultsDirName is the Nest directory holding the settings every Egg
+// inherits, in DefaultsFileName
+const DefaultsDirName = "Defaults"
+
+// DefaultsFileName is the file of DefaultsDirName declaring the defaults
+// block
+const DefaultsFileName = "config.fly"
+
+// defaultsBlockType is the block of Defaults/config.fly:
+//
+//	defaults {
+//	  cloud {
+//	    provider = "yandex"
+//	    region   = "ru-central1-a"
+//	  }
+//
+//	  runner {
+//	    idle_timeout = "10m"
+//	  }
+//	}
+const defaultsBlockType = "defaults"
+
+// defaultBlocks are the blocks of an egg the defaults block may set
+var defaultBlocks = []string{"cloud", "resources", "runner"}
+
+// DefaultsSchema describes the defaults block. Its attributes are the
+// optional versions of those of an egg, since each egg completes them.
+var DefaultsSchema = &BlockSchema{
+	Type:        defaultsBlockType,
+	Description: "Settings every egg of the Nest inherits unless it sets them",
+	Blocks: []NestedBlockSchema{
+		{Schema: optionalSchema(cloudSchema)},
+		{Schema: optionalSchema(resourcesSchema)},
+		{Schema: optionalSchema(runnerSchema)},
+	},
+}
+
+// optionalSchema returns a copy of schema whose attributes are all optional
+func optionalSchema(schema *BlockSchema) *BlockSchema {
+	optional := *schema
+	optional.Attributes = make([]AttributeSchema, len(schema.Attributes))
+	for i, attr := range schema.Attributes {
+		attr.Required = false
+		optional.Attributes[i] = attr
+	}
+	return &optional
+}
+
+// ApplyDefaults merges the cloud, resources and runner blocks of defaults
+// into every egg of config. Attributes an egg sets take precedence, and the
+// inherited values keep their position in the defaults file so validation
+// errors and gosling explain point at it. An egg choosing a resources preset
+// does not inherit cpu, memory and disk, which the preset sets.
+func ApplyDefaults(config *Config, defaults *Block) {
+	for i := range config.Blocks {
+		egg := &config.Blocks[i]
+		if egg.Type != "egg" {
+			continue
+		}
+		for _, blockType := range defaultBlocks {
+			inherited, ok := defaults.GetBlock(blockType)
+			if !ok {
+				continue
+			}
+			// Eggs may change their blocks, as presets do, so each gets a copy
+			copied := cloneBlock(*withoutPresetSizes(inherited, egg))
+			if own, ok := egg.GetBlock(blockType); ok {
+				*own = mergeBlock(copied, *own)
+			} else {
+				egg.Blocks = append(egg.Blocks, copied)
+			}
+		}
+	}
+}
+
+// withoutPresetSizes returns the inherited resources block without cpu,
+// memory and disk when egg picks a preset, so the preset sets them
+func withoutPresetSizes(inherited *Block, egg *Block) *Block {
+	if inherited.Type != "resources" {
+		return inherited
+	}
+	resources, ok := egg.GetBlock("resources")
+	if !ok {
+		return inherited
+	}
+	if _, ok := resources.GetAttribute("preset"); !ok {
+		return inherited
+	}
+	trimmed := *inherited
+	trimmed.Attributes = make(map[string]Value, len(inherited.Attributes))
+	for name, val := range inherited.Attributes {
+		if name != "cpu" && name != "memory" && name != "disk" {
+			trimmed.Attributes[name] = val
+		}
+	}
+	return &trimmed
+}
+
+// cloneBlock returns a copy of block sharing no attribute maps with it
+func cloneBlock(block Block) Block {
+	clone := block
+	clone.Attributes = make(map[string]Value, len(block.Attributes))
+	for name, val := range block.Attributes {
+		clone.Attributes[name] = val
+	}
+	clone.Blocks = make([]Block, len(block.Blocks))
+	for i, nested := range block.Blocks {
+		clone.Blocks[i] = cloneBlock(nested)
+	}
+	return clone
+}
+
+// LoadDefaults returns the defaults block of a Defaults/config.fly, or nil
+// if the file does not exist
+func LoadDefaults(filename string) (*Block, error) {
+	return NewParser().loadDefaults(filename)
+}
+
+func (p *Parser) loadDefaults(filename string) (*Block, error) {
+	content, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	config, err := p.parse(content, filename, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse defaults file: %w", err)
+	}
+	var defaults *Block
+	for i := range config.Blocks {
+		block := &config.Blocks[i]
+		if block.Type != defaultsBlockType {
+			return nil, fmt.Errorf("%s: unexpected block %q in defaults file (expected 'defaults')", block.Position, block.Type)
+		}
+		if defaults != nil {
+			return nil, fmt.Errorf("%s: defaults already declared at %s", block.Position, defaults.Position)
+		}
+		defaults = block
+	}
+	return defaults, nil
+}
+
+// findDefaultsFile returns the Defaults/config.fly of the Nest containing
+// filename, unless filename is that file
+func findDefaultsFile(filename string) (string, bool) {
+	dir, ok := findNestDir(filename, DefaultsDirName)
+	if !ok {
+		return "", false
+	}
+	path := filepath.Join(dir, DefaultsFileName)
+	if abs, err := filepath.Abs(filename); err == nil && abs == path {
+		return "", false
+	}
+	return path, true
+}
+
+// applyNestDefaults applies the defaults of the Nest containing filename,
+// which are loaded once per Nest. Environment overlays inherit nothing:
+// they are merged over their base file, which does.
+func (p *Parser) applyNestDefaults(config *Config, filename string) error {
+	if basePath, _, ok := SplitOverlayPath(filename); ok && isFile(basePath) {
+		return nil
+	}
+	path, ok := findDefaultsFile(filename)
+	if !ok {
+		return nil
+	}
+	defaults, ok := p.defaults[path]
+	if !ok {
+		var err error
+		if defaults, err = p.loadDefaults(path); err != nil {
+			return err
+		}
+		if p.defaults == nil {
+			p.defaults = make(map[string]*Block)
+		}
+		p.defaults[path] = defaults
+	}
+	if defaults != nil {
+		ApplyDefaults(config, defaults)
+	}
+	return nil
+}
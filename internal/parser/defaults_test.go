@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const nestDefaults = `
+defaults {
+  cloud {
+    provider = "aws"
+    region   = "eu-west-1"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    idle_timeout = "15m"
+  }
+}
+`
+
+// attributeOf returns the attribute name of the blockType block of the first
+// egg of config
+func attributeOf(t *testing.T, config *Config, blockType, name string) Value {
+	t.Helper()
+	block, ok := config.Blocks[0].GetBlock(blockType)
+	if !ok {
+		t.Fatalf("%s block missing", blockType)
+	}
+	val, ok := block.GetAttribute(name)
+	if !ok {
+		t.Fatalf("%s.%s missing", blockType, name)
+	}
+	return val
+}
+
+func TestNestDefaults(t *testing.T) {
+	root := t.TempDir()
+	defaultsPath := filepath.Join(root, DefaultsDirName, DefaultsFileName)
+	writeFlyFile(t, defaultsPath, nestDefaults)
+	configPath := filepath.Join(root, "Eggs", "my-app", "config.fly")
+	writeFlyFile(t, configPath, presetEgg("vm", "yandex", "ru-central1-a", "    cpu = 4"))
+	presetPath := filepath.Join(root, "Eggs", "sized", "config.fly")
+	writeFlyFile(t, presetPath, presetEgg("vm", "yandex", "ru-central1-a", `    preset = "small"`))
+
+	p := NewParser()
+	config, err := p.ParseFile(configPath)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	// The egg's own attributes take precedence
+	provider := attributeOf(t, config, "cloud", "provider")
+	if got, _ := provider.AsString(); got != "yandex" {
+		t.Errorf("expected the egg's provider, got %q", got)
+	}
+	cpu := attributeOf(t, config, "resources", "cpu")
+	if got, _ := cpu.AsInt(); got != 4 {
+		t.Errorf("expected the egg's cpu, got %d", got)
+	}
+	// The others are inherited, positioned in the defaults file
+	memory := attributeOf(t, config, "resources", "memory")
+	if got, _ := memory.AsInt(); got != 4096 {
+		t.Errorf("expected the default memory, got %d", got)
+	}
+	if memory.Position.File != defaultsPath || memory.Position.Line != 10 {
+		t.Errorf("expected memory positioned at %s:10, got %s", defaultsPath, memory.Position)
+	}
+	idleTimeout := attributeOf(t, config, "runner", "idle_timeout")
+	if got, _ := idleTimeout.AsString(); got != "15m" {
+		t.Errorf("expected the default idle_timeout, got %q", got)
+	}
+	if result := NewValidator(config).Validate(); !result.IsValid() {
+		t.Errorf("expected the merged egg to be valid, got %v", result)
+	}
+
+	// A preset sets cpu, memory and disk instead of the defaults
+	config, err = p.ParseFile(presetPath)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if got, want := eggResources(t, config), BuiltinPresets()["small"].Resources("yandex", "vm"); got != want {
+		t.Errorf("expected the small preset %+v, got %+v", want, got)
+	}
+
+	// Applying the defaults leaves them unchanged for the next egg
+	defaults, err := LoadDefaults(defaultsPath)
+	if err != nil {
+		t.Fatalf("LoadDefaults failed: %v", err)
+	}
+	if _, err := LoadDefaults(filepath.Join(root, "missing.fly")); err != nil {
+		t.Errorf("expected a missing defaults file to be ignored, got %v", err)
+	}
+	egg := &Config{Blocks: []Block{{Type: "egg", Labels: []string{"other"}, Attributes: map[string]Value{}}}}
+	ApplyDefaults(egg, defaults)
+	resources, _ := egg.Blocks[0].GetBlock("resources")
+	resources.Attributes["cpu"] = Value{Type: NumberType, Raw: float64(64)}
+	inherited, _ := defaults.GetBlock("resources")
+	if cpu := inherited.Attributes["cpu"]; cpu.String() != "2" {
+		t.Errorf("expected the defaults to keep cpu = 2, got %s", cpu.String())
+	}
+}
+
+func TestLoadDefaultsErrors(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		wantErr string
+	}{
+		"other block": {
+			content: "egg \"my-app\" {\n  type = \"vm\"\n}\n",
+			wantErr: `unexpected block "egg" in defaults file`,
+		},
+		"declared twice": {
+			content: "defaults {\n}\n\ndefaults {\n}\n",
+			wantErr: "defaults already declared",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultsFileName)
+			writeFlyFile(t, path, tt.content)
+			if _, err := LoadDefaults(path); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Deprecation marks an attribute or nested block that is being phased out
+// of the .fly schema. Validation warns about it with CodeDeprecated, and
+// Migrate rewrites it when the deprecation has a Rewrite.
+type Deprecation struct {
+	// Replacement tells what to use instead
+	Replacement string `json:"replacement"`
+	// RemovedIn is the gosling version that stops accepting it, if decided
+	RemovedIn string `json:"removed_in,omitempty"`
+	// Rewrite replaces the deprecated attribute or block in the source.
+	// path runs from the top-level block to the block holding it.
+	Rewrite func(path []*hclwrite.Block) error `json:"-"`
+}
+
+// message describes the deprecation of name
+func (d *Deprecation) message(name string) string {
+	msg := name + " is deprecated"
+	if d.RemovedIn != "" {
+		msg += " and will be removed in gosling " + d.RemovedIn
+	}
+	return msg + ": " + d.Replacement
+}
+
+// Migrate upgrades .fly source to SchemaVersion and rewrites the deprecated
+// attributes and blocks whose deprecation has a Rewrite, keeping comments.
+// It returns the new source, formatted, and the path of each rewritten
+// attribute or block, such as egg.resources.type.
+func Migrate(src []byte, filename string) ([]byte, []string, error) {
+	// Bare include directives are not HCL; they are put back afterwards
+	file, diags := hclwrite.ParseConfig(expandIncludeDirectives(src, filename), filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("failed to parse %s: %s", filename, diags.Error())
+	}
+	versioned := file.Body().GetAttribute(schemaVersionAttribute) != nil
+	migrated, err := upgradeSchema(file.Body())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to migrate %s: %w", filename, err)
+	}
+	upgraded := len(migrated) > 0
+	for _, block := range file.Body().Blocks() {
+		schema, ok := LookupSchema(block.Type())
+		if !ok {
+			continue
+		}
+		if err := migrateBlock([]*hclwrite.Block{block}, schema, &migrated); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate %s: %w", filename, err)
+		}
+	}
+	if len(migrated) == 0 {
+		return src, nil, nil
+	}
+	out := file.Bytes()
+	if upgraded && !versioned {
+		out = append([]byte(fmt.Sprintf("%s = %d\n\n", schemaVersionAttribute, SchemaVersion)), out...)
+	}
+	return collapseIncludeDirectives(hclwrite.Format(out), src, filename), migrated, nil
+}
+
+// migrateBlock rewrites the deprecations below the last block of path,
+// which schema describes
+func migrateBlock(path []*hclwrite.Block, schema *BlockSchema, migrated *[]string) error {
+	body := path[len(path)-1].Body()
+	names := make([]string, len(path))
+	for i, block := range path {
+		names[i] = block.Type()
+	}
+	prefix := strings.Join(names, ".") + "."
+
+	for i := range schema.Attributes {
+		attr := &schema.Attributes[i]
+		if attr.Deprecated == nil || attr.Deprecated.Rewrite == nil || body.GetAttribute(attr.Name) == nil {
+			continue
+		}
+		if err := attr.Deprecated.Rewrite(path); err != nil {
+			return fmt.Errorf("%s%s: %w", prefix, attr.Name, err)
+		}
+		*migrated = append(*migrated, prefix+attr.Name)
+	}
+
+	for _, nestedBlock := range body.Blocks() {
+		nested, ok := schema.NestedBlock(nestedBlock.Type())
+		if !ok {
+			if nested, ok = anyTypeBlock(schema); !ok {
+				continue
+			}
+		}
+		if d := nested.Deprecated; d != nil && d.Rewrite != nil {
+			if err := d.Rewrite(path); err != nil {
+				return fmt.Errorf("%s%s: %w", prefix, nestedBlock.Type(), err)
+			}
+			*migrated = append(*migrated, prefix+nestedBlock.Type())
+			continue
+		}
+		nestedPath := append(append([]*hclwrite.Block(nil), path...), nestedBlock)
+		if err := migrateBlock(nestedPath, nested.Schema, migrated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// anyTypeBlock returns the entry of schema matching nested blocks of every
+// type, if it has one
+func anyTypeBlock(schema *BlockSchema) (*NestedBlockSchema, bool) {
+	for i := range schema.Blocks {
+		if schema.Blocks[i].AnyType {
+			return &schema.Blocks[i], true
+		}
+	}
+	return nil, false
+}
+
+// moveResourcesType rewrites the deprecated resources.type: the type of the
+// egg or eggsbucket, which it used to override, is the one that applies
+func moveResourcesType(path []*hclwrite.Block) error {
+	top, resources := path[0].Body(), path[len(path)-1].Body()
+	if top.GetAttribute("type") == nil {
+		top.SetAttributeRaw("type", resources.GetAttribute("type").Expr().BuildTokens(nil))
+	}
+	resources.RemoveAttribute("type")
+	return nil
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	src := []byte(`schema_version = 2
+
+egg "my-app" {
+  type = "vm"
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+    type   = "serverless" # ignored
+  }
+}
+
+eggsbucket "shared" {
+  resources {
+    # Overrides nothing
+    type = "vm"
+  }
+}
+`)
+
+	updated, migrated, err := Migrate(src, "config.fly")
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if strings.Join(migrated, ",") != "egg.resources.type,eggsbucket.resources.type" {
+		t.Errorf("unexpected migrations %v", migrated)
+	}
+	want := `schema_version = 2
+
+egg "my-app" {
+  type = "vm"
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+}
+
+eggsbucket "shared" {
+  resources {
+  }
+  type = "vm"
+}
+`
+	if string(updated) != want {
+		t.Errorf("unexpected migrated source:\n%s", updated)
+	}
+
+	if _, migrated, err := Migrate(updated, "config.fly"); err != nil || len(migrated) != 0 {
+		t.Errorf("expected nothing left to migrate, got %v, %v", migrated, err)
+	}
+}
+
+func TestDeprecationMessage(t *testing.T) {
+	attr, _ := resourcesSchema.Attribute("type")
+	want := "type is deprecated and will be removed in gosling 2.0: it is ignored; set type on the egg or eggsbucket block"
+	if got := attr.Deprecated.message("type"); got != want {
+		t.Errorf("message() = %q, want %q", got, want)
+	}
+}
+
+func TestMigrateKeepsIncludeDirectives(t *testing.T) {
+	src := []byte(`include "../_shared/runner.fly"
+include "../_shared/network.fly" {}
+
+egg "my-app" {
+  type = "vm"
+
+  resources {
+    cpu  = 2
+    type = "vm"
+  }
+}
+`)
+
+	updated, migrated, err := Migrate(src, "config.fly")
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(migrated) == 0 {
+		t.Fatal("expected a migration")
+	}
+	for _, line := range []string{"include \"../_shared/runner.fly\"\n", "include \"../_shared/network.fly\" {}\n"} {
+		if !strings.Contains(string(updated), line) {
+			t.Errorf("expected %q to be kept, got:\n%s", line, updated)
+		}
+	}
+	if strings.Contains(string(updated), "type = \"vm\"\n  }") {
+		t.Errorf("expected resources.type to be migrated, got:\n%s", updated)
+	}
+}
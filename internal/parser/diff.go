@@ -0,0 +1,218 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeKind describes how a value differs between two configurations
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change is a single attribute-level difference between two configurations
+type Change struct {
+	// Block identifies the top-level block, e.g. egg "my-app"
+	Block string `json:"block"`
+	// Path is the dotted attribute path inside Block, e.g. resources.memory.
+	// It names a nested block when an empty block was added or removed, and
+	// is empty when an empty top-level block was.
+	Path string     `json:"path,omitempty"`
+	Kind ChangeKind `json:"kind"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = c.Block
+	}
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", path, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", path, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %s → %s", path, c.Old, c.New)
+	}
+}
+
+// Diff compares two configurations structurally and returns the differences
+// attribute by attribute. Blocks are matched by type and labels; repeated
+// blocks with the same type and labels are matched in order. Either config
+// may be nil, which is treated as empty.
+func Diff(old, new *Config) []Change {
+	var oldBlocks, newBlocks []Block
+	if old != nil {
+		oldBlocks = old.Blocks
+	}
+	if new != nil {
+		newBlocks = new.Blocks
+	}
+
+	var changes []Change
+	for _, pair := range pairBlocks(oldBlocks, newBlocks) {
+		ref := pair.block().Type
+		for _, label := range pair.block().Labels {
+			ref += fmt.Sprintf(" %q", label)
+		}
+		if pair.index > 0 {
+			ref += fmt.Sprintf(" #%d", pair.index+1)
+		}
+		before := len(changes)
+		changes = diffBlock(changes, ref, "", pair.old, pair.new)
+		if len(changes) == before && (pair.old == nil || pair.new == nil) {
+			// An empty block was added or removed
+			changes = append(changes, blockChange(ref, "", pair))
+		}
+	}
+	return changes
+}
+
+// blockPair holds the two versions of a block; either side may be nil
+type blockPair struct {
+	old, new *Block
+	// index distinguishes repeated blocks with the same type and labels
+	index int
+}
+
+func (p blockPair) block() *Block {
+	if p.new != nil {
+		return p.new
+	}
+	return p.old
+}
+
+// pairBlocks matches sibling blocks by type and labels, keeping the order of
+// old followed by blocks that only exist in new
+func pairBlocks(old, new []Block) []blockPair {
+	key := func(b *Block) string {
+		return b.Type + "\x00" + strings.Join(b.Labels, "\x00")
+	}
+
+	newByKey := make(map[string][]*Block)
+	for i := range new {
+		k := key(&new[i])
+		newByKey[k] = append(newByKey[k], &new[i])
+	}
+
+	var pairs []blockPair
+	oldCount := make(map[string]int)
+	for i := range old {
+		k := key(&old[i])
+		idx := oldCount[k]
+		oldCount[k]++
+		pair := blockPair{old: &old[i], index: idx}
+		if idx < len(newByKey[k]) {
+			pair.new = newByKey[k][idx]
+		}
+		pairs = append(pairs, pair)
+	}
+
+	newCount := make(map[string]int)
+	for i := range new {
+		k := key(&new[i])
+		idx := newCount[k]
+		newCount[k]++
+		if idx >= oldCount[k] {
+			pairs = append(pairs, blockPair{new: &new[i], index: idx})
+		}
+	}
+	return pairs
+}
+
+// diffBlock appends the differences between two versions of a block
+func diffBlock(changes []Change, ref, prefix string, old, new *Block) []Change {
+	var oldAttrs, newAttrs map[string]Value
+	var oldBlocks, newBlocks []Block
+	if old != nil {
+		oldAttrs, oldBlocks = old.Attributes, old.Blocks
+	}
+	if new != nil {
+		newAttrs, newBlocks = new.Attributes, new.Blocks
+	}
+
+	names := make([]string, 0, len(oldAttrs)+len(newAttrs))
+	for name := range oldAttrs {
+		names = append(names, name)
+	}
+	for name := range newAttrs {
+		if _, ok := oldAttrs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		oldVal, inOld := oldAttrs[name]
+		newVal, inNew := newAttrs[name]
+		changes = diffValue(changes, ref, prefix+name, oldVal, newVal, inOld, inNew)
+	}
+
+	for _, pair := range pairBlocks(oldBlocks, newBlocks) {
+		path := prefix + pair.block().Type
+		for _, label := range pair.block().Labels {
+			path += "." + label
+		}
+		if pair.index > 0 {
+			path += fmt.Sprintf("[%d]", pair.index)
+		}
+		before := len(changes)
+		changes = diffBlock(changes, ref, path+".", pair.old, pair.new)
+		if len(changes) == before && (pair.old == nil || pair.new == nil) {
+			changes = append(changes, blockChange(ref, path, pair))
+		}
+	}
+
+	return changes
+}
+
+// diffValue appends the differences between two versions of an attribute.
+// Maps are compared key by key; other values are compared as a whole.
+func diffValue(changes []Change, ref, path string, old, new Value, inOld, inNew bool) []Change {
+	switch {
+	case inOld && !inNew:
+		return append(changes, Change{Block: ref, Path: path, Kind: ChangeRemoved, Old: old.String()})
+	case !inOld && inNew:
+		return append(changes, Change{Block: ref, Path: path, Kind: ChangeAdded, New: new.String()})
+	}
+
+	if old.Type == MapType && new.Type == MapType {
+		oldMap, newMap := old.Raw.(map[string]Value), new.Raw.(map[string]Value)
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys = append(keys, k)
+		}
+		for k := range newMap {
+			if _, ok := oldMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			oldVal, inOldMap := oldMap[k]
+			newVal, inNewMap := newMap[k]
+			changes = diffValue(changes, ref, path+"."+k, oldVal, newVal, inOldMap, inNewMap)
+		}
+		return changes
+	}
+
+	if oldStr, newStr := old.String(), new.String(); oldStr != newStr {
+		changes = append(changes, Change{Block: ref, Path: path, Kind: ChangeModified, Old: oldStr, New: newStr})
+	}
+	return changes
+}
+
+// blockChange records that an empty block was added or removed
+func blockChange(ref, path string, pair blockPair) Change {
+	if pair.old == nil {
+		return Change{Block: ref, Path: path, Kind: ChangeAdded, New: "{}"}
+	}
+	return Change{Block: ref, Path: path, Kind: ChangeRemoved, Old: "{}"}
+}
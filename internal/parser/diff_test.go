@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func mustParse(t *testing.T, filename, content string) *Config {
+	t.Helper()
+	config, err := NewParser().Parse([]byte(content), filename)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", filename, err)
+	}
+	return config
+}
+
+func TestDiffAttributeChanges(t *testing.T) {
+	old := mustParse(t, "old.fly", `
+egg "my-app" {
+  type = "vm"
+
+  resources {
+    cpu    = 2
+    memory = 4096
+  }
+
+  runner {
+    tags = ["docker"]
+  }
+
+  environment {
+    LOG_LEVEL = "info"
+  }
+}
+`)
+	// Same content reformatted and reordered, plus real changes
+	new := mustParse(t, "new.fly", `
+egg "my-app" {
+  resources {
+    memory = 8192
+    cpu = 2
+  }
+  type = "vm"
+
+  runner {
+    tags       = ["docker", "linux"]
+    concurrent = 4
+  }
+}
+`)
+
+	want := []Change{
+		{Block: `egg "my-app"`, Path: "resources.memory", Kind: ChangeModified, Old: "4096", New: "8192"},
+		{Block: `egg "my-app"`, Path: "runner.concurrent", Kind: ChangeAdded, New: "4"},
+		{Block: `egg "my-app"`, Path: "runner.tags", Kind: ChangeModified, Old: `["docker"]`, New: `["docker", "linux"]`},
+		{Block: `egg "my-app"`, Path: "environment.LOG_LEVEL", Kind: ChangeRemoved, Old: `"info"`},
+	}
+	if got := Diff(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := want[0].String(); got != "~ resources.memory: 4096 → 8192" {
+		t.Errorf("unexpected change string %q", got)
+	}
+}
+
+func TestDiffIdenticalConfigs(t *testing.T) {
+	content := `
+job "backup" {
+  schedule = "0 2 * * *"
+  labels   = { "team" = "ops", "tier" = "1" }
+}
+`
+	if changes := Diff(mustParse(t, "a.fly", content), mustParse(t, "b.fly", content)); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestDiffBlocksAddedAndRemoved(t *testing.T) {
+	old := mustParse(t, "old.fly", `
+egg "a" {
+  type = "vm"
+}
+
+egg "b" {
+  type = "vm"
+}
+`)
+	new := mustParse(t, "new.fly", `
+egg "a" {
+  type = "vm"
+  labels = { "team" = "ci" }
+}
+
+egg "c" {
+  type = "serverless"
+}
+`)
+
+	want := []Change{
+		{Block: `egg "a"`, Path: "labels", Kind: ChangeAdded, New: `{team = "ci"}`},
+		{Block: `egg "b"`, Path: "type", Kind: ChangeRemoved, Old: `"vm"`},
+		{Block: `egg "c"`, Path: "type", Kind: ChangeAdded, New: `"serverless"`},
+	}
+	if got := Diff(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// A nil config is treated as empty
+	if got := Diff(nil, old); len(got) != 2 || got[0].Kind != ChangeAdded {
+		t.Errorf("expected every attribute added, got %+v", got)
+	}
+}
+
+func TestDiffMapKeys(t *testing.T) {
+	old := mustParse(t, "old.fly", `
+job "build" {
+  labels = { "team" = "ci", "tier" = "1" }
+}
+`)
+	new := mustParse(t, "new.fly", `
+job "build" {
+  labels = { "team" = "platform", "region" = "eu" }
+}
+`)
+
+	want := []Change{
+		{Block: `job "build"`, Path: "labels.region", Kind: ChangeAdded, New: `"eu"`},
+		{Block: `job "build"`, Path: "labels.team", Kind: ChangeModified, Old: `"ci"`, New: `"platform"`},
+		{Block: `job "build"`, Path: "labels.tier", Kind: ChangeRemoved, Old: `"1"`},
+	}
+	if got := Diff(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() =\n%+v\nwant\n%+v", got, want)
+	}
+}
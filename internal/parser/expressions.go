@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// variableBlockType declares a value expressions refer to as var.<name>:
+//
+//	variable "base_tags" {
+//	  default = ["docker", "linux"]
+//	}
+//
+// Variables are visible in the file declaring them and in the files
+// including it; the including file wins on conflicts.
+const variableBlockType = "variable"
+
+// functions are the functions expressions in .fly files may call
+var functions = map[string]function.Function{
+	"abs":        stdlib.AbsoluteFunc,
+	"ceil":       stdlib.CeilFunc,
+	"coalesce":   stdlib.CoalesceFunc,
+	"concat":     stdlib.ConcatFunc,
+	"contains":   stdlib.ContainsFunc,
+	"distinct":   stdlib.DistinctFunc,
+	"flatten":    stdlib.FlattenFunc,
+	"floor":      stdlib.FloorFunc,
+	"format":     stdlib.FormatFunc,
+	"join":       stdlib.JoinFunc,
+	"keys":       stdlib.KeysFunc,
+	"length":     stdlib.LengthFunc,
+	"lookup":     stdlib.LookupFunc,
+	"lower":      stdlib.LowerFunc,
+	"max":        stdlib.MaxFunc,
+	"merge":      stdlib.MergeFunc,
+	"min":        stdlib.MinFunc,
+	"replace":    stdlib.ReplaceFunc,
+	"split":      stdlib.SplitFunc,
+	"title":      stdlib.TitleFunc,
+	"trimprefix": stdlib.TrimPrefixFunc,
+	"trimspace":  stdlib.TrimSpaceFunc,
+	"trimsuffix": stdlib.TrimSuffixFunc,
+	"upper":      stdlib.UpperFunc,
+	"values":     stdlib.ValuesFunc,
+}
+
+// parseVariables evaluates the defaults of the variable blocks among blocks.
+// Defaults may call functions but not refer to other variables.
+func parseVariables(blocks []*hclsyntax.Block) (map[string]cty.Value, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	variables := make(map[string]cty.Value)
+	declared := make(map[string]bool)
+	ctx := &hcl.EvalContext{Functions: functions}
+	for _, block := range blocks {
+		if block.Type != variableBlockType {
+			continue
+		}
+		rng := block.DefRange()
+		if len(block.Labels) != 1 || !hclsyntax.ValidIdentifier(block.Labels[0]) {
+			diags = append(diags, newDiagnostic(rng, CodeLabels, "Invalid variable block", `A variable block takes one label, its name, made of letters, digits, "_" and "-", e.g. variable "base_tags".`))
+			continue
+		}
+		name := block.Labels[0]
+		if declared[name] {
+			diags = append(diags, newDiagnostic(rng, CodeDuplicateBlock, "Duplicate variable", fmt.Sprintf("The variable %q is already declared in this file.", name)))
+			continue
+		}
+		declared[name] = true
+
+		for _, nested := range block.Body.Blocks {
+			diags = append(diags, newDiagnostic(nested.DefRange(), CodeUnknown, "Unexpected block", "A variable block only sets default and description."))
+		}
+		for attrName, attr := range block.Body.Attributes {
+			if attrName != "default" && attrName != "description" {
+				diags = append(diags, newDiagnostic(attr.NameRange, CodeUnknown, "Unexpected attribute", "A variable block only sets default and description."))
+			}
+		}
+		attr, ok := block.Body.Attributes["default"]
+		if !ok {
+			diags = append(diags, newDiagnostic(rng, CodeMissingAttribute, "Missing variable default", fmt.Sprintf("The variable %q must set default.", name)))
+			continue
+		}
+		val, valDiags := attr.Expr.Value(ctx)
+		diags = append(diags, expressionDiagnostics(valDiags)...)
+		if !valDiags.HasErrors() {
+			variables[name] = val
+		}
+	}
+	return variables, diags
+}
+
+// newDiagnostic reports an error at rng with a diagnostic code
+func newDiagnostic(rng hcl.Range, code, summary, detail string) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  &rng,
+		Extra:    diagnosticCode(code),
+	}
+}
+
+// evalContext is the context expressions are evaluated in. Without complete
+// variables, for a file whose includes could not be read, references to
+// variables evaluate to unknown values instead of failing.
+func evalContext(variables map[string]cty.Value, complete bool) *hcl.EvalContext {
+	vars := cty.EmptyObjectVal
+	if !complete {
+		vars = cty.DynamicVal
+	} else if len(variables) > 0 {
+		vars = cty.ObjectVal(variables)
+	}
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{"var": vars},
+		Functions: functions,
+	}
+}
+
+// evaluate computes the value of an expression such as var.cpu * 1024 or
+// concat(var.base_tags, ["gpu"]). Unknown values, from a file that already
+// has errors, are left out without a diagnostic.
+func (p *Parser) evaluate(expr hclsyntax.Expression, ctx *hcl.EvalContext, pos Position) (*Value, hcl.Diagnostics) {
+	if diags := undeclaredVariables(expr, ctx); diags.HasErrors() {
+		return nil, diags
+	}
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		return nil, expressionDiagnostics(diags)
+	}
+	if !val.IsWhollyKnown() {
+		return nil, nil
+	}
+	value, err := valueOf(val, pos)
+	if err != nil {
+		return nil, unsupported(expr, "Unsupported value", "The expression "+err.Error()+", which .fly files do not support.")
+	}
+	return value, nil
+}
+
+// ReferencedVariables returns the variables, as var.<name>, that the
+// attribute value at pos refers to, in source order. pos must be the
+// position of a Value from a file p has parsed; otherwise it returns nil.
+func (p *Parser) ReferencedVariables(pos Position) []string {
+	file, ok := p.parser.Files()[pos.File]
+	if !ok {
+		return nil
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	var names []string
+	seen := make(map[string]bool)
+	hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		attr, ok := node.(*hclsyntax.Attribute)
+		if !ok {
+			return nil
+		}
+		start := attr.Expr.Range().Start
+		if start.Line != pos.Line || start.Column != pos.Column {
+			return nil
+		}
+		for _, traversal := range attr.Expr.Variables() {
+			if traversal.RootName() != "var" || len(traversal) < 2 {
+				continue
+			}
+			if name, ok := traversal[1].(hcl.TraverseAttr); ok && !seen[name.Name] {
+				seen[name.Name] = true
+				names = append(names, "var."+name.Name)
+			}
+		}
+		return nil
+	})
+	return names
+}
+
+// undeclaredVariables reports the var.<name> references of expr to variables
+// that are not declared, which HCL would describe as missing attributes
+func undeclaredVariables(expr hclsyntax.Expression, ctx *hcl.EvalContext) hcl.Diagnostics {
+	vars := ctx.Variables["var"]
+	if !vars.Type().IsObjectType() {
+		return nil
+	}
+	var diags hcl.Diagnostics
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "var" || len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok || vars.Type().HasAttribute(attr.Name) {
+			continue
+		}
+		rng := traversal.SourceRange()
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Undeclared variable",
+			Detail:   fmt.Sprintf("There is no variable named %q; declare it with a variable block in this file or an included one.", attr.Name),
+			Subject:  &rng,
+			Extra:    diagnosticCode(CodeExpression),
+		})
+	}
+	return diags
+}
+
+// expressionDiagnostics codes the diagnostics of an evaluation
+func expressionDiagnostics(diags hcl.Diagnostics) hcl.Diagnostics {
+	for _, diag := range diags {
+		diag.Extra = diagnosticCode(CodeExpression)
+	}
+	return diags
+}
+
+// valueOf converts an evaluated value; every element is given pos
+func valueOf(val cty.Value, pos Position) (*Value, error) {
+	if val.IsNull() {
+		return nil, fmt.Errorf("evaluates to null")
+	}
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return &Value{Position: pos, Type: StringType, Raw: val.AsString()}, nil
+	case ty == cty.Number:
+		return &Value{Position: pos, Type: NumberType, Raw: numberOf(val.AsBigFloat(), "")}, nil
+	case ty == cty.Bool:
+		return &Value{Position: pos, Type: BoolType, Raw: val.True()}, nil
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		list := make([]Value, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			item, err := valueOf(elem, pos)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, *item)
+		}
+		return &Value{Position: pos, Type: ListType, Raw: list}, nil
+	case ty.IsMapType() || ty.IsObjectType():
+		m := make(map[string]Value, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			item, err := valueOf(elem, pos)
+			if err != nil {
+				return nil, err
+			}
+			m[key.AsString()] = *item
+		}
+		return &Value{Position: pos, Type: MapType, Raw: m}, nil
+	}
+	return nil, fmt.Errorf("evaluates to a %s", ty.FriendlyName())
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEvaluatesExpressions(t *testing.T) {
+	content := []byte(`
+variable "cpu" {
+  description = "vCPUs of every runner"
+  default     = 4
+}
+
+variable "base_tags" {
+  default = ["docker", "linux"]
+}
+
+egg "my-app" {
+  type = var.cpu > 2 ? "vm" : "serverless"
+
+  resources {
+    cpu    = var.cpu
+    memory = var.cpu * 1024
+    disk   = max(20, var.cpu * 5)
+  }
+
+  runner {
+    tags        = concat(var.base_tags, ["gpu"])
+    concurrent  = var.cpu / 2
+    description = format("%s runner with %d vCPUs", upper("my-app"), var.cpu)
+  }
+
+  gitlab {
+    server_name = "gitlab.${lower("EXAMPLE")}.com"
+  }
+}
+`)
+
+	p := NewParser()
+	config, err := p.Parse(content, "test.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(config.Blocks) != 1 {
+		t.Fatalf("expected variable blocks to be left out, got %d blocks", len(config.Blocks))
+	}
+	egg := &config.Blocks[0]
+	resources, _ := egg.GetBlock("resources")
+	runner, _ := egg.GetBlock("runner")
+	gitlab, _ := egg.GetBlock("gitlab")
+
+	for _, tt := range []struct {
+		block *Block
+		name  string
+		want  string
+	}{
+		{egg, "type", `"vm"`},
+		{resources, "cpu", "4"},
+		{resources, "memory", "4096"},
+		{resources, "disk", "20"},
+		{runner, "tags", `["docker", "linux", "gpu"]`},
+		{runner, "concurrent", "2"},
+		{runner, "description", `"MY-APP runner with 4 vCPUs"`},
+		{gitlab, "server_name", `"gitlab.example.com"`},
+	} {
+		val, ok := tt.block.GetAttribute(tt.name)
+		if !ok {
+			t.Errorf("%s: missing", tt.name)
+			continue
+		}
+		if got := val.String(); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	memory, _ := resources.GetAttribute("memory")
+	if memory.Position.Line != 16 || memory.Position.Column != 14 {
+		t.Errorf("expected the position of the expression, got %s", memory.Position)
+	}
+
+	tags, _ := runner.GetAttribute("tags")
+	if got := p.ReferencedVariables(tags.Position); len(got) != 1 || got[0] != "var.base_tags" {
+		t.Errorf("expected tags to refer to var.base_tags, got %v", got)
+	}
+	description, _ := runner.GetAttribute("description")
+	if got := p.ReferencedVariables(description.Position); len(got) != 1 || got[0] != "var.cpu" {
+		t.Errorf("expected description to refer to var.cpu once, got %v", got)
+	}
+	serverName, _ := gitlab.GetAttribute("server_name")
+	if got := p.ReferencedVariables(serverName.Position); got != nil {
+		t.Errorf("expected server_name to refer to no variables, got %v", got)
+	}
+}
+
+func TestParseIncludedVariables(t *testing.T) {
+	root := t.TempDir()
+	writeFlyFile(t, filepath.Join(root, "_shared", "vars.fly"), `
+variable "base_tags" {
+  default = ["docker", "linux"]
+}
+
+variable "memory" {
+  default = 2048
+}
+`)
+	configPath := filepath.Join(root, "my-app", "config.fly")
+	writeFlyFile(t, configPath, `include "../_shared/vars.fly"
+
+variable "memory" {
+  default = 8192
+}
+
+egg "my-app" {
+  resources {
+    memory = var.memory
+  }
+
+  runner {
+    tags = concat(var.base_tags, ["gpu"])
+  }
+}
+`)
+
+	config, err := NewParser().ParseFile(configPath)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	egg := &config.Blocks[0]
+	resources, _ := egg.GetBlock("resources")
+	if memory, _ := resources.GetAttribute("memory"); memory.String() != "8192" {
+		t.Errorf("expected the including file's variable to win, got %s", memory.String())
+	}
+	runner, _ := egg.GetBlock("runner")
+	if tags, _ := runner.GetAttribute("tags"); tags.String() != `["docker", "linux", "gpu"]` {
+		t.Errorf("expected the included variable, got %s", tags.String())
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	content := []byte(`
+variable "cpu" {
+  type    = "number"
+  default = 2
+}
+
+variable "cpu" {
+  default = 4
+}
+
+variable "memory" {
+}
+
+egg "my-app" {
+  resources {
+    cpu    = var.cpus
+    memory = var.cpu * "lots"
+    disk   = shout("20")
+  }
+}
+`)
+
+	_, err := NewParser().Parse(content, "test.fly")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected a SyntaxError, got %v", err)
+	}
+	var got []string
+	for _, diag := range syntaxErr.Diagnostics {
+		got = append(got, DiagnosticCode(diag))
+	}
+	want := []string{CodeUnknown, CodeDuplicateBlock, CodeMissingAttribute, CodeExpression, CodeExpression, CodeExpression}
+	if len(got) != len(want) {
+		t.Fatalf("expected codes %v, got %v:\n%v", want, got, err)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("diagnostic %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
@@ -2,36 +2,35 @@ package parser
 
 import "fmt"
 
-// ParseAndValidate parses a .fly file and validates it
-func ParseAndValidate(filename string) (*Config, error) {
+// ParseAndValidate parses a .fly file and validates it. The validation
+// result is returned whenever the file parses, so callers can report its
+// warnings, or its errors apart from the warnings; err is set when the file
+// fails to parse or to validate.
+func ParseAndValidate(filename string) (*Config, *ValidationResult, error) {
 	parser := NewParser()
 	config, err := parser.ParseFile(filename)
 	if err != nil {
-		return nil, fmt.Errorf("parse error: %w", err)
+		return nil, nil, fmt.Errorf("parse error: %w", err)
 	}
-
-	validator := NewValidator(config)
-	result := validator.Validate()
-	if !result.IsValid() {
-		return nil, fmt.Errorf("validation error: %w", result)
-	}
-
-	return config, nil
+	return validated(config)
 }
 
-// ParseAndValidateContent parses .fly content and validates it
-func ParseAndValidateContent(content []byte, filename string) (*Config, error) {
+// ParseAndValidateContent parses .fly content and validates it, returning
+// results as ParseAndValidate does
+func ParseAndValidateContent(content []byte, filename string) (*Config, *ValidationResult, error) {
 	parser := NewParser()
 	config, err := parser.Parse(content, filename)
 	if err != nil {
-		return nil, fmt.Errorf("parse error: %w", err)
+		return nil, nil, fmt.Errorf("parse error: %w", err)
 	}
+	return validated(config)
+}
 
-	validator := NewValidator(config)
-	result := validator.Validate()
+// validated validates config, returning it only if it is valid
+func validated(config *Config) (*Config, *ValidationResult, error) {
+	result := NewValidator(config).Validate()
 	if !result.IsValid() {
-		return nil, fmt.Errorf("validation error: %w", result)
+		return nil, result, fmt.Errorf("validation error: %w", result)
 	}
-
-	return config, nil
+	return config, result, nil
 }
@@ -0,0 +1,266 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// includeBlockType is the directive used to pull shared fragments into a file:
+//
+//	include "../_shared/defaults.fly"
+//
+// Included blocks act as defaults: a block in the including file is merged
+// over every included block of the same type whose labels match (or that has
+// no labels), with the including file winning on conflicts. Included blocks
+// that match nothing are added as-is.
+const includeBlockType = "include"
+
+// expandIncludeDirectives rewrites top-level `include "path"` lines into
+// `include "path" {}` blocks so they can be parsed as regular HCL. The HCL
+// lexer is used so heredocs and strings that merely contain the word
+// "include" are left untouched.
+func expandIncludeDirectives(content []byte, filename string) []byte {
+	var insertAt []int
+	for _, include := range topLevelIncludes(content, filename) {
+		if include.bare {
+			insertAt = append(insertAt, include.pathEnd)
+		}
+	}
+	if len(insertAt) == 0 {
+		return content
+	}
+
+	var buf bytes.Buffer
+	prev := 0
+	for _, offset := range insertAt {
+		buf.Write(content[prev:offset])
+		buf.WriteString(" {}")
+		prev = offset
+	}
+	buf.Write(content[prev:])
+	return buf.Bytes()
+}
+
+// collapseIncludeDirectives turns the empty include blocks of content back
+// into bare directives where the matching include of original, in order, was
+// written as one. It undoes expandIncludeDirectives for tools that rewrite
+// the expanded source.
+func collapseIncludeDirectives(content, original []byte, filename string) []byte {
+	before := topLevelIncludes(original, filename)
+	after := topLevelIncludes(content, filename)
+	if len(before) != len(after) {
+		return content
+	}
+	out := content
+	for i := len(after) - 1; i >= 0; i-- {
+		if before[i].bare && after[i].emptyEnd > 0 {
+			out = append(out[:after[i].pathEnd:after[i].pathEnd], out[after[i].emptyEnd:]...)
+		}
+	}
+	return out
+}
+
+// includeToken is a top-level include of a .fly file
+type includeToken struct {
+	pathEnd  int  // byte offset just after the closing quote of the path
+	bare     bool // written as a directive, without a body
+	emptyEnd int  // byte offset just after the "{}" of an empty body, or 0
+}
+
+// topLevelIncludes lexes content and returns its top-level includes in order
+func topLevelIncludes(content []byte, filename string) []includeToken {
+	tokens, diags := hclsyntax.LexConfig(content, filename, hcl.Pos{Line: 1, Column: 1, Byte: 0})
+	if diags.HasErrors() {
+		// Let the real parse report the syntax error
+		return nil
+	}
+
+	var includes []includeToken
+	depth := 0
+	lineStart := true
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.Type {
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen, hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			depth++
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen, hclsyntax.TokenTemplateSeqEnd:
+			depth--
+		}
+
+		if depth == 0 && lineStart && tok.Type == hclsyntax.TokenIdent && string(tok.Bytes) == includeBlockType &&
+			i+4 < len(tokens) &&
+			tokens[i+1].Type == hclsyntax.TokenOQuote &&
+			tokens[i+2].Type == hclsyntax.TokenQuotedLit &&
+			tokens[i+3].Type == hclsyntax.TokenCQuote {
+			include := includeToken{pathEnd: tokens[i+3].Range.End.Byte}
+			switch {
+			case endsLine(tokens[i+4]):
+				include.bare = true
+				i += 3
+				lineStart = false
+				includes = append(includes, include)
+				continue
+			case i+5 < len(tokens) && tokens[i+4].Type == hclsyntax.TokenOBrace && tokens[i+5].Type == hclsyntax.TokenCBrace:
+				include.emptyEnd = tokens[i+5].Range.End.Byte
+			}
+			includes = append(includes, include)
+		}
+
+		lineStart = endsLine(tok)
+	}
+	return includes
+}
+
+// endsLine reports whether tok terminates a line. Line comments include their
+// trailing newline, so they end the line too.
+func endsLine(tok hclsyntax.Token) bool {
+	switch tok.Type {
+	case hclsyntax.TokenNewline, hclsyntax.TokenEOF:
+		return true
+	case hclsyntax.TokenComment:
+		return bytes.HasSuffix(tok.Bytes, []byte("\n"))
+	}
+	return false
+}
+
+// resolveIncludes parses the files named by the include blocks. Their
+// blocks and variables are merged in order, later includes overriding
+// earlier ones. stack holds the absolute paths of the files currently being
+// included, for cycle detection.
+func (p *Parser) resolveIncludes(includes []Block, filename string, stack []string) (*Config, error) {
+	result := &Config{}
+	var includePaths []string
+	var includePositions []Position
+	for _, block := range includes {
+		if len(block.Labels) != 1 || len(block.Attributes) > 0 || len(block.Blocks) > 0 {
+			return nil, fmt.Errorf("%s: include must be written as include \"<path>\"", block.Position)
+		}
+		includePaths = append(includePaths, block.Labels[0])
+		includePositions = append(includePositions, block.Position)
+	}
+	if len(includePaths) == 0 {
+		return result, nil
+	}
+
+	absFile, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", filename, err)
+	}
+	stack = append(stack, absFile)
+
+	for i, includePath := range includePaths {
+		target := includePath
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(absFile), target)
+		}
+		target = filepath.Clean(target)
+
+		for j, seen := range stack {
+			if seen == target {
+				cycle := append(append([]string{}, stack[j:]...), target)
+				return nil, fmt.Errorf("%s: include cycle detected: %s", includePositions[i], strings.Join(cycle, " -> "))
+			}
+		}
+
+		content, err := os.ReadFile(target)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to include %q: %w", includePositions[i], includePath, err)
+		}
+		fragment, err := p.parse(content, target, stack)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to include %q: %w", includePositions[i], includePath, err)
+		}
+		// Later includes override earlier ones
+		result.Blocks = mergeBlockLists(result.Blocks, fragment.Blocks)
+		for name, val := range fragment.variables {
+			if result.variables == nil {
+				result.variables = make(map[string]cty.Value)
+			}
+			result.variables[name] = val
+		}
+	}
+	return result, nil
+}
+
+// mergeIncludedBlocks merges each local block over the included blocks it
+// matches. Included blocks that match no local block are appended.
+func mergeIncludedBlocks(included, local []Block) []Block {
+	used := make([]bool, len(included))
+	result := make([]Block, 0, len(local)+len(included))
+	for _, block := range local {
+		merged := block
+		for i, inc := range included {
+			if inc.Type != block.Type || (len(inc.Labels) > 0 && !labelsEqual(inc.Labels, block.Labels)) {
+				continue
+			}
+			merged = mergeBlock(inc, merged)
+			used[i] = true
+		}
+		result = append(result, merged)
+	}
+	for i, inc := range included {
+		if !used[i] {
+			result = append(result, inc)
+		}
+	}
+	return result
+}
+
+// mergeBlockLists merges two lists of sibling blocks: blocks with the same
+// type and labels are merged (override wins), others are kept in order
+func mergeBlockLists(base, override []Block) []Block {
+	result := make([]Block, 0, len(base)+len(override))
+	result = append(result, base...)
+	for _, block := range override {
+		merged := false
+		for i := range result {
+			if result[i].Type == block.Type && labelsEqual(result[i].Labels, block.Labels) {
+				result[i] = mergeBlock(result[i], block)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+// mergeBlock deep-merges override into base. Attributes in override replace
+// those in base; nested blocks are merged by type and labels.
+func mergeBlock(base, override Block) Block {
+	merged := Block{
+		Position:   override.Position,
+		Type:       override.Type,
+		Labels:     override.Labels,
+		Attributes: make(map[string]Value, len(base.Attributes)+len(override.Attributes)),
+	}
+	for name, val := range base.Attributes {
+		merged.Attributes[name] = val
+	}
+	for name, val := range override.Attributes {
+		merged.Attributes[name] = val
+	}
+	merged.Blocks = mergeBlockLists(base.Blocks, override.Blocks)
+	return merged
+}
+
+func labelsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFlyFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestParseIncludeMergesDefaults(t *testing.T) {
+	root := t.TempDir()
+	writeFlyFile(t, filepath.Join(root, "Eggs", "_shared", "defaults.fly"), `
+# Runner defaults shared by all eggs
+egg {
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags         = ["docker", "linux"]
+    concurrent   = 3
+    idle_timeout = "10m"
+  }
+}
+`)
+	configPath := filepath.Join(root, "Eggs", "my-app", "config.fly")
+	writeFlyFile(t, configPath, `include "../_shared/defaults.fly" # shared runner defaults
+
+egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  resources {
+    memory = 8192
+  }
+
+  gitlab {
+    project_id   = 12345
+    server_name  = "gitlab.com"
+    token_secret = "yc-lockbox://gitlab/runner-token"
+  }
+
+  environment {
+    NOTE = <<EOT
+include "not-a-directive"
+EOT
+  }
+}
+`)
+
+	config, err := NewParser().ParseFile(configPath)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if len(config.Blocks) != 1 {
+		t.Fatalf("expected 1 block after merging, got %d", len(config.Blocks))
+	}
+
+	egg := &config.Blocks[0]
+	if egg.Type != "egg" || len(egg.Labels) != 1 || egg.Labels[0] != "my-app" {
+		t.Fatalf("expected egg \"my-app\", got %s %v", egg.Type, egg.Labels)
+	}
+
+	resources, ok := egg.GetBlock("resources")
+	if !ok {
+		t.Fatal("expected resources block")
+	}
+	memoryVal, _ := resources.GetAttribute("memory")
+	if memory, _ := memoryVal.AsInt(); memory != 8192 {
+		t.Errorf("expected local memory 8192 to override default, got %d", memory)
+	}
+	cpuVal, ok := resources.GetAttribute("cpu")
+	if !ok {
+		t.Fatal("expected cpu to be inherited from defaults")
+	}
+	if !strings.HasSuffix(cpuVal.Position.File, "defaults.fly") {
+		t.Errorf("expected inherited attribute to point at defaults.fly, got %s", cpuVal.Position.File)
+	}
+
+	if _, ok := egg.GetBlock("runner"); !ok {
+		t.Error("expected runner block to be inherited from defaults")
+	}
+
+	result := NewValidator(config).Validate()
+	if !result.IsValid() {
+		t.Errorf("expected merged config to be valid: %v", result.Error())
+	}
+}
+
+func TestParseIncludeLabeledAndUnmatchedBlocks(t *testing.T) {
+	root := t.TempDir()
+	writeFlyFile(t, filepath.Join(root, "shared.fly"), `
+egg "other-app" {
+  type = "serverless"
+}
+
+egg "my-app" {
+  type = "serverless"
+  runner {
+    concurrent = 1
+  }
+}
+`)
+	configPath := filepath.Join(root, "config.fly")
+	writeFlyFile(t, configPath, `include "shared.fly"
+
+egg "my-app" {
+  type = "vm"
+}
+`)
+
+	config, err := NewParser().ParseFile(configPath)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if len(config.Blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(config.Blocks))
+	}
+
+	myApp := &config.Blocks[0]
+	typeVal, _ := myApp.GetAttribute("type")
+	if typeStr, _ := typeVal.AsString(); typeStr != "vm" {
+		t.Errorf("expected local type to win, got %q", typeStr)
+	}
+	if _, ok := myApp.GetBlock("runner"); !ok {
+		t.Error("expected runner block from matching labeled block")
+	}
+	if config.Blocks[1].Labels[0] != "other-app" {
+		t.Errorf("expected unmatched included block to be appended, got %v", config.Blocks[1].Labels)
+	}
+}
+
+func TestParseIncludeCycle(t *testing.T) {
+	root := t.TempDir()
+	writeFlyFile(t, filepath.Join(root, "a.fly"), "include \"b.fly\"\n")
+	writeFlyFile(t, filepath.Join(root, "b.fly"), "include \"a.fly\"\n")
+
+	_, err := NewParser().ParseFile(filepath.Join(root, "a.fly"))
+	if err == nil {
+		t.Fatal("expected include cycle error")
+	}
+	if !strings.Contains(err.Error(), "include cycle detected") {
+		t.Errorf("expected cycle error, got: %v", err)
+	}
+}
+
+func TestParseIncludeMissingFile(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "config.fly")
+	writeFlyFile(t, configPath, "include \"missing.fly\"\n")
+
+	_, err := NewParser().ParseFile(configPath)
+	if err == nil || !strings.Contains(err.Error(), `failed to include "missing.fly"`) {
+		t.Errorf("expected missing include error, got: %v", err)
+	}
+}
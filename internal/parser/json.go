@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// JSONExtension is the extension of .fly files written in the JSON syntax of
+// HCL, such as configurations generated by other tools
+const JSONExtension = ".fly.json"
+
+// IsFlyFile reports whether path is a .fly file, in either syntax
+func IsFlyFile(path string) bool {
+	return flyExtension(path) != ""
+}
+
+// flyExtension returns the .fly or .fly.json extension of path, or "" if it
+// has neither
+func flyExtension(path string) string {
+	switch {
+	case strings.HasSuffix(path, JSONExtension):
+		return JSONExtension
+	case filepath.Ext(path) == ".fly":
+		return ".fly"
+	}
+	return ""
+}
+
+// parseJSON parses a .fly.json file. JSON cannot tell a nested block from an
+// object attribute, so the block schemas decide: properties the schema
+// describes as blocks are blocks, the others are attributes. Variables,
+// includes and count or for_each are native syntax only.
+func (p *Parser) parseJSON(content []byte, filename string) (*Config, error) {
+	file, diags := p.parser.ParseJSON(content, filename)
+	if diags.HasErrors() {
+		return nil, p.syntaxError(diags)
+	}
+
+	topLevel := &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: schemaVersionAttribute}},
+	}
+	for _, blockType := range SchemaTypes() {
+		schema, _ := LookupSchema(blockType)
+		topLevel.Blocks = append(topLevel.Blocks, blockHeader(schema))
+	}
+	body, diags := file.Body.Content(topLevel)
+
+	config := &Config{
+		Position: Position{File: filename, Line: 1, Column: 1},
+		Blocks:   make([]Block, 0, len(body.Blocks)),
+	}
+	if attr, ok := body.Attributes[schemaVersionAttribute]; ok {
+		version, versionDiags := parseSchemaVersion(attr.Expr)
+		diags = append(diags, versionDiags...)
+		config.SchemaVersion = version
+		config.schemaVersionPos = positionOf(attr.Expr.Range())
+	}
+
+	ctx := evalContext(nil, true)
+	for _, hclBlock := range body.Blocks {
+		schema, _ := LookupSchema(hclBlock.Type)
+		block, blockDiags := jsonBlock(hclBlock, schema, ctx)
+		diags = append(diags, blockDiags...)
+		config.Blocks = append(config.Blocks, *block)
+	}
+	if diags.HasErrors() {
+		return nil, p.syntaxError(diags)
+	}
+	return config, nil
+}
+
+// jsonBlock converts a block of a .fly.json file described by schema
+func jsonBlock(hclBlock *hcl.Block, schema *BlockSchema, ctx *hcl.EvalContext) (*Block, hcl.Diagnostics) {
+	block := &Block{
+		Position:   positionOf(hclBlock.DefRange),
+		Type:       hclBlock.Type,
+		Labels:     hclBlock.Labels,
+		Attributes: make(map[string]Value),
+		Blocks:     make([]Block, 0),
+	}
+
+	bodySchema := &hcl.BodySchema{}
+	nestedSchemas := make(map[string]*BlockSchema)
+	for _, nested := range schema.Blocks {
+		if nested.AnyType {
+			continue
+		}
+		bodySchema.Blocks = append(bodySchema.Blocks, blockHeader(nested.Schema))
+		nestedSchemas[nested.Schema.Type] = nested.Schema
+	}
+	content, remain, diags := hclBlock.Body.PartialContent(bodySchema)
+
+	// The remaining properties are attributes, unless the schema takes
+	// nested blocks of any type, which are the object properties it does
+	// not describe as attributes
+	attrs, attrDiags := remain.JustAttributes()
+	diags = append(diags, attrDiags...)
+	anyType, hasAnyType := anyTypeBlock(schema)
+	anySchema := &hcl.BodySchema{}
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attr := attrs[name]
+		if hasAnyType {
+			if _, known := schema.Attribute(name); !known {
+				if val, valDiags := attr.Expr.Value(nil); !valDiags.HasErrors() && val.Type().IsObjectType() {
+					anySchema.Blocks = append(anySchema.Blocks, hcl.BlockHeaderSchema{Type: name})
+					continue
+				}
+			}
+		}
+		pos := positionOf(attr.Expr.Range())
+		val, valDiags := attr.Expr.Value(ctx)
+		if valDiags.HasErrors() {
+			diags = append(diags, expressionDiagnostics(valDiags)...)
+			continue
+		}
+		value, err := valueOf(val, pos)
+		if err != nil {
+			diags = append(diags, newDiagnostic(attr.Expr.Range(), CodeUnsupported, "Unsupported value",
+				"The value "+err.Error()+", which .fly files do not support."))
+			continue
+		}
+		block.Attributes[name] = *value
+	}
+
+	blocks := content.Blocks
+	if len(anySchema.Blocks) > 0 {
+		anyContent, _, anyDiags := remain.PartialContent(anySchema)
+		diags = append(diags, anyDiags...)
+		blocks = append(blocks, anyContent.Blocks...)
+	}
+	for _, nestedHCL := range blocks {
+		nestedSchema, ok := nestedSchemas[nestedHCL.Type]
+		if !ok {
+			nestedSchema = anyType.Schema
+		}
+		nested, nestedDiags := jsonBlock(nestedHCL, nestedSchema, ctx)
+		diags = append(diags, nestedDiags...)
+		block.Blocks = append(block.Blocks, *nested)
+	}
+	return block, diags
+}
+
+// blockHeader returns the HCL header of blocks described by schema
+func blockHeader(schema *BlockSchema) hcl.BlockHeaderSchema {
+	header := hcl.BlockHeaderSchema{Type: schema.Type}
+	if schema.Label != "" {
+		header.LabelNames = []string{"name"}
+	}
+	return header
+}
+
+// positionOf returns the start of a source range
+func positionOf(rng hcl.Range) Position {
+	return Position{File: rng.Filename, Line: rng.Start.Line, Column: rng.Start.Column}
+}
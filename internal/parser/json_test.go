@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	native := []byte(`
+schema_version = 2
+
+egg "my-app" {
+  type = "vm"
+
+  resources {
+    cpu    = 2
+    memory = 4096
+  }
+
+  runner {
+    tags       = ["docker"]
+    concurrent = 3
+  }
+
+  environment {
+    DEPLOY_ENV = "prod"
+  }
+}
+
+mothergoose {
+  message_queues {
+    webhook_queue {
+      name = "mothergoose-webhooks"
+    }
+  }
+}
+`)
+	jsonContent := []byte(`{
+  "schema_version": 2,
+  "egg": {
+    "my-app": {
+      "type": "vm",
+      "resources": {"cpu": 2, "memory": 4096},
+      "runner": {"tags": ["docker"], "concurrent": 3},
+      "environment": {"DEPLOY_ENV": "prod"}
+    }
+  },
+  "mothergoose": {
+    "message_queues": {
+      "webhook_queue": {"name": "mothergoose-webhooks"}
+    }
+  }
+}`)
+
+	want, err := NewParser().Parse(native, "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	got, err := NewParser().Parse(jsonContent, "config.fly.json")
+	if err != nil {
+		t.Fatalf("Parse of JSON failed: %v", err)
+	}
+	if changes := Diff(want, got); len(changes) != 0 {
+		t.Errorf("expected the JSON syntax to parse like the native one, got %v", changes)
+	}
+	if got.SchemaVersion != 2 {
+		t.Errorf("expected schema_version 2, got %d", got.SchemaVersion)
+	}
+	if pos := got.Blocks[0].Position; pos.File != "config.fly.json" || pos.Line != 4 {
+		t.Errorf("unexpected egg position %v", pos)
+	}
+
+	_, err = NewParser().Parse([]byte(`{"egg": {"my-app": {"resources": {"cpu": null}}}}`), "config.fly.json")
+	if err == nil || !strings.Contains(err.Error(), "null") {
+		t.Errorf("expected an error for a null value, got %v", err)
+	}
+}
+
+func TestJSONOverlayPath(t *testing.T) {
+	if got := OverlayPath("Eggs/my-app/config.fly.json", "prod"); got != "Eggs/my-app/config.prod.fly.json" {
+		t.Errorf("OverlayPath() = %q", got)
+	}
+	base, env, ok := SplitOverlayPath("Eggs/my-app/config.prod.fly.json")
+	if !ok || base != "Eggs/my-app/config.fly.json" || env != "prod" {
+		t.Errorf("SplitOverlayPath() = %q, %q, %v", base, env, ok)
+	}
+	if _, _, ok := SplitOverlayPath("Eggs/my-app/config.fly.json"); ok {
+		t.Error("expected config.fly.json not to be an overlay")
+	}
+}
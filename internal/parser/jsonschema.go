@@ -0,0 +1,167 @@
+package parser
+
+// JSONSchemaDraft is the JSON Schema dialect of the documents returned by JSONSchema
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Patterns of labels and duration attributes in JSON Schema documents
+const (
+	identifierPattern = `^[a-zA-Z][a-zA-Z0-9_-]*$`
+	durationPattern   = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+)
+
+// JSONSchema converts a top-level block schema to a JSON Schema document
+// describing the block in HCL's JSON syntax, where a block is an object
+// property named after its type and labels are nested object keys:
+//
+//	{"egg": {"my-app": {"type": "vm", "cloud": {"provider": "yandex"}}}}
+//
+// Types, required attributes and nested blocks, ranges, allowed values and
+// duration formats are described; cross-field checks are not. As with the
+// validator, unknown attributes and blocks are allowed unless strict.
+func JSONSchema(schema *BlockSchema, strict bool) map[string]interface{} {
+	doc := map[string]interface{}{
+		"$schema":    JSONSchemaDraft,
+		"title":      schema.Type,
+		"type":       "object",
+		"properties": map[string]interface{}{schema.Type: blockJSONSchema(schema, strict)},
+		"required":   []string{schema.Type},
+	}
+	if schema.Description != "" {
+		doc["description"] = schema.Description
+	}
+	return doc
+}
+
+// blockJSONSchema describes the value of a block property: its body, or an
+// object of bodies keyed by label
+func blockJSONSchema(schema *BlockSchema, strict bool) map[string]interface{} {
+	body := bodyJSONSchema(schema, strict)
+	if schema.Label == "" {
+		return body
+	}
+	labelled := map[string]interface{}{
+		"type":                 "object",
+		"minProperties":        1,
+		"additionalProperties": body,
+	}
+	if !schema.FreeLabel {
+		labelled["propertyNames"] = map[string]interface{}{"pattern": identifierPattern}
+	}
+	return labelled
+}
+
+// bodyJSONSchema describes the attributes and nested blocks of a block
+func bodyJSONSchema(schema *BlockSchema, strict bool) map[string]interface{} {
+	body := map[string]interface{}{"type": "object"}
+	if schema.Description != "" {
+		body["description"] = schema.Description
+	}
+	if schema.Open {
+		return body
+	}
+
+	properties := make(map[string]interface{})
+	var requiredAttrs, requiredBlocks []string
+	var hasPreset bool
+	for _, attr := range schema.Attributes {
+		properties[attr.Name] = attributeJSONSchema(&attr)
+		if attr.Required {
+			requiredAttrs = append(requiredAttrs, attr.Name)
+		}
+		hasPreset = hasPreset || attr.Name == "preset"
+	}
+
+	var anyType map[string]interface{}
+	for _, nested := range schema.Blocks {
+		value := blockJSONSchema(nested.Schema, strict)
+		if nested.AnyType {
+			anyType = value
+			continue
+		}
+		if nested.Multiple && nested.Schema.Label == "" {
+			// Repeated unlabeled blocks are an array of bodies, or one body
+			array := map[string]interface{}{"type": "array", "items": value}
+			if nested.MinItems > 0 {
+				array["minItems"] = nested.MinItems
+			}
+			value = map[string]interface{}{"anyOf": []interface{}{value, array}}
+		}
+		if nested.Deprecated != nil {
+			value["deprecated"] = true
+		}
+		properties[nested.Schema.Type] = value
+		if nested.Required || nested.MinItems > 0 {
+			requiredBlocks = append(requiredBlocks, nested.Schema.Type)
+		}
+	}
+	if len(properties) > 0 {
+		body["properties"] = properties
+	}
+
+	required := requiredBlocks
+	if hasPreset && len(requiredAttrs) > 0 {
+		// A preset fills in the required attributes
+		body["anyOf"] = []interface{}{
+			map[string]interface{}{"required": requiredAttrs},
+			map[string]interface{}{"required": []string{"preset"}},
+		}
+	} else {
+		required = append(requiredAttrs, requiredBlocks...)
+	}
+	if len(required) > 0 {
+		body["required"] = required
+	}
+
+	switch {
+	case anyType != nil:
+		body["additionalProperties"] = anyType
+	case strict && !schema.FreeAttributes:
+		body["additionalProperties"] = false
+	}
+	return body
+}
+
+// attributeJSONSchema describes an attribute value
+func attributeJSONSchema(attr *AttributeSchema) map[string]interface{} {
+	value := make(map[string]interface{})
+	switch attr.Type {
+	case AttrString:
+		value["type"] = "string"
+	case AttrNumber:
+		value["type"] = "number"
+	case AttrInteger:
+		value["type"] = "integer"
+	case AttrBool:
+		value["type"] = "boolean"
+	case AttrList:
+		value["type"] = "array"
+	case AttrStringList:
+		value["type"] = "array"
+		value["items"] = map[string]interface{}{"type": "string"}
+	case AttrMap:
+		value["type"] = "object"
+	}
+	if attr.Description != "" {
+		value["description"] = attr.Description
+	}
+	if attr.Deprecated != nil {
+		value["deprecated"] = true
+	}
+	if attr.Min != nil {
+		value["minimum"] = *attr.Min
+	}
+	if attr.Max != nil {
+		value["maximum"] = *attr.Max
+	}
+	if len(attr.Enum) > 0 {
+		value["enum"] = attr.Enum
+	}
+	switch attr.Format {
+	case "duration":
+		value["pattern"] = durationPattern
+	case "":
+	default:
+		value["format"] = attr.Format
+	}
+	return value
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// jsonPath walks a decoded JSON document along keys
+func jsonPath(t *testing.T, doc interface{}, keys ...string) interface{} {
+	t.Helper()
+	for _, key := range keys {
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			t.Fatalf("expected an object at %q", key)
+		}
+		if doc, ok = obj[key]; !ok {
+			t.Fatalf("missing key %q", key)
+		}
+	}
+	return doc
+}
+
+func TestJSONSchema(t *testing.T) {
+	decode := func(strict bool) map[string]interface{} {
+		data, err := json.Marshal(JSONSchema(EggSchema, strict))
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+
+	doc := decode(false)
+	if doc["$schema"] != JSONSchemaDraft {
+		t.Errorf("expected the draft 2020-12 dialect, got %v", doc["$schema"])
+	}
+	egg := jsonPath(t, doc, "properties", "egg")
+	if jsonPath(t, egg, "propertyNames", "pattern") != identifierPattern {
+		t.Error("expected egg names to be checked")
+	}
+	body := jsonPath(t, egg, "additionalProperties")
+	if jsonPath(t, body, "properties", "resources", "properties", "cpu", "minimum") != 1.0 {
+		t.Error("expected cpu minimum of 1")
+	}
+	if jsonPath(t, body, "properties", "resources", "properties", "cpu", "type") != "integer" {
+		t.Error("expected cpu to be an integer")
+	}
+	if jsonPath(t, body, "properties", "runner", "properties", "idle_timeout", "pattern") != durationPattern {
+		t.Error("expected idle_timeout to be a duration")
+	}
+	// cpu, memory and disk may come from a preset instead
+	if alternatives := jsonPath(t, body, "properties", "resources", "anyOf").([]interface{}); len(alternatives) != 2 {
+		t.Errorf("expected the resources to be required or a preset, got %v", alternatives)
+	}
+	if _, ok := jsonPath(t, body, "properties", "cloud").(map[string]interface{})["additionalProperties"]; ok {
+		t.Error("expected unknown attributes to be allowed unless strict")
+	}
+
+	body = jsonPath(t, decode(true), "properties", "egg", "additionalProperties")
+	if jsonPath(t, body, "properties", "cloud", "additionalProperties") != false {
+		t.Error("expected unknown attributes to be rejected when strict")
+	}
+	if _, ok := jsonPath(t, body, "properties", "environment").(map[string]interface{})["additionalProperties"]; ok {
+		t.Error("expected environment variables of any name when strict")
+	}
+}
+
+func TestJSONSchemaAllTypes(t *testing.T) {
+	for _, blockType := range SchemaTypes() {
+		schema, _ := LookupSchema(blockType)
+		if _, err := json.Marshal(JSONSchema(schema, true)); err != nil {
+			t.Errorf("%s: failed to marshal: %v", blockType, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// SchemaVersion is the version of the .fly schema this gosling reads.
+// Files declare the version they are written for with a top-level
+// schema_version attribute; Migrate upgrades older files.
+const SchemaVersion = 2
+
+const schemaVersionAttribute = "schema_version"
+
+// Migration upgrades .fly source from schema version From to From+1
+type Migration struct {
+	From        int
+	Description string
+	// Apply rewrites body, the top level of a file, and returns the path of
+	// each rewritten attribute or block
+	Apply func(body *hclwrite.Body) []string
+}
+
+// migrations holds the upgrades between schema versions, in order
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "gitlab.server is renamed to server_name",
+		Apply: func(body *hclwrite.Body) []string {
+			return renameAttribute(body, nil, "gitlab", "server", "server_name")
+		},
+	},
+}
+
+// parseSchemaVersion reads the value of the schema_version attribute of a
+// file, which must be a whole number
+func parseSchemaVersion(expr hcl.Expression) (int, hcl.Diagnostics) {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return 0, diags
+	}
+	n, ok := wholeNumber(val)
+	if !ok || n < 1 {
+		return 0, hcl.Diagnostics{newDiagnostic(expr.Range(), CodeType,
+			"Invalid schema_version", "schema_version must be a positive whole number.")}
+	}
+	return int(n), nil
+}
+
+// validateSchemaVersion reports a file written for a newer schema, which
+// this gosling may misread, and warns about one written for an older
+// schema, which gosling migrate upgrades
+func (v *Validator) validateSchemaVersion() {
+	version, pos := v.config.SchemaVersion, v.config.schemaVersionPos
+	switch {
+	case version > SchemaVersion:
+		v.result.addError(pos, schemaVersionAttribute, CodeValue, fmt.Sprintf(
+			"schema_version %d is written for a newer gosling, which reads schema version %d; upgrade gosling", version, SchemaVersion))
+	case version != 0 && version < SchemaVersion:
+		v.result.addWarning(pos, schemaVersionAttribute, CodeDeprecated, fmt.Sprintf(
+			"schema_version %d is outdated; run gosling migrate to upgrade it to %d", version, SchemaVersion))
+	}
+}
+
+// upgradeSchema applies the migrations of a file from its schema_version,
+// or version 1 when it has none, and updates schema_version, which Migrate
+// adds to the top of files without one
+func upgradeSchema(body *hclwrite.Body) ([]string, error) {
+	version := 1
+	if attr := body.GetAttribute(schemaVersionAttribute); attr != nil {
+		n, err := strconv.Atoi(strings.TrimSpace(string(attr.Expr().BuildTokens(nil).Bytes())))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("schema_version must be a positive whole number")
+		}
+		version = n
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("schema_version %d is newer than %d, the version this gosling reads", version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return nil, nil
+	}
+
+	var migrated []string
+	for _, m := range migrations {
+		if m.From >= version {
+			migrated = append(migrated, m.Apply(body)...)
+		}
+	}
+	if body.GetAttribute(schemaVersionAttribute) != nil {
+		body.SetAttributeValue(schemaVersionAttribute, cty.NumberIntVal(SchemaVersion))
+	}
+	return append(migrated, fmt.Sprintf("schema_version %d → %d", version, SchemaVersion)), nil
+}
+
+// renameAttribute renames attribute from to to in the blocks of type
+// blockType at any depth of body, keeping its value and comments. A block
+// already setting to is left alone. path is the path of body.
+func renameAttribute(body *hclwrite.Body, path []string, blockType, from, to string) []string {
+	var renamed []string
+	for _, block := range body.Blocks() {
+		blockPath := append(append([]string(nil), path...), block.Type())
+		inner := block.Body()
+		if block.Type() == blockType && inner.GetAttribute(from) != nil && inner.GetAttribute(to) == nil {
+			for _, tok := range inner.GetAttribute(from).BuildTokens(nil) {
+				if tok.Type == hclsyntax.TokenIdent && string(tok.Bytes) == from {
+					tok.Bytes = []byte(to)
+					break
+				}
+			}
+			renamed = append(renamed, strings.Join(append(blockPath, from), "."))
+		}
+		renamed = append(renamed, renameAttribute(inner, blockPath, blockType, from, to)...)
+	}
+	return renamed
+}
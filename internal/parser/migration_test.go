@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrateSchemaVersion(t *testing.T) {
+	src := []byte(`# Runners of my-group
+egg "my-app" {
+  type = "vm"
+
+  gitlab {
+    # Self-hosted
+    server     = "gitlab.example.com"
+    project_id = 123
+  }
+}
+`)
+
+	updated, migrated, err := Migrate(src, "config.fly")
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if strings.Join(migrated, ",") != "egg.gitlab.server,schema_version 1 → 2" {
+		t.Errorf("unexpected migrations %v", migrated)
+	}
+	want := `schema_version = 2
+
+# Runners of my-group
+egg "my-app" {
+  type = "vm"
+
+  gitlab {
+    # Self-hosted
+    server_name = "gitlab.example.com"
+    project_id  = 123
+  }
+}
+`
+	if string(updated) != want {
+		t.Errorf("unexpected migrated source:\n%s", updated)
+	}
+
+	if _, migrated, err := Migrate(updated, "config.fly"); err != nil || len(migrated) != 0 {
+		t.Errorf("expected nothing left to migrate, got %v, %v", migrated, err)
+	}
+
+	updated, migrated, err = Migrate([]byte("schema_version = 1\n\negg \"a\" {\n}\n"), "config.fly")
+	if err != nil || strings.Join(migrated, ",") != "schema_version 1 → 2" || !strings.HasPrefix(string(updated), "schema_version = 2\n") {
+		t.Errorf("expected schema_version to be updated in place, got %q, %v, %v", updated, migrated, err)
+	}
+
+	if _, _, err := Migrate([]byte("schema_version = 3\n"), "config.fly"); err == nil {
+		t.Error("expected an error for a newer schema_version")
+	}
+}
+
+func TestValidateSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		errors   int
+		warnings int
+	}{
+		{"current", "schema_version = 2\n", 0, 0},
+		{"missing", "", 0, 0},
+		{"older", "schema_version = 1\n", 0, 1},
+		{"newer", "schema_version = 3\n", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewParser().Parse([]byte(tt.version), "config.fly")
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			result := NewValidator(config).Validate()
+			if len(result.Errors) != tt.errors || len(result.Warnings) != tt.warnings {
+				t.Errorf("expected %d error(s) and %d warning(s), got %v and %v", tt.errors, tt.warnings, result.Errors, result.Warnings)
+			}
+		})
+	}
+
+	if _, err := NewParser().Parse([]byte("schema_version = 1.5\n"), "config.fly"); err == nil {
+		t.Error("expected an error for a fractional schema_version")
+	}
+}
@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// MotherGooseFunctions are the nested blocks of a mothergoose block that
+// declare a serverless function or container
+var MotherGooseFunctions = []string{"fastapi_app", "celery_workers", "uglyfox_workers"}
+
+// MotherGooseDatabaseTypes are the supported database types
+var MotherGooseDatabaseTypes = []string{"ydb", "dynamodb"}
+
+var (
+	// Cloud resource names: lowercase letters, digits and hyphens
+	resourceNamePattern = regexp.MustCompile(`^[a-z]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	// S3-compatible bucket names
+	bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+)
+
+// ValidateMotherGoose checks a mothergoose configuration before its
+// infrastructure is provisioned. On top of the schema checks of Validate it
+// requires names that the cloud accepts and that are unique, a target
+// function for every trigger, and references to functions, queues and service
+// accounts that resolve.
+func ValidateMotherGoose(config *Config) *ValidationResult {
+	result := NewValidator(config).Validate()
+	var found bool
+	for i := range config.Blocks {
+		if config.Blocks[i].Type != "mothergoose" {
+			continue
+		}
+		if found {
+			result.AddError(config.Blocks[i].Position, "mothergoose", "only one mothergoose block is allowed")
+			continue
+		}
+		found = true
+		(&motherGooseValidator{result: result}).validate(&config.Blocks[i])
+	}
+	if !found {
+		result.AddError(config.Pos(), "mothergoose", "no mothergoose block found")
+	}
+	return result
+}
+
+// motherGooseValidator collects the declared names so references can be
+// checked once every block has been seen
+type motherGooseValidator struct {
+	result          *ValidationResult
+	functions       map[string]bool
+	queues          map[string]bool
+	serviceAccounts map[string]bool
+	// Checked at the end, once all names are known
+	refs []motherGooseRef
+}
+
+type motherGooseRef struct {
+	val   Value
+	field string
+	kind  string
+}
+
+func (v *motherGooseValidator) validate(block *Block) {
+	v.functions = make(map[string]bool)
+	v.queues = make(map[string]bool)
+	v.serviceAccounts = make(map[string]bool)
+	buckets := make(map[string]bool)
+
+	if accounts, ok := block.GetBlock("service_accounts"); ok {
+		for i := range accounts.Blocks {
+			v.name(&accounts.Blocks[i], resourceNamePattern, v.serviceAccounts, "service account")
+		}
+	}
+
+	for _, blockType := range MotherGooseFunctions {
+		if fn, ok := block.GetBlock(blockType); ok {
+			v.function(fn)
+		}
+	}
+	if gateway, ok := block.GetBlock("api_gateway"); ok {
+		v.name(gateway, resourceNamePattern, nil, "API gateway")
+		if auth, ok := gateway.GetBlock("auth_function"); ok {
+			v.function(auth)
+		}
+	}
+
+	if queues, ok := block.GetBlock("message_queues"); ok {
+		for i := range queues.Blocks {
+			queue := &queues.Blocks[i]
+			v.name(queue, resourceNamePattern, v.queues, "queue")
+			v.ref(queue, "dead_letter_queue", "queue")
+		}
+	}
+
+	if triggers, ok := block.GetBlock("triggers"); ok {
+		for i := range triggers.Blocks {
+			trigger := &triggers.Blocks[i]
+			v.name(trigger, resourceNamePattern, nil, "trigger")
+			if _, ok := trigger.GetAttribute("target_function"); !ok {
+				v.result.AddError(trigger.Position, "target_function",
+					fmt.Sprintf("%s block must have a 'target_function' attribute", trigger.Type))
+			}
+			v.ref(trigger, "target_function", "function")
+			v.ref(trigger, "service_account", "service account")
+		}
+	}
+
+	if database, ok := block.GetBlock("database"); ok {
+		v.name(database, resourceNamePattern, nil, "database")
+	}
+
+	if storage, ok := block.GetBlock("storage"); ok {
+		for i := range storage.Blocks {
+			v.name(&storage.Blocks[i], bucketNamePattern, buckets, "bucket")
+		}
+	}
+
+	v.resolveRefs()
+}
+
+// function checks the name and service account of a function or container
+func (v *motherGooseValidator) function(block *Block) {
+	v.name(block, resourceNamePattern, v.functions, "function")
+	v.ref(block, "service_account", "service account")
+}
+
+// name checks the name attribute of block against pattern and, when seen is
+// not nil, that no other resource of the kind has the same name. A missing
+// name is left to the schema.
+func (v *motherGooseValidator) name(block *Block, pattern *regexp.Regexp, seen map[string]bool, kind string) {
+	val, ok := block.GetAttribute("name")
+	if !ok {
+		return
+	}
+	name, err := val.AsString()
+	if err != nil {
+		return
+	}
+	if !pattern.MatchString(name) {
+		v.result.AddError(val.Position, "name", fmt.Sprintf("invalid %s name %q", kind, name))
+		return
+	}
+	if seen == nil {
+		return
+	}
+	if seen[name] {
+		v.result.AddError(val.Position, "name", fmt.Sprintf("duplicate %s name %q", kind, name))
+	}
+	seen[name] = true
+}
+
+// ref records attr of block, if set, as a reference to a resource of kind
+func (v *motherGooseValidator) ref(block *Block, attr, kind string) {
+	if val, ok := block.GetAttribute(attr); ok {
+		v.refs = append(v.refs, motherGooseRef{val, attr, kind})
+	}
+}
+
+// resolveRefs reports references to undeclared functions, queues and service accounts
+func (v *motherGooseValidator) resolveRefs() {
+	declared := map[string]map[string]bool{
+		"function":        v.functions,
+		"queue":           v.queues,
+		"service account": v.serviceAccounts,
+	}
+	for _, ref := range v.refs {
+		name, err := ref.val.AsString()
+		if err != nil {
+			continue // Reported by the schema
+		}
+		if !declared[ref.kind][name] {
+			v.result.AddError(ref.val.Position, ref.field, fmt.Sprintf("%s %q is not declared in the mothergoose block", ref.kind, name))
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+// motherGooseConfig is a complete mothergoose block
+const motherGooseConfig = `
+mothergoose {
+  api_gateway {
+    name = "polar-gosling-api"
+  }
+
+  fastapi_app {
+    name            = "mothergoose-api"
+    image           = "cr.yandex/polar-gosling/mothergoose:latest"
+    memory          = 512
+    min_instances   = 1
+    max_instances   = 10
+    service_account = "mothergoose-sa"
+  }
+
+  celery_workers {
+    name    = "mothergoose-celery"
+    runtime = "python312"
+    memory  = 1024
+    command = ["celery", "worker"]
+  }
+
+  uglyfox_workers {
+    name    = "uglyfox-celery"
+    runtime = "python312"
+    memory  = 512
+  }
+
+  message_queues {
+    webhook_queue {
+      name              = "mothergoose-webhooks"
+      dead_letter_queue = "mothergoose-dlq"
+    }
+
+    dead_letter_queue {
+      name              = "mothergoose-dlq"
+      message_retention = 604800
+    }
+  }
+
+  triggers {
+    git_sync {
+      name            = "git-sync-trigger"
+      schedule        = "*/5 * * * *"
+      target_function = "mothergoose-api"
+      service_account = "mothergoose-sa"
+    }
+  }
+
+  database {
+    type = "ydb"
+    name = "polar-gosling-db"
+  }
+
+  storage {
+    state_bucket {
+      name       = "polar-gosling-state"
+      versioning = true
+    }
+  }
+
+  service_accounts {
+    mothergoose {
+      name  = "mothergoose-sa"
+      roles = ["ydb.editor"]
+    }
+  }
+}
+`
+
+func TestValidateMotherGoose(t *testing.T) {
+	config, err := NewParser().Parse([]byte(motherGooseConfig), "mothergoose.fly")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if result := ValidateMotherGoose(config); !result.IsValid() {
+		t.Errorf("ValidateMotherGoose() = %s", result.Error())
+	}
+}
+
+func TestValidateMotherGooseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		wantErr string
+	}{
+		{"undeclared target function", `target_function = "mothergoose-api"`, `target_function = "missing"`, `function "missing" is not declared`},
+		{"undeclared service account", `service_account = "mothergoose-sa"
+  }
+
+  celery_workers`, `service_account = "other-sa"
+  }
+
+  celery_workers`, `service account "other-sa" is not declared`},
+		{"undeclared dead letter queue", `dead_letter_queue = "mothergoose-dlq"`, `dead_letter_queue = "dlq"`, `queue "dlq" is not declared`},
+		{"duplicate function", `name    = "uglyfox-celery"`, `name    = "mothergoose-celery"`, `duplicate function name "mothergoose-celery"`},
+		{"invalid bucket name", `name       = "polar-gosling-state"`, `name       = "Polar_State"`, `invalid bucket name "Polar_State"`},
+		{"invalid schedule", `schedule        = "*/5 * * * *"`, `schedule        = "*/5 * * *"`, "invalid cron expression"},
+		{"missing memory", `memory  = 512
+  }
+
+  message_queues`, `}
+
+  message_queues`, "uglyfox_workers block must have a 'memory' attribute"},
+		{"min above max", `min_instances   = 1`, `min_instances   = 20`, "min_instances (20) must not exceed max_instances (10)"},
+		{"unknown database type", `type = "ydb"`, `type = "postgres"`, `type must be 'ydb' or 'dynamodb'`},
+		{"missing trigger target", `target_function = "mothergoose-api"`, ``, "must have a 'target_function' attribute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.Replace(motherGooseConfig, tt.old, tt.new, 1)
+			if content == motherGooseConfig {
+				t.Fatalf("test case does not change the configuration")
+			}
+			config, err := NewParser().Parse([]byte(content), "mothergoose.fly")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			result := ValidateMotherGoose(config)
+			if !strings.Contains(result.Error(), tt.wantErr) {
+				t.Errorf("ValidateMotherGoose() = %q, want an error containing %q", result.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMotherGooseRequiresBlock(t *testing.T) {
+	config, err := NewParser().Parse([]byte(`job "cleanup" {
+  schedule = "0 * * * *"
+  script   = "echo"
+  runner {
+    tags = ["docker"]
+  }
+}
+`), "job.fly")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if result := ValidateMotherGoose(config); !strings.Contains(result.Error(), "no mothergoose block found") {
+		t.Errorf("ValidateMotherGoose() = %q", result.Error())
+	}
+}
+
+func TestMotherGooseSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		wantErr string
+	}{
+		{"unknown runtime", `runtime = "python312"
+    memory  = 1024`, `runtime = "python27"
+    memory  = 1024`, `runtime must be one of`},
+		{"memory out of range", `memory          = 512`, `memory          = 64`, "memory must be between 128 and 8192"},
+		{"runtime or image", `runtime = "python312"
+    memory  = 512`, `memory  = 512`, "uglyfox_workers block must have a 'runtime' or an 'image' attribute"},
+		{"queue without name", `name              = "mothergoose-dlq"`, ``, "dead_letter_queue block must have a 'name' attribute"},
+		{"invalid trigger schedule", `schedule        = "*/5 * * * *"`, `schedule        = "*/5 * * *"`, "invalid cron expression"},
+		{"invalid role", `roles = ["ydb.editor"]`, `roles = ["admin"]`, `invalid role "admin"`},
+		{"missing database", `database {
+    type = "ydb"
+    name = "polar-gosling-db"
+  }`, ``, "mothergoose block must have a 'database' nested block"},
+		{"empty storage", `state_bucket {
+      name       = "polar-gosling-state"
+      versioning = true
+    }`, ``, "storage block must have at least one <bucket> block"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.Replace(motherGooseConfig, tt.old, tt.new, 1)
+			if content == motherGooseConfig {
+				t.Fatalf("test case does not change the configuration")
+			}
+			config, err := NewParser().Parse([]byte(content), "mothergoose.fly")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			result := NewValidator(config).Validate()
+			if !strings.Contains(result.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %q, want an error containing %q", result.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMotherGooseSchemaOptionalBlocks(t *testing.T) {
+	config, err := NewParser().Parse([]byte(`
+mothergoose {
+  api_gateway {
+    name = "polar-gosling-api"
+  }
+
+  fastapi_app {
+    name   = "mothergoose-api"
+    image  = "cr.yandex/polar-gosling/mothergoose:latest"
+    memory = 512
+  }
+
+  database {
+    type = "dynamodb"
+    name = "polar-gosling-db"
+  }
+
+  storage {
+    state_bucket {
+      name = "polar-gosling-state"
+    }
+  }
+}
+`), "mothergoose.fly")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if result := NewValidator(config).Validate(); !result.IsValid() {
+		t.Errorf("Validate() = %s", result.Error())
+	}
+}
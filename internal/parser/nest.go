@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidateNest checks references between the configuration files of a Nest,
+// which Validate cannot see one file at a time: egg and EggsBucket names and
+// GitLab project IDs must be unique across the Nest, and every job's runner
+// tags must all be offered by the runner of at least one egg or EggsBucket.
+// Environment overlays should not be passed, as they repeat their base file.
+func ValidateNest(configs []*Config) *ValidationResult {
+	result := &ValidationResult{Errors: make([]*ValidationError, 0)}
+	names := make(map[string]Position)
+	projects := make(map[int]projectOwner)
+	var runnerTags []map[string]bool
+
+	for _, config := range configs {
+		for i := range config.Blocks {
+			block := &config.Blocks[i]
+			if block.Type != "egg" && block.Type != "eggsbucket" {
+				continue
+			}
+			if len(block.Labels) == 1 {
+				name := block.Labels[0]
+				if pos, dup := names[name]; dup {
+					result.AddError(block.Position, "name",
+						fmt.Sprintf("duplicate egg name %q, also defined at %s", name, pos))
+				} else {
+					names[name] = block.Position
+				}
+			}
+			if runner, ok := block.GetBlock("runner"); ok {
+				runnerTags = append(runnerTags, tagSet(runner))
+			}
+
+			if block.Type == "egg" {
+				if gitlab, ok := block.GetBlock("gitlab"); ok {
+					checkNestProject(result, projects, gitlab, block, fmt.Sprintf("egg %q", strings.Join(block.Labels, " ")))
+				}
+				continue
+			}
+			if repositories, ok := block.GetBlock("repositories"); ok {
+				for _, repo := range repositories.GetBlocks("repo") {
+					if gitlab, ok := repo.GetBlock("gitlab"); ok {
+						checkNestProject(result, projects, gitlab, block,
+							fmt.Sprintf("repo %q of eggsbucket %q", strings.Join(repo.Labels, " "), strings.Join(block.Labels, " ")))
+					}
+				}
+			}
+		}
+	}
+
+	for _, config := range configs {
+		for i := range config.Blocks {
+			block := &config.Blocks[i]
+			if block.Type != "job" {
+				continue
+			}
+			runner, ok := block.GetBlock("runner")
+			if !ok {
+				continue
+			}
+			val, ok := runner.GetAttribute("tags")
+			if !ok {
+				continue
+			}
+			if tags := tagSet(runner); !satisfiable(tags, runnerTags) {
+				result.AddError(val.Position, "tags",
+					fmt.Sprintf("no egg runner has all of the tags %v of job %q", sortedTags(tags), strings.Join(block.Labels, " ")))
+			}
+		}
+	}
+
+	return result
+}
+
+// projectOwner is the egg or EggsBucket repo that first used a project ID
+type projectOwner struct {
+	name  string
+	block *Block
+}
+
+// checkNestProject reports the project_id of gitlab if another egg or repo
+// already uses it. Repos of the same EggsBucket are left to its schema, and
+// instances of the same repeated egg may share a project.
+func checkNestProject(result *ValidationResult, projects map[int]projectOwner, gitlab, owner *Block, name string) {
+	val, ok := gitlab.GetAttribute("project_id")
+	if !ok {
+		return
+	}
+	id, err := val.AsInt()
+	if err != nil {
+		return
+	}
+	prev, dup := projects[id]
+	if !dup {
+		projects[id] = projectOwner{name: name, block: owner}
+		return
+	}
+	// Instances of an egg with count or for_each share its source block
+	if prev.block != owner && prev.block.Position != owner.Position {
+		result.AddError(val.Position, "project_id",
+			fmt.Sprintf("project_id %d of %s is already used by %s", id, name, prev.name))
+	}
+}
+
+// tagSet returns the tags of a runner block
+func tagSet(runner *Block) map[string]bool {
+	tags := make(map[string]bool)
+	val, ok := runner.GetAttribute("tags")
+	if !ok {
+		return tags
+	}
+	list, err := val.AsList()
+	if err != nil {
+		return tags
+	}
+	for _, item := range list {
+		if tag, err := item.AsString(); err == nil {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+// satisfiable reports whether one of runners offers every tag in tags
+func satisfiable(tags map[string]bool, runners []map[string]bool) bool {
+	for _, runner := range runners {
+		ok := true
+		for tag := range tags {
+			if !runner[tag] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedTags(tags map[string]bool) []string {
+	sorted := make([]string, 0, len(tags))
+	for tag := range tags {
+		sorted = append(sorted, tag)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// nestEgg returns an egg configuration with the given runner tags and project
+func nestEgg(name, tags string, projectID int) string {
+	return fmt.Sprintf(`
+egg %q {
+  type = "vm"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags = %s
+    concurrent = 2
+  }
+
+  gitlab {
+    project_id = %d
+    server_name = "gitlab.example.com"
+    token_secret = "yc-lockbox://gitlab/runner-token"
+  }
+}
+`, name, tags, projectID)
+}
+
+const nestBucket = `
+eggsbucket "shared" {
+  type = "vm"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 4096
+    disk   = 20
+  }
+
+  runner {
+    tags = ["docker", "shared"]
+    concurrent = 2
+  }
+
+  repositories {
+    repo "frontend" {
+      gitlab {
+        project_id = 10
+        server_name = "gitlab.example.com"
+        token_secret = "yc-lockbox://gitlab/frontend-token"
+      }
+    }
+  }
+}
+`
+
+func nestJob(tags string) string {
+	return fmt.Sprintf(`
+job "rotate-secrets" {
+  schedule = "0 2 * * *"
+
+  runner {
+    type = "vm"
+    tags = %s
+  }
+
+  script = "echo rotate"
+}
+`, tags)
+}
+
+func parseNest(t *testing.T, files ...string) []*Config {
+	t.Helper()
+	configs := make([]*Config, 0, len(files))
+	for i, content := range files {
+		config, err := NewParser().Parse([]byte(content), fmt.Sprintf("file%d.fly", i))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+func TestValidateNest(t *testing.T) {
+	configs := parseNest(t,
+		nestEgg("app", `["docker", "linux"]`, 1),
+		nestBucket,
+		nestJob(`["docker", "linux"]`),
+		nestJob(`["shared"]`),
+	)
+	if result := ValidateNest(configs); !result.IsValid() {
+		t.Errorf("Validation failed: %v", result.Error())
+	}
+}
+
+func TestValidateNestErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		wantField string
+		wantMsg   string
+	}{
+		{
+			name:      "duplicate egg name",
+			files:     []string{nestEgg("app", `["docker"]`, 1), nestEgg("app", `["docker"]`, 2)},
+			wantField: "name",
+			wantMsg:   `duplicate egg name "app", also defined at file0.fly`,
+		},
+		{
+			name:      "egg and eggsbucket share a name",
+			files:     []string{nestBucket, nestEgg("shared", `["docker"]`, 1)},
+			wantField: "name",
+			wantMsg:   `duplicate egg name "shared"`,
+		},
+		{
+			name:      "duplicate project across eggs",
+			files:     []string{nestEgg("app", `["docker"]`, 1), nestEgg("api", `["docker"]`, 1)},
+			wantField: "project_id",
+			wantMsg:   `project_id 1 of egg "api" is already used by egg "app"`,
+		},
+		{
+			name:      "duplicate project across egg and eggsbucket",
+			files:     []string{nestBucket, nestEgg("app", `["docker"]`, 10)},
+			wantField: "project_id",
+			wantMsg:   `already used by repo "frontend" of eggsbucket "shared"`,
+		},
+		{
+			name:      "unsatisfiable job tags",
+			files:     []string{nestEgg("app", `["docker"]`, 1), nestBucket, nestJob(`["docker", "gpu"]`)},
+			wantField: "tags",
+			wantMsg:   `no egg runner has all of the tags [docker gpu] of job "rotate-secrets"`,
+		},
+		{
+			name:      "job without eggs",
+			files:     []string{nestJob(`["docker"]`)},
+			wantField: "tags",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidateNest(parseNest(t, tt.files...))
+			if len(result.Errors) != 1 || result.Errors[0].Field != tt.wantField {
+				t.Fatalf("expected one error for field %q, got: %v", tt.wantField, result.Error())
+			}
+			if !strings.Contains(result.Errors[0].Message, tt.wantMsg) {
+				t.Errorf("expected message containing %q, got %q", tt.wantMsg, result.Errors[0].Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// numberPrecision is the precision numbers are kept in, as in HCL
+const numberPrecision = 512
+
+// Number is the value of a NumberType Value. It keeps the text the number
+// was written as, so printing a parsed configuration reproduces it, and its
+// exact value, so large IDs do not lose precision as float64.
+type Number struct {
+	val  *big.Float
+	text string
+}
+
+// NewNumber returns the number f
+func NewNumber(f float64) Number {
+	return Number{val: new(big.Float).SetPrec(numberPrecision).SetFloat64(f)}
+}
+
+// NewIntNumber returns the whole number n
+func NewIntNumber(n int64) Number {
+	return Number{val: new(big.Float).SetPrec(numberPrecision).SetInt64(n)}
+}
+
+// ParseNumber parses a decimal number such as 12345, 2.5 or 1e3, keeping
+// text for printing
+func ParseNumber(text string) (Number, error) {
+	val, _, err := big.ParseFloat(text, 10, numberPrecision, big.ToNearestEven)
+	if err != nil {
+		return Number{}, fmt.Errorf("invalid number %q: %w", text, err)
+	}
+	return Number{val: val, text: text}, nil
+}
+
+// numberOf returns the number with the value of f, printed as it was
+// written when text is set
+func numberOf(f *big.Float, text string) Number {
+	return Number{val: new(big.Float).Copy(f), text: text}
+}
+
+// BigFloat returns the exact value of the number
+func (n Number) BigFloat() *big.Float {
+	if n.val == nil {
+		return new(big.Float).SetPrec(numberPrecision)
+	}
+	return new(big.Float).Copy(n.val)
+}
+
+// Float64 returns the nearest float64 to the number
+func (n Number) Float64() float64 {
+	f, _ := n.BigFloat().Float64()
+	return f
+}
+
+// Int64 returns the number if it is a whole number that fits an int64
+func (n Number) Int64() (int64, bool) {
+	val := n.BigFloat()
+	if val.IsInf() || !val.IsInt() {
+		return 0, false
+	}
+	i, acc := val.Int64()
+	return i, acc == big.Exact
+}
+
+// Equal reports whether two numbers have the same value, however written
+func (n Number) Equal(other Number) bool {
+	return n.BigFloat().Cmp(other.BigFloat()) == 0
+}
+
+// String returns the number as written or, for a computed number, in plain
+// decimal notation without an exponent
+func (n Number) String() string {
+	if n.text != "" {
+		return n.text
+	}
+	val := n.BigFloat()
+	if val.IsInt() {
+		return val.Text('f', 0)
+	}
+	f, _ := val.Float64()
+	if math.IsInf(f, 0) {
+		return val.Text('g', 10)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
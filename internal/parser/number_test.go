@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKeepsNumbers(t *testing.T) {
+	content := []byte(`
+egg "my-app" {
+  resources {
+    memory  = 12345678
+    cpu     = 2.50
+    disk    = 1e3
+    swap    = -512
+    scratch = 1024 * 3 / 2
+  }
+
+  gitlab {
+    project_id = 123456789012345678
+  }
+}
+`)
+	config, err := NewParser().Parse(content, "test.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	egg := &config.Blocks[0]
+	resources, _ := egg.GetBlock("resources")
+	for name, want := range map[string]string{"memory": "12345678", "cpu": "2.50", "disk": "1e3", "swap": "-512", "scratch": "1536"} {
+		if val, _ := resources.GetAttribute(name); val.String() != want {
+			t.Errorf("%s printed as %s, want %s", name, val.String(), want)
+		}
+	}
+
+	gitlab, _ := egg.GetBlock("gitlab")
+	projectID, _ := gitlab.GetAttribute("project_id")
+	exact, err := projectID.AsBigFloat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exact.Text('f', 0) != "123456789012345678" {
+		t.Errorf("expected the exact project ID, got %s", exact.Text('f', 0))
+	}
+	if id, err := projectID.AsInt(); err != nil || id != 123456789012345678 {
+		t.Errorf("AsInt() = %d, %v", id, err)
+	}
+
+	// Printing and parsing again gives the same numbers
+	reparsed, err := NewParser().Parse([]byte(config.String()), "printed.fly")
+	if err != nil {
+		t.Fatalf("failed to parse printed config: %v", err)
+	}
+	if !strings.Contains(config.String(), "project_id = 123456789012345678") {
+		t.Errorf("expected the project ID as written, got:\n%s", config.String())
+	}
+	reparsedGitlab, _ := reparsed.Blocks[0].GetBlock("gitlab")
+	if val, _ := reparsedGitlab.GetAttribute("project_id"); !val.Equals(&projectID) {
+		t.Errorf("project_id changed after printing: %s", val.String())
+	}
+}
+
+func TestNumber(t *testing.T) {
+	written, err := ParseNumber("1e3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !written.Equal(NewIntNumber(1000)) || !written.Equal(NewNumber(1000)) {
+		t.Error("expected 1e3 to equal 1000")
+	}
+	if _, err := ParseNumber("ten"); err == nil {
+		t.Error("expected an error for a word")
+	}
+
+	for _, tt := range []struct {
+		n    Number
+		want string
+	}{
+		{NewNumber(12345678), "12345678"},
+		{NewNumber(0.1), "0.1"},
+		{NewNumber(1e21), "1000000000000000000000"},
+		{Number{}, "0"},
+	} {
+		if got := tt.n.String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+	}
+
+	if _, ok := NewNumber(2.5).Int64(); ok {
+		t.Error("expected 2.5 not to be an int")
+	}
+	if n, ok := NewNumber(-3).Int64(); !ok || n != -3 {
+		t.Errorf("Int64() = %d, %v", n, ok)
+	}
+}
@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OverlayPath returns the path of the environment overlay for a .fly file,
+// e.g. Eggs/my-app/config.fly and "prod" give Eggs/my-app/config.prod.fly,
+// and config.fly.json gives config.prod.fly.json.
+func OverlayPath(basePath, env string) string {
+	ext := flyExtension(basePath)
+	if ext == "" {
+		ext = filepath.Ext(basePath)
+	}
+	return strings.TrimSuffix(basePath, ext) + "." + env + ext
+}
+
+// SplitOverlayPath reports whether path is an environment overlay and, if so,
+// returns the base file it applies to and the environment name.
+func SplitOverlayPath(path string) (basePath, env string, ok bool) {
+	dir, file := filepath.Split(path)
+	ext := flyExtension(file)
+	if ext == "" {
+		return "", "", false
+	}
+	stem := strings.TrimSuffix(file, ext)
+	idx := strings.LastIndex(stem, ".")
+	if idx <= 0 || idx == len(stem)-1 {
+		return "", "", false
+	}
+	env = stem[idx+1:]
+	if !IsValidEnvironmentName(env) {
+		return "", "", false
+	}
+	return dir + stem[:idx] + ext, env, true
+}
+
+// IsValidEnvironmentName checks that env can be used in an overlay file name
+func IsValidEnvironmentName(env string) bool {
+	return isValidIdentifier(env)
+}
+
+// ParseFileForEnv parses a .fly file and deep-merges the overlay for env over
+// it. An empty env, or an environment without an overlay file, yields the base
+// configuration unchanged.
+func (p *Parser) ParseFileForEnv(filename, env string) (*Config, error) {
+	if env != "" && !IsValidEnvironmentName(env) {
+		return nil, fmt.Errorf("invalid environment name %q", env)
+	}
+
+	if env == "" {
+		return p.ParseFile(filename)
+	}
+	overlayPath := OverlayPath(filename, env)
+	if _, err := os.Stat(overlayPath); os.IsNotExist(err) {
+		return p.ParseFile(filename)
+	}
+
+	// Defaults and presets are applied after merging so an overlay can
+	// change the preset
+	base, err := p.parseFileUnexpanded(filename)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := p.parseFileUnexpanded(overlayPath)
+	if err != nil {
+		return nil, err
+	}
+	merged := MergeOverlay(base, overlay)
+	if err := p.applyNestDefaults(merged, filename); err != nil {
+		return nil, err
+	}
+	if err := p.expandPresets(merged, filename); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// parseFileUnexpanded parses a .fly file without expanding resource presets
+func (p *Parser) parseFileUnexpanded(filename string) (*Config, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	return p.parse(content, filename, nil)
+}
+
+// MergeOverlay deep-merges overlay over base. Overlay attributes replace base
+// attributes and nested blocks are merged by type and labels. An unlabeled
+// overlay block applies to every base block of the same type, so an overlay
+// does not have to repeat the egg name.
+func MergeOverlay(base, overlay *Config) *Config {
+	blocks := make([]Block, len(base.Blocks))
+	copy(blocks, base.Blocks)
+
+	for _, over := range overlay.Blocks {
+		matched := false
+		for i := range blocks {
+			if blocks[i].Type != over.Type || (len(over.Labels) > 0 && !labelsEqual(over.Labels, blocks[i].Labels)) {
+				continue
+			}
+			pos, labels := blocks[i].Position, blocks[i].Labels
+			blocks[i] = mergeBlock(blocks[i], over)
+			blocks[i].Position, blocks[i].Labels = pos, labels
+			matched = true
+		}
+		if !matched {
+			blocks = append(blocks, over)
+		}
+	}
+
+	return &Config{Position: base.Position, Blocks: blocks, SchemaVersion: base.SchemaVersion, schemaVersionPos: base.schemaVersionPos}
+}
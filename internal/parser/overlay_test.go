@@ -0,0 +1,151 @@
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const overlayBaseConfig = `
+egg "my-app" {
+  type = "vm"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  resources {
+    cpu    = 2
+    memory = 2048
+    disk   = 20
+  }
+
+  runner {
+    tags       = ["docker"]
+    concurrent = 2
+  }
+
+  gitlab {
+    project_id   = 12345
+    server_name  = "gitlab.com"
+    token_secret = "yc-lockbox://gitlab/runner-token"
+  }
+}
+`
+
+func TestParseFileForEnvMergesOverlay(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "config.fly")
+	writeFlyFile(t, configPath, overlayBaseConfig)
+	writeFlyFile(t, filepath.Join(root, "config.prod.fly"), `
+egg {
+  resources {
+    cpu    = 8
+    memory = 16384
+  }
+
+  runner {
+    concurrent = 10
+  }
+}
+`)
+
+	config, err := NewParser().ParseFileForEnv(configPath, "prod")
+	if err != nil {
+		t.Fatalf("ParseFileForEnv failed: %v", err)
+	}
+	if len(config.Blocks) != 1 {
+		t.Fatalf("expected 1 block, got %d", len(config.Blocks))
+	}
+	egg := config.Blocks[0]
+	if len(egg.Labels) != 1 || egg.Labels[0] != "my-app" {
+		t.Errorf("expected egg label to be kept, got %v", egg.Labels)
+	}
+	if egg.Position.File != configPath {
+		t.Errorf("expected block position in base file, got %s", egg.Position.File)
+	}
+
+	resources, _ := egg.GetBlock("resources")
+	for name, want := range map[string]float64{"cpu": 8, "memory": 16384, "disk": 20} {
+		attr, ok := resources.GetAttribute(name)
+		if !ok {
+			t.Fatalf("resources.%s missing after merge", name)
+		}
+		got, _ := attr.AsNumber()
+		if got != want {
+			t.Errorf("resources.%s = %v, want %v", name, got, want)
+		}
+	}
+
+	runner, _ := egg.GetBlock("runner")
+	if _, ok := runner.GetAttribute("tags"); !ok {
+		t.Error("runner.tags from the base file was lost")
+	}
+
+	if result := NewValidator(config).Validate(); !result.IsValid() {
+		t.Errorf("merged config should be valid: %s", result.Error())
+	}
+}
+
+func TestParseFileForEnvWithoutOverlay(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "config.fly")
+	writeFlyFile(t, configPath, overlayBaseConfig)
+
+	config, err := NewParser().ParseFileForEnv(configPath, "stage")
+	if err != nil {
+		t.Fatalf("ParseFileForEnv failed: %v", err)
+	}
+	resources, _ := config.Blocks[0].GetBlock("resources")
+	cpu, _ := resources.GetAttribute("cpu")
+	if got, _ := cpu.AsNumber(); got != 2 {
+		t.Errorf("expected base cpu 2, got %v", got)
+	}
+
+	if _, err := NewParser().ParseFileForEnv(configPath, "../prod"); err == nil {
+		t.Error("expected error for invalid environment name")
+	}
+}
+
+func TestParseFileForEnvInvalidMergedResult(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "config.fly")
+	writeFlyFile(t, configPath, overlayBaseConfig)
+	writeFlyFile(t, filepath.Join(root, "config.prod.fly"), `
+egg {
+  resources {
+    cpu = 0
+  }
+}
+`)
+
+	config, err := NewParser().ParseFileForEnv(configPath, "prod")
+	if err != nil {
+		t.Fatalf("ParseFileForEnv failed: %v", err)
+	}
+	if result := NewValidator(config).Validate(); result.IsValid() {
+		t.Error("expected merged config with cpu = 0 to be invalid")
+	}
+}
+
+func TestSplitOverlayPath(t *testing.T) {
+	tests := []struct {
+		path string
+		base string
+		env  string
+		ok   bool
+	}{
+		{filepath.Join("Eggs", "app", "config.prod.fly"), filepath.Join("Eggs", "app", "config.fly"), "prod", true},
+		{filepath.Join("Eggs", "app", "config.fly"), "", "", false},
+		{"config..fly", "", "", false},
+		{".prod.fly", "", "", false},
+		{"config.prod.hcl", "", "", false},
+	}
+
+	for _, tt := range tests {
+		base, env, ok := SplitOverlayPath(tt.path)
+		if ok != tt.ok || base != tt.base || env != tt.env {
+			t.Errorf("SplitOverlayPath(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.path, base, env, ok, tt.base, tt.env, tt.ok)
+		}
+	}
+}
@@ -1,18 +1,25 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/polar-gosling/gosling/internal/telemetry"
 	"github.com/zclconf/go-cty/cty"
 )
 
 // Parser parses .fly configuration files
 type Parser struct {
-	parser *hclparse.Parser
+	parser   *hclparse.Parser
+	presets  map[string]PresetCatalog // Preset catalogs by Presets directory
+	defaults map[string]*Block        // Defaults blocks by defaults file, nil if none
 }
 
 // NewParser creates a new parser instance
@@ -32,15 +39,43 @@ func (p *Parser) ParseFile(filename string) (*Config, error) {
 	return p.Parse(content, filename)
 }
 
-// Parse parses .fly content and returns the AST
+// Parse parses .fly content and returns the AST. Include directives are
+// resolved relative to the directory of filename, and the defaults and
+// resource presets with the Defaults/ and Presets/ of the Nest containing it.
 func (p *Parser) Parse(content []byte, filename string) (*Config, error) {
-	file, diags := p.parser.ParseHCL(content, filename)
-	if diags.HasErrors() {
-		return nil, p.formatDiagnostics(diags)
+	config, err := p.parse(content, filename, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.applyNestDefaults(config, filename); err != nil {
+		return nil, err
 	}
+	if err := p.expandPresets(config, filename); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
 
+// parse parses content; stack lists the files currently being included
+func (p *Parser) parse(content []byte, filename string, stack []string) (_ *Config, err error) {
+	if stack == nil {
+		defer func(start time.Time) {
+			telemetry.RecordParse(context.Background(), time.Since(start), err)
+		}(time.Now())
+	}
+	if flyExtension(filename) == JSONExtension {
+		return p.parseJSON(content, filename)
+	}
+	content = expandIncludeDirectives(content, filename)
+
+	// HCL recovers from syntax errors, so the rest of the file is still
+	// converted and every problem is reported at once
+	file, diags := p.parser.ParseHCL(content, filename)
 	body, ok := file.Body.(*hclsyntax.Body)
 	if !ok {
+		if diags.HasErrors() {
+			return nil, p.syntaxError(diags)
+		}
 		return nil, fmt.Errorf("unexpected body type")
 	}
 
@@ -53,20 +88,75 @@ func (p *Parser) Parse(content []byte, filename string) (*Config, error) {
 		Blocks: make([]Block, 0),
 	}
 
-	// Parse top-level blocks
+	if attr, ok := body.Attributes[schemaVersionAttribute]; ok {
+		version, versionDiags := parseSchemaVersion(attr.Expr)
+		diags = append(diags, versionDiags...)
+		config.SchemaVersion = version
+		config.schemaVersionPos = positionOf(attr.Expr.Range())
+	}
+
+	var includes []Block
+	var local []*hclsyntax.Block
 	for _, hclBlock := range body.Blocks {
-		block, err := p.parseBlock(hclBlock, filename)
-		if err != nil {
+		switch hclBlock.Type {
+		case includeBlockType:
+			block, blockDiags := p.parseBlock(hclBlock, filename, nil)
+			diags = append(diags, blockDiags...)
+			includes = append(includes, *block)
+		case variableBlockType:
+		default:
+			if repeated(hclBlock) {
+				diags = withoutLabelErrors(diags, hclBlock)
+			}
+			local = append(local, hclBlock)
+		}
+	}
+	variables, varDiags := parseVariables(body.Blocks)
+	diags = append(diags, varDiags...)
+
+	// Expressions may use the variables of included files, so those are
+	// read first. After a syntax error they are not, and references to
+	// variables are left unevaluated.
+	included := &Config{}
+	if !diags.HasErrors() {
+		if included, err = p.resolveIncludes(includes, filename, stack); err != nil {
 			return nil, err
 		}
-		config.Blocks = append(config.Blocks, *block)
+	}
+	config.variables = make(map[string]cty.Value, len(included.variables)+len(variables))
+	for name, val := range included.variables {
+		config.variables[name] = val
+	}
+	for name, val := range variables {
+		config.variables[name] = val
+	}
+	ctx := evalContext(config.variables, !diags.HasErrors() || len(includes) == 0)
+
+	// Parse top-level blocks
+	blocks := make([]Block, 0, len(local))
+	for _, hclBlock := range local {
+		if repeated(hclBlock) {
+			instances, blockDiags := p.expandBlock(hclBlock, content, filename, ctx)
+			diags = append(diags, blockDiags...)
+			blocks = append(blocks, instances...)
+			continue
+		}
+		block, blockDiags := p.parseBlock(hclBlock, filename, ctx)
+		diags = append(diags, blockDiags...)
+		blocks = append(blocks, *block)
+	}
+	if diags.HasErrors() {
+		return nil, p.syntaxError(diags)
 	}
 
+	config.Blocks = mergeIncludedBlocks(included.Blocks, blocks)
 	return config, nil
 }
 
-// parseBlock converts an HCL block to our AST Block
-func (p *Parser) parseBlock(hclBlock *hclsyntax.Block, filename string) (*Block, error) {
+// parseBlock converts an HCL block to our AST Block. Attributes that cannot
+// be converted are left out and reported in the diagnostics.
+func (p *Parser) parseBlock(hclBlock *hclsyntax.Block, filename string, ctx *hcl.EvalContext) (*Block, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
 	block := &Block{
 		Position: Position{
 			File:   filename,
@@ -79,29 +169,36 @@ func (p *Parser) parseBlock(hclBlock *hclsyntax.Block, filename string) (*Block,
 		Blocks:     make([]Block, 0),
 	}
 
-	// Parse attributes
-	for name, attr := range hclBlock.Body.Attributes {
-		val, err := p.parseExpression(attr.Expr, filename)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing attribute %s: %w", name, err)
+	// Parse attributes in source order, so diagnostics are too
+	attrs := make([]*hclsyntax.Attribute, 0, len(hclBlock.Body.Attributes))
+	for _, attr := range hclBlock.Body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+	})
+	for _, attr := range attrs {
+		val, valDiags := p.parseExpression(attr.Expr, filename, ctx)
+		diags = append(diags, valDiags...)
+		if val != nil {
+			block.Attributes[attr.Name] = *val
 		}
-		block.Attributes[name] = *val
 	}
 
 	// Parse nested blocks
 	for _, nestedHCL := range hclBlock.Body.Blocks {
-		nested, err := p.parseBlock(nestedHCL, filename)
-		if err != nil {
-			return nil, err
-		}
+		nested, nestedDiags := p.parseBlock(nestedHCL, filename, ctx)
+		diags = append(diags, nestedDiags...)
 		block.Blocks = append(block.Blocks, *nested)
 	}
 
-	return block, nil
+	return block, diags
 }
 
-// parseExpression converts an HCL expression to our Value type
-func (p *Parser) parseExpression(expr hclsyntax.Expression, filename string) (*Value, error) {
+// parseExpression converts an HCL expression to our Value type, evaluating
+// references, operators and function calls in ctx. It returns nil and the
+// diagnostics when the expression is not supported or fails to evaluate.
+func (p *Parser) parseExpression(expr hclsyntax.Expression, filename string, ctx *hcl.EvalContext) (*Value, hcl.Diagnostics) {
 	pos := Position{
 		File:   filename,
 		Line:   expr.Range().Start.Line,
@@ -119,19 +216,21 @@ func (p *Parser) parseExpression(expr hclsyntax.Expression, filename string) (*V
 				return p.parseLiteralValue(lit, pos)
 			}
 		}
-		// For complex templates, we'll need to evaluate them
-		// For now, return an error for unsupported template expressions
-		return nil, fmt.Errorf("complex template expressions not yet supported at %s", pos)
+		return p.evaluate(expr, ctx, pos)
 
 	case *hclsyntax.TupleConsExpr:
 		// Parse list/array
+		var diags hcl.Diagnostics
 		list := make([]Value, 0, len(e.Exprs))
 		for _, itemExpr := range e.Exprs {
-			item, err := p.parseExpression(itemExpr, filename)
-			if err != nil {
-				return nil, err
+			item, itemDiags := p.parseExpression(itemExpr, filename, ctx)
+			diags = append(diags, itemDiags...)
+			if item != nil {
+				list = append(list, *item)
 			}
-			list = append(list, *item)
+		}
+		if diags.HasErrors() {
+			return nil, diags
 		}
 		return &Value{
 			Position: pos,
@@ -141,32 +240,32 @@ func (p *Parser) parseExpression(expr hclsyntax.Expression, filename string) (*V
 
 	case *hclsyntax.ObjectConsExpr:
 		// Parse map/object
+		var diags hcl.Diagnostics
 		m := make(map[string]Value)
 		for _, item := range e.Items {
-			// Get the key
-			keyExpr, ok := item.KeyExpr.(*hclsyntax.ObjectConsKeyExpr)
-			if !ok {
-				return nil, fmt.Errorf("unsupported map key type at %s", pos)
-			}
-
-			// For now, we only support simple string keys
-			key := ""
-			if len(keyExpr.Wrapped.(*hclsyntax.TemplateExpr).Parts) == 1 {
-				if lit, ok := keyExpr.Wrapped.(*hclsyntax.TemplateExpr).Parts[0].(*hclsyntax.LiteralValueExpr); ok {
-					key = lit.Val.AsString()
+			// Keys are quoted strings or, as in HCL, bare names
+			key := hcl.ExprAsKeyword(item.KeyExpr)
+			if keyExpr, ok := item.KeyExpr.(*hclsyntax.ObjectConsKeyExpr); ok && key == "" {
+				if tmpl, ok := keyExpr.Wrapped.(*hclsyntax.TemplateExpr); ok && len(tmpl.Parts) == 1 {
+					if lit, ok := tmpl.Parts[0].(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
+						key = lit.Val.AsString()
+					}
 				}
 			}
-
 			if key == "" {
-				return nil, fmt.Errorf("invalid map key at %s", pos)
+				diags = append(diags, unsupported(item.KeyExpr, "Invalid map key", "Map keys must be quoted strings or names.")...)
+				continue
 			}
 
 			// Get the value
-			val, err := p.parseExpression(item.ValueExpr, filename)
-			if err != nil {
-				return nil, err
+			val, valDiags := p.parseExpression(item.ValueExpr, filename, ctx)
+			diags = append(diags, valDiags...)
+			if val != nil {
+				m[key] = *val
 			}
-			m[key] = *val
+		}
+		if diags.HasErrors() {
+			return nil, diags
 		}
 		return &Value{
 			Position: pos,
@@ -174,25 +273,23 @@ func (p *Parser) parseExpression(expr hclsyntax.Expression, filename string) (*V
 			Raw:      m,
 		}, nil
 
-	case *hclsyntax.ScopeTraversalExpr:
-		// Variable reference - for now, return as string representation
-		// In a full implementation, we'd resolve these during evaluation
-		return &Value{
-			Position: pos,
-			Type:     StringType,
-			Raw:      fmt.Sprintf("${%s}", e.Traversal.RootName()),
-		}, nil
-
 	default:
-		return nil, fmt.Errorf("unsupported expression type %T at %s", expr, pos)
+		// References, operators, conditionals and function calls
+		return p.evaluate(expr, ctx, pos)
 	}
 }
 
 // parseLiteralValue converts an HCL literal value to our Value type
-func (p *Parser) parseLiteralValue(lit *hclsyntax.LiteralValueExpr, pos Position) (*Value, error) {
+func (p *Parser) parseLiteralValue(lit *hclsyntax.LiteralValueExpr, pos Position) (*Value, hcl.Diagnostics) {
 	ctyVal := lit.Val
 	ctyType := ctyVal.Type()
 
+	// HCL stands in unknown values for expressions it failed to parse; the
+	// syntax error is already reported
+	if !ctyVal.IsKnown() {
+		return nil, nil
+	}
+
 	// Check for string type
 	if ctyType.Equals(cty.String) {
 		return &Value{
@@ -202,9 +299,12 @@ func (p *Parser) parseLiteralValue(lit *hclsyntax.LiteralValueExpr, pos Position
 		}, nil
 	}
 
-	// Check for number type
+	// Check for number type, keeping the literal as written
 	if ctyType.Equals(cty.Number) {
-		num, _ := ctyVal.AsBigFloat().Float64()
+		num := numberOf(ctyVal.AsBigFloat(), "")
+		if written, err := ParseNumber(p.sourceText(lit.SrcRange)); err == nil && written.Equal(num) {
+			num = written
+		}
 		return &Value{
 			Position: pos,
 			Type:     NumberType,
@@ -221,29 +321,58 @@ func (p *Parser) parseLiteralValue(lit *hclsyntax.LiteralValueExpr, pos Position
 		}, nil
 	}
 
-	return nil, fmt.Errorf("unsupported literal type %s at %s", ctyType.FriendlyName(), pos)
+	return nil, unsupported(lit, "Unsupported value", fmt.Sprintf("Values of type %s are not supported in .fly files.", ctyType.FriendlyName()))
 }
 
-// formatDiagnostics formats HCL diagnostics into a readable error message
-func (p *Parser) formatDiagnostics(diags hcl.Diagnostics) error {
-	var messages []string
-	for _, diag := range diags {
-		msg := fmt.Sprintf("%s: %s", diag.Subject, diag.Detail)
-		if diag.Context != nil {
-			msg = fmt.Sprintf("%s (context: %s)", msg, *diag.Context)
-		}
-		messages = append(messages, msg)
+// sourceText returns the source of rng, or "" when it is not known
+func (p *Parser) sourceText(rng hcl.Range) string {
+	file, ok := p.parser.Files()[rng.Filename]
+	if !ok || rng.Start.Byte < 0 || rng.End.Byte > len(file.Bytes) || rng.Start.Byte > rng.End.Byte {
+		return ""
 	}
-	return fmt.Errorf("parse errors:\n%s", joinMessages(messages))
+	return string(file.Bytes[rng.Start.Byte:rng.End.Byte])
+}
+
+// unsupported reports an expression the parser cannot convert
+func unsupported(expr hclsyntax.Expression, summary, detail string) hcl.Diagnostics {
+	rng := expr.Range()
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  &rng,
+		Extra:    diagnosticCode(CodeUnsupported),
+	}}
 }
 
-func joinMessages(messages []string) string {
-	result := ""
-	for i, msg := range messages {
-		if i > 0 {
-			result += "\n"
+// SyntaxError is returned when .fly content is not valid HCL or uses
+// expressions the parser does not support. Diagnostics locates every such
+// problem in the file, for editors.
+type SyntaxError struct {
+	Diagnostics hcl.Diagnostics
+	files       map[string]*hcl.File // Sources of the snippets in Error
+}
+
+// Error describes each problem with the source line it is on
+func (e *SyntaxError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "parse failed with %d error(s):\n\n", len(e.Diagnostics.Errs()))
+	// Writing to a strings.Builder cannot fail
+	_ = hcl.NewDiagnosticTextWriter(&b, e.files, 0, false).WriteDiagnostics(e.Diagnostics)
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// syntaxError returns the error reporting diags in source order
+func (p *Parser) syntaxError(diags hcl.Diagnostics) error {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Subject, diags[j].Subject
+		if a == nil || b == nil {
+			return b == nil && a != nil
 		}
-		result += fmt.Sprintf("  - %s", msg)
-	}
-	return result
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Start.Byte < b.Start.Byte
+	})
+	return &SyntaxError{Diagnostics: diags, files: p.parser.Files()}
 }
@@ -507,7 +507,7 @@ func TestFlyParserTypeErrorDetection(t *testing.T) {
 func TestFlyParserVariableInterpolation(t *testing.T) {
 	properties := gopter.NewProperties(nil)
 
-	properties.Property("variable references are correctly parsed and represented",
+	properties.Property("variable references are substituted with the variable values",
 		prop.ForAll(
 			func(varName, varValue string) bool {
 				// Generate a configuration with a variable reference
@@ -521,8 +521,7 @@ func TestFlyParserVariableInterpolation(t *testing.T) {
 					return false
 				}
 
-				// Verify the variable reference is correctly represented
-				// The parser should preserve variable references as strings like "${var_name}"
+				// Variable blocks are not part of the AST
 				if len(parsed.Blocks) != 1 {
 					t.Logf("Expected 1 block, got %d", len(parsed.Blocks))
 					return false
@@ -537,38 +536,31 @@ func TestFlyParserVariableInterpolation(t *testing.T) {
 					return false
 				}
 
-				// The reference should be a string containing the variable reference
+				// The reference should be replaced by the variable value
 				refStr, err := refVal.AsString()
 				if err != nil {
 					t.Logf("Reference is not a string: %v", err)
 					return false
 				}
-
-				// The parser currently only captures the root name of the traversal
-				// For "var.varName", it captures "var"
-				// This is a known limitation that will be addressed in full implementation
-				expectedRef := "${var}"
-				if refStr != expectedRef {
-					t.Logf("Expected reference %q, got %q", expectedRef, refStr)
+				if refStr != varValue {
+					t.Logf("Expected reference %q, got %q", varValue, refStr)
 					return false
 				}
 
-				// Check that the variable definition exists
-				defVal, ok := block.GetAttribute("definition")
+				// Interpolations substitute the value too
+				interpVal, ok := block.GetAttribute("interpolation")
 				if !ok {
-					t.Logf("Missing 'definition' attribute")
+					t.Logf("Missing 'interpolation' attribute")
 					return false
 				}
-
-				// The definition should match the provided value
-				defStr, err := defVal.AsString()
+				interpStr, err := interpVal.AsString()
 				if err != nil {
-					t.Logf("Definition is not a string: %v", err)
+					t.Logf("Interpolation is not a string: %v", err)
 					return false
 				}
 
-				if defStr != varValue {
-					t.Logf("Expected definition %q, got %q", varValue, defStr)
+				if interpStr != "prefix-"+varValue {
+					t.Logf("Expected interpolation %q, got %q", "prefix-"+varValue, interpStr)
 					return false
 				}
 
@@ -585,10 +577,14 @@ func TestFlyParserVariableInterpolation(t *testing.T) {
 func generateConfigWithVariableReference(varName, varValue string) string {
 	return fmt.Sprintf(`
 variable "%s" {
-  definition = %q
-  reference = var.%s
+  default = %q
 }
-`, varName, varValue, varName)
+
+egg "test" {
+  reference     = var.%s
+  interpolation = "prefix-${var.%s}"
+}
+`, varName, varValue, varName, varName)
 }
 
 // Feature: gitops-runner-orchestration, Property 4a: Fly Parser EggsBucket Support
@@ -942,3 +938,72 @@ egg "test-app" {
 `
 	}
 }
+
+// Feature: gitops-runner-orchestration, Property 1a: Fly Parser Heredoc Round-Trip
+// Validates: Requirements 2.1, 2.4
+func TestFlyParserHeredocRoundTrip(t *testing.T) {
+	properties := gopter.NewProperties(nil)
+
+	properties.Property("multi-line job scripts survive parse → print → parse unchanged",
+		prop.ForAll(
+			func(script string) bool {
+				block := createJobBlock("heredoc-job")
+				block.Attributes["script"] = Value{
+					Position: Position{File: "generated.fly", Line: 1, Column: 1},
+					Type:     StringType,
+					Raw:      script,
+				}
+				config := &Config{Blocks: []Block{block}}
+
+				printed := config.String()
+				if !strings.Contains(printed, "script = <<") {
+					t.Logf("Expected heredoc for multi-line script, got:\n%s", printed)
+					return false
+				}
+
+				parser := NewParser()
+				first, err := parser.Parse([]byte(printed), "generated.fly")
+				if err != nil {
+					t.Logf("Parse error: %v\nInput:\n%s", err, printed)
+					return false
+				}
+
+				// Print and parse a second time to make sure the output is stable
+				reprinted := first.String()
+				second, err := parser.Parse([]byte(reprinted), "generated.fly")
+				if err != nil {
+					t.Logf("Second parse error: %v\nInput:\n%s", err, reprinted)
+					return false
+				}
+
+				scriptVal := second.Blocks[0].Attributes["script"]
+				got, _ := scriptVal.AsString()
+				if got != script {
+					t.Logf("Script changed after round-trip:\nwant %q\ngot  %q", script, got)
+					return false
+				}
+				return printed == reprinted && configEquals(config, second)
+			},
+			genHeredocScript(),
+		))
+
+	properties.TestingRun(t, gopter.ConsoleReporter(false))
+}
+
+// genHeredocScript generates multi-line shell-like scripts ending with a newline,
+// including indented lines, blank lines, and template-like sequences.
+func genHeredocScript() gopter.Gen {
+	line := gen.OneGenOf(
+		gen.AlphaString(),
+		gen.AlphaString().Map(func(s string) string { return "  " + s }),
+		gen.AlphaString().Map(func(s string) string { return "\t" + s }),
+		gen.AlphaString().Map(func(s string) string { return "echo ${" + s + "}" }),
+		gen.Const(""),
+		gen.Const("EOT"),
+	)
+	return gen.SliceOfN(6, line).
+		SuchThat(func(lines []string) bool { return len(lines) >= 2 }).
+		Map(func(lines []string) string {
+			return "#!/bin/bash\n" + strings.Join(lines, "\n") + "\n"
+		})
+}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -18,7 +20,7 @@ egg "my-app" {
     cpu    = 2
     memory = 4096
     disk   = 20
-	type = vm
+	type = "vm"
   }
 
   runner {
@@ -432,6 +434,39 @@ egg "my-app" {
 	}
 }
 
+func TestParseReportsEveryError(t *testing.T) {
+	content := []byte(`
+egg "my-app" {
+  type = "vm"
+  tags = ["${tag}"]
+
+  runner {
+    concurrent = 
+  }
+
+  gitlab {
+    server_name = env("GITLAB_HOST")
+  }
+}
+`)
+
+	_, err := NewParser().Parse(content, "test.fly")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Expected a SyntaxError, got %v", err)
+	}
+	var lines []int
+	for _, diag := range syntaxErr.Diagnostics {
+		lines = append(lines, diag.Subject.Start.Line)
+	}
+	if len(lines) != 3 || lines[0] != 4 || lines[1] != 7 || lines[2] != 11 {
+		t.Errorf("Expected errors on lines 4, 7 and 11, got %v", lines)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "parse failed with 3 error(s)") || !strings.Contains(msg, `11:     server_name = env("GITLAB_HOST")`) {
+		t.Errorf("Expected every error with its source line, got:\n%s", msg)
+	}
+}
+
 func TestParseTypeError(t *testing.T) {
 	content := []byte(`
 egg "my-app" {
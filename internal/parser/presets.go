@@ -0,0 +1,324 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// PresetsDirName is the Nest directory holding custom resource presets
+const PresetsDirName = "Presets"
+
+// PresetResources is the size of a runner: vCPUs, memory in MB and disk in GB.
+// Zero fields are left to the preset variant below it.
+type PresetResources struct {
+	CPU    int
+	Memory int
+	Disk   int
+}
+
+// merge returns r with the non-zero fields of over applied
+func (r PresetResources) merge(over PresetResources) PresetResources {
+	if over.CPU != 0 {
+		r.CPU = over.CPU
+	}
+	if over.Memory != 0 {
+		r.Memory = over.Memory
+	}
+	if over.Disk != 0 {
+		r.Disk = over.Disk
+	}
+	return r
+}
+
+// Preset is a named runner size, e.g. resources { preset = "medium" }.
+// Variants adjust it for a provider ("aws") or a provider and runner type
+// ("aws/serverless") so the same name fits each provider's limits.
+type Preset struct {
+	Name     string
+	Default  PresetResources
+	Variants map[string]PresetResources
+	Position Position // Definition in Presets/, zero for built-in presets
+}
+
+// Resources returns the size of the preset for a provider and runner type
+func (p *Preset) Resources(provider, runnerType string) PresetResources {
+	r := p.Default.merge(p.Variants[provider])
+	return r.merge(p.Variants[provider+"/"+runnerType])
+}
+
+// PresetCatalog maps preset names to presets
+type PresetCatalog map[string]*Preset
+
+// Names returns the preset names in sorted order
+func (c PresetCatalog) Names() []string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuiltinPresets returns the presets available in every Nest
+func BuiltinPresets() PresetCatalog {
+	return PresetCatalog{
+		"small": {Name: "small", Default: PresetResources{CPU: 2, Memory: 2048, Disk: 20},
+			Variants: map[string]PresetResources{
+				"yandex/serverless": {CPU: 1, Memory: 1024, Disk: 10},
+			}},
+		"medium": {Name: "medium", Default: PresetResources{CPU: 2, Memory: 4096, Disk: 40},
+			Variants: map[string]PresetResources{
+				"yandex/serverless": {CPU: 1, Memory: 2048, Disk: 10},
+			}},
+		"large": {Name: "large", Default: PresetResources{CPU: 4, Memory: 8192, Disk: 80},
+			Variants: map[string]PresetResources{
+				"yandex/serverless": {CPU: 2, Memory: 4096, Disk: 20},
+			}},
+		"xlarge": {Name: "xlarge", Default: PresetResources{CPU: 8, Memory: 16384, Disk: 160},
+			Variants: map[string]PresetResources{
+				"yandex/serverless": {CPU: 4, Memory: 4096, Disk: 40},
+				"aws/serverless":    {CPU: 6, Memory: 10240},
+			}},
+	}
+}
+
+// LoadPresets returns the built-in presets overridden by the preset blocks of
+// the .fly files in dir. A preset defined in dir replaces the built-in preset
+// of the same name. A missing directory yields the built-in presets.
+func LoadPresets(dir string) (PresetCatalog, error) {
+	return NewParser().loadPresets(dir)
+}
+
+func (p *Parser) loadPresets(dir string) (PresetCatalog, error) {
+	catalog := BuiltinPresets()
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return catalog, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".fly" {
+			continue
+		}
+		filePath := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		}
+		// Presets are parsed without expanding presets themselves
+		config, err := p.parse(content, filePath, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse preset file: %w", err)
+		}
+		for i := range config.Blocks {
+			preset, err := parsePreset(&config.Blocks[i])
+			if err != nil {
+				return nil, err
+			}
+			if existing, ok := catalog[preset.Name]; ok && existing.Position.File != "" {
+				return nil, fmt.Errorf("%s: preset %q already defined at %s", preset.Position, preset.Name, existing.Position)
+			}
+			catalog[preset.Name] = preset
+		}
+	}
+	return catalog, nil
+}
+
+// parsePreset converts a preset block:
+//
+//	preset "medium" {
+//	  cpu    = 2
+//	  memory = 4096
+//	  disk   = 40
+//
+//	  provider "yandex" "serverless" {
+//	    memory = 2048
+//	  }
+//	}
+func parsePreset(block *Block) (*Preset, error) {
+	if block.Type != "preset" {
+		return nil, fmt.Errorf("%s: unexpected block %q in preset file (expected 'preset')", block.Position, block.Type)
+	}
+	if len(block.Labels) != 1 || !isValidIdentifier(block.Labels[0]) {
+		return nil, fmt.Errorf("%s: preset block must have exactly one label (the preset name)", block.Position)
+	}
+	preset := &Preset{Name: block.Labels[0], Variants: make(map[string]PresetResources), Position: block.Position}
+
+	var err error
+	if preset.Default, err = parsePresetResources(block); err != nil {
+		return nil, err
+	}
+	for i := range block.Blocks {
+		nested := &block.Blocks[i]
+		if nested.Type != "provider" {
+			return nil, fmt.Errorf("%s: unexpected block %q in preset (expected 'provider')", nested.Position, nested.Type)
+		}
+		if len(nested.Labels) == 0 || len(nested.Labels) > 2 || !contains(CloudProviders, nested.Labels[0]) {
+			return nil, fmt.Errorf("%s: provider block must be labeled with a provider (%v) and optionally a runner type", nested.Position, CloudProviders)
+		}
+		key := nested.Labels[0]
+		if len(nested.Labels) == 2 {
+			if !contains(RunnerTypes, nested.Labels[1]) {
+				return nil, fmt.Errorf("%s: runner type must be one of %v, got %q", nested.Position, RunnerTypes, nested.Labels[1])
+			}
+			key += "/" + nested.Labels[1]
+		}
+		if preset.Variants[key], err = parsePresetResources(nested); err != nil {
+			return nil, err
+		}
+	}
+
+	r := preset.Default
+	if r.CPU == 0 || r.Memory == 0 || r.Disk == 0 {
+		return nil, fmt.Errorf("%s: preset %q must set cpu, memory and disk", block.Position, preset.Name)
+	}
+	return preset, nil
+}
+
+// parsePresetResources reads the cpu, memory and disk attributes of block
+func parsePresetResources(block *Block) (PresetResources, error) {
+	var r PresetResources
+	for name, val := range block.Attributes {
+		n, err := val.AsInt()
+		if err != nil || n <= 0 {
+			return r, fmt.Errorf("%s: %s must be a positive number", val.Position, name)
+		}
+		switch name {
+		case "cpu":
+			r.CPU = n
+		case "memory":
+			r.Memory = n
+		case "disk":
+			r.Disk = n
+		default:
+			return r, fmt.Errorf("%s: unknown preset attribute %q (expected cpu, memory or disk)", val.Position, name)
+		}
+	}
+	return r, nil
+}
+
+// ExpandPresets fills in cpu, memory and disk of every egg and eggsbucket
+// resources block that sets a preset. Attributes written next to the preset
+// take precedence, and the expanded values carry the position of the preset
+// attribute so validation errors point at it.
+func ExpandPresets(config *Config, catalog PresetCatalog) error {
+	for i := range config.Blocks {
+		block := &config.Blocks[i]
+		if block.Type != "egg" && block.Type != "eggsbucket" {
+			continue
+		}
+		resources, ok := block.GetBlock("resources")
+		if !ok {
+			continue
+		}
+		presetVal, ok := resources.GetAttribute("preset")
+		if !ok {
+			continue
+		}
+		name, err := presetVal.AsString()
+		if err != nil {
+			return fmt.Errorf("%s: preset must be a string", presetVal.Position)
+		}
+		preset, ok := catalog[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown resource preset %q: known presets are %v", presetVal.Position, name, catalog.Names())
+		}
+
+		var provider, runnerType string
+		if cloud, ok := block.GetBlock("cloud"); ok {
+			if v, ok := cloud.GetAttribute("provider"); ok {
+				provider, _ = v.AsString()
+			}
+		}
+		if v, ok := block.GetAttribute("type"); ok {
+			runnerType, _ = v.AsString()
+		}
+		if v, ok := resources.GetAttribute("type"); ok {
+			runnerType, _ = v.AsString()
+		}
+
+		size := preset.Resources(provider, runnerType)
+		for attr, n := range map[string]int{"cpu": size.CPU, "memory": size.Memory, "disk": size.Disk} {
+			if _, set := resources.Attributes[attr]; !set {
+				resources.Attributes[attr] = Value{Position: presetVal.Position, Type: NumberType, Raw: NewIntNumber(int64(n))}
+			}
+		}
+	}
+	return nil
+}
+
+// findPresetsDir returns the Presets directory of the Nest containing
+// filename: the nearest ancestor directory with both Eggs and Presets
+func findPresetsDir(filename string) (string, bool) {
+	return findNestDir(filename, PresetsDirName)
+}
+
+// findNestDir returns the directory name of the Nest containing filename:
+// the nearest ancestor directory with both Eggs and name
+func findNestDir(filename, name string) (string, bool) {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return "", false
+	}
+	for {
+		nestDir := filepath.Join(dir, name)
+		if isDir(nestDir) && isDir(filepath.Join(dir, "Eggs")) {
+			return nestDir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// usesPresets reports whether any egg or eggsbucket of config sets a preset
+func usesPresets(config *Config) bool {
+	for i := range config.Blocks {
+		if resources, ok := config.Blocks[i].GetBlock("resources"); ok {
+			if _, ok := resources.GetAttribute("preset"); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandPresets expands presets with the catalog of the Nest containing
+// filename. The catalog is only loaded when a preset is used.
+func (p *Parser) expandPresets(config *Config, filename string) error {
+	if !usesPresets(config) {
+		return nil
+	}
+	dir, _ := findPresetsDir(filename)
+	catalog, ok := p.presets[dir]
+	if !ok {
+		var err error
+		if dir == "" {
+			catalog = BuiltinPresets()
+		} else if catalog, err = p.loadPresets(dir); err != nil {
+			return err
+		}
+		if p.presets == nil {
+			p.presets = make(map[string]PresetCatalog)
+		}
+		p.presets[dir] = catalog
+	}
+	return ExpandPresets(config, catalog)
+}
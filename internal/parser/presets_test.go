@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// presetEggConfig is an egg whose resources block is filled in by %s
+const presetEggConfig = `
+egg "my-app" {
+  type = "%s"
+
+  cloud {
+    provider = "%s"
+    region   = "%s"
+  }
+
+  resources {
+%s
+  }
+
+  runner {
+    tags       = ["docker"]
+    concurrent = 2
+  }
+
+  gitlab {
+    project_id   = 12345
+    server_name  = "gitlab.com"
+    token_secret = "yc-lockbox://gitlab/runner-token"
+  }
+}
+`
+
+func presetEgg(runnerType, provider, region, resources string) string {
+	return fmt.Sprintf(presetEggConfig, runnerType, provider, region, resources)
+}
+
+// eggResources returns the resources of the first egg of config
+func eggResources(t *testing.T, config *Config) PresetResources {
+	t.Helper()
+	resources, ok := config.Blocks[0].GetBlock("resources")
+	if !ok {
+		t.Fatal("resources block missing")
+	}
+	var r PresetResources
+	for name, dst := range map[string]*int{"cpu": &r.CPU, "memory": &r.Memory, "disk": &r.Disk} {
+		val, ok := resources.GetAttribute(name)
+		if !ok {
+			t.Fatalf("resources.%s missing", name)
+		}
+		*dst, _ = val.AsInt()
+	}
+	return r
+}
+
+func TestBuiltinPresets(t *testing.T) {
+	tests := []struct {
+		name       string
+		runnerType string
+		provider   string
+		region     string
+		resources  string
+		want       PresetResources
+	}{
+		{name: "default", runnerType: "vm", provider: "yandex", region: "ru-central1-a", resources: `preset = "medium"`, want: PresetResources{2, 4096, 40}},
+		{name: "provider variant", runnerType: "serverless", provider: "yandex", region: "ru-central1-a", resources: `preset = "large"`, want: PresetResources{2, 4096, 20}},
+		{name: "partial variant", runnerType: "serverless", provider: "aws", region: "us-east-1", resources: `preset = "xlarge"`, want: PresetResources{6, 10240, 160}},
+		{name: "explicit attribute wins", runnerType: "vm", provider: "aws", region: "us-east-1", resources: "preset = \"small\"\n    disk = 50", want: PresetResources{2, 2048, 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewParser().Parse([]byte(presetEgg(tt.runnerType, tt.provider, tt.region, tt.resources)), "config.fly")
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := eggResources(t, config); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if result := NewValidator(config).Validate(); !result.IsValid() {
+				t.Errorf("expanded config is invalid: %v", result.Error())
+			}
+		})
+	}
+
+	// Every built-in preset satisfies the limits of every provider and runner type
+	regions := map[string]string{ProviderYandex: "ru-central1-a", ProviderAWS: "us-east-1", ProviderAzure: "westeurope"}
+	for _, name := range BuiltinPresets().Names() {
+		for _, provider := range CloudProviders {
+			for _, runnerType := range []string{"vm", "serverless"} {
+				content := presetEgg(runnerType, provider, regions[provider], `preset = "`+name+`"`)
+				config, err := NewParser().Parse([]byte(content), "config.fly")
+				if err != nil {
+					t.Fatalf("Parse failed: %v", err)
+				}
+				if result := NewValidator(config).Validate(); !result.IsValid() {
+					t.Errorf("preset %s on %s %s is invalid: %v", name, provider, runnerType, result.Error())
+				}
+			}
+		}
+	}
+}
+
+func TestUnknownPreset(t *testing.T) {
+	_, err := NewParser().Parse([]byte(presetEgg("vm", "aws", "us-east-1", `preset = "huge"`)), "config.fly")
+	if err == nil || !strings.Contains(err.Error(), `unknown resource preset "huge"`) || !strings.Contains(err.Error(), "config.fly:") {
+		t.Errorf("expected unknown preset error with position, got %v", err)
+	}
+}
+
+func TestNestPresets(t *testing.T) {
+	root := t.TempDir()
+	writeFlyFile(t, filepath.Join(root, "Presets", "sizes.fly"), `
+preset "medium" {
+  cpu    = 4
+  memory = 8192
+  disk   = 40
+
+  provider "aws" "serverless" {
+    memory = 3008
+  }
+}
+
+preset "build" {
+  cpu    = 16
+  memory = 32768
+  disk   = 200
+}
+`)
+	configPath := filepath.Join(root, "Eggs", "my-app", "config.fly")
+	writeFlyFile(t, configPath, presetEgg("vm", "aws", "us-east-1", `preset = "medium"`))
+	writeFlyFile(t, filepath.Join(root, "Eggs", "my-app", "config.prod.fly"), `
+egg {
+  resources {
+    preset = "build"
+  }
+}
+`)
+
+	p := NewParser()
+	config, err := p.ParseFile(configPath)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if got, want := eggResources(t, config), (PresetResources{4, 8192, 40}); got != want {
+		t.Errorf("expected the Nest's medium preset %+v, got %+v", want, got)
+	}
+
+	// The overlay's preset is expanded after merging
+	config, err = p.ParseFileForEnv(configPath, "prod")
+	if err != nil {
+		t.Fatalf("ParseFileForEnv failed: %v", err)
+	}
+	if got, want := eggResources(t, config), (PresetResources{16, 32768, 200}); got != want {
+		t.Errorf("expected the overlay's build preset %+v, got %+v", want, got)
+	}
+
+	catalog, err := LoadPresets(filepath.Join(root, "Presets"))
+	if err != nil {
+		t.Fatalf("LoadPresets failed: %v", err)
+	}
+	if got := catalog["medium"].Resources("aws", "serverless"); got != (PresetResources{4, 3008, 40}) {
+		t.Errorf("unexpected aws serverless medium: %+v", got)
+	}
+	if _, ok := catalog["small"]; !ok {
+		t.Error("expected built-in presets to remain available")
+	}
+}
+
+func TestLoadPresetsErrors(t *testing.T) {
+	const sizes = "  cpu    = 1\n  memory = 1024\n  disk   = 10\n"
+	tests := map[string]string{
+		"missing disk":      "preset \"tiny\" {\n  cpu    = 1\n  memory = 1024\n}\n",
+		"unknown provider":  "preset \"tiny\" {\n" + sizes + "  provider \"gcp\" {\n  }\n}\n",
+		"runner type":       "preset \"tiny\" {\n" + sizes + "  provider \"aws\" \"lambda\" {\n  }\n}\n",
+		"unknown attribute": "preset \"tiny\" {\n" + sizes + "  gpu    = 1\n}\n",
+		"already defined":   "preset \"tiny\" {\n" + sizes + "}\npreset \"tiny\" {\n" + sizes + "}\n",
+		"unexpected block":  "egg \"tiny\" {\n}\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFlyFile(t, filepath.Join(dir, "sizes.fly"), content)
+			if _, err := LoadPresets(dir); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
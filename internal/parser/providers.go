@@ -0,0 +1,806 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Supported cloud providers
+const (
+	ProviderYandex = "yandex"
+	ProviderAWS    = "aws"
+	ProviderAzure  = "azure"
+)
+
+// CloudProviders lists the provider names accepted in cloud blocks
+var CloudProviders = []string{ProviderYandex, ProviderAWS, ProviderAzure}
+
+// yandexZones are the Yandex Cloud availability zones
+var yandexZones = map[string]bool{
+	"ru-central1-a": true,
+	"ru-central1-b": true,
+	"ru-central1-c": true,
+	"ru-central1-d": true,
+}
+
+// awsRegions are the AWS regions where both EC2 and Lambda are available
+var awsRegions = map[string]bool{
+	"us-east-1":      true,
+	"us-east-2":      true,
+	"us-west-1":      true,
+	"us-west-2":      true,
+	"ca-central-1":   true,
+	"sa-east-1":      true,
+	"eu-west-1":      true,
+	"eu-west-2":      true,
+	"eu-west-3":      true,
+	"eu-central-1":   true,
+	"eu-north-1":     true,
+	"eu-south-1":     true,
+	"ap-south-1":     true,
+	"ap-southeast-1": true,
+	"ap-southeast-2": true,
+	"ap-northeast-1": true,
+	"ap-northeast-2": true,
+	"ap-northeast-3": true,
+	"me-south-1":     true,
+	"af-south-1":     true,
+}
+
+// yandexServerlessMemory are the memory sizes (MB) of Yandex Cloud serverless containers
+var yandexServerlessMemory = []int{128, 256, 512, 1024, 2048, 4096}
+
+// Memory limits (MB) of AWS Lambda functions
+const (
+	awsLambdaMinMemory = 128
+	awsLambdaMaxMemory = 10240
+)
+
+// MaxServerlessJobTimeout is the longest a job may run on a serverless
+// runner, and the job timeout of those that do not set job_timeout
+const MaxServerlessJobTimeout = 60 * time.Minute
+
+// serverlessJobTimeouts are the providers whose serverless runners stop jobs
+// before MaxServerlessJobTimeout
+var serverlessJobTimeouts = map[string]time.Duration{
+	ProviderAWS: 15 * time.Minute, // AWS Lambda
+}
+
+// ServerlessJobTimeout returns the longest a job may run on a serverless
+// runner of provider
+func ServerlessJobTimeout(provider string) time.Duration {
+	if timeout, ok := serverlessJobTimeouts[provider]; ok {
+		return timeout
+	}
+	return MaxServerlessJobTimeout
+}
+
+// azureRegions are the Azure regions where both VMs and Azure Functions are available
+var azureRegions = map[string]bool{
+	"eastus":             true,
+	"eastus2":            true,
+	"westus":             true,
+	"westus2":            true,
+	"westus3":            true,
+	"centralus":          true,
+	"northcentralus":     true,
+	"southcentralus":     true,
+	"canadacentral":      true,
+	"brazilsouth":        true,
+	"northeurope":        true,
+	"westeurope":         true,
+	"uksouth":            true,
+	"francecentral":      true,
+	"germanywestcentral": true,
+	"swedencentral":      true,
+	"switzerlandnorth":   true,
+	"norwayeast":         true,
+	"eastasia":           true,
+	"southeastasia":      true,
+	"japaneast":          true,
+	"koreacentral":       true,
+	"centralindia":       true,
+	"australiaeast":      true,
+	"southafricanorth":   true,
+	"uaenorth":           true,
+}
+
+// providerRegions maps each provider to its known regions or zones
+var providerRegions = map[string]map[string]bool{
+	ProviderYandex: yandexZones,
+	ProviderAWS:    awsRegions,
+	ProviderAzure:  azureRegions,
+}
+
+// providerPlatforms are the instance platforms (Yandex) or families (AWS) a
+// VM runner may ask for with resources.platform. Azure sizes are chosen from
+// cpu and memory.
+var providerPlatforms = map[string]map[string]bool{
+	ProviderYandex: {
+		"standard-v1":     true,
+		"standard-v2":     true,
+		"standard-v3":     true,
+		"highfreq-v3":     true,
+		"gpu-standard-v2": true,
+		"gpu-standard-v3": true,
+		"standard-v3-t4":  true,
+	},
+	ProviderAWS: {
+		"t3":   true,
+		"m5":   true,
+		"m6i":  true,
+		"m7i":  true,
+		"m7g":  true,
+		"c6i":  true,
+		"c7i":  true,
+		"c7g":  true,
+		"r6i":  true,
+		"r7g":  true,
+		"g4dn": true,
+		"g5":   true,
+		"p3":   true,
+		"p4d":  true,
+	},
+}
+
+// gpuPlatforms are the GPU types of each provider and the platform (Yandex)
+// or instance family (AWS) offering them
+var gpuPlatforms = map[string]map[string]string{
+	ProviderYandex: {
+		"v100": "gpu-standard-v2",
+		"a100": "gpu-standard-v3",
+		"t4":   "standard-v3-t4",
+	},
+	ProviderAWS: {
+		"t4":   "g4dn",
+		"a10g": "g5",
+		"v100": "p3",
+		"a100": "p4d",
+	},
+}
+
+// Platforms returns the VM platforms or instance families of provider in
+// sorted order
+func Platforms(provider string) []string {
+	return sortedKeys(providerPlatforms[provider])
+}
+
+// GPUTypes returns the GPU types of provider in sorted order
+func GPUTypes(provider string) []string {
+	types := make([]string, 0, len(gpuPlatforms[provider]))
+	for gpuType := range gpuPlatforms[provider] {
+		types = append(types, gpuType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// GPUPlatform returns the platform or instance family of provider offering
+// gpuType, and whether there is one
+func GPUPlatform(provider, gpuType string) (string, bool) {
+	platform, ok := gpuPlatforms[provider][gpuType]
+	return platform, ok
+}
+
+// AzureVMSizeSpec describes the capacity of an Azure VM size
+type AzureVMSizeSpec struct {
+	Name   string
+	CPU    int
+	Memory int // MB
+}
+
+// azureVMSizes are the general-purpose sizes runners are placed on, smallest first
+var azureVMSizes = []AzureVMSizeSpec{
+	{Name: "Standard_B1s", CPU: 1, Memory: 1024},
+	{Name: "Standard_B1ms", CPU: 1, Memory: 2048},
+	{Name: "Standard_B2s", CPU: 2, Memory: 4096},
+	{Name: "Standard_B2ms", CPU: 2, Memory: 8192},
+	{Name: "Standard_D4s_v5", CPU: 4, Memory: 16384},
+	{Name: "Standard_D8s_v5", CPU: 8, Memory: 32768},
+	{Name: "Standard_D16s_v5", CPU: 16, Memory: 65536},
+	{Name: "Standard_D32s_v5", CPU: 32, Memory: 131072},
+	{Name: "Standard_D48s_v5", CPU: 48, Memory: 196608},
+	{Name: "Standard_D64s_v5", CPU: 64, Memory: 262144},
+	{Name: "Standard_D96s_v5", CPU: 96, Memory: 393216},
+}
+
+// IsValidAzureRegion reports whether region is a supported Azure region
+func IsValidAzureRegion(region string) bool {
+	return IsValidRegion(ProviderAzure, region)
+}
+
+// AzureRegions returns the supported Azure regions in sorted order
+func AzureRegions() []string {
+	return Regions(ProviderAzure)
+}
+
+// IsValidRegion reports whether region is a known region (or zone) of provider
+func IsValidRegion(provider, region string) bool {
+	return providerRegions[provider][region]
+}
+
+// Regions returns the known regions (or zones) of provider in sorted order
+func Regions(provider string) []string {
+	return sortedKeys(providerRegions[provider])
+}
+
+// sortedKeys returns the keys of set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AzureVMSize returns the smallest Azure VM size with at least the requested
+// vCPUs and memory (MB)
+func AzureVMSize(cpu, memory int) (AzureVMSizeSpec, error) {
+	for _, size := range azureVMSizes {
+		if size.CPU >= cpu && size.Memory >= memory {
+			return size, nil
+		}
+	}
+	largest := azureVMSizes[len(azureVMSizes)-1]
+	return AzureVMSizeSpec{}, fmt.Errorf("no Azure VM size provides %d vCPU and %d MB memory (largest is %s with %d vCPU and %d MB)",
+		cpu, memory, largest.Name, largest.CPU, largest.Memory)
+}
+
+// checkCloudRegion validates the region against the provider's known regions
+func checkCloudRegion(block *Block, result *ValidationResult) {
+	providerVal, ok := block.GetAttribute("provider")
+	if !ok {
+		return
+	}
+	provider, err := providerVal.AsString()
+	if err != nil || providerRegions[provider] == nil {
+		return
+	}
+	regionVal, ok := block.GetAttribute("region")
+	if !ok {
+		return
+	}
+	region, err := regionVal.AsString()
+	if err != nil {
+		return
+	}
+	if !IsValidRegion(provider, region) {
+		kind := "region"
+		if provider == ProviderYandex {
+			kind = "zone"
+		}
+		result.AddError(regionVal.Position, "region",
+			fmt.Sprintf("unsupported %s %s %q: must be one of %v", provider, kind, region, Regions(provider)))
+	}
+}
+
+// checkProviderResources validates an egg or eggsbucket's resources against
+// the limits of its cloud provider and runner type
+func checkProviderResources(block *Block, result *ValidationResult) {
+	typeVal, ok := block.GetAttribute("type")
+	if !ok {
+		return
+	}
+	runnerType, err := typeVal.AsString()
+	if err != nil {
+		return
+	}
+	cloudBlock, ok := block.GetBlock("cloud")
+	if !ok {
+		return
+	}
+	providerVal, ok := cloudBlock.GetAttribute("provider")
+	if !ok {
+		return
+	}
+	provider, err := providerVal.AsString()
+	if err != nil {
+		return
+	}
+	resourcesBlock, ok := block.GetBlock("resources")
+	if !ok {
+		return
+	}
+	cpuVal, cpuOk := resourcesBlock.GetAttribute("cpu")
+	memoryVal, memoryOk := resourcesBlock.GetAttribute("memory")
+	if !cpuOk || !memoryOk {
+		return
+	}
+	cpu, cpuErr := cpuVal.AsInt()
+	memory, memoryErr := memoryVal.AsInt()
+	if cpuErr != nil || memoryErr != nil {
+		return
+	}
+
+	switch {
+	case provider == ProviderYandex && runnerType == "vm":
+		if cpu != 1 && cpu%2 != 0 {
+			result.AddError(cpuVal.Position, "cpu",
+				fmt.Sprintf("yandex VMs need 1 or an even number of vCPUs, got %d", cpu))
+		}
+		if minMemory := cpu * 1024; memory < minMemory {
+			result.AddError(memoryVal.Position, "memory",
+				fmt.Sprintf("yandex VMs need at least 1024 MB of memory per vCPU (%d MB for %d vCPUs), got %d MB", minMemory, cpu, memory))
+		}
+	case provider == ProviderYandex && runnerType == "serverless":
+		if !containsInt(yandexServerlessMemory, memory) {
+			result.AddError(memoryVal.Position, "memory",
+				fmt.Sprintf("yandex serverless containers support memory of %v MB, got %d MB", yandexServerlessMemory, memory))
+		}
+	case provider == ProviderAWS && runnerType == "serverless":
+		if memory < awsLambdaMinMemory || memory > awsLambdaMaxMemory {
+			result.AddError(memoryVal.Position, "memory",
+				fmt.Sprintf("AWS Lambda memory must be between %d and %d MB, got %d MB", awsLambdaMinMemory, awsLambdaMaxMemory, memory))
+		}
+	case provider == ProviderAzure && runnerType == "vm":
+		if _, err := AzureVMSize(cpu, memory); err != nil {
+			result.AddError(resourcesBlock.Position, "resources", err.Error())
+		}
+	}
+}
+
+// checkPlatform validates the gpu, gpu_type and platform of an egg or
+// eggsbucket's resources against its cloud provider. They only apply to VM
+// runners, and a GPU type decides the platform.
+func checkPlatform(block *Block, result *ValidationResult) {
+	resourcesBlock, ok := block.GetBlock("resources")
+	if !ok {
+		return
+	}
+	gpuVal, hasGPU := resourcesBlock.GetAttribute("gpu")
+	gpuTypeVal, hasGPUType := resourcesBlock.GetAttribute("gpu_type")
+	platformVal, hasPlatform := resourcesBlock.GetAttribute("platform")
+	if !hasGPU && !hasGPUType && !hasPlatform {
+		return
+	}
+
+	if typeVal, ok := block.GetAttribute("type"); ok {
+		if runnerType, err := typeVal.AsString(); err == nil && runnerType != "vm" {
+			for _, name := range []string{"gpu", "gpu_type", "platform"} {
+				if val, ok := resourcesBlock.GetAttribute(name); ok {
+					result.AddError(val.Position, name,
+						fmt.Sprintf("%s only applies to VM runners, not %s runners", name, runnerType))
+				}
+			}
+			return
+		}
+	}
+	if hasGPU && !hasGPUType {
+		result.AddError(gpuVal.Position, "gpu", "gpu requires gpu_type")
+	}
+	if hasGPUType && !hasGPU {
+		result.AddError(gpuTypeVal.Position, "gpu_type", "gpu_type requires gpu, the number of GPUs")
+	}
+
+	cloudBlock, ok := block.GetBlock("cloud")
+	if !ok {
+		return
+	}
+	providerVal, ok := cloudBlock.GetAttribute("provider")
+	if !ok {
+		return
+	}
+	provider, err := providerVal.AsString()
+	if err != nil || providerRegions[provider] == nil {
+		return
+	}
+	if providerPlatforms[provider] == nil {
+		for _, name := range []string{"gpu", "platform"} {
+			if val, ok := resourcesBlock.GetAttribute(name); ok {
+				result.AddError(val.Position, name,
+					fmt.Sprintf("%s VM runners do not support %s; their size is chosen from cpu and memory", provider, name))
+			}
+		}
+		return
+	}
+
+	var platform string
+	if hasPlatform {
+		if platform, err = platformVal.AsString(); err == nil && !providerPlatforms[provider][platform] {
+			result.AddError(platformVal.Position, "platform",
+				fmt.Sprintf("unsupported %s platform %q: must be one of %v", provider, platform, Platforms(provider)))
+			return
+		}
+	}
+	if !hasGPUType {
+		return
+	}
+	gpuType, err := gpuTypeVal.AsString()
+	if err != nil {
+		return
+	}
+	gpuPlatform, ok := GPUPlatform(provider, gpuType)
+	if !ok {
+		result.AddError(gpuTypeVal.Position, "gpu_type",
+			fmt.Sprintf("unsupported %s gpu_type %q: must be one of %v", provider, gpuType, GPUTypes(provider)))
+		return
+	}
+	if platform != "" && platform != gpuPlatform {
+		result.AddError(platformVal.Position, "platform",
+			fmt.Sprintf("%s %s GPUs run on platform %q, got %q", provider, gpuType, gpuPlatform, platform))
+	}
+}
+
+// networkIDPrefixes are the prefixes of the network IDs of each provider
+var networkIDPrefixes = map[string]map[string]string{
+	ProviderAWS: {
+		"vpc_id":          "vpc-",
+		"subnet_id":       "subnet-",
+		"security_groups": "sg-",
+	},
+}
+
+// checkNetworkIDs validates the IDs of a cloud block's network against the
+// format of its provider's IDs
+func checkNetworkIDs(block *Block, result *ValidationResult) {
+	networkBlock, ok := block.GetBlock("network")
+	if !ok {
+		return
+	}
+	providerVal, ok := block.GetAttribute("provider")
+	if !ok {
+		return
+	}
+	provider, err := providerVal.AsString()
+	if err != nil {
+		return
+	}
+	for _, name := range []string{"vpc_id", "subnet_id", "security_groups"} {
+		prefix, ok := networkIDPrefixes[provider][name]
+		if !ok {
+			continue
+		}
+		val, ok := networkBlock.GetAttribute(name)
+		if !ok {
+			continue
+		}
+		ids := []Value{val}
+		if list, err := val.AsList(); err == nil {
+			ids = list
+		}
+		for _, idVal := range ids {
+			if id, err := idVal.AsString(); err == nil && !strings.HasPrefix(id, prefix) {
+				result.AddError(idVal.Position, name,
+					fmt.Sprintf("%s %s IDs start with %q, got %q", provider, name, prefix, id))
+			}
+		}
+	}
+}
+
+// checkNetworkPlacement warns about network settings deploy would ignore:
+// serverless runners and Azure VMs, whose network interface is a variable of
+// the deployment, are not placed in the network
+func checkNetworkPlacement(block *Block, result *ValidationResult) {
+	cloudBlock, ok := block.GetBlock("cloud")
+	if !ok {
+		return
+	}
+	networkBlock, ok := cloudBlock.GetBlock("network")
+	if !ok {
+		return
+	}
+	if typeVal, ok := block.GetAttribute("type"); ok {
+		if runnerType, err := typeVal.AsString(); err == nil && runnerType != "vm" {
+			result.AddWarning(networkBlock.Position, "network",
+				fmt.Sprintf("network only applies to VM runners and is ignored for %s runners", runnerType))
+			return
+		}
+	}
+	if providerVal, ok := cloudBlock.GetAttribute("provider"); ok {
+		if provider, err := providerVal.AsString(); err == nil && provider == ProviderAzure {
+			result.AddWarning(networkBlock.Position, "network",
+				"azure VM runners use the network interface given to their deployment (network_interface_id); network is ignored")
+		}
+	}
+}
+
+// imageFamilyPattern matches image families such as ubuntu-22-04-docker
+var imageFamilyPattern = regexp.MustCompile(`^[a-z]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// imageIDPatterns match the image IDs of each provider
+var imageIDPatterns = map[string]*regexp.Regexp{
+	ProviderYandex: regexp.MustCompile(`^[a-z0-9]{20}$`),
+	ProviderAWS:    regexp.MustCompile(`^ami-([0-9a-f]{8}|[0-9a-f]{17})$`),
+	ProviderAzure:  regexp.MustCompile(`^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Compute/(images|galleries)/.+$`),
+}
+
+// imageIDExamples are examples of the image IDs of each provider, for errors
+var imageIDExamples = map[string]string{
+	ProviderYandex: "fd8abcdefghijklmnopq",
+	ProviderAWS:    "ami-0123456789abcdef0",
+	ProviderAzure:  "/subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Compute/images/<name>",
+}
+
+// checkRunnerImage validates the runner's image or image_id. They only apply
+// to VM runners, exclude each other, and an image_id must have the format of
+// its provider's image IDs.
+func checkRunnerImage(block *Block, result *ValidationResult) {
+	runnerBlock, ok := block.GetBlock("runner")
+	if !ok {
+		return
+	}
+	imageVal, hasImage := runnerBlock.GetAttribute("image")
+	imageIDVal, hasImageID := runnerBlock.GetAttribute("image_id")
+	if !hasImage && !hasImageID {
+		return
+	}
+
+	if typeVal, ok := block.GetAttribute("type"); ok {
+		if runnerType, err := typeVal.AsString(); err == nil && runnerType != "vm" {
+			for _, name := range []string{"image", "image_id"} {
+				if val, ok := runnerBlock.GetAttribute(name); ok {
+					result.AddWarning(val.Position, name,
+						fmt.Sprintf("%s only applies to VM runners and is ignored for %s runners", name, runnerType))
+				}
+			}
+			return
+		}
+	}
+	if hasImage && hasImageID {
+		result.AddError(imageIDVal.Position, "image_id", "set either image or image_id, not both")
+		return
+	}
+	if hasImage {
+		if image, err := imageVal.AsString(); err == nil && !imageFamilyPattern.MatchString(image) {
+			result.AddError(imageVal.Position, "image",
+				fmt.Sprintf("image must be an image family of lowercase letters, digits and hyphens (e.g. ubuntu-22-04-docker), got %q", image))
+		}
+	}
+
+	cloudBlock, ok := block.GetBlock("cloud")
+	if !ok {
+		return
+	}
+	providerVal, ok := cloudBlock.GetAttribute("provider")
+	if !ok {
+		return
+	}
+	provider, err := providerVal.AsString()
+	if err != nil || providerRegions[provider] == nil {
+		return
+	}
+	if hasImage && provider == ProviderAzure {
+		result.AddError(imageVal.Position, "image",
+			"azure VM runners do not support image families; set image_id to an image resource ID")
+		return
+	}
+	if !hasImageID {
+		return
+	}
+	if imageID, err := imageIDVal.AsString(); err == nil && !imageIDPatterns[provider].MatchString(imageID) {
+		result.AddError(imageIDVal.Position, "image_id",
+			fmt.Sprintf("invalid %s image_id %q: expected an ID such as %s", provider, imageID, imageIDExamples[provider]))
+	}
+}
+
+// runnerOSes and runnerArchs are the operating systems and architectures
+// the runners of each provider and runner type run on. Kubernetes runners
+// schedule their jobs on nodes of that OS and architecture.
+var (
+	runnerOSes = map[string]map[string][]string{
+		ProviderYandex: {"vm": {OSLinux, OSWindows}, "serverless": {OSLinux}, "kubernetes": {OSLinux, OSWindows}},
+		ProviderAWS:    {"vm": {OSLinux, OSWindows, OSMacOS}, "serverless": {OSLinux}, "kubernetes": {OSLinux, OSWindows}},
+		ProviderAzure:  {"vm": {OSLinux, OSWindows}, "serverless": {OSLinux}, "kubernetes": {OSLinux, OSWindows}},
+	}
+	runnerArchs = map[string]map[string][]string{
+		ProviderYandex: {"vm": {ArchAMD64}, "serverless": {ArchAMD64}, "kubernetes": {ArchAMD64, ArchARM64}},
+		ProviderAWS:    {"vm": {ArchAMD64, ArchARM64}, "serverless": {ArchAMD64, ArchARM64}, "kubernetes": {ArchAMD64, ArchARM64}},
+		ProviderAzure:  {"vm": {ArchAMD64}, "serverless": {ArchAMD64}, "kubernetes": {ArchAMD64, ArchARM64}},
+	}
+)
+
+// awsARMPlatforms are the instance families of AWS Graviton (arm64) CPUs
+var awsARMPlatforms = map[string]bool{"m7g": true, "c7g": true, "r7g": true}
+
+// checkRunnerOS validates the runner's os and arch against what its cloud
+// provider offers for its runner type. Windows runs on amd64 only, runners
+// other than linux need an image of their OS, and on AWS the platform must
+// match the architecture.
+func checkRunnerOS(block *Block, result *ValidationResult) {
+	runnerBlock, ok := block.GetBlock("runner")
+	if !ok {
+		return
+	}
+	osVal, hasOS := runnerBlock.GetAttribute("os")
+	archVal, hasArch := runnerBlock.GetAttribute("arch")
+	if !hasOS && !hasArch {
+		return
+	}
+	runnerOS, arch := OSLinux, ArchAMD64
+	var err error
+	if hasOS {
+		if runnerOS, err = osVal.AsString(); err != nil {
+			return
+		}
+	}
+	if hasArch {
+		if arch, err = archVal.AsString(); err != nil {
+			return
+		}
+	}
+	if runnerOS == OSWindows && arch != ArchAMD64 {
+		result.AddError(archVal.Position, "arch", fmt.Sprintf("windows runners only run on %s, got arch %q", ArchAMD64, arch))
+	}
+
+	typeVal, ok := block.GetAttribute("type")
+	if !ok {
+		return
+	}
+	runnerType, err := typeVal.AsString()
+	if err != nil {
+		return
+	}
+	if runnerType == "vm" && runnerOS != OSLinux {
+		_, hasImage := runnerBlock.GetAttribute("image")
+		_, hasImageID := runnerBlock.GetAttribute("image_id")
+		if !hasImage && !hasImageID {
+			result.AddError(osVal.Position, "os", fmt.Sprintf("%s runners require image or image_id; the default images are linux", runnerOS))
+		}
+	}
+
+	cloudBlock, ok := block.GetBlock("cloud")
+	if !ok {
+		return
+	}
+	providerVal, ok := cloudBlock.GetAttribute("provider")
+	if !ok {
+		return
+	}
+	provider, err := providerVal.AsString()
+	if err != nil || runnerOSes[provider] == nil {
+		return
+	}
+	if supported := runnerOSes[provider][runnerType]; hasOS && !containsString(supported, runnerOS) {
+		result.AddError(osVal.Position, "os",
+			fmt.Sprintf("%s %s runners support os %s, got %q", provider, runnerType, strings.Join(supported, ", "), runnerOS))
+	}
+	if supported := runnerArchs[provider][runnerType]; hasArch && !containsString(supported, arch) {
+		result.AddError(archVal.Position, "arch",
+			fmt.Sprintf("%s %s runners support arch %s, got %q", provider, runnerType, strings.Join(supported, ", "), arch))
+	}
+	if provider != ProviderAWS || runnerType != "vm" {
+		return
+	}
+
+	resourcesBlock, ok := block.GetBlock("resources")
+	if !ok {
+		return
+	}
+	if gpuTypeVal, ok := resourcesBlock.GetAttribute("gpu_type"); ok && arch == ArchARM64 {
+		result.AddError(gpuTypeVal.Position, "gpu_type", "arm64 aws VM runners do not support GPUs")
+	}
+	platformVal, ok := resourcesBlock.GetAttribute("platform")
+	if !ok {
+		return
+	}
+	platform, err := platformVal.AsString()
+	if err != nil || !providerPlatforms[provider][platform] {
+		return
+	}
+	switch {
+	case arch == ArchARM64 && !awsARMPlatforms[platform]:
+		result.AddError(platformVal.Position, "platform",
+			fmt.Sprintf("arm64 aws VM runners need a Graviton platform (%s), got %q", strings.Join(sortedKeys(awsARMPlatforms), ", "), platform))
+	case arch != ArchARM64 && awsARMPlatforms[platform]:
+		result.AddError(platformVal.Position, "platform",
+			fmt.Sprintf("platform %q has arm64 CPUs; set arch = %q", platform, ArchARM64))
+	}
+}
+
+// checkAutoscaling warns about an egg's autoscaling block when deploy would
+// ignore it: serverless runners are scaled by their cloud provider
+func checkAutoscaling(block *Block, result *ValidationResult) {
+	autoscalingBlock, ok := block.GetBlock("autoscaling")
+	if !ok {
+		return
+	}
+	if typeVal, ok := block.GetAttribute("type"); ok {
+		if runnerType, err := typeVal.AsString(); err == nil && runnerType != "vm" {
+			scaler := "their cloud provider scales"
+			if runnerType == "kubernetes" {
+				scaler = "run each job in a pod of their cluster"
+			}
+			result.AddWarning(autoscalingBlock.Position, "autoscaling",
+				fmt.Sprintf("autoscaling only applies to VM runners and is ignored for %s runners, which %s", runnerType, scaler))
+		}
+	}
+}
+
+// checkKubernetesTarget checks that kubernetes runners have a kubernetes
+// block, and warns about one on other runners, which ignore it
+func checkKubernetesTarget(block *Block, result *ValidationResult) {
+	typeVal, ok := block.GetAttribute("type")
+	if !ok {
+		return
+	}
+	runnerType, err := typeVal.AsString()
+	if err != nil {
+		return
+	}
+	kubernetesBlock, ok := block.GetBlock("kubernetes")
+	switch {
+	case runnerType == "kubernetes" && !ok:
+		result.AddError(block.Position, "kubernetes", "kubernetes runners require a kubernetes block with the namespace and kubeconfig_secret of their cluster")
+	case runnerType != "kubernetes" && ok:
+		result.AddWarning(kubernetesBlock.Position, "kubernetes",
+			fmt.Sprintf("kubernetes only applies to kubernetes runners and is ignored for %s runners", runnerType))
+	}
+}
+
+// checkJobTimeout validates the runner's job_timeout against the limit of the
+// serverless runners of its cloud provider. VM runners ignore it.
+func checkJobTimeout(block *Block, result *ValidationResult) {
+	runnerBlock, ok := block.GetBlock("runner")
+	if !ok {
+		return
+	}
+	timeoutVal, ok := runnerBlock.GetAttribute("job_timeout")
+	if !ok {
+		return
+	}
+	typeVal, ok := block.GetAttribute("type")
+	if !ok {
+		return
+	}
+	if runnerType, err := typeVal.AsString(); err != nil || runnerType != "serverless" {
+		if err == nil {
+			result.AddWarning(timeoutVal.Position, "job_timeout",
+				fmt.Sprintf("job_timeout only applies to serverless runners; jobs on %s runners use the project's timeout", runnerLabel(runnerType)))
+		}
+		return
+	}
+	cloudBlock, ok := block.GetBlock("cloud")
+	if !ok {
+		return
+	}
+	providerVal, ok := cloudBlock.GetAttribute("provider")
+	if !ok {
+		return
+	}
+	provider, err := providerVal.AsString()
+	if err != nil {
+		return
+	}
+	str, err := timeoutVal.AsString()
+	if err != nil {
+		return
+	}
+	timeout, err := time.ParseDuration(str)
+	if err != nil {
+		return
+	}
+	if limit := ServerlessJobTimeout(provider); timeout > limit {
+		result.AddError(timeoutVal.Position, "job_timeout",
+			fmt.Sprintf("%s serverless runners stop jobs after %d minutes, got job_timeout %s", provider, int(limit/time.Minute), str))
+	}
+}
+
+// runnerLabel returns how messages name the runners of runnerType
+func runnerLabel(runnerType string) string {
+	if runnerType == "vm" {
+		return "VM"
+	}
+	return runnerType
+}
+
+func containsString(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, v int) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
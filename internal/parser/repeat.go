@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// An egg block setting count or for_each stands for one egg per instance,
+// named by labels that may refer to the instance:
+//
+//	egg "worker-${count.index}" {
+//	  count = 5
+//	}
+//
+//	egg "runner-${each.key}" {
+//	  for_each = { small = 2, large = 8 }
+//	  resources {
+//	    cpu = each.value
+//	  }
+//	}
+//
+// for_each takes a map, or a list of strings whose items are both key and
+// value. Instances are expanded while parsing, so the rest of gosling sees
+// plain egg blocks.
+const (
+	countAttribute   = "count"
+	forEachAttribute = "for_each"
+	// maxInstances guards against a typo stamping out thousands of eggs
+	maxInstances = 1000
+)
+
+// repeated reports whether block is expanded into instances
+func repeated(block *hclsyntax.Block) bool {
+	if block.Type != "egg" {
+		return false
+	}
+	_, count := block.Body.Attributes[countAttribute]
+	_, forEach := block.Body.Attributes[forEachAttribute]
+	return count || forEach
+}
+
+// withoutLabelErrors drops HCL's errors about the templates in the labels of
+// block, which HCL does not allow but repeated blocks evaluate
+func withoutLabelErrors(diags hcl.Diagnostics, block *hclsyntax.Block) hcl.Diagnostics {
+	kept := diags[:0]
+	for _, diag := range diags {
+		inLabel := false
+		for _, rng := range block.LabelRanges {
+			if diag.Subject != nil && rng.ContainsOffset(diag.Subject.Start.Byte) {
+				inLabel = true
+			}
+		}
+		if !inLabel {
+			kept = append(kept, diag)
+		}
+	}
+	return kept
+}
+
+// repeatInstance is the value of count or each in one instance
+type repeatInstance struct {
+	name  string // count or each
+	value cty.Value
+}
+
+// expandBlock parses an instance of a repeated block for each index of count
+// or element of for_each. content is the source of the block, for its labels.
+func (p *Parser) expandBlock(hclBlock *hclsyntax.Block, content []byte, filename string, ctx *hcl.EvalContext) ([]Block, hcl.Diagnostics) {
+	instances, diags := repeatInstances(hclBlock, ctx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var blocks []Block
+	names := make(map[string]int)
+	for i, instance := range instances {
+		instanceCtx := &hcl.EvalContext{
+			Variables: map[string]cty.Value{"var": ctx.Variables["var"], instance.name: instance.value},
+			Functions: ctx.Functions,
+		}
+		labels, labelDiags := instanceLabels(hclBlock, content, filename, instanceCtx)
+		block, blockDiags := p.parseBlock(hclBlock, filename, instanceCtx)
+		if labelDiags.HasErrors() || blockDiags.HasErrors() {
+			// The other instances most likely repeat the errors
+			return nil, append(append(diags, labelDiags...), blockDiags...)
+		}
+		if labels == nil {
+			continue // Unknown, as the file already has errors
+		}
+		delete(block.Attributes, countAttribute)
+		delete(block.Attributes, forEachAttribute)
+		block.Labels = labels
+		block.Repeated = true
+
+		name := strings.Join(labels, " ")
+		if prev, dup := names[name]; dup {
+			rng := hclBlock.DefRange()
+			diags = append(diags, newDiagnostic(rng, CodeDuplicateBlock, fmt.Sprintf("Duplicate %s name", hclBlock.Type),
+				fmt.Sprintf("Instances %d and %d are both named %q; use ${count.index} or ${each.key} in the label.", prev, i, name)))
+			continue
+		}
+		names[name] = i
+		blocks = append(blocks, *block)
+	}
+	return blocks, diags
+}
+
+// repeatInstances evaluates count or for_each. It returns no instances
+// without diagnostics when the value is unknown.
+func repeatInstances(hclBlock *hclsyntax.Block, ctx *hcl.EvalContext) ([]repeatInstance, hcl.Diagnostics) {
+	countAttr, hasCount := hclBlock.Body.Attributes[countAttribute]
+	forEachAttr, hasForEach := hclBlock.Body.Attributes[forEachAttribute]
+	if hasCount && hasForEach {
+		return nil, hcl.Diagnostics{newDiagnostic(forEachAttr.NameRange, CodeConstraint, "Invalid combination of count and for_each",
+			"A block may set count or for_each, not both.")}
+	}
+
+	attr := countAttr
+	if hasForEach {
+		attr = forEachAttr
+	}
+	if diags := undeclaredVariables(attr.Expr, ctx); diags.HasErrors() {
+		return nil, diags
+	}
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return nil, expressionDiagnostics(diags)
+	}
+	if !val.IsWhollyKnown() {
+		return nil, nil
+	}
+	rng := attr.Expr.Range()
+
+	if hasCount {
+		count, ok := wholeNumber(val)
+		// An empty file has nothing to deploy, so zero instances is a mistake
+		if !ok || count < 1 || count > maxInstances {
+			return nil, hcl.Diagnostics{newDiagnostic(rng, CodeRange, "Invalid count", fmt.Sprintf("count must be a whole number between 1 and %d.", maxInstances))}
+		}
+		instances := make([]repeatInstance, count)
+		for i := range instances {
+			instances[i] = repeatInstance{name: "count", value: cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(int64(i))})}
+		}
+		return instances, nil
+	}
+
+	ty := val.Type()
+	isMap := ty.IsMapType() || ty.IsObjectType()
+	if val.IsNull() || !(isMap || ty.IsListType() || ty.IsTupleType() || ty.IsSetType()) {
+		return nil, hcl.Diagnostics{newDiagnostic(rng, CodeType, "Invalid for_each", "for_each must be a map, or a list of strings.")}
+	}
+	if n := val.LengthInt(); n < 1 || n > maxInstances {
+		return nil, hcl.Diagnostics{newDiagnostic(rng, CodeRange, "Invalid for_each", fmt.Sprintf("for_each must have between 1 and %d elements.", maxInstances))}
+	}
+	var instances []repeatInstance
+	for it := val.ElementIterator(); it.Next(); {
+		key, elem := it.Element()
+		if !isMap {
+			if elem.IsNull() || elem.Type() != cty.String {
+				return nil, hcl.Diagnostics{newDiagnostic(rng, CodeType, "Invalid for_each", "The items of a for_each list must be strings.")}
+			}
+			key = elem
+		}
+		instances = append(instances, repeatInstance{name: "each", value: cty.ObjectVal(map[string]cty.Value{"key": key, "value": elem})})
+	}
+	return instances, nil
+}
+
+// instanceLabels evaluates the labels of a repeated block as templates, or
+// returns nil when they are unknown. HCL rejects templates in labels, so
+// they are read from content.
+func instanceLabels(hclBlock *hclsyntax.Block, content []byte, filename string, ctx *hcl.EvalContext) ([]string, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	labels := make([]string, len(hclBlock.LabelRanges))
+	for i, rng := range hclBlock.LabelRanges {
+		// The label without its quotes
+		start := hcl.Pos{Line: rng.Start.Line, Column: rng.Start.Column + 1, Byte: rng.Start.Byte + 1}
+		expr, parseDiags := hclsyntax.ParseTemplate(content[start.Byte:rng.End.Byte-1], filename, start)
+		if parseDiags.HasErrors() {
+			diags = append(diags, parseDiags...)
+			continue
+		}
+		if undeclared := undeclaredVariables(expr, ctx); undeclared.HasErrors() {
+			diags = append(diags, undeclared...)
+			continue
+		}
+		val, valDiags := expr.Value(ctx)
+		if valDiags.HasErrors() {
+			diags = append(diags, expressionDiagnostics(valDiags)...)
+			continue
+		}
+		if !val.IsKnown() {
+			return nil, diags
+		}
+		if val.IsNull() || val.Type() != cty.String {
+			diags = append(diags, newDiagnostic(rng, CodeLabels, "Invalid label", "The label must evaluate to a string."))
+			continue
+		}
+		labels[i] = val.AsString()
+	}
+	return labels, diags
+}
+
+// wholeNumber returns val if it is a whole number
+func wholeNumber(val cty.Value) (int64, bool) {
+	if val.IsNull() || val.Type() != cty.Number {
+		return 0, false
+	}
+	f := val.AsBigFloat()
+	if !f.IsInt() {
+		return 0, false
+	}
+	n, acc := f.Int64()
+	return n, acc == big.Exact
+}
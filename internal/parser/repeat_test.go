@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const repeatedEggBody = `
+  type = "vm"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  runner {
+    tags       = ["docker"]
+    concurrent = 2
+  }
+
+  gitlab {
+    project_id   = 12345
+    server_name  = "gitlab.com"
+    token_secret = "yc-lockbox://gitlab/runner-token"
+  }
+`
+
+func TestParseCount(t *testing.T) {
+	content := `
+variable "workers" {
+  default = 3
+}
+
+egg "worker-${count.index}" {
+  count = var.workers
+` + repeatedEggBody + `
+  resources {
+    cpu    = 2
+    memory = 2048 * (count.index + 1)
+    disk   = 20
+  }
+}
+`
+	config, err := NewParser().Parse([]byte(content), "test.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(config.Blocks) != 3 {
+		t.Fatalf("expected 3 eggs, got %d", len(config.Blocks))
+	}
+	for i, block := range config.Blocks {
+		if want := []string{"worker-" + string(rune('0'+i))}; !labelsEqual(block.Labels, want) {
+			t.Errorf("egg %d: expected labels %v, got %v", i, want, block.Labels)
+		}
+		if _, ok := block.GetAttribute("count"); ok {
+			t.Errorf("egg %d: expected count to be removed", i)
+		}
+		if !block.Repeated {
+			t.Errorf("egg %d: expected the instance to be marked repeated", i)
+		}
+		resources, _ := block.GetBlock("resources")
+		if memory, _ := resources.GetAttribute("memory"); memory.String() != []string{"2048", "4096", "6144"}[i] {
+			t.Errorf("egg %d: unexpected memory %s", i, memory.String())
+		}
+		if result := NewValidator(&Config{Blocks: []Block{block}}).Validate(); !result.IsValid() {
+			t.Errorf("egg %d: expected a valid egg: %v", i, result.Error())
+		}
+	}
+
+	if result := ValidateNest([]*Config{config}); !result.IsValid() {
+		t.Errorf("expected instances to share their project: %v", result.Error())
+	}
+}
+
+func TestParseForEach(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		forEach string
+		labels  []string
+		cpus    []string
+	}{
+		{"map", `{ small = 2, large = 8 }`, []string{"runner-large", "runner-small"}, []string{"8", "2"}},
+		{"list", `["2", "4"]`, []string{"runner-2", "runner-4"}, []string{`"2"`, `"4"`}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			content := `egg "runner-${each.key}" {
+  for_each = ` + tt.forEach + `
+  resources {
+    cpu = each.value
+  }
+}
+`
+			config, err := NewParser().Parse([]byte(content), "test.fly")
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(config.Blocks) != len(tt.labels) {
+				t.Fatalf("expected %d eggs, got %d", len(tt.labels), len(config.Blocks))
+			}
+			for i, block := range config.Blocks {
+				if block.Labels[0] != tt.labels[i] {
+					t.Errorf("egg %d: expected %s, got %s", i, tt.labels[i], block.Labels[0])
+				}
+				resources, _ := block.GetBlock("resources")
+				if cpu, _ := resources.GetAttribute("cpu"); cpu.String() != tt.cpus[i] {
+					t.Errorf("egg %d: expected cpu %s, got %s", i, tt.cpus[i], cpu.String())
+				}
+				if _, ok := block.GetAttribute("for_each"); ok {
+					t.Errorf("egg %d: expected for_each to be removed", i)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRepeatErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		content string
+		code    string
+		message string
+	}{
+		{"duplicate names", `egg "worker" {
+  count = 2
+}`, CodeDuplicateBlock, `Instances 0 and 1 are both named "worker"`},
+		{"count and for_each", `egg "worker-${count.index}" {
+  count    = 2
+  for_each = ["a"]
+}`, CodeConstraint, "not both"},
+		{"fractional count", `egg "worker-${count.index}" {
+  count = 1.5
+}`, CodeRange, "whole number"},
+		{"zero count", `egg "worker-${count.index}" {
+  count = 0
+}`, CodeRange, "between 1 and"},
+		{"empty for_each", `egg "worker-${each.key}" {
+  for_each = {}
+}`, CodeRange, "between 1 and"},
+		{"for_each of numbers", `egg "worker-${each.key}" {
+  for_each = [1, 2]
+}`, CodeType, "must be strings"},
+		{"unknown reference", `egg "worker-${each.key}" {
+  count = 2
+}`, CodeExpression, `"each"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().Parse([]byte(tt.content), "test.fly")
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected a SyntaxError, got %v", err)
+			}
+			if len(syntaxErr.Diagnostics) != 1 || DiagnosticCode(syntaxErr.Diagnostics[0]) != tt.code {
+				t.Fatalf("expected one %s diagnostic, got:\n%v", tt.code, err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected %q in:\n%v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestParseTemplateLabelWithoutRepeat(t *testing.T) {
+	_, err := NewParser().Parse([]byte("egg \"worker-${var.name}\" {\n}\n"), "test.fly")
+	if err == nil || !strings.Contains(err.Error(), "Template sequences are not allowed") {
+		t.Errorf("expected labels of other blocks to stay literal, got %v", err)
+	}
+}
@@ -0,0 +1,420 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AttrType is the expected type of an attribute value
+type AttrType string
+
+const (
+	AttrString     AttrType = "string"
+	AttrNumber     AttrType = "number"
+	AttrInteger    AttrType = "integer"
+	AttrBool       AttrType = "bool"
+	AttrList       AttrType = "list"
+	AttrStringList AttrType = "list(string)"
+	AttrMap        AttrType = "map"
+)
+
+// AttributeSchema describes a single attribute of a block
+type AttributeSchema struct {
+	Name        string   `json:"name"`
+	Type        AttrType `json:"type"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description,omitempty"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Format      string   `json:"format,omitempty"` // e.g. "duration", "cron"
+	// MinDuration and MaxDuration bound "duration" attributes, written as Go durations (e.g. "1m")
+	MinDuration string `json:"min_duration,omitempty"`
+	MaxDuration string `json:"max_duration,omitempty"`
+	// Deprecated marks an attribute being phased out
+	Deprecated *Deprecation `json:"deprecated,omitempty"`
+	// Recommended, when set, tells why the optional attribute should be set
+	Recommended string `json:"recommended,omitempty"`
+
+	// ElemName names a single list element in error messages (e.g. "tag")
+	ElemName string `json:"-"`
+	// Check runs after type, range and enum checks pass
+	Check func(val Value, result *ValidationResult) `json:"-"`
+}
+
+// NestedBlockSchema describes a nested block and how many times it may occur
+type NestedBlockSchema struct {
+	Required bool         `json:"required"`
+	Multiple bool         `json:"multiple"`
+	MinItems int          `json:"min_items,omitempty"`
+	Schema   *BlockSchema `json:"schema"`
+	// AnyType matches nested blocks of every type not described by another
+	// entry, for blocks named by the user (e.g. the queues of message_queues).
+	// Schema.Type then only names the blocks in documentation. Implies Multiple.
+	AnyType bool `json:"any_type,omitempty"`
+	// Deprecated marks a nested block being phased out
+	Deprecated *Deprecation `json:"deprecated,omitempty"`
+}
+
+// BlockSchema declaratively describes a block: its labels, attributes and
+// nested blocks. Unknown attributes and blocks are ignored unless the
+// validator is strict, so schemas can be introduced gradually without
+// breaking existing configurations.
+type BlockSchema struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Label       string `json:"label,omitempty"`      // e.g. "egg name"; empty means no labels allowed
+	FreeLabel   bool   `json:"free_label,omitempty"` // label need not be an identifier
+	// FreeAttributes blocks accept attributes of any name (e.g. environment variables)
+	FreeAttributes bool                `json:"free_attributes,omitempty"`
+	Attributes     []AttributeSchema   `json:"attributes,omitempty"`
+	Blocks         []NestedBlockSchema `json:"blocks,omitempty"`
+
+	// Open blocks accept any labels (used for blocks whose contents are not yet described)
+	Open bool `json:"open,omitempty"`
+	// Check runs cross-field validation after the schema checks
+	Check func(block *Block, result *ValidationResult) `json:"-"`
+}
+
+// Attribute returns the schema of a named attribute
+func (s *BlockSchema) Attribute(name string) (*AttributeSchema, bool) {
+	for i := range s.Attributes {
+		if s.Attributes[i].Name == name {
+			return &s.Attributes[i], true
+		}
+	}
+	return nil, false
+}
+
+// NestedBlock returns the schema of a named nested block
+func (s *BlockSchema) NestedBlock(blockType string) (*NestedBlockSchema, bool) {
+	for i := range s.Blocks {
+		if s.Blocks[i].Schema.Type == blockType {
+			return &s.Blocks[i], true
+		}
+	}
+	return nil, false
+}
+
+var (
+	schemaMu sync.RWMutex
+	schemas  = make(map[string]*BlockSchema)
+)
+
+// RegisterSchema adds a top-level block schema to the registry, replacing
+// any existing schema for the same block type
+func RegisterSchema(schema *BlockSchema) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	schemas[schema.Type] = schema
+}
+
+// LookupSchema returns the registered schema for a top-level block type
+func LookupSchema(blockType string) (*BlockSchema, bool) {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	schema, ok := schemas[blockType]
+	return schema, ok
+}
+
+// SchemaTypes returns the registered top-level block types in sorted order
+func SchemaTypes() []string {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	types := make([]string, 0, len(schemas))
+	for t := range schemas {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// validateWithSchema validates a block against its schema
+func (v *Validator) validateWithSchema(block *Block, schema *BlockSchema) {
+	if !schema.Open {
+		if schema.Label != "" {
+			if len(block.Labels) != 1 {
+				v.result.addError(block.Position, "labels", CodeLabels,
+					fmt.Sprintf("%s block must have exactly one label (the %s)", block.Type, schema.Label))
+				return
+			}
+			if !schema.FreeLabel && !isValidIdentifier(block.Labels[0]) {
+				v.result.addError(block.Position, "name", CodeInvalidName,
+					fmt.Sprintf("invalid %s %q: must contain only alphanumeric characters, hyphens, and underscores", schema.Label, block.Labels[0]))
+			}
+		} else if len(block.Labels) > 0 {
+			v.result.addError(block.Position, "labels", CodeLabels,
+				fmt.Sprintf("%s block should not have labels", block.Type))
+		}
+	}
+
+	for i := range schema.Attributes {
+		v.validateAttribute(block, &schema.Attributes[i])
+	}
+
+	for _, nested := range schema.Blocks {
+		if nested.Deprecated != nil && !nested.AnyType {
+			for _, deprecated := range block.GetBlocks(nested.Schema.Type) {
+				v.result.addWarning(deprecated.Position, deprecated.Type, CodeDeprecated, nested.Deprecated.message(deprecated.Type+" block"))
+			}
+		}
+		if nested.AnyType {
+			v.validateAnyTypeBlocks(block, schema, nested)
+			continue
+		}
+		blockType := nested.Schema.Type
+		if !nested.Multiple {
+			nestedBlock, ok := block.GetBlock(blockType)
+			if !ok {
+				if nested.Required {
+					v.result.addError(block.Position, blockType, CodeMissingBlock,
+						fmt.Sprintf("%s block must have a '%s' nested block", block.Type, blockType))
+				}
+				continue
+			}
+			v.validateWithSchema(nestedBlock, nested.Schema)
+			for _, dup := range block.GetBlocks(blockType)[1:] {
+				v.result.addError(dup.Position, blockType, CodeDuplicateBlock,
+					fmt.Sprintf("%s block must have only one '%s' block", block.Type, blockType))
+			}
+			continue
+		}
+
+		nestedBlocks := block.GetBlocks(blockType)
+		if len(nestedBlocks) < nested.MinItems {
+			v.result.addError(block.Position, blockType, CodeMissingBlock,
+				fmt.Sprintf("%s block must have at least %s '%s' block", block.Type, countWord(nested.MinItems), blockType))
+		}
+		for i := range nestedBlocks {
+			v.validateWithSchema(&nestedBlocks[i], nested.Schema)
+		}
+	}
+
+	if v.strict && !schema.Open {
+		v.checkUnknown(block, schema)
+	}
+
+	if schema.Check != nil {
+		schema.Check(block, v.result)
+	}
+}
+
+// checkUnknown reports the attributes and nested blocks of block that schema
+// does not describe, suggesting the closest known name
+func (v *Validator) checkUnknown(block *Block, schema *BlockSchema) {
+	if !schema.FreeAttributes {
+		known := make([]string, 0, len(schema.Attributes))
+		for _, attr := range schema.Attributes {
+			known = append(known, attr.Name)
+		}
+		names := make([]string, 0, len(block.Attributes))
+		for name := range block.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, ok := schema.Attribute(name); !ok {
+				v.result.addError(block.Attributes[name].Position, name, CodeUnknown, unknownMessage("attribute", name, block.Type, known))
+			}
+		}
+	}
+
+	known := make([]string, 0, len(schema.Blocks))
+	for _, nested := range schema.Blocks {
+		if nested.AnyType {
+			return // Every block type is accepted
+		}
+		known = append(known, nested.Schema.Type)
+	}
+	for i := range block.Blocks {
+		if _, ok := schema.NestedBlock(block.Blocks[i].Type); !ok {
+			v.result.addError(block.Blocks[i].Position, block.Blocks[i].Type, CodeUnknown, unknownMessage("block", block.Blocks[i].Type, block.Type, known))
+		}
+	}
+}
+
+// unknownMessage describes an unknown attribute or block, with a suggestion
+// when the name looks like a typo of a known one
+func unknownMessage(kind, name, blockType string, known []string) string {
+	msg := fmt.Sprintf("unknown %s %q in %s block", kind, name, blockType)
+	if suggestion := closest(name, known); suggestion != "" {
+		msg += fmt.Sprintf("; did you mean %q?", suggestion)
+	}
+	return msg
+}
+
+// validateAnyTypeBlocks validates the nested blocks of block that no other
+// entry of schema describes against nested.Schema
+func (v *Validator) validateAnyTypeBlocks(block *Block, schema *BlockSchema, nested NestedBlockSchema) {
+	var count int
+	for i := range block.Blocks {
+		if other, ok := schema.NestedBlock(block.Blocks[i].Type); ok && !other.AnyType {
+			continue
+		}
+		count++
+		v.validateWithSchema(&block.Blocks[i], nested.Schema)
+	}
+	if count < nested.MinItems {
+		v.result.addError(block.Position, nested.Schema.Type, CodeMissingBlock,
+			fmt.Sprintf("%s block must have at least %s %s block", block.Type, countWord(nested.MinItems), nested.Schema.Type))
+	}
+}
+
+// validateAttribute validates a single attribute against its schema
+func (v *Validator) validateAttribute(block *Block, attr *AttributeSchema) {
+	v.coerceAttribute(block, attr)
+	val, ok := block.GetAttribute(attr.Name)
+	if !ok {
+		if attr.Required {
+			v.result.addError(block.Position, attr.Name, CodeMissingAttribute,
+				fmt.Sprintf("%s block must have %s '%s' attribute", block.Type, article(attr.Name), attr.Name))
+		} else if attr.Recommended != "" {
+			v.result.addWarning(block.Position, attr.Name, CodeRecommended,
+				fmt.Sprintf("%s block should set '%s': %s", block.Type, attr.Name, attr.Recommended))
+		}
+		return
+	}
+	if attr.Deprecated != nil {
+		v.result.addWarning(val.Position, attr.Name, CodeDeprecated, attr.Deprecated.message(attr.Name))
+	}
+
+	typeHint := ""
+	if attr.Format == "duration" {
+		typeHint = " (duration)"
+	}
+
+	switch attr.Type {
+	case AttrString:
+		str, err := val.AsString()
+		if err != nil {
+			v.result.addError(val.Position, attr.Name, CodeType,
+				fmt.Sprintf("%s must be a string%s", attr.Name, typeHint))
+			return
+		}
+		if len(attr.Enum) > 0 && !contains(attr.Enum, str) {
+			v.result.addError(val.Position, attr.Name, CodeValue,
+				fmt.Sprintf("%s must be %s, got %q", attr.Name, enumDescription(attr.Enum), str))
+			return
+		}
+		if attr.Format == "duration" {
+			if msg := durationViolation(attr, str); msg != "" {
+				v.result.addError(val.Position, attr.Name, CodeDuration, msg)
+				return
+			}
+		}
+	case AttrNumber, AttrInteger:
+		num, err := val.AsNumber()
+		if err != nil {
+			v.result.addError(val.Position, attr.Name, CodeType,
+				fmt.Sprintf("%s must be a number", attr.Name))
+			return
+		}
+		if _, err := val.AsInt(); attr.Type == AttrInteger && err != nil {
+			v.result.addError(val.Position, attr.Name, CodeType,
+				fmt.Sprintf("%s must be a whole number, got %v", attr.Name, num))
+			return
+		}
+		if msg := rangeViolation(attr, num); msg != "" {
+			v.result.addError(val.Position, attr.Name, CodeRange, msg)
+			return
+		}
+	case AttrBool:
+		if _, err := val.AsBool(); err != nil {
+			v.result.addError(val.Position, attr.Name, CodeType,
+				fmt.Sprintf("%s must be a bool", attr.Name))
+			return
+		}
+	case AttrList, AttrStringList:
+		list, err := val.AsList()
+		if err != nil {
+			v.result.addError(val.Position, attr.Name, CodeType,
+				fmt.Sprintf("%s must be a list", attr.Name))
+			return
+		}
+		if attr.Type == AttrStringList {
+			elemName := attr.ElemName
+			if elemName == "" {
+				elemName = "element"
+			}
+			for i, elem := range list {
+				if _, err := elem.AsString(); err != nil {
+					v.result.addError(elem.Position, fmt.Sprintf("%s[%d]", attr.Name, i), CodeType,
+						fmt.Sprintf("%s must be a string", elemName))
+				}
+			}
+		}
+	case AttrMap:
+		if _, err := val.AsMap(); err != nil {
+			v.result.addError(val.Position, attr.Name, CodeType,
+				fmt.Sprintf("%s must be a map", attr.Name))
+			return
+		}
+	}
+
+	if attr.Check != nil {
+		attr.Check(val, v.result)
+	}
+}
+
+// rangeViolation returns an error message if num is outside the attribute's bounds
+func rangeViolation(attr *AttributeSchema, num float64) string {
+	switch {
+	case attr.Min != nil && attr.Max != nil && (num < *attr.Min || num > *attr.Max):
+		return fmt.Sprintf("%s must be between %v and %v, got %v", attr.Name, *attr.Min, *attr.Max, num)
+	case attr.Min != nil && attr.Max == nil && num < *attr.Min:
+		return fmt.Sprintf("%s must be at least %v, got %v", attr.Name, *attr.Min, num)
+	case attr.Max != nil && attr.Min == nil && num > *attr.Max:
+		return fmt.Sprintf("%s must be at most %v, got %v", attr.Name, *attr.Max, num)
+	}
+	return ""
+}
+
+// durationViolation parses a duration attribute and checks its bounds,
+// returning an error message or ""
+func durationViolation(attr *AttributeSchema, str string) string {
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return fmt.Sprintf("%s: expected Go duration like 10m, got '%s'", attr.Name, str)
+	}
+	minDur, _ := time.ParseDuration(attr.MinDuration)
+	maxDur, _ := time.ParseDuration(attr.MaxDuration)
+	switch {
+	case attr.MinDuration != "" && attr.MaxDuration != "" && (d < minDur || d > maxDur):
+		return fmt.Sprintf("%s must be between %s and %s, got %s", attr.Name, attr.MinDuration, attr.MaxDuration, str)
+	case attr.MinDuration != "" && d < minDur:
+		return fmt.Sprintf("%s must be at least %s, got %s", attr.Name, attr.MinDuration, str)
+	case attr.MaxDuration != "" && d > maxDur:
+		return fmt.Sprintf("%s must be at most %s, got %s", attr.Name, attr.MaxDuration, str)
+	}
+	return ""
+}
+
+// enumDescription formats allowed values for error messages
+func enumDescription(values []string) string {
+	if len(values) == 2 {
+		return fmt.Sprintf("'%s' or '%s'", values[0], values[1])
+	}
+	return fmt.Sprintf("one of %v", values)
+}
+
+// article returns "a" or "an" for the given word
+func article(word string) string {
+	if word != "" && strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an"
+	}
+	return "a"
+}
+
+func countWord(n int) string {
+	if n == 1 {
+		return "one"
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func float(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRegisterSchemaAddsBlockType(t *testing.T) {
+	widgetSchema := &BlockSchema{
+		Type:  "widget",
+		Label: "widget name",
+		Attributes: []AttributeSchema{
+			{Name: "size", Type: AttrNumber, Required: true, Min: float(1), Max: float(10)},
+			{Name: "color", Type: AttrString, Enum: []string{"red", "green", "blue"}},
+			{Name: "enabled", Type: AttrBool},
+		},
+		Blocks: []NestedBlockSchema{
+			{Multiple: true, MinItems: 1, Schema: &BlockSchema{Type: "part", Label: "part name"}},
+		},
+	}
+	RegisterSchema(widgetSchema)
+	defer func() {
+		schemaMu.Lock()
+		delete(schemas, "widget")
+		schemaMu.Unlock()
+	}()
+
+	content := []byte(`widget "w1" {
+  size    = 42
+  color   = "purple"
+  enabled = "yes"
+}
+`)
+	config, err := NewParser().Parse(content, "test.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	result := NewValidator(config).Validate()
+	fields := make(map[string]string)
+	for _, e := range result.Errors {
+		fields[e.Field] = e.Message
+	}
+
+	expected := map[string]string{
+		"size":    "size must be between 1 and 10, got 42",
+		"color":   `color must be one of [red green blue], got "purple"`,
+		"enabled": "enabled must be a bool",
+		"part":    "widget block must have at least one 'part' block",
+	}
+	for field, msg := range expected {
+		if fields[field] != msg {
+			t.Errorf("field %s: expected %q, got %q", field, msg, fields[field])
+		}
+	}
+	if len(result.Errors) != len(expected) {
+		t.Errorf("expected %d errors, got %d: %v", len(expected), len(result.Errors), result.Error())
+	}
+}
+
+func TestSchemaTypes(t *testing.T) {
+	types := SchemaTypes()
+	for _, want := range []string{"egg", "eggsbucket", "job", "mothergoose", "uglyfox"} {
+		if !contains(types, want) {
+			t.Errorf("expected schema for %q to be registered, got %v", want, types)
+		}
+	}
+}
+
+func TestEggSchemaJSON(t *testing.T) {
+	data, err := json.Marshal(EggSchema)
+	if err != nil {
+		t.Fatalf("failed to marshal egg schema: %v", err)
+	}
+
+	var decoded struct {
+		Type   string `json:"type"`
+		Blocks []struct {
+			Required bool `json:"required"`
+			Schema   struct {
+				Type       string `json:"type"`
+				Attributes []struct {
+					Name     string   `json:"name"`
+					Type     string   `json:"type"`
+					Required bool     `json:"required"`
+					Min      *float64 `json:"min"`
+					Enum     []string `json:"enum"`
+				} `json:"attributes"`
+			} `json:"schema"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal egg schema: %v", err)
+	}
+
+	if decoded.Type != "egg" {
+		t.Errorf("expected type egg, got %q", decoded.Type)
+	}
+	found := false
+	for _, nested := range decoded.Blocks {
+		if nested.Schema.Type != "resources" {
+			continue
+		}
+		found = true
+		if !nested.Required {
+			t.Error("expected resources block to be required")
+		}
+		for _, attr := range nested.Schema.Attributes {
+			if attr.Name == "cpu" && (attr.Min == nil || *attr.Min != 1) {
+				t.Errorf("expected cpu min of 1, got %v", attr.Min)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected resources block in egg schema")
+	}
+	if strings.Contains(string(data), "Check") {
+		t.Error("expected check functions to be excluded from JSON")
+	}
+}
@@ -0,0 +1,901 @@
+package parser
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Built-in schemas for the block types understood by Gosling. Nested schemas
+// are shared between block types where the .fly format reuses them.
+
+var cloudSchema = &BlockSchema{
+	Type:        "cloud",
+	Description: "Cloud provider and region to deploy into",
+	Attributes: []AttributeSchema{
+		{Name: "provider", Type: AttrString, Required: true, Enum: CloudProviders, Description: "Cloud provider"},
+		{Name: "region", Type: AttrString, Required: true, Description: "Cloud region or zone"},
+	},
+	Blocks: []NestedBlockSchema{
+		{Schema: networkSchema},
+	},
+	Check: func(block *Block, result *ValidationResult) {
+		checkCloudRegion(block, result)
+		checkNetworkIDs(block, result)
+	},
+}
+
+var networkSchema = &BlockSchema{
+	Type:        "network",
+	Description: "Network VM runners are placed in; unset IDs are module variables of the deployment",
+	Attributes: []AttributeSchema{
+		{Name: "vpc_id", Type: AttrString, Description: "VPC (network) of the runners"},
+		{Name: "subnet_id", Type: AttrString, Description: "Subnet of the runners, in the runner's region or zone"},
+		{Name: "security_groups", Type: AttrStringList, ElemName: "security group", Description: "Security group IDs applied to the runners"},
+		{Name: "public_ip", Type: AttrBool, Description: "Give runners a public IP address (default true)"},
+	},
+}
+
+var resourcesSchema = &BlockSchema{
+	Type:        "resources",
+	Description: "Compute resources for each runner; cpu, memory and disk may come from a preset",
+	Attributes: []AttributeSchema{
+		{Name: "cpu", Type: AttrInteger, Required: true, Min: float(1), Max: float(128), Description: "Number of vCPUs"},
+		{Name: "memory", Type: AttrInteger, Required: true, Min: float(512), Max: float(524288), Description: "Memory in MB (512 MB to 512 GB)"},
+		{Name: "disk", Type: AttrInteger, Required: true, Min: float(10), Max: float(10240), Description: "Disk size in GB (10 GB to 10 TB)"},
+		{
+			Name:        "type",
+			Type:        AttrString,
+			Enum:        []string{"vm", "serverless"},
+			Description: "Resource type override",
+			Deprecated: &Deprecation{
+				Replacement: "it is ignored; set type on the egg or eggsbucket block",
+				RemovedIn:   "2.0",
+				Rewrite:     moveResourcesType,
+			},
+		},
+		{Name: "preset", Type: AttrString, Description: "Size preset (small, medium, large, xlarge or one from Presets/) filling in cpu, memory and disk"},
+		{Name: "gpu", Type: AttrInteger, Min: float(1), Max: float(8), Description: "Number of GPUs of each VM runner"},
+		{Name: "gpu_type", Type: AttrString, Description: "GPU model (e.g. t4, v100, a100); decides the platform"},
+		{Name: "platform", Type: AttrString, Description: "VM platform (Yandex, e.g. standard-v3) or instance family (AWS, e.g. c7g)"},
+	},
+}
+
+var runnerSchema = &BlockSchema{
+	Type:        "runner",
+	Description: "GitLab Runner settings",
+	Attributes: []AttributeSchema{
+		{Name: "tags", Type: AttrStringList, Required: true, ElemName: "tag", Description: "Runner tags"},
+		{Name: "concurrent", Type: AttrInteger, Required: true, Min: float(1), Max: float(100), Description: "Maximum concurrent jobs"},
+		{Name: "idle_timeout", Type: AttrString, Format: "duration", MinDuration: "1m", MaxDuration: "24h", Description: "How long an idle runner is kept"},
+		{Name: "job_timeout", Type: AttrString, Format: "duration", MinDuration: "1m", MaxDuration: "60m", Description: "Longest a job may run on a serverless runner (default 60m; at most 15m on AWS)"},
+		{Name: "image", Type: AttrString, Description: "Base image family of VM runners (e.g. ubuntu-22-04-docker); the latest image of the family is used"},
+		{Name: "image_id", Type: AttrString, Description: "Provider image ID of VM runners (Yandex image ID, AWS AMI or Azure image resource ID)"},
+		{Name: "os", Type: AttrString, Enum: RunnerOSes, Description: "Operating system of the runners (default linux)"},
+		{Name: "arch", Type: AttrString, Enum: RunnerArchs, Description: "CPU architecture of the runners (default amd64)"},
+		{Name: "privileged", Type: AttrBool, Description: "Run job containers in privileged mode, with access to the host's devices (default false)"},
+		{Name: "docker_dind", Type: AttrBool, Description: "Give jobs a Docker-in-Docker daemon to build images with; requires privileged"},
+	},
+	Blocks: []NestedBlockSchema{
+		{Schema: cacheSchema},
+	},
+}
+
+// Operating systems and CPU architectures of runners
+const (
+	OSLinux   = "linux"
+	OSWindows = "windows"
+	OSMacOS   = "macos"
+	ArchAMD64 = "amd64"
+	ArchARM64 = "arm64"
+)
+
+// RunnerOSes and RunnerArchs are the values of a runner's os and arch; the
+// first of each is the default
+var (
+	RunnerOSes  = []string{OSLinux, OSWindows, OSMacOS}
+	RunnerArchs = []string{ArchAMD64, ArchARM64}
+)
+
+// Cache types of a runner's cache block
+const (
+	CacheS3    = "s3"
+	CacheGCS   = "gcs"
+	CacheLocal = "local"
+)
+
+// RunnerSecretSchemes are the schemes of the secret URIs runners resolve
+var RunnerSecretSchemes = []string{"yc-lockbox://", "aws-sm://", "azure-kv://", "vault://"}
+
+var cacheSchema = &BlockSchema{
+	Type:        "cache",
+	Description: "Cache shared by the runner's jobs",
+	Attributes: []AttributeSchema{
+		{Name: "type", Type: AttrString, Required: true, Enum: []string{CacheS3, CacheGCS, CacheLocal}, Description: "Cache storage: s3 (or S3-compatible), gcs or local"},
+		{Name: "bucket", Type: AttrString, Description: "Bucket of an s3 or gcs cache"},
+		{Name: "path", Type: AttrString, Description: "Prefix of the cache in the bucket, or directory of a local cache"},
+		{Name: "server_address", Type: AttrString, Description: "Endpoint of an S3-compatible store (e.g. storage.yandexcloud.net)"},
+		{Name: "credentials_secret", Type: AttrString, Description: "Secret URI of the credentials of an s3 or gcs cache"},
+		{Name: "shared", Type: AttrBool, Description: "Share the cache between runners (default false)"},
+	},
+	Check: checkCache,
+}
+
+// checkCache validates the bucket, server_address and credentials_secret of
+// a cache block against its type
+func checkCache(block *Block, result *ValidationResult) {
+	typeVal, ok := block.GetAttribute("type")
+	if !ok {
+		return
+	}
+	cacheType, err := typeVal.AsString()
+	if err != nil {
+		return
+	}
+	if cacheType == CacheLocal {
+		for _, name := range []string{"bucket", "server_address", "credentials_secret"} {
+			if val, ok := block.GetAttribute(name); ok {
+				result.AddError(val.Position, name, fmt.Sprintf("%s only applies to s3 and gcs caches", name))
+			}
+		}
+		return
+	}
+	if val, ok := block.GetAttribute("server_address"); ok && cacheType != CacheS3 {
+		result.AddError(val.Position, "server_address", "server_address only applies to s3 caches")
+	}
+
+	if bucketVal, ok := block.GetAttribute("bucket"); !ok {
+		result.AddError(block.Position, "bucket", fmt.Sprintf("%s caches require bucket", cacheType))
+	} else if bucket, err := bucketVal.AsString(); err == nil && !bucketNamePattern.MatchString(bucket) {
+		result.AddError(bucketVal.Position, "bucket",
+			fmt.Sprintf("invalid bucket name %q: use 3-63 lowercase letters, digits, dots and hyphens", bucket))
+	}
+
+	secretVal, ok := block.GetAttribute("credentials_secret")
+	if !ok {
+		result.AddError(block.Position, "credentials_secret", fmt.Sprintf("%s caches require credentials_secret, the secret URI of their credentials", cacheType))
+		return
+	}
+	checkSecretURI(secretVal, "credentials_secret", result)
+}
+
+// checkSecretURI validates that the attribute name is a secret URI runners
+// can resolve
+func checkSecretURI(val Value, name string, result *ValidationResult) {
+	secret, err := val.AsString()
+	if err != nil {
+		return
+	}
+	for _, scheme := range RunnerSecretSchemes {
+		if strings.HasPrefix(secret, scheme) {
+			return
+		}
+	}
+	result.AddError(val.Position, name,
+		fmt.Sprintf("%s must be a secret URI starting with one of %s", name, strings.Join(RunnerSecretSchemes, ", ")))
+}
+
+// namespacePattern matches Kubernetes namespace names (DNS-1123 labels)
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+var kubernetesSchema = &BlockSchema{
+	Type:        "kubernetes",
+	Description: "Existing cluster the runners of a kubernetes egg run in",
+	Attributes: []AttributeSchema{
+		{Name: "namespace", Type: AttrString, Required: true, Description: "Namespace of the runner and its job pods"},
+		{Name: "node_selector", Type: AttrMap, Description: "Node labels job pods are scheduled on"},
+		{Name: "kubeconfig_secret", Type: AttrString, Required: true, Description: "Secret URI of the kubeconfig giving access to the cluster"},
+	},
+	Check: checkKubernetesCluster,
+}
+
+// checkKubernetesCluster validates the namespace, node_selector and
+// kubeconfig_secret of a kubernetes block
+func checkKubernetesCluster(block *Block, result *ValidationResult) {
+	if val, ok := block.GetAttribute("namespace"); ok {
+		if namespace, err := val.AsString(); err == nil && !namespacePattern.MatchString(namespace) {
+			result.AddError(val.Position, "namespace",
+				fmt.Sprintf("invalid namespace %q: use at most 63 lowercase letters, digits and hyphens", namespace))
+		}
+	}
+	if val, ok := block.GetAttribute("node_selector"); ok {
+		if labels, err := val.AsMap(); err == nil {
+			for label, labelVal := range labels {
+				if _, err := labelVal.AsString(); err != nil {
+					result.AddError(labelVal.Position, "node_selector", fmt.Sprintf("node_selector label %q must be a string", label))
+				}
+			}
+		}
+	}
+	if val, ok := block.GetAttribute("kubeconfig_secret"); ok {
+		checkSecretURI(val, "kubeconfig_secret", result)
+	}
+}
+
+// Autoscaling of an egg's runners when its autoscaling block leaves the
+// attribute out
+const (
+	DefaultScaleUpThreshold = 80
+	DefaultScaleDownDelay   = 10 * time.Minute
+)
+
+var autoscalingSchema = &BlockSchema{
+	Type:        "autoscaling",
+	Description: "Autoscaling of the egg's VM runners, provisioned per egg by MotherGoose",
+	Attributes: []AttributeSchema{
+		{Name: "min", Type: AttrInteger, Required: true, Min: float(0), Max: float(100), Description: "Fewest runner VMs kept running"},
+		{Name: "max", Type: AttrInteger, Required: true, Min: float(1), Max: float(100), Description: "Most runner VMs"},
+		{Name: "scale_up_threshold", Type: AttrInteger, Min: float(1), Max: float(100), Description: "Percentage of busy job slots above which a runner is added (default 80)"},
+		{Name: "scale_down_delay", Type: AttrString, Format: "duration", MinDuration: "1m", MaxDuration: "24h", Description: "How long load stays below the threshold before a runner is removed (default 10m)"},
+	},
+	Check: checkAutoscalingCounts,
+}
+
+// checkAutoscalingCounts validates that min <= max
+func checkAutoscalingCounts(block *Block, result *ValidationResult) {
+	minVal, minOk := block.GetAttribute("min")
+	maxVal, maxOk := block.GetAttribute("max")
+	if !minOk || !maxOk {
+		return
+	}
+	minNum, minErr := minVal.AsInt()
+	maxNum, maxErr := maxVal.AsInt()
+	if minErr == nil && maxErr == nil && minNum > maxNum {
+		result.AddError(minVal.Position, "min",
+			fmt.Sprintf("min (%d) cannot be greater than max (%d)", minNum, maxNum))
+	}
+}
+
+// caCertAttribute is the CA certificate of a self-hosted GitLab server
+var caCertAttribute = AttributeSchema{
+	Name:        "ca_cert",
+	Type:        AttrString,
+	Description: "CA certificate of a self-hosted GitLab server: PEM certificates or a secret URI",
+	Check: func(val Value, result *ValidationResult) {
+		str, _ := val.AsString()
+		if msg := caCertViolation(str); msg != "" {
+			result.AddError(val.Position, "ca_cert", msg)
+		}
+	},
+}
+
+// caCertViolation checks that a ca_cert is a secret URI or a PEM bundle of
+// valid certificates, returning an error message or ""
+func caCertViolation(str string) string {
+	str = strings.TrimSpace(str)
+	if !strings.HasPrefix(str, "-----BEGIN") {
+		if strings.Contains(str, "://") {
+			return ""
+		}
+		return "ca_cert must be PEM certificates or a secret URI (e.g. vault://secret/gitlab/ca)"
+	}
+
+	rest, count := []byte(str), 0
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Sprintf("ca_cert must contain only certificates, found %s", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Sprintf("ca_cert certificate %d is invalid: %v", count+1, err)
+		}
+		count++
+	}
+	if count == 0 || len(strings.TrimSpace(string(rest))) > 0 {
+		return "ca_cert is not a valid PEM certificate bundle"
+	}
+	return ""
+}
+
+var gitlabSchema = &BlockSchema{
+	Type:        "gitlab",
+	Description: "GitLab project the runners register with",
+	Attributes: []AttributeSchema{
+		{Name: "project_id", Type: AttrInteger, Required: true, Min: float(1), Max: float(999999999), Description: "GitLab project ID"},
+		{Name: "server_name", Type: AttrString, Required: true, Description: "GitLab server hostname"},
+		{Name: "token_secret", Type: AttrString, Required: true, Description: "Secret URI of the runner token"},
+		caCertAttribute,
+	},
+}
+
+// eggGitlabSchema lets an egg serve a whole GitLab group instead of one project
+var eggGitlabSchema = &BlockSchema{
+	Type:        "gitlab",
+	Description: "GitLab project or group the runners register with; set exactly one of project_id and group_id",
+	Attributes: []AttributeSchema{
+		{Name: "project_id", Type: AttrInteger, Min: float(1), Max: float(999999999), Description: "GitLab project ID"},
+		{Name: "group_id", Type: AttrInteger, Min: float(1), Max: float(999999999), Description: "GitLab group ID; runners serve every project in the group"},
+		{Name: "server_name", Type: AttrString, Required: true, Description: "GitLab server hostname"},
+		{Name: "token_secret", Type: AttrString, Required: true, Description: "Secret URI of the runner token"},
+		caCertAttribute,
+	},
+	Check: func(block *Block, result *ValidationResult) {
+		_, hasProject := block.GetAttribute("project_id")
+		groupVal, hasGroup := block.GetAttribute("group_id")
+		switch {
+		case hasProject && hasGroup:
+			result.AddError(groupVal.Position, "group_id", "gitlab block must set either project_id or group_id, not both")
+		case !hasProject && !hasGroup:
+			result.AddError(block.Position, "project_id", "gitlab block must have a 'project_id' or 'group_id' attribute")
+		}
+	},
+}
+
+var environmentSchema = &BlockSchema{
+	Type:           "environment",
+	Description:    "Environment variables passed to jobs; every attribute must be a string",
+	FreeAttributes: true,
+	Check: func(block *Block, result *ValidationResult) {
+		for name, val := range block.Attributes {
+			if _, err := val.AsString(); err != nil {
+				result.AddError(val.Position, name, "environment variables must be strings")
+			}
+		}
+	},
+}
+
+var repoSchema = &BlockSchema{
+	Type:        "repo",
+	Description: "A repository served by an EggsBucket",
+	Label:       "repo name",
+	Blocks: []NestedBlockSchema{
+		{Required: true, Schema: gitlabSchema},
+	},
+}
+
+var repositoriesSchema = &BlockSchema{
+	Type:        "repositories",
+	Description: "Repositories served by an EggsBucket",
+	Blocks: []NestedBlockSchema{
+		{Multiple: true, MinItems: 1, Schema: repoSchema},
+	},
+	Check: checkRepositories,
+}
+
+// checkRepositories validates that no two repos share a name or a GitLab
+// project, which would register the same runners twice
+func checkRepositories(block *Block, result *ValidationResult) {
+	names := make(map[string]bool)
+	projects := make(map[int]string)
+	for _, repo := range block.GetBlocks("repo") {
+		if len(repo.Labels) == 1 {
+			name := repo.Labels[0]
+			if names[name] {
+				result.AddError(repo.Position, "repo", fmt.Sprintf("duplicate repo name %q", name))
+			}
+			names[name] = true
+		}
+		gitlab, ok := repo.GetBlock("gitlab")
+		if !ok {
+			continue
+		}
+		val, ok := gitlab.GetAttribute("project_id")
+		if !ok {
+			continue
+		}
+		id, err := val.AsInt()
+		if err != nil {
+			continue
+		}
+		if other, dup := projects[id]; dup {
+			result.AddError(val.Position, "project_id",
+				fmt.Sprintf("project_id %d is already used by repo %q", id, other))
+			continue
+		}
+		projects[id] = strings.Join(repo.Labels, " ")
+	}
+}
+
+var jobRunnerSchema = &BlockSchema{
+	Type:        "runner",
+	Description: "Runner a job executes on",
+	Attributes: []AttributeSchema{
+		{Name: "type", Type: AttrString, Required: true, Enum: RunnerTypes, Description: "Runner type"},
+		{Name: "tags", Type: AttrStringList, Required: true, ElemName: "tag", Description: "Runner tags"},
+	},
+}
+
+var pruningSchema = &BlockSchema{
+	Type:        "pruning",
+	Description: "When UglyFox terminates failed or old runners",
+	Attributes: []AttributeSchema{
+		{Name: "failed_threshold", Type: AttrInteger, Required: true, Min: float(1), Max: float(100), Description: "Failures before a runner is pruned"},
+		{Name: "max_age", Type: AttrString, Required: true, Format: "duration", MinDuration: "1h", MaxDuration: "720h", Description: "Maximum runner age"},
+		{Name: "check_interval", Type: AttrString, Required: true, Format: "duration", MinDuration: "30s", MaxDuration: "24h", Description: "How often runners are checked"},
+	},
+}
+
+// poolAttributes are the attributes shared by apex and nadir pools
+var poolAttributes = []AttributeSchema{
+	{Name: "max_count", Type: AttrInteger, Required: true, Min: float(0), Max: float(1000), Description: "Maximum runners in the pool"},
+	{Name: "min_count", Type: AttrInteger, Required: true, Min: float(0), Max: float(1000), Description: "Minimum runners in the pool"},
+}
+
+// checkPoolCounts validates that min_count <= max_count
+func checkPoolCounts(block *Block, result *ValidationResult) {
+	minVal, minOk := block.GetAttribute("min_count")
+	maxVal, maxOk := block.GetAttribute("max_count")
+	if !minOk || !maxOk {
+		return
+	}
+	minNum, minErr := minVal.AsInt()
+	maxNum, maxErr := maxVal.AsInt()
+	if minErr == nil && maxErr == nil && minNum > maxNum {
+		result.AddError(block.Position, "min_count",
+			fmt.Sprintf("min_count (%d) cannot be greater than max_count (%d)", minNum, maxNum))
+	}
+}
+
+var apexSchema = &BlockSchema{
+	Type:        "apex",
+	Description: "Pool of active runners",
+	Attributes: append(append([]AttributeSchema{}, poolAttributes...),
+		AttributeSchema{Name: "cpu_threshold", Type: AttrNumber, Min: float(0), Max: float(100), Description: "CPU percentage that triggers promotion"},
+		AttributeSchema{Name: "memory_threshold", Type: AttrNumber, Min: float(0), Max: float(100), Description: "Memory percentage that triggers promotion"},
+	),
+	Check: checkPoolCounts,
+}
+
+var nadirSchema = &BlockSchema{
+	Type:        "nadir",
+	Description: "Pool of idle runners",
+	Attributes: append(append([]AttributeSchema{}, poolAttributes...),
+		AttributeSchema{Name: "idle_timeout", Type: AttrString, Required: true, Format: "duration", MinDuration: "1m", MaxDuration: "24h", Description: "How long a runner may idle before demotion"},
+	),
+	Check: checkPoolCounts,
+}
+
+var runnersConditionSchema = &BlockSchema{
+	Type:        "runners_condition",
+	Description: "Pool sizing for a group of Eggs",
+	Label:       "condition name",
+	Attributes: []AttributeSchema{
+		{
+			Name:        "eggs_entities",
+			Type:        AttrStringList,
+			Required:    true,
+			ElemName:    "egg entity",
+			Description: "Egg names this condition applies to",
+			Check: func(val Value, result *ValidationResult) {
+				list, _ := val.AsList()
+				if len(list) == 0 {
+					result.AddError(val.Position, "eggs_entities",
+						"eggs_entities must contain at least one egg name")
+				}
+				for i, entity := range list {
+					if entityStr, err := entity.AsString(); err == nil && !isValidIdentifier(entityStr) {
+						result.AddError(entity.Position, fmt.Sprintf("eggs_entities[%d]", i),
+							fmt.Sprintf("invalid egg name %q: must contain only alphanumeric characters, hyphens, and underscores", entityStr))
+					}
+				}
+			},
+		},
+	},
+	Blocks: []NestedBlockSchema{
+		{Required: true, Schema: apexSchema},
+		{Required: true, Schema: nadirSchema},
+	},
+}
+
+var ruleSchema = &BlockSchema{
+	Type:        "rule",
+	Description: "A runner lifecycle policy rule",
+	Label:       "rule name",
+	FreeLabel:   true,
+	Attributes: []AttributeSchema{
+		{Name: "condition", Type: AttrString, Required: true, Description: "Condition expression"},
+		{Name: "action", Type: AttrString, Required: true, Enum: []string{"terminate", "demote_to_nadir", "promote_to_apex"}, Description: "Action to take when the condition matches"},
+	},
+}
+
+var policiesSchema = &BlockSchema{
+	Type:        "policies",
+	Description: "Runner lifecycle policies",
+	Blocks: []NestedBlockSchema{
+		{Multiple: true, MinItems: 1, Schema: ruleSchema},
+	},
+}
+
+// RunnerTypes are the deployment types of eggs and eggsbuckets
+var RunnerTypes = []string{"vm", "serverless", "kubernetes"}
+
+// EggSchema describes an egg block
+var EggSchema = &BlockSchema{
+	Type:        "egg",
+	Description: "A single repository with its own runners",
+	Label:       "egg name",
+	Attributes: []AttributeSchema{
+		{Name: "type", Type: AttrString, Required: true, Enum: RunnerTypes, Description: "Runner deployment type"},
+	},
+	Blocks: []NestedBlockSchema{
+		{Required: true, Schema: cloudSchema},
+		{Required: true, Schema: resourcesSchema},
+		{Required: true, Schema: runnerSchema},
+		{Required: true, Schema: eggGitlabSchema},
+		{Schema: environmentSchema},
+		{Schema: autoscalingSchema},
+		{Schema: kubernetesSchema},
+	},
+	Check: checkRunnerHost,
+}
+
+// EggsBucketSchema describes an eggsbucket block
+var EggsBucketSchema = &BlockSchema{
+	Type:        "eggsbucket",
+	Description: "Several repositories sharing one runner configuration",
+	Label:       "bucket name",
+	Attributes: []AttributeSchema{
+		{Name: "type", Type: AttrString, Required: true, Enum: RunnerTypes, Description: "Runner deployment type"},
+	},
+	Blocks: []NestedBlockSchema{
+		{Required: true, Schema: cloudSchema},
+		{Required: true, Schema: resourcesSchema},
+		{Required: true, Schema: runnerSchema},
+		{Required: true, Schema: repositoriesSchema},
+		{Schema: environmentSchema},
+		{Schema: kubernetesSchema},
+	},
+	Check: checkRunnerHost,
+}
+
+// maxCPUsPerJob is the number of vCPUs per concurrent job above which most
+// of a runner VM sits idle
+const maxCPUsPerJob = 4
+
+// checkRunnerHost checks an egg or eggsbucket block as a whole
+func checkRunnerHost(block *Block, result *ValidationResult) {
+	checkProviderResources(block, result)
+	checkPlatform(block, result)
+	checkNetworkPlacement(block, result)
+	checkRunnerImage(block, result)
+	checkRunnerOS(block, result)
+	checkKubernetesTarget(block, result)
+	checkPrivileged(block, result)
+	checkAutoscaling(block, result)
+	checkJobTimeout(block, result)
+	checkIdleCPUs(block, result)
+}
+
+// checkPrivileged validates the runner's privileged and docker_dind:
+// Docker-in-Docker needs privileged containers, which serverless runners
+// cannot run, and has no Windows daemon
+func checkPrivileged(block *Block, result *ValidationResult) {
+	runnerBlock, ok := block.GetBlock("runner")
+	if !ok {
+		return
+	}
+	enabled := make(map[string]Value)
+	for _, name := range []string{"privileged", "docker_dind"} {
+		if val, ok := runnerBlock.GetAttribute(name); ok {
+			if b, err := val.AsBool(); err == nil && b {
+				enabled[name] = val
+			}
+		}
+	}
+	if len(enabled) == 0 {
+		return
+	}
+
+	if typeVal, ok := block.GetAttribute("type"); ok {
+		if runnerType, err := typeVal.AsString(); err == nil && runnerType == "serverless" {
+			for _, name := range []string{"privileged", "docker_dind"} {
+				if val, ok := enabled[name]; ok {
+					result.AddError(val.Position, name, fmt.Sprintf("%s is not supported by serverless runners, which cannot run privileged containers", name))
+				}
+			}
+			return
+		}
+	}
+	dind, ok := enabled["docker_dind"]
+	if !ok {
+		return
+	}
+	if _, ok := enabled["privileged"]; !ok {
+		result.AddError(dind.Position, "docker_dind", "docker_dind requires privileged = true")
+	}
+	if osVal, ok := runnerBlock.GetAttribute("os"); ok {
+		if runnerOS, err := osVal.AsString(); err == nil && runnerOS != OSLinux {
+			result.AddError(dind.Position, "docker_dind", fmt.Sprintf("docker_dind is only supported by linux runners, not %s", runnerOS))
+		}
+	}
+}
+
+// checkIdleCPUs warns about VM runners with more vCPUs than their jobs can
+// use, such as concurrent = 1 on 8 vCPUs
+func checkIdleCPUs(block *Block, result *ValidationResult) {
+	typeVal, ok := block.GetAttribute("type")
+	if !ok {
+		return
+	}
+	if runnerType, err := typeVal.AsString(); err != nil || runnerType != "vm" {
+		return
+	}
+	resourcesBlock, resourcesOk := block.GetBlock("resources")
+	runnerBlock, runnerOk := block.GetBlock("runner")
+	if !resourcesOk || !runnerOk {
+		return
+	}
+	cpuVal, cpuOk := resourcesBlock.GetAttribute("cpu")
+	concurrentVal, concurrentOk := runnerBlock.GetAttribute("concurrent")
+	if !cpuOk || !concurrentOk {
+		return
+	}
+	cpu, cpuErr := cpuVal.AsInt()
+	concurrent, concurrentErr := concurrentVal.AsInt()
+	if cpuErr == nil && concurrentErr == nil && concurrent > 0 && cpu > concurrent*maxCPUsPerJob {
+		result.AddWarning(concurrentVal.Position, "concurrent",
+			fmt.Sprintf("concurrent (%d) leaves most of the %d vCPUs idle; raise it or use fewer vCPUs", concurrent, cpu))
+	}
+}
+
+// JobSchema describes a job block
+var JobSchema = &BlockSchema{
+	Type:        "job",
+	Description: "A scheduled self-management task",
+	Label:       "job name",
+	Attributes: []AttributeSchema{
+		{
+			Name:        "schedule",
+			Type:        AttrString,
+			Required:    true,
+			Format:      "cron",
+			Description: "Cron expression",
+			Check:       checkCron,
+		},
+		{Name: "script", Type: AttrString, Required: true, Description: "Shell script to run"},
+	},
+	Blocks: []NestedBlockSchema{
+		{Required: true, Schema: jobRunnerSchema},
+	},
+}
+
+// checkCron reports a schedule that is not a valid cron expression, pointing
+// at the offending field
+func checkCron(val Value, result *ValidationResult) {
+	scheduleStr, _ := val.AsString()
+	if cronErr := ValidateCronExpression(scheduleStr); cronErr != nil {
+		result.AddError(stringOffsetPosition(val.Position, cronErr.Offset), "schedule",
+			fmt.Sprintf("invalid cron expression %q: %s", scheduleStr, cronErr.Error()))
+	}
+}
+
+// UglyFoxSchema describes an uglyfox block. Pools are sized either per group
+// of Eggs in runners_condition blocks or, for every Egg alike, by apex and
+// nadir blocks directly inside the uglyfox block.
+var UglyFoxSchema = &BlockSchema{
+	Type:        "uglyfox",
+	Description: "Runner lifecycle management",
+	Blocks: []NestedBlockSchema{
+		{Required: true, Schema: pruningSchema},
+		{Multiple: true, Schema: runnersConditionSchema},
+		{Schema: apexSchema},
+		{Schema: nadirSchema},
+		{Schema: policiesSchema},
+	},
+	Check: checkUglyFoxPools,
+}
+
+// checkUglyFoxPools validates that an uglyfox block uses exactly one of the
+// two pool layouts
+func checkUglyFoxPools(block *Block, result *ValidationResult) {
+	conditions := block.GetBlocks("runners_condition")
+	apex, hasApex := block.GetBlock("apex")
+	nadir, hasNadir := block.GetBlock("nadir")
+	if len(conditions) > 0 {
+		for _, pool := range []*Block{apex, nadir} {
+			if pool != nil {
+				result.AddError(pool.Position, pool.Type,
+					fmt.Sprintf("%s block must be inside a runners_condition block when runners_condition blocks are used", pool.Type))
+			}
+		}
+		return
+	}
+	switch {
+	case !hasApex && !hasNadir:
+		result.AddError(block.Position, "runners_condition",
+			"uglyfox block must have at least one 'runners_condition' block, or 'apex' and 'nadir' blocks")
+	case !hasApex:
+		result.AddError(block.Position, "apex", "uglyfox block with a 'nadir' block must also have an 'apex' block")
+	case !hasNadir:
+		result.AddError(block.Position, "nadir", "uglyfox block with an 'apex' block must also have a 'nadir' block")
+	}
+}
+
+// MotherGooseRuntimes are the runtimes of MotherGoose functions
+var MotherGooseRuntimes = []string{"python311", "python312", "nodejs18", "nodejs20", "nodejs22", "golang121", "golang123", "java21"}
+
+// iamRolePattern matches IAM role names such as ydb.editor or
+// serverless.containers.invoker
+var iamRolePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*(\.[a-zA-Z][a-zA-Z0-9-]*)+$`)
+
+// motherGooseFunctionSchema describes a serverless function or container of
+// the MotherGoose backend
+func motherGooseFunctionSchema(blockType, description string) *BlockSchema {
+	return &BlockSchema{
+		Type:        blockType,
+		Description: description,
+		Attributes: []AttributeSchema{
+			{Name: "name", Type: AttrString, Required: true, Description: "Function or container name"},
+			{Name: "runtime", Type: AttrString, Enum: MotherGooseRuntimes, Description: "Function runtime"},
+			{Name: "image", Type: AttrString, Description: "Container image; used instead of a runtime"},
+			{Name: "handler", Type: AttrString, Description: "Entry point of a function"},
+			{Name: "command", Type: AttrStringList, ElemName: "argument", Description: "Container command override"},
+			{Name: "memory", Type: AttrInteger, Required: true, Min: float(128), Max: float(8192), Description: "Memory in MB"},
+			{Name: "timeout", Type: AttrInteger, Min: float(1), Max: float(3600), Description: "Request timeout in seconds", Recommended: "the provider's default of a few seconds is often too short"},
+			{Name: "min_instances", Type: AttrInteger, Min: float(0), Max: float(100), Description: "Instances kept warm"},
+			{Name: "max_instances", Type: AttrInteger, Min: float(1), Max: float(1000), Description: "Maximum instances"},
+			{Name: "service_account", Type: AttrString, Description: "Service account the function runs as"},
+		},
+		Blocks: []NestedBlockSchema{
+			{Schema: &BlockSchema{Type: "env", Description: "Environment variables", Open: true}},
+			{Schema: &BlockSchema{Type: "scaling", Description: "Autoscaling settings", Open: true}},
+		},
+		Check: checkMotherGooseFunction,
+	}
+}
+
+// checkMotherGooseFunction requires a runtime or an image and keeps
+// min_instances at or below max_instances
+func checkMotherGooseFunction(block *Block, result *ValidationResult) {
+	_, hasRuntime := block.GetAttribute("runtime")
+	_, hasImage := block.GetAttribute("image")
+	if !hasRuntime && !hasImage {
+		result.AddError(block.Position, "runtime", fmt.Sprintf("%s block must have a 'runtime' or an 'image' attribute", block.Type))
+	}
+	minVal, hasMin := block.GetAttribute("min_instances")
+	maxVal, hasMax := block.GetAttribute("max_instances")
+	if !hasMin || !hasMax {
+		return
+	}
+	minCount, minErr := minVal.AsInt()
+	maxCount, maxErr := maxVal.AsInt()
+	if minErr == nil && maxErr == nil && minCount > maxCount {
+		result.AddError(minVal.Position, "min_instances",
+			fmt.Sprintf("min_instances (%d) must not exceed max_instances (%d)", minCount, maxCount))
+	}
+}
+
+var apiGatewaySchema = &BlockSchema{
+	Type:        "api_gateway",
+	Description: "API gateway in front of the MotherGoose API",
+	Attributes: []AttributeSchema{
+		{Name: "name", Type: AttrString, Required: true, Description: "API gateway name"},
+		{Name: "type", Type: AttrString, Description: "Cloud-specific gateway type, e.g. aws_api_gateway_v2"},
+		{Name: "openapi_spec", Type: AttrString, Description: "OpenAPI specification file"},
+	},
+	Blocks: []NestedBlockSchema{
+		{Schema: motherGooseFunctionSchema("auth_function", "Webhook authentication function")},
+		{Schema: &BlockSchema{Type: "cors", Description: "CORS settings", Open: true}},
+	},
+}
+
+var messageQueuesSchema = &BlockSchema{
+	Type:        "message_queues",
+	Description: "Message queues, one block per queue",
+	Blocks: []NestedBlockSchema{
+		{AnyType: true, Schema: &BlockSchema{
+			Type:        "<queue>",
+			Description: "A message queue",
+			Attributes: []AttributeSchema{
+				{Name: "name", Type: AttrString, Required: true, Description: "Queue name"},
+				{Name: "visibility_timeout", Type: AttrInteger, Min: float(0), Max: float(43200), Description: "Seconds a received message stays hidden"},
+				{Name: "message_retention", Type: AttrInteger, Min: float(60), Max: float(1209600), Description: "Seconds a message is kept"},
+				{Name: "max_receives", Type: AttrInteger, Min: float(1), Max: float(1000), Description: "Receives before a message moves to the dead-letter queue"},
+				{Name: "dead_letter_queue", Type: AttrString, Description: "Name of the dead-letter queue"},
+			},
+		}},
+	},
+}
+
+var triggersSchema = &BlockSchema{
+	Type:        "triggers",
+	Description: "Scheduled calls to MotherGoose endpoints, one block per trigger",
+	Blocks: []NestedBlockSchema{
+		{AnyType: true, Schema: &BlockSchema{
+			Type:        "<trigger>",
+			Description: "A scheduled trigger",
+			Attributes: []AttributeSchema{
+				{Name: "name", Type: AttrString, Required: true, Description: "Trigger name"},
+				{Name: "type", Type: AttrString, Description: "Cloud-specific trigger type, e.g. aws_eventbridge_scheduler"},
+				{Name: "schedule", Type: AttrString, Required: true, Format: "cron", Description: "Cron expression", Check: checkCron},
+				{Name: "target_function", Type: AttrString, Description: "Function to call"},
+				{Name: "endpoint", Type: AttrString, Description: "Endpoint path to call"},
+				{Name: "method", Type: AttrString, Enum: []string{"GET", "POST", "PUT", "DELETE"}, Description: "HTTP method"},
+				{Name: "service_account", Type: AttrString, Description: "Service account the trigger calls as"},
+			},
+		}},
+	},
+}
+
+var databaseSchema = &BlockSchema{
+	Type:        "database",
+	Description: "MotherGoose database",
+	Attributes: []AttributeSchema{
+		{Name: "type", Type: AttrString, Required: true, Enum: MotherGooseDatabaseTypes, Description: "Database engine"},
+		{Name: "name", Type: AttrString, Required: true, Description: "Database name"},
+		{Name: "mode", Type: AttrString, Enum: []string{"serverless", "dedicated"}, Description: "Capacity mode"},
+	},
+	Blocks: []NestedBlockSchema{
+		{Schema: &BlockSchema{Type: "tables", Description: "Table definitions", Open: true}},
+	},
+}
+
+var storageSchema = &BlockSchema{
+	Type:        "storage",
+	Description: "Object storage buckets, one block per bucket",
+	Blocks: []NestedBlockSchema{
+		{AnyType: true, MinItems: 1, Schema: &BlockSchema{
+			Type:        "<bucket>",
+			Description: "An object storage bucket",
+			Attributes: []AttributeSchema{
+				{Name: "name", Type: AttrString, Required: true, Description: "Bucket name"},
+				{Name: "versioning", Type: AttrBool, Description: "Keep object versions"},
+			},
+			Blocks: []NestedBlockSchema{
+				{Schema: &BlockSchema{Type: "lifecycle_rules", Description: "Object lifecycle rules", Open: true}},
+			},
+		}},
+	},
+}
+
+var serviceAccountsSchema = &BlockSchema{
+	Type:        "service_accounts",
+	Description: "IAM service accounts, one block per account",
+	Blocks: []NestedBlockSchema{
+		{AnyType: true, Schema: &BlockSchema{
+			Type:        "<account>",
+			Description: "A service account",
+			Attributes: []AttributeSchema{
+				{Name: "name", Type: AttrString, Required: true, Description: "Service account name"},
+				{
+					Name:        "roles",
+					Type:        AttrStringList,
+					ElemName:    "role",
+					Description: "IAM roles, e.g. ydb.editor",
+					Check: func(val Value, result *ValidationResult) {
+						roles, _ := val.AsList()
+						for i, role := range roles {
+							if name, err := role.AsString(); err == nil && !iamRolePattern.MatchString(name) {
+								result.AddError(role.Position, fmt.Sprintf("roles[%d]", i),
+									fmt.Sprintf("invalid role %q: expected a dotted name such as ydb.editor", name))
+							}
+						}
+					},
+				},
+			},
+		}},
+	},
+}
+
+// MotherGooseSchema describes a mothergoose block
+var MotherGooseSchema = &BlockSchema{
+	Type:        "mothergoose",
+	Description: "MotherGoose backend infrastructure",
+	Blocks: []NestedBlockSchema{
+		{Required: true, Schema: apiGatewaySchema},
+		{Required: true, Schema: motherGooseFunctionSchema("fastapi_app", "MotherGoose API")},
+		{Schema: motherGooseFunctionSchema("celery_workers", "Celery workers for asynchronous tasks")},
+		{Schema: motherGooseFunctionSchema("uglyfox_workers", "UglyFox runner lifecycle workers")},
+		{Schema: messageQueuesSchema},
+		{Schema: triggersSchema},
+		{Required: true, Schema: databaseSchema},
+		{Required: true, Schema: storageSchema},
+		{Schema: serviceAccountsSchema},
+	},
+}
+
+func init() {
+	RegisterSchema(EggSchema)
+	RegisterSchema(EggsBucketSchema)
+	RegisterSchema(JobSchema)
+	RegisterSchema(UglyFoxSchema)
+	RegisterSchema(MotherGooseSchema)
+	RegisterSchema(DefaultsSchema)
+}
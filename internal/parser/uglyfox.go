@@ -0,0 +1,36 @@
+package parser
+
+import "fmt"
+
+// CheckEggsEntities checks an uglyfox configuration against the Eggs and
+// EggsBuckets of its Nest, adding an error to result for every name in the
+// eggs_entities of a runners_condition that is not one of eggs
+func CheckEggsEntities(config *Config, eggs []string, result *ValidationResult) {
+	known := make(map[string]bool, len(eggs))
+	for _, egg := range eggs {
+		known[egg] = true
+	}
+	for i := range config.Blocks {
+		if config.Blocks[i].Type != "uglyfox" {
+			continue
+		}
+		for _, condition := range config.Blocks[i].GetBlocks("runners_condition") {
+			val, ok := condition.GetAttribute("eggs_entities")
+			if !ok {
+				continue
+			}
+			list, err := val.AsList()
+			if err != nil {
+				continue // Reported by the schema
+			}
+			for j, entity := range list {
+				name, err := entity.AsString()
+				if err != nil || known[name] {
+					continue
+				}
+				result.AddError(entity.Position, fmt.Sprintf("eggs_entities[%d]", j),
+					fmt.Sprintf("egg %q is not defined in the Nest", name))
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// Parse converts the policy blocks of a parsed policy file
+func Parse(config *parser.Config) ([]*Policy, error) {
+	var policies []*Policy
+	for i := range config.Blocks {
+		block := &config.Blocks[i]
+		if block.Type != "policy" {
+			return nil, fmt.Errorf("%s: unexpected block %q in policy file (expected 'policy')", block.Position, block.Type)
+		}
+		policy, err := parsePolicy(block)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func parsePolicy(block *parser.Block) (*Policy, error) {
+	if len(block.Labels) != 1 {
+		return nil, fmt.Errorf("%s: policy block must have exactly one label (the policy name)", block.Position)
+	}
+	policy := &Policy{
+		Name:       block.Labels[0],
+		BlockTypes: defaultBlockTypes,
+		Position:   block.Position,
+	}
+
+	var err error
+	if policy.Description, err = optionalString(block, "description"); err != nil {
+		return nil, err
+	}
+	if policy.Environments, err = optionalStringList(block, "environments"); err != nil {
+		return nil, err
+	}
+	if policy.Eggs, err = optionalStringList(block, "eggs"); err != nil {
+		return nil, err
+	}
+	blockTypes, err := optionalStringList(block, "block_types")
+	if err != nil {
+		return nil, err
+	}
+	if len(blockTypes) > 0 {
+		policy.BlockTypes = blockTypes
+	}
+
+	for _, ruleBlock := range block.GetBlocks("rule") {
+		rule, err := parseRule(&ruleBlock)
+		if err != nil {
+			return nil, err
+		}
+		policy.Rules = append(policy.Rules, rule)
+	}
+	if len(policy.Rules) == 0 {
+		return nil, fmt.Errorf("%s: policy %q has no rule blocks", block.Position, policy.Name)
+	}
+	return policy, nil
+}
+
+func parseRule(block *parser.Block) (Rule, error) {
+	rule := Rule{Position: block.Position}
+
+	var err error
+	if rule.Attribute, err = optionalString(block, "attribute"); err != nil {
+		return rule, err
+	}
+	if rule.Attribute == "" {
+		return rule, fmt.Errorf("%s: rule must set attribute (e.g. \"resources.memory\")", block.Position)
+	}
+	if value, ok := block.GetAttribute("required"); ok {
+		if rule.Required, err = value.AsBool(); err != nil {
+			return rule, fmt.Errorf("%s: required must be a bool", value.Position)
+		}
+	}
+	if rule.Min, err = optionalNumber(block, "min"); err != nil {
+		return rule, err
+	}
+	if rule.Max, err = optionalNumber(block, "max"); err != nil {
+		return rule, err
+	}
+	if rule.In, err = optionalStringList(block, "in"); err != nil {
+		return rule, err
+	}
+	if rule.NotIn, err = optionalStringList(block, "not_in"); err != nil {
+		return rule, err
+	}
+	if rule.Message, err = optionalString(block, "message"); err != nil {
+		return rule, err
+	}
+	pattern, err := optionalString(block, "pattern")
+	if err != nil {
+		return rule, err
+	}
+	if pattern != "" {
+		if rule.Pattern, err = regexp.Compile(pattern); err != nil {
+			return rule, fmt.Errorf("%s: invalid pattern: %w", block.Position, err)
+		}
+	}
+
+	if !rule.Required && rule.Min == nil && rule.Max == nil && len(rule.In) == 0 && len(rule.NotIn) == 0 && rule.Pattern == nil {
+		return rule, fmt.Errorf("%s: rule for %s has no constraint (set required, min, max, in, not_in or pattern)", block.Position, rule.Attribute)
+	}
+	return rule, nil
+}
+
+func optionalString(block *parser.Block, name string) (string, error) {
+	value, ok := block.GetAttribute(name)
+	if !ok {
+		return "", nil
+	}
+	s, err := value.AsString()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s must be a string", value.Position, name)
+	}
+	return s, nil
+}
+
+func optionalNumber(block *parser.Block, name string) (*float64, error) {
+	value, ok := block.GetAttribute(name)
+	if !ok {
+		return nil, nil
+	}
+	n, err := value.AsNumber()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s must be a number", value.Position, name)
+	}
+	return &n, nil
+}
+
+func optionalStringList(block *parser.Block, name string) ([]string, error) {
+	value, ok := block.GetAttribute(name)
+	if !ok {
+		return nil, nil
+	}
+	items, err := value.AsList()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s must be a list of strings", value.Position, name)
+	}
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		s, err := item.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s must be a list of strings", item.Position, name)
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
@@ -0,0 +1,355 @@
+// Package policy enforces organisation-level guardrails on Egg and EggsBucket
+// configurations.
+//
+// Unlike lint rules, which are built in and advisory, policies are written by
+// the Nest's owners in Policies/*.fly and block validate and deploy when
+// violated. A policy can be skipped for one run only with an explicit reason.
+//
+// Example:
+//
+//	policy "dev-memory-limit" {
+//	  description  = "Dev runners are limited to 16 GB"
+//	  environments = ["dev"]
+//
+//	  rule {
+//	    attribute = "resources.memory"
+//	    max       = 16384
+//	  }
+//	}
+//
+//	policy "team-x-no-aws" {
+//	  eggs = ["team-x-*"]
+//
+//	  rule {
+//	    attribute = "cloud.provider"
+//	    not_in    = ["aws"]
+//	    message   = "team X runs on Yandex Cloud only"
+//	  }
+//	}
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+// DirName is the directory of the Nest that holds policy files
+const DirName = "Policies"
+
+// defaultBlockTypes are the blocks a policy applies to unless block_types is set
+var defaultBlockTypes = []string{"egg", "eggsbucket"}
+
+// Policy is a named set of rules with the scope they apply to
+type Policy struct {
+	Name         string
+	Description  string
+	Environments []string // Overlay environments; empty means every environment
+	Eggs         []string // Glob patterns of block names; empty means every block
+	BlockTypes   []string
+	Rules        []Rule
+	Position     parser.Position
+}
+
+// Rule constrains the values found at a dotted attribute path, e.g.
+// "resources.memory" or "repositories.repo.gitlab.project_id". List values
+// are checked element by element.
+type Rule struct {
+	Attribute string
+	Required  bool
+	Min       *float64
+	Max       *float64
+	In        []string
+	NotIn     []string
+	Pattern   *regexp.Regexp
+	Message   string
+	Position  parser.Position
+}
+
+// Violation is a rule broken by a configuration block
+type Violation struct {
+	Policy  string `json:"policy"`
+	Block   string `json:"block"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s violates policy %q: %s", v.File, v.Line, v.Column, v.Block, v.Policy, v.Message)
+}
+
+// Engine evaluates a Nest's policies
+type Engine struct {
+	policies []*Policy
+	skipped  map[string]string
+}
+
+// Load reads every policy file in dir. A missing directory yields an engine
+// without policies.
+func Load(dir string) (*Engine, error) {
+	engine := &Engine{skipped: make(map[string]string)}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return engine, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policies directory: %w", err)
+	}
+
+	p := parser.NewParser()
+	seen := make(map[string]parser.Position)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		filePath := filepath.Join(dir, entry.Name())
+		switch filepath.Ext(entry.Name()) {
+		case ".fly":
+		case ".rego":
+			// Refuse rather than ignore, so nobody believes the policy is enforced
+			return nil, fmt.Errorf("%s: Rego policies are not supported; write the policy as a .fly file", filePath)
+		default:
+			continue
+		}
+
+		config, err := p.ParseFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy file: %w", err)
+		}
+		policies, err := Parse(config)
+		if err != nil {
+			return nil, err
+		}
+		for _, policy := range policies {
+			if pos, ok := seen[policy.Name]; ok {
+				return nil, fmt.Errorf("%s: policy %q already defined at %s", policy.Position, policy.Name, pos)
+			}
+			seen[policy.Name] = policy.Position
+			engine.policies = append(engine.policies, policy)
+		}
+	}
+	return engine, nil
+}
+
+// Policies returns the loaded policies
+func (e *Engine) Policies() []*Policy {
+	if e == nil {
+		return nil
+	}
+	return e.policies
+}
+
+// Skip disables the named policies for this run. A reason is required so the
+// override is deliberate and can be reported.
+func (e *Engine) Skip(names []string, reason string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("skipping policies requires a reason (--policy-skip-reason)")
+	}
+	for _, name := range names {
+		if !e.has(name) {
+			return fmt.Errorf("unknown policy %q", name)
+		}
+		e.skipped[name] = reason
+	}
+	return nil
+}
+
+// Skipped returns the skipped policy names in sorted order
+func (e *Engine) Skipped() []string {
+	if e == nil {
+		return nil
+	}
+	names := make([]string, 0, len(e.skipped))
+	for name := range e.skipped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *Engine) has(name string) bool {
+	for _, policy := range e.policies {
+		if policy.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate checks every block of config against the policies in scope for
+// env ("" for the base configuration). A nil engine has no policies.
+func (e *Engine) Evaluate(config *parser.Config, env string) []Violation {
+	if e == nil {
+		return nil
+	}
+	var violations []Violation
+	for _, policy := range e.policies {
+		if _, skipped := e.skipped[policy.Name]; skipped {
+			continue
+		}
+		for i := range config.Blocks {
+			block := &config.Blocks[i]
+			if !policy.appliesTo(block, env) {
+				continue
+			}
+			for _, rule := range policy.Rules {
+				for _, message := range rule.check(block) {
+					violations = append(violations, Violation{
+						Policy:  policy.Name,
+						Block:   blockName(block),
+						File:    block.Position.File,
+						Line:    block.Position.Line,
+						Column:  block.Position.Column,
+						Message: message,
+					})
+				}
+			}
+		}
+	}
+	return violations
+}
+
+func (p *Policy) appliesTo(block *parser.Block, env string) bool {
+	if !contains(p.BlockTypes, block.Type) {
+		return false
+	}
+	if len(p.Environments) > 0 && !contains(p.Environments, env) {
+		return false
+	}
+	if len(p.Eggs) == 0 {
+		return true
+	}
+	if len(block.Labels) == 0 {
+		return false
+	}
+	for _, pattern := range p.Eggs {
+		if ok, _ := path.Match(pattern, block.Labels[0]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns a message for every value at the rule's path that breaks it
+func (r *Rule) check(block *parser.Block) []string {
+	values := lookup(block, r.Attribute)
+	if len(values) == 0 {
+		if r.Required {
+			return []string{r.describe(fmt.Sprintf("%s is required", r.Attribute))}
+		}
+		return nil
+	}
+
+	var messages []string
+	for _, value := range values {
+		if message := r.checkValue(value); message != "" {
+			messages = append(messages, r.describe(message))
+		}
+	}
+	return messages
+}
+
+func (r *Rule) checkValue(value parser.Value) string {
+	if value.Type == parser.ListType {
+		items, _ := value.AsList()
+		for _, item := range items {
+			if message := r.checkValue(item); message != "" {
+				return message
+			}
+		}
+		return ""
+	}
+
+	if r.Min != nil || r.Max != nil {
+		n, err := value.AsNumber()
+		if err != nil {
+			return fmt.Sprintf("%s must be a number, got %s", r.Attribute, value.String())
+		}
+		if r.Min != nil && n < *r.Min {
+			return fmt.Sprintf("%s must be at least %g, got %g", r.Attribute, *r.Min, n)
+		}
+		if r.Max != nil && n > *r.Max {
+			return fmt.Sprintf("%s must be at most %g, got %g", r.Attribute, *r.Max, n)
+		}
+	}
+
+	s := scalarString(value)
+	if len(r.In) > 0 && !contains(r.In, s) {
+		return fmt.Sprintf("%s must be one of %v, got %q", r.Attribute, r.In, s)
+	}
+	if contains(r.NotIn, s) {
+		return fmt.Sprintf("%s must not be %q", r.Attribute, s)
+	}
+	if r.Pattern != nil && !r.Pattern.MatchString(s) {
+		return fmt.Sprintf("%s must match %s, got %q", r.Attribute, r.Pattern, s)
+	}
+	return ""
+}
+
+// describe prefers the policy author's message over the generated one
+func (r *Rule) describe(message string) string {
+	if r.Message != "" {
+		return fmt.Sprintf("%s (%s)", r.Message, message)
+	}
+	return message
+}
+
+// lookup returns the values at a dotted path. Intermediate segments name
+// nested blocks; repeated blocks contribute one value each.
+func lookup(block *parser.Block, attrPath string) []parser.Value {
+	segments := strings.Split(attrPath, ".")
+	blocks := []*parser.Block{block}
+	for _, segment := range segments[:len(segments)-1] {
+		var next []*parser.Block
+		for _, b := range blocks {
+			nested := b.GetBlocks(segment)
+			for i := range nested {
+				next = append(next, &nested[i])
+			}
+		}
+		blocks = next
+	}
+
+	var values []parser.Value
+	for _, b := range blocks {
+		if value, ok := b.GetAttribute(segments[len(segments)-1]); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// scalarString renders strings without quotes and other values as written
+func scalarString(value parser.Value) string {
+	if s, err := value.AsString(); err == nil {
+		return s
+	}
+	return value.String()
+}
+
+func blockName(block *parser.Block) string {
+	if len(block.Labels) == 0 {
+		return block.Type
+	}
+	return fmt.Sprintf("%s %q", block.Type, block.Labels[0])
+}
+
+func contains(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/parser"
+)
+
+const testPolicies = `
+policy "dev-memory-limit" {
+  description  = "Dev runners are limited to 16 GB"
+  environments = ["dev"]
+
+  rule {
+    attribute = "resources.memory"
+    max       = 16384
+  }
+}
+
+policy "team-x-no-aws" {
+  eggs = ["team-x-*"]
+
+  rule {
+    attribute = "cloud.provider"
+    not_in    = ["aws"]
+    message   = "team X runs on Yandex Cloud only"
+  }
+}
+
+policy "no-privileged-tags" {
+  rule {
+    attribute = "runner.tags"
+    not_in    = ["privileged"]
+  }
+}
+
+policy "bucket-projects" {
+  block_types = ["eggsbucket"]
+
+  rule {
+    attribute = "repositories.repo.gitlab.project_id"
+    min       = 1000
+  }
+}
+`
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, dir, "limits.fly", testPolicies)
+	writeFile(t, dir, "README.md", "not a policy")
+	engine, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(engine.Policies()) != 4 {
+		t.Fatalf("expected 4 policies, got %d", len(engine.Policies()))
+	}
+	return engine
+}
+
+func parseConfig(t *testing.T, content string) *parser.Config {
+	t.Helper()
+	config, err := parser.NewParser().Parse([]byte(content), "config.fly")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	return config
+}
+
+func eggConfig(name, provider string, memory int, tags string) string {
+	return `
+egg "` + name + `" {
+  type = "vm"
+  cloud {
+    provider = "` + provider + `"
+    region   = "us-east-1"
+  }
+  resources {
+    cpu    = 4
+    memory = ` + strconv.Itoa(memory) + `
+    disk   = 20
+  }
+  runner {
+    tags = [` + tags + `]
+    concurrent = 2
+  }
+}
+`
+}
+
+func TestEvaluate(t *testing.T) {
+	engine := loadTestEngine(t)
+
+	tests := []struct {
+		name     string
+		config   string
+		env      string
+		policies []string
+	}{
+		{"compliant", eggConfig("my-app", "aws", 32768, `"docker"`), "", nil},
+		{"memory limit applies in dev only", eggConfig("my-app", "aws", 32768, `"docker"`), "dev", []string{"dev-memory-limit"}},
+		{"egg name glob", eggConfig("team-x-api", "aws", 4096, `"docker"`), "", []string{"team-x-no-aws"}},
+		{"list elements", eggConfig("my-app", "yandex", 4096, `"docker", "privileged"`), "", []string{"no-privileged-tags"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := engine.Evaluate(parseConfig(t, tt.config), tt.env)
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.Policy)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.policies, ",") {
+				t.Errorf("got violations %v, want %v", violations, tt.policies)
+			}
+		})
+	}
+
+	violations := engine.Evaluate(parseConfig(t, eggConfig("team-x-api", "aws", 4096, `"docker"`)), "")
+	if !strings.Contains(violations[0].Message, "team X runs on Yandex Cloud only") || violations[0].Block != `egg "team-x-api"` {
+		t.Errorf("unexpected violation %+v", violations[0])
+	}
+}
+
+func TestEvaluateNestedBlocks(t *testing.T) {
+	engine := loadTestEngine(t)
+	config := parseConfig(t, `
+eggsbucket "platform" {
+  repositories {
+    repo "auth" {
+      gitlab {
+        project_id = 12345
+      }
+    }
+    repo "legacy" {
+      gitlab {
+        project_id = 7
+      }
+    }
+  }
+}
+`)
+	violations := engine.Evaluate(config, "")
+	if len(violations) != 1 || !strings.Contains(violations[0].Message, "got 7") {
+		t.Errorf("expected one violation for the legacy repo, got %v", violations)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	engine := loadTestEngine(t)
+	if err := engine.Skip([]string{"team-x-no-aws"}, ""); err == nil {
+		t.Error("expected error when skipping without a reason")
+	}
+	if err := engine.Skip([]string{"no-such-policy"}, "migration"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+	if err := engine.Skip([]string{"team-x-no-aws"}, "migration to Yandex Cloud in progress"); err != nil {
+		t.Fatalf("Skip failed: %v", err)
+	}
+	if violations := engine.Evaluate(parseConfig(t, eggConfig("team-x-api", "aws", 4096, `"docker"`)), ""); len(violations) != 0 {
+		t.Errorf("expected skipped policy not to be evaluated, got %v", violations)
+	}
+	if skipped := engine.Skipped(); len(skipped) != 1 || skipped[0] != "team-x-no-aws" {
+		t.Errorf("unexpected skipped policies %v", skipped)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	rule := func(attrs string) string {
+		return "policy \"p\" {\n  rule {\n" + attrs + "\n  }\n}\n"
+	}
+	tests := map[string]string{
+		"rego.rego":      "package gosling",
+		"no-rules.fly":   `policy "empty" {}`,
+		"no-label.fly":   "policy {\n  rule {\n    attribute = \"type\"\n    required = true\n  }\n}\n",
+		"no-constr.fly":  rule(`attribute = "type"`),
+		"bad-regexp.fly": rule("attribute = \"type\"\npattern = \"(\""),
+		"wrong-blk.fly":  `egg "my-app" {}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, name, content)
+			if _, err := Load(dir); err == nil {
+				t.Errorf("expected error loading %s", name)
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	dup := "policy \"dup\" {\n  rule {\n    attribute = \"type\"\n    required = true\n  }\n}\n"
+	writeFile(t, dir, "a.fly", dup)
+	writeFile(t, dir, "b.fly", dup)
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "already defined") {
+		t.Errorf("expected duplicate policy error, got %v", err)
+	}
+
+	engine, err := Load(filepath.Join(dir, "missing"))
+	if err != nil || len(engine.Policies()) != 0 {
+		t.Errorf("expected empty engine for missing directory, got %v", err)
+	}
+}
@@ -21,16 +21,22 @@ const defaultHeartbeatInterval = 30 * time.Second
 
 // Config holds the configuration for a runner instance.
 type Config struct {
-	EggName           string
-	RunnerID          string
-	TokenSecretURI    string
-	GitLabServer      string
+	EggName        string
+	RunnerID       string
+	TokenSecretURI string
+	GitLabServer   string
+	// ProjectID or GroupID is the target of authentication-token registration
+	ProjectID         int
+	GroupID           int
+	Registration      gitlab.RegistrationMethod
 	Tags              []string
 	AgentVersion      string
 	MotherGooseURL    string
 	APIKey            string
 	MetricsInterval   time.Duration
 	HeartbeatInterval time.Duration
+	// GitLabOptions configure TLS and proxying for self-hosted GitLab servers
+	GitLabOptions []gitlab.ClientOption
 }
 
 // Manager manages the GitLab Runner Agent process lifecycle.
@@ -47,7 +53,7 @@ type Manager struct {
 // New creates a new Manager from the given Config.
 func New(cfg *Config) (*Manager, error) {
 	gitlabURL := fmt.Sprintf("https://%s", cfg.GitLabServer)
-	glClient, err := gitlab.NewClient(gitlabURL, "")
+	glClient, err := gitlab.NewClient(gitlabURL, "", cfg.GitLabOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GitLab client: %w", err)
 	}
@@ -138,13 +144,16 @@ func (m *Manager) retrieveFromVault(uri string) (string, error) {
 // Register registers the runner with GitLab using the provided token.
 func (m *Manager) Register(ctx context.Context, token string) error {
 	gitlabURL := fmt.Sprintf("https://%s", m.Config.GitLabServer)
-	glClient, err := gitlab.NewClient(gitlabURL, token)
+	opts := append([]gitlab.ClientOption{gitlab.WithRegistrationMethod(m.Config.Registration)}, m.Config.GitLabOptions...)
+	glClient, err := gitlab.NewClient(gitlabURL, token, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to create GitLab client with token: %w", err)
 	}
 	m.GitLabClient = glClient
 
 	cfg := &gitlab.RunnerConfig{
+		ProjectID:   m.Config.ProjectID,
+		GroupID:     m.Config.GroupID,
 		Token:       token,
 		Description: fmt.Sprintf("gosling-runner-%s", m.Config.EggName),
 		Tags:        m.Config.Tags,
@@ -34,6 +34,48 @@ func (m *mockMGClient) ListEggs(_ context.Context) ([]*deployer.EggConfig, error
 func (m *mockMGClient) CreateOrUpdateEgg(_ context.Context, _ *deployer.EggConfig) error {
 	return nil
 }
+func (m *mockMGClient) CreateOrUpdateEggs(_ context.Context, _ []*deployer.EggConfig) error {
+	return nil
+}
+func (m *mockMGClient) ListRunners(_ context.Context, _ mothergoose.RunnerFilter) ([]*mothergoose.Runner, error) {
+	return nil, nil
+}
+func (m *mockMGClient) GetRunner(_ context.Context, _ string) (*mothergoose.Runner, error) {
+	return nil, nil
+}
+func (m *mockMGClient) CreateOrUpdateJob(_ context.Context, _ *deployer.JobConfig) error {
+	return nil
+}
+func (m *mockMGClient) ListJobs(_ context.Context) ([]*deployer.JobConfig, error) {
+	return nil, nil
+}
+func (m *mockMGClient) GetUglyFoxConfig(_ context.Context) (*deployer.UglyFoxConfig, error) {
+	return nil, nil
+}
+func (m *mockMGClient) UpdateUglyFoxConfig(_ context.Context, _ *deployer.UglyFoxConfig) error {
+	return nil
+}
+func (m *mockMGClient) RotateEggToken(_ context.Context, _ *mothergoose.TokenRotation) error {
+	return nil
+}
+func (m *mockMGClient) DeleteEgg(_ context.Context, _ string) error {
+	return nil
+}
+func (m *mockMGClient) DeleteDeploymentPlan(_ context.Context, _, _ string) error {
+	return nil
+}
+func (m *mockMGClient) CreateDeploymentPlan(_ context.Context, _ *deployer.DeploymentPlan) error {
+	return nil
+}
+func (m *mockMGClient) SubmitDeploymentPlan(_ context.Context, plan *deployer.DeploymentPlan) (*deployer.DeploymentPlan, error) {
+	return plan, nil
+}
+func (m *mockMGClient) ApplyPlan(_ context.Context, _, _ string) (*deployer.DeploymentPlan, error) {
+	return nil, nil
+}
+func (m *mockMGClient) RollbackEgg(_ context.Context, _, _ string) (*deployer.DeploymentPlan, error) {
+	return nil, nil
+}
 func (m *mockMGClient) GetDeploymentPlan(_ context.Context, _, _ string) (*deployer.DeploymentPlan, error) {
 	return nil, nil
 }
@@ -52,6 +94,18 @@ func (m *mockMGClient) ReportRunnerMetrics(_ context.Context, runnerID string, p
 	m.lastMetricsPayload = payload
 	return m.metricsErr
 }
+func (m *mockMGClient) PauseRunners(_ context.Context, _, _ string) ([]*mothergoose.Runner, error) {
+	return nil, nil
+}
+func (m *mockMGClient) ResumeRunners(_ context.Context, _, _ string) ([]*mothergoose.Runner, error) {
+	return nil, nil
+}
+func (m *mockMGClient) DrainRunners(_ context.Context, _, _ string) ([]*mothergoose.Runner, error) {
+	return nil, nil
+}
+func (m *mockMGClient) StreamLogs(_ context.Context, _ string, _ mothergoose.LogStreamOptions, _ func(*mothergoose.LogEntry) error) error {
+	return nil
+}
 
 // newTestManager builds a Manager wired with a mock client and stub stat reader.
 func newTestManager(eggName, runnerID string, mock *mockMGClient) *Manager {
@@ -0,0 +1,25 @@
+package secrets
+
+import "errors"
+
+// KeychainService is the service name gosling's keychain entries are stored under
+const KeychainService = "gosling"
+
+var (
+	// ErrNotFound is returned when the keychain has no entry for an account
+	ErrNotFound = errors.New("secret not found in keychain")
+	// ErrKeychainUnavailable is returned when no OS keychain can be used
+	ErrKeychainUnavailable = errors.New("no OS keychain available")
+)
+
+// Keychain stores secrets in the operating system's credential store:
+// macOS Keychain, the freedesktop Secret Service on Linux or the Windows
+// Credential Manager.
+type Keychain interface {
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+// DefaultKeychain is the keychain of the current operating system
+var DefaultKeychain Keychain = osKeychain{}
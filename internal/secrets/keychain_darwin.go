@@ -0,0 +1,59 @@
+//go:build darwin
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// osKeychain uses the macOS Keychain through the security tool
+type osKeychain struct{}
+
+func (osKeychain) Get(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read keychain: %w", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (osKeychain) Set(service, account, secret string) error {
+	if strings.ContainsAny(secret, "\r\n") {
+		return fmt.Errorf("failed to write keychain: the secret must be a single line")
+	}
+	// The command is read from stdin by security -i so the secret does not
+	// show up in the process list. -U updates an existing entry instead of
+	// failing.
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		quoteSecurityArg(service), quoteSecurityArg(account), quoteSecurityArg(secret)))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to write keychain: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	// security -i reports a failed command on its output but still exits 0
+	if msg := strings.TrimSpace(string(out)); strings.Contains(strings.ToLower(msg), "error") {
+		return fmt.Errorf("failed to write keychain: %s", msg)
+	}
+	return nil
+}
+
+// quoteSecurityArg quotes s as an argument of the command lines read by
+// security -i
+func quoteSecurityArg(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (osKeychain) Delete(service, account string) error {
+	if out, err := exec.Command("security", "delete-generic-password", "-s", service, "-a", account).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to delete keychain entry: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+//go:build linux
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// osKeychain uses the freedesktop Secret Service (GNOME Keyring, KWallet)
+// through secret-tool from libsecret
+type osKeychain struct{}
+
+func secretTool() (string, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return "", fmt.Errorf("%w: install secret-tool (libsecret-tools) to use the Secret Service", ErrKeychainUnavailable)
+	}
+	return path, nil
+}
+
+func (osKeychain) Get(service, account string) (string, error) {
+	tool, err := secretTool()
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command(tool, "lookup", "service", service, "account", account).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read keychain: %w", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (osKeychain) Set(service, account, secret string) error {
+	tool, err := secretTool()
+	if err != nil {
+		return err
+	}
+	// The secret is passed on stdin so it does not show up in the process list
+	cmd := exec.Command(tool, "store", "--label", service+" "+account, "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write keychain: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (osKeychain) Delete(service, account string) error {
+	tool, err := secretTool()
+	if err != nil {
+		return err
+	}
+	if out, err := exec.Command(tool, "clear", "service", service, "account", account).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to delete keychain entry: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build linux
+
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSecretTool installs a secret-tool that keeps secrets in files
+const fakeSecretTool = `#!/bin/sh
+store="$SECRET_TOOL_STORE/$3-$5"
+case "$1" in
+  lookup) [ -f "$store" ] || exit 1; cat "$store" ;;
+  store)  shift 2; store="$SECRET_TOOL_STORE/$3-$5"; cat > "$store" ;;
+  clear)  rm -f "$store" ;;
+esac
+`
+
+func TestLinuxKeychain(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(fakeSecretTool), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("SECRET_TOOL_STORE", dir)
+
+	keychain := osKeychain{}
+	if _, err := keychain.Get(KeychainService, "prod"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := keychain.Set(KeychainService, "prod", "s3cr3t"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if secret, err := keychain.Get(KeychainService, "prod"); err != nil || secret != "s3cr3t" {
+		t.Errorf("got %q, %v", secret, err)
+	}
+	if err := keychain.Delete(KeychainService, "prod"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if err := keychain.Set(KeychainService, "prod", "s3cr3t"); !errors.Is(err, ErrKeychainUnavailable) {
+		t.Errorf("expected ErrKeychainUnavailable without secret-tool, got %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !linux && !windows
+
+package secrets
+
+// osKeychain reports that no keychain is available on this platform
+type osKeychain struct{}
+
+func (osKeychain) Get(service, account string) (string, error) {
+	return "", ErrKeychainUnavailable
+}
+
+func (osKeychain) Set(service, account, secret string) error {
+	return ErrKeychainUnavailable
+}
+
+func (osKeychain) Delete(service, account string) error {
+	return ErrKeychainUnavailable
+}
@@ -0,0 +1,193 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	lockbox "github.com/yandex-cloud/go-genproto/yandex/cloud/lockbox/v1"
+	ycsdk "github.com/yandex-cloud/go-sdk"
+	"github.com/yandex-cloud/go-sdk/iamkey"
+)
+
+// Schemes of the secret references understood by Resolve
+var referenceSchemes = []string{"keychain://", "env://", "file://", "vault://", "yc-lockbox://"}
+
+// IsReference reports whether s refers to a secret rather than being one
+func IsReference(s string) bool {
+	for _, scheme := range referenceSchemes {
+		if strings.HasPrefix(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeychainReference returns the reference to account's keychain entry
+func KeychainReference(account string) string {
+	return "keychain://" + account
+}
+
+// Resolve returns the secret ref points to:
+//
+//	keychain://{account}           OS keychain entry of KeychainService
+//	env://{VAR}                    environment variable
+//	file://{path}                  file contents
+//	vault://{mount}/{path}/{key}   HashiCorp Vault KV v2 (VAULT_ADDR, VAULT_TOKEN)
+//	yc-lockbox://{secret-id}/{key} Yandex Cloud Lockbox entry
+//
+// Any other value is a plaintext secret and is returned as is.
+func Resolve(ctx context.Context, ref string) (string, error) {
+	scheme, rest, _ := strings.Cut(ref, "://")
+	switch scheme {
+	case "keychain":
+		secret, err := DefaultKeychain.Get(KeychainService, rest)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", ref, err)
+		}
+		return secret, nil
+	case "env":
+		secret := os.Getenv(rest)
+		if secret == "" {
+			return "", fmt.Errorf("%s refers to environment variable %s, which is not set", ref, rest)
+		}
+		return secret, nil
+	case "file":
+		data, err := os.ReadFile(rest)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case "vault":
+		return resolveVault(ctx, ref, rest)
+	case "yc-lockbox":
+		return resolveLockbox(ctx, ref, rest)
+	default:
+		return ref, nil
+	}
+}
+
+// resolveVault reads a field of a Vault KV v2 secret
+func resolveVault(ctx context.Context, ref, path string) (string, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 {
+		return "", fmt.Errorf("invalid vault URI %s: expected vault://{mount}/{path}/{key}", ref)
+	}
+	mount, secretPath, key := parts[0], strings.Join(parts[1:len(parts)-1], "/"), parts[len(parts)-1]
+
+	req, err := newVaultRequest(ctx, ref, http.MethodGet, mount, secretPath, nil)
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to read %s: HTTP %d: %s", ref, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	value, ok := secret.Data.Data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("%s: key %q not found in secret", ref, key)
+	}
+	return value, nil
+}
+
+// newVaultRequest creates a request for the KV v2 secret at mount/path,
+// authenticated with VAULT_TOKEN or the token of `vault login`
+func newVaultRequest(ctx context.Context, ref, method, mount, path string, body io.Reader) (*http.Request, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, fmt.Errorf("VAULT_ADDR must be set to access %s", ref)
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		// Written by `vault login`
+		if home, err := os.UserHomeDir(); err == nil {
+			if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				token = strings.TrimSpace(string(data))
+			}
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("VAULT_TOKEN must be set (or run 'vault login') to access %s", ref)
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(addr, "/"), mount, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	return req, nil
+}
+
+// resolveLockbox reads a text entry of a Yandex Cloud Lockbox secret
+func resolveLockbox(ctx context.Context, ref, path string) (string, error) {
+	secretID, key, ok := strings.Cut(path, "/")
+	if !ok || secretID == "" || key == "" {
+		return "", fmt.Errorf("invalid yc-lockbox URI %s: expected yc-lockbox://{secret-id}/{key}", ref)
+	}
+
+	credentials, err := yandexCredentials()
+	if err != nil {
+		return "", err
+	}
+	sdk, err := ycsdk.Build(ctx, ycsdk.Config{Credentials: credentials})
+	if err != nil {
+		return "", fmt.Errorf("failed to create Yandex Cloud SDK: %w", err)
+	}
+	defer sdk.Shutdown(ctx)
+
+	payload, err := sdk.LockboxPayload().Payload().Get(ctx, &lockbox.GetPayloadRequest{SecretId: secretID})
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	for _, entry := range payload.GetEntries() {
+		if entry.GetKey() == key {
+			return entry.GetTextValue(), nil
+		}
+	}
+	return "", fmt.Errorf("%s: key %q not found in secret", ref, key)
+}
+
+// yandexCredentials picks Yandex Cloud credentials the way the yc CLI
+// environment variables describe them, falling back to the instance
+// service account
+func yandexCredentials() (ycsdk.Credentials, error) {
+	switch {
+	case os.Getenv("YC_IAM_TOKEN") != "":
+		return ycsdk.NewIAMTokenCredentials(os.Getenv("YC_IAM_TOKEN")), nil
+	case os.Getenv("YC_TOKEN") != "":
+		return ycsdk.OAuthToken(os.Getenv("YC_TOKEN")), nil
+	case os.Getenv("YC_SERVICE_ACCOUNT_KEY_FILE") != "":
+		key, err := iamkey.ReadFromJSONFile(os.Getenv("YC_SERVICE_ACCOUNT_KEY_FILE"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account key: %w", err)
+		}
+		return ycsdk.ServiceAccountKey(key)
+	default:
+		return ycsdk.InstanceServiceAccount(), nil
+	}
+}
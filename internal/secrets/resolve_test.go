@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MG_KEY", "from-env")
+	ctx := context.Background()
+
+	for ref, want := range map[string]string{"plaintext": "plaintext", "env://MG_KEY": "from-env", "file://" + keyFile: "from-file"} {
+		if got, err := Resolve(ctx, ref); err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+	if _, err := Resolve(ctx, "env://GOSLING_UNSET_KEY"); err == nil {
+		t.Error("expected error for unset environment variable")
+	}
+	if IsReference("plaintext") || !IsReference("keychain://prod") || !IsReference("vault://secret/a/b") {
+		t.Error("IsReference misclassified a value")
+	}
+}
+
+func TestResolveVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/gosling/prod" || r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"data": map[string]string{"api-key": "from-vault"}},
+		})
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+	ctx := context.Background()
+
+	if got, err := Resolve(ctx, "vault://secret/gosling/prod/api-key"); err != nil || got != "from-vault" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, err := Resolve(ctx, "vault://secret/gosling/prod/other"); err == nil {
+		t.Error("expected error for missing key")
+	}
+	if _, err := Resolve(ctx, "vault://secret/gosling/staging/api-key"); err == nil {
+		t.Error("expected error for HTTP failure")
+	}
+	if _, err := Resolve(ctx, "vault://secret/api-key"); err == nil {
+		t.Error("expected error for URI without a key")
+	}
+}
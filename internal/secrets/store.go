@@ -0,0 +1,137 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	lockbox "github.com/yandex-cloud/go-genproto/yandex/cloud/lockbox/v1"
+	ycsdk "github.com/yandex-cloud/go-sdk"
+)
+
+// Store writes secret to the location ref points to, using the reference
+// formats of Resolve. Other keys of a Vault or Lockbox secret are kept.
+// Environment variables and plaintext values cannot be written.
+func Store(ctx context.Context, ref, secret string) error {
+	scheme, rest, _ := strings.Cut(ref, "://")
+	switch scheme {
+	case "keychain":
+		if err := DefaultKeychain.Set(KeychainService, rest, secret); err != nil {
+			return fmt.Errorf("failed to write %s: %w", ref, err)
+		}
+		return nil
+	case "file":
+		if err := os.WriteFile(rest, []byte(secret+"\n"), 0600); err != nil {
+			return fmt.Errorf("failed to write secret file: %w", err)
+		}
+		return nil
+	case "vault":
+		return storeVault(ctx, ref, rest, secret)
+	case "yc-lockbox":
+		return storeLockbox(ctx, ref, rest, secret)
+	case "env":
+		return fmt.Errorf("cannot write %s: environment variables are read-only", ref)
+	default:
+		if rest == "" {
+			return fmt.Errorf("cannot write to %q: not a secret reference", ref)
+		}
+		return fmt.Errorf("cannot write %s: unsupported secret backend %s://", ref, scheme)
+	}
+}
+
+// storeVault sets a field of a Vault KV v2 secret with a JSON merge patch,
+// creating the secret if it does not exist yet
+func storeVault(ctx context.Context, ref, path, secret string) error {
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 {
+		return fmt.Errorf("invalid vault URI %s: expected vault://{mount}/{path}/{key}", ref)
+	}
+	mount, secretPath, key := parts[0], strings.Join(parts[1:len(parts)-1], "/"), parts[len(parts)-1]
+
+	body, err := json.Marshal(map[string]interface{}{"data": map[string]string{key: secret}})
+	if err != nil {
+		return fmt.Errorf("failed to encode Vault request: %w", err)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	status, err := doVaultWrite(ctx, client, ref, http.MethodPatch, mount, secretPath, body)
+	if err != nil {
+		return err
+	}
+	// PATCH only updates existing secrets
+	if status == http.StatusNotFound {
+		if status, err = doVaultWrite(ctx, client, ref, http.MethodPost, mount, secretPath, body); err != nil {
+			return err
+		}
+	}
+	if status != http.StatusOK && status != http.StatusNoContent {
+		return fmt.Errorf("failed to write %s: HTTP %d", ref, status)
+	}
+	return nil
+}
+
+// doVaultWrite sends a write request and returns its status code. Errors
+// other than 404 are returned with the response body.
+func doVaultWrite(ctx context.Context, client *http.Client, ref, method, mount, path string, body []byte) (int, error) {
+	req, err := newVaultRequest(ctx, ref, method, mount, path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", ref, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return resp.StatusCode, nil
+	default:
+		data, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("failed to write %s: HTTP %d: %s", ref, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+}
+
+// storeLockbox adds a version of a Yandex Cloud Lockbox secret with the entry
+// changed, carrying the other entries over from the current version
+func storeLockbox(ctx context.Context, ref, path, secret string) error {
+	secretID, key, ok := strings.Cut(path, "/")
+	if !ok || secretID == "" || key == "" {
+		return fmt.Errorf("invalid yc-lockbox URI %s: expected yc-lockbox://{secret-id}/{key}", ref)
+	}
+
+	credentials, err := yandexCredentials()
+	if err != nil {
+		return err
+	}
+	sdk, err := ycsdk.Build(ctx, ycsdk.Config{Credentials: credentials})
+	if err != nil {
+		return fmt.Errorf("failed to create Yandex Cloud SDK: %w", err)
+	}
+	defer sdk.Shutdown(ctx)
+
+	op, err := sdk.WrapOperation(sdk.LockboxSecret().Secret().AddVersion(ctx, &lockbox.AddVersionRequest{
+		SecretId:    secretID,
+		Description: "written by gosling",
+		PayloadEntries: []*lockbox.PayloadEntryChange{{
+			Key:   key,
+			Value: &lockbox.PayloadEntryChange_TextValue{TextValue: secret},
+		}},
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", ref, err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreFile(t *testing.T) {
+	ref := "file://" + filepath.Join(t.TempDir(), "token")
+	ctx := context.Background()
+
+	if err := Store(ctx, ref, "glrt-new"); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if got, err := Resolve(ctx, ref); err != nil || got != "glrt-new" {
+		t.Errorf("Resolve after Store = %q, %v", got, err)
+	}
+	for _, ref := range []string{"env://RUNNER_TOKEN", "plaintext", "aws-sm://gitlab-tokens/my-app"} {
+		if err := Store(ctx, ref, "glrt-new"); err == nil {
+			t.Errorf("Store(%q): expected error", ref)
+		}
+	}
+}
+
+func TestStoreVault(t *testing.T) {
+	stored := map[string]map[string]string{"gosling/prod": {"other": "kept"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		path := r.URL.Path[len("/v1/secret/data/"):]
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.Method {
+		case http.MethodPatch:
+			if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+				http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+				return
+			}
+			if stored[path] == nil {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			for k, v := range body.Data {
+				stored[path][k] = v
+			}
+		case http.MethodPost:
+			stored[path] = body.Data
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+	ctx := context.Background()
+
+	if err := Store(ctx, "vault://secret/gosling/prod/runner-token", "glrt-new"); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if got := stored["gosling/prod"]; got["runner-token"] != "glrt-new" || got["other"] != "kept" {
+		t.Errorf("existing secret = %v, want runner-token set and other kept", got)
+	}
+	if err := Store(ctx, "vault://secret/gosling/staging/runner-token", "glrt-new"); err != nil {
+		t.Fatalf("Store of a new secret failed: %v", err)
+	}
+	if got := stored["gosling/staging"]["runner-token"]; got != "glrt-new" {
+		t.Errorf("new secret runner-token = %q", got)
+	}
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	if err := Store(ctx, "vault://secret/gosling/prod/runner-token", "glrt-new"); err == nil {
+		t.Error("expected error for HTTP failure")
+	}
+}
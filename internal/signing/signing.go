@@ -0,0 +1,142 @@
+// Package signing signs deployment plans so MotherGoose can reject plans
+// that were modified after they left the CLI.
+//
+// Keys are Ed25519 key pairs in PEM form, as produced by:
+//
+//	openssl genpkey -algorithm ed25519 -out plan-signing.key
+//	openssl pkey -in plan-signing.key -pubout -out plan-signing.pub
+//
+// Like minisign, every key has a short ID derived from its public key so a
+// verifier holding several trusted keys knows which one to check against.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+// PayloadVersion identifies the form of the signed payload. Bump it only when
+// the payload has to cover different fields.
+const PayloadVersion = "v1"
+
+var (
+	// ErrUnsigned is returned when verifying a plan without a signature
+	ErrUnsigned = errors.New("plan is not signed")
+
+	// ErrUnknownKey is returned when no trusted key matches the plan's key ID
+	ErrUnknownKey = errors.New("plan was signed by an untrusted key")
+
+	// ErrInvalidSignature is returned when the signature does not match the plan
+	ErrInvalidSignature = errors.New("plan signature is invalid")
+)
+
+// KeyID returns the short identifier of a public key: the first 8 bytes of
+// its SHA-256 digest in hex
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePrivateKey decodes a PEM encoded PKCS #8 Ed25519 private key
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("signing key must be a PEM encoded PRIVATE KEY")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key must be an Ed25519 key, got %T", key)
+	}
+	return priv, nil
+}
+
+// ParsePublicKeys decodes every PEM encoded PKIX Ed25519 public key in data,
+// indexed by key ID
+func ParsePublicKeys(data []byte) (map[string]ed25519.PublicKey, error) {
+	keys := make(map[string]ed25519.PublicKey)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key must be an Ed25519 key, got %T", key)
+		}
+		keys[KeyID(pub)] = pub
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no PEM encoded PUBLIC KEY found")
+	}
+	return keys, nil
+}
+
+// LoadPublicKeys reads the trusted public keys from a PEM file
+func LoadPublicKeys(path string) (map[string]ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key file: %w", err)
+	}
+	keys, err := ParsePublicKeys(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// Payload returns the bytes covered by a plan's signature. Besides the plan
+// binary it binds the plan ID, Egg and config hash, so a valid plan cannot be
+// replayed for another Egg.
+func Payload(plan *deployer.DeploymentPlan) []byte {
+	sum := sha256.Sum256(plan.PlanBinary)
+	return []byte(strings.Join([]string{
+		"gosling-plan-signature/" + PayloadVersion,
+		"id=" + plan.ID,
+		"egg=" + plan.EggName,
+		"type=" + plan.PlanType,
+		"config_hash=" + plan.ConfigHash,
+		"plan_sha256=" + hex.EncodeToString(sum[:]),
+	}, "\n") + "\n")
+}
+
+// SignPlan sets the plan's signature and signing key ID
+func SignPlan(plan *deployer.DeploymentPlan, priv ed25519.PrivateKey) {
+	plan.Signature = ed25519.Sign(priv, Payload(plan))
+	plan.SigningKeyID = KeyID(priv.Public().(ed25519.PublicKey))
+}
+
+// VerifyPlan checks the plan's signature against the trusted keys
+func VerifyPlan(plan *deployer.DeploymentPlan, keys map[string]ed25519.PublicKey) error {
+	if len(plan.Signature) == 0 {
+		return ErrUnsigned
+	}
+	pub, ok := keys[plan.SigningKeyID]
+	if !ok {
+		return fmt.Errorf("%w (key ID %q)", ErrUnknownKey, plan.SigningKeyID)
+	}
+	if !ed25519.Verify(pub, Payload(plan), plan.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
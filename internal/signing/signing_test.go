@@ -0,0 +1,105 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func generateKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	return pub, priv
+}
+
+func TestSignAndVerifyPlan(t *testing.T) {
+	pub, priv := generateKey(t)
+	otherPub, _ := generateKey(t)
+	keys := map[string]ed25519.PublicKey{KeyID(pub): pub}
+
+	newPlan := func() *deployer.DeploymentPlan {
+		plan := &deployer.DeploymentPlan{
+			ID:         "plan-1",
+			EggName:    "my-app",
+			PlanType:   "runner",
+			PlanBinary: []byte(`{"egg_name":"my-app"}`),
+			ConfigHash: "v1:abc",
+		}
+		SignPlan(plan, priv)
+		return plan
+	}
+
+	if err := VerifyPlan(newPlan(), keys); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*deployer.DeploymentPlan)
+		keys   map[string]ed25519.PublicKey
+		want   error
+	}{
+		{"unsigned", func(p *deployer.DeploymentPlan) { p.Signature = nil }, keys, ErrUnsigned},
+		{"untrusted key", func(*deployer.DeploymentPlan) {}, map[string]ed25519.PublicKey{KeyID(otherPub): otherPub}, ErrUnknownKey},
+		{"tampered binary", func(p *deployer.DeploymentPlan) { p.PlanBinary = []byte(`{"egg_name":"evil"}`) }, keys, ErrInvalidSignature},
+		{"replayed for another egg", func(p *deployer.DeploymentPlan) { p.EggName = "other-app" }, keys, ErrInvalidSignature},
+		{"changed config hash", func(p *deployer.DeploymentPlan) { p.ConfigHash = "v1:def" }, keys, ErrInvalidSignature},
+		{"swapped key ID", func(p *deployer.DeploymentPlan) { p.SigningKeyID = KeyID(otherPub) }, map[string]ed25519.PublicKey{KeyID(pub): pub, KeyID(otherPub): otherPub}, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := newPlan()
+			tt.modify(plan)
+			if err := VerifyPlan(plan, tt.keys); !errors.Is(err, tt.want) {
+				t.Errorf("VerifyPlan() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	pub, priv := generateKey(t)
+	otherPub, _ := generateKey(t)
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	if err != nil {
+		t.Fatalf("ParsePrivateKey failed: %v", err)
+	}
+	if !parsed.Equal(priv) {
+		t.Error("parsed private key does not match")
+	}
+	if _, err := ParsePrivateKey([]byte("not a key")); err == nil {
+		t.Error("expected error for non-PEM private key")
+	}
+
+	var bundle []byte
+	for _, key := range []ed25519.PublicKey{pub, otherPub} {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})...)
+	}
+	keys, err := ParsePublicKeys(bundle)
+	if err != nil {
+		t.Fatalf("ParsePublicKeys failed: %v", err)
+	}
+	if len(keys) != 2 || !keys[KeyID(pub)].Equal(pub) || !keys[KeyID(otherPub)].Equal(otherPub) {
+		t.Errorf("expected both keys indexed by ID, got %v", keys)
+	}
+	if _, err := ParsePublicKeys(nil); err == nil {
+		t.Error("expected error for empty key file")
+	}
+}
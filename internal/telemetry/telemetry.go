@@ -0,0 +1,248 @@
+// Package telemetry traces and measures the requests gosling makes to
+// MotherGoose, GitLab and the cloud providers with OpenTelemetry.
+//
+// Instrumentation goes through the global OpenTelemetry providers, which
+// discard everything until Setup installs exporters. Exporters are
+// configured with the standard environment variables:
+//
+//	OTEL_EXPORTER_OTLP_ENDPOINT          e.g. http://otel-collector:4318
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT   traces only
+//	OTEL_EXPORTER_OTLP_METRICS_ENDPOINT  metrics only
+//	OTEL_EXPORTER_OTLP_HEADERS           e.g. authorization=Bearer ...
+//	OTEL_SERVICE_NAME                    default: gosling
+//	OTEL_SDK_DISABLED=true               turns OTLP export off
+//
+// Long-running commands can also serve the metrics to Prometheus on a local
+// address, see Config.MetricsAddr.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of gosling's spans and metrics
+const ScopeName = "github.com/polar-gosling/gosling"
+
+// Attribute keys specific to gosling
+const (
+	ClientKey  = attribute.Key("gosling.client")  // mothergoose, gitlab
+	RetriesKey = attribute.Key("gosling.retries") // Retries of a logical request
+	EggKey     = attribute.Key("gosling.egg")
+)
+
+// Config selects what Setup installs
+type Config struct {
+	Version     string // Reported as service.version
+	MetricsAddr string // Serve Prometheus metrics at http://MetricsAddr/metrics if set
+}
+
+// Setup installs OTLP/HTTP exporters for traces and metrics when an
+// endpoint is configured in the environment, and serves metrics to
+// Prometheus when cfg.MetricsAddr is set. The returned function flushes and
+// stops them; it must be called before the process exits. When nothing is
+// configured nothing is installed and the function does nothing.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	disabled, _ := strconv.ParseBool(os.Getenv("OTEL_SDK_DISABLED"))
+	traces := !disabled && (os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "")
+	metrics := !disabled && (os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") != "")
+
+	var shutdowns []func(context.Context) error
+	shutdown := func(ctx context.Context) error {
+		var errs []error
+		for _, fn := range shutdowns {
+			errs = append(errs, fn(ctx))
+		}
+		return errors.Join(errs...)
+	}
+	if !traces && !metrics && cfg.MetricsAddr == "" {
+		return shutdown, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceVersion(cfg.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe telemetry resource: %w", err)
+	}
+	if os.Getenv("OTEL_SERVICE_NAME") == "" {
+		res, _ = resource.Merge(res, resource.NewSchemaless(semconv.ServiceName("gosling")))
+	}
+
+	if traces {
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+		shutdowns = append(shutdowns, provider.Shutdown)
+	}
+
+	var readers []sdkmetric.Option
+	if metrics {
+		exporter, err := otlpmetrichttp.New(ctx)
+		if err != nil {
+			shutdown(ctx)
+			return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		}
+		readers = append(readers, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)))
+	}
+	if cfg.MetricsAddr != "" {
+		reader, stop, err := servePrometheus(cfg.MetricsAddr)
+		if err != nil {
+			shutdown(ctx)
+			return nil, err
+		}
+		readers = append(readers, sdkmetric.WithReader(reader))
+		shutdowns = append(shutdowns, stop)
+	}
+	if len(readers) > 0 {
+		provider := sdkmetric.NewMeterProvider(append(readers, sdkmetric.WithResource(res))...)
+		otel.SetMeterProvider(provider)
+		// Stop the provider before the Prometheus server
+		shutdowns = append([]func(context.Context) error{provider.Shutdown}, shutdowns...)
+	}
+	return shutdown, nil
+}
+
+// Tracer returns gosling's tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(ScopeName)
+}
+
+// StartSpan starts a span of an internal operation, such as deploying an Egg
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends span, marking it failed if err is not nil
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// CloudAttributes describe the cloud provider and region an operation targets
+func CloudAttributes(provider, region string) []attribute.KeyValue {
+	return []attribute.KeyValue{semconv.CloudProviderKey.String(provider), semconv.CloudRegion(region)}
+}
+
+// StartRequest starts the span of a logical request of client, covering
+// every attempt made to complete it
+func StartRequest(ctx context.Context, client, method, path string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, client+" "+method, trace.WithAttributes(
+		ClientKey.String(client),
+		semconv.HTTPRequestMethodKey.String(method),
+		semconv.URLPath(path),
+	))
+}
+
+// EndRequest ends the span of a logical request that was retried retries
+// times, marking it failed if err is not nil
+func EndRequest(span trace.Span, retries int, err error) {
+	span.SetAttributes(RetriesKey.Int(retries))
+	EndSpan(span, err)
+}
+
+// instruments are the metrics recorded by gosling
+type instruments struct {
+	requestDuration metric.Float64Histogram
+	retries         metric.Int64Counter
+	apiErrors       metric.Int64Counter
+	validations     metric.Int64Counter
+	deploys         metric.Int64Counter
+	parseDuration   metric.Float64Histogram
+}
+
+var (
+	instrumentsMu sync.Mutex
+	metricsOf     *instruments
+	metricsFrom   metric.MeterProvider // Provider metricsOf was created from
+)
+
+// meters returns gosling's metric instruments, created from the global
+// provider the first time they are used after it changes
+func meters() *instruments {
+	provider := otel.GetMeterProvider()
+	instrumentsMu.Lock()
+	defer instrumentsMu.Unlock()
+	if metricsOf != nil && metricsFrom == provider {
+		return metricsOf
+	}
+
+	meter := provider.Meter(ScopeName)
+	m := &instruments{}
+	m.requestDuration, _ = meter.Float64Histogram("http.client.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP requests to MotherGoose and GitLab"))
+	m.retries, _ = meter.Int64Counter("gosling.client.retries",
+		metric.WithUnit("{retry}"),
+		metric.WithDescription("Requests retried after a failed attempt"))
+	m.apiErrors, _ = meter.Int64Counter("gosling.api.errors",
+		metric.WithUnit("{error}"),
+		metric.WithDescription("Failed HTTP requests to MotherGoose and GitLab"))
+	m.validations, _ = meter.Int64Counter("gosling.validations",
+		metric.WithUnit("{file}"),
+		metric.WithDescription(".fly files validated"))
+	m.deploys, _ = meter.Int64Counter("gosling.deploys",
+		metric.WithUnit("{egg}"),
+		metric.WithDescription("Eggs deployed, by outcome"))
+	m.parseDuration, _ = meter.Float64Histogram("gosling.parse.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of parsing a .fly file"))
+	metricsOf, metricsFrom = m, provider
+	return m
+}
+
+// RecordRetry counts a retry of a request made by client
+func RecordRetry(ctx context.Context, client, method string) {
+	meters().retries.Add(ctx, 1, metric.WithAttributes(ClientKey.String(client), semconv.HTTPRequestMethodKey.String(method)))
+}
+
+// Attribute key of the outcome of validations, deploys and parses
+const resultKey = attribute.Key("result")
+
+// RecordValidation counts a validated .fly file
+func RecordValidation(ctx context.Context, valid bool) {
+	result := "valid"
+	if !valid {
+		result = "invalid"
+	}
+	meters().validations.Add(ctx, 1, metric.WithAttributes(resultKey.String(result)))
+}
+
+// RecordDeploy counts an Egg deployed with status, e.g. applied or failed
+func RecordDeploy(ctx context.Context, status string) {
+	meters().deploys.Add(ctx, 1, metric.WithAttributes(resultKey.String(status)))
+}
+
+// RecordParse records how long parsing a .fly file took
+func RecordParse(ctx context.Context, d time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	meters().parseDuration.Record(ctx, d.Seconds(), metric.WithAttributes(resultKey.String(result)))
+}
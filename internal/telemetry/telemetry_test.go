@@ -0,0 +1,174 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordTelemetry installs in-memory trace and metric providers for a test
+func recordTelemetry(t *testing.T) (*tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	tracerProvider, meterProvider, propagator := otel.GetTracerProvider(), otel.GetMeterProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetMeterProvider(meterProvider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	return spans, reader
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTransport(t *testing.T) {
+	spans, reader := recordTelemetry(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Traceparent") == "" {
+			t.Error("expected the trace context to be propagated")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Transport("mothergoose", nil)}
+	ctx, parent := StartRequest(context.Background(), "mothergoose", "GET", "/eggs")
+	req, _ := http.NewRequestWithContext(WithAttempt(ctx, 2), "GET", server.URL+"/eggs?token=secret", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	EndRequest(parent, 2, nil)
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("expected a request and an attempt span, got %d", len(ended))
+	}
+	attempt := ended[0]
+	if attempt.Name() != "GET" || attempt.Parent().SpanID() != ended[1].SpanContext().SpanID() {
+		t.Errorf("expected the GET attempt to be a child of the request span, got %q", attempt.Name())
+	}
+	if got := spanAttr(attempt, "http.response.status_code").AsInt64(); got != 503 {
+		t.Errorf("expected status code 503, got %d", got)
+	}
+	if got := spanAttr(attempt, "http.request.resend_count").AsInt64(); got != 2 {
+		t.Errorf("expected resend count 2, got %d", got)
+	}
+	if got := spanAttr(attempt, "url.full").AsString(); got != server.URL+"/eggs" {
+		t.Errorf("expected the URL without its query, got %q", got)
+	}
+	if got := spanAttr(ended[1], RetriesKey).AsInt64(); got != 2 {
+		t.Errorf("expected 2 retries on the request span, got %d", got)
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &metrics); err != nil {
+		t.Fatal(err)
+	}
+	var count uint64
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if hist, ok := m.Data.(metricdata.Histogram[float64]); ok && m.Name == "http.client.request.duration" {
+				for _, point := range hist.DataPoints {
+					count += point.Count
+				}
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected one recorded request duration, got %d", count)
+	}
+}
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "")
+	provider := otel.GetTracerProvider()
+
+	shutdown, err := Setup(context.Background(), Config{Version: "test"})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if otel.GetTracerProvider() != provider {
+		t.Error("expected no tracer provider to be installed without an endpoint")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+}
+
+func TestSetupServesPrometheus(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	provider := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(provider) })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	shutdown, err := Setup(context.Background(), Config{Version: "test", MetricsAddr: addr})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer shutdown(context.Background())
+
+	if _, err := Setup(context.Background(), Config{MetricsAddr: addr}); err == nil {
+		t.Error("expected an error serving metrics on an address in use")
+	}
+
+	RecordValidation(context.Background(), false)
+	RecordDeploy(context.Background(), "applied")
+	RecordParse(context.Background(), 10*time.Millisecond, nil)
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	client := &http.Client{Transport: Transport("mothergoose", nil)}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatal("expected the request to a closed server to fail")
+	}
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`gosling_validations_total{otel_scope_name="github.com/polar-gosling/gosling",otel_scope_version="",result="invalid"} 1`,
+		`gosling_deploys_total{otel_scope_name="github.com/polar-gosling/gosling",otel_scope_version="",result="applied"} 1`,
+		`gosling_parse_duration_seconds_count{otel_scope_name="github.com/polar-gosling/gosling",otel_scope_version="",result="ok"} 1`,
+		`gosling_api_errors_total{`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// attemptKey is the context key of the attempt number of a retried request
+type attemptKey struct{}
+
+// WithAttempt records in ctx that requests made with it are the attempt-th
+// retry of a request, reported as http.request.resend_count
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// transport is an http.RoundTripper creating a client span and recording the
+// duration of every request
+type transport struct {
+	client string
+	base   http.RoundTripper
+}
+
+// Transport instruments the requests that client (e.g. "gitlab") sends
+// through base, or http.DefaultTransport if base is nil
+func Transport(client string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{client: client, base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := []attribute.KeyValue{
+		ClientKey.String(t.client),
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.ServerAddress(req.URL.Hostname()),
+	}
+	spanAttrs := append([]attribute.KeyValue{semconv.URLFull(redactedURL(req))}, attrs...)
+	if attempt, ok := req.Context().Value(attemptKey{}).(int); ok && attempt > 0 {
+		spanAttrs = append(spanAttrs, semconv.HTTPRequestResendCount(attempt))
+	}
+
+	ctx, span := Tracer().Start(req.Context(), req.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(spanAttrs...))
+	defer span.End()
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	failed := err != nil
+	if err != nil {
+		attrs = append(attrs, semconv.ErrorTypeKey.String("transport"))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		attrs = append(attrs, semconv.HTTPResponseStatusCode(resp.StatusCode))
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			failed = true
+			attrs = append(attrs, semconv.ErrorTypeKey.String(strconv.Itoa(resp.StatusCode)))
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+	meters().requestDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	if failed {
+		meters().apiErrors.Add(ctx, 1, metric.WithAttributes(attrs...))
+	}
+	return resp, err
+}
+
+// redactedURL returns the URL of req without its query, which may carry tokens
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.User = nil
+	return u.String()
+}
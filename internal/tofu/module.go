@@ -0,0 +1,203 @@
+// Package tofu generates OpenTofu modules from deployment configurations.
+//
+// The generated module describes the same runner infrastructure MotherGoose
+// provisions for an Egg, so users can inspect it or apply it themselves.
+// Values MotherGoose supplies at deploy time (images, networks, credentials)
+// become input variables.
+package tofu
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Module is a generated OpenTofu module
+type Module struct {
+	main      *hclwrite.File
+	variables *hclwrite.File
+	outputs   *hclwrite.File
+}
+
+// Files returns the module's files keyed by file name
+func (m *Module) Files() map[string][]byte {
+	return map[string][]byte{
+		"main.tf":      m.main.Bytes(),
+		"variables.tf": m.variables.Bytes(),
+		"outputs.tf":   m.outputs.Bytes(),
+	}
+}
+
+// FileNames returns the module's file names in sorted order
+func (m *Module) FileNames() []string {
+	names := make([]string, 0, 3)
+	for name := range m.Files() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteDir writes the module's files to dir, creating it if needed
+func (m *Module) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for name, content := range m.Files() {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// providerSources are the registry addresses of the supported providers
+var providerSources = map[deployer.CloudProvider]struct{ name, source string }{
+	deployer.CloudProviderYandex: {"yandex", "yandex-cloud/yandex"},
+	deployer.CloudProviderAWS:    {"aws", "hashicorp/aws"},
+	deployer.CloudProviderAzure:  {"azurerm", "hashicorp/azurerm"},
+}
+
+// newModule starts a module with the provider configuration and the variables
+// every runner needs
+func newModule(cloud deployer.CloudConfig) (*Module, error) {
+	provider, ok := providerSources[cloud.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cloud provider: %s", cloud.Provider)
+	}
+	m := &Module{
+		main:      hclwrite.NewEmptyFile(),
+		variables: hclwrite.NewEmptyFile(),
+		outputs:   hclwrite.NewEmptyFile(),
+	}
+
+	body := m.main.Body()
+	required := body.AppendNewBlock("terraform", nil).Body().AppendNewBlock("required_providers", nil).Body()
+	required.SetAttributeValue(provider.name, cty.ObjectVal(map[string]cty.Value{
+		"source": cty.StringVal(provider.source),
+	}))
+	body.AppendNewline()
+
+	providerBody := body.AppendNewBlock("provider", []string{provider.name}).Body()
+	switch cloud.Provider {
+	case deployer.CloudProviderYandex:
+		providerBody.SetAttributeValue("zone", cty.StringVal(cloud.Region))
+		providerBody.SetAttributeTraversal("folder_id", varRef("folder_id"))
+		m.variable("folder_id", "Yandex Cloud folder to create runners in", false, nil)
+	case deployer.CloudProviderAWS:
+		providerBody.SetAttributeValue("region", cty.StringVal(cloud.Region))
+	case deployer.CloudProviderAzure:
+		providerBody.AppendNewBlock("features", nil)
+	}
+	body.AppendNewline()
+
+	m.variable("runner_image", "Gosling runner container image", false, nil)
+	m.variable("mothergoose_url", "MotherGoose API URL the runner reports to", false, nil)
+	m.variable("mothergoose_api_key", "MotherGoose API key", true, nil)
+	gitlabServer := cty.StringVal("gitlab.com")
+	m.variable("gitlab_server", "GitLab server FQDN", false, &gitlabServer)
+	return m, nil
+}
+
+// variable declares an input variable
+func (m *Module) variable(name, description string, sensitive bool, def *cty.Value) {
+	body := m.variables.Body()
+	if len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+	v := body.AppendNewBlock("variable", []string{name}).Body()
+	v.SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+	v.SetAttributeValue("description", cty.StringVal(description))
+	if def != nil {
+		v.SetAttributeValue("default", *def)
+	}
+	if sensitive {
+		v.SetAttributeValue("sensitive", cty.True)
+	}
+}
+
+// output declares an output referring to an attribute of a resource
+func (m *Module) output(name, description string, ref hcl.Traversal) {
+	body := m.outputs.Body()
+	if len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+	o := body.AppendNewBlock("output", []string{name}).Body()
+	o.SetAttributeValue("description", cty.StringVal(description))
+	o.SetAttributeTraversal("value", ref)
+}
+
+// addRunnerLocals adds a locals block defining local.runner_command, the full
+// `gosling runner` command line, and returns its body. Every provider runs it
+// in place of the image's entrypoint so that all runners start the same way.
+func (m *Module) addRunnerLocals(eggName string, runner deployer.RunnerConfig, gitlab deployer.GitLabConfig) *hclwrite.Body {
+	args := []hclwrite.Tokens{
+		str("gosling"), str("runner"),
+		str("--egg-name"), str(eggName),
+		str("--token-secret"), str(gitlab.TokenSecret),
+		str("--gitlab-server"), refTokens(varRef("gitlab_server")),
+		str("--mothergoose-url"), refTokens(varRef("mothergoose_url")),
+		str("--api-key"), refTokens(varRef("mothergoose_api_key")),
+	}
+	if len(runner.Tags) > 0 {
+		args = append(args, str("--tags"), str(strings.Join(runner.Tags, ",")))
+	}
+	if gitlab.GroupID != 0 {
+		args = append(args, str("--group-id"), str(strconv.Itoa(gitlab.GroupID)))
+	} else if gitlab.ProjectID != 0 {
+		args = append(args, str("--project-id"), str(strconv.Itoa(gitlab.ProjectID)))
+	}
+	if gitlab.CACert != "" {
+		args = append(args, str("--gitlab-ca-cert"), str(gitlab.CACert))
+	}
+
+	body := m.main.Body()
+	locals := body.AppendNewBlock("locals", nil).Body()
+	locals.SetAttributeRaw("runner_command", hclwrite.TokensForTuple(args))
+	body.AppendNewline()
+	return locals
+}
+
+func varRef(name string) hcl.Traversal {
+	return hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: name}}
+}
+
+func localRef(name string) hcl.Traversal {
+	return hcl.Traversal{hcl.TraverseRoot{Name: "local"}, hcl.TraverseAttr{Name: name}}
+}
+
+func resourceRef(resourceType, name, attr string) hcl.Traversal {
+	return hcl.Traversal{hcl.TraverseRoot{Name: resourceType}, hcl.TraverseAttr{Name: name}, hcl.TraverseAttr{Name: attr}}
+}
+
+func dataRef(dataType, name, attr string) hcl.Traversal {
+	return hcl.Traversal{hcl.TraverseRoot{Name: "data"}, hcl.TraverseAttr{Name: dataType}, hcl.TraverseAttr{Name: name}, hcl.TraverseAttr{Name: attr}}
+}
+
+func refTokens(traversal hcl.Traversal) hclwrite.Tokens {
+	return hclwrite.TokensForTraversal(traversal)
+}
+
+func str(s string) hclwrite.Tokens {
+	return hclwrite.TokensForValue(cty.StringVal(s))
+}
+
+// stringMap converts an environment map to a cty map value
+func stringMap(m map[string]string) cty.Value {
+	if len(m) == 0 {
+		return cty.MapValEmpty(cty.String)
+	}
+	values := make(map[string]cty.Value, len(m))
+	for k, v := range m {
+		values[k] = cty.StringVal(v)
+	}
+	return cty.MapVal(values)
+}
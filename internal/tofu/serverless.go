@@ -0,0 +1,112 @@
+package tofu
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Limits of AWS Lambda functions
+const (
+	awsLambdaMaxTimeout        = 15 * time.Minute
+	awsLambdaMinEphemeralStore = 512   // MB
+	awsLambdaMaxEphemeralStore = 10240 // MB
+)
+
+// ServerlessModule generates the module for a serverless runner
+func ServerlessModule(sc *deployer.ServerlessConfig) (*Module, error) {
+	m, err := newModule(sc.Cloud)
+	if err != nil {
+		return nil, err
+	}
+	m.addRunnerLocals(sc.EggName, sc.Runner, sc.GitLab)
+
+	body := m.main.Body()
+	switch sc.Cloud.Provider {
+	case deployer.CloudProviderYandex:
+		r := body.AppendNewBlock("resource", []string{"yandex_serverless_container", "runner"}).Body()
+		r.SetAttributeValue("name", cty.StringVal("gosling-"+sc.EggName))
+		r.SetAttributeTraversal("folder_id", varRef("folder_id"))
+		r.SetAttributeTraversal("service_account_id", varRef("service_account_id"))
+		r.SetAttributeValue("memory", cty.NumberIntVal(int64(sc.Resources.Memory)))
+		r.SetAttributeValue("cores", cty.NumberIntVal(int64(sc.Resources.CPU)))
+		r.SetAttributeValue("concurrency", cty.NumberIntVal(int64(sc.Runner.Concurrent)))
+		r.SetAttributeValue("execution_timeout", cty.StringVal(seconds(sc.Timeout)))
+		r.AppendNewline()
+		image := r.AppendNewBlock("image", nil).Body()
+		image.SetAttributeTraversal("url", varRef("runner_image"))
+		image.SetAttributeTraversal("command", localRef("runner_command"))
+		image.SetAttributeValue("environment", stringMap(sc.Environment))
+
+		m.variable("service_account_id", "Service account the container runs as; needs access to the runner token secret", false, nil)
+		m.output("container_id", "ID of the runner container", resourceRef("yandex_serverless_container", "runner", "id"))
+
+	case deployer.CloudProviderAWS:
+		timeout := sc.Timeout
+		if timeout <= 0 || timeout > awsLambdaMaxTimeout {
+			timeout = awsLambdaMaxTimeout
+		}
+		storage := clamp(sc.Resources.Disk*1024, awsLambdaMinEphemeralStore, awsLambdaMaxEphemeralStore)
+
+		r := body.AppendNewBlock("resource", []string{"aws_lambda_function", "runner"}).Body()
+		r.SetAttributeValue("function_name", cty.StringVal("gosling-"+sc.EggName))
+		r.SetAttributeValue("package_type", cty.StringVal("Image"))
+		r.SetAttributeTraversal("image_uri", varRef("runner_image"))
+		r.SetAttributeTraversal("role", varRef("lambda_role_arn"))
+		r.SetAttributeValue("memory_size", cty.NumberIntVal(int64(sc.Resources.Memory)))
+		if sc.Runner.Arch == "arm64" {
+			r.SetAttributeValue("architectures", stringList([]string{"arm64"}))
+		}
+		r.SetAttributeValue("timeout", cty.NumberIntVal(int64(timeout/time.Second)))
+		r.SetAttributeValue("tags", cty.MapVal(map[string]cty.Value{"egg": cty.StringVal(sc.EggName)}))
+		r.AppendNewline()
+		r.AppendNewBlock("image_config", nil).Body().SetAttributeTraversal("entry_point", localRef("runner_command"))
+		r.AppendNewBlock("ephemeral_storage", nil).Body().SetAttributeValue("size", cty.NumberIntVal(int64(storage)))
+		if len(sc.Environment) > 0 {
+			r.AppendNewBlock("environment", nil).Body().SetAttributeValue("variables", stringMap(sc.Environment))
+		}
+
+		m.variable("lambda_role_arn", "IAM role the function runs as; needs access to the runner token secret", false, nil)
+		m.output("function_arn", "ARN of the runner function", resourceRef("aws_lambda_function", "runner", "arn"))
+
+	case deployer.CloudProviderAzure:
+		r := body.AppendNewBlock("resource", []string{"azurerm_container_group", "runner"}).Body()
+		r.SetAttributeValue("name", cty.StringVal("gosling-"+sc.EggName))
+		r.SetAttributeTraversal("resource_group_name", varRef("resource_group_name"))
+		r.SetAttributeValue("location", cty.StringVal(sc.Cloud.Region))
+		r.SetAttributeValue("os_type", cty.StringVal("Linux"))
+		r.SetAttributeValue("ip_address_type", cty.StringVal("None"))
+		r.SetAttributeValue("restart_policy", cty.StringVal("OnFailure"))
+		r.SetAttributeValue("tags", cty.MapVal(map[string]cty.Value{"egg": cty.StringVal(sc.EggName)}))
+		r.AppendNewline()
+		container := r.AppendNewBlock("container", nil).Body()
+		container.SetAttributeValue("name", cty.StringVal("gosling-runner"))
+		container.SetAttributeTraversal("image", varRef("runner_image"))
+		container.SetAttributeValue("cpu", cty.NumberIntVal(int64(sc.Resources.CPU)))
+		container.SetAttributeValue("memory", cty.NumberFloatVal(float64(sc.Resources.Memory)/1024))
+		container.SetAttributeTraversal("commands", localRef("runner_command"))
+		container.SetAttributeValue("environment_variables", stringMap(sc.Environment))
+
+		m.variable("resource_group_name", "Resource group to create the runner in", false, nil)
+		m.output("container_group_id", "ID of the runner container group", resourceRef("azurerm_container_group", "runner", "id"))
+	}
+
+	return m, nil
+}
+
+// seconds formats d as a whole number of seconds (e.g. "3600s")
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%ds", int64(d/time.Second))
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,361 @@
+package tofu
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/polar-gosling/gosling/internal/deployer"
+)
+
+func testRunner() (deployer.RunnerConfig, deployer.GitLabConfig) {
+	return deployer.RunnerConfig{Tags: []string{"docker", "linux"}, Concurrent: 2, IdleTimeout: 10 * time.Minute},
+		deployer.GitLabConfig{ProjectID: 42, TokenSecret: "yc-lockbox://gitlab/runner-token"}
+}
+
+// resourceTypes parses a module's main.tf and returns its resource types
+func resourceTypes(t *testing.T, m *Module) []string {
+	t.Helper()
+	var types []string
+	for name, content := range m.Files() {
+		file, diags := hclsyntax.ParseConfig(content, name, hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatalf("%s is not valid HCL: %s\n%s", name, diags.Error(), content)
+		}
+		if name != "main.tf" {
+			continue
+		}
+		for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+			if block.Type == "resource" {
+				types = append(types, block.Labels[0])
+			}
+		}
+	}
+	return types
+}
+
+func TestVMModule(t *testing.T) {
+	runner, gitlab := testRunner()
+	tests := []struct {
+		provider deployer.CloudProvider
+		vmSize   string
+		want     string
+	}{
+		{provider: deployer.CloudProviderYandex, want: "yandex_compute_instance"},
+		{provider: deployer.CloudProviderAWS, want: "aws_instance"},
+		{provider: deployer.CloudProviderAzure, vmSize: "Standard_B2s", want: "azurerm_linux_virtual_machine"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			m, err := VMModule(&deployer.VMConfig{
+				EggName:     "my-app",
+				Cloud:       deployer.CloudConfig{Provider: tt.provider, Region: "eu-central-1"},
+				Resources:   deployer.ResourceConfig{CPU: 2, Memory: 4096, Disk: 20},
+				VMSize:      tt.vmSize,
+				Runner:      runner,
+				GitLab:      gitlab,
+				Environment: map[string]string{"LOG_LEVEL": "info"},
+			})
+			if err != nil {
+				t.Fatalf("VMModule failed: %v", err)
+			}
+			if types := resourceTypes(t, m); len(types) != 1 || types[0] != tt.want {
+				t.Errorf("expected a single %s resource, got %v", tt.want, types)
+			}
+			main := string(m.Files()["main.tf"])
+			for _, want := range []string{`"yc-lockbox://gitlab/runner-token"`, `"docker,linux"`, `'LOG_LEVEL=info'`, `"--entrypoint", "''"`, "local.runner_command"} {
+				if !strings.Contains(main, want) {
+					t.Errorf("expected %s in main.tf:\n%s", want, main)
+				}
+			}
+		})
+	}
+}
+
+func TestServerlessModule(t *testing.T) {
+	runner, gitlab := testRunner()
+	tests := []struct {
+		provider deployer.CloudProvider
+		want     string
+		contains []string
+	}{
+		{provider: deployer.CloudProviderYandex, want: "yandex_serverless_container", contains: []string{`execution_timeout  = "3600s"`, "command     = local.runner_command"}},
+		{provider: deployer.CloudProviderAWS, want: "aws_lambda_function", contains: []string{"timeout       = 900", "entry_point = local.runner_command"}},
+		{provider: deployer.CloudProviderAzure, want: "azurerm_container_group", contains: []string{"commands              = local.runner_command"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			m, err := ServerlessModule(&deployer.ServerlessConfig{
+				EggName:   "my-app",
+				Cloud:     deployer.CloudConfig{Provider: tt.provider, Region: "eu-central-1"},
+				Resources: deployer.ResourceConfig{CPU: 1, Memory: 2048, Disk: 10},
+				Runner:    runner,
+				GitLab:    gitlab,
+				Timeout:   time.Hour,
+			})
+			if err != nil {
+				t.Fatalf("ServerlessModule failed: %v", err)
+			}
+			if types := resourceTypes(t, m); len(types) != 1 || types[0] != tt.want {
+				t.Errorf("expected a single %s resource, got %v", tt.want, types)
+			}
+			main := string(m.Files()["main.tf"])
+			for _, want := range tt.contains {
+				if !strings.Contains(main, want) {
+					t.Errorf("expected %s in main.tf:\n%s", want, main)
+				}
+			}
+		})
+	}
+}
+
+func TestModuleVariables(t *testing.T) {
+	runner, gitlab := testRunner()
+	m, err := ServerlessModule(&deployer.ServerlessConfig{
+		EggName: "my-app",
+		Cloud:   deployer.CloudConfig{Provider: deployer.CloudProviderAWS, Region: "us-east-1"},
+		Runner:  runner,
+		GitLab:  gitlab,
+	})
+	if err != nil {
+		t.Fatalf("ServerlessModule failed: %v", err)
+	}
+	variables := string(m.Files()["variables.tf"])
+	for _, name := range []string{"runner_image", "mothergoose_url", "mothergoose_api_key", "gitlab_server", "lambda_role_arn"} {
+		if !strings.Contains(variables, `variable "`+name+`"`) {
+			t.Errorf("expected variable %s in variables.tf:\n%s", name, variables)
+		}
+	}
+	if !strings.Contains(variables, "sensitive   = true") {
+		t.Error("expected the API key variable to be sensitive")
+	}
+}
+
+func TestAWSInstanceTypeFor(t *testing.T) {
+	tests := []struct {
+		cpu, memory int
+		want        string
+	}{
+		{cpu: 1, memory: 512, want: "t3.micro"},
+		{cpu: 2, memory: 4096, want: "t3.medium"},
+		{cpu: 4, memory: 8192, want: "t3.xlarge"},
+		{cpu: 16, memory: 1024, want: "m5.4xlarge"},
+	}
+	for _, tt := range tests {
+		if got, err := awsInstanceTypeFor(tt.cpu, tt.memory); err != nil || got != tt.want {
+			t.Errorf("awsInstanceTypeFor(%d, %d) = %q, %v; want %q", tt.cpu, tt.memory, got, err, tt.want)
+		}
+	}
+	if _, err := awsInstanceTypeFor(128, 1024); err == nil {
+		t.Error("expected error for more vCPUs than any instance type")
+	}
+}
+
+func TestVMModulePlatform(t *testing.T) {
+	runner, gitlab := testRunner()
+	tests := []struct {
+		provider  deployer.CloudProvider
+		resources deployer.ResourceConfig
+		contains  []string
+	}{
+		{
+			provider:  deployer.CloudProviderYandex,
+			resources: deployer.ResourceConfig{CPU: 8, Memory: 16384, Disk: 100, GPU: 1, GPUType: "a100", Platform: "gpu-standard-v3"},
+			contains:  []string{`platform_id = "gpu-standard-v3"`, "gpus   = 1"},
+		},
+		{
+			provider:  deployer.CloudProviderAWS,
+			resources: deployer.ResourceConfig{CPU: 6, Memory: 8192, Disk: 40, Platform: "c7g"},
+			contains:  []string{`instance_type = "c7g.2xlarge"`},
+		},
+		{
+			provider:  deployer.CloudProviderAWS,
+			resources: deployer.ResourceConfig{CPU: 2, Memory: 8192, Disk: 100, GPU: 1, GPUType: "t4", Platform: "g4dn"},
+			contains:  []string{`instance_type = "g4dn.xlarge"`},
+		},
+	}
+
+	for _, tt := range tests {
+		m, err := VMModule(&deployer.VMConfig{
+			EggName:   "my-app",
+			Cloud:     deployer.CloudConfig{Provider: tt.provider, Region: "eu-central-1"},
+			Resources: tt.resources,
+			Runner:    runner,
+			GitLab:    gitlab,
+		})
+		if err != nil {
+			t.Fatalf("VMModule failed: %v", err)
+		}
+		main := string(m.Files()["main.tf"])
+		for _, want := range tt.contains {
+			if !strings.Contains(main, want) {
+				t.Errorf("expected %s in main.tf:\n%s", want, main)
+			}
+		}
+	}
+
+	if _, err := awsFamilyInstanceType("p4d", 128); err == nil {
+		t.Error("expected error for more vCPUs than any p4d instance type")
+	}
+}
+
+func TestVMModuleNetwork(t *testing.T) {
+	runner, gitlab := testRunner()
+	network := deployer.NetworkConfig{SubnetID: "subnet-0c2d", SecurityGroups: []string{"sg-01"}, DisablePublicIP: true}
+	tests := []struct {
+		provider deployer.CloudProvider
+		contains []string
+	}{
+		{provider: deployer.CloudProviderYandex, contains: []string{`subnet_id          = "subnet-0c2d"`, "nat                = false", `security_group_ids = ["sg-01"]`}},
+		{provider: deployer.CloudProviderAWS, contains: []string{`subnet_id                   = "subnet-0c2d"`, `vpc_security_group_ids      = ["sg-01"]`, "associate_public_ip_address = false"}},
+	}
+
+	for _, tt := range tests {
+		m, err := VMModule(&deployer.VMConfig{
+			EggName:   "my-app",
+			Cloud:     deployer.CloudConfig{Provider: tt.provider, Region: "eu-central-1"},
+			Resources: deployer.ResourceConfig{CPU: 2, Memory: 4096, Disk: 20},
+			Network:   network,
+			Runner:    runner,
+			GitLab:    gitlab,
+		})
+		if err != nil {
+			t.Fatalf("VMModule failed: %v", err)
+		}
+		main := string(m.Files()["main.tf"])
+		for _, want := range tt.contains {
+			if !strings.Contains(main, want) {
+				t.Errorf("expected %s in main.tf:\n%s", want, main)
+			}
+		}
+		if variables := string(m.Files()["variables.tf"]); strings.Contains(variables, `variable "subnet_id"`) {
+			t.Errorf("expected no subnet_id variable with a subnet set:\n%s", variables)
+		}
+	}
+}
+
+func TestVMModuleImage(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    deployer.CloudProvider
+		image       string
+		imageID     string
+		contains    []string
+		noVariables []string
+	}{
+		{name: "yandex family", provider: deployer.CloudProviderYandex, image: "ubuntu-22-04-docker",
+			contains: []string{`data "yandex_compute_image" "runner"`, `family = "ubuntu-22-04-docker"`, "image_id = data.yandex_compute_image.runner.id"}, noVariables: []string{"image_id"}},
+		{name: "yandex ID", provider: deployer.CloudProviderYandex, imageID: "fd8kdq6d0p8sij7h5qe3",
+			contains: []string{`image_id = "fd8kdq6d0p8sij7h5qe3"`}, noVariables: []string{"image_id"}},
+		{name: "aws family", provider: deployer.CloudProviderAWS, image: "ubuntu-22-04-docker",
+			contains: []string{`data "aws_ami" "runner"`, `values = ["ubuntu-22-04-docker-*"]`, "data.aws_ami.runner.id"}, noVariables: []string{"ami_id"}},
+		{name: "aws AMI", provider: deployer.CloudProviderAWS, imageID: "ami-0123456789abcdef0",
+			contains: []string{`"ami-0123456789abcdef0"`}, noVariables: []string{"ami_id"}},
+		{name: "azure ID", provider: deployer.CloudProviderAzure, imageID: "/subscriptions/0000/resourceGroups/runners/providers/Microsoft.Compute/images/docker",
+			contains: []string{`source_image_id = "/subscriptions/0000/resourceGroups/runners/providers/Microsoft.Compute/images/docker"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, gitlab := testRunner()
+			runner.Image, runner.ImageID = tt.image, tt.imageID
+			m, err := VMModule(&deployer.VMConfig{
+				EggName:   "my-app",
+				Cloud:     deployer.CloudConfig{Provider: tt.provider, Region: "westeurope"},
+				Resources: deployer.ResourceConfig{CPU: 2, Memory: 4096, Disk: 20},
+				VMSize:    "Standard_B2s",
+				Runner:    runner,
+				GitLab:    gitlab,
+			})
+			if err != nil {
+				t.Fatalf("VMModule failed: %v", err)
+			}
+			resourceTypes(t, m)
+			main := string(m.Files()["main.tf"])
+			for _, want := range tt.contains {
+				if !strings.Contains(main, want) {
+					t.Errorf("expected %s in main.tf:\n%s", want, main)
+				}
+			}
+			if tt.provider == deployer.CloudProviderAzure && strings.Contains(main, "source_image_reference") {
+				t.Errorf("expected no source_image_reference with an image_id:\n%s", main)
+			}
+			variables := string(m.Files()["variables.tf"])
+			for _, name := range tt.noVariables {
+				if strings.Contains(variables, `variable "`+name+`"`) {
+					t.Errorf("expected no %s variable:\n%s", name, variables)
+				}
+			}
+		})
+	}
+}
+
+func TestModuleArch(t *testing.T) {
+	runner, gitlab := testRunner()
+	runner.Arch, runner.Image = "arm64", "ubuntu-22-04-docker"
+	m, err := VMModule(&deployer.VMConfig{
+		EggName:   "my-app",
+		Cloud:     deployer.CloudConfig{Provider: deployer.CloudProviderAWS, Region: "eu-west-1"},
+		Resources: deployer.ResourceConfig{CPU: 4, Memory: 8192, Disk: 20},
+		Runner:    runner,
+		GitLab:    gitlab,
+	})
+	if err != nil {
+		t.Fatalf("VMModule failed: %v", err)
+	}
+	main := string(m.Files()["main.tf"])
+	for _, want := range []string{`instance_type = "m7g.xlarge"`, `values = ["arm64"]`} {
+		if !strings.Contains(main, want) {
+			t.Errorf("expected %s in main.tf:\n%s", want, main)
+		}
+	}
+
+	m, err = ServerlessModule(&deployer.ServerlessConfig{
+		EggName:   "my-app",
+		Cloud:     deployer.CloudConfig{Provider: deployer.CloudProviderAWS, Region: "eu-west-1"},
+		Resources: deployer.ResourceConfig{CPU: 1, Memory: 1024, Disk: 10},
+		Runner:    runner,
+		GitLab:    gitlab,
+		Timeout:   10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("ServerlessModule failed: %v", err)
+	}
+	if main := string(m.Files()["main.tf"]); !strings.Contains(main, `architectures = ["arm64"]`) {
+		t.Errorf("expected arm64 architectures in main.tf:\n%s", main)
+	}
+
+	runner.OS, runner.Arch = "windows", ""
+	_, err = VMModule(&deployer.VMConfig{
+		EggName:   "my-app",
+		Cloud:     deployer.CloudConfig{Provider: deployer.CloudProviderAzure, Region: "westeurope"},
+		Resources: deployer.ResourceConfig{CPU: 2, Memory: 4096, Disk: 20},
+		VMSize:    "Standard_B2s",
+		Runner:    runner,
+		GitLab:    gitlab,
+	})
+	if err == nil || !strings.Contains(err.Error(), "exporting windows runners is not supported") {
+		t.Errorf("expected an error exporting a windows runner, got %v", err)
+	}
+}
+
+func TestRunnerArgsCACert(t *testing.T) {
+	runner, gitlab := testRunner()
+	gitlab.CACert = "vault://secret/gitlab/ca"
+	m, err := ServerlessModule(&deployer.ServerlessConfig{
+		EggName: "my-app",
+		Cloud:   deployer.CloudConfig{Provider: deployer.CloudProviderYandex, Region: "ru-central1-a"},
+		Runner:  runner,
+		GitLab:  gitlab,
+	})
+	if err != nil {
+		t.Fatalf("ServerlessModule failed: %v", err)
+	}
+	if main := string(m.Files()["main.tf"]); !strings.Contains(main, `"--gitlab-ca-cert", "vault://secret/gitlab/ca"`) {
+		t.Errorf("expected --gitlab-ca-cert in runner_command:\n%s", main)
+	}
+}
@@ -0,0 +1,310 @@
+package tofu
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/polar-gosling/gosling/internal/deployer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// awsInstanceType is an EC2 instance type runners are placed on
+type awsInstanceType struct {
+	Name   string
+	CPU    int
+	Memory int // MB
+}
+
+// awsInstanceTypes are the general-purpose instance types, smallest first
+var awsInstanceTypes = []awsInstanceType{
+	{Name: "t3.micro", CPU: 2, Memory: 1024},
+	{Name: "t3.small", CPU: 2, Memory: 2048},
+	{Name: "t3.medium", CPU: 2, Memory: 4096},
+	{Name: "t3.large", CPU: 2, Memory: 8192},
+	{Name: "t3.xlarge", CPU: 4, Memory: 16384},
+	{Name: "t3.2xlarge", CPU: 8, Memory: 32768},
+	{Name: "m5.4xlarge", CPU: 16, Memory: 65536},
+	{Name: "m5.8xlarge", CPU: 32, Memory: 131072},
+	{Name: "m5.12xlarge", CPU: 48, Memory: 196608},
+	{Name: "m5.16xlarge", CPU: 64, Memory: 262144},
+	{Name: "m5.24xlarge", CPU: 96, Memory: 393216},
+}
+
+// awsInstanceTypeFor returns the smallest instance type with the requested resources
+func awsInstanceTypeFor(cpu, memory int) (string, error) {
+	for _, t := range awsInstanceTypes {
+		if t.CPU >= cpu && t.Memory >= memory {
+			return t.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no AWS instance type provides %d vCPU and %d MB memory", cpu, memory)
+}
+
+// awsFamilySize is an instance size of an EC2 family, such as 2xlarge in c7g.2xlarge
+type awsFamilySize struct {
+	Name string
+	CPU  int
+}
+
+// awsFamilySizes are the sizes most families come in, smallest first
+var awsFamilySizes = []awsFamilySize{
+	{Name: "large", CPU: 2},
+	{Name: "xlarge", CPU: 4},
+	{Name: "2xlarge", CPU: 8},
+	{Name: "4xlarge", CPU: 16},
+	{Name: "8xlarge", CPU: 32},
+	{Name: "12xlarge", CPU: 48},
+	{Name: "16xlarge", CPU: 64},
+	{Name: "24xlarge", CPU: 96},
+}
+
+// awsFamilySizeOverrides are the sizes of the families that do not come in
+// all of awsFamilySizes, smallest first
+var awsFamilySizeOverrides = map[string][]awsFamilySize{
+	"t3":   {{Name: "large", CPU: 2}, {Name: "xlarge", CPU: 4}, {Name: "2xlarge", CPU: 8}},
+	"g4dn": {{Name: "xlarge", CPU: 4}, {Name: "2xlarge", CPU: 8}, {Name: "4xlarge", CPU: 16}, {Name: "8xlarge", CPU: 32}, {Name: "12xlarge", CPU: 48}, {Name: "16xlarge", CPU: 64}},
+	"g5":   {{Name: "xlarge", CPU: 4}, {Name: "2xlarge", CPU: 8}, {Name: "4xlarge", CPU: 16}, {Name: "8xlarge", CPU: 32}, {Name: "12xlarge", CPU: 48}, {Name: "16xlarge", CPU: 64}, {Name: "24xlarge", CPU: 96}, {Name: "48xlarge", CPU: 192}},
+	"p3":   {{Name: "2xlarge", CPU: 8}, {Name: "8xlarge", CPU: 32}, {Name: "16xlarge", CPU: 64}},
+	"p4d":  {{Name: "24xlarge", CPU: 96}},
+}
+
+// awsARMFamily is the instance family of arm64 runners that set no platform
+const awsARMFamily = "m7g"
+
+// awsFamilyInstanceType returns the smallest instance type of family with the
+// requested vCPUs. Memory grows with the size at a ratio set by the family.
+func awsFamilyInstanceType(family string, cpu int) (string, error) {
+	sizes, ok := awsFamilySizeOverrides[family]
+	if !ok {
+		sizes = awsFamilySizes
+	}
+	for _, size := range sizes {
+		if size.CPU >= cpu {
+			return family + "." + size.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no AWS %s instance type provides %d vCPU", family, cpu)
+}
+
+// setSubnet sets the subnet_id of body to the subnet of network, or to a
+// variable described by description when the network sets none
+func (m *Module) setSubnet(body *hclwrite.Body, network deployer.NetworkConfig, description string) {
+	if network.SubnetID != "" {
+		body.SetAttributeValue("subnet_id", cty.StringVal(network.SubnetID))
+		return
+	}
+	body.SetAttributeTraversal("subnet_id", varRef("subnet_id"))
+	m.variable("subnet_id", description, false, nil)
+}
+
+// setImage sets attr of body to the runner's image: its image_id, the
+// image of its image family found by the runner data source of dataType, or
+// else a variable of the deployment. The data source must already be
+// declared when the runner sets an image family.
+func (m *Module) setImage(body *hclwrite.Body, attr string, runner deployer.RunnerConfig, dataType, variable, description string) {
+	switch {
+	case runner.ImageID != "":
+		body.SetAttributeValue(attr, cty.StringVal(runner.ImageID))
+	case runner.Image != "":
+		body.SetAttributeTraversal(attr, dataRef(dataType, "runner", "id"))
+	default:
+		body.SetAttributeTraversal(attr, varRef(variable))
+		m.variable(variable, description, false, nil)
+	}
+}
+
+// stringList returns values as an HCL list of strings
+func stringList(values []string) cty.Value {
+	list := make([]cty.Value, len(values))
+	for i, v := range values {
+		list[i] = cty.StringVal(v)
+	}
+	return cty.ListVal(list)
+}
+
+// VMModule generates the module for a VM runner
+func VMModule(vm *deployer.VMConfig) (*Module, error) {
+	if vm.Runner.OS != "" && vm.Runner.OS != "linux" {
+		return nil, fmt.Errorf("exporting %s runners is not supported: the boot script of egg %s only runs on linux", vm.Runner.OS, vm.EggName)
+	}
+	m, err := newModule(vm.Cloud)
+	if err != nil {
+		return nil, err
+	}
+	locals := m.addRunnerLocals(vm.EggName, vm.Runner, vm.GitLab)
+	setUserData(locals, vm.Environment)
+
+	body := m.main.Body()
+	switch vm.Cloud.Provider {
+	case deployer.CloudProviderYandex:
+		if vm.Runner.Image != "" && vm.Runner.ImageID == "" {
+			image := body.AppendNewBlock("data", []string{"yandex_compute_image", "runner"}).Body()
+			image.SetAttributeValue("family", cty.StringVal(vm.Runner.Image))
+			body.AppendNewline()
+		}
+		r := body.AppendNewBlock("resource", []string{"yandex_compute_instance", "runner"}).Body()
+		r.SetAttributeValue("name", cty.StringVal("gosling-"+vm.EggName))
+		platform := vm.Resources.Platform
+		if platform == "" {
+			platform = "standard-v3"
+		}
+		r.SetAttributeValue("platform_id", cty.StringVal(platform))
+		r.SetAttributeValue("zone", cty.StringVal(vm.Cloud.Region))
+		r.SetAttributeValue("labels", cty.MapVal(map[string]cty.Value{"egg": cty.StringVal(vm.EggName)}))
+		r.AppendNewline()
+		resources := r.AppendNewBlock("resources", nil).Body()
+		resources.SetAttributeValue("cores", cty.NumberIntVal(int64(vm.Resources.CPU)))
+		resources.SetAttributeValue("memory", cty.NumberFloatVal(float64(vm.Resources.Memory)/1024))
+		if vm.Resources.GPU > 0 {
+			resources.SetAttributeValue("gpus", cty.NumberIntVal(int64(vm.Resources.GPU)))
+		}
+		disk := r.AppendNewBlock("boot_disk", nil).Body().AppendNewBlock("initialize_params", nil).Body()
+		m.setImage(disk, "image_id", vm.Runner, "yandex_compute_image", "image_id", "Boot disk image with Docker available (e.g. a Container Optimized Image)")
+		disk.SetAttributeValue("size", cty.NumberIntVal(int64(vm.Resources.Disk)))
+		network := r.AppendNewBlock("network_interface", nil).Body()
+		m.setSubnet(network, vm.Network, "VPC subnet in the runner's zone")
+		network.SetAttributeValue("nat", cty.BoolVal(!vm.Network.DisablePublicIP))
+		if len(vm.Network.SecurityGroups) > 0 {
+			network.SetAttributeValue("security_group_ids", stringList(vm.Network.SecurityGroups))
+		}
+		r.AppendNewline()
+		r.SetAttributeRaw("metadata", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+			{Name: str("user-data"), Value: refTokens(localRef("user_data"))},
+		}))
+
+		m.output("instance_id", "ID of the runner VM", resourceRef("yandex_compute_instance", "runner", "id"))
+
+	case deployer.CloudProviderAWS:
+		instanceType, err := awsInstanceTypeFor(vm.Resources.CPU, vm.Resources.Memory)
+		switch {
+		case vm.Resources.Platform != "":
+			instanceType, err = awsFamilyInstanceType(vm.Resources.Platform, vm.Resources.CPU)
+		case vm.Runner.Arch == "arm64":
+			instanceType, err = awsFamilyInstanceType(awsARMFamily, vm.Resources.CPU)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if vm.Runner.Image != "" && vm.Runner.ImageID == "" {
+			image := body.AppendNewBlock("data", []string{"aws_ami", "runner"}).Body()
+			image.SetAttributeValue("most_recent", cty.True)
+			image.SetAttributeValue("owners", stringList([]string{"self"}))
+			image.AppendNewline()
+			filter := image.AppendNewBlock("filter", nil).Body()
+			filter.SetAttributeValue("name", cty.StringVal("name"))
+			filter.SetAttributeValue("values", stringList([]string{vm.Runner.Image + "-*"}))
+			if vm.Runner.Arch == "arm64" {
+				image.AppendNewline()
+				arch := image.AppendNewBlock("filter", nil).Body()
+				arch.SetAttributeValue("name", cty.StringVal("architecture"))
+				arch.SetAttributeValue("values", stringList([]string{"arm64"}))
+			}
+			body.AppendNewline()
+		}
+		r := body.AppendNewBlock("resource", []string{"aws_instance", "runner"}).Body()
+		m.setImage(r, "ami", vm.Runner, "aws_ami", "ami_id", "AMI with Docker available")
+		r.SetAttributeValue("instance_type", cty.StringVal(instanceType))
+		m.setSubnet(r, vm.Network, "VPC subnet to launch the runner in")
+		if len(vm.Network.SecurityGroups) > 0 {
+			r.SetAttributeValue("vpc_security_group_ids", stringList(vm.Network.SecurityGroups))
+		}
+		if vm.Network.DisablePublicIP {
+			r.SetAttributeValue("associate_public_ip_address", cty.False)
+		}
+		r.SetAttributeTraversal("user_data", localRef("user_data"))
+		r.SetAttributeValue("tags", cty.MapVal(map[string]cty.Value{
+			"Name": cty.StringVal("gosling-" + vm.EggName),
+			"egg":  cty.StringVal(vm.EggName),
+		}))
+		r.AppendNewline()
+		r.AppendNewBlock("root_block_device", nil).Body().SetAttributeValue("volume_size", cty.NumberIntVal(int64(vm.Resources.Disk)))
+
+		m.output("instance_id", "ID of the runner instance", resourceRef("aws_instance", "runner", "id"))
+
+	case deployer.CloudProviderAzure:
+		if vm.VMSize == "" {
+			return nil, fmt.Errorf("azure VM size is not set for egg %s", vm.EggName)
+		}
+		r := body.AppendNewBlock("resource", []string{"azurerm_linux_virtual_machine", "runner"}).Body()
+		r.SetAttributeValue("name", cty.StringVal("gosling-"+vm.EggName))
+		r.SetAttributeTraversal("resource_group_name", varRef("resource_group_name"))
+		r.SetAttributeValue("location", cty.StringVal(vm.Cloud.Region))
+		r.SetAttributeValue("size", cty.StringVal(vm.VMSize))
+		r.SetAttributeValue("admin_username", cty.StringVal("gosling"))
+		r.SetAttributeRaw("network_interface_ids", hclwrite.TokensForTuple([]hclwrite.Tokens{refTokens(varRef("network_interface_id"))}))
+		r.SetAttributeRaw("custom_data", hclwrite.TokensForFunctionCall("base64encode", refTokens(localRef("user_data"))))
+		r.SetAttributeValue("tags", cty.MapVal(map[string]cty.Value{"egg": cty.StringVal(vm.EggName)}))
+		r.AppendNewline()
+		sshKey := r.AppendNewBlock("admin_ssh_key", nil).Body()
+		sshKey.SetAttributeValue("username", cty.StringVal("gosling"))
+		sshKey.SetAttributeTraversal("public_key", varRef("ssh_public_key"))
+		osDisk := r.AppendNewBlock("os_disk", nil).Body()
+		osDisk.SetAttributeValue("caching", cty.StringVal("ReadWrite"))
+		osDisk.SetAttributeValue("storage_account_type", cty.StringVal("Standard_LRS"))
+		osDisk.SetAttributeValue("disk_size_gb", cty.NumberIntVal(int64(vm.Resources.Disk)))
+		if vm.Runner.ImageID != "" {
+			r.SetAttributeValue("source_image_id", cty.StringVal(vm.Runner.ImageID))
+		} else {
+			image := r.AppendNewBlock("source_image_reference", nil).Body()
+			image.SetAttributeValue("publisher", cty.StringVal("Canonical"))
+			image.SetAttributeValue("offer", cty.StringVal("0001-com-ubuntu-server-jammy"))
+			image.SetAttributeValue("sku", cty.StringVal("22_04-lts"))
+			image.SetAttributeValue("version", cty.StringVal("latest"))
+		}
+
+		m.variable("resource_group_name", "Resource group to create the runner in", false, nil)
+		m.variable("network_interface_id", "Network interface to attach to the runner VM", false, nil)
+		m.variable("ssh_public_key", "SSH public key for the gosling admin user", false, nil)
+		m.output("vm_id", "ID of the runner VM", resourceRef("azurerm_linux_virtual_machine", "runner", "id"))
+	}
+
+	return m, nil
+}
+
+// setUserData defines local.user_data, a boot script that installs Docker and
+// starts the runner container
+func setUserData(locals *hclwrite.Body, environment map[string]string) {
+	dockerRun := []hclwrite.Tokens{str("docker"), str("run"), str("-d"), str("--restart"), str("always"), str("--name"), str("gosling-runner"), str("--entrypoint"), str("''")}
+	keys := make([]string, 0, len(environment))
+	for key := range environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		dockerRun = append(dockerRun, str("-e"), str(shellQuote(key+"="+environment[key])))
+	}
+	dockerRun = append(dockerRun, refTokens(varRef("runner_image")))
+
+	command := hclwrite.TokensForFunctionCall("join",
+		str(" "),
+		hclwrite.TokensForFunctionCall("concat",
+			hclwrite.TokensForTuple(dockerRun),
+			hclwrite.TokensForFunctionCall("formatlist", str("'%s'"), refTokens(localRef("runner_command"))),
+		),
+	)
+	script := hclwrite.TokensForFunctionCall("join",
+		str("\n"),
+		hclwrite.TokensForTuple([]hclwrite.Tokens{
+			str("#!/bin/sh"),
+			str("set -e"),
+			str("command -v docker >/dev/null || curl -fsSL https://get.docker.com | sh"),
+			command,
+		}),
+	)
+
+	locals.SetAttributeRaw("user_data", script)
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	quoted := "'"
+	for _, r := range s {
+		if r == '\'' {
+			quoted += `'\''`
+		} else {
+			quoted += string(r)
+		}
+	}
+	return quoted + "'"
+}
@@ -0,0 +1,211 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GitLab event names, as sent in EventHeader
+const (
+	EventPush     = "Push Hook"
+	EventTagPush  = "Tag Push Hook"
+	EventPipeline = "Pipeline Hook"
+)
+
+// ErrUnsupportedEvent is returned, wrapped, by ParseEvent for events other
+// than push, tag push and pipeline events
+var ErrUnsupportedEvent = errors.New("unsupported webhook event")
+
+// ParseEvent decodes the body of a webhook for event (the EventHeader value)
+// into a *PushEvent, for push and tag push events, or a *PipelineEvent
+func ParseEvent(event string, body []byte) (any, error) {
+	var payload any
+	switch event {
+	case EventPush, EventTagPush:
+		payload = &PushEvent{}
+	case EventPipeline:
+		payload = &PipelineEvent{}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEvent, event)
+	}
+	if err := json.Unmarshal(body, payload); err != nil {
+		return nil, fmt.Errorf("failed to decode %s payload: %w", event, err)
+	}
+	return payload, nil
+}
+
+// Time is a timestamp of a GitLab webhook. Push events use RFC 3339 while
+// pipeline events use "2006-01-02 15:04:05 UTC"; both are accepted, and null
+// leaves the zero time.
+type Time struct {
+	time.Time
+}
+
+// gitlabTimeLayouts are the timestamp layouts found in GitLab webhooks
+var gitlabTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, layout := range gitlabTimeLayouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid timestamp %q", s)
+}
+
+// Project is the project a webhook event happened in
+type Project struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+	DefaultBranch     string `json:"default_branch"`
+}
+
+// User is the GitLab user who triggered an event
+type User struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+// Author is the author of a commit
+type Author struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Commit is a commit of a push event
+type Commit struct {
+	ID        string   `json:"id"`
+	Message   string   `json:"message"`
+	Title     string   `json:"title"`
+	Timestamp Time     `json:"timestamp"`
+	URL       string   `json:"url"`
+	Author    Author   `json:"author"`
+	Added     []string `json:"added"`
+	Modified  []string `json:"modified"`
+	Removed   []string `json:"removed"`
+}
+
+// PushEvent is the payload of a push or tag push webhook
+type PushEvent struct {
+	ObjectKind   string   `json:"object_kind"` // "push" or "tag_push"
+	Before       string   `json:"before"`
+	After        string   `json:"after"`
+	Ref          string   `json:"ref"`
+	CheckoutSHA  string   `json:"checkout_sha"`
+	UserID       int      `json:"user_id"`
+	UserName     string   `json:"user_name"`
+	UserUsername string   `json:"user_username"`
+	ProjectID    int      `json:"project_id"`
+	Project      Project  `json:"project"`
+	Commits      []Commit `json:"commits"`
+	// TotalCommitsCount counts every pushed commit; Commits holds at most 20
+	TotalCommitsCount int `json:"total_commits_count"`
+}
+
+// Branch returns the pushed branch, or "" for a tag push
+func (e *PushEvent) Branch() string {
+	branch, ok := strings.CutPrefix(e.Ref, "refs/heads/")
+	if !ok {
+		return ""
+	}
+	return branch
+}
+
+// Tag returns the pushed tag, or "" for a branch push
+func (e *PushEvent) Tag() string {
+	tag, ok := strings.CutPrefix(e.Ref, "refs/tags/")
+	if !ok {
+		return ""
+	}
+	return tag
+}
+
+// Deleted reports whether the push deleted the branch or tag
+func (e *PushEvent) Deleted() bool {
+	return strings.Trim(e.After, "0") == ""
+}
+
+// ChangedFiles returns the files added, modified or removed by the commits
+// of the push, sorted and without duplicates. Pushes of more than 20 commits
+// only list the files of the last 20.
+func (e *PushEvent) ChangedFiles() []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, commit := range e.Commits {
+		for _, list := range [][]string{commit.Added, commit.Modified, commit.Removed} {
+			for _, file := range list {
+				if !seen[file] {
+					seen[file] = true
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Pipeline is the object_attributes of a pipeline event
+type Pipeline struct {
+	ID         int      `json:"id"`
+	IID        int      `json:"iid"`
+	Ref        string   `json:"ref"`
+	Tag        bool     `json:"tag"`
+	SHA        string   `json:"sha"`
+	BeforeSHA  string   `json:"before_sha"`
+	Source     string   `json:"source"`
+	Status     string   `json:"status"`
+	Stages     []string `json:"stages"`
+	CreatedAt  Time     `json:"created_at"`
+	FinishedAt Time     `json:"finished_at"` // Zero while the pipeline runs
+	Duration   float64  `json:"duration"`    // Seconds
+	URL        string   `json:"url"`
+}
+
+// BuildRunner is the runner a job of a pipeline event ran on
+type BuildRunner struct {
+	ID          int      `json:"id"`
+	Description string   `json:"description"`
+	RunnerType  string   `json:"runner_type"`
+	Active      bool     `json:"active"`
+	IsShared    bool     `json:"is_shared"`
+	Tags        []string `json:"tags"`
+}
+
+// Build is a job of a pipeline event
+type Build struct {
+	ID            int          `json:"id"`
+	Stage         string       `json:"stage"`
+	Name          string       `json:"name"`
+	Status        string       `json:"status"`
+	CreatedAt     Time         `json:"created_at"`
+	StartedAt     Time         `json:"started_at"`
+	FinishedAt    Time         `json:"finished_at"`
+	Duration      float64      `json:"duration"` // Seconds
+	FailureReason string       `json:"failure_reason"`
+	Runner        *BuildRunner `json:"runner"` // Nil before the job is picked up
+}
+
+// PipelineEvent is the payload of a pipeline webhook
+type PipelineEvent struct {
+	ObjectKind       string   `json:"object_kind"` // "pipeline"
+	ObjectAttributes Pipeline `json:"object_attributes"`
+	User             User     `json:"user"`
+	Project          Project  `json:"project"`
+	Builds           []Build  `json:"builds"`
+}
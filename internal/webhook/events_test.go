@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const pushPayload = `{
+  "object_kind": "push",
+  "before": "95790bf891e76fee5e1747ab589903a6a1f80f22",
+  "after": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+  "ref": "refs/heads/main",
+  "checkout_sha": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+  "user_id": 4,
+  "user_name": "John Smith",
+  "user_username": "jsmith",
+  "project_id": 15,
+  "project": {
+    "id": 15,
+    "name": "nest",
+    "path_with_namespace": "platform/nest",
+    "web_url": "https://gitlab.example.com/platform/nest",
+    "default_branch": "main"
+  },
+  "commits": [
+    {
+      "id": "b6568db1bc1dcd7f8b4d5a946b0b91f9dacd7327",
+      "message": "Grow my-app runners\n",
+      "title": "Grow my-app runners",
+      "timestamp": "2024-01-02T10:00:00+02:00",
+      "author": {"name": "John Smith", "email": "jsmith@example.com"},
+      "added": ["Jobs/rotate-secrets.fly"],
+      "modified": ["Eggs/my-app/config.fly"],
+      "removed": []
+    },
+    {
+      "id": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+      "message": "Tune UglyFox\n",
+      "title": "Tune UglyFox",
+      "timestamp": "2024-01-02T11:00:00+02:00",
+      "author": {"name": "John Smith", "email": "jsmith@example.com"},
+      "added": [],
+      "modified": ["UF/config.fly", "Eggs/my-app/config.fly"],
+      "removed": []
+    }
+  ],
+  "total_commits_count": 2
+}`
+
+const pipelinePayload = `{
+  "object_kind": "pipeline",
+  "object_attributes": {
+    "id": 31,
+    "iid": 3,
+    "ref": "main",
+    "tag": false,
+    "sha": "bcbb5ec396a2c0f828686f14fac9b80b780504f2",
+    "source": "push",
+    "status": "success",
+    "stages": ["build", "test"],
+    "created_at": "2024-01-02 10:00:00 UTC",
+    "finished_at": "2024-01-02 10:05:30 UTC",
+    "duration": 330
+  },
+  "user": {"id": 1, "name": "Administrator", "username": "root"},
+  "project": {"id": 15, "path_with_namespace": "platform/my-app"},
+  "builds": [
+    {
+      "id": 380,
+      "stage": "test",
+      "name": "unit",
+      "status": "success",
+      "created_at": "2024-01-02 10:00:01 UTC",
+      "started_at": "2024-01-02 10:01:00 UTC",
+      "finished_at": "2024-01-02 10:05:00 UTC",
+      "duration": 240.5,
+      "runner": {"id": 380987, "description": "my-app-runner-1", "runner_type": "project_type", "active": true, "is_shared": false, "tags": ["docker"]}
+    },
+    {
+      "id": 381,
+      "stage": "build",
+      "name": "package",
+      "status": "created",
+      "created_at": "2024-01-02 10:00:01 UTC",
+      "started_at": null,
+      "finished_at": null,
+      "runner": null
+    }
+  ]
+}`
+
+func TestParsePushEvent(t *testing.T) {
+	payload, err := ParseEvent(EventPush, []byte(pushPayload))
+	if err != nil {
+		t.Fatalf("ParseEvent failed: %v", err)
+	}
+	event, ok := payload.(*PushEvent)
+	if !ok {
+		t.Fatalf("expected a *PushEvent, got %T", payload)
+	}
+	if event.Branch() != "main" || event.Tag() != "" || event.Deleted() {
+		t.Errorf("unexpected ref %q", event.Ref)
+	}
+	if event.Project.PathWithNamespace != "platform/nest" || event.UserUsername != "jsmith" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if want := time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC); !event.Commits[0].Timestamp.Equal(want) {
+		t.Errorf("expected the commit timestamp %s, got %s", want, event.Commits[0].Timestamp)
+	}
+	if got := strings.Join(event.ChangedFiles(), ","); got != "Eggs/my-app/config.fly,Jobs/rotate-secrets.fly,UF/config.fly" {
+		t.Errorf("unexpected changed files %s", got)
+	}
+
+	deleted := &PushEvent{Ref: "refs/tags/v1.0.0", After: "0000000000000000000000000000000000000000"}
+	if deleted.Tag() != "v1.0.0" || deleted.Branch() != "" || !deleted.Deleted() {
+		t.Errorf("expected a deleted tag, got %+v", deleted)
+	}
+}
+
+func TestParsePipelineEvent(t *testing.T) {
+	payload, err := ParseEvent(EventPipeline, []byte(pipelinePayload))
+	if err != nil {
+		t.Fatalf("ParseEvent failed: %v", err)
+	}
+	event, ok := payload.(*PipelineEvent)
+	if !ok {
+		t.Fatalf("expected a *PipelineEvent, got %T", payload)
+	}
+	pipeline := event.ObjectAttributes
+	if pipeline.ID != 31 || pipeline.Status != "success" || pipeline.Duration != 330 {
+		t.Errorf("unexpected pipeline %+v", pipeline)
+	}
+	if got := pipeline.FinishedAt.Sub(pipeline.CreatedAt.Time); got != 330*time.Second {
+		t.Errorf("expected the pipeline to take 330s, got %s", got)
+	}
+	if len(event.Builds) != 2 || event.Builds[0].Runner == nil || event.Builds[0].Runner.Description != "my-app-runner-1" {
+		t.Fatalf("unexpected builds %+v", event.Builds)
+	}
+	if pending := event.Builds[1]; pending.Runner != nil || !pending.StartedAt.IsZero() {
+		t.Errorf("expected a job that has not started, got %+v", pending)
+	}
+}
+
+func TestParseEventErrors(t *testing.T) {
+	if _, err := ParseEvent("Issue Hook", []byte("{}")); !errors.Is(err, ErrUnsupportedEvent) {
+		t.Errorf("expected ErrUnsupportedEvent, got %v", err)
+	}
+	if _, err := ParseEvent(EventPipeline, []byte(`{"object_attributes": {"created_at": "yesterday"}}`)); err == nil {
+		t.Error("expected an invalid timestamp to fail")
+	}
+}
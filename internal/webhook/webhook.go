@@ -0,0 +1,145 @@
+// Package webhook verifies and decodes the GitLab webhooks that notify
+// MotherGoose, for local testing tools and commands that receive them.
+//
+// GitLab authenticates a webhook by sending its secret token verbatim in
+// X-Gitlab-Token. Relays that forward the events can also sign the payload:
+// SignatureHeader then carries "sha256=" followed by the hex HMAC-SHA256 of
+// the body, as computed by Sign.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// TokenHeader carries the secret token of a GitLab webhook
+	TokenHeader = "X-Gitlab-Token"
+	// EventHeader names the event of a GitLab webhook, e.g. "Push Hook"
+	EventHeader = "X-Gitlab-Event"
+	// SignatureHeader carries the HMAC-SHA256 signature of the payload
+	SignatureHeader = "X-Gitlab-Signature"
+
+	// signaturePrefix precedes the hex signature in SignatureHeader
+	signaturePrefix = "sha256="
+)
+
+// MaxPayloadSize is the largest webhook body Verify reads
+const MaxPayloadSize = 25 << 20
+
+var (
+	// ErrMissingToken is returned when a request has no X-Gitlab-Token
+	ErrMissingToken = errors.New("webhook token is missing")
+
+	// ErrInvalidToken is returned when X-Gitlab-Token is not the expected secret
+	ErrInvalidToken = errors.New("webhook token is invalid")
+
+	// ErrMissingSignature is returned when a request has no payload signature
+	ErrMissingSignature = errors.New("webhook signature is missing")
+
+	// ErrInvalidSignature is returned when the signature does not match the payload
+	ErrInvalidSignature = errors.New("webhook signature is invalid")
+
+	// ErrPayloadTooLarge is returned for a body larger than MaxPayloadSize
+	ErrPayloadTooLarge = errors.New("webhook payload is too large")
+)
+
+// Verifier checks that webhook requests come from GitLab. A zero Verifier
+// accepts every request.
+type Verifier struct {
+	// Token is the secret token of the webhook; empty skips the check
+	Token string
+	// Secret is the key of the payload HMAC; nil skips the check
+	Secret []byte
+}
+
+// Verify checks the token and signature of r and returns its body. The body
+// of r is consumed.
+func (v *Verifier) Verify(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxPayloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook payload: %w", err)
+	}
+	if len(body) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+	if err := v.VerifyPayload(r.Header, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// VerifyPayload checks the token and signature in header against body
+func (v *Verifier) VerifyPayload(header http.Header, body []byte) error {
+	if v.Token != "" {
+		token := header.Get(TokenHeader)
+		if token == "" {
+			return ErrMissingToken
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(v.Token)) != 1 {
+			return ErrInvalidToken
+		}
+	}
+	if v.Secret != nil {
+		signature := header.Get(SignatureHeader)
+		if signature == "" {
+			return ErrMissingSignature
+		}
+		if !ValidSignature(v.Secret, body, signature) {
+			return ErrInvalidSignature
+		}
+	}
+	return nil
+}
+
+// Sign returns the SignatureHeader value of body signed with secret
+func Sign(secret, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(payloadMAC(secret, body))
+}
+
+// ValidSignature reports whether signature is the SignatureHeader value of
+// body signed with secret
+func ValidSignature(secret, body []byte, signature string) bool {
+	hexSum, ok := strings.CutPrefix(signature, signaturePrefix)
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(got, payloadMAC(secret, body))
+}
+
+// payloadMAC returns the HMAC-SHA256 of body
+func payloadMAC(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// NewRequest builds a webhook request for event with body, carrying token
+// and, with a secret, the payload signature; for testing receivers locally
+func NewRequest(url, event string, body []byte, token string, secret []byte) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	if token != "" {
+		req.Header.Set(TokenHeader, token)
+	}
+	if secret != nil {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+	return req, nil
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"object_kind": "push"}`)
+	secret := []byte("hmac-secret")
+	verifier := &Verifier{Token: "token", Secret: secret}
+
+	tests := []struct {
+		name    string
+		token   string
+		secret  []byte
+		body    string
+		wantErr error
+	}{
+		{name: "valid", token: "token", secret: secret},
+		{name: "missing token", secret: secret, wantErr: ErrMissingToken},
+		{name: "wrong token", token: "other", secret: secret, wantErr: ErrInvalidToken},
+		{name: "missing signature", token: "token", wantErr: ErrMissingSignature},
+		{name: "wrong secret", token: "token", secret: []byte("other"), wantErr: ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := NewRequest("http://localhost/webhooks/gitlab", EventPush, body, tt.token, tt.secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := verifier.Verify(req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && string(got) != string(body) {
+				t.Errorf("expected the body back, got %q", got)
+			}
+		})
+	}
+
+	// A signature of another payload does not verify
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"object_kind": "tag_push"}`))
+	req.Header.Set(TokenHeader, "token")
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+	if _, err := verifier.Verify(req); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected a tampered payload to fail, got %v", err)
+	}
+
+	// The zero Verifier accepts any request
+	req = httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+	if _, err := (&Verifier{}).Verify(req); err != nil {
+		t.Errorf("expected the zero Verifier to accept, got %v", err)
+	}
+}
+
+func TestValidSignature(t *testing.T) {
+	secret, body := []byte("key"), []byte("The quick brown fox jumps over the lazy dog")
+	// HMAC-SHA256 test vector
+	signature := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if got := Sign(secret, body); got != signature {
+		t.Errorf("Sign = %s, want %s", got, signature)
+	}
+	for _, invalid := range []string{"", strings.TrimPrefix(signature, "sha256="), "sha256=zz", "sha1=" + signature[7:]} {
+		if ValidSignature(secret, body, invalid) {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+	if !ValidSignature(secret, body, signature) {
+		t.Error("expected the signature to be valid")
+	}
+}
+
+func TestVerifyPayloadTooLarge(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("x", MaxPayloadSize+1)))
+	if _, err := (&Verifier{}).Verify(req); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("expected ErrPayloadTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package gosling
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/polar-gosling/gosling/internal/mothergoose"
+)
+
+// MotherGoose API client types
+type (
+	Client       = mothergoose.Client
+	ClientOption = mothergoose.ClientOption
+	RetryPolicy  = mothergoose.RetryPolicy
+	OIDCConfig   = mothergoose.OIDCConfig
+
+	CircuitBreakerPolicy = mothergoose.CircuitBreakerPolicy
+	CircuitState         = mothergoose.CircuitState
+	CircuitStatus        = mothergoose.CircuitStatus
+	HTTPError            = mothergoose.HTTPError
+	APIError             = mothergoose.APIError
+	EggStatus            = mothergoose.EggStatus
+	Runner               = mothergoose.Runner
+	RunnerFilter         = mothergoose.RunnerFilter
+	LogEntry             = mothergoose.LogEntry
+	LogStreamOptions     = mothergoose.LogStreamOptions
+	TokenRotation        = mothergoose.TokenRotation
+
+	HeartbeatPayload     = mothergoose.HeartbeatPayload
+	RunnerMetricsPayload = mothergoose.RunnerMetricsPayload
+
+	ListEggsOptions            = mothergoose.ListEggsOptions
+	ListDeploymentPlansOptions = mothergoose.ListDeploymentPlansOptions
+	EggsPage                   = mothergoose.EggsPage
+	DeploymentPlansPage        = mothergoose.DeploymentPlansPage
+	EggsPager                  = mothergoose.EggsPager
+	DeploymentPlansPager       = mothergoose.DeploymentPlansPager
+)
+
+// MotherGooseClient is the interface implemented by Client; depend on it to
+// substitute a fake in tests
+type MotherGooseClient = mothergoose.MotherGooseClient
+
+// NewClient creates a MotherGoose API client
+func NewClient(baseURL, apiKey string, opts ...ClientOption) *Client {
+	return mothergoose.NewClient(baseURL, apiKey, opts...)
+}
+
+// WithTimeout sets the HTTP request timeout
+func WithTimeout(timeout time.Duration) ClientOption {
+	return mothergoose.WithTimeout(timeout)
+}
+
+// WithMaxRetries sets how many times failed requests are retried
+func WithMaxRetries(maxRetries int) ClientOption {
+	return mothergoose.WithMaxRetries(maxRetries)
+}
+
+// WithRetryPolicy sets the backoff between retries
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return mothergoose.WithRetryPolicy(policy)
+}
+
+// DefaultRetryPolicy returns the policy used unless WithRetryPolicy is given
+func DefaultRetryPolicy() RetryPolicy {
+	return mothergoose.DefaultRetryPolicy
+}
+
+// WithHTTPClient sets the underlying HTTP client
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return mothergoose.WithHTTPClient(httpClient)
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections to MotherGoose are
+// kept for reuse
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return mothergoose.WithMaxIdleConnsPerHost(n)
+}
+
+// WithRateLimit limits the client to requestsPerSecond requests with bursts
+// of up to burst requests
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return mothergoose.WithRateLimit(requestsPerSecond, burst)
+}
+
+// WithResponseCache caches GET responses, revalidating them with their ETag
+// and honoring Cache-Control
+func WithResponseCache() ClientOption {
+	return mothergoose.WithResponseCache()
+}
+
+// WithOIDC authenticates with access tokens from the OIDC client credentials
+// flow instead of the API key
+func WithOIDC(config OIDCConfig) ClientOption {
+	return mothergoose.WithOIDC(config)
+}
+
+// WithCircuitBreaker sets when the client stops sending requests to a failing host
+func WithCircuitBreaker(policy CircuitBreakerPolicy) ClientOption {
+	return mothergoose.WithCircuitBreaker(policy)
+}
+
+// Circuit breaker states
+const (
+	CircuitClosed   = mothergoose.CircuitClosed
+	CircuitOpen     = mothergoose.CircuitOpen
+	CircuitHalfOpen = mothergoose.CircuitHalfOpen
+)
+
+// ErrBackendUnavailable is returned, wrapped, for requests refused by an open
+// circuit breaker
+var ErrBackendUnavailable = mothergoose.ErrBackendUnavailable
+
+// IsNotFound reports whether err is MotherGoose answering that the requested
+// Egg, plan or runner does not exist
+func IsNotFound(err error) bool {
+	return mothergoose.IsNotFound(err)
+}
+
+// IsConflict reports whether err is MotherGoose refusing a request that
+// conflicts with the current state
+func IsConflict(err error) bool {
+	return mothergoose.IsConflict(err)
+}
+
+// IsValidation reports whether err is MotherGoose rejecting an invalid request
+func IsValidation(err error) bool {
+	return mothergoose.IsValidation(err)
+}
+
+// NewEggsPager iterates over all eggs page by page
+func NewEggsPager(client *Client, opts ListEggsOptions) *EggsPager {
+	return mothergoose.NewEggsPager(client, opts)
+}
+
+// NewDeploymentPlansPager iterates over an egg's deployment plans page by page
+func NewDeploymentPlansPager(client *Client, eggName string, opts ListDeploymentPlansOptions) *DeploymentPlansPager {
+	return mothergoose.NewDeploymentPlansPager(client, eggName, opts)
+}
+
+// WaitForPlan polls a deployment plan until it is applied, has failed or was
+// rolled back, or ctx is done
+func WaitForPlan(ctx context.Context, client MotherGooseClient, eggName, planID string, interval time.Duration) (*DeploymentPlan, error) {
+	return mothergoose.WaitForPlan(ctx, client, eggName, planID, interval)
+}
@@ -0,0 +1,108 @@
+package gosling
+
+import "github.com/polar-gosling/gosling/internal/deployer"
+
+// Parsed block types returned by ParseEgg, ParseEggsBucket and ParseUglyFox
+type (
+	ParsedEggConfig        = deployer.ParsedEggConfig
+	ParsedEggsBucketConfig = deployer.ParsedEggsBucketConfig
+	CloudInfo              = deployer.CloudInfo
+	ResourceInfo           = deployer.ResourceInfo
+	RunnerInfo             = deployer.RunnerInfo
+	GitLabInfo             = deployer.GitLabInfo
+	RepositoryInfo         = deployer.RepositoryInfo
+	KubernetesInfo         = deployer.KubernetesInfo
+	Positions              = deployer.Positions
+	ParsedUglyFoxConfig    = deployer.ParsedUglyFoxConfig
+	PruningInfo            = deployer.PruningInfo
+	PoolInfo               = deployer.PoolInfo
+	RunnersConditionInfo   = deployer.RunnersConditionInfo
+	PolicyRuleInfo         = deployer.PolicyRuleInfo
+)
+
+// Deployment configuration types exchanged with MotherGoose
+type (
+	EggConfig        = deployer.EggConfig
+	EggsBucketConfig = deployer.EggsBucketConfig
+	RepositoryConfig = deployer.RepositoryConfig
+	JobConfig        = deployer.JobConfig
+	JobRunnerConfig  = deployer.JobRunnerConfig
+	UglyFoxConfig    = deployer.UglyFoxConfig
+	PruningConfig    = deployer.PruningConfig
+	PoolConfig       = deployer.PoolConfig
+	CloudConfig      = deployer.CloudConfig
+	ResourceConfig   = deployer.ResourceConfig
+	RunnerConfig     = deployer.RunnerConfig
+	GitLabConfig     = deployer.GitLabConfig
+	VMConfig         = deployer.VMConfig
+	ServerlessConfig = deployer.ServerlessConfig
+	KubernetesConfig = deployer.KubernetesConfig
+	K8sConfig        = deployer.K8sConfig
+	DeploymentPlan   = deployer.DeploymentPlan
+
+	RunnersConditionConfig = deployer.RunnersConditionConfig
+	PolicyRuleConfig       = deployer.PolicyRuleConfig
+
+	CloudProvider = deployer.CloudProvider
+	RunnerType    = deployer.RunnerType
+)
+
+// Cloud providers
+const (
+	CloudProviderYandex = deployer.CloudProviderYandex
+	CloudProviderAWS    = deployer.CloudProviderAWS
+	CloudProviderAzure  = deployer.CloudProviderAzure
+)
+
+// Deployment plan statuses
+const (
+	PlanStatusPending    = deployer.PlanStatusPending
+	PlanStatusApplying   = deployer.PlanStatusApplying
+	PlanStatusApplied    = deployer.PlanStatusApplied
+	PlanStatusFailed     = deployer.PlanStatusFailed
+	PlanStatusRolledBack = deployer.PlanStatusRolledBack
+)
+
+// Runner types
+const (
+	RunnerTypeVM         = deployer.RunnerTypeVM
+	RunnerTypeServerless = deployer.RunnerTypeServerless
+	RunnerTypeKubernetes = deployer.RunnerTypeKubernetes
+)
+
+// Converter turns parsed eggs and eggsbuckets into deployment configurations
+type Converter = deployer.Converter
+
+// NewConverter creates a Converter
+func NewConverter() *Converter {
+	return deployer.NewConverter()
+}
+
+// ConfigHashVersion identifies the canonical form hashed by ConfigHash
+const ConfigHashVersion = deployer.ConfigHashVersion
+
+// ConfigHash returns the stable, versioned hash of an Egg configuration that
+// gosling deploy sends to MotherGoose
+func ConfigHash(egg *EggConfig) string {
+	return deployer.ConfigHash(egg)
+}
+
+// ParseEgg extracts an egg block into a ParsedEggConfig
+func ParseEgg(block *Block) (*ParsedEggConfig, error) {
+	return deployer.ParseEgg(block)
+}
+
+// ParseEggsBucket extracts an eggsbucket block into a ParsedEggsBucketConfig
+func ParseEggsBucket(block *Block) (*ParsedEggsBucketConfig, error) {
+	return deployer.ParseEggsBucket(block)
+}
+
+// ParseJob extracts a job block into a JobConfig
+func ParseJob(block *Block) (*JobConfig, error) {
+	return deployer.ParseJob(block)
+}
+
+// ParseUglyFox extracts an uglyfox block into a ParsedUglyFoxConfig
+func ParseUglyFox(block *Block) (*ParsedUglyFoxConfig, error) {
+	return deployer.ParseUglyFox(block)
+}
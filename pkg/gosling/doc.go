@@ -0,0 +1,15 @@
+// Package gosling is the public Go API of the Gosling CLI.
+//
+// It exposes the .fly parser and AST, the converter from parsed blocks to
+// deployment configurations, and the MotherGoose API client, so that other
+// tools (test harnesses, editor plugins) can reuse them without depending on
+// the CLI's internal packages.
+//
+// The API follows semantic versioning: within a major version, exported
+// identifiers are not removed and their signatures do not change. New
+// identifiers may be added in minor versions. Everything under internal/ may
+// change at any time and is not covered by this guarantee.
+package gosling
+
+// APIVersion is the semantic version of this package's API
+const APIVersion = "1.0.0"
@@ -0,0 +1,82 @@
+package gosling_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/polar-gosling/gosling/pkg/gosling"
+)
+
+const eggFly = `
+egg "my-app" {
+  type = "serverless"
+
+  cloud {
+    provider = "yandex"
+    region   = "ru-central1-a"
+  }
+
+  resources {
+    cpu    = 1
+    memory = 2048
+    disk   = 10
+  }
+
+  runner {
+    tags         = ["docker"]
+    concurrent   = 1
+    idle_timeout = "10m"
+  }
+
+  gitlab {
+    project_id   = 42
+    token_secret = "yc-lockbox://gitlab/token"
+    server_name  = "gitlab.example.com"
+  }
+}
+`
+
+func TestParseValidateConvert(t *testing.T) {
+	config, err := gosling.Parse([]byte(eggFly), "config.fly")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if result := gosling.Validate(config); !result.IsValid() {
+		t.Fatalf("Validate failed: %v", result.Error())
+	}
+
+	egg, err := gosling.ParseEgg(&config.Blocks[0])
+	if err != nil {
+		t.Fatalf("ParseEgg failed: %v", err)
+	}
+	serverless, err := gosling.NewConverter().EggToServerlessConfig(egg)
+	if err != nil {
+		t.Fatalf("EggToServerlessConfig failed: %v", err)
+	}
+	if serverless.Resources.Memory != 2048 {
+		t.Errorf("expected memory 2048, got %d", serverless.Resources.Memory)
+	}
+}
+
+func TestClientGetEggStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eggs/my-app/status" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(gosling.EggStatus{EggName: "my-app", ConfigHash: "abc123"})
+	}))
+	defer server.Close()
+
+	var client gosling.MotherGooseClient = gosling.NewClient(server.URL, "key", gosling.WithMaxRetries(0))
+	status, err := client.GetEggStatus(context.Background(), "my-app")
+	if err != nil {
+		t.Fatalf("GetEggStatus failed: %v", err)
+	}
+	if status.ConfigHash != "abc123" {
+		t.Errorf("expected config hash abc123, got %q", status.ConfigHash)
+	}
+}
@@ -0,0 +1,83 @@
+package gosling
+
+import "github.com/polar-gosling/gosling/internal/parser"
+
+// AST types produced by the .fly parser
+type (
+	Config   = parser.Config
+	Block    = parser.Block
+	Value    = parser.Value
+	Position = parser.Position
+	Node     = parser.Node
+
+	ValueType = parser.ValueType
+	Number    = parser.Number
+)
+
+// Value types
+const (
+	StringType = parser.StringType
+	NumberType = parser.NumberType
+	BoolType   = parser.BoolType
+	ListType   = parser.ListType
+	MapType    = parser.MapType
+)
+
+// Validation types
+type (
+	ValidationResult = parser.ValidationResult
+	ValidationError  = parser.ValidationError
+	Severity         = parser.Severity
+)
+
+// Validation severities
+const (
+	SeverityError   = parser.SeverityError
+	SeverityWarning = parser.SeverityWarning
+)
+
+// Diff types
+type (
+	Change     = parser.Change
+	ChangeKind = parser.ChangeKind
+)
+
+// Change kinds
+const (
+	ChangeAdded    = parser.ChangeAdded
+	ChangeRemoved  = parser.ChangeRemoved
+	ChangeModified = parser.ChangeModified
+)
+
+// ParseFile parses a .fly file, resolving includes
+func ParseFile(filename string) (*Config, error) {
+	return parser.NewParser().ParseFile(filename)
+}
+
+// Parse parses .fly content; filename is used in positions and to resolve includes
+func Parse(content []byte, filename string) (*Config, error) {
+	return parser.NewParser().Parse(content, filename)
+}
+
+// ParseFileForEnv parses a .fly file merged with its environment overlay
+// (e.g. config.prod.fly). A missing overlay yields the base configuration.
+func ParseFileForEnv(filename, env string) (*Config, error) {
+	return parser.NewParser().ParseFileForEnv(filename, env)
+}
+
+// Validate checks a parsed configuration against the block schemas
+func Validate(config *Config) *ValidationResult {
+	return parser.NewValidator(config).Validate()
+}
+
+// ParseAndValidate parses a .fly file and returns an error if it is invalid.
+// Use Validate on the result of ParseFile for warnings.
+func ParseAndValidate(filename string) (*Config, error) {
+	config, _, err := parser.ParseAndValidate(filename)
+	return config, err
+}
+
+// Diff returns the semantic differences between two configurations
+func Diff(old, new *Config) []Change {
+	return parser.Diff(old, new)
+}